@@ -69,6 +69,7 @@ var greenHandler = &greenHandler_
 func buildPrivateWins(s gwu.Session) {
 	// Create and build a window
 	win := gwu.NewWindow("main", "Main Window")
+	win.SetRequiredRoles(gwu.ROLE_AUTHENTICATED)
 	win.Style().SetFullWidth()
 	win.SetCellPadding(2)
 
@@ -222,6 +223,7 @@ func buildPrivateWins(s gwu.Session) {
 	s.AddWin(win)
 
 	win2 := gwu.NewWindow("main2", "Main2 Window")
+	win2.SetRequiredRoles("admin")
 	win2.Add(gwu.NewLabel("This is just a test 2nd window."))
 	back := gwu.NewButton("Back")
 	back.AddEHandlerFunc(func(e gwu.Event) {
@@ -231,6 +233,13 @@ func buildPrivateWins(s gwu.Session) {
 	s.AddWin(win2)
 }
 
+// Brute-force protection of the login window
+var loginThrottle = gwu.NewDefaultLoginThrottle()
+
+func checkCredentials(user, password string) bool {
+	return user == "admin" && gwu.ConstTimeEquals(password, "a")
+}
+
 func buildLoginWin(s gwu.Session) {
 	win := gwu.NewWindow("login", "Login Window")
 	win.Style().SetFullSize()
@@ -269,13 +278,15 @@ func buildLoginWin(s gwu.Session) {
 	p.Add(table)
 	b := gwu.NewButton("OK")
 	b.AddEHandlerFunc(func(e gwu.Event) {
-		if tb.Text() == "admin" && pb.Text() == "a" {
+		err := loginThrottle.Login(tb.Text(), pb.Text(), e.Request().RemoteAddr(), checkCredentials)
+		if err == nil {
+			e.Session().SetPrincipal(gwu.NewPrincipal(tb.Text(), "admin"))
 			e.Session().RemoveWin(win) // Login win is removed, password will not be retrievable from the browser
 			buildPrivateWins(e.Session())
 			e.ReloadWin("main")
 		} else {
 			e.SetFocusedComp(tb)
-			errL.SetText("Invalid user name or password!")
+			errL.SetText(err.Error())
 			e.MarkDirty(errL)
 		}
 	}, gwu.ETYPE_CLICK)
@@ -327,6 +338,7 @@ func main() {
 	server.SetText("Test GUI Application")
 
 	server.AddSessCreatorName("login", "Login Window")
+	server.SetLoginWin("login") // Unauthenticated requests for the main windows go to the login window
 	server.AddSHandler(SessHandler{})
 
 	win := gwu.NewWindow("home", "Home Window")
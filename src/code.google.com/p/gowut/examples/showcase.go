@@ -666,8 +666,10 @@ func buildShowcaseWin(sess gwu.Session) {
 			fmt.Println("LOADING window:", e.Src().Id())
 		case gwu.ETYPE_WIN_UNLOAD:
 			fmt.Println("UNLOADING window:", e.Src().Id())
+		case gwu.ETYPE_VISIBILITY_CHANGE:
+			fmt.Println("Window hidden:", win.Hidden())
 		}
-	}, gwu.ETYPE_WIN_LOAD, gwu.ETYPE_WIN_UNLOAD)
+	}, gwu.ETYPE_WIN_LOAD, gwu.ETYPE_WIN_UNLOAD, gwu.ETYPE_VISIBILITY_CHANGE)
 
 	hiddenPan := gwu.NewNaturalPanel()
 	sess.SetAttr("hiddenPan", hiddenPan)
@@ -0,0 +1,175 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Permission-aware component visibility: hiding and disabling components
+// based on the roles of the session.
+
+package gwu
+
+// Access type: the access of a session to a component.
+type Access int
+
+// Accesses.
+const (
+	ACCESS_FULL     Access = iota // Component is visible and enabled
+	ACCESS_DISABLED               // Component is visible but disabled
+	ACCESS_HIDDEN                 // Component is hidden
+)
+
+// Policy is a function type which determines the access of a session
+// to a component, evaluated when the component is rendered and when
+// an event is received from it.
+// The session identity is available via Session.User() and Session.Roles().
+type Policy func(sess Session) Access
+
+// accessImpl is the access control implementation of components.
+type accessImpl struct {
+	visibleRoles []string // Roles the component is visible for, nil means all
+	enabledRoles []string // Roles the component is enabled for, nil means all
+	policy       Policy   // Optional access policy
+
+	showWhen   Condition // Optional condition the visibility is bound to
+	enableWhen Condition // Optional condition the enabled state is bound to
+
+	readOnlyDeep bool // Tells if the descendant inputs are rendered read-only (of containers)
+}
+
+func (c *compImpl) SetVisibleForRoles(roles ...string) {
+	c.visibleRoles = roles
+}
+
+func (c *compImpl) SetEnabledForRoles(roles ...string) {
+	c.enabledRoles = roles
+}
+
+func (c *compImpl) Policy() Policy {
+	return c.policy
+}
+
+func (c *compImpl) SetPolicy(policy Policy) {
+	c.policy = policy
+}
+
+func (c *compImpl) Access(sess Session) Access {
+	access := c.sessAccess(sess)
+	if access != ACCESS_HIDDEN {
+		if a := c.bindingAccess(); a > access {
+			access = a
+		}
+	}
+	return access
+}
+
+// sessAccess returns the access of the specified session to the component
+// based on its roles and policy only.
+func (c *compImpl) sessAccess(sess Session) Access {
+	if sess == nil {
+		return ACCESS_FULL
+	}
+
+	access := ACCESS_FULL
+	if c.visibleRoles != nil && !hasAnyRole(sess, c.visibleRoles) {
+		return ACCESS_HIDDEN
+	}
+	if c.enabledRoles != nil && !hasAnyRole(sess, c.enabledRoles) {
+		access = ACCESS_DISABLED
+	}
+	if c.policy != nil {
+		if a := c.policy(sess); a > access {
+			access = a
+		}
+	}
+	return access
+}
+
+// hasAnyRole tells if the session has any of the specified roles.
+func hasAnyRole(sess Session, roles []string) bool {
+	for _, role := range roles {
+		if sess.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveAccess returns the access of the session to the specified component
+// taking its ancestors into account: a component is only as accessible
+// as its least accessible ancestor.
+func effectiveAccess(c Comp, sess Session) Access {
+	access := ACCESS_FULL
+	for ; c != nil; c = c.Parent() {
+		if a := c.Access(sess); a > access {
+			access = a
+			if access == ACCESS_HIDDEN {
+				break
+			}
+		}
+	}
+	return access
+}
+
+func (c *compImpl) ReadOnlyDeep() bool {
+	return c.readOnlyDeep
+}
+
+func (c *compImpl) SetReadOnlyDeep(readOnly bool) {
+	c.readOnlyDeep = readOnly
+}
+
+func (c *compImpl) inReadOnly() bool {
+	return c.input && c.inReadOnlyContainer()
+}
+
+// inReadOnlyContainer tells if an ancestor container is in read-only mode.
+func (c *compImpl) inReadOnlyContainer() bool {
+	for p := c.parent; p != nil; p = p.Parent() {
+		if p.ReadOnlyDeep() {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_STR_ACCESS_HIDDEN   = []byte(" hidden")                        // " hidden"
+	_STR_ACCESS_DISABLED = []byte(` disabled aria-disabled="true"`) // ` disabled aria-disabled="true"`
+	_STR_READ_ONLY       = []byte(` disabled aria-readonly="true"`) // ` disabled aria-readonly="true"`
+	_STR_INVISIBLE       = []byte(" data-gwu-invisible")            // " data-gwu-invisible"
+)
+
+// renderAccess renders the attributes hiding or disabling the component
+// according to its visibility and the access of the session the output
+// is rendered for, or making it read-only if it is an input in a read-only container.
+func (c *compImpl) renderAccess(w writer) {
+	c.renderBindings(w)
+
+	access := c.Access(w.sess)
+	switch {
+	case access == ACCESS_HIDDEN || c.visibility == HIDDEN_COLLAPSE:
+		w.Write(_STR_ACCESS_HIDDEN)
+		return
+	case c.visibility == HIDDEN_PRESERVE:
+		w.Write(_STR_INVISIBLE)
+	}
+
+	switch access {
+	case ACCESS_DISABLED:
+		w.Write(_STR_ACCESS_DISABLED)
+	default:
+		if c.inReadOnly() {
+			w.Write(_STR_READ_ONLY)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"testing"
+)
+
+func TestReadOnlyInputs(t *testing.T) {
+	p := NewPanel()
+	p.SetReadOnlyDeep(true)
+
+	inputs := map[string]Comp{
+		"TextBox":      NewTextBox(""),
+		"CheckBox":     NewCheckBox(""),
+		"ComboBox":     NewComboBox(nil),
+		"FileUpload":   NewFileUpload(),
+		"SignaturePad": NewSignaturePad(),
+		"Terminal":     NewTerminal(),
+		"Board":        NewBoard(),
+		"Tree":         NewTree(),
+	}
+	for name, c := range inputs {
+		p.Add(c)
+		if !c.inReadOnly() {
+			t.Errorf("%s is not read-only in a read-only container", name)
+		}
+	}
+
+	b := NewButton("")
+	p.Add(b)
+	if b.inReadOnly() {
+		t.Errorf("Button is read-only in a read-only container")
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Window access control: principals, required roles of windows
+// and redirecting unauthenticated requests to the login window.
+
+package gwu
+
+import (
+	"net/http"
+)
+
+// Role which is held by all authenticated sessions: windows requiring it
+// (see Window.SetRequiredRoles()) can be accessed by any logged-in user.
+const ROLE_AUTHENTICATED = "*"
+
+// Principal interface defines the identity of a logged-in user
+// (see Session.SetPrincipal()).
+// Applications may implement it with their own user type.
+type Principal interface {
+	// Name returns the name of the user.
+	Name() string
+
+	// Roles returns the roles of the user.
+	Roles() []string
+}
+
+// Principal implementation.
+type principalImpl struct {
+	name  string   // Name of the user
+	roles []string // Roles of the user
+}
+
+// NewPrincipal creates a new Principal with the specified name and roles.
+func NewPrincipal(name string, roles ...string) Principal {
+	return &principalImpl{name: name, roles: roles}
+}
+
+func (p *principalImpl) Name() string {
+	return p.name
+}
+
+func (p *principalImpl) Roles() []string {
+	return p.roles
+}
+
+// AccessController interface controls the access of sessions to windows
+// (see Server.SetAccessController()).
+type AccessController interface {
+	// Authenticated tells if the specified session is authenticated
+	// (a user is logged in).
+	Authenticated(sess Session) bool
+
+	// CanAccess tells if the specified session may access the specified window.
+	CanAccess(sess Session, win Window) bool
+}
+
+// Role based AccessController implementation.
+type roleAccessController struct{}
+
+// NewRoleAccessController creates a new AccessController which grants access
+// to windows based on their required roles (see Window.SetRequiredRoles()):
+// windows not requiring roles can be accessed by anyone, other windows
+// by authenticated sessions having any of the required roles.
+// A session is authenticated if it has a principal or a user
+// (see Session.Principal() and Session.User()).
+func NewRoleAccessController() AccessController {
+	return roleAccessController{}
+}
+
+func (roleAccessController) Authenticated(sess Session) bool {
+	return sess.Principal() != nil || len(sess.User()) > 0
+}
+
+func (ac roleAccessController) CanAccess(sess Session, win Window) bool {
+	roles := win.RequiredRoles()
+	if len(roles) == 0 {
+		return true
+	}
+	if !ac.Authenticated(sess) {
+		return false
+	}
+	for _, role := range roles {
+		if role == ROLE_AUTHENTICATED || sess.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sessionImpl) Principal() Principal {
+	s.principalMutex.RLock()
+	defer s.principalMutex.RUnlock()
+	return s.principal
+}
+
+func (s *sessionImpl) SetPrincipal(p Principal) {
+	s.principalMutex.Lock()
+	defer s.principalMutex.Unlock()
+	s.principal = p
+	if p == nil {
+		s.user, s.roles = "", nil
+	} else {
+		s.user, s.roles = p.Name(), p.Roles()
+	}
+}
+
+func (w *windowImpl) RequiredRoles() []string {
+	return w.requiredRoles
+}
+
+func (w *windowImpl) SetRequiredRoles(roles ...string) {
+	w.requiredRoles = roles
+}
+
+func (s *serverImpl) AccessController() AccessController {
+	return s.accessController
+}
+
+func (s *serverImpl) SetAccessController(ac AccessController) {
+	s.accessController = ac
+}
+
+func (s *serverImpl) LoginWin() string {
+	return s.loginWin
+}
+
+func (s *serverImpl) SetLoginWin(name string) {
+	s.loginWin = name
+}
+
+// canAccess tells if the specified session may access the specified window.
+func (s *serverImpl) canAccess(sess Session, win Window) bool {
+	return s.accessController == nil || s.accessController.CanAccess(sess, win)
+}
+
+// toLogin tells if a request of the specified session for the specified window
+// has to be redirected to the login window: if the session is not authenticated
+// and a login window is set (other than the requested one).
+func (s *serverImpl) toLogin(sess Session, winName string) bool {
+	return len(s.loginWin) > 0 && winName != s.loginWin && s.accessController != nil &&
+		!s.accessController.Authenticated(sess)
+}
+
+// accessDenied responds to a request not allowed to access the specified window:
+// redirects it to the login window if the session is not authenticated (see toLogin()),
+// else responds with 403 Forbidden.
+// path is the window-relative path of the request.
+func (s *serverImpl) accessDenied(sess Session, winName, path string, w http.ResponseWriter, r *http.Request) {
+	if s.logger != nil {
+		s.logger.Println("\tAccess denied to window:", winName)
+	}
+
+	if !s.toLogin(sess, winName) {
+		http.Error(w, "403 Forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch path {
+	case "":
+		http.Redirect(w, r, s.appPath+s.loginWin, http.StatusFound)
+	case s.paths.Event:
+		// Let the client navigate to the login window
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8") // We send it as text
+		NewWriter(w).Writevs(_ERA_RELOAD_WIN, _STR_COMMA, s.loginWin)
+	default:
+		http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+	}
+}
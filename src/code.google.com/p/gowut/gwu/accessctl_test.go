@@ -0,0 +1,104 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// newAccessTestServer creates a server with a public "main" window, an "admin"
+// window requiring the "admin" role and a "login" window set as the login window.
+func newAccessTestServer() *serverImpl {
+	s := newServerImpl("app", "", "", "")
+	s.AddWin(NewWindow("main", "Main"))
+	admin := NewWindow("admin", "Admin")
+	admin.SetRequiredRoles("admin")
+	s.AddWin(admin)
+	s.AddWin(NewWindow("login", "Login"))
+	s.SetLoginWin("login")
+	return s
+}
+
+// serve serves a request of the specified path with the specified session (may be nil).
+func serve(s *serverImpl, method, path string, sess Session) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	if sess != nil {
+		r.AddCookie(&http.Cookie{Name: _GWU_SESSID_COOKIE, Value: sess.Id()})
+	}
+	w := httptest.NewRecorder()
+	s.serveHTTP(w, r)
+	return w
+}
+
+func TestAccessPublicWindow(t *testing.T) {
+	s := newAccessTestServer()
+	if w := serve(s, "GET", "/app/main", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestAccessRedirectToLogin(t *testing.T) {
+	s := newAccessTestServer()
+
+	w := serve(s, "GET", "/app/admin", nil)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/app/login" {
+		t.Errorf("Expected redirect to login, got: %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	// Events let the client navigate to the login window
+	w = serve(s, "POST", "/app/admin/"+s.paths.Event, nil)
+	if exp := strconv.Itoa(_ERA_RELOAD_WIN) + ",login"; w.Code != http.StatusOK || w.Body.String() != exp {
+		t.Errorf("Expected: %q, got: %d %q", exp, w.Code, w.Body.String())
+	}
+
+	// Other internal endpoints are denied
+	if w = serve(s, "POST", "/app/admin/"+s.paths.RenderComp, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected: %d, got: %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestAccessForbidden(t *testing.T) {
+	s := newAccessTestServer()
+	sess := s.newSession(nil)
+	sess.SetPrincipal(NewPrincipal("bob", "user"))
+
+	// Authenticated but missing the required role: no redirect
+	if w := serve(s, "GET", "/app/admin", sess); w.Code != http.StatusForbidden {
+		t.Errorf("Expected: %d, got: %d", http.StatusForbidden, w.Code)
+	}
+	if w := serve(s, "POST", "/app/admin/"+s.paths.Event, sess); w.Code != http.StatusForbidden {
+		t.Errorf("Expected: %d, got: %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestAccessGranted(t *testing.T) {
+	s := newAccessTestServer()
+	sess := s.newSession(nil)
+	sess.SetPrincipal(NewPrincipal("alice", "admin"))
+
+	if w := serve(s, "GET", "/app/admin", sess); w.Code != http.StatusOK {
+		t.Errorf("Expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+
+	// Logging out revokes access
+	sess.SetPrincipal(nil)
+	if w := serve(s, "GET", "/app/admin", sess); w.Code != http.StatusFound {
+		t.Errorf("Expected: %d, got: %d", http.StatusFound, w.Code)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Event audit trail: recording who triggered which event handlers
+// on which component, with the component values before and after.
+
+package gwu
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name of the session attribute whose (string) value is recorded
+// as the user in audit records.
+// Set this attribute in the session when the user logs in.
+const AUDIT_USER_ATTR = "gwu-audit-user"
+
+// AuditRecord describes an event dispatched to the handlers of a component.
+type AuditRecord struct {
+	Time       time.Time // Time of the event
+	SessionId  string    // Id of the session (empty string for the public session)
+	User       string    // User of the session, taken from the AUDIT_USER_ATTR session attribute if set, else Session.User()
+	RemoteAddr string    // Remote address of the client
+	WinName    string    // Name of the window
+	CompId     ID        // Id of the source component
+	EventType  EventType // Type of the event
+	Handlers   []string  // Names of the event handlers the event was dispatched to
+	Before     string    // Value of the component before the event
+	After      string    // Value of the component after the event handlers were called
+}
+
+// String returns a one-line, human readable representation of the record.
+func (r *AuditRecord) String() string {
+	return fmt.Sprintf("AUDIT sess=%q user=%q addr=%s win=%q comp=%v etype=%v handlers=[%s] before=%q after=%q",
+		r.SessionId, r.User, r.RemoteAddr, r.WinName, r.CompId, r.EventType, strings.Join(r.Handlers, ","), r.Before, r.After)
+}
+
+// AuditStore interface defines a storage backend for audit records.
+type AuditStore interface {
+	// Store stores an audit record.
+	// It is called synchronously after event dispatching while the session
+	// is locked, so implementations should not block for a long time.
+	Store(rec *AuditRecord)
+}
+
+// AuditStoreFunc is a function type which implements AuditStore.
+type AuditStoreFunc func(rec *AuditRecord)
+
+// Store calls f(rec).
+func (f AuditStoreFunc) Store(rec *AuditRecord) {
+	f(rec)
+}
+
+// logAuditStore is an AuditStore which writes records to a logger.
+type logAuditStore struct {
+	logger *log.Logger // Logger to write audit records to
+}
+
+// NewLogAuditStore creates a new AuditStore which writes
+// audit records to the specified logger.
+func NewLogAuditStore(logger *log.Logger) AuditStore {
+	return logAuditStore{logger}
+}
+
+func (s logAuditStore) Store(rec *AuditRecord) {
+	s.logger.Println(rec)
+}
+
+// auditValue returns the value of a component as a string
+// for audit purposes.
+// Empty string is returned for components not having a value.
+func auditValue(c Comp) string {
+	switch c2 := c.(type) {
+	case TextBox:
+		if tb, isImpl := c2.(*textBoxImpl); isImpl && tb.isPassw {
+			return "***"
+		}
+		return c2.Text()
+	case StateButton:
+		return strconv.FormatBool(c2.State())
+	case SwitchButton:
+		return strconv.FormatBool(c2.State())
+	case ListBox:
+		return strings.Join(c2.SelectedValues(), ",")
+	}
+	return ""
+}
+
+// handlerName returns the name of an event handler for audit purposes.
+// In case of handler functions this is the name of the function.
+func handlerName(h EventHandler) string {
+	if hfw, isFunc := h.(handlerFuncWrapper); isFunc {
+		if f := runtime.FuncForPC(reflect.ValueOf(hfw.hf).Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// newAuditRecord creates a new audit record for the specified event.
+// The After value has to be filled later.
+func newAuditRecord(e *eventImpl, win Window, remoteAddr string) *AuditRecord {
+	sess := e.shared.session
+	rec := &AuditRecord{Time: time.Now(), SessionId: sess.Id(), RemoteAddr: remoteAddr, WinName: win.Name(),
+		CompId: e.src.Id(), EventType: e.etype, Before: auditValue(e.src)}
+	if user, isString := sess.Attr(AUDIT_USER_ATTR).(string); isString {
+		rec.User = user
+	} else {
+		rec.User = sess.User()
+	}
+	for _, h := range e.src.eHandlers(e.etype) {
+		if h != EMPTY_EHANDLER {
+			rec.Handlers = append(rec.Handlers, handlerName(h))
+		}
+	}
+	return rec
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Authentication adapters: populating the session identity
+// from HTTP Basic Auth or from trusted reverse proxy headers.
+
+package gwu
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Authenticator interface authenticates incoming HTTP requests.
+// 
+// If an authenticator is set to the server (see Server.SetAuthenticator()),
+// the user of each request is determined by the authenticator.
+// If there is no private session for the user yet (or the current
+// session belongs to another user), a new private session is created
+// for the user, and its user is set (see Session.User()).
+type Authenticator interface {
+	// Authenticate authenticates the specified request received by
+	// the specified server.
+	// Returns the name of the user and true if the request is authenticated,
+	// false otherwise.
+	Authenticate(s Server, r *http.Request) (user string, ok bool)
+
+	// Challenge responds to a request which is not authenticated
+	// but authentication is required (see Server.SetAuthRequired()).
+	Challenge(w http.ResponseWriter, r *http.Request)
+}
+
+// CredentialsFunc is a function type which checks user credentials.
+// Returns true if the password is valid for the user.
+type CredentialsFunc func(user, password string) bool
+
+// HTTP Basic Auth Authenticator implementation.
+type basicAuthenticator struct {
+	realm string          // Realm sent in challenges
+	check CredentialsFunc // Credential checker
+}
+
+// NewBasicAuthenticator creates a new Authenticator which authenticates
+// requests by HTTP Basic Authentication, and checks the credentials
+// with the specified function.
+// realm is the protection space sent to the clients in challenges.
+// 
+// Login attempts authenticating a new session are throttled by the login
+// throttle of the server (see Server.SetLoginThrottle()). Requests of an
+// already authenticated session are not throttled, so a lockout caused
+// by an attacker does not log out the user.
+// 
+// Note that Basic Authentication sends credentials in clear text,
+// so it should only be used over HTTPS.
+func NewBasicAuthenticator(realm string, check CredentialsFunc) Authenticator {
+	return &basicAuthenticator{realm: realm, check: check}
+}
+
+func (a *basicAuthenticator) Authenticate(s Server, r *http.Request) (user string, ok bool) {
+	user, password, ok := r.BasicAuth()
+	if !ok || len(user) == 0 {
+		return "", false
+	}
+	if t := s.LoginThrottle(); t != nil && s.sessUser(r) != user {
+		if t.Login(user, password, s.ClientAddr(r), a.check) != nil {
+			return "", false
+		}
+		return user, true
+	}
+	if !a.check(user, password) {
+		return "", false
+	}
+	return user, true
+}
+
+func (a *basicAuthenticator) Challenge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Basic realm="+strconv.Quote(a.realm))
+	http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+}
+
+// Reverse proxy header Authenticator implementation.
+type headerAuthenticator struct {
+	header string // Name of the header holding the user name
+}
+
+// NewHeaderAuthenticator creates a new Authenticator which takes the
+// user from the specified HTTP header (e.g. "X-Remote-User") set by an
+// authenticating reverse proxy (e.g. a corporate SSO proxy).
+// 
+// The header is only accepted from trusted proxies (see Server.SetTrustedProxies()),
+// requests from other addresses are not authenticated, so clients
+// cannot spoof the user by setting the header themselves.
+// The proxy must always set (or remove) the header.
+func NewHeaderAuthenticator(header string) Authenticator {
+	return &headerAuthenticator{header: header}
+}
+
+func (a *headerAuthenticator) Authenticate(s Server, r *http.Request) (user string, ok bool) {
+	if !s.trusted(hostOnly(r.RemoteAddr)) {
+		return "", false
+	}
+	user = strings.TrimSpace(r.Header.Get(a.header))
+	return user, len(user) > 0
+}
+
+func (a *headerAuthenticator) Challenge(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+}
+
+// ConstTimeEquals compares 2 strings in constant time
+// (not depending on the content), which is required when comparing
+// secrets like passwords to avoid timing attacks.
+// Can be used in a CredentialsFunc.
+func ConstTimeEquals(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func (s *serverImpl) Authenticator() Authenticator {
+	return s.authenticator
+}
+
+func (s *serverImpl) SetAuthenticator(a Authenticator) {
+	s.authenticator = a
+}
+
+func (s *serverImpl) AuthRequired() bool {
+	return s.authRequired
+}
+
+func (s *serverImpl) SetAuthRequired(required bool) {
+	s.authRequired = required
+}
+
+func (s *serverImpl) sessUser(r *http.Request) string {
+	c, err := r.Cookie(_GWU_SESSID_COOKIE)
+	if err != nil {
+		return ""
+	}
+	if sess := s.sessionById(c.Value); sess != nil {
+		return sess.User()
+	}
+	return ""
+}
+
+// authenticate authenticates the request with the authenticator
+// of the server, and returns the session to serve the request with.
+// sess is the session of the request, nil if there is none.
+// If a new session is created for the authenticated user,
+// the session cookie is also added to the response.
+// If the request is not authenticated but authentication is required,
+// a challenge is sent and false is returned.
+func (s *serverImpl) authenticate(sess Session, w http.ResponseWriter, r *http.Request) (Session, bool) {
+	user, ok := s.authenticator.Authenticate(s, r)
+	if !ok {
+		if s.authRequired {
+			s.authenticator.Challenge(w, r)
+			return nil, false
+		}
+		return sess, true
+	}
+
+	if sess != nil && sess.User() == user {
+		return sess, true
+	}
+
+	// No session yet, or the session belongs to another user:
+	if sess != nil {
+		s.removeSess2(sess, SESS_REMOVE_INVALIDATED)
+	}
+	sess = s.newSession(nil)
+	sess.SetPrincipal(NewPrincipal(user))
+	s.addSessCookie(sess, w, r)
+	if s.logger != nil {
+		s.logger.Println("SESSION authenticated:", sess.Id(), "user:", user)
+	}
+
+	return sess, true
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Value bindings: keeping the values of components synchronized.
+
+package gwu
+
+import (
+	"strconv"
+)
+
+// ValueTransform is a function type which transforms the value
+// of a bound source component to the value of the target component.
+type ValueTransform func(value string) string
+
+// Bind binds the value of the target component to the value of the source component:
+// whenever the source component synchronizes a new value, the (optionally transformed)
+// value is set to the target component, and the target is marked dirty.
+// The value of the target is also set when Bind() is called.
+// Pass a nil transform to use the value as-is.
+//
+// TextBox sources are synchronized as the user types (on ETYPE_INPUT), so e.g.
+// a Label can mirror a TextBox live. Other sources are bound on their
+// value synchronizing event types.
+//
+// Supported source components are TextBox, PasswBox, ListBox (its first selected value),
+// Slider, NumberBox and state buttons ("true" or "false").
+// Supported target components are the same plus components having text (HasText, e.g. Label);
+// numbers not parsable as such are ignored.
+func Bind(source, target Comp, transform ValueTransform) {
+	bind(source, target, transform, true)
+}
+
+// BindTwoWay binds the values of 2 components to each other (see Bind()),
+// e.g. a Slider and a NumberBox to keep them synchronized.
+// aToB transforms the value of a to the value of b, bToA transforms the
+// value of b to the value of a; nil transforms use the values as-is.
+// The value of b is set from a when BindTwoWay() is called.
+func BindTwoWay(a, b Comp, aToB, bToA ValueTransform) {
+	bind(a, b, aToB, true)
+	bind(b, a, bToA, false)
+}
+
+// bind binds the value of the target component to the value of the source component,
+// optionally setting the value of the target right away.
+func bind(source, target Comp, transform ValueTransform, init bool) {
+	sync := func() {
+		value := bindingValue(source)
+		if transform != nil {
+			value = transform(value)
+		}
+		setBindingValue(target, value)
+	}
+	if init {
+		sync()
+	}
+
+	if _, isTextBox := source.(TextBox); isTextBox {
+		source.AddSyncOnETypes(ETYPE_INPUT)
+	}
+	source.AddEHandlerFunc(func(e Event) {
+		sync()
+		e.MarkDirty(target)
+	}, source.SyncOnETypes()...)
+}
+
+// setBindingValue sets the value of a component from the value of a bound component.
+func setBindingValue(c Comp, value string) {
+	switch c2 := c.(type) {
+	case Slider:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			c2.SetValue(v)
+		}
+	case NumberBox:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			c2.SetValue(v)
+		}
+	case StateButton:
+		if v, err := strconv.ParseBool(value); err == nil {
+			c2.SetState(v)
+		}
+	case ListBox:
+		for i, v := range c2.Values() {
+			if v == value {
+				c2.SetSelectedIndices([]int{i})
+				return
+			}
+		}
+		c2.ClearSelected()
+	case HasText:
+		c2.SetText(value)
+	}
+}
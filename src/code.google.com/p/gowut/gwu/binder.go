@@ -0,0 +1,358 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Binder: data binding between struct fields and components.
+
+package gwu
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindError describes a field whose value could not be loaded or stored.
+type BindError struct {
+	Field string // Name of the field
+	Comp  Comp   // Component bound to the field
+	Err   error  // The error
+}
+
+func (e *BindError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+// BindErrors is a list of bind errors, returned by Binder.Load() and Binder.Store().
+type BindErrors []*BindError
+
+func (e BindErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, e2 := range e {
+		msgs[i] = e2.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Binder interface defines a data binding between the fields of a
+// struct and components, so forms don't need manual getters and setters.
+//
+// Supported components and field types:
+//
+//	TextBox, PasswBox:        string, bool, int and uint types, float types
+//	CheckBox, SwitchButton:   bool
+//	ListBox:                  string (selected value), int (selected index),
+//	                          []string (selected values), []int (selected indices)
+//	DateBox:                  time.Time
+//
+// Fields are specified by name, fields of embedded or nested structs
+// by a dot separated path (e.g. "Address.City").
+// Only exported fields can be bound.
+type Binder interface {
+	// Bind binds the binder to obj, which must be a pointer to a struct.
+	Bind(obj interface{}) error
+
+	// Obj returns the bound object.
+	Obj() interface{}
+
+	// Add binds a field to a component.
+	// Returns the binder so calls can be chained.
+	Add(field string, c Comp) Binder
+
+	// Load loads the values of the fields into the components.
+	// If e is not nil, the components are marked dirty.
+	// Returns a BindErrors if some fields could not be loaded
+	// (e.g. invalid field name or unsupported field type).
+	Load(e Event) error
+
+	// Store validates the values of the components (see HasValidators),
+	// and stores them into the fields.
+	// Values which fail validation or conversion are not stored, and
+	// the "gwu-Invalid" style class is added to their components.
+	// If e is not nil, components whose validity changed are marked dirty.
+	// Returns a BindErrors if some values could not be stored,
+	// which can be used to report the errors to the user.
+	Store(e Event) error
+}
+
+// binding binds a field to a component.
+type binding struct {
+	field string // Name (path) of the field
+	comp  Comp   // Component bound to the field
+}
+
+// Binder implementation.
+type binderImpl struct {
+	obj      interface{} // The bound object
+	bindings []binding   // The bindings
+}
+
+// NewBinder creates a new Binder.
+func NewBinder() Binder {
+	return &binderImpl{}
+}
+
+func (b *binderImpl) Bind(obj interface{}) error {
+	if v := reflect.ValueOf(obj); v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("Only pointers to structs can be bound!")
+	}
+	b.obj = obj
+	return nil
+}
+
+func (b *binderImpl) Obj() interface{} {
+	return b.obj
+}
+
+func (b *binderImpl) Add(field string, c Comp) Binder {
+	b.bindings = append(b.bindings, binding{field: field, comp: c})
+	return b
+}
+
+// fieldValue returns the (settable) value of the field specified by its path.
+func (b *binderImpl) fieldValue(field string) (reflect.Value, error) {
+	if b.obj == nil {
+		return reflect.Value{}, errors.New("No object is bound!")
+	}
+	v := reflect.ValueOf(b.obj).Elem()
+	for _, name := range strings.Split(field, ".") {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, errors.New("Nil pointer in path!")
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, errors.New("Not a struct: " + name)
+		}
+		if v = v.FieldByName(name); !v.IsValid() {
+			return reflect.Value{}, errors.New("No such field!")
+		}
+		if !v.CanSet() {
+			return reflect.Value{}, errors.New("Unexported field!")
+		}
+	}
+	return v, nil
+}
+
+func (b *binderImpl) Load(e Event) error {
+	var errs BindErrors
+	for _, bd := range b.bindings {
+		if err := b.load(bd); err != nil {
+			errs = append(errs, &BindError{Field: bd.field, Comp: bd.comp, Err: err})
+			continue
+		}
+		if e != nil {
+			e.MarkDirty(bd.comp)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Error returned for unsupported field types.
+var errUnsupportedType = errors.New("Unsupported field type!")
+
+// load loads the value of a field into its component.
+func (b *binderImpl) load(bd binding) error {
+	v, err := b.fieldValue(bd.field)
+	if err != nil {
+		return err
+	}
+
+	switch c := bd.comp.(type) {
+	case DateBox:
+		t, ok := v.Interface().(time.Time)
+		if !ok {
+			return errUnsupportedType
+		}
+		c.SetDate(t)
+	case TextBox:
+		switch v.Kind() {
+		case reflect.String:
+			c.SetText(v.String())
+		case reflect.Bool:
+			c.SetText(strconv.FormatBool(v.Bool()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			c.SetText(strconv.FormatInt(v.Int(), 10))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			c.SetText(strconv.FormatUint(v.Uint(), 10))
+		case reflect.Float32, reflect.Float64:
+			c.SetText(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+		default:
+			return errUnsupportedType
+		}
+	case StateButton:
+		if v.Kind() != reflect.Bool {
+			return errUnsupportedType
+		}
+		c.SetState(v.Bool())
+	case SwitchButton:
+		if v.Kind() != reflect.Bool {
+			return errUnsupportedType
+		}
+		c.SetState(v.Bool())
+	case ListBox:
+		switch {
+		case v.Kind() == reflect.String:
+			c.ClearSelected()
+			for i, value := range c.Values() {
+				if value == v.String() {
+					c.SetSelected(i, true)
+					break
+				}
+			}
+		case v.Kind() == reflect.Int:
+			c.ClearSelected()
+			if i := int(v.Int()); i >= 0 && i < len(c.Values()) {
+				c.SetSelected(i, true)
+			}
+		case v.Type() == reflect.TypeOf([]string(nil)):
+			var indices []int
+			for _, s := range v.Interface().([]string) {
+				for i, value := range c.Values() {
+					if value == s {
+						indices = append(indices, i)
+						break
+					}
+				}
+			}
+			c.SetSelectedIndices(indices)
+		case v.Type() == reflect.TypeOf([]int(nil)):
+			var indices []int
+			for _, i := range v.Interface().([]int) {
+				if i >= 0 && i < len(c.Values()) {
+					indices = append(indices, i)
+				}
+			}
+			c.SetSelectedIndices(indices)
+		default:
+			return errUnsupportedType
+		}
+	default:
+		return errors.New("Unsupported component!")
+	}
+	return nil
+}
+
+func (b *binderImpl) Store(e Event) error {
+	var errs BindErrors
+	for _, bd := range b.bindings {
+		wasInvalid := bd.comp.Style().HasClass("gwu-Invalid")
+
+		var err error
+		if hv, ok := bd.comp.(HasValidators); ok {
+			err = hv.Validate()
+		}
+		if err == nil {
+			if err = b.store(bd); err != nil {
+				// Conversion error
+				if !bd.comp.Style().HasClass("gwu-Invalid") {
+					bd.comp.Style().AddClass("gwu-Invalid")
+				}
+			} else {
+				bd.comp.Style().RemoveClass("gwu-Invalid")
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, &BindError{Field: bd.field, Comp: bd.comp, Err: err})
+		}
+		if e != nil && wasInvalid != bd.comp.Style().HasClass("gwu-Invalid") {
+			e.MarkDirty(bd.comp)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// store stores the value of a component into its field.
+func (b *binderImpl) store(bd binding) error {
+	v, err := b.fieldValue(bd.field)
+	if err != nil {
+		return err
+	}
+
+	switch c := bd.comp.(type) {
+	case DateBox:
+		if v.Type() != reflect.TypeOf(time.Time{}) {
+			return errUnsupportedType
+		}
+		v.Set(reflect.ValueOf(c.Date()))
+	case TextBox:
+		text := c.Text()
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(text)
+		case reflect.Bool:
+			x, err := strconv.ParseBool(strings.TrimSpace(text))
+			if err != nil {
+				return errors.New("Invalid boolean value!")
+			}
+			v.SetBool(x)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			x, err := strconv.ParseInt(strings.TrimSpace(text), 10, v.Type().Bits())
+			if err != nil {
+				return errors.New("Invalid integer number!")
+			}
+			v.SetInt(x)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			x, err := strconv.ParseUint(strings.TrimSpace(text), 10, v.Type().Bits())
+			if err != nil {
+				return errors.New("Invalid non-negative integer number!")
+			}
+			v.SetUint(x)
+		case reflect.Float32, reflect.Float64:
+			x, err := strconv.ParseFloat(strings.TrimSpace(text), v.Type().Bits())
+			if err != nil {
+				return errors.New("Invalid number!")
+			}
+			v.SetFloat(x)
+		default:
+			return errUnsupportedType
+		}
+	case StateButton:
+		if v.Kind() != reflect.Bool {
+			return errUnsupportedType
+		}
+		v.SetBool(c.State())
+	case SwitchButton:
+		if v.Kind() != reflect.Bool {
+			return errUnsupportedType
+		}
+		v.SetBool(c.State())
+	case ListBox:
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(c.SelectedValue())
+		case v.Kind() == reflect.Int:
+			v.SetInt(int64(c.SelectedIdx()))
+		case v.Type() == reflect.TypeOf([]string(nil)):
+			v.Set(reflect.ValueOf(c.SelectedValues()))
+		case v.Type() == reflect.TypeOf([]int(nil)):
+			v.Set(reflect.ValueOf(c.SelectedIndices()))
+		default:
+			return errUnsupportedType
+		}
+	default:
+		return errors.New("Unsupported component!")
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Board component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Board interface defines a Kanban board: a container of cards (components)
+// arranged in columns. Cards can be dragged by the user within and between columns.
+// 
+// You can register ETYPE_CARD_MOVED event handlers which will be called when the user
+// moves a card. The event source will be the board, the moved card and its source and
+// target positions can be acquired by MovedCard().
+// 
+// Default style classes: "gwu-Board", "gwu-Board-Column", "gwu-Board-Column-Title",
+// "gwu-Board-Cards", "gwu-Board-Card"
+type Board interface {
+	// Board is a Container.
+	Container
+
+	// AddColumn adds a new column with the specified title,
+	// and returns its index.
+	AddColumn(title string) int
+
+	// RemoveColumn removes the column at the specified index
+	// along with its cards.
+	// Returns false if the index is invalid.
+	RemoveColumn(col int) bool
+
+	// ColumnsCount returns the number of columns.
+	ColumnsCount() int
+
+	// ColumnTitle returns the title of the column at the specified index.
+	ColumnTitle(col int) string
+
+	// SetColumnTitle sets the title of the column at the specified index.
+	SetColumnTitle(col int, title string)
+
+	// Cards returns the cards of the column at the specified index.
+	Cards(col int) []Comp
+
+	// AddCard adds a card to the end of the column at the specified index.
+	// Returns false if the index is invalid.
+	AddCard(col int, card Comp) bool
+
+	// InsertCard inserts a card into the column at the specified index,
+	// at the specified position.
+	// Returns false if an index is invalid.
+	InsertCard(col, pos int, card Comp) bool
+
+	// MoveCard moves a card of the board to the specified column
+	// and position (position is meant after the card is removed from
+	// its current position).
+	// Returns false if card is not a card of the board or an index is invalid.
+	MoveCard(card Comp, col, pos int) bool
+
+	// CardPos returns the column and position of the specified card.
+	// Returns (-1, -1) if the card is not a card of the board.
+	CardPos(card Comp) (col, pos int)
+
+	// MovedCard returns the card moved by the user last time,
+	// and its source and target positions.
+	MovedCard() (card Comp, fromCol, fromPos, toCol, toPos int)
+}
+
+// Board column.
+type boardColumn struct {
+	title string // Column title
+	cards []Comp // Cards of the column
+}
+
+// Board implementation.
+type boardImpl struct {
+	compImpl // Component implementation
+
+	columns []*boardColumn // Columns of the board
+
+	movedCard                      Comp // Card moved by the user last time
+	fromCol, fromPos, toCol, toPos int  // Source and target positions of the moved card
+	moveRejected                   bool // Tells if the card move received from the client was rejected
+}
+
+// NewBoard creates a new Board.
+func NewBoard() Board {
+	c := &boardImpl{compImpl: newInputCompImpl(nil)}
+	c.Style().AddClass("gwu-Board")
+	return c
+}
+
+func (c *boardImpl) Remove(c2 Comp) bool {
+	col, pos := c.CardPos(c2)
+	if col < 0 {
+		return false
+	}
+
+	c.removeCard(col, pos)
+	c2.setParent(nil)
+	return true
+}
+
+// removeCard removes the card at the specified position from the slice of cards
+// (does not clear the parent of the card).
+func (c *boardImpl) removeCard(col, pos int) {
+	column := c.columns[col]
+	column.cards = append(column.cards[:pos], column.cards[pos+1:]...)
+}
+
+// insertCard inserts the card at the specified position into the slice of cards
+// (does not set the parent of the card).
+func (c *boardImpl) insertCard(col, pos int, card Comp) {
+	column := c.columns[col]
+	column.cards = append(column.cards, nil)
+	copy(column.cards[pos+1:], column.cards[pos:])
+	column.cards[pos] = card
+}
+
+func (c *boardImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	for _, column := range c.columns {
+		for _, card := range column.cards {
+			if card.Id() == id {
+				return card
+			}
+			if c2, isContainer := card.(Container); isContainer {
+				if c3 := c2.ById(id); c3 != nil {
+					return c3
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *boardImpl) Clear() {
+	for _, column := range c.columns {
+		for _, card := range column.cards {
+			card.setParent(nil)
+		}
+		column.cards = nil
+	}
+}
+
+func (c *boardImpl) AddColumn(title string) int {
+	c.columns = append(c.columns, &boardColumn{title: title})
+	return len(c.columns) - 1
+}
+
+func (c *boardImpl) RemoveColumn(col int) bool {
+	if col < 0 || col >= len(c.columns) {
+		return false
+	}
+
+	for _, card := range c.columns[col].cards {
+		card.setParent(nil)
+	}
+	c.columns = append(c.columns[:col], c.columns[col+1:]...)
+	return true
+}
+
+func (c *boardImpl) ColumnsCount() int {
+	return len(c.columns)
+}
+
+func (c *boardImpl) ColumnTitle(col int) string {
+	if col < 0 || col >= len(c.columns) {
+		return ""
+	}
+	return c.columns[col].title
+}
+
+func (c *boardImpl) SetColumnTitle(col int, title string) {
+	if col >= 0 && col < len(c.columns) {
+		c.columns[col].title = title
+	}
+}
+
+func (c *boardImpl) Cards(col int) []Comp {
+	if col < 0 || col >= len(c.columns) {
+		return nil
+	}
+	cards := make([]Comp, len(c.columns[col].cards))
+	copy(cards, c.columns[col].cards)
+	return cards
+}
+
+func (c *boardImpl) AddCard(col int, card Comp) bool {
+	if col < 0 || col >= len(c.columns) {
+		return false
+	}
+	return c.InsertCard(col, len(c.columns[col].cards), card)
+}
+
+func (c *boardImpl) InsertCard(col, pos int, card Comp) bool {
+	if col < 0 || col >= len(c.columns) || pos < 0 || pos > len(c.columns[col].cards) {
+		return false
+	}
+
+	card.makeOrphan()
+	c.insertCard(col, pos, card)
+	card.setParent(c)
+	return true
+}
+
+func (c *boardImpl) MoveCard(card Comp, col, pos int) bool {
+	fromCol, fromPos := c.CardPos(card)
+	if fromCol < 0 || col < 0 || col >= len(c.columns) {
+		return false
+	}
+	count := len(c.columns[col].cards)
+	if col == fromCol {
+		count-- // Position is meant after removing the card
+	}
+	if pos < 0 || pos > count {
+		return false
+	}
+
+	c.removeCard(fromCol, fromPos)
+	c.insertCard(col, pos, card)
+	return true
+}
+
+func (c *boardImpl) CardPos(card Comp) (col, pos int) {
+	for col, column := range c.columns {
+		for pos, card2 := range column.cards {
+			if card2.Equals(card) {
+				return col, pos
+			}
+		}
+	}
+	return -1, -1
+}
+
+func (c *boardImpl) MovedCard() (card Comp, fromCol, fromPos, toCol, toPos int) {
+	return c.movedCard, c.fromCol, c.fromPos, c.toCol, c.toPos
+}
+
+func (c *boardImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_CARD_MOVED {
+		return
+	}
+
+	// Value format: "fromCol,fromPos,toCol,toPos"
+	c.moveRejected = true
+	event.MarkDirty(c) // Either moved or rejected, the client has to be updated
+	parts := strings.Split(r.FormValue(_PARAM_COMP_VALUE), ",")
+	if len(parts) != 4 {
+		return
+	}
+	var idxs [4]int
+	for i, part := range parts {
+		idx, err := strconv.Atoi(part)
+		if err != nil {
+			return
+		}
+		idxs[i] = idx
+	}
+	fromCol, fromPos, toCol, toPos := idxs[0], idxs[1], idxs[2], idxs[3]
+	if fromCol < 0 || fromCol >= len(c.columns) || fromPos < 0 || fromPos >= len(c.columns[fromCol].cards) {
+		return
+	}
+	card := c.columns[fromCol].cards[fromPos]
+	if !c.MoveCard(card, toCol, toPos) {
+		return
+	}
+	c.movedCard, c.fromCol, c.fromPos, c.toCol, c.toPos = card, fromCol, fromPos, toCol, toPos
+	c.moveRejected = false
+}
+
+func (c *boardImpl) dispatchEvent(e Event) {
+	if e.Type() == ETYPE_CARD_MOVED && c.moveRejected {
+		c.moveRejected = false
+		return
+	}
+	c.compImpl.dispatchEvent(e)
+}
+
+var (
+	_STR_BOARD_COLUMN_OP = []byte(`<div class="gwu-Board-Column"><div class="gwu-Board-Column-Title">`) // `<div class="gwu-Board-Column"><div class="gwu-Board-Column-Title">`
+	_STR_BOARD_CARDS_OP  = []byte(`</div><div class="gwu-Board-Cards">`)                                // `</div><div class="gwu-Board-Cards">`
+	_STR_BOARD_CARD_OP   = []byte(`<div class="gwu-Board-Card">`)                                       // `<div class="gwu-Board-Card">`
+	_STR_BOARD_INIT_OP   = []byte("<script>bdInit(")                                                    // "<script>bdInit("
+	_STR_BOARD_INIT_CL   = []byte(");</script>")                                                        // ");</script>"
+)
+
+func (c *boardImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	for _, column := range c.columns {
+		w.Write(_STR_BOARD_COLUMN_OP)
+		w.Writees(column.title)
+		w.Write(_STR_BOARD_CARDS_OP)
+		for _, card := range column.cards {
+			w.Write(_STR_BOARD_CARD_OP)
+			card.Render(w)
+			w.Write(_STR_DIV_CL)
+		}
+		w.Write(_STR_DIV_CL)
+		w.Write(_STR_DIV_CL)
+	}
+
+	// To render: <script>bdInit(compId,etype);</script>
+	w.writeScript(_STR_BOARD_INIT_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_CARD_MOVED))
+	w.Write(_STR_BOARD_INIT_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+func (c *boardImpl) usage() ([]Comp, int64) {
+	var comps []Comp
+	var memory int64
+	for _, column := range c.columns {
+		comps = append(comps, column.cards...)
+		memory += _ITEM_FOOTPRINT + int64(len(column.title))
+	}
+	return comps, memory
+}
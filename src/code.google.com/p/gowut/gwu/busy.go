@@ -0,0 +1,58 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Busy indicator displayed while events are being processed.
+
+package gwu
+
+import (
+	"time"
+)
+
+// Busy indicator type.
+type BusyIndicator int
+
+// Busy indicators.
+const (
+	BUSY_NONE    BusyIndicator = iota // No busy indicator
+	BUSY_BAR                          // Global (indeterminate progress) bar at the top of the window
+	BUSY_SPINNER                      // Spinner on the component that generated the event
+)
+
+// Default delay after which the busy indicator is displayed.
+const DEFAULT_BUSY_DELAY = 300 * time.Millisecond
+
+// Style class marking components whose events do not display the busy indicator.
+const _CLASS_NO_BUSY = "gwu-NoBusy"
+
+// SetBusySuppressed sets if the busy indicator is suppressed for the events
+// generated by the specified component, e.g. for background syncs.
+//
+// Suppression is implemented with the "gwu-NoBusy" style class, so it takes
+// effect when the component is (re-)rendered.
+// Events of Timers are suppressed by default.
+func SetBusySuppressed(c Comp, suppressed bool) {
+	if suppressed {
+		c.Style().AddClass(_CLASS_NO_BUSY)
+	} else {
+		c.Style().RemoveClass(_CLASS_NO_BUSY)
+	}
+}
+
+// BusySuppressed tells if the busy indicator is suppressed for the events
+// generated by the specified component.
+func BusySuppressed(c Comp) bool {
+	return c.Style().HasClass(_CLASS_NO_BUSY)
+}
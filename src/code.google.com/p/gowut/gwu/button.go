@@ -18,6 +18,8 @@
 package gwu
 
 // Button interface defines a clickable button.
+// The text is HTML-escaped when rendered. Trusted HTML markup can be set
+// by SetSafeHTML() of SafeHTMLer (which is implemented by Button).
 // 
 // Suggested event type to handle actions: ETYPE_CLICK
 // 
@@ -35,9 +37,9 @@ type Button interface {
 
 // Button implementation.
 type buttonImpl struct {
-	compImpl       // Component implementation
-	hasTextImpl    // Has text implementation
-	hasEnabledImpl // Has enabled implementation
+	compImpl        // Component implementation
+	hasSafeTextImpl // Has safe text implementation
+	hasEnabledImpl  // Has enabled implementation
 }
 
 // NewButton creates a new Button.
@@ -49,7 +51,7 @@ func NewButton(text string) Button {
 
 // newButtonImpl creates a new buttonImpl.
 func newButtonImpl(valueProviderJs []byte, text string) buttonImpl {
-	return buttonImpl{newCompImpl(valueProviderJs), newHasTextImpl(text), newHasEnabledImpl()}
+	return buttonImpl{newCompImpl(valueProviderJs), newHasSafeTextImpl(text), newHasEnabledImpl()}
 }
 
 var (
@@ -0,0 +1,121 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Trusted proxy configuration and real client address handling.
+
+package gwu
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseTrustedProxy parses a trusted proxy which is either an IP address
+// or a network in CIDR notation.
+func parseTrustedProxy(proxy string) (*net.IPNet, error) {
+	if strings.Contains(proxy, "/") {
+		_, ipnet, err := net.ParseCIDR(proxy)
+		return ipnet, err
+	}
+
+	ip := net.ParseIP(proxy)
+	if ip == nil {
+		return nil, errors.New("Invalid IP address: " + proxy)
+	}
+	bits := 8 * net.IPv4len
+	if ip.To4() == nil {
+		bits = 8 * net.IPv6len
+	} else {
+		ip = ip.To4()
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func (s *serverImpl) TrustedProxies() []string {
+	return s.trustedProxies
+}
+
+func (s *serverImpl) SetTrustedProxies(proxies ...string) error {
+	nets := make([]*net.IPNet, len(proxies))
+	for i, proxy := range proxies {
+		ipnet, err := parseTrustedProxy(proxy)
+		if err != nil {
+			return err
+		}
+		nets[i] = ipnet
+	}
+
+	s.trustedProxies = proxies
+	s.trustedNets = nets
+	return nil
+}
+
+// trusted tells if the specified IP address (string) is a trusted proxy.
+func (s *serverImpl) trusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, ipnet := range s.trustedNets {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOnly returns the host part of an address in the form of "host:port".
+// The address is returned unchanged if it does not contain a port.
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func (s *serverImpl) ClientAddr(r *http.Request) string {
+	addr := hostOnly(r.RemoteAddr)
+	if !s.trusted(addr) {
+		// Direct connection or untrusted proxy: headers cannot be trusted
+		return addr
+	}
+
+	// Walk the X-Forwarded-For chain from the right (closest proxy),
+	// the first address which is not a trusted proxy is the client.
+	// Proxies may append their own header lines instead of extending
+	// the existing one, so all lines have to be taken into account.
+	if xff := strings.Join(r.Header["X-Forwarded-For"], ","); len(xff) > 0 {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := hostOnly(strings.TrimSpace(hops[i]))
+			if len(hop) == 0 {
+				continue
+			}
+			addr = hop
+			if !s.trusted(hop) {
+				return addr
+			}
+		}
+		return addr
+	}
+
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); len(xri) > 0 {
+		return hostOnly(xri)
+	}
+
+	return addr
+}
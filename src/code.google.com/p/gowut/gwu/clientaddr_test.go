@@ -0,0 +1,68 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClientAddr(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	if err := s.SetTrustedProxies("10.0.0.1", "192.168.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		remote string
+		xff    []string
+		xri    string
+		exp    string
+	}{
+		{"direct", "1.2.3.4:5000", nil, "", "1.2.3.4"},
+		{"untrusted proxy, spoofed XFF", "1.2.3.4:5000", []string{"6.6.6.6"}, "", "1.2.3.4"},
+		{"untrusted proxy, spoofed X-Real-IP", "1.2.3.4:5000", nil, "6.6.6.6", "1.2.3.4"},
+		{"trusted proxy", "10.0.0.1:5000", []string{"1.2.3.4"}, "", "1.2.3.4"},
+		{"trusted proxy chain", "10.0.0.1:5000", []string{"1.2.3.4, 192.168.1.1"}, "", "1.2.3.4"},
+		{"client supplied XFF entries", "10.0.0.1:5000", []string{"6.6.6.6, 1.2.3.4"}, "", "1.2.3.4"},
+		{"proxy added its own header line", "10.0.0.1:5000", []string{"6.6.6.6", "1.2.3.4"}, "", "1.2.3.4"},
+		{"proxy line after trusted hops", "10.0.0.1:5000", []string{"6.6.6.6, 1.2.3.4", "192.168.1.1"}, "", "1.2.3.4"},
+		{"only trusted hops", "10.0.0.1:5000", []string{"192.168.1.1"}, "", "192.168.1.1"},
+		{"X-Real-IP from trusted proxy", "10.0.0.1:5000", nil, "1.2.3.4", "1.2.3.4"},
+		{"XFF with port", "10.0.0.1:5000", []string{"1.2.3.4:6000"}, "", "1.2.3.4"},
+	}
+
+	for _, c := range cases {
+		r := &http.Request{RemoteAddr: c.remote, Header: http.Header{}}
+		for _, xff := range c.xff {
+			r.Header.Add("X-Forwarded-For", xff)
+		}
+		if len(c.xri) > 0 {
+			r.Header.Set("X-Real-IP", c.xri)
+		}
+		if got := s.ClientAddr(r); got != c.exp {
+			t.Errorf("%s: expected: %s, got: %s", c.name, c.exp, got)
+		}
+	}
+}
+
+func TestSetTrustedProxiesInvalid(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	if err := s.SetTrustedProxies("not-an-ip"); err == nil {
+		t.Error("Expected error for invalid proxy")
+	}
+}
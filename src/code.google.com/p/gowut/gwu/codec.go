@@ -0,0 +1,137 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Value codecs: conversion of values sent by the client.
+
+package gwu
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ValueCodec converts a value sent by the client (browser) before it is
+// stored in the state of a component, e.g. parses localized decimal
+// separators, trims or normalizes text.
+// If an error is returned, the value is rejected: the state of the
+// component is not changed (and the previous value is restored in the browser).
+//
+// Codecs are added to components by Comp.AddValueCodec().
+type ValueCodec func(value string) (string, error)
+
+// errNotANumber is returned by number codecs for values which are not numbers.
+var errNotANumber = errors.New("Not a number!")
+
+var (
+	// CodecTrim trims leading and trailing white space.
+	CodecTrim ValueCodec = func(value string) (string, error) {
+		return strings.TrimSpace(value), nil
+	}
+
+	// CodecNormalizeSpace trims leading and trailing white space,
+	// and replaces white space sequences (including Unicode spaces
+	// such as no-break spaces) with a single space.
+	CodecNormalizeSpace ValueCodec = func(value string) (string, error) {
+		return strings.Join(strings.Fields(value), " "), nil
+	}
+
+	// CodecStripControl removes control and invisible format characters
+	// (such as zero width spaces and bidirectional text controls),
+	// except tabs and new lines.
+	CodecStripControl ValueCodec = func(value string) (string, error) {
+		return strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\n' || !unicode.IsControl(r) && !unicode.Is(unicode.Cf, r) {
+				return r
+			}
+			return -1
+		}, value), nil
+	}
+)
+
+// DecimalCodec returns a codec which converts numbers written with the
+// specified decimal and grouping separators to the canonical form,
+// e.g. DecimalCodec(',', '.') converts "1.234,5" to "1234.5".
+// White space is also removed (some locales group digits by spaces).
+// Values which are not numbers are rejected, empty values are allowed.
+func DecimalCodec(decimalSep, groupSep rune) ValueCodec {
+	return func(value string) (string, error) {
+		value = strings.Map(func(r rune) rune {
+			switch {
+			case r == groupSep || unicode.IsSpace(r):
+				return -1
+			case r == decimalSep:
+				return '.'
+			}
+			return r
+		}, value)
+		if len(value) == 0 {
+			return value, nil
+		}
+		if v, err := strconv.ParseFloat(value, 64); err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return value, errNotANumber
+		}
+		return value, nil
+	}
+}
+
+func (c *compImpl) AddValueCodec(codec ValueCodec) {
+	c.valueCodecs = append(c.valueCodecs, codec)
+}
+
+// clientValue returns the component value sent by the client with the event,
+// converted by the value codecs of the component.
+// present tells if the value was sent. A non-nil error is returned if
+// a codec rejected the value.
+func (c *compImpl) clientValue(r *http.Request) (value string, present bool, err error) {
+	value = r.FormValue(_PARAM_COMP_VALUE)
+	if _, present = r.Form[_PARAM_COMP_VALUE]; !present { // Form is surely parsed (we called FormValue())
+		return
+	}
+
+	value, err = c.convertValue(value)
+	return
+}
+
+// convertValue converts a value sent by the client by the value codecs
+// of the component.
+func (c *compImpl) convertValue(value string) (string, error) {
+	for _, codec := range c.valueCodecs {
+		var err error
+		if value, err = codec(value); err != nil {
+			return value, err
+		}
+	}
+	return value, nil
+}
+
+// clientFloat returns the component value sent by the client with the event
+// as a number, converted by the value codecs of the component.
+// ok is false if the value was not sent, a codec rejected it,
+// or it is not a (finite) number.
+func (c *compImpl) clientFloat(r *http.Request) (value float64, text string, ok bool) {
+	text, present, err := c.clientValue(r)
+	if !present || err != nil {
+		return
+	}
+
+	text = strings.TrimSpace(text)
+	value, err = strconv.ParseFloat(text, 64)
+	ok = err == nil && !math.IsNaN(value) && !math.IsInf(value, 0)
+	return
+}
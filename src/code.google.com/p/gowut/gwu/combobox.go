@@ -0,0 +1,332 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// ComboBox component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SuggestProvider is a function which provides the suggestions
+// of a ComboBox for the text typed in by the user.
+type SuggestProvider func(e Event, text string) []string
+
+// Default max number of suggestions displayed by a ComboBox.
+const DEFAULT_MAX_SUGGESTIONS = 10
+
+// ComboBox interface defines an editable text box with a dropdown list
+// of suggestions (autocomplete).
+//
+// Suggestions are provided by a SuggestProvider which is called as the user
+// types (ETYPE_KEY_UP events, sent when the user stops typing for the delay).
+// If there is no suggest provider, the values of the combo box containing
+// the typed text are suggested. The dropdown button lists the suggestions
+// for an empty text (e.g. all values).
+//
+// Suggested event type to handle actions: ETYPE_CHANGE
+//
+// ETYPE_CHANGE is sent when the user chooses a suggestion, or when the
+// combo box loses focus or ENTER is pressed after editing the text.
+//
+// Default style classes: "gwu-ComboBox", "gwu-ComboBox-Input", "gwu-ComboBox-Button",
+// "gwu-ComboBox-List", "gwu-ComboBox-Item", "gwu-ComboBox-Item-Active"
+type ComboBox interface {
+	// ComboBox is a component.
+	Comp
+
+	// ComboBox has text.
+	HasText
+
+	// ComboBox can be enabled/disabled.
+	HasEnabled
+
+	// Values returns the values of the combo box.
+	Values() []string
+
+	// SetValues sets the values of the combo box,
+	// which are suggested if there is no suggest provider.
+	SetValues(values []string)
+
+	// SuggestProvider returns the suggest provider.
+	SuggestProvider() SuggestProvider
+
+	// SetSuggestProvider sets the suggest provider which is called
+	// to provide the suggestions for the text typed in by the user.
+	// Pass nil to suggest the values of the combo box.
+	SetSuggestProvider(provider SuggestProvider)
+
+	// Suggestions returns the current suggestions.
+	Suggestions() []string
+
+	// Delay returns the delay of suggestion requests in milliseconds.
+	Delay() int
+
+	// SetDelay sets the delay of suggestion requests in milliseconds:
+	// suggestions are requested when the user stops typing for this long.
+	// Default is 300.
+	SetDelay(delay int)
+
+	// MaxSuggestions returns the max number of displayed suggestions.
+	MaxSuggestions() int
+
+	// SetMaxSuggestions sets the max number of displayed suggestions.
+	// Default is DEFAULT_MAX_SUGGESTIONS.
+	SetMaxSuggestions(max int)
+
+	// Placeholder returns the placeholder text.
+	Placeholder() string
+
+	// SetPlaceholder sets the placeholder text, a short hint
+	// displayed in the combo box when it is empty.
+	// Pass an empty string to not display a placeholder.
+	SetPlaceholder(placeholder string)
+}
+
+// ComboBox implementation.
+type comboBoxImpl struct {
+	compImpl       // Component implementation
+	hasTextImpl    // Has text implementation
+	hasEnabledImpl // Has enabled implementation
+
+	values      []string        // Values of the combo box
+	provider    SuggestProvider // Suggest provider
+	suggestions []string        // Current suggestions
+	delay       int             // Delay of suggestion requests in milliseconds
+	max         int             // Max number of displayed suggestions
+	placeholder string          // Placeholder text
+
+	list *comboBoxList // List of the suggestions
+}
+
+// comboBoxList is the dropdown list of the suggestions of a combo box.
+// It is re-rendered when suggestions arrive, without
+// re-rendering (and losing the focus of) the input.
+type comboBoxList struct {
+	compImpl // Component implementation
+
+	cb   *comboBoxImpl // The combo box
+	open bool          // Tells if the list is to be displayed
+}
+
+// NewComboBox creates a new ComboBox.
+func NewComboBox(values []string) ComboBox {
+	c := &comboBoxImpl{compImpl: newInputCompImpl(nil), hasTextImpl: newHasTextImpl(""), hasEnabledImpl: newHasEnabledImpl(),
+		values: values, delay: 300, max: DEFAULT_MAX_SUGGESTIONS}
+	c.list = &comboBoxList{compImpl: newCompImpl(nil), cb: c}
+	c.list.setParent(c)
+	c.list.Style().AddClass("gwu-ComboBox-List")
+	c.Style().AddClass("gwu-ComboBox")
+	return c
+}
+
+// Remove is needed to be the parent of the list (Container).
+func (c *comboBoxImpl) Remove(c2 Comp) bool {
+	return false
+}
+
+func (c *comboBoxImpl) ById(id ID) Comp {
+	switch id {
+	case c.id:
+		return c
+	case c.list.id:
+		return c.list
+	}
+	return nil
+}
+
+// Clear clears the suggestions.
+func (c *comboBoxImpl) Clear() {
+	c.suggestions = nil
+	c.list.open = false
+}
+
+func (c *comboBoxImpl) Values() []string {
+	return c.values
+}
+
+func (c *comboBoxImpl) SetValues(values []string) {
+	c.values = values
+}
+
+func (c *comboBoxImpl) SuggestProvider() SuggestProvider {
+	return c.provider
+}
+
+func (c *comboBoxImpl) SetSuggestProvider(provider SuggestProvider) {
+	c.provider = provider
+}
+
+func (c *comboBoxImpl) Suggestions() []string {
+	suggestions := make([]string, len(c.suggestions))
+	copy(suggestions, c.suggestions)
+	return suggestions
+}
+
+func (c *comboBoxImpl) Delay() int {
+	return c.delay
+}
+
+func (c *comboBoxImpl) SetDelay(delay int) {
+	c.delay = delay
+}
+
+func (c *comboBoxImpl) MaxSuggestions() int {
+	return c.max
+}
+
+func (c *comboBoxImpl) SetMaxSuggestions(max int) {
+	c.max = max
+}
+
+func (c *comboBoxImpl) Placeholder() string {
+	return c.placeholder
+}
+
+func (c *comboBoxImpl) SetPlaceholder(placeholder string) {
+	c.placeholder = placeholder
+}
+
+// suggest updates the suggestions for the specified text.
+func (c *comboBoxImpl) suggest(e Event, text string) {
+	if c.provider != nil {
+		c.suggestions = c.provider(e, text)
+	} else {
+		c.suggestions = nil
+		text = strings.ToLower(text)
+		for _, value := range c.values {
+			if strings.Contains(strings.ToLower(value), text) {
+				c.suggestions = append(c.suggestions, value)
+			}
+		}
+	}
+	if c.max > 0 && len(c.suggestions) > c.max {
+		c.suggestions = c.suggestions[:c.max]
+	}
+}
+
+func (c *comboBoxImpl) preprocessEvent(event Event, r *http.Request) {
+	switch event.Type() {
+	case ETYPE_KEY_UP:
+		value, present, err := c.clientText(event, r, SANITIZE_NONE)
+		if !present || err != nil {
+			return
+		}
+		c.text = value
+		c.suggest(event, c.text)
+		c.list.open = true
+	case ETYPE_STATE_CHANGE:
+		// Dropdown button
+		c.suggest(event, "")
+		c.list.open = true
+	case ETYPE_CHANGE:
+		value, present, err := c.clientText(event, r, SANITIZE_NONE)
+		if !present || err != nil {
+			return
+		}
+		c.text = value
+		c.suggestions = nil
+		c.list.open = false
+	default:
+		return
+	}
+	event.MarkDirty(c.list)
+}
+
+var (
+	_STR_CB_INPUT_OP  = []byte(`<input type="text" class="gwu-ComboBox-Input" autocomplete="off"`)                                                      // `<input type="text" class="gwu-ComboBox-Input" autocomplete="off"`
+	_STR_CB_KEY_DOWN  = []byte(` onkeydown="cbKeyDown(event,`)                                                                                          // ` onkeydown="cbKeyDown(event,`
+	_STR_CB_KEY_UP    = []byte(`)" onkeyup="cbKeyUp(event,`)                                                                                            // `)" onkeyup="cbKeyUp(event,`
+	_STR_CB_CHANGE    = []byte(`)" onchange="cbChange(this,`)                                                                                           // `)" onchange="cbChange(this,`
+	_STR_CB_BLUR      = []byte(`)" onblur="cbList(`)                                                                                                    // `)" onblur="cbList(`
+	_STR_CB_BLUR_CL   = []byte(`).style.display='none'"`)                                                                                               // `).style.display='none'"`
+	_STR_CB_BUTTON_OP = []byte(`<button type="button" class="gwu-ComboBox-Button" tabindex="-1" onmousedown="event.preventDefault()" onclick="cbDrop(`) // `<button type="button" class="gwu-ComboBox-Button" tabindex="-1" onmousedown="event.preventDefault()" onclick="cbDrop(`
+	_STR_CB_BUTTON_CL = []byte(`)">&#9660;</button>`)                                                                                                   // `)">&#9660;</button>`
+	_STR_CB_HIDDEN    = []byte(` style="display:none"`)                                                                                                 // ` style="display:none"`
+	_STR_CB_ITEM_OP   = []byte(`<div class="gwu-ComboBox-Item" onmousedown="event.preventDefault();cbSelect(`)                                          // `<div class="gwu-ComboBox-Item" onmousedown="event.preventDefault();cbSelect(`
+	_STR_CB_ITEM_THIS = []byte(`,this)">`)                                                                                                              // `,this)">`
+)
+
+func (c *comboBoxImpl) Render(w writer) {
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	// Change and key up events are sent by the input
+	for etype := range c.handlers {
+		if etype != ETYPE_CHANGE && etype != ETYPE_KEY_UP {
+			c.renderEHandler(w, etype)
+		}
+	}
+	w.Write(_STR_GT)
+
+	// To render: <input type="text" class="gwu-ComboBox-Input" autocomplete="off" placeholder="placeholder"
+	// onkeydown="cbKeyDown(event,compId,etype)" onkeyup="cbKeyUp(event,compId,etype,delay)"
+	// onchange="cbChange(this,compId,etype)" onblur="cbList(compId).style.display='none'" value="text"/>
+	w.Write(_STR_CB_INPUT_OP)
+	c.renderEnabled(w)
+	if len(c.placeholder) > 0 {
+		w.Write(_STR_PLACEHOLDER)
+		w.Writees(c.placeholder)
+		w.Write(_STR_QUOTE)
+	}
+	w.Write(_STR_CB_KEY_DOWN)
+	w.Writevs(int(c.id), _STR_COMMA, int(ETYPE_CHANGE))
+	w.Write(_STR_CB_KEY_UP)
+	w.Writevs(int(c.id), _STR_COMMA, int(ETYPE_KEY_UP), _STR_COMMA, c.delay)
+	w.Write(_STR_CB_CHANGE)
+	w.Writevs(int(c.id), _STR_COMMA, int(ETYPE_CHANGE))
+	w.Write(_STR_CB_BLUR)
+	w.Writev(int(c.id))
+	w.Write(_STR_CB_BLUR_CL)
+	w.Write(_STR_VALUE)
+	c.renderText(w)
+	w.Write(_STR_INPUT_CL)
+
+	if c.enabled {
+		// To render: <button ... onclick="cbDrop(compId,etype)">&#9660;</button>
+		w.Write(_STR_CB_BUTTON_OP)
+		w.Writevs(int(c.id), _STR_COMMA, int(ETYPE_STATE_CHANGE))
+		w.Write(_STR_CB_BUTTON_CL)
+	}
+
+	c.list.open = false
+	c.list.Render(w)
+
+	w.Write(_STR_SPAN_CL)
+}
+
+// Render renders the list of suggestions, displayed if it is open.
+func (c *comboBoxList) Render(w writer) {
+	cb := c.cb
+
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	if !c.open || len(cb.suggestions) == 0 {
+		w.Write(_STR_CB_HIDDEN)
+	}
+	w.Write(_STR_GT)
+
+	for _, s := range cb.suggestions {
+		// To render: <div class="gwu-ComboBox-Item" onmousedown="event.preventDefault();cbSelect(compId,etype,this)">suggestion</div>
+		w.Write(_STR_CB_ITEM_OP)
+		w.Writevs(int(cb.id), _STR_COMMA, int(ETYPE_CHANGE))
+		w.Write(_STR_CB_ITEM_THIS)
+		w.Writees(s)
+		w.Write(_STR_DIV_CL)
+	}
+
+	w.Write(_STR_DIV_CL)
+}
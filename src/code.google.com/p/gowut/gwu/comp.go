@@ -18,8 +18,8 @@
 package gwu
 
 import (
-	"html"
 	"net/http"
+	"net/url"
 	"strconv"
 )
 
@@ -45,6 +45,17 @@ type Container interface {
 
 	// Clear clears the container, removes all child components.
 	Clear()
+
+	// ReadOnlyDeep tells if the container is in read-only mode.
+	ReadOnlyDeep() bool
+
+	// SetReadOnlyDeep sets if the container is in read-only mode, in which
+	// all descendant input components (components the user can edit, e.g.
+	// TextBox, ComboBox, Tree, Board or the editable cells of a Table) are
+	// rendered read-only (disabled), without changing the components themselves.
+	// Events of read-only inputs are rejected.
+	// Useful for "view" and "edit" modes of the same form.
+	SetReadOnlyDeep(readOnly bool)
 }
 
 // Comp interface: the base of all UI components.
@@ -71,6 +82,7 @@ type Comp interface {
 	Attr(name string) string
 
 	// SetAttr sets the value of the specified HTML attribute.
+	// The value is HTML-escaped when rendered.
 	// Pass an empty string value to delete the attribute.
 	SetAttr(name, value string)
 
@@ -94,6 +106,83 @@ type Comp interface {
 	// DescendantOf tells if this component is a descendant of the specified another component.
 	DescendantOf(c2 Comp) bool
 
+	// Visible tells if the component is visible.
+	Visible() bool
+
+	// SetVisible sets if the component is visible.
+	// Hidden components are not removed, they keep their state,
+	// and do not take up space (see SetVisibility() to preserve their space).
+	// See ShowComps() and HideComps() to show and hide multiple components
+	// from event handlers.
+	SetVisible(visible bool)
+
+	// Visibility returns the visibility of the component.
+	Visibility() Visibility
+
+	// SetVisibility sets the visibility of the component.
+	// Default is VISIBLE.
+	SetVisibility(visibility Visibility)
+
+	// VisibleAt tells if the component is visible at the specified breakpoint
+	// (viewport width range).
+	VisibleAt(bp Breakpoint) bool
+
+	// SetVisibleAt sets if the component is visible at the specified breakpoint
+	// (viewport width range), e.g. SetVisibleAt(BP_PHONE, false) hides the
+	// component on phones. This is applied by the browser (by media-query
+	// style classes), so no event is needed when the viewport is resized.
+	// Has no effect if the component is not visible (see Visible()).
+	// Default is visible at all breakpoints.
+	SetVisibleAt(bp Breakpoint, visible bool)
+
+	// SetVisibleForRoles sets the roles the component is visible for:
+	// the component is hidden for sessions having none of the roles
+	// (see Session.Roles()). Call it without roles to make the component
+	// visible for everyone (default).
+	// 
+	// Note that hidden components are still rendered (with the hidden attribute),
+	// so sensitive data must not be placed into them. Events of hidden and
+	// disabled components (and of their descendants) are rejected.
+	SetVisibleForRoles(roles ...string)
+
+	// SetEnabledForRoles sets the roles the component is enabled for:
+	// the component is disabled for sessions having none of the roles.
+	// Call it without roles to make the component enabled for everyone (default).
+	SetEnabledForRoles(roles ...string)
+
+	// Policy returns the access policy of the component.
+	Policy() Policy
+
+	// SetPolicy sets an access policy which is evaluated (in addition to the
+	// roles set by SetVisibleForRoles() and SetEnabledForRoles()) against
+	// the session when the component is rendered and when an event is received
+	// from it. The most restrictive access applies.
+	// Pass nil to remove the policy.
+	SetPolicy(policy Policy)
+
+	// Access returns the access of the specified session to the component
+	// (not taking its ancestors into account).
+	// Conditions bound by ShowWhen() and EnableWhen() are also applied;
+	// for a nil session only these are taken into account.
+	Access(sess Session) Access
+
+	// inReadOnly tells if the component is an input rendered read-only
+	// because an ancestor container is in read-only mode.
+	inReadOnly() bool
+
+	// ContextMenu returns the context menu of the component.
+	ContextMenu() PopupMenu
+
+	// SetContextMenu sets the context menu of the component, which is opened
+	// at the mouse position on right click (instead of the browser's context menu).
+	// The popup menu must be added to the window (or to any container of it).
+	// Pass nil to remove the context menu.
+	SetContextMenu(menu PopupMenu)
+
+	// setBinding sets the condition the visibility (if show is true)
+	// or the enabled state of the component is bound to.
+	setBinding(show bool, cond Condition)
+
 	// AddEHandler adds a new event handler.
 	AddEHandler(handler EventHandler, etypes ...EventType)
 
@@ -103,6 +192,9 @@ type Comp interface {
 	// HandlersCount returns the number of added handlers.
 	HandlersCount(etype EventType) int
 
+	// eHandlers returns the handlers added for the specified event type.
+	eHandlers(etype EventType) []EventHandler
+
 	// SyncOnETypes returns the event types on which to synchronize component value
 	// from browser to the server.
 	SyncOnETypes() []EventType
@@ -111,14 +203,53 @@ type Comp interface {
 	// component value from browser to the server.
 	AddSyncOnETypes(etypes ...EventType)
 
+	// AddSyncValue adds an additional named value which is sent to the server
+	// along with the component value on the sync event types (see SyncOnETypes()),
+	// so composite components (e.g. a range slider with two thumbs) can
+	// synchronize all their state in one event.
+	// valueProviderJs is a JavaScript expression which provides the value,
+	// in which this refers to the HTML element of the component.
+	// Values sent with an event are available by Event.SyncValue()
+	// and its typed variants.
+	AddSyncValue(name, valueProviderJs string)
+
+	// AddValueCodec adds a value codec which converts (or rejects)
+	// the component value sent by the client before it is stored
+	// in the component's state. Codecs are applied in the order
+	// they were added.
+	AddValueCodec(codec ValueCodec)
+
+	// MaxValueSize returns the max size of the values sent by the client
+	// with an event in bytes.
+	MaxValueSize() int
+
+	// SetMaxValueSize sets the max size of the values sent by the client
+	// with an event in bytes (the component value and the additional named
+	// values, see AddSyncValue()). If exceeded, the event is not processed,
+	// instead an ETYPE_LIMIT_EXCEEDED event is dispatched to the component.
+	// Pass 0 to use the max value size of the server (see Server.SetMaxValueSize()),
+	// pass a negative value to not limit the size. The default is 0.
+	SetMaxValueSize(size int)
+
 	// PreprocessEvent preprocesses an incoming event before it is dispatched.
 	// This gives the opportunity for components to update their new value
 	// before event handlers are called for example.
 	preprocessEvent(event Event, r *http.Request)
 
+	// checkValueVersion checks the version of the component value sent by
+	// the client with the event, and calls the conflict handler if the value
+	// is stale. Returns false if the client value has to be discarded.
+	checkValueVersion(e Event, r *http.Request) bool
+
 	// DispatchEvent dispatches the event to all registered event handlers.
 	dispatchEvent(e Event)
 
+	// usage returns what the component retains beyond what is reachable by
+	// walking the component tree (see walkComps()), for session usage accounting:
+	// components (e.g. cached views) and the approximate memory of other
+	// content (e.g. buffered lines).
+	usage() (comps []Comp, memory int64)
+
 	// Render renders the component (as HTML code).
 	Render(w writer)
 }
@@ -131,9 +262,20 @@ type compImpl struct {
 	attrs     map[string]string // Explicitly set HTML attributes for the component's wrapper tag.
 	styleImpl *styleImpl        // Style builder.
 
+	accessImpl             // Access control implementation
+	visibility  Visibility // Visibility of the component
+	contextMenu PopupMenu  // Optional context menu
+
 	handlers        map[EventType][]EventHandler // Event handlers mapped from event type. Lazily initialized.
 	valueProviderJs []byte                       // If the HTML representation of the component has a value, this JavaScript code code must provide it. It will be automatically sent as the PARAM_COMP_ID parameter.
 	syncOnETypes    map[EventType]bool           // Tells on which event types should comp value sync happen.
+	syncValues      []syncValue                  // Additional named values to sync. Lazily initialized.
+	valueCodecs     []ValueCodec                 // Codecs of the value sent by the client. Lazily initialized.
+	maxValueSize    int                          // Max size of values sent by the client
+	input           bool                         // Tells if the component is an editable input (read-only mode applies to it)
+
+	valueVersion    int             // Version of the component value, incremented on server side changes.
+	conflictHandler ConflictHandler // Optional handler of stale value submissions.
 }
 
 // newCompImpl creates a new compImpl.
@@ -145,6 +287,15 @@ func newCompImpl(valueProviderJs []byte) compImpl {
 	return compImpl{id: id, attrs: map[string]string{"id": id.String()}, styleImpl: newStyleImpl(), valueProviderJs: valueProviderJs}
 }
 
+// newInputCompImpl creates a new compImpl of an editable input,
+// which is rendered read-only and does not accept events
+// in read-only containers.
+func newInputCompImpl(valueProviderJs []byte) compImpl {
+	c := newCompImpl(valueProviderJs)
+	c.input = true
+	return c
+}
+
 func (c *compImpl) Id() ID {
 	return c.id
 }
@@ -193,11 +344,11 @@ func (c *compImpl) SetIAttr(name string, value int) {
 }
 
 func (c *compImpl) ToolTip() string {
-	return html.UnescapeString(c.Attr("title"))
+	return c.Attr("title")
 }
 
 func (c *compImpl) SetToolTip(toolTip string) {
-	c.SetAttr("title", html.EscapeString(toolTip))
+	c.SetAttr("title", toolTip)
 }
 
 func (c *compImpl) Style() Style {
@@ -222,6 +373,8 @@ func (c *compImpl) renderAttrsAndStyle(w writer) {
 		w.WriteAttr(name, value)
 	}
 
+	c.renderAccess(w)
+
 	c.styleImpl.render(w)
 }
 
@@ -242,6 +395,10 @@ func (c *compImpl) HandlersCount(etype EventType) int {
 	return len(c.handlers[etype])
 }
 
+func (c *compImpl) eHandlers(etype EventType) []EventHandler {
+	return c.handlers[etype]
+}
+
 func (c *compImpl) SyncOnETypes() []EventType {
 	if c.syncOnETypes == nil {
 		return nil
@@ -268,6 +425,34 @@ func (c *compImpl) AddSyncOnETypes(etypes ...EventType) {
 	}
 }
 
+// syncValue is an additional named value of a component to sync.
+type syncValue struct {
+	name            string // Name of the value
+	valueProviderJs string // JavaScript expression providing the value
+}
+
+func (c *compImpl) AddSyncValue(name, valueProviderJs string) {
+	c.syncValues = append(c.syncValues, syncValue{name: name, valueProviderJs: valueProviderJs})
+}
+
+func (c *compImpl) ValueVersion() int {
+	return c.valueVersion
+}
+
+func (c *compImpl) ConflictHandler() ConflictHandler {
+	return c.conflictHandler
+}
+
+func (c *compImpl) SetConflictHandler(handler ConflictHandler) {
+	c.conflictHandler = handler
+}
+
+// valueChanged registers a server side change of the component value
+// by incrementing the value version.
+func (c *compImpl) valueChanged() {
+	c.valueVersion++
+}
+
 var (
 	_STR_SE_PREFIX = []byte(`="se(event,`) // `="se(event,`
 	_STR_SE_SUFFIX = []byte(`)"`)          // `)"`
@@ -276,25 +461,67 @@ var (
 // rendrenderEventHandlers renders the event handlers as attributes.
 func (c *compImpl) renderEHandlers(w writer) {
 	for etype, _ := range c.handlers {
-		etypeAttr := etypeAttrs[etype]
-		if len(etypeAttr) == 0 { // Only general events are added to the etypeAttrs map
-			continue
+		if etype == ETYPE_CONTEXT_MENU && c.contextMenu != nil {
+			continue // Rendered by renderContextMenu()
 		}
+		c.renderEHandler(w, etype)
+	}
+	c.renderContextMenu(w)
+}
 
-		// To render                 : ` <etypeAttr>="se(event,etype,compId,value)"`
-		// Example (checkbox onclick): ` onclick="se(event,0,4327,this.checked)"`
-		w.Write(_STR_SPACE)
-		w.Write(etypeAttr)
-		w.Write(_STR_SE_PREFIX)
-		w.Writev(int(etype))
+// renderEHandler renders the event sender attribute of the specified event type.
+func (c *compImpl) renderEHandler(w writer, etype EventType) {
+	etypeAttr := etypeAttrs[etype]
+	if len(etypeAttr) == 0 { // Only general events are added to the etypeAttrs map
+		return
+	}
+
+	// To render                 : ` <etypeAttr>="se(event,etype,compId,value[,valueVersion])"`
+	// Example (checkbox onclick): ` onclick="se(event,0,4327,this.checked)"`
+	w.Write(_STR_SPACE)
+	w.Write(etypeAttr)
+	w.Write(_STR_SE_PREFIX)
+	w.Writev(int(etype))
+	w.Write(_STR_COMMA)
+	w.Writev(int(c.id))
+	if (len(c.valueProviderJs) > 0 || len(c.syncValues) > 0) && c.syncOnETypes != nil && c.syncOnETypes[etype] {
 		w.Write(_STR_COMMA)
-		w.Writev(int(c.id))
-		if len(c.valueProviderJs) > 0 && c.syncOnETypes != nil && c.syncOnETypes[etype] {
-			w.Write(_STR_COMMA)
+		if len(c.valueProviderJs) > 0 {
 			w.Write(c.valueProviderJs)
+		} else {
+			w.Write(_STR_NULL)
+		}
+		if c.conflictHandler != nil {
+			w.Write(_STR_COMMA)
+			w.Writev(c.valueVersion)
+		} else if len(c.syncValues) > 0 {
+			w.Write(_STR_COMMA)
+			w.Write(_STR_NULL)
+		}
+		if len(c.syncValues) > 0 {
+			c.renderSyncValues(w)
 		}
-		w.Write(_STR_SE_SUFFIX)
 	}
+	w.Write(_STR_SE_SUFFIX)
+}
+
+var _STR_NULL = []byte("null") // "null"
+
+// renderSyncValues renders the additional named values to sync
+// as a JavaScript object, preceded by a comma.
+func (c *compImpl) renderSyncValues(w writer) {
+	// To render: ,{'name1':(valueProviderJs1),'name2':(valueProviderJs2)}
+	w.Writes(",{")
+	for i, sv := range c.syncValues {
+		if i > 0 {
+			w.Write(_STR_COMMA)
+		}
+		// Names are query escaped so they are safe in the JS string and in the request
+		w.Writess("'", url.QueryEscape(sv.name), "':(")
+		w.Writees(sv.valueProviderJs)
+		w.Writes(")")
+	}
+	w.Writes("}")
 }
 
 // THIS IS AN EMPTY IMPLEMENTATION AS NOT ALL COMPONENTS NEED THIS.
@@ -302,6 +529,25 @@ func (c *compImpl) renderEHandlers(w writer) {
 func (b *compImpl) preprocessEvent(event Event, r *http.Request) {
 }
 
+func (c *compImpl) checkValueVersion(e Event, r *http.Request) bool {
+	if c.conflictHandler == nil {
+		return true
+	}
+
+	version, err := strconv.Atoi(r.FormValue(_PARAM_COMP_VERSION))
+	if err != nil || version == c.valueVersion {
+		return true // No version info or not stale
+	}
+
+	if c.conflictHandler(e, r.FormValue(_PARAM_COMP_VALUE)) == CONFLICT_OVERWRITE {
+		return true
+	}
+
+	// Client has a stale value, send the server value:
+	e.MarkDirty(e.Src())
+	return false
+}
+
 func (c *compImpl) dispatchEvent(e Event) {
 	for _, handler := range c.handlers[e.Type()] {
 		handler.HandleEvent(e)
@@ -21,6 +21,9 @@
 package gwu
 
 import (
+	"encoding/base64"
+	"errors"
+	"net/http"
 	"strconv"
 )
 
@@ -56,6 +59,64 @@ func (c *hasTextImpl) renderText(w writer) {
 	w.Writees(c.text)
 }
 
+// SafeHTMLer interface defines a text property which can also be set
+// to trusted HTML markup which is rendered as-is.
+// Texts set by SetText() are HTML-escaped when rendered.
+// 
+// Implemented by Label and Button (and CheckBox, RadioButton built on it).
+// Example:
+// 
+// 	l := gwu.NewLabel("")
+// 	l.(gwu.SafeHTMLer).SetSafeHTML("<b>Trusted</b> markup")
+type SafeHTMLer interface {
+	// SafeHTMLer has text.
+	HasText
+
+	// SafeHTML tells if the text is HTML markup which is rendered
+	// as-is (see SetSafeHTML()).
+	SafeHTML() bool
+
+	// SetSafeHTML sets the text to the specified HTML markup which is
+	// rendered as-is, without HTML-escaping.
+	// Only use it with trusted markup, never with user data!
+	SetSafeHTML(html string)
+}
+
+// newHasSafeTextImpl creates a new hasSafeTextImpl
+func newHasSafeTextImpl(text string) hasSafeTextImpl {
+	return hasSafeTextImpl{hasTextImpl: newHasTextImpl(text)}
+}
+
+// SafeHTMLer implementation.
+type hasSafeTextImpl struct {
+	hasTextImpl // Has text implementation
+
+	safeHTML bool // Tells if the text is HTML markup to be rendered as-is
+}
+
+func (c *hasSafeTextImpl) SetText(text string) {
+	c.text = text
+	c.safeHTML = false
+}
+
+func (c *hasSafeTextImpl) SafeHTML() bool {
+	return c.safeHTML
+}
+
+func (c *hasSafeTextImpl) SetSafeHTML(html string) {
+	c.text = html
+	c.safeHTML = true
+}
+
+// renderText renders the text, HTML-escaped unless it is safe HTML.
+func (c *hasSafeTextImpl) renderText(w writer) {
+	if c.safeHTML {
+		w.Writes(c.text)
+	} else {
+		c.hasTextImpl.renderText(w)
+	}
+}
+
 // HasEnabled interface defines an enabled property.
 type HasEnabled interface {
 	// Enabled returns the enabled property.
@@ -124,6 +185,119 @@ func (c *hasUrlImpl) renderUrl(attr string, w writer) {
 	w.WriteAttr(attr, c.url)
 }
 
+// Conflict resolution type.
+type ConflictResolution int
+
+// Conflict resolutions.
+const (
+	CONFLICT_OVERWRITE   ConflictResolution = iota // Client value overwrites the server value
+	CONFLICT_KEEP_SERVER                           // Client value is discarded, server value is kept (and re-rendered)
+)
+
+// ConflictHandler is a function which is called when a stale component value
+// is submitted by the client: the value was edited by the user in the browser
+// while the server changed the value of the component meanwhile.
+// 
+// clientValue is the raw value as sent by the client (e.g. the text of a TextBox,
+// "true" or "false" for a CheckBox, comma separated selected indices for a ListBox).
+// At the time of the call the component still holds the server value.
+// 
+// The handler may merge the client value into the server value (and return
+// CONFLICT_KEEP_SERVER), and may notify the user by changing other components
+// and marking them dirty in the event.
+type ConflictHandler func(e Event, clientValue string) ConflictResolution
+
+// HasValueVersion interface defines a versioned synchronized component value.
+// The value version is incremented each time the component value is changed
+// on the server side (by calling its setter methods), which allows detecting
+// stale submissions: the client sends the version of the value it edited along
+// with the value.
+// Versions are only checked if a conflict handler is set.
+type HasValueVersion interface {
+	// ValueVersion returns the version of the component value.
+	ValueVersion() int
+
+	// ConflictHandler returns the conflict handler.
+	ConflictHandler() ConflictHandler
+
+	// SetConflictHandler sets the conflict handler which is called
+	// when a stale value is submitted by the client.
+	// Pass nil to disable version checking (this is the default).
+	SetConflictHandler(handler ConflictHandler)
+}
+
+// Default max size of binary component values in bytes.
+const DEFAULT_MAX_BINARY_SIZE = 1 << 20
+
+// HasBinaryValue interface defines a binary synchronized component value,
+// e.g. the image of a canvas snapshot or an audio recording.
+// 
+// Binary values are transferred base64 encoded (URL-safe alphabet without
+// padding). The size of the values is limited on the server side,
+// values exceeding the max size are rejected.
+type HasBinaryValue interface {
+	// BinaryValue returns the binary value.
+	// nil is returned if there is no value.
+	BinaryValue() []byte
+
+	// MaxBinarySize returns the max size of the binary value in bytes.
+	MaxBinarySize() int
+
+	// SetMaxBinarySize sets the max size of the binary value in bytes.
+	// Values exceeding this size sent by the client are rejected.
+	// Pass a value <= 0 to not limit the size.
+	// The default is DEFAULT_MAX_BINARY_SIZE.
+	SetMaxBinarySize(maxSize int)
+}
+
+// HasBinaryValue implementation.
+type hasBinaryValueImpl struct {
+	binValue   []byte // The binary value
+	maxBinSize int    // Max size of the binary value
+}
+
+var errBinaryValueTooBig = errors.New("Binary value too big!")
+
+// newHasBinaryValueImpl creates a new hasBinaryValueImpl
+func newHasBinaryValueImpl() hasBinaryValueImpl {
+	return hasBinaryValueImpl{maxBinSize: DEFAULT_MAX_BINARY_SIZE}
+}
+
+func (c *hasBinaryValueImpl) BinaryValue() []byte {
+	return c.binValue
+}
+
+func (c *hasBinaryValueImpl) MaxBinarySize() int {
+	return c.maxBinSize
+}
+
+func (c *hasBinaryValueImpl) SetMaxBinarySize(maxSize int) {
+	c.maxBinSize = maxSize
+}
+
+// parseBinaryValue parses and stores the binary value sent by the client.
+// The size limit is checked before decoding.
+// The stored value is unchanged if an error is returned.
+func (c *hasBinaryValueImpl) parseBinaryValue(r *http.Request) error {
+	return c.decodeBinaryValue(r.FormValue(_PARAM_COMP_VALUE))
+}
+
+// decodeBinaryValue decodes and stores the specified base64 encoded binary value.
+// The size limit is checked before decoding.
+// The stored value is unchanged if an error is returned.
+func (c *hasBinaryValueImpl) decodeBinaryValue(value string) error {
+	if c.maxBinSize > 0 && base64.RawURLEncoding.DecodedLen(len(value)) > c.maxBinSize {
+		return errBinaryValueTooBig
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	c.binValue = data
+	return nil
+}
+
 // Horizontal alignment type.
 type HAlign string
 
@@ -136,6 +310,20 @@ const (
 	HA_DEFAULT HAlign = "" // Browser default (or inherited) horizontal alignment
 )
 
+// String returns the CSS value of the horizontal alignment.
+func (a HAlign) String() string {
+	return string(a)
+}
+
+// Valid tells if the horizontal alignment is one of the HA_ constants.
+func (a HAlign) Valid() bool {
+	switch a {
+	case HA_LEFT, HA_CENTER, HA_RIGHT, HA_DEFAULT:
+		return true
+	}
+	return false
+}
+
 // Vertical alignment type.
 type VAlign string
 
@@ -148,6 +336,20 @@ const (
 	VA_DEFAULT VAlign = "" // Browser default (or inherited) vertical alignment
 )
 
+// String returns the CSS value of the vertical alignment.
+func (a VAlign) String() string {
+	return string(a)
+}
+
+// Valid tells if the vertical alignment is one of the VA_ constants.
+func (a VAlign) Valid() bool {
+	switch a {
+	case VA_TOP, VA_MIDDLE, VA_BOTTOM, VA_DEFAULT:
+		return true
+	}
+	return false
+}
+
 // HasHVAlign interfaces defines a horizontal and a vertical
 // alignment property.
 type HasHVAlign interface {
@@ -340,14 +542,14 @@ type TableView interface {
 	CellSpacing() int
 
 	// SetCellSpacing sets the cell spacing.
-	// Has no effect if layout is LAYOUT_NATURAL.
+	// Has no effect if layout is LAYOUT_NATURAL, a flex or the grid layout.
 	SetCellSpacing(spacing int)
 
 	// CellPadding returns the cell spacing.
 	CellPadding() int
 
 	// SetCellPadding sets the cell padding.
-	// Has no effect if layout is LAYOUT_NATURAL.
+	// Has no effect if layout is LAYOUT_NATURAL, a flex or the grid layout.
 	SetCellPadding(padding int)
 }
 
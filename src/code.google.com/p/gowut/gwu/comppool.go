@@ -0,0 +1,149 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Component pool to recycle components of frequently refreshed views.
+
+package gwu
+
+// Default max size of CompPool.
+const DEFAULT_COMP_POOL_SIZE = 1000
+
+// CompPool interface defines a pool of recyclable components, to reuse
+// the components generated row-by-row in frequently refreshed data views
+// (e.g. the cells of a PagedTable) instead of creating new ones on each
+// refresh, reducing allocations and GC pressure.
+//
+// Components are obtained by Get() specifying their kind (e.g. "price-label"),
+// and are released to the pool by Put() when no longer displayed.
+// Components of the same kind must be interchangeable: a recycled component
+// retains its state (e.g. style, event handlers), so set the values
+// which differ in each row after Get(), and register event handlers
+// only when the component is created.
+//
+// A CompPool belongs to the session its components belong to,
+// and is not safe for concurrent use (just like the components).
+//
+// Example:
+//
+//	pool := gwu.NewCompPool()
+//	pagedTable.SetCompPool(pool)
+//	pagedTable.SetRowProvider(func(from, count int) [][]gwu.Comp {
+//		rows := make([][]gwu.Comp, count)
+//		for i := range rows {
+//			l := pool.Get("name", func() gwu.Comp { return gwu.NewLabel("") }).(gwu.Label)
+//			l.SetText(names[from+i])
+//			rows[i] = []gwu.Comp{l}
+//		}
+//		return rows
+//	}, len(names))
+type CompPool interface {
+	// Get returns a released component of the specified kind,
+	// or creates a new one with the specified function
+	// if the pool has no released component of the kind.
+	Get(kind string, create func() Comp) Comp
+
+	// Put releases the specified components to the pool.
+	// Components still having a parent are removed from their parent.
+	// Components not obtained from the pool (by Get()) are ignored.
+	// If the pool is full, released components are discarded.
+	Put(comps ...Comp)
+
+	// Size returns the number of released components in the pool.
+	Size() int
+
+	// MaxSize returns the max number of released components kept in the pool.
+	MaxSize() int
+
+	// SetMaxSize sets the max number of released components kept in the pool.
+	// Pass 0 to not limit the size.
+	// Default is DEFAULT_COMP_POOL_SIZE.
+	SetMaxSize(maxSize int)
+}
+
+// CompPool implementation.
+type compPoolImpl struct {
+	free    map[string][]Comp // Released components mapped from their kinds
+	kinds   map[ID]string     // Kinds of the components in use (obtained by Get()), mapped from their ids
+	size    int               // Number of released components
+	maxSize int               // Max number of released components
+}
+
+// NewCompPool creates a new CompPool.
+func NewCompPool() CompPool {
+	return &compPoolImpl{free: make(map[string][]Comp), kinds: make(map[ID]string), maxSize: DEFAULT_COMP_POOL_SIZE}
+}
+
+func (p *compPoolImpl) Get(kind string, create func() Comp) Comp {
+	var c Comp
+	if free := p.free[kind]; len(free) > 0 {
+		c = free[len(free)-1]
+		free[len(free)-1] = nil
+		p.free[kind] = free[:len(free)-1]
+		p.size--
+	} else {
+		c = create()
+	}
+	p.kinds[c.Id()] = kind
+	return c
+}
+
+func (p *compPoolImpl) Put(comps ...Comp) {
+	for _, c := range comps {
+		if c == nil {
+			continue
+		}
+		kind, ok := p.kinds[c.Id()]
+		if !ok {
+			continue
+		}
+		delete(p.kinds, c.Id())
+		if parent := c.Parent(); parent != nil {
+			parent.Remove(c)
+		}
+		if p.maxSize > 0 && p.size >= p.maxSize {
+			continue
+		}
+		p.free[kind] = append(p.free[kind], c)
+		p.size++
+	}
+}
+
+func (p *compPoolImpl) Size() int {
+	return p.size
+}
+
+func (p *compPoolImpl) MaxSize() int {
+	return p.maxSize
+}
+
+func (p *compPoolImpl) SetMaxSize(maxSize int) {
+	p.maxSize = maxSize
+
+	// Discard released components over the new max size
+	for kind, free := range p.free {
+		if maxSize <= 0 || p.size <= maxSize {
+			break
+		}
+		n := p.size - maxSize
+		if n > len(free) {
+			n = len(free)
+		}
+		for i := len(free) - n; i < len(free); i++ {
+			free[i] = nil
+		}
+		p.free[kind] = free[:len(free)-n]
+		p.size -= n
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// ComputedLabel component interface and implementation.
+
+package gwu
+
+// ComputeFunc is a function type which computes the text of a ComputedLabel.
+type ComputeFunc func() string
+
+// ComputedLabel interface defines a label whose text is computed
+// by a function over watched components, e.g. the live total of an order form.
+//
+// The text is recomputed (and the label is marked dirty if the text changed)
+// whenever a watched component synchronizes a new value, on the event types
+// returned by its SyncOnETypes().
+//
+// Default style classes: "gwu-Label", "gwu-ComputedLabel"
+type ComputedLabel interface {
+	// ComputedLabel is a Label.
+	Label
+
+	// Watch adds components to be watched.
+	Watch(comps ...Comp)
+
+	// Recompute recomputes the text of the label.
+	// Return value indicates if the text has changed.
+	// The label is not marked dirty by this method.
+	Recompute() bool
+}
+
+// ComputedLabel implementation.
+type computedLabelImpl struct {
+	labelImpl // Label implementation
+
+	compute ComputeFunc // Function computing the text
+}
+
+// NewComputedLabel creates a new ComputedLabel which computes its text
+// with the specified function, and watches the specified components.
+func NewComputedLabel(compute ComputeFunc, watched ...Comp) ComputedLabel {
+	c := &computedLabelImpl{labelImpl: labelImpl{compImpl: newCompImpl(nil), hasSafeTextImpl: newHasSafeTextImpl("")}, compute: compute}
+	c.Style().AddClass("gwu-Label").AddClass("gwu-ComputedLabel")
+	c.Recompute()
+	c.Watch(watched...)
+	return c
+}
+
+func (c *computedLabelImpl) Watch(comps ...Comp) {
+	for _, comp := range comps {
+		comp.AddEHandlerFunc(func(e Event) {
+			if c.Recompute() {
+				e.MarkDirty(c)
+			}
+		}, comp.SyncOnETypes()...)
+	}
+}
+
+func (c *computedLabelImpl) Recompute() bool {
+	text := c.compute()
+	if text == c.text {
+		return false
+	}
+	c.SetText(text)
+	return true
+}
@@ -0,0 +1,243 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Show-when bindings: binding the visibility or enabled state
+// of components to conditions over the values of other components.
+
+package gwu
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Condition is a predicate over the values of components.
+//
+// Conditions are evaluated on the client side whenever an input changes
+// so bound components are shown / enabled instantly, and they are
+// also evaluated on the server side (using the synchronized values)
+// when bound components are rendered and when events are received from them.
+//
+// Conditions can be created with the Checked(), ValueIn(), Empty(),
+// Not(), And() and Or() functions.
+type Condition interface {
+	// Eval evaluates the condition on the server side.
+	Eval() bool
+
+	// jsonValue returns the value of the condition to be encoded
+	// for the client side.
+	jsonValue() []interface{}
+}
+
+// Condition operators.
+const (
+	_COND_CHECKED = "checked" // State button is checked
+	_COND_IN      = "in"      // Value is one of the listed values
+	_COND_EMPTY   = "empty"   // Value is empty
+	_COND_NOT     = "not"     // Negation of a condition
+	_COND_AND     = "and"     // All the conditions hold
+	_COND_OR      = "or"      // Any of the conditions hold
+)
+
+// condImpl is the Condition implementation.
+type condImpl struct {
+	op     string      // Operator
+	comp   Comp        // Component whose value is tested
+	values []string    // Values to test against (of _COND_IN)
+	conds  []Condition // Operand conditions (of logical operators)
+}
+
+// Checked returns a condition which holds if the specified state button
+// (e.g. CheckBox or RadioButton) is checked.
+func Checked(sb StateButton) Condition {
+	return &condImpl{op: _COND_CHECKED, comp: sb}
+}
+
+// ValueIn returns a condition which holds if the value of the specified
+// component is one of the specified values.
+//
+// Supported components are TextBox, PasswBox, ListBox (its first selected value
+// is tested), Slider, NumberBox and state buttons ("true" or "false").
+func ValueIn(c Comp, values ...string) Condition {
+	return &condImpl{op: _COND_IN, comp: c, values: values}
+}
+
+// Empty returns a condition which holds if the value of the specified
+// component is empty. Supported components are the same as of ValueIn().
+func Empty(c Comp) Condition {
+	return &condImpl{op: _COND_EMPTY, comp: c}
+}
+
+// Not returns a condition which holds if the specified condition does not.
+func Not(cond Condition) Condition {
+	return &condImpl{op: _COND_NOT, conds: []Condition{cond}}
+}
+
+// And returns a condition which holds if all the specified conditions hold.
+func And(conds ...Condition) Condition {
+	return &condImpl{op: _COND_AND, conds: conds}
+}
+
+// Or returns a condition which holds if any of the specified conditions hold.
+func Or(conds ...Condition) Condition {
+	return &condImpl{op: _COND_OR, conds: conds}
+}
+
+func (c *condImpl) Eval() bool {
+	switch c.op {
+	case _COND_CHECKED:
+		return c.comp.(StateButton).State()
+	case _COND_IN:
+		v := bindingValue(c.comp)
+		for _, value := range c.values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	case _COND_EMPTY:
+		return bindingValue(c.comp) == ""
+	case _COND_NOT:
+		return !c.conds[0].Eval()
+	case _COND_AND:
+		for _, cond := range c.conds {
+			if !cond.Eval() {
+				return false
+			}
+		}
+		return true
+	case _COND_OR:
+		for _, cond := range c.conds {
+			if cond.Eval() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *condImpl) jsonValue() []interface{} {
+	// Encoded as an array: [op, compId, values...] or [op, conds...]
+	v := []interface{}{c.op}
+	if c.comp != nil {
+		v = append(v, int(c.comp.Id()))
+		for _, value := range c.values {
+			v = append(v, value)
+		}
+	}
+	for _, cond := range c.conds {
+		v = append(v, cond.jsonValue())
+	}
+	return v
+}
+
+// bindingValue returns the value of a component as it is tested by conditions.
+// Empty string is returned for components not having a value.
+func bindingValue(c Comp) string {
+	switch c2 := c.(type) {
+	case TextBox:
+		return c2.Text()
+	case ListBox:
+		return c2.SelectedValue()
+	case StateButton:
+		return strconv.FormatBool(c2.State())
+	case Slider:
+		return strconv.FormatFloat(c2.Value(), 'f', -1, 64)
+	case NumberBox:
+		return strconv.FormatFloat(c2.Value(), 'f', -1, 64)
+	}
+	return ""
+}
+
+// ShowWhen binds the visibility of the target component to the specified condition:
+// the component is only visible while the condition holds.
+// Pass nil to remove the binding.
+//
+// The condition is evaluated on the client side when inputs change, and is verified
+// on the server side: events of a hidden target component are rejected.
+// The source components must synchronize their values (inputs do so by default),
+// and the target must not be hidden by other means (e.g. SetVisible() or roles),
+// else the binding is not applied on the client side.
+func ShowWhen(target Comp, cond Condition) {
+	target.setBinding(true, cond)
+}
+
+// EnableWhen binds the enabled state of the target component to the specified condition:
+// the component is only enabled while the condition holds.
+// Pass nil to remove the binding.
+//
+// The condition is evaluated on the client side when inputs change, and is verified
+// on the server side: events of a disabled target component are rejected.
+// The same rules apply as for ShowWhen().
+func EnableWhen(target Comp, cond Condition) {
+	target.setBinding(false, cond)
+}
+
+func (c *compImpl) setBinding(show bool, cond Condition) {
+	if show {
+		c.showWhen = cond
+	} else {
+		c.enableWhen = cond
+	}
+}
+
+// bindingAccess returns the access to the component based on its bindings.
+func (c *compImpl) bindingAccess() Access {
+	if c.showWhen != nil && !c.showWhen.Eval() {
+		return ACCESS_HIDDEN
+	}
+	if c.enableWhen != nil && !c.enableWhen.Eval() {
+		return ACCESS_DISABLED
+	}
+	return ACCESS_FULL
+}
+
+var (
+	_STR_BIND_SHOW   = []byte(` data-gwu-show="`)   // ` data-gwu-show="`
+	_STR_BIND_ENABLE = []byte(` data-gwu-enable="`) // ` data-gwu-enable="`
+)
+
+// renderBindings renders the attributes of the bindings which are
+// evaluated on the client side.
+// Nothing is rendered if the component is hidden or disabled by other means,
+// so the client side does not override those.
+func (c *compImpl) renderBindings(w writer) {
+	if c.showWhen == nil && c.enableWhen == nil {
+		return
+	}
+	if c.visibility != VISIBLE || c.sessAccess(w.sess) != ACCESS_FULL || c.inReadOnly() {
+		return
+	}
+
+	if c.showWhen != nil {
+		w.Write(_STR_BIND_SHOW)
+		renderCondition(w, c.showWhen)
+		w.Write(_STR_QUOTE)
+	}
+	if c.enableWhen != nil {
+		w.Write(_STR_BIND_ENABLE)
+		renderCondition(w, c.enableWhen)
+		w.Write(_STR_QUOTE)
+	}
+}
+
+// renderCondition renders the HTML escaped JSON encoding of a condition.
+func renderCondition(w writer, cond Condition) {
+	data, err := json.Marshal(cond.jsonValue())
+	if err != nil {
+		return
+	}
+	w.Writees(string(data))
+}
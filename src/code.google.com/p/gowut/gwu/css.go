@@ -21,6 +21,7 @@ package gwu
 const (
 	THEME_DEFAULT = "default" // Default CSS theme
 	THEME_DEBUG   = "debug"   // Debug CSS theme, useful for developing/debugging purposes. 
+	THEME_DARK    = "dark"    // Dark CSS theme (dark mode)
 )
 
 // resNameStaticCss returns the CSS resource name
@@ -42,14 +43,49 @@ func init() {
 
 body {font-family:Arial}
 
+[hidden] {display:none !important}
+[data-gwu-invisible] {visibility:hidden !important}
+[aria-disabled="true"] {pointer-events:none; opacity:0.5}
+[aria-readonly="true"] {pointer-events:none}
+
 .gwu-Window {}
 
 .gwu-Panel {}
 
 .gwu-Table {}
+.gwu-Table-Sortable {cursor:pointer; white-space:nowrap}
+.gwu-Table-SortInd {display:inline-block; min-width:12px; padding-left:3px; font-size:80%}
+.gwu-Table-Editor {box-sizing:border-box; width:100%; margin:0}
+.gwu-Table-SelRow {cursor:pointer}
+.gwu-Table-SelRow:hover {background:#f0f0ff}
+.gwu-Table-Selected, .gwu-Table-Selected:hover {background:#d0d8ff}
 
 .gwu-Label {}
 
+.gwu-ComputedLabel {}
+
+.gwu-Shared {}
+
+.gwu-Panel-FlexRow {display:flex; flex-direction:row}
+.gwu-Panel-FlexColumn {display:flex; flex-direction:column}
+.gwu-Panel-FlexRow > div, .gwu-Panel-FlexColumn > div {min-width:0px; min-height:0px}
+.gwu-Panel-Grid {display:grid}
+.gwu-Panel-Grid > div {min-width:0px; min-height:0px}
+
+.gwu-SplitPanel {display:flex; overflow:hidden}
+.gwu-SplitPanel-Horizontal {flex-direction:row}
+.gwu-SplitPanel-Vertical {flex-direction:column}
+.gwu-SplitPanel-First {flex-grow:0; flex-shrink:0; overflow:auto}
+.gwu-SplitPanel-Second {flex:1 1 0px; overflow:auto}
+.gwu-SplitPanel-Splitter {flex:0 0 5px; background:#d0d0f8; touch-action:none}
+.gwu-SplitPanel-Horizontal > .gwu-SplitPanel-Splitter {cursor:col-resize}
+.gwu-SplitPanel-Vertical > .gwu-SplitPanel-Splitter {cursor:row-resize}
+.gwu-SplitPanel-Splitter:hover {background:#8080f8}
+
+.gwu-PresenceList {list-style:none; margin:0px; padding:0px}
+.gwu-PresenceList-User {padding:2px 4px 2px 4px}
+.gwu-PresenceList-User:before {content:"\25CF"; color:#40b040; padding-right:5px}
+
 .gwu-Link {}
 
 .gwu-Image {}
@@ -62,14 +98,66 @@ body {font-family:Arial}
 .gwu-RadioButton {}
 .gwu-RadioButton-Disabled {color:#888}
 
+.gwu-Slider {vertical-align:middle}
+
+.gwu-NumberBox {display:inline-flex; white-space:nowrap}
+.gwu-NumberBox-Input {width:80px; text-align:right}
+.gwu-NumberBox-Down, .gwu-NumberBox-Up {min-width:24px; padding:0px 4px}
+
 .gwu-ListBox {}
+.gwu-ListBox-Icon {padding-left:20px; background-repeat:no-repeat; background-position:2px center; background-size:16px 16px}
 
 .gwu-TextBox {}
 
 .gwu-PasswBox {}
+.gwu-Invalid {border-color:#e00000; background:#fff0f0}
+
+.gwu-RichTextBox {display:inline-block; border:1px solid #8080f8; min-width:300px}
+.gwu-RichTextBox-Toolbar {padding:2px; border-bottom:1px solid #8080f8; background:#f0f0ff}
+.gwu-RichTextBox-Toolbar button {min-width:28px; margin-right:2px}
+.gwu-RichTextBox-Editor {min-height:100px; padding:4px; outline:none; overflow-y:auto}
+
+.gwu-MenuBar {display:flex; background:#f0f0ff; border-bottom:1px solid #8080f8; user-select:none}
+.gwu-Menu {position:relative}
+.gwu-Menu-Label {display:block; padding:4px 10px 4px 10px; cursor:default; white-space:nowrap}
+.gwu-Menu-Label:focus, .gwu-MenuItem:focus {outline:none}
+.gwu-Menu-Label:hover, .gwu-Menu-Label:focus, .gwu-Menu-Open > .gwu-Menu-Label, .gwu-MenuItem:hover, .gwu-MenuItem:focus {background:#c0c0ff}
+.gwu-Menu-Disabled > .gwu-Menu-Label, .gwu-MenuItem-Disabled {color:#888}
+.gwu-Menu-Popup {display:none; position:absolute; left:0px; top:100%; z-index:20; min-width:160px; padding:2px 0px 2px 0px; background:white; border:1px solid #8080f8; box-shadow:2px 2px 4px rgba(0,0,0,0.2)}
+.gwu-Menu-Popup .gwu-Menu-Popup, .gwu-PopupMenu .gwu-Menu-Popup {left:100%; top:-3px}
+.gwu-Menu-Open > .gwu-Menu-Popup {display:block}
+.gwu-Menu-Popup .gwu-Menu > .gwu-Menu-Label:after, .gwu-PopupMenu .gwu-Menu > .gwu-Menu-Label:after {content:"\25B8"; float:right; padding-left:12px}
+.gwu-Menu-Icon {width:16px; height:16px; vertical-align:middle; margin-right:6px}
+.gwu-Menu-Separator {height:1px; margin:2px 0px 2px 0px; background:#c0c0c0}
+.gwu-MenuItem {padding:4px 10px 4px 10px; cursor:default; white-space:nowrap}
+.gwu-PopupMenu {display:none; position:fixed; z-index:30; min-width:160px; padding:2px 0px 2px 0px; background:white; border:1px solid #8080f8; box-shadow:2px 2px 4px rgba(0,0,0,0.2); user-select:none}
+.gwu-PopupMenu-Open {display:block}
+
+.gwu-ComboBox {display:inline-block; position:relative; white-space:nowrap}
+.gwu-ComboBox-Button {padding:0px 4px 0px 4px; margin-left:1px}
+.gwu-ComboBox-List {position:absolute; left:0px; top:100%; z-index:10; min-width:100%; max-height:200px; overflow-y:auto; background:white; border:1px solid #8080f8}
+.gwu-ComboBox-Item {padding:2px 4px 2px 4px; cursor:default}
+.gwu-ComboBox-Item:hover {background:#e0e0ff}
+.gwu-ComboBox-Item-Active {background:#c0c0ff}
+
+.gwu-FileUpload {}
+
+.gwu-DateBox {}
+
+.gwu-MediaCapture {}
+.gwu-MediaCapture-Preview {display:block; max-width:320px}
+
+.gwu-SignaturePad {}
+.gwu-SignaturePad-Canvas {display:block; border:1px solid #808080; background:#ffffff; touch-action:none}
+.gwu-SignaturePad-Clear {}
+
+.gwu-Scanner {}
+.gwu-Scanner-Preview {display:block; max-width:320px}
 
 .gwu-Html {}
 
+.gwu-Router {}
+
 .gwu-SwitchButton {}
 .gwu-SwitchButton-On-Active {background:#00a000; color:#d0ffd0}
 .gwu-SwitchButton-Off-Active {background:#d03030; color:#ffd0d0}
@@ -82,6 +170,172 @@ body {font-family:Arial}
 .gwu-Expander-Header, .gwu-Expander-Header-Expanded {padding-left:19px; cursor:pointer}
 .gwu-Expander-Content {padding-left:19px}
 
+.gwu-Tree {}
+.gwu-Tree-Toggle {display:inline-block; width:16px; height:16px; vertical-align:middle}
+.gwu-Tree-Toggle.gwuimg-collapsed, .gwu-Tree-Toggle.gwuimg-expanded {cursor:pointer}
+.gwu-Tree-Label {padding:0px 2px 0px 2px; cursor:pointer}
+.gwu-Tree-Label-Selected {background:#8080f8; color:white}
+.gwu-Tree-Children {padding-left:16px}
+
+.gwu-DialogLayer {}
+.gwu-Dialog-Overlay {position:fixed; top:0px; left:0px; width:100%; height:100%; background:rgba(0,0,0,0.4); z-index:100}
+.gwu-Dialog {position:fixed; top:50%; left:50%; transform:translate(-50%,-50%); background:#ffffff; border:2px solid #8080f8; z-index:101}
+.gwu-Dialog-Title {padding:3px 5px 3px 5px; background:#8080f8; color:white; font-weight:bold}
+.gwu-Dialog-Content {padding:5px}
+.gwu-Dialog-Buttons {margin:0px 5px 5px auto}
+
+.gwu-Board {display:flex; align-items:flex-start}
+.gwu-Board-Column {min-width:200px; margin:0px 5px 0px 5px; background:#e8e8ff; border:1px solid #8080f8}
+.gwu-Board-Column-Title {padding:3px 5px 3px 5px; background:#8080f8; color:white; font-weight:bold}
+.gwu-Board-Cards {min-height:50px; padding:3px}
+.gwu-Board-Card {margin:3px 0px 3px 0px; padding:5px; background:#ffffff; border:1px solid #c0c0c0; cursor:move}
+
+.gwu-MessageList {position:relative}
+.gwu-MessageList-Messages {height:300px; overflow-y:auto}
+.gwu-MessageList-Message {display:flex; align-items:flex-start; margin:3px}
+.gwu-MessageList-Own {flex-direction:row-reverse}
+.gwu-MessageList-Avatar {width:32px; height:32px; border-radius:16px; margin:0px 5px 0px 5px}
+.gwu-MessageList-Body {padding:3px 6px 3px 6px; border-radius:5px; background:#e8e8ff}
+.gwu-MessageList-Own .gwu-MessageList-Body {background:#d0ffd0}
+.gwu-MessageList-Author {font-weight:bold}
+.gwu-MessageList-Time {padding-left:8px; color:#808080; font-size:80%}
+.gwu-MessageList-Text {white-space:pre-wrap}
+.gwu-MessageList-NewMessages {position:absolute; bottom:5px; left:50%; transform:translateX(-50%); padding:2px 8px 2px 8px; border-radius:8px; background:#8080f8; color:white; cursor:pointer}
+.gwu-LogView {}
+.gwu-LogView-Filter {margin-bottom:3px}
+.gwu-LogView-Lines {height:300px; overflow-y:auto; font-family:monospace; background:#f8f8f8; border:1px solid #c0c0c0}
+.gwu-LogView-Line {white-space:pre-wrap}
+.gwu-LogView-Debug {color:#808080}
+.gwu-LogView-Info {color:#000080}
+.gwu-LogView-Warn {color:#c07000}
+.gwu-LogView-Error {color:#c00000; font-weight:bold}
+.gwu-Terminal {outline:none}
+.gwu-Terminal-Output {height:300px; margin:0px; padding:3px; overflow-y:auto; white-space:pre-wrap; word-break:break-all; font-family:monospace; background:#202020; color:#e0e0e0}
+.gwu-Terminal:focus .gwu-Terminal-Output {box-shadow:0px 0px 3px 1px #8080f8}
+.gwu-DiffView {font-family:monospace; border:1px solid #c0c0c0}
+.gwu-DiffView-Header th {text-align:left; padding:2px 5px 2px 5px; background:#e0e0e0}
+.gwu-DiffView-Num {padding:0px 5px 0px 5px; text-align:right; color:#808080; background:#f0f0f0}
+.gwu-DiffView-Line {padding:0px 5px 0px 5px; white-space:pre-wrap}
+.gwu-DiffView-Del {background:#ffe0e0}
+.gwu-DiffView-Add {background:#e0ffe0}
+.gwu-DiffView-Empty {background:#f0f0f0}
+.gwu-DiffView-Skip {text-align:center; color:#808080; background:#f0f0ff}
+.gwu-JsonView {font-family:monospace}
+.gwu-JsonView-Search {margin-bottom:3px}
+.gwu-JsonView-Node {padding-left:16px; white-space:nowrap}
+.gwu-JsonView-Toggle {display:inline-block; width:16px; height:16px; margin-left:-16px; vertical-align:middle; cursor:pointer}
+.gwu-JsonView-Key {color:#800080; cursor:pointer}
+.gwu-JsonView-Summary {color:#808080}
+.gwu-JsonView-String {color:#008000}
+.gwu-JsonView-Number {color:#0000c0}
+.gwu-JsonView-Bool {color:#c06000}
+.gwu-JsonView-Null {color:#808080}
+.gwu-JsonView-Match {background:#ffff80}
+.gwu-EmptyState {padding:24px; text-align:center; color:#606060}
+.gwu-EmptyState-Icon {width:64px; height:64px; margin-bottom:8px; opacity:0.6}
+.gwu-EmptyState-Title {font-size:120%; font-weight:bold; color:#404040}
+.gwu-EmptyState-Description {padding-top:4px}
+.gwu-EmptyState-Action {padding-top:12px}
+
+.gwu-Graph {height:400px; border:1px solid #c0c0c0; overflow:hidden}
+.gwu-Graph-Svg {display:block; width:100%; height:100%; cursor:move}
+.gwu-Graph-Edge {fill:none; stroke:#808080; stroke-width:1.5px}
+.gwu-Graph-EdgeText {font-size:11px; text-anchor:middle; fill:#404040; stroke:white; stroke-width:3px; paint-order:stroke}
+.gwu-Graph-Node {cursor:pointer}
+.gwu-Graph-Node rect {fill:#e0e0ff; stroke:#8080f8; stroke-width:1.5px}
+.gwu-Graph-Node:hover rect {fill:#c0c0ff}
+.gwu-Graph-Node text {font-size:12px; text-anchor:middle; dominant-baseline:central}
+
+.gwu-HeatmapCalendar {font-size:10px; color:#606060}
+.gwu-HeatmapCalendar-Month {height:12px; white-space:nowrap; overflow:visible}
+.gwu-HeatmapCalendar-Weekday {padding-right:4px}
+.gwu-HeatmapCalendar-Day {width:11px; height:11px; border-radius:2px; cursor:pointer}
+.gwu-HeatmapCalendar-Legend {text-align:right; padding:2px}
+.gwu-HeatmapCalendar-Legend .gwu-HeatmapCalendar-Day {display:inline-block; margin:0px 1px 0px 1px; vertical-align:middle; cursor:default}
+
+.gwu-PagedTable {}
+.gwu-PagedTable-Pager {padding:2px; white-space:nowrap}
+.gwu-PagedTable-Pager button {min-width:24px; margin:0px 2px 0px 2px}
+.gwu-PagedTable-Info {padding:0px 6px 0px 6px}
+
+.gwu-PdfView {}
+.gwu-PdfView-Controls {padding:2px}
+.gwu-PdfView-Controls button {min-width:24px; margin:0px 2px 0px 2px}
+.gwu-PdfView-Page {text-align:right}
+.gwu-PdfView-Zoom {display:inline-block; min-width:40px; text-align:center}
+.gwu-PdfView-Doc {display:block; width:100%; height:500px; border:1px solid #c0c0c0}
+
+.gwu-Tour {}
+.gwu-Tour-Highlight {position:fixed; z-index:1000; border-radius:4px; box-shadow:0px 0px 0px 9999px rgba(0,0,0,0.5); pointer-events:none}
+.gwu-Tour-Popup {position:fixed; z-index:1001; max-width:300px; padding:8px; background:white; border:1px solid #8080f8; border-radius:4px; box-shadow:2px 2px 8px rgba(0,0,0,0.3)}
+.gwu-Tour-Text {padding-bottom:8px}
+.gwu-Tour-Buttons {text-align:right; white-space:nowrap}
+.gwu-Tour-Buttons button {margin-left:4px}
+.gwu-Tour-Progress {float:left; color:#808080}
+
+.gwu-CommandPalette {display:none; position:fixed; top:0px; left:0px; width:100%; height:100%; z-index:1000; background:rgba(0,0,0,0.3)}
+.gwu-CommandPalette-Open {display:block}
+.gwu-CommandPalette-Box {width:500px; max-width:90%; margin:80px auto 0px auto; background:white; border:1px solid #8080f8; border-radius:4px; box-shadow:2px 2px 12px rgba(0,0,0,0.3)}
+.gwu-CommandPalette-Input {box-sizing:border-box; width:100%; padding:8px; font-size:110%; border:none; border-bottom:1px solid #c0c0c0; outline:none}
+.gwu-CommandPalette-List {max-height:300px; overflow-y:auto}
+.gwu-CommandPalette-Item {padding:5px 8px 5px 8px; cursor:pointer}
+.gwu-CommandPalette-Hint {float:right; padding-left:10px; color:#808080; font-size:90%}
+.gwu-CommandPalette-Selected {background:#c0c0ff}
+
+.gwu-ShutdownOverlay {position:fixed; top:0px; left:0px; width:100%; height:100%; z-index:10001; background:rgba(0,0,0,0.4)}
+.gwu-ShutdownOverlay-Text {position:absolute; top:40%; left:50%; transform:translate(-50%,-50%); padding:12px 20px 12px 20px; background:white; border:1px solid #8080f8; border-radius:4px; font-weight:bold}
+.gwu-IdleWarning {position:fixed; top:0px; left:0px; width:100%; height:100%; z-index:10000; background:rgba(0,0,0,0.4)}
+.gwu-IdleWarning-Box {position:absolute; top:40%; left:50%; transform:translate(-50%,-50%); padding:16px 24px 16px 24px; background:white; border:1px solid #8080f8; border-radius:4px; text-align:center}
+.gwu-IdleWarning-Countdown {margin:10px 0px 12px 0px; font-size:200%; font-weight:bold}
+
+.gwu-LoginWindow {}
+.gwu-LoginWindow-Form {padding:16px 24px 16px 24px; background:#f8f8ff; border:1px solid #8080f8; border-radius:4px}
+.gwu-LoginWindow-Title {font-weight:bold; font-size:150%}
+.gwu-LoginWindow-Error {color:red}
+.gwu-LoginWindow-Remember {}
+.gwu-LoginWindow-Button {min-width:80px}
+
+.gwu-SideNav {display:block; position:sticky; top:0px; box-sizing:border-box; width:220px; height:100vh; overflow-y:auto; background:#f0f0f8; border-right:1px solid #c0c0c0}
+.gwu-SideNav-Collapsed {width:48px; overflow-x:hidden}
+.gwu-SideNav-Toggle {padding:6px 14px 6px 14px; font-size:120%; cursor:pointer}
+.gwu-SideNav-List {list-style:none; margin:0px; padding:0px}
+.gwu-SideNav-List .gwu-SideNav-List {display:none; padding-left:16px}
+.gwu-SideNav-Expanded > .gwu-SideNav-List {display:block}
+.gwu-SideNav-Collapsed .gwu-SideNav-List .gwu-SideNav-List {padding-left:0px}
+.gwu-SideNav-Item > a {display:block; padding:6px 10px 6px 14px; color:inherit; text-decoration:none; white-space:nowrap}
+.gwu-SideNav-Item > a:hover {background:#e0e0f0}
+.gwu-SideNav-Section > a:after {content:"\25B8"; float:right}
+.gwu-SideNav-Expanded > a:after {content:"\25BE"}
+.gwu-SideNav-Active > a {background:#c0c0ff; font-weight:bold}
+.gwu-SideNav-Icon {display:inline-block; width:20px; text-align:center}
+.gwu-SideNav-Text {padding-left:8px}
+.gwu-SideNav-Collapsed .gwu-SideNav-Text, .gwu-SideNav-Collapsed .gwu-SideNav-Section > a:after {display:none}
+
+.gwu-Skeleton {}
+.gwu-Skeleton-Line, .gwu-Skeleton-Block, .gwu-Skeleton-Avatar {background:linear-gradient(90deg, #e8e8e8 25%, #f5f5f5 50%, #e8e8e8 75%); background-size:200% 100%; animation:gwu-Skeleton-Shimmer 1.5s infinite linear}
+.gwu-Skeleton-Line {height:12px; margin:6px 0px 6px 0px; border-radius:3px}
+.gwu-Skeleton-Line:last-child {width:60%}
+.gwu-Skeleton-Block {width:100%; height:100px; border-radius:4px}
+.gwu-Skeleton-Avatar {width:40px; height:40px; border-radius:50%}
+@keyframes gwu-Skeleton-Shimmer {from {background-position:100% 0%} to {background-position:-100% 0%}}
+
+.gwu-ProgressBar {position:relative; height:18px; overflow:hidden; background:#e8e8e8; border:1px solid #c0c0c0; border-radius:3px}
+.gwu-ProgressBar-Bar {height:100%; background:#4a90d9; transition:width 0.3s}
+.gwu-ProgressBar-Text {position:absolute; top:0px; left:0px; width:100%; line-height:18px; text-align:center; font-size:80%}
+.gwu-ProgressBar-Indeterminate {position:absolute; top:0px; width:30%; height:100%; background:#4a90d9; animation:gwu-ProgressBar-Slide 1.2s ease-in-out infinite}
+@keyframes gwu-ProgressBar-Slide {from {left:-30%} to {left:100%}}
+
+.gwu-ErrorBoundary {}
+.gwu-ErrorBoundary-Error {padding:8px; color:#a00000; background:#fff0f0; border:1px solid #f0c0c0; border-radius:4px}
+.gwu-ErrorBoundary-Text {padding-bottom:6px}
+.gwu-ErrorBoundary-Retry {}
+
+.gwu-BusyBar {display:none; position:fixed; top:0px; left:0px; width:100%; height:3px; z-index:10000; background:linear-gradient(90deg, transparent 0%, #4a90d9 50%, transparent 100%); background-size:50% 100%; background-repeat:no-repeat; animation:gwu-BusyBar-Slide 1s linear infinite}
+@keyframes gwu-BusyBar-Slide {from {background-position:-100% 0%} to {background-position:200% 0%}}
+.gwu-Busy {cursor:progress; opacity:0.7}
+.gwu-Busy::after {content:""; display:inline-block; width:8px; height:8px; margin-left:4px; vertical-align:middle; border:2px solid #4a90d9; border-top-color:transparent; border-radius:50%; animation:gwu-Busy-Spin 0.8s linear infinite}
+@keyframes gwu-Busy-Spin {to {transform:rotate(360deg)}}
+
 .gwu-TabBar {}
 .gwu-TabBar-Top {padding:0px 5px 0px 5px; border-bottom:5px solid #8080f8}
 .gwu-TabBar-Bottom {padding:0px 5px 0px 5px; border-top:5px solid #8080f8}
@@ -91,10 +345,45 @@ body {font-family:Arial}
 .gwu-TabBar-Selected    {padding-left:5px; padding-right:5px; border:1px solid #8080f8; background:#8080f8; cursor:default}
 .gwu-TabPanel {}
 .gwu-TabPanel-Content {border:1px solid #8080f8; width:100%; height:100%}
-`)
+` + responsiveCss())
 
 	staticCss[resNameStaticCss(THEME_DEBUG)] = []byte(string(staticCss[resNameStaticCss(THEME_DEFAULT)]) +
 		`
 .gwu-Window td, .gwu-Table td, .gwu-Panel td, .gwu-TabPanel td {border:1px solid black}
 `)
+
+	staticCss[resNameStaticCss(THEME_DARK)] = []byte(string(staticCss[resNameStaticCss(THEME_DEFAULT)]) +
+		`
+body {background:#1e1e24; color:#e0e0e8; color-scheme:dark}
+a, .gwu-Link {color:#90a8ff}
+input, textarea, select, button {background:#2a2a32; color:#e0e0e8; border:1px solid #606078}
+.gwu-Table-SelRow:hover {background:#30304a}
+.gwu-Table-Selected, .gwu-Table-Selected:hover {background:#404070}
+.gwu-Invalid {border-color:#ff6060; background:#402020}
+.gwu-RichTextBox-Toolbar, .gwu-MenuBar {background:#2a2a3a}
+.gwu-Menu-Popup, .gwu-PopupMenu, .gwu-ComboBox-List, .gwu-Dialog, .gwu-Tour-Popup, .gwu-CommandPalette-Box, .gwu-IdleWarning-Box, .gwu-LoginWindow-Form {background:#2a2a32}
+.gwu-CommandPalette-Selected {background:#404070}
+.gwu-SideNav {background:#26263a; border-color:#505060}
+.gwu-SideNav-Item > a:hover {background:#30304a}
+.gwu-SideNav-Active > a {background:#404070}
+.gwu-Menu-Label:hover, .gwu-Menu-Label:focus, .gwu-Menu-Open > .gwu-Menu-Label, .gwu-MenuItem:hover, .gwu-MenuItem:focus {background:#404070}
+.gwu-ComboBox-Item:hover {background:#30304a}
+.gwu-ComboBox-Item-Active {background:#404070}
+.gwu-Board-Column {background:#26263a}
+.gwu-Board-Card {background:#2a2a32; border-color:#505060}
+.gwu-MessageList-Body {background:#30304a}
+.gwu-MessageList-Own .gwu-MessageList-Body {background:#284028}
+.gwu-LogView-Lines {background:#18181c; border-color:#505060}
+.gwu-DiffView-Header th, .gwu-DiffView-Num, .gwu-DiffView-Empty, .gwu-DiffView-Skip {background:#26262e}
+.gwu-DiffView-Del {background:#4a2020}
+.gwu-DiffView-Add {background:#204a20}
+.gwu-JsonView-Match {background:#606020}
+.gwu-ProgressBar {background:#2a2a32; border-color:#505060}
+.gwu-ErrorBoundary-Error {color:#ff9090; background:#402020; border-color:#804040}
+.gwu-TabBar-NotSelected {background:#30304a; border-color:#1e1e24}
+`)
+
+	for _, css := range staticCss {
+		registerCssClasses(css)
+	}
 }
@@ -0,0 +1,245 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Defines the DateBox component.
+
+package gwu
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DateBox interface defines a component for date input purpose.
+// It is rendered as a date input field, browsers display a date picker
+// for it, and display the date in the format of the user's locale.
+// 
+// The date is synchronized with the server on ETYPE_CHANGE event,
+// the parsed date is available by Date() in the event handlers.
+// Invalid dates and dates outside of the min and max bounds sent by
+// the client are rejected (the previous date is restored).
+// 
+// Suggested event type to handle actions: ETYPE_CHANGE
+// 
+// Default style class: "gwu-DateBox"
+type DateBox interface {
+	// DateBox is a component.
+	Comp
+
+	// DateBox can be enabled/disabled.
+	HasEnabled
+
+	// DateBox has a versioned value (date).
+	HasValueVersion
+
+	// Date returns the date.
+	// The zero time is returned if there is no date set
+	// (use the IsZero() method of time.Time to test it).
+	Date() time.Time
+
+	// SetDate sets the date. Only the date part (year, month, day)
+	// is used. Pass the zero time to clear the date.
+	SetDate(date time.Time)
+
+	// Min returns the min date (lower bound).
+	// The zero time is returned if there is no lower bound.
+	Min() time.Time
+
+	// SetMin sets the min date (lower bound).
+	// Pass the zero time to not limit it.
+	SetMin(min time.Time)
+
+	// Max returns the max date (upper bound).
+	// The zero time is returned if there is no upper bound.
+	Max() time.Time
+
+	// SetMax sets the max date (upper bound).
+	// Pass the zero time to not limit it.
+	SetMax(max time.Time)
+
+	// Lang returns the language of the date box.
+	Lang() string
+
+	// SetLang sets the language of the date box (e.g. "en-US", "de")
+	// which is used to format the date (see Text()), and is also
+	// a hint for the browser how to display the date.
+	// Pass an empty string to not specify it, in which case
+	// Text() uses the ISO layout ("2006-01-02").
+	SetLang(lang string)
+
+	// Text returns the date formatted according to the language
+	// of the date box (see DateLayout()).
+	// Empty string is returned if there is no date set.
+	Text() string
+}
+
+// The date layout used to transfer dates between the browser and the server.
+const _DATE_LAYOUT_ISO = "2006-01-02"
+
+// DateLayouts maps languages to date layouts (in the format of the
+// time package) used to format dates.
+// Both full language tags (e.g. "en-GB") and primary languages (e.g. "en")
+// can be mapped. You may register layouts for additional languages.
+var DateLayouts = map[string]string{
+	"en":    "01/02/2006",
+	"en-GB": "02/01/2006",
+	"de":    "02.01.2006",
+	"fr":    "02/01/2006",
+	"es":    "02/01/2006",
+	"it":    "02/01/2006",
+	"hu":    "2006. 01. 02.",
+	"ja":    "2006/01/02",
+	"zh":    "2006/01/02",
+}
+
+// DateLayout returns the date layout for the specified language.
+// The full language tag is looked up first, then the primary language
+// in DateLayouts. If neither is found, the ISO layout ("2006-01-02")
+// is returned.
+func DateLayout(lang string) string {
+	if layout, found := DateLayouts[lang]; found {
+		return layout
+	}
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		if layout, found := DateLayouts[lang[:i]]; found {
+			return layout
+		}
+	}
+	return _DATE_LAYOUT_ISO
+}
+
+// DateBox implementation.
+type dateBoxImpl struct {
+	compImpl       // Component implementation
+	hasEnabledImpl // Has enabled implementation
+
+	date, min, max time.Time // The date, and the date bounds
+}
+
+// NewDateBox creates a new DateBox.
+func NewDateBox(date time.Time) DateBox {
+	c := &dateBoxImpl{compImpl: newInputCompImpl(_STR_ENC_URI_THIS_V), hasEnabledImpl: newHasEnabledImpl()}
+	c.SetDate(date)
+	c.AddSyncOnETypes(ETYPE_CHANGE)
+	c.Style().AddClass("gwu-DateBox")
+	return c
+}
+
+// truncDate returns the date part of the specified time (at UTC midnight).
+func truncDate(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func (c *dateBoxImpl) Date() time.Time {
+	return c.date
+}
+
+func (c *dateBoxImpl) SetDate(date time.Time) {
+	c.date = truncDate(date)
+	c.valueChanged()
+}
+
+func (c *dateBoxImpl) Min() time.Time {
+	return c.min
+}
+
+func (c *dateBoxImpl) SetMin(min time.Time) {
+	c.min = truncDate(min)
+}
+
+func (c *dateBoxImpl) Max() time.Time {
+	return c.max
+}
+
+func (c *dateBoxImpl) SetMax(max time.Time) {
+	c.max = truncDate(max)
+}
+
+func (c *dateBoxImpl) Lang() string {
+	return c.Attr("lang")
+}
+
+func (c *dateBoxImpl) SetLang(lang string) {
+	c.SetAttr("lang", lang)
+}
+
+func (c *dateBoxImpl) Text() string {
+	if c.date.IsZero() {
+		return ""
+	}
+	return c.date.Format(DateLayout(c.Lang()))
+}
+
+// inBounds tells if the specified date is within the date bounds.
+func (c *dateBoxImpl) inBounds(date time.Time) bool {
+	return (c.min.IsZero() || !date.Before(c.min)) && (c.max.IsZero() || !date.After(c.max))
+}
+
+func (c *dateBoxImpl) preprocessEvent(event Event, r *http.Request) {
+	value, present, err := c.clientValue(r)
+	if !present {
+		return
+	}
+	if err == nil && len(value) == 0 {
+		// Empty value clears the date
+		c.date = time.Time{}
+		return
+	}
+
+	var date time.Time
+	if err == nil {
+		date, err = time.Parse(_DATE_LAYOUT_ISO, value)
+	}
+	if err != nil || !c.inBounds(date) {
+		// Reject it, restore the previous date in the browser
+		event.MarkDirty(c)
+		return
+	}
+	c.date = date
+}
+
+var (
+	_STR_DATE_INPUT_OP = []byte(`<input type="date"`) // `<input type="date"`
+	_STR_MIN           = []byte(` min="`)             // ` min="`
+	_STR_MAX           = []byte(` max="`)             // ` max="`
+)
+
+func (c *dateBoxImpl) Render(w writer) {
+	w.Write(_STR_DATE_INPUT_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEnabled(w)
+	c.renderEHandlers(w)
+
+	if !c.min.IsZero() {
+		w.Write(_STR_MIN)
+		w.Writes(c.min.Format(_DATE_LAYOUT_ISO))
+		w.Write(_STR_QUOTE)
+	}
+	if !c.max.IsZero() {
+		w.Write(_STR_MAX)
+		w.Writes(c.max.Format(_DATE_LAYOUT_ISO))
+		w.Write(_STR_QUOTE)
+	}
+	w.Write(_STR_VALUE)
+	if !c.date.IsZero() {
+		w.Writes(c.date.Format(_DATE_LAYOUT_ISO))
+	}
+	w.Write(_STR_INPUT_CL)
+}
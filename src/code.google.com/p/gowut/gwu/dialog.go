@@ -0,0 +1,329 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Dialog component interface and implementation.
+
+package gwu
+
+// Dialog result type.
+type DialogResult int
+
+// Dialog results.
+const (
+	DLG_RESULT_NONE   DialogResult = iota // No result, the dialog was closed by Event.CloseDialog()
+	DLG_RESULT_OK                         // OK button was clicked
+	DLG_RESULT_CANCEL                     // Cancel button was clicked
+	DLG_RESULT_YES                        // Yes button was clicked
+	DLG_RESULT_NO                         // No button was clicked
+
+	DLG_RESULT_CUSTOM DialogResult = 100 // Custom results should start from this value
+)
+
+// Default button texts of the dialog results.
+var dialogResultTexts = map[DialogResult]string{
+	DLG_RESULT_OK:     "OK",
+	DLG_RESULT_CANCEL: "Cancel",
+	DLG_RESULT_YES:    "Yes",
+	DLG_RESULT_NO:     "No",
+}
+
+// DialogResultHandler is a function which is called when a dialog
+// is closed by clicking on one of its buttons.
+type DialogResultHandler func(e Event, result DialogResult)
+
+// Dialog interface defines a popup window which is displayed on top of
+// the content of a Window. A dialog is opened by Event.ShowDialog()
+// and closed by Event.CloseDialog() during event handling.
+// 
+// A dialog has a title, a content component and a button bar.
+// Clicking on a button added by AddButton() closes the dialog,
+// and calls the result handler with the result of the button.
+// A modal dialog covers the window content with an overlay, so the window
+// content cannot be used while the dialog is open.
+// 
+// Default style classes: "gwu-Dialog", "gwu-Dialog-Title", "gwu-Dialog-Content",
+// "gwu-Dialog-Buttons", "gwu-Dialog-Overlay"
+type Dialog interface {
+	// Dialog is a Container.
+	Container
+
+	// A dialog has text which is displayed as its title.
+	HasText
+
+	// Content returns the content component of the dialog.
+	Content() Comp
+
+	// SetContent sets the content component of the dialog.
+	SetContent(c Comp)
+
+	// Modal tells if the dialog is modal.
+	Modal() bool
+
+	// SetModal sets if the dialog is modal.
+	// Dialogs are modal by default.
+	// Changes take effect the next time the dialog is shown.
+	SetModal(modal bool)
+
+	// AddButton adds a new button to the button bar with the specified text.
+	// Clicking on the button closes the dialog with the specified result.
+	// The added button is returned so it can be further customized.
+	AddButton(text string, result DialogResult) Button
+
+	// ButtonBar returns the button bar of the dialog
+	// (a Panel with horizontal layout).
+	ButtonBar() Panel
+
+	// ResultHandler returns the result handler of the dialog.
+	ResultHandler() DialogResultHandler
+
+	// SetResultHandler sets the result handler of the dialog
+	// which is called after the dialog is closed by clicking on one of its buttons.
+	// The dialog can be kept open by showing it again from the handler.
+	SetResultHandler(h DialogResultHandler)
+
+	// Result returns the result the dialog was closed with last time.
+	Result() DialogResult
+
+	// Window returns the window the dialog is shown in.
+	// Returns nil if the dialog is not shown.
+	Window() Window
+}
+
+// Dialog implementation.
+type dialogImpl struct {
+	compImpl    // Component implementation
+	hasTextImpl // Has text implementation
+
+	content   Comp                // Content component
+	buttonBar Panel               // Button bar
+	modal     bool                // Tells if the dialog is modal
+	handler   DialogResultHandler // Result handler
+	result    DialogResult        // Last result
+}
+
+// NewDialog creates a new Dialog with the specified title, and buttons
+// for the specified results (with default texts).
+// Example:
+//     dlg := NewDialog("Delete?", DLG_RESULT_YES, DLG_RESULT_NO)
+func NewDialog(title string, results ...DialogResult) Dialog {
+	c := &dialogImpl{compImpl: newCompImpl(nil), hasTextImpl: newHasTextImpl(title), modal: true}
+	c.Style().AddClass("gwu-Dialog")
+
+	c.buttonBar = NewHorizontalPanel()
+	c.buttonBar.Style().AddClass("gwu-Dialog-Buttons")
+	c.buttonBar.setParent(c)
+
+	for _, result := range results {
+		c.AddButton(dialogResultTexts[result], result)
+	}
+	return c
+}
+
+func (c *dialogImpl) Remove(c2 Comp) bool {
+	if c.content != nil && c.content.Equals(c2) {
+		c2.setParent(nil)
+		c.content = nil
+		return true
+	}
+	return false
+}
+
+func (c *dialogImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	if c.content != nil {
+		if c.content.Id() == id {
+			return c.content
+		}
+		if c2, isContainer := c.content.(Container); isContainer {
+			if c3 := c2.ById(id); c3 != nil {
+				return c3
+			}
+		}
+	}
+
+	return c.buttonBar.ById(id)
+}
+
+func (c *dialogImpl) Clear() {
+	if c.content != nil {
+		c.content.setParent(nil)
+		c.content = nil
+	}
+}
+
+func (c *dialogImpl) Content() Comp {
+	return c.content
+}
+
+func (c *dialogImpl) SetContent(content Comp) {
+	c.Clear()
+	content.makeOrphan()
+	c.content = content
+	content.setParent(c)
+}
+
+func (c *dialogImpl) Modal() bool {
+	return c.modal
+}
+
+func (c *dialogImpl) SetModal(modal bool) {
+	c.modal = modal
+}
+
+func (c *dialogImpl) AddButton(text string, result DialogResult) Button {
+	b := NewButton(text)
+	b.AddEHandlerFunc(func(e Event) {
+		c.result = result
+		e.CloseDialog(c)
+		if c.handler != nil {
+			c.handler(e, result)
+		}
+	}, ETYPE_CLICK)
+	c.buttonBar.Add(b)
+	return b
+}
+
+func (c *dialogImpl) ButtonBar() Panel {
+	return c.buttonBar
+}
+
+func (c *dialogImpl) ResultHandler() DialogResultHandler {
+	return c.handler
+}
+
+func (c *dialogImpl) SetResultHandler(h DialogResultHandler) {
+	c.handler = h
+}
+
+func (c *dialogImpl) Result() DialogResult {
+	return c.result
+}
+
+func (c *dialogImpl) Window() Window {
+	if layer, isLayer := c.parent.(*dialogLayer); isLayer {
+		return layer.win
+	}
+	return nil
+}
+
+var (
+	_STR_DIV_OP         = []byte("<div")                             // "<div"
+	_STR_DIV_CL         = []byte("</div>")                           // "</div>"
+	_STR_DIALOG_TITLE   = []byte(`<div class="gwu-Dialog-Title">`)   // `<div class="gwu-Dialog-Title">`
+	_STR_DIALOG_CONTENT = []byte(`<div class="gwu-Dialog-Content">`) // `<div class="gwu-Dialog-Content">`
+	_STR_DIALOG_OVERLAY = []byte(`<div class="gwu-Dialog-Overlay">`) // `<div class="gwu-Dialog-Overlay">`
+)
+
+func (c *dialogImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_DIALOG_TITLE)
+	c.renderText(w)
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_DIALOG_CONTENT)
+	if c.content != nil {
+		c.content.Render(w)
+	}
+	w.Write(_STR_DIV_CL)
+
+	c.buttonBar.Render(w)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// dialogLayer is the container of the dialogs shown in a window.
+// It is rendered after the content of the window, outside of it.
+// For this reason the layer has no parent: the window being dirty must not
+// make the dialogs dirty, re-rendering the window does not render them.
+type dialogLayer struct {
+	compImpl // Component implementation
+
+	win     Window   // Window the dialogs are shown in
+	dialogs []Dialog // Shown dialogs, the last one is on top
+}
+
+// newDialogLayer creates a new dialogLayer.
+func newDialogLayer(win Window) *dialogLayer {
+	c := &dialogLayer{compImpl: newCompImpl(nil), win: win}
+	c.Style().AddClass("gwu-DialogLayer")
+	return c
+}
+
+func (c *dialogLayer) Remove(c2 Comp) bool {
+	for i, d := range c.dialogs {
+		if d.Equals(c2) {
+			c2.setParent(nil)
+			c.dialogs = append(c.dialogs[:i], c.dialogs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *dialogLayer) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	for _, d := range c.dialogs {
+		if c2 := d.ById(id); c2 != nil {
+			return c2
+		}
+	}
+	return nil
+}
+
+func (c *dialogLayer) Clear() {
+	for _, d := range c.dialogs {
+		d.setParent(nil)
+	}
+	c.dialogs = nil
+}
+
+// show shows the specified dialog on top of the other dialogs.
+func (c *dialogLayer) show(d Dialog) {
+	d.makeOrphan()
+	c.dialogs = append(c.dialogs, d)
+	d.setParent(c)
+}
+
+func (c *dialogLayer) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	w.Write(_STR_GT)
+
+	for _, d := range c.dialogs {
+		if d.Modal() {
+			w.Write(_STR_DIALOG_OVERLAY)
+			d.Render(w)
+			w.Write(_STR_DIV_CL)
+		} else {
+			d.Render(w)
+		}
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
+func (c *dialogImpl) usage() ([]Comp, int64) {
+	return usageComps(c.content, c.buttonBar), 0
+}
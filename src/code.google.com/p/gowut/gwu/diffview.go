@@ -0,0 +1,450 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// DiffView component interface and implementation.
+
+package gwu
+
+import (
+	"strings"
+)
+
+// Diff view mode type.
+type DiffMode int
+
+// Diff view modes.
+const (
+	DIFF_UNIFIED      DiffMode = iota // Unified view: removed and added lines below each other
+	DIFF_SIDE_BY_SIDE                 // Side-by-side view: old text on the left, new text on the right
+)
+
+// DiffView interface defines a component which computes the (line) diff
+// of two texts on the server, and displays it in unified or side-by-side view
+// with removed and added lines highlighted, e.g. for config-management UIs.
+//
+// Unchanged lines farther from changes than the context are collapsed.
+//
+// Default style classes: "gwu-DiffView", "gwu-DiffView-Header", "gwu-DiffView-Equal",
+// "gwu-DiffView-Change", "gwu-DiffView-Num", "gwu-DiffView-Line", "gwu-DiffView-Del",
+// "gwu-DiffView-Add", "gwu-DiffView-Empty", "gwu-DiffView-Skip"
+type DiffView interface {
+	// DiffView is a component.
+	Comp
+
+	// Texts returns the old and new texts.
+	Texts() (oldText, newText string)
+
+	// SetTexts sets the old and new texts to diff.
+	SetTexts(oldText, newText string)
+
+	// Titles returns the titles of the old and new texts.
+	Titles() (oldTitle, newTitle string)
+
+	// SetTitles sets the titles of the old and new texts
+	// (e.g. file names or versions) displayed above the diff.
+	// No header is displayed if both titles are empty.
+	SetTitles(oldTitle, newTitle string)
+
+	// Mode returns the view mode.
+	Mode() DiffMode
+
+	// SetMode sets the view mode.
+	// Default is DIFF_UNIFIED.
+	SetMode(mode DiffMode)
+
+	// Context returns the number of unchanged lines displayed around changes.
+	Context() int
+
+	// SetContext sets the number of unchanged lines displayed around changes.
+	// Pass a negative value to display all unchanged lines.
+	// Default is 3.
+	SetContext(lines int)
+
+	// Stats returns the number of removed and added lines.
+	Stats() (removed, added int)
+}
+
+// Diff operation type.
+type diffOp int
+
+// Diff operations.
+const (
+	diffEqual diffOp = iota // Line is unchanged
+	diffDel                 // Line is removed
+	diffIns                 // Line is added
+)
+
+// diffLine is a line of a diff.
+type diffLine struct {
+	op     diffOp // Operation
+	oldNum int    // Number of the line in the old text, 0 if added
+	newNum int    // Number of the line in the new text, 0 if removed
+	text   string // Text of the line
+}
+
+// DiffView implementation.
+type diffViewImpl struct {
+	compImpl // Component implementation
+
+	oldText, newText   string     // Old and new texts
+	oldTitle, newTitle string     // Titles of the old and new texts
+	mode               DiffMode   // View mode
+	context            int        // Number of unchanged lines displayed around changes
+	lines              []diffLine // Computed diff, lazily initialized
+}
+
+// NewDiffView creates a new DiffView.
+func NewDiffView(oldText, newText string) DiffView {
+	c := &diffViewImpl{compImpl: newCompImpl(nil), oldText: oldText, newText: newText, context: 3}
+	c.Style().AddClass("gwu-DiffView")
+	return c
+}
+
+func (c *diffViewImpl) Texts() (oldText, newText string) {
+	return c.oldText, c.newText
+}
+
+func (c *diffViewImpl) SetTexts(oldText, newText string) {
+	c.oldText, c.newText = oldText, newText
+	c.lines = nil
+}
+
+func (c *diffViewImpl) Titles() (oldTitle, newTitle string) {
+	return c.oldTitle, c.newTitle
+}
+
+func (c *diffViewImpl) SetTitles(oldTitle, newTitle string) {
+	c.oldTitle, c.newTitle = oldTitle, newTitle
+}
+
+func (c *diffViewImpl) Mode() DiffMode {
+	return c.mode
+}
+
+func (c *diffViewImpl) SetMode(mode DiffMode) {
+	c.mode = mode
+}
+
+func (c *diffViewImpl) Context() int {
+	return c.context
+}
+
+func (c *diffViewImpl) SetContext(lines int) {
+	c.context = lines
+}
+
+func (c *diffViewImpl) Stats() (removed, added int) {
+	for _, l := range c.diff() {
+		switch l.op {
+		case diffDel:
+			removed++
+		case diffIns:
+			added++
+		}
+	}
+	return
+}
+
+// diff returns the diff of the texts, computing it if needed.
+func (c *diffViewImpl) diff() []diffLine {
+	if c.lines == nil {
+		c.lines = diffLines(splitLines(c.oldText), splitLines(c.newText))
+	}
+	return c.lines
+}
+
+// splitLines splits a text into lines.
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// diffLines computes the diff of lines a and b
+// using Myers' O(ND) difference algorithm.
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d] holds v[-d..d] at the start of step d
+	var trace [][]int
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+				x = v[off+k+1] // Down (insertion)
+			} else {
+				x = v[off+k-1] + 1 // Right (deletion)
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Backtrack the edit path from the end:
+	lines := make([]diffLine, 0, max)
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		tv := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || k != d && tv[k-1+d] < tv[k+1+d] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := tv[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			lines = append(lines, diffLine{op: diffEqual, oldNum: x + 1, newNum: y + 1, text: a[x]})
+		}
+		if x == prevX {
+			y--
+			lines = append(lines, diffLine{op: diffIns, newNum: y + 1, text: b[y]})
+		} else {
+			x--
+			lines = append(lines, diffLine{op: diffDel, oldNum: x + 1, text: a[x]})
+		}
+	}
+	for x > 0 {
+		x, y = x-1, y-1
+		lines = append(lines, diffLine{op: diffEqual, oldNum: x + 1, newNum: y + 1, text: a[x]})
+	}
+
+	// Reverse to get lines in order:
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+var (
+	_STR_DV_TABLE_OP  = []byte(`<table cellspacing="0"`)                                                                                      // `<table cellspacing="0"`
+	_STR_DV_HEADER_OP = []byte(`<tr class="gwu-DiffView-Header"><th`)                                                                         // `<tr class="gwu-DiffView-Header"><th`
+	_STR_DV_TH_OP     = []byte("<th")                                                                                                         // "<th"
+	_STR_DV_TH_CL     = []byte("</th>")                                                                                                       // "</th>"
+	_STR_DV_COLSPAN   = []byte(` colspan="`)                                                                                                  // ` colspan="`
+	_STR_DV_ROW_OP    = []byte(`<tr class="gwu-DiffView-`)                                                                                    // `<tr class="gwu-DiffView-`
+	_STR_DV_NUM_OP    = []byte(`<td class="gwu-DiffView-Num">`)                                                                               // `<td class="gwu-DiffView-Num">`
+	_STR_DV_LINE_OP   = []byte(`<td class="gwu-DiffView-Line">`)                                                                              // `<td class="gwu-DiffView-Line">`
+	_STR_DV_DEL_OP    = []byte(`<td class="gwu-DiffView-Line gwu-DiffView-Del">`)                                                             // `<td class="gwu-DiffView-Line gwu-DiffView-Del">`
+	_STR_DV_ADD_OP    = []byte(`<td class="gwu-DiffView-Line gwu-DiffView-Add">`)                                                             // `<td class="gwu-DiffView-Line gwu-DiffView-Add">`
+	_STR_DV_EMPTY     = []byte(`<td class="gwu-DiffView-Num gwu-DiffView-Empty"></td><td class="gwu-DiffView-Line gwu-DiffView-Empty"></td>`) // `<td class="gwu-DiffView-Num gwu-DiffView-Empty"></td><td class="gwu-DiffView-Line gwu-DiffView-Empty"></td>`
+	_STR_DV_SKIP_OP   = []byte(`<tr class="gwu-DiffView-Skip"><td colspan="`)                                                                 // `<tr class="gwu-DiffView-Skip"><td colspan="`
+	_STR_DV_SKIP_CL   = []byte(`">&#8943;</td></tr>`)                                                                                         // `">&#8943;</td></tr>`
+	_STR_DV_TD_CL     = []byte("</td>")                                                                                                       // "</td>"
+	_STR_DV_TR_CL     = []byte("</tr>")                                                                                                       // "</tr>"
+)
+
+// Names of the row style classes of the diff operations.
+var diffRowClasses = map[diffOp]string{diffEqual: "Equal", diffDel: "Del", diffIns: "Add"}
+
+// Line prefixes of the diff operations in unified view.
+var diffPrefixes = map[diffOp]string{diffEqual: "  ", diffDel: "- ", diffIns: "+ "}
+
+func (c *diffViewImpl) Render(w writer) {
+	w.Write(_STR_DV_TABLE_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	cols := 3
+	if c.mode == DIFF_SIDE_BY_SIDE {
+		cols = 4
+	}
+	c.renderHeader(w, cols)
+
+	lines := c.diff()
+	visible := c.visibleLines(lines)
+	for i := 0; i < len(lines); {
+		if !visible[i] {
+			// Collapse hidden unchanged lines:
+			for i < len(lines) && !visible[i] {
+				i++
+			}
+			w.Write(_STR_DV_SKIP_OP)
+			w.Writev(cols)
+			w.Write(_STR_DV_SKIP_CL)
+			continue
+		}
+		if c.mode == DIFF_SIDE_BY_SIDE {
+			i = c.renderSideBySide(w, lines, i)
+		} else {
+			c.renderUnified(w, &lines[i])
+			i++
+		}
+	}
+
+	w.Write(_STR_TABLE_CL)
+}
+
+// visibleLines tells which lines of the diff are visible
+// (not collapsed) based on the context.
+func (c *diffViewImpl) visibleLines(lines []diffLine) []bool {
+	visible := make([]bool, len(lines))
+	for i, l := range lines {
+		if c.context < 0 || l.op != diffEqual {
+			visible[i] = true
+			continue
+		}
+		for j := i - c.context; j <= i+c.context; j++ {
+			if j >= 0 && j < len(lines) && lines[j].op != diffEqual {
+				visible[i] = true
+				break
+			}
+		}
+	}
+	return visible
+}
+
+// renderHeader renders the header row with the titles, if there are titles.
+func (c *diffViewImpl) renderHeader(w writer, cols int) {
+	if len(c.oldTitle) == 0 && len(c.newTitle) == 0 {
+		return
+	}
+
+	if c.mode == DIFF_SIDE_BY_SIDE {
+		// To render: <tr class="gwu-DiffView-Header"><th colspan="2">old</th><th colspan="2">new</th></tr>
+		w.Write(_STR_DV_HEADER_OP)
+		w.Write(_STR_DV_COLSPAN)
+		w.Writev(2)
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		w.Writees(c.oldTitle)
+		w.Write(_STR_DV_TH_CL)
+		w.Write(_STR_DV_TH_OP)
+		w.Write(_STR_DV_COLSPAN)
+		w.Writev(2)
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		w.Writees(c.newTitle)
+		w.Write(_STR_DV_TH_CL)
+		w.Write(_STR_DV_TR_CL)
+		return
+	}
+
+	// To render: <tr class="gwu-DiffView-Header"><th colspan="3">--- old</th></tr> and the same for +++ new
+	for _, title := range []string{"--- " + c.oldTitle, "+++ " + c.newTitle} {
+		w.Write(_STR_DV_HEADER_OP)
+		w.Write(_STR_DV_COLSPAN)
+		w.Writev(cols)
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		w.Writees(title)
+		w.Write(_STR_DV_TH_CL)
+		w.Write(_STR_DV_TR_CL)
+	}
+}
+
+// renderUnified renders a line of the diff in unified view.
+func (c *diffViewImpl) renderUnified(w writer, l *diffLine) {
+	w.Write(_STR_DV_ROW_OP)
+	w.Writes(diffRowClasses[l.op])
+	w.Write(_STR_QUOTE)
+	w.Write(_STR_GT)
+	c.renderNum(w, l.oldNum)
+	c.renderNum(w, l.newNum)
+	w.Write(_STR_DV_LINE_OP)
+	w.Writees(diffPrefixes[l.op] + l.text)
+	w.Write(_STR_DV_TD_CL)
+	w.Write(_STR_DV_TR_CL)
+}
+
+// renderSideBySide renders lines of the diff starting at index i in side-by-side view,
+// and returns the index of the next line to render.
+// Removed lines are paired with the added lines following them.
+func (c *diffViewImpl) renderSideBySide(w writer, lines []diffLine, i int) int {
+	if lines[i].op == diffEqual {
+		l := &lines[i]
+		w.Write(_STR_DV_ROW_OP)
+		w.Writes(diffRowClasses[diffEqual])
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		c.renderNum(w, l.oldNum)
+		w.Write(_STR_DV_LINE_OP)
+		w.Writees(l.text)
+		w.Write(_STR_DV_TD_CL)
+		c.renderNum(w, l.newNum)
+		w.Write(_STR_DV_LINE_OP)
+		w.Writees(l.text)
+		w.Write(_STR_DV_TD_CL)
+		w.Write(_STR_DV_TR_CL)
+		return i + 1
+	}
+
+	// A block of changes: removed lines followed by added lines
+	dels := i
+	for i < len(lines) && lines[i].op == diffDel {
+		i++
+	}
+	ins := i
+	for i < len(lines) && lines[i].op == diffIns {
+		i++
+	}
+	delCount, insCount := ins-dels, i-ins
+
+	for j := 0; j < delCount || j < insCount; j++ {
+		w.Write(_STR_DV_ROW_OP)
+		w.Writes("Change")
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		if j < delCount {
+			l := &lines[dels+j]
+			c.renderNum(w, l.oldNum)
+			w.Write(_STR_DV_DEL_OP)
+			w.Writees(l.text)
+			w.Write(_STR_DV_TD_CL)
+		} else {
+			w.Write(_STR_DV_EMPTY)
+		}
+		if j < insCount {
+			l := &lines[ins+j]
+			c.renderNum(w, l.newNum)
+			w.Write(_STR_DV_ADD_OP)
+			w.Writees(l.text)
+			w.Write(_STR_DV_TD_CL)
+		} else {
+			w.Write(_STR_DV_EMPTY)
+		}
+		w.Write(_STR_DV_TR_CL)
+	}
+	return i
+}
+
+// renderNum renders a line number cell.
+// 0 is rendered as an empty cell.
+func (c *diffViewImpl) renderNum(w writer, num int) {
+	w.Write(_STR_DV_NUM_OP)
+	if num > 0 {
+		w.Writev(num)
+	}
+	w.Write(_STR_DV_TD_CL)
+}
@@ -209,28 +209,65 @@ Buttons will have red background without having to change their style individual
 Component palette
 
 Containers to group and lay out components:
+	Board     - a Kanban board with columns of draggable cards (comps)
+	Dialog    - a popup window displayed on top of the window content, with a button bar
+	ErrorBoundary - isolates failures: displays an error view (with retry) if its content panics
 	Expander  - shows and hides a content comp when clicking on the header comp
+	FlexPanel - lays out its children using CSS flexbox (justify, align, wrap, grow settings)
+	GridPanel - lays out its children in a CSS grid with column templates, gaps and row / column spans
 	(Link)    - allows only one optional child
+	LoginWindow - a standard login window (user name, password, remember me) with a credential checker
+	MenuBar   - a desktop-style top menu with Menus of MenuItems, separators and nested submenus
+	PagedTable - a table for large datasets, rows of the current page are fetched by a row provider
 	Panel     - it has configurable layout
+	PopupMenu - a context menu opened at the mouse position on right click (see Comp.SetContextMenu())
+	Router    - displays one view at a time selected by a path, integrated with browser history
+	SplitPanel - displays 2 components next to each other, resizable by dragging the splitter
 	Table     - it is dynamic and flexible
 	TabPanel  - for tabbed displaying components (only 1 is visible at a time)
 	Window    - top of component hierarchy, it is an extension of the Panel
 
 Input components to get data from users:
 	CheckBox
+	ComboBox   (editable text box with autocomplete suggestions)
+	DateBox    (date input with a date picker)
+	FileUpload (uploads files with progress events)
 	ListBox    (it's either a drop-down list or a multi-line/multi-select list box)
+	MediaCapture (captures webcam snapshots or records audio)
+	NumberBox  (numeric input with up/down stepper buttons)
 	TextBox    (it's either a one-line text box or a multi-line text area)
 	PasswBox
 	RadioButton
+	RichTextBox (WYSIWYG editor for formatted text)
+	Scanner    (scans barcodes and QR codes with the device camera)
+	SignaturePad (users draw their signature with the mouse or by touch)
+	Slider     (selects a numeric value from a range by dragging a handle)
 	SwitchButton
 
 Other components:
 	Button
+	CommandPalette (Ctrl+K popup listing named actions, filtered as the user types)
+	ComputedLabel (text computed from watched components, e.g. live order totals)
+	DiffView (displays the diff of two texts in unified or side-by-side view)
+	EmptyState (displayed when there is nothing to display, e.g. "No results")
+	Graph (displays nodes and edges, e.g. org charts and topologies, with pan and zoom)
+	HeatmapCalendar (displays values of days as a contribution-style calendar)
 	Html
 	Image
+	JsonView (displays a Go value or JSON as a collapsible tree with search)
 	Label
 	Link
+	LogView (displays log lines streamed from a reader or channel)
+	MessageList (a list of messages, e.g. a chat, optimized for appending)
+	PdfView (displays a PDF document with page navigation and zoom controls)
+	PresenceList (displays the users currently online, see Server.Presence())
+	SideNav (sticky left-rail navigation with sections and icons, synchronized with a Router)
+	ProgressBar (displays the progress of an operation, in percent or indeterminate)
+	Skeleton (placeholder displayed while content is loading, see LoadWithSkeletons())
+	Terminal (connects keystrokes and output to a server-side PTY or io.ReadWriter)
 	Timer
+	Tour   (a guided tour highlighting components step by step, e.g. for onboarding)
+	Tree   (displays hierarchical data, child nodes can be loaded on demand)
 
 
 Full application example
@@ -0,0 +1,96 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// File download: sending server generated content to the browser
+// from event handlers.
+
+package gwu
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// Max time a download waits to be fetched by the client.
+const _DOWNLOAD_TIMEOUT = time.Minute
+
+// download describes a file to be downloaded by the client.
+type download struct {
+	name        string    // Name of the file
+	contentType string    // Content type of the file
+	r           io.Reader // Reader of the content
+	sessId      string    // Id of the session the download belongs to
+	created     time.Time // Time when the download was registered
+}
+
+// close closes the reader of the download if it is an io.Closer.
+func (d *download) close() {
+	if c, isCloser := d.r.(io.Closer); isCloser {
+		c.Close()
+	}
+}
+
+// addDownload registers the specified download, and returns the token
+// the client can fetch it with.
+func (s *serverImpl) addDownload(d *download) string {
+	s.downloadsMutex.Lock()
+	defer s.downloadsMutex.Unlock()
+
+	// Remove expired downloads
+	now := time.Now()
+	for token, d2 := range s.downloads {
+		if now.Sub(d2.created) > _DOWNLOAD_TIMEOUT {
+			delete(s.downloads, token)
+			d2.close()
+		}
+	}
+
+	token := genId()
+	d.created = now
+	s.downloads[token] = d
+	return token
+}
+
+// handleDownload handles a download request: sends the content
+// of the download registered for the token of the request.
+func (s *serverImpl) handleDownload(sess Session, wr http.ResponseWriter, r *http.Request) {
+	token := r.FormValue(_PARAM_TOKEN)
+
+	s.downloadsMutex.Lock()
+	d := s.downloads[token]
+	if d != nil && d.sessId == sess.Id() {
+		delete(s.downloads, token)
+	} else {
+		d = nil
+	}
+	s.downloadsMutex.Unlock()
+
+	if d == nil {
+		http.NotFound(wr, r)
+		return
+	}
+	defer d.close()
+
+	if len(d.contentType) > 0 {
+		wr.Header().Set("Content-Type", d.contentType)
+	} else {
+		wr.Header().Set("Content-Type", "application/octet-stream")
+	}
+	wr.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": d.name}))
+	wr.Header().Set("Cache-Control", "no-store")
+	io.Copy(wr, d.r)
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// EmptyState component interface and implementation.
+
+package gwu
+
+// EmptyState interface defines a component which is displayed in place of
+// content when there is nothing to display (e.g. "No results" or
+// "Nothing here yet"), with an optional icon, a title, a description
+// and an optional action button (e.g. "Create your first project").
+//
+// Default style classes: "gwu-EmptyState", "gwu-EmptyState-Icon", "gwu-EmptyState-Title",
+// "gwu-EmptyState-Description", "gwu-EmptyState-Action"
+type EmptyState interface {
+	// EmptyState is a Container (of the action button).
+	Container
+
+	// An empty state has text which is displayed as its title.
+	HasText
+
+	// Icon returns the URL of the icon.
+	Icon() string
+
+	// SetIcon sets the URL of the icon displayed above the title.
+	// Pass an empty string to not display an icon.
+	SetIcon(url string)
+
+	// Description returns the description.
+	Description() string
+
+	// SetDescription sets the description displayed below the title.
+	SetDescription(description string)
+
+	// Action returns the action button.
+	// Returns nil if there is no action button.
+	Action() Button
+
+	// SetAction creates an action button with the specified text
+	// (replacing the previous one), and returns it so event handlers
+	// can be added to it.
+	// Pass an empty string to remove the action button, nil is returned then.
+	SetAction(text string) Button
+}
+
+// EmptyState implementation.
+type emptyStateImpl struct {
+	compImpl    // Component implementation
+	hasTextImpl // Has text implementation
+
+	icon        string // URL of the icon
+	description string // Description
+	action      Button // Action button
+}
+
+// NewEmptyState creates a new EmptyState.
+func NewEmptyState(title, description string) EmptyState {
+	c := &emptyStateImpl{compImpl: newCompImpl(nil), hasTextImpl: newHasTextImpl(title), description: description}
+	c.Style().AddClass("gwu-EmptyState")
+	return c
+}
+
+func (c *emptyStateImpl) Remove(c2 Comp) bool {
+	if c.action == nil || !c.action.Equals(c2) {
+		return false
+	}
+	c.action.setParent(nil)
+	c.action = nil
+	return true
+}
+
+func (c *emptyStateImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+	if c.action != nil && c.action.Id() == id {
+		return c.action
+	}
+	return nil
+}
+
+func (c *emptyStateImpl) Clear() {
+	if c.action != nil {
+		c.Remove(c.action)
+	}
+}
+
+func (c *emptyStateImpl) Icon() string {
+	return c.icon
+}
+
+func (c *emptyStateImpl) SetIcon(url string) {
+	c.icon = url
+}
+
+func (c *emptyStateImpl) Description() string {
+	return c.description
+}
+
+func (c *emptyStateImpl) SetDescription(description string) {
+	c.description = description
+}
+
+func (c *emptyStateImpl) Action() Button {
+	return c.action
+}
+
+func (c *emptyStateImpl) SetAction(text string) Button {
+	c.Clear()
+	if len(text) == 0 {
+		return nil
+	}
+	c.action = NewButton(text)
+	c.action.setParent(c)
+	return c.action
+}
+
+var (
+	_STR_ES_ICON_OP  = []byte(`<img class="gwu-EmptyState-Icon" src="`)   // `<img class="gwu-EmptyState-Icon" src="`
+	_STR_ES_ICON_CL  = []byte(`">`)                                       // `">`
+	_STR_ES_TITLE_OP = []byte(`<div class="gwu-EmptyState-Title">`)       // `<div class="gwu-EmptyState-Title">`
+	_STR_ES_DESC_OP  = []byte(`<div class="gwu-EmptyState-Description">`) // `<div class="gwu-EmptyState-Description">`
+	_STR_ES_ACTION   = []byte(`<div class="gwu-EmptyState-Action">`)      // `<div class="gwu-EmptyState-Action">`
+)
+
+func (c *emptyStateImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	if len(c.icon) > 0 {
+		w.Write(_STR_ES_ICON_OP)
+		w.Writees(c.icon)
+		w.Write(_STR_ES_ICON_CL)
+	}
+
+	w.Write(_STR_ES_TITLE_OP)
+	c.renderText(w)
+	w.Write(_STR_DIV_CL)
+
+	if len(c.description) > 0 {
+		w.Write(_STR_ES_DESC_OP)
+		w.Writees(c.description)
+		w.Write(_STR_DIV_CL)
+	}
+
+	if c.action != nil {
+		w.Write(_STR_ES_ACTION)
+		c.action.Render(w)
+		w.Write(_STR_DIV_CL)
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
+func (c *emptyStateImpl) usage() ([]Comp, int64) {
+	return usageComps(c.action), 0
+}
@@ -0,0 +1,277 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// ErrorBoundary component interface and implementation.
+
+package gwu
+
+import (
+	"bytes"
+)
+
+// ErrorBoundary interface defines a container which isolates failures of its content:
+// if rendering the content or an event handler of any descendant component panics,
+// the panic is recovered and only the region of the error boundary is replaced
+// with an error view (with a retry button), instead of the whole window response failing.
+//
+// Clicking on the retry button clears the error (see Reset()) and displays the content again.
+// Event handlers added to the retry button are called after that, e.g. to rebuild the content.
+//
+// If error boundaries are nested, the nearest one handles the panic.
+//
+// Default style classes: "gwu-ErrorBoundary", "gwu-ErrorBoundary-Error",
+// "gwu-ErrorBoundary-Text", "gwu-ErrorBoundary-Retry"
+type ErrorBoundary interface {
+	// ErrorBoundary is a Container (of the content, the error view and the retry button).
+	Container
+
+	// Content returns the content component.
+	Content() Comp
+
+	// SetContent sets the content component.
+	SetContent(content Comp)
+
+	// Err returns the value of the recovered panic.
+	// nil is returned if there was no panic (since the last Reset()).
+	Err() interface{}
+
+	// Reset clears the error, the content is displayed again.
+	// The error boundary has to be marked dirty for the change to be visible.
+	Reset()
+
+	// ErrorText returns the text of the default error view.
+	ErrorText() string
+
+	// SetErrorText sets the text of the default error view.
+	// Default is "Something went wrong.".
+	SetErrorText(text string)
+
+	// ErrorView returns the custom error view.
+	// nil is returned if the default error view is used.
+	ErrorView() Comp
+
+	// SetErrorView sets a custom error view, displayed (above the retry
+	// button) in place of the default error text.
+	// Pass nil to use the default error view.
+	SetErrorView(view Comp)
+
+	// RetryButton returns the retry button, so its text can be changed
+	// and event handlers can be added to it.
+	RetryButton() Button
+}
+
+// ErrorBoundary implementation.
+type errorBoundaryImpl struct {
+	compImpl // Component implementation
+
+	content   Comp        // Content component
+	err       interface{} // Value of the recovered panic
+	errorText string      // Text of the default error view
+	errorView Comp        // Custom error view
+	retry     Button      // Retry button
+}
+
+// NewErrorBoundary creates a new ErrorBoundary with the specified content.
+func NewErrorBoundary(content Comp) ErrorBoundary {
+	c := &errorBoundaryImpl{compImpl: newCompImpl(nil), errorText: "Something went wrong."}
+	c.retry = NewButton("Retry")
+	c.retry.setParent(c)
+	c.retry.AddEHandlerFunc(func(e Event) {
+		c.Reset()
+		e.MarkDirty(c)
+	}, ETYPE_CLICK)
+	if content != nil {
+		c.SetContent(content)
+	}
+	c.Style().AddClass("gwu-ErrorBoundary")
+	return c
+}
+
+func (c *errorBoundaryImpl) Remove(c2 Comp) bool {
+	if c.content != nil && c.content.Equals(c2) {
+		c2.setParent(nil)
+		c.content = nil
+		return true
+	}
+
+	if c.errorView != nil && c.errorView.Equals(c2) {
+		c2.setParent(nil)
+		c.errorView = nil
+		return true
+	}
+
+	return false
+}
+
+func (c *errorBoundaryImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	for _, c2 := range []Comp{c.content, c.errorView, c.retry} {
+		if c2 == nil {
+			continue
+		}
+		if c2.Id() == id {
+			return c2
+		}
+		if c3, isContainer := c2.(Container); isContainer {
+			if c4 := c3.ById(id); c4 != nil {
+				return c4
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *errorBoundaryImpl) Clear() {
+	if c.content != nil {
+		c.Remove(c.content)
+	}
+	if c.errorView != nil {
+		c.Remove(c.errorView)
+	}
+}
+
+func (c *errorBoundaryImpl) Content() Comp {
+	return c.content
+}
+
+func (c *errorBoundaryImpl) SetContent(content Comp) {
+	if c.content != nil {
+		c.Remove(c.content)
+	}
+	content.makeOrphan()
+	c.content = content
+	content.setParent(c)
+}
+
+func (c *errorBoundaryImpl) Err() interface{} {
+	return c.err
+}
+
+func (c *errorBoundaryImpl) Reset() {
+	c.err = nil
+}
+
+func (c *errorBoundaryImpl) ErrorText() string {
+	return c.errorText
+}
+
+func (c *errorBoundaryImpl) SetErrorText(text string) {
+	c.errorText = text
+}
+
+func (c *errorBoundaryImpl) ErrorView() Comp {
+	return c.errorView
+}
+
+func (c *errorBoundaryImpl) SetErrorView(view Comp) {
+	if c.errorView != nil {
+		c.Remove(c.errorView)
+	}
+	if view == nil {
+		return
+	}
+	view.makeOrphan()
+	c.errorView = view
+	view.setParent(c)
+}
+
+func (c *errorBoundaryImpl) RetryButton() Button {
+	return c.retry
+}
+
+var (
+	_STR_EB_ERROR_OP = []byte(`<div class="gwu-ErrorBoundary-Error">`) // `<div class="gwu-ErrorBoundary-Error">`
+	_STR_EB_TEXT_OP  = []byte(`<div class="gwu-ErrorBoundary-Text">`)  // `<div class="gwu-ErrorBoundary-Text">`
+	_STR_EB_RETRY_OP = []byte(`<div class="gwu-ErrorBoundary-Retry">`) // `<div class="gwu-ErrorBoundary-Retry">`
+)
+
+func (c *errorBoundaryImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	if c.err == nil && c.content != nil {
+		c.renderContent(w)
+	}
+
+	if c.err != nil {
+		w.Write(_STR_EB_ERROR_OP)
+		if c.errorView != nil {
+			c.errorView.Render(w)
+		} else {
+			w.Write(_STR_EB_TEXT_OP)
+			w.Writees(c.errorText)
+			w.Write(_STR_DIV_CL)
+		}
+		w.Write(_STR_EB_RETRY_OP)
+		c.retry.Render(w)
+		w.Write(_STR_DIV_CL)
+		w.Write(_STR_DIV_CL)
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderContent renders the content into a buffer first, so if rendering panics,
+// the partial output of the content is discarded, and the error is recorded.
+func (c *errorBoundaryImpl) renderContent(w writer) {
+	buf := &bytes.Buffer{}
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = r
+			return
+		}
+		w.Write(buf.Bytes())
+	}()
+
+	c.content.Render(w.sub(buf))
+}
+
+// guardEvent calls f which processes an event of the specified component.
+// If the component is inside an ErrorBoundary and f panics, the panic is recovered
+// and the (nearest) error boundary is marked dirty to display its error view.
+func guardEvent(e Event, c Comp, f func()) {
+	var b *errorBoundaryImpl
+	for p := c.Parent(); p != nil; p = p.Parent() {
+		if b2, ok := p.(*errorBoundaryImpl); ok {
+			b = b2
+			break
+		}
+	}
+
+	if b == nil {
+		f()
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			b.err = r
+			e.MarkDirty(b)
+		}
+	}()
+
+	f()
+}
+
+func (c *errorBoundaryImpl) usage() ([]Comp, int64) {
+	return usageComps(c.content, c.errorView, c.retry), 0
+}
@@ -18,42 +18,145 @@
 package gwu
 
 import (
+	"errors"
+	"io"
+	"sort"
 	"strconv"
 )
 
 // Event type (kind) type.
 type EventType int
 
-// Converts an Event type to a string.
+// String returns the name of the event type, e.g. "click".
+// The number of the event type is returned for invalid event types.
 func (etype EventType) String() string {
+	if etype.Valid() {
+		return etypeNames[etype]
+	}
 	return strconv.Itoa(int(etype))
 }
 
+// Valid tells if the event type is a built-in or a registered custom event type
+// (see RegisterEventType()).
+func (etype EventType) Valid() bool {
+	return etype >= 0 && int(etype) < len(etypeNames)
+}
+
 // Event types.
 const (
 	// General events for all components
-	ETYPE_CLICK      EventType = iota // Mouse click event
-	ETYPE_DBL_CLICK                   // Mouse double click event
-	ETYPE_MOUSE_DOWN                  // Mouse down event
-	ETYPE_MOUSE_MOVE                  // Mouse move event
-	ETYPE_MOUSE_OVER                  // Mouse over event
-	ETYPE_MOUSE_OUT                   // Mouse out event
-	ETYPE_MOUSE_UP                    // Mouse up event
-	ETYPE_KEY_DOWN                    // Key down event
-	ETYPE_KEY_PRESS                   // Key press event
-	ETYPE_KEY_UP                      // Key up event
-	ETYPE_BLUR                        // Blur event (component loses focus)
-	ETYPE_CHANGE                      // Change event (value change)
-	ETYPE_FOCUS                       // Focus event (component gains focus)
+	ETYPE_CLICK        EventType = iota // Mouse click event
+	ETYPE_DBL_CLICK                     // Mouse double click event
+	ETYPE_MOUSE_DOWN                    // Mouse down event
+	ETYPE_MOUSE_MOVE                    // Mouse move event
+	ETYPE_MOUSE_OVER                    // Mouse over event
+	ETYPE_MOUSE_OUT                     // Mouse out event
+	ETYPE_MOUSE_UP                      // Mouse up event
+	ETYPE_KEY_DOWN                      // Key down event
+	ETYPE_KEY_PRESS                     // Key press event
+	ETYPE_KEY_UP                        // Key up event
+	ETYPE_BLUR                          // Blur event (component loses focus)
+	ETYPE_CHANGE                        // Change event (value change)
+	ETYPE_FOCUS                         // Focus event (component gains focus)
+	ETYPE_INPUT                         // Input event (value is being changed, e.g. while dragging a slider)
+	ETYPE_CONTEXT_MENU                  // Context menu event (e.g. right click)
 
 	// Window events (for Window only)
-	ETYPE_WIN_LOAD   // Window load event
-	ETYPE_WIN_UNLOAD // Window unload event
+	ETYPE_WIN_LOAD          // Window load event
+	ETYPE_WIN_UNLOAD        // Window unload event
+	ETYPE_VISIBILITY_CHANGE // Visibility change event (the page is hidden or becomes visible, see Window.Hidden())
 
 	// Internal events, generated and dispatched internally while processing another event
-	ETYPE_STATE_CHANGE // State change 
+	ETYPE_STATE_CHANGE   // State change
+	ETYPE_LIMIT_EXCEEDED // Limit exceeded (e.g. too big value or upload, see Event.LimitError())
+
+	// Upload events (for FileUpload only)
+	ETYPE_UPLOAD_PROGRESS // File upload progress event
+	ETYPE_UPLOAD_DONE     // File upload done event
+
+	// Tab panel events (for TabPanel only)
+	ETYPE_TAB_MOVED // Tab moved event
+
+	// Board events (for Board only)
+	ETYPE_CARD_MOVED // Card moved event
+
+	// Table events (for Table only)
+	ETYPE_SORT        // Sort event (sort column or direction changed)
+	ETYPE_CELL_EDITED // Cell edited event (value of an editable cell changed)
+	ETYPE_ROW_SELECT  // Row select event (a row was clicked, the selection changed)
 )
 
+// Names of the event types, indexed by event type.
+// Custom event types are appended by RegisterEventType().
+var etypeNames = []string{
+	ETYPE_CLICK:             "click",
+	ETYPE_DBL_CLICK:         "dblclick",
+	ETYPE_MOUSE_DOWN:        "mousedown",
+	ETYPE_MOUSE_MOVE:        "mousemove",
+	ETYPE_MOUSE_OVER:        "mouseover",
+	ETYPE_MOUSE_OUT:         "mouseout",
+	ETYPE_MOUSE_UP:          "mouseup",
+	ETYPE_KEY_DOWN:          "keydown",
+	ETYPE_KEY_PRESS:         "keypress",
+	ETYPE_KEY_UP:            "keyup",
+	ETYPE_BLUR:              "blur",
+	ETYPE_CHANGE:            "change",
+	ETYPE_FOCUS:             "focus",
+	ETYPE_INPUT:             "input",
+	ETYPE_CONTEXT_MENU:      "contextmenu",
+	ETYPE_WIN_LOAD:          "winload",
+	ETYPE_WIN_UNLOAD:        "winunload",
+	ETYPE_VISIBILITY_CHANGE: "visibilitychange",
+	ETYPE_STATE_CHANGE:      "statechange",
+	ETYPE_LIMIT_EXCEEDED:    "limitexceeded",
+	ETYPE_UPLOAD_PROGRESS:   "uploadprogress",
+	ETYPE_UPLOAD_DONE:       "uploaddone",
+	ETYPE_TAB_MOVED:         "tabmoved",
+	ETYPE_CARD_MOVED:        "cardmoved",
+	ETYPE_SORT:              "sort",
+	ETYPE_CELL_EDITED:       "celledited",
+	ETYPE_ROW_SELECT:        "rowselect"}
+
+// ParseEventType returns the event type of the specified name
+// (see EventType.String()), including registered custom event types.
+func ParseEventType(name string) (etype EventType, ok bool) {
+	for i, name_ := range etypeNames {
+		if name_ == name {
+			return EventType(i), true
+		}
+	}
+	return -1, false
+}
+
+// RegisterEventType registers a custom event type with the specified name,
+// and returns it. Custom event types are in the ECAT_CUSTOM category.
+// 
+// If attr is not empty, it is the name of the HTML event attribute the
+// event type is bound to (e.g. "onwheel"), and components having handlers
+// for the event type will send it just like the general event types.
+// Else the event type can be sent by custom JavaScript code, e.g.
+// 	se(null,etype,compId,value)
+// 
+// Event types should be registered at startup (e.g. in an init function),
+// registering is not synchronized with rendering and event dispatching.
+// An error is returned if name is empty or is the name of an already
+// registered event type.
+func RegisterEventType(name, attr string) (EventType, error) {
+	if len(name) == 0 {
+		return -1, errors.New("Event type name must not be empty!")
+	}
+	if _, ok := ParseEventType(name); ok {
+		return -1, errors.New("Event type already registered: " + name)
+	}
+
+	etype := EventType(len(etypeNames))
+	etypeNames = append(etypeNames, name)
+	if len(attr) > 0 {
+		etypeAttrs[etype] = []byte(attr)
+	}
+	return etype, nil
+}
+
 // Event type category.
 type EventCategory int
 
@@ -62,6 +165,11 @@ const (
 	ECAT_GENERAL  EventCategory = iota // General event type for all components
 	ECAT_WINDOW                        // Window event type for Window only
 	ECAT_INTERNAL                      // Internal event generated and dispatched internally while processing another event
+	ECAT_UPLOAD                        // Upload event type for FileUpload only
+	ECAT_TABPANEL                      // Tab panel event type for TabPanel only
+	ECAT_BOARD                         // Board event type for Board only
+	ECAT_TABLE                         // Table event type for Table only
+	ECAT_CUSTOM                        // Custom event type (see RegisterEventType())
 
 	ECAT_UNKNOWN EventCategory = -1 // Unknown event category
 )
@@ -69,12 +177,22 @@ const (
 // Category returns the event type category.
 func (etype EventType) Category() EventCategory {
 	switch {
-	case etype >= ETYPE_CLICK && etype <= ETYPE_FOCUS:
+	case etype >= ETYPE_CLICK && etype <= ETYPE_CONTEXT_MENU:
 		return ECAT_GENERAL
-	case etype >= ETYPE_WIN_LOAD && etype <= ETYPE_WIN_UNLOAD:
+	case etype >= ETYPE_WIN_LOAD && etype <= ETYPE_VISIBILITY_CHANGE:
 		return ECAT_WINDOW
-	case etype >= ETYPE_STATE_CHANGE && etype <= ETYPE_STATE_CHANGE:
+	case etype >= ETYPE_STATE_CHANGE && etype <= ETYPE_LIMIT_EXCEEDED:
 		return ECAT_INTERNAL
+	case etype >= ETYPE_UPLOAD_PROGRESS && etype <= ETYPE_UPLOAD_DONE:
+		return ECAT_UPLOAD
+	case etype >= ETYPE_TAB_MOVED && etype <= ETYPE_TAB_MOVED:
+		return ECAT_TABPANEL
+	case etype >= ETYPE_CARD_MOVED && etype <= ETYPE_CARD_MOVED:
+		return ECAT_BOARD
+	case etype >= ETYPE_SORT && etype <= ETYPE_ROW_SELECT:
+		return ECAT_TABLE
+	case etype > ETYPE_ROW_SELECT && etype.Valid():
+		return ECAT_CUSTOM
 	}
 
 	return ECAT_UNKNOWN
@@ -82,24 +200,27 @@ func (etype EventType) Category() EventCategory {
 
 // Attribute names for the general event types; only for the general event types.
 var etypeAttrs map[EventType][]byte = map[EventType][]byte{
-	ETYPE_CLICK:      []byte("onclick"),
-	ETYPE_DBL_CLICK:  []byte("ondblclick"),
-	ETYPE_MOUSE_DOWN: []byte("onmousedown"),
-	ETYPE_MOUSE_MOVE: []byte("onmousemove"),
-	ETYPE_MOUSE_OVER: []byte("onmouseover"),
-	ETYPE_MOUSE_OUT:  []byte("onmouseout"),
-	ETYPE_MOUSE_UP:   []byte("onmouseup"),
-	ETYPE_KEY_DOWN:   []byte("onkeydown"),
-	ETYPE_KEY_PRESS:  []byte("onkeypress"),
-	ETYPE_KEY_UP:     []byte("onkeyup"),
-	ETYPE_BLUR:       []byte("onblur"),
-	ETYPE_CHANGE:     []byte("onchange"),
-	ETYPE_FOCUS:      []byte("onfocus")}
+	ETYPE_CLICK:        []byte("onclick"),
+	ETYPE_DBL_CLICK:    []byte("ondblclick"),
+	ETYPE_MOUSE_DOWN:   []byte("onmousedown"),
+	ETYPE_MOUSE_MOVE:   []byte("onmousemove"),
+	ETYPE_MOUSE_OVER:   []byte("onmouseover"),
+	ETYPE_MOUSE_OUT:    []byte("onmouseout"),
+	ETYPE_MOUSE_UP:     []byte("onmouseup"),
+	ETYPE_KEY_DOWN:     []byte("onkeydown"),
+	ETYPE_KEY_PRESS:    []byte("onkeypress"),
+	ETYPE_KEY_UP:       []byte("onkeyup"),
+	ETYPE_BLUR:         []byte("onblur"),
+	ETYPE_CHANGE:       []byte("onchange"),
+	ETYPE_FOCUS:        []byte("onfocus"),
+	ETYPE_INPUT:        []byte("oninput"),
+	ETYPE_CONTEXT_MENU: []byte("oncontextmenu")}
 
 // Function names for window event types.
 var etypeFuncs map[EventType][]byte = map[EventType][]byte{
-	ETYPE_WIN_LOAD:   []byte("onload"),
-	ETYPE_WIN_UNLOAD: []byte("onbeforeunload")} // Bind it to onbeforeunload (instead of onunload) for several reasons (onunload might cause trouble for AJAX; onunload is not called in IE if page is just refreshed...)
+	ETYPE_WIN_LOAD:          []byte("onload"),
+	ETYPE_VISIBILITY_CHANGE: []byte("onvisibilitychange"),
+	ETYPE_WIN_UNLOAD:        []byte("onbeforeunload")} // Bind it to onbeforeunload (instead of onunload) for several reasons (onunload might cause trouble for AJAX; onunload is not called in IE if page is just refreshed...)
 
 // Mouse button type.
 type MouseBtn int
@@ -237,6 +358,13 @@ type Event interface {
 	// Key code returns the key code.
 	KeyCode() Key
 
+	// Request returns a read-only view of the HTTP request the event
+	// originates from, which gives access to e.g. the request headers,
+	// the client address and the TLS state.
+	// nil is returned if the event does not originate from an HTTP request
+	// (e.g. the event passed to the function of Session.Push()).
+	Request() RequestInfo
+
 	// Requests the specified window to be reloaded
 	// after processing the current event.
 	// Tip: pass an empty string to reload the current window.
@@ -254,12 +382,72 @@ type Event interface {
 	// Also note that components will not be re-rendered multiple times.
 	// For example if a child component and its parent component are both
 	// marked dirty, the child component will only be re-rendered once. 
+	// Marks are coalesced when the event processing ends: only the topmost
+	// dirty ancestors are re-rendered, based on the component tree at that time
+	// (so it does not matter if components are moved after being marked dirty).
 	MarkDirty(comps ...Comp)
 
+	// DirtyComps returns the components marked dirty so far, coalesced:
+	// components having a dirty ancestor are not included (as they are
+	// re-rendered along with their ancestor). Components are sorted by their ids.
+	DirtyComps() []Comp
+
+	// SyncValue returns the additional named value sent with the event
+	// by the source component (see Comp.AddSyncValue()),
+	// and tells if the value was sent.
+	SyncValue(name string) (value string, ok bool)
+
+	// SyncInt returns the additional named value sent with the event as an int.
+	// ok is false if the value was not sent or is not an int.
+	SyncInt(name string) (value int, ok bool)
+
+	// SyncFloat returns the additional named value sent with the event as a float64.
+	// ok is false if the value was not sent or is not a number.
+	SyncFloat(name string) (value float64, ok bool)
+
+	// SyncBool returns the additional named value sent with the event as a bool.
+	// ok is false if the value was not sent or is not a bool.
+	SyncBool(name string) (value bool, ok bool)
+
+	// LimitError returns the exceeded limit in ETYPE_LIMIT_EXCEEDED events.
+	// nil is returned for other event types.
+	LimitError() *LimitError
+
+	// ReloadComp forces the specified component to be re-rendered
+	// in the window it is added to, even if that is not the window
+	// the event originates from (in which case it is delivered over
+	// the push channel of the window). See Window.Refresh() for details.
+	// If the component is not added to a window of the session,
+	// this is the same as MarkDirty().
+	ReloadComp(c Comp)
+
 	// SetFocusedComp sets the component to be focused after processing
 	// the current event.
 	SetFocusedComp(comp Comp)
 
+	// SendFile sends a file to the browser as a download after processing
+	// the current event, e.g. a server generated report or CSV export.
+	// The content is read from r when the browser fetches the file
+	// (after the event handlers returned). If r is an io.Closer,
+	// it is closed after the content is sent (or if it is not fetched in time).
+	// If contentType is empty, "application/octet-stream" is used.
+	// Only one file can be sent per event, if called multiple times,
+	// only the last file is sent.
+	// The file is not sent if the window is reloaded.
+	SendFile(name, contentType string, r io.Reader)
+
+	// ShowDialog shows the specified dialog on top of the window
+	// the event originates from, after processing the current event.
+	// If the dialog is already shown, it is brought to the top.
+	// This is a no-op if the event does not originate from a window
+	// (e.g. the event passed to the function of Session.Push()).
+	ShowDialog(d Dialog)
+
+	// CloseDialog closes the specified dialog
+	// after processing the current event.
+	// This is a no-op if the dialog is not shown.
+	CloseDialog(d Dialog)
+
 	// Session returns the current session.
 	// The Private() method of the session can be used to tell if the session
 	// is a private session or the public shared session.
@@ -291,6 +479,8 @@ type eventImpl struct {
 
 	x, y int // Mouse coordinates (relative to component); not part of shared data because they component-relative
 
+	limitErr *LimitError // The exceeded limit (ETYPE_LIMIT_EXCEEDED only)
+
 	shared *sharedEvtData // Shared event data
 }
 
@@ -303,11 +493,16 @@ type sharedEvtData struct {
 	modKeys int      // State of the modifier keys
 	keyCode Key      // Key code
 
-	reload      bool        // Tells if the window has to be reloaded
-	reloadWin   string      // The name of the window to be reloaded
-	dirtyComps  map[ID]Comp // The dirty components
-	focusedComp Comp        // Component to be focused after the event processing
-	session     Session     // Session
+	request *requestInfoImpl // Request the event originates from
+	win     Window           // Window the event originates from
+
+	reload      bool              // Tells if the window has to be reloaded
+	reloadWin   string            // The name of the window to be reloaded
+	dirtyComps  map[ID]Comp       // The dirty components
+	syncValues  map[string]string // Additional named values sent with the event
+	focusedComp Comp              // Component to be focused after the event processing
+	download    *download         // File to be downloaded after the event processing
+	session     Session           // Session
 }
 
 // newEventImpl creates a new eventImpl
@@ -321,6 +516,13 @@ func (e *eventImpl) Type() EventType {
 	return e.etype
 }
 
+func (e *eventImpl) Request() RequestInfo {
+	if e.shared.request == nil {
+		return nil // Return an untyped nil, not a nil *requestInfoImpl
+	}
+	return e.shared.request
+}
+
 func (e *eventImpl) Src() Comp {
 	return e.src
 }
@@ -367,24 +569,100 @@ func (e *eventImpl) MarkDirty(comps ...Comp) {
 	// even if later the panel (P) is removed completely, and its child (A) is added to another Panel (P2).
 	// In this case P2 will be (must be) marked dirty, and the child (A) will be re-rendered properly
 	// along with P2.
+	// 
+	// Descendants of comp marked dirty earlier are not removed here (that would make marking
+	// quadratic), the dirty components are coalesced once when the event processing ends.
 
 	shared := e.shared
 
 	for _, comp := range comps {
 		if !shared.dirty(comp) { // If not yet dirty
-			// Before adding it, remove all components that are
-			// descendants of comp, they will inherit the dirty mark from comp.
-			for id, c := range shared.dirtyComps {
-				if c.DescendantOf(comp) {
-					delete(shared.dirtyComps, id)
-				}
-			}
-
 			shared.dirtyComps[comp.Id()] = comp
 		}
 	}
 }
 
+func (e *eventImpl) DirtyComps() []Comp {
+	shared := e.shared
+	shared.coalesceDirty()
+
+	ids := make([]int, 0, len(shared.dirtyComps))
+	for id := range shared.dirtyComps {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	comps := make([]Comp, len(ids))
+	for i, id := range ids {
+		comps[i] = shared.dirtyComps[ID(id)]
+	}
+	return comps
+}
+
+func (e *eventImpl) SyncValue(name string) (value string, ok bool) {
+	value, ok = e.shared.syncValues[name]
+	return
+}
+
+func (e *eventImpl) SyncInt(name string) (value int, ok bool) {
+	if s, found := e.shared.syncValues[name]; found {
+		if v, err := strconv.Atoi(s); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func (e *eventImpl) SyncFloat(name string) (value float64, ok bool) {
+	if s, found := e.shared.syncValues[name]; found {
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func (e *eventImpl) SyncBool(name string) (value bool, ok bool) {
+	if s, found := e.shared.syncValues[name]; found {
+		if v, err := strconv.ParseBool(s); err == nil {
+			return v, true
+		}
+	}
+	return false, false
+}
+
+func (e *eventImpl) LimitError() *LimitError {
+	return e.limitErr
+}
+
+func (e *eventImpl) ReloadComp(c Comp) {
+	if win := e.shared.session.compWin(c); win != nil {
+		e.reloadIn(win, c)
+	} else {
+		e.MarkDirty(c)
+	}
+}
+
+// reloadIn re-renders the specified components of the specified window.
+func (e *eventImpl) reloadIn(win Window, comps ...Comp) {
+	if len(comps) == 0 {
+		return
+	}
+
+	// Dirty components of events not originating from a window (e.g. pushes)
+	// are delivered to their windows anyway.
+	if e.shared.win == nil || e.shared.win.Id() == win.Id() {
+		e.MarkDirty(comps...)
+		return
+	}
+
+	ids := make([]ID, len(comps))
+	for i, c := range comps {
+		ids[i] = c.Id()
+	}
+	win.pushQueue().add(ids, false)
+}
+
 // dirty returns true if the specified component is already marked dirty.
 // Note that a component being dirty makes all of its descendants dirty, recursively.
 // 
@@ -397,9 +675,9 @@ func (s *sharedEvtData) dirty(c2 Comp) bool {
 		return true
 	}
 
-	// Second-class being dirty:
-	for _, c := range s.dirtyComps {
-		if c2.DescendantOf(c) {
+	// Second-class being dirty (walk up the ancestors):
+	for parent := c2.Parent(); parent != nil; parent = parent.Parent() {
+		if _, found := s.dirtyComps[parent.Id()]; found {
 			return true
 		}
 	}
@@ -407,10 +685,47 @@ func (s *sharedEvtData) dirty(c2 Comp) bool {
 	return false
 }
 
+// coalesceDirty removes the dirty components which have a dirty ancestor,
+// based on the current component tree, so only the topmost dirty
+// components remain (which are re-rendered along with their descendants).
+func (s *sharedEvtData) coalesceDirty() {
+	for id, c := range s.dirtyComps {
+		if parent := c.Parent(); parent != nil && s.dirty(parent) {
+			delete(s.dirtyComps, id)
+		}
+	}
+}
+
 func (e *eventImpl) SetFocusedComp(comp Comp) {
 	e.shared.focusedComp = comp
 }
 
+func (e *eventImpl) SendFile(name, contentType string, r io.Reader) {
+	if d := e.shared.download; d != nil {
+		d.close()
+	}
+	e.shared.download = &download{name: name, contentType: contentType, r: r}
+}
+
+func (e *eventImpl) ShowDialog(d Dialog) {
+	if e.shared.win == nil {
+		return
+	}
+	layer := e.shared.win.dialogLayer()
+	if oldLayer, isLayer := d.Parent().(*dialogLayer); isLayer && oldLayer != layer {
+		e.MarkDirty(oldLayer)
+	}
+	layer.show(d)
+	e.MarkDirty(layer)
+}
+
+func (e *eventImpl) CloseDialog(d Dialog) {
+	if layer, isLayer := d.Parent().(*dialogLayer); isLayer {
+		layer.Remove(d)
+		e.MarkDirty(layer)
+	}
+}
+
 func (e *eventImpl) Session() Session {
 	return e.shared.session
 }
@@ -420,7 +735,7 @@ func (e *eventImpl) NewSession() Session {
 }
 
 func (e *eventImpl) RemoveSess() {
-	e.shared.server.removeSess(e)
+	e.shared.server.removeSess(e, SESS_REMOVE_LOGOUT)
 }
 
 func (e *eventImpl) forkEvent(etype EventType, src Comp) Event {
@@ -0,0 +1,177 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Topic based event bus: publishing and subscribing to domain events.
+
+package gwu
+
+import (
+	"strings"
+	"sync"
+)
+
+// BusHandler is a function which is called when a domain event is published
+// on a topic it is subscribed to. data is the data of the domain event.
+type BusHandler func(e Event, topic string, data interface{})
+
+// Subscription is the subscription of a handler to a topic of an EventBus.
+type Subscription interface {
+	// Topic returns the topic of the subscription.
+	Topic() string
+
+	// Unsubscribe cancels the subscription.
+	Unsubscribe()
+}
+
+// EventBus interface defines a topic based publish / subscribe event bus,
+// so loosely coupled components and windows can publish and subscribe to
+// domain events such as "order.updated" without knowing about each other.
+//
+// A topic ending with ".*" subscribes to all topics having that prefix,
+// e.g. "order.*" matches "order.updated" and "order.deleted"; "*" matches all topics.
+//
+// Handlers of subscribers in the publishing session are called synchronously,
+// within the publishing event; handlers of subscribers in other sessions
+// (possible on the application-wide bus) are called by Session.Push().
+// The components passed on subscription are marked dirty automatically after
+// the handler is called.
+//
+// Subscribers should unsubscribe when their components are discarded,
+// e.g. when their window is removed.
+type EventBus interface {
+	// Subscribe subscribes a handler to a topic.
+	// sess is the session of the subscriber, comps are the components of the
+	// subscriber to be marked dirty after the handler is called.
+	Subscribe(sess Session, topic string, handler BusHandler, comps ...Comp) Subscription
+
+	// Publish publishes a domain event on a topic.
+	// e is the event during which the domain event is published;
+	// it may be nil if publishing outside of event handling (e.g. from a background goroutine),
+	// in which case all handlers are called by Session.Push().
+	Publish(e Event, topic string, data interface{})
+}
+
+// Name of the session attribute storing the event bus.
+const _ATTR_EVENT_BUS = "gwu-EventBus"
+
+// Mutex to synchronize the creation of event buses.
+var eventBusMutex sync.Mutex
+
+// EventBusOf returns the event bus of the specified session, it is created on first use.
+// Since the Server is a Session (the public session), pass the Server
+// to get the application-wide event bus.
+func EventBusOf(sess Session) EventBus {
+	eventBusMutex.Lock()
+	defer eventBusMutex.Unlock()
+
+	if bus, ok := sess.Attr(_ATTR_EVENT_BUS).(EventBus); ok {
+		return bus
+	}
+	bus := &eventBusImpl{}
+	sess.SetAttr(_ATTR_EVENT_BUS, bus)
+	return bus
+}
+
+// EventBus implementation.
+type eventBusImpl struct {
+	mutex sync.Mutex          // Mutex to synchronize subscription access
+	subs  []*subscriptionImpl // Subscriptions
+}
+
+// Subscription implementation.
+type subscriptionImpl struct {
+	bus     *eventBusImpl // The bus of the subscription
+	sess    Session       // Session of the subscriber
+	topic   string        // The subscribed topic
+	handler BusHandler    // The handler to call
+	comps   []Comp        // Components to mark dirty
+}
+
+func (b *eventBusImpl) Subscribe(sess Session, topic string, handler BusHandler, comps ...Comp) Subscription {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	sub := &subscriptionImpl{bus: b, sess: sess, topic: topic, handler: handler, comps: comps}
+	b.subs = append(b.subs, sub)
+	return sub
+}
+
+func (b *eventBusImpl) Publish(e Event, topic string, data interface{}) {
+	// Copy matching subscriptions, handlers may (un)subscribe
+	b.mutex.Lock()
+	var subs []*subscriptionImpl
+	for _, sub := range b.subs {
+		if topicMatches(sub.topic, topic) {
+			subs = append(subs, sub)
+		}
+	}
+	b.mutex.Unlock()
+
+	for _, sub := range subs {
+		if e != nil && sub.sess == e.Session() {
+			sub.call(e, topic, data)
+			continue
+		}
+
+		sub := sub
+		push := func() {
+			sub.sess.Push(func(e Event) {
+				sub.call(e, topic, data)
+			})
+		}
+		if e != nil {
+			// The publishing session is locked, do not wait for the subscriber's session
+			go push()
+		} else {
+			push()
+		}
+	}
+}
+
+// topicMatches tells if a subscribed topic (pattern) matches a published topic.
+func topicMatches(pattern, topic string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(topic, pattern[:len(pattern)-1])
+	}
+	return pattern == topic
+}
+
+func (s *subscriptionImpl) Topic() string {
+	return s.topic
+}
+
+func (s *subscriptionImpl) Unsubscribe() {
+	b := s.bus
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for i, sub := range b.subs {
+		if sub == s {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// call calls the handler of the subscription, and marks the components dirty.
+func (s *subscriptionImpl) call(e Event, topic string, data interface{}) {
+	s.handler(e, topic, data)
+	if len(s.comps) > 0 {
+		e.MarkDirty(s.comps...)
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Defines the FileUpload component.
+
+package gwu
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// FileUpload interface defines a component for uploading files
+// from the browser to the server.
+// 
+// When the user selects a file, it is uploaded (streamed) to the server
+// right away. During the upload ETYPE_UPLOAD_PROGRESS events are
+// dispatched periodically (Progress() tells the progress), and when the
+// upload completes, ETYPE_UPLOAD_DONE is dispatched. In ETYPE_UPLOAD_DONE
+// handlers the uploaded content can be read from Reader().
+// 
+// Changes made in ETYPE_UPLOAD_PROGRESS handlers are delivered to the
+// browser as pushes (see Session.Push()), so they are displayed during
+// the upload if the push channel of the window is enabled
+// (see Window.SetPushEnabled()) or WebSocket is used (see Server.SetWsEnabled()).
+// 
+// Suggested event type to handle actions: ETYPE_UPLOAD_DONE
+// 
+// Default style class: "gwu-FileUpload"
+type FileUpload interface {
+	// FileUpload is a component.
+	Comp
+
+	// FileUpload can be enabled/disabled.
+	HasEnabled
+
+	// Accept returns the accepted file types.
+	Accept() string
+
+	// SetAccept sets the accepted file types, a comma separated list
+	// of file extensions and/or MIME types, e.g. ".png,.jpg" or "image/*".
+	// This is only a hint for the browser's file chooser, it is not
+	// enforced on the server side.
+	SetAccept(accept string)
+
+	// MaxSize returns the max size of uploaded files in bytes.
+	MaxSize() int64
+
+	// SetMaxSize sets the max size of uploaded files in bytes.
+	// Uploads exceeding this size are rejected, and an ETYPE_LIMIT_EXCEEDED
+	// event is dispatched (see Event.LimitError()).
+	// Pass a value <= 0 to not limit the size. This is the default.
+	SetMaxSize(maxSize int64)
+
+	// FileName returns the name of the file being uploaded
+	// or last uploaded.
+	FileName() string
+
+	// FileType returns the content (MIME) type of the file being
+	// uploaded or last uploaded, as reported by the browser.
+	FileType() string
+
+	// Progress returns the number of bytes received so far and
+	// the total size of the file being uploaded or last uploaded.
+	// total is -1 if it is not known.
+	Progress() (loaded, total int64)
+
+	// Reader returns a reader of the uploaded content.
+	// Only valid in ETYPE_UPLOAD_DONE event handlers, nil is returned
+	// outside of them.
+	Reader() io.Reader
+}
+
+// Min time between 2 upload progress events.
+const _UPLOAD_PROGRESS_INTERVAL = 500 * time.Millisecond
+
+// FileUpload implementation.
+type fileUploadImpl struct {
+	compImpl       // Component implementation
+	hasEnabledImpl // Has enabled implementation
+
+	accept        string    // Accepted file types
+	maxSize       int64     // Max size of uploaded files
+	fileName      string    // Name of the uploaded file
+	fileType      string    // Content type of the uploaded file
+	loaded, total int64     // Upload progress
+	reader        io.Reader // Reader of the uploaded content
+}
+
+// NewFileUpload creates a new FileUpload.
+func NewFileUpload() FileUpload {
+	c := &fileUploadImpl{compImpl: newInputCompImpl(nil), hasEnabledImpl: newHasEnabledImpl(), total: -1}
+	c.Style().AddClass("gwu-FileUpload")
+	return c
+}
+
+func (c *fileUploadImpl) Accept() string {
+	return c.accept
+}
+
+func (c *fileUploadImpl) SetAccept(accept string) {
+	c.accept = accept
+}
+
+func (c *fileUploadImpl) MaxSize() int64 {
+	return c.maxSize
+}
+
+func (c *fileUploadImpl) SetMaxSize(maxSize int64) {
+	c.maxSize = maxSize
+}
+
+func (c *fileUploadImpl) FileName() string {
+	return c.fileName
+}
+
+func (c *fileUploadImpl) FileType() string {
+	return c.fileType
+}
+
+func (c *fileUploadImpl) Progress() (loaded, total int64) {
+	return c.loaded, c.total
+}
+
+func (c *fileUploadImpl) Reader() io.Reader {
+	return c.reader
+}
+
+var (
+	_STR_FILE_INPUT_OP = []byte(`<input type="file"`)   // `<input type="file"`
+	_STR_ACCEPT        = []byte(` accept="`)            // ` accept="`
+	_STR_FU_ONCHANGE   = []byte(` onchange="fuUpload(`) // ` onchange="fuUpload(`
+	_STR_FU_THIS       = []byte(`this,`)                // `this,`
+	_STR_FU_INPUT_CL   = []byte(`)"/>`)                 // `)"/>`
+)
+
+func (c *fileUploadImpl) Render(w writer) {
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_FILE_INPUT_OP)
+	if len(c.accept) > 0 {
+		w.Write(_STR_ACCEPT)
+		w.Writees(c.accept)
+		w.Write(_STR_QUOTE)
+	}
+	c.renderEnabled(w)
+	w.Write(_STR_FU_ONCHANGE)
+	w.Write(_STR_FU_THIS)
+	w.Writev(int(c.id))
+	w.Write(_STR_FU_INPUT_CL)
+
+	w.Write(_STR_SPAN_CL)
+}
+
+// newUploadEvent creates a new upload event of the specified type.
+func (s *serverImpl) newUploadEvent(etype EventType, c *fileUploadImpl, sess Session, win Window, r *http.Request) *eventImpl {
+	e := newEventImpl(etype, c, s, sess)
+	shared := e.shared
+	e.x, e.y, shared.wx, shared.wy, shared.mbtn = -1, -1, -1, -1, MOUSE_BTN_UNKNOWN
+	shared.request = newRequestInfoImpl(r, s.ClientAddr(r))
+	shared.win = win
+	return e
+}
+
+// rejectUpload rejects a file upload exceeding a limit: dispatches
+// an ETYPE_LIMIT_EXCEEDED event to the FileUpload, and sends back the result.
+func (s *serverImpl) rejectUpload(c *fileUploadImpl, sess Session, win Window, err *LimitError, wr http.ResponseWriter, r *http.Request) {
+	rwMutex := sess.rwMutex()
+	rwMutex.Lock()
+	defer rwMutex.Unlock()
+
+	e := s.newUploadEvent(ETYPE_LIMIT_EXCEEDED, c, sess, win, r)
+	e.limitErr = err
+	c.dispatchEvent(e)
+
+	s.writeEresp(win, e.shared, wr, r)
+}
+
+// handleUpload handles a file upload: saves the uploaded content
+// to a temporary file, dispatching progress events periodically,
+// and when done, dispatches the upload done event.
+func (s *serverImpl) handleUpload(sess Session, win Window, wr http.ResponseWriter, r *http.Request) {
+	// Parameters are sent in the URL, the body is the file content which must
+	// not be parsed as a form (it is limited below). Setting the form makes
+	// later FormValue() calls (e.g. when dispatching events) use only the URL.
+	query := r.URL.Query()
+	r.Form, r.PostForm = query, make(url.Values)
+
+	id, err := AtoID(query.Get(_PARAM_COMP_ID))
+	if err != nil {
+		http.Error(wr, "Invalid component id!", http.StatusBadRequest)
+		return
+	}
+
+	if s.isStopping() {
+		// Uploads are not accepted anymore, just notify the client
+		wr.Header().Set("Content-Type", "text/plain; charset=utf-8") // We send it as text
+		s.writeShutdown(NewWriter(wr))
+		return
+	}
+
+	rwMutex := sess.rwMutex()
+
+	rwMutex.RLock()
+	c, isFileUpload := win.ById(id).(*fileUploadImpl)
+	var maxSize int64
+	var allowed bool
+	if isFileUpload {
+		maxSize = c.maxSize
+		// Same checks as for events
+		allowed = effectiveAccess(c, sess) == ACCESS_FULL && !c.inReadOnly() && c.enabled
+	}
+	rwMutex.RUnlock()
+
+	if !isFileUpload {
+		http.Error(wr, "FileUpload not found!", http.StatusBadRequest)
+		return
+	}
+	if !allowed {
+		if s.logger != nil {
+			s.logger.Println("\tUpload denied to comp:", id)
+		}
+		http.Error(wr, "Access denied!", http.StatusForbidden)
+		return
+	}
+	// Uploads are exempt from the max request size, the max size of the FileUpload applies
+	if maxSize > 0 {
+		r.Body = http.MaxBytesReader(wr, r.Body, maxSize)
+	}
+	if maxSize > 0 && r.ContentLength > maxSize {
+		s.rejectUpload(c, sess, win, &LimitError{Limit: LIMIT_UPLOAD_SIZE, Size: r.ContentLength, Max: maxSize}, wr, r)
+		return
+	}
+
+	// Reserve the upload from the quota of the session
+	var reserved, loaded int64
+	if r.ContentLength >= 0 {
+		if !sess.reserveUpload(r.ContentLength, s.uploadQuota) {
+			s.rejectUpload(c, sess, win, &LimitError{Limit: LIMIT_UPLOAD_QUOTA, Size: sess.Uploaded() + r.ContentLength, Max: s.uploadQuota}, wr, r)
+			return
+		}
+		reserved = r.ContentLength
+	} else if s.uploadQuota > 0 {
+		http.Error(wr, "Length required!", http.StatusLengthRequired)
+		return
+	}
+	// Only the received bytes count
+	defer func() { sess.reserveUpload(loaded-reserved, 0) }()
+
+	f, err := ioutil.TempFile("", "gwu-upload-")
+	if err != nil {
+		http.Error(wr, "Failed to save file!", http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	rwMutex.Lock()
+	c.fileName, c.fileType = query.Get(_PARAM_FILE_NAME), r.Header.Get("Content-Type")
+	c.loaded, c.total = 0, r.ContentLength
+	rwMutex.Unlock()
+
+	buf := make([]byte, 32*1024)
+	last := time.Now()
+	for {
+		n, err := r.Body.Read(buf)
+		if n > 0 {
+			if _, err := f.Write(buf[:n]); err != nil {
+				http.Error(wr, "Failed to save file!", http.StatusInternalServerError)
+				return
+			}
+			loaded += int64(n)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(wr, "Failed to receive file!", http.StatusBadRequest)
+			return
+		}
+
+		if time.Since(last) >= _UPLOAD_PROGRESS_INTERVAL {
+			last = time.Now()
+			rwMutex.Lock()
+			c.loaded = loaded
+			e := s.newUploadEvent(ETYPE_UPLOAD_PROGRESS, c, sess, win, r)
+			c.dispatchEvent(e)
+			sess.pushChanges(e.shared)
+			rwMutex.Unlock()
+		}
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		http.Error(wr, "Failed to save file!", http.StatusInternalServerError)
+		return
+	}
+
+	rwMutex.Lock()
+	defer rwMutex.Unlock()
+
+	c.loaded = loaded
+	if c.total < 0 {
+		c.total = loaded
+	}
+	c.reader = f
+	e := s.newUploadEvent(ETYPE_UPLOAD_DONE, c, sess, win, r)
+	c.dispatchEvent(e)
+	c.reader = nil
+
+	s.writeEresp(win, e.shared, wr, r)
+}
@@ -0,0 +1,316 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// FlexPanel component interface and implementation.
+
+package gwu
+
+import (
+	"strconv"
+)
+
+// Justify content type: alignment of flex items along the main axis.
+type Justify string
+
+// Justify content constants.
+const (
+	JUSTIFY_START         Justify = "flex-start"    // Items are packed to the start
+	JUSTIFY_END           Justify = "flex-end"      // Items are packed to the end
+	JUSTIFY_CENTER        Justify = "center"        // Items are centered
+	JUSTIFY_SPACE_BETWEEN Justify = "space-between" // Free space is distributed between items
+	JUSTIFY_SPACE_AROUND  Justify = "space-around"  // Free space is distributed around items
+	JUSTIFY_SPACE_EVENLY  Justify = "space-evenly"  // Free space is distributed evenly
+
+	JUSTIFY_DEFAULT Justify = "" // Browser default (JUSTIFY_START)
+)
+
+// String returns the CSS value of the justify content.
+func (j Justify) String() string {
+	return string(j)
+}
+
+// Valid tells if the justify content is one of the JUSTIFY_ constants.
+func (j Justify) Valid() bool {
+	switch j {
+	case JUSTIFY_START, JUSTIFY_END, JUSTIFY_CENTER, JUSTIFY_SPACE_BETWEEN, JUSTIFY_SPACE_AROUND, JUSTIFY_SPACE_EVENLY, JUSTIFY_DEFAULT:
+		return true
+	}
+	return false
+}
+
+// Flex alignment type: alignment of flex items along the cross axis.
+type FlexAlign string
+
+// Flex alignment constants.
+const (
+	FLEX_ALIGN_START    FlexAlign = "flex-start" // Items are aligned to the start
+	FLEX_ALIGN_END      FlexAlign = "flex-end"   // Items are aligned to the end
+	FLEX_ALIGN_CENTER   FlexAlign = "center"     // Items are centered
+	FLEX_ALIGN_STRETCH  FlexAlign = "stretch"    // Items are stretched to fill the cross size
+	FLEX_ALIGN_BASELINE FlexAlign = "baseline"   // Items are aligned by their baselines
+
+	FLEX_ALIGN_DEFAULT FlexAlign = "" // Browser default (FLEX_ALIGN_STRETCH), or the align items of the panel for a child
+)
+
+// String returns the CSS value of the flex alignment.
+func (a FlexAlign) String() string {
+	return string(a)
+}
+
+// Valid tells if the flex alignment is one of the FLEX_ALIGN_ constants.
+func (a FlexAlign) Valid() bool {
+	switch a {
+	case FLEX_ALIGN_START, FLEX_ALIGN_END, FLEX_ALIGN_CENTER, FLEX_ALIGN_STRETCH, FLEX_ALIGN_BASELINE, FLEX_ALIGN_DEFAULT:
+		return true
+	}
+	return false
+}
+
+// FlexPanel interface defines a Panel which lays out its children
+// using CSS flexbox instead of a table, which makes responsive
+// layouts possible (e.g. children wrapping to multiple lines on narrow screens).
+//
+// Layout of a FlexPanel is either LAYOUT_FLEX_ROW or LAYOUT_FLEX_COLUMN.
+// Flex settings of the children can be specified by their flex formatters
+// (see FlexFmt()).
+//
+// Default style classes: "gwu-Panel", "gwu-Panel-FlexRow", "gwu-Panel-FlexColumn"
+type FlexPanel interface {
+	// FlexPanel is a Panel.
+	Panel
+
+	// Justify returns the alignment of the children along the main axis.
+	Justify() Justify
+
+	// SetJustify sets the alignment of the children along the main axis.
+	SetJustify(justify Justify)
+
+	// AlignItems returns the alignment of the children along the cross axis.
+	AlignItems() FlexAlign
+
+	// SetAlignItems sets the alignment of the children along the cross axis.
+	SetAlignItems(align FlexAlign)
+
+	// Wrap tells if children wrap to multiple lines if they do not fit.
+	Wrap() bool
+
+	// SetWrap sets if children wrap to multiple lines if they do not fit.
+	// Default is false.
+	SetWrap(wrap bool)
+
+	// Gap returns the gap between children, e.g. "10px".
+	Gap() string
+
+	// SetGap sets the gap between children, e.g. "10px" or "1em".
+	SetGap(gap string)
+
+	// SetGapPx sets the gap between children, in pixels.
+	SetGapPx(gap int)
+
+	// LayoutAt returns the layout of the panel at the specified breakpoint.
+	LayoutAt(bp Breakpoint) Layout
+
+	// SetLayoutAt sets the layout of the panel at the specified breakpoint
+	// (viewport width range), e.g. SetLayoutAt(BP_PHONE, LAYOUT_FLEX_COLUMN)
+	// stacks the children vertically on phones.
+	// Only LAYOUT_FLEX_ROW and LAYOUT_FLEX_COLUMN are supported,
+	// pass any other layout to use Layout() at the breakpoint.
+	SetLayoutAt(bp Breakpoint, layout Layout)
+
+	// FlexFmt returns the flex formatter of the specified child component.
+	// If the specified component is not a child, nil is returned.
+	FlexFmt(c Comp) FlexFmt
+}
+
+// FlexFmt interface defines a flex formatter which can be used to
+// specify the flex settings of the wrapper cells of
+// child components of a FlexPanel.
+//
+// Flex settings are stored in the style of the cell formatter,
+// so they are also available through CellFmt.
+type FlexFmt interface {
+	// FlexFmt is a CellFmt.
+	CellFmt
+
+	// Grow returns the grow factor, -1 if not set.
+	Grow() int
+
+	// SetGrow sets the grow factor: the share of the free space
+	// the child receives. Pass -1 to use the browser default (0).
+	SetGrow(grow int)
+
+	// Shrink returns the shrink factor, -1 if not set.
+	Shrink() int
+
+	// SetShrink sets the shrink factor: how much the child shrinks
+	// if there is not enough space. Pass -1 to use the browser default (1).
+	SetShrink(shrink int)
+
+	// Basis returns the initial size of the child, e.g. "200px" or "30%".
+	Basis() string
+
+	// SetBasis sets the initial size of the child, e.g. "200px" or "30%".
+	// Pass an empty string to use the browser default (the size of the content).
+	SetBasis(basis string)
+
+	// AlignSelf returns the alignment of the child along the cross axis.
+	AlignSelf() FlexAlign
+
+	// SetAlignSelf sets the alignment of the child along the cross axis,
+	// overriding the align items of the panel.
+	SetAlignSelf(align FlexAlign)
+
+	// Order returns the display order of the child, 0 if not set.
+	Order() int
+
+	// SetOrder sets the display order of the child. Children are displayed
+	// in increasing order, children with the same order in the order
+	// they were added.
+	SetOrder(order int)
+}
+
+// FlexPanel implementation.
+type flexPanelImpl struct {
+	panelImpl // Panel implementation
+}
+
+// NewFlexPanel creates a new FlexPanel initialized with
+// LAYOUT_FLEX_ROW layout.
+func NewFlexPanel() FlexPanel {
+	c := &flexPanelImpl{newPanelImpl()}
+	c.Style().AddClass("gwu-Panel")
+	c.SetLayout(LAYOUT_FLEX_ROW)
+	return c
+}
+
+// NewFlexColumnPanel creates a new FlexPanel initialized with
+// LAYOUT_FLEX_COLUMN layout.
+func NewFlexColumnPanel() FlexPanel {
+	c := NewFlexPanel()
+	c.SetLayout(LAYOUT_FLEX_COLUMN)
+	return c
+}
+
+func (c *flexPanelImpl) Justify() Justify {
+	return Justify(c.Style().Get(ST_JUSTIFY_CONTENT))
+}
+
+func (c *flexPanelImpl) SetJustify(justify Justify) {
+	c.Style().Set(ST_JUSTIFY_CONTENT, string(justify))
+}
+
+func (c *flexPanelImpl) AlignItems() FlexAlign {
+	return FlexAlign(c.Style().Get(ST_ALIGN_ITEMS))
+}
+
+func (c *flexPanelImpl) SetAlignItems(align FlexAlign) {
+	c.Style().Set(ST_ALIGN_ITEMS, string(align))
+}
+
+func (c *flexPanelImpl) Wrap() bool {
+	return c.Style().Get(ST_FLEX_WRAP) == "wrap"
+}
+
+func (c *flexPanelImpl) SetWrap(wrap bool) {
+	if wrap {
+		c.Style().Set(ST_FLEX_WRAP, "wrap")
+	} else {
+		c.Style().Set(ST_FLEX_WRAP, "")
+	}
+}
+
+func (c *flexPanelImpl) Gap() string {
+	return c.Style().Get(ST_GAP)
+}
+
+func (c *flexPanelImpl) SetGap(gap string) {
+	c.Style().Set(ST_GAP, gap)
+}
+
+func (c *flexPanelImpl) SetGapPx(gap int) {
+	c.SetGap(strconv.Itoa(gap) + "px")
+}
+
+func (c *flexPanelImpl) FlexFmt(c2 Comp) FlexFmt {
+	cf := c.CellFmt(c2)
+	if cf == nil {
+		return nil
+	}
+	return flexFmtImpl{cf.(*cellFmtImpl)}
+}
+
+// FlexFmt implementation.
+type flexFmtImpl struct {
+	*cellFmtImpl // Cell formatter implementation, flex settings are stored in its style
+}
+
+// intStyle returns the value of the specified style attribute as an int,
+// def if not set or not an int.
+func (c flexFmtImpl) intStyle(name string, def int) int {
+	if value, err := strconv.Atoi(c.Style().Get(name)); err == nil {
+		return value
+	}
+	return def
+}
+
+// setIntStyle sets the value of the specified style attribute as an int,
+// removes it if the value is equal to unset.
+func (c flexFmtImpl) setIntStyle(name string, value, unset int) {
+	if value == unset {
+		c.Style().Set(name, "")
+	} else {
+		c.Style().Set(name, strconv.Itoa(value))
+	}
+}
+
+func (c flexFmtImpl) Grow() int {
+	return c.intStyle(ST_FLEX_GROW, -1)
+}
+
+func (c flexFmtImpl) SetGrow(grow int) {
+	c.setIntStyle(ST_FLEX_GROW, grow, -1)
+}
+
+func (c flexFmtImpl) Shrink() int {
+	return c.intStyle(ST_FLEX_SHRINK, -1)
+}
+
+func (c flexFmtImpl) SetShrink(shrink int) {
+	c.setIntStyle(ST_FLEX_SHRINK, shrink, -1)
+}
+
+func (c flexFmtImpl) Basis() string {
+	return c.Style().Get(ST_FLEX_BASIS)
+}
+
+func (c flexFmtImpl) SetBasis(basis string) {
+	c.Style().Set(ST_FLEX_BASIS, basis)
+}
+
+func (c flexFmtImpl) AlignSelf() FlexAlign {
+	return FlexAlign(c.Style().Get(ST_ALIGN_SELF))
+}
+
+func (c flexFmtImpl) SetAlignSelf(align FlexAlign) {
+	c.Style().Set(ST_ALIGN_SELF, string(align))
+}
+
+func (c flexFmtImpl) Order() int {
+	return c.intStyle(ST_ORDER, 0)
+}
+
+func (c flexFmtImpl) SetOrder(order int) {
+	c.setIntStyle(ST_ORDER, order, 0)
+}
@@ -0,0 +1,541 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Graph component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+)
+
+// Graph layout direction type.
+type GraphLayout int
+
+// Graph layout directions.
+const (
+	GRAPH_LAYOUT_TOP_DOWN   GraphLayout = iota // Root nodes at the top, child nodes below their parents
+	GRAPH_LAYOUT_LEFT_RIGHT                    // Root nodes on the left, child nodes right to their parents
+)
+
+// Default node size and gaps of graphs (in pixels).
+const (
+	_GRAPH_NODE_WIDTH  = 120 // Default node width
+	_GRAPH_NODE_HEIGHT = 40  // Default node height
+	_GRAPH_GAP         = 30  // Gap between nodes of the same level
+	_GRAPH_LEVEL_GAP   = 50  // Gap between levels
+)
+
+// GraphNode interface defines a node of a Graph.
+// Graph nodes are not components, they are created by
+// the AddNode() method of the graph.
+type GraphNode interface {
+	// Id returns the unique id of the node.
+	Id() ID
+
+	// Graph returns the graph the node belongs to.
+	// Returns nil if the node has been removed from the graph.
+	Graph() Graph
+
+	// The node has a text which is displayed as its label.
+	HasText
+
+	// Data returns the user data attached to the node.
+	Data() interface{}
+
+	// SetData attaches a user data to the node,
+	// e.g. the host name in a network topology view.
+	SetData(data interface{})
+
+	// Class returns the additional style class of the node.
+	Class() string
+
+	// SetClass sets an additional style class of the node,
+	// e.g. to display the status of a host.
+	// Pass an empty string to remove it.
+	SetClass(class string)
+
+	// Pos returns the position of the node if it is fixed.
+	// fixed is false if the position is calculated by the layout.
+	Pos() (x, y int, fixed bool)
+
+	// SetPos fixes the position (top-left corner) of the node
+	// as a layout hint, overriding the calculated position.
+	SetPos(x, y int)
+
+	// ClearPos clears the fixed position of the node, so its
+	// position will be calculated by the layout.
+	ClearPos()
+}
+
+// GraphEdge is a directed edge of a Graph.
+type GraphEdge struct {
+	From, To GraphNode // Nodes connected by the edge
+	Text     string    // Label of the edge, optional
+}
+
+// Graph interface defines a component which displays nodes and edges,
+// e.g. for topology and org-chart views.
+//
+// Nodes are laid out as a tree: nodes without incoming edges are the roots,
+// and child nodes are placed below (or right to) their parents.
+// Positions of individual nodes can be fixed with GraphNode.SetPos().
+//
+// The user can pan the graph by dragging it, zoom with the mouse wheel,
+// and double click resets the view to fit the graph.
+//
+// You can register ETYPE_CLICK event handlers which will be called when the user
+// clicks on a node (or on the background). The event source will be the graph,
+// the node can be acquired by EventNode().
+//
+// Default style classes: "gwu-Graph", "gwu-Graph-Svg", "gwu-Graph-Edge", "gwu-Graph-EdgeText",
+// "gwu-Graph-Node"
+type Graph interface {
+	// Graph is a component.
+	Comp
+
+	// AddNode creates a new node with the specified text,
+	// and adds it to the graph.
+	AddNode(text string) GraphNode
+
+	// RemoveNode removes a node and its edges from the graph.
+	// Return value indicates if the node was in the graph and was removed.
+	RemoveNode(node GraphNode) bool
+
+	// Nodes returns the nodes of the graph.
+	Nodes() []GraphNode
+
+	// NodeById returns the node of the graph specified by its id.
+	// Returns nil if no such node exists.
+	NodeById(id ID) GraphNode
+
+	// AddEdge adds an edge from a node to another one with an optional label.
+	AddEdge(from, to GraphNode, text string)
+
+	// RemoveEdge removes the edges from a node to another one.
+	// Return value indicates if an edge was removed.
+	RemoveEdge(from, to GraphNode) bool
+
+	// Edges returns the edges of the graph.
+	Edges() []GraphEdge
+
+	// Clear removes all nodes and edges.
+	Clear()
+
+	// Layout returns the layout direction.
+	Layout() GraphLayout
+
+	// SetLayout sets the layout direction.
+	// Default is GRAPH_LAYOUT_TOP_DOWN.
+	SetLayout(layout GraphLayout)
+
+	// NodeSize returns the size of the nodes in pixels.
+	NodeSize() (width, height int)
+
+	// SetNodeSize sets the size of the nodes in pixels.
+	// Default is 120x40.
+	SetNodeSize(width, height int)
+
+	// EventNode returns the node of the last click event of the graph.
+	// Returns nil if the background was clicked.
+	EventNode() GraphNode
+}
+
+// Graph node implementation.
+type graphNodeImpl struct {
+	hasTextImpl // Has text implementation
+
+	id    ID          // The node id
+	graph *graphImpl  // The graph the node belongs to
+	data  interface{} // User data
+	class string      // Additional style class
+	x, y  int         // Fixed position
+	fixed bool        // Tells if the position is fixed
+}
+
+// graphEdge is an edge of the graph implementation.
+type graphEdge struct {
+	from, to *graphNodeImpl // Nodes connected by the edge
+	text     string         // Label of the edge
+}
+
+// Graph implementation.
+type graphImpl struct {
+	compImpl // Component implementation
+
+	nodes     []*graphNodeImpl      // Nodes of the graph
+	nodeMap   map[ID]*graphNodeImpl // Nodes of the graph mapped from node id
+	edges     []graphEdge           // Edges of the graph
+	layout    GraphLayout           // Layout direction
+	nodeW     int                   // Node width
+	nodeH     int                   // Node height
+	eventNode *graphNodeImpl        // Node of the last event
+}
+
+// NewGraph creates a new Graph.
+func NewGraph() Graph {
+	c := &graphImpl{compImpl: newCompImpl(nil), nodeMap: make(map[ID]*graphNodeImpl),
+		nodeW: _GRAPH_NODE_WIDTH, nodeH: _GRAPH_NODE_HEIGHT}
+	c.Style().AddClass("gwu-Graph")
+	return c
+}
+
+func (n *graphNodeImpl) Id() ID {
+	return n.id
+}
+
+func (n *graphNodeImpl) Graph() Graph {
+	if n.graph == nil {
+		return nil
+	}
+	return n.graph
+}
+
+func (n *graphNodeImpl) Data() interface{} {
+	return n.data
+}
+
+func (n *graphNodeImpl) SetData(data interface{}) {
+	n.data = data
+}
+
+func (n *graphNodeImpl) Class() string {
+	return n.class
+}
+
+func (n *graphNodeImpl) SetClass(class string) {
+	n.class = class
+}
+
+func (n *graphNodeImpl) Pos() (x, y int, fixed bool) {
+	return n.x, n.y, n.fixed
+}
+
+func (n *graphNodeImpl) SetPos(x, y int) {
+	n.x, n.y, n.fixed = x, y, true
+}
+
+func (n *graphNodeImpl) ClearPos() {
+	n.x, n.y, n.fixed = 0, 0, false
+}
+
+func (c *graphImpl) AddNode(text string) GraphNode {
+	n := &graphNodeImpl{hasTextImpl: newHasTextImpl(text), id: nextCompId(), graph: c}
+	c.nodes = append(c.nodes, n)
+	c.nodeMap[n.id] = n
+	return n
+}
+
+func (c *graphImpl) RemoveNode(node GraphNode) bool {
+	n := c.nodeMap[node.Id()]
+	if n == nil {
+		return false
+	}
+
+	for i, n2 := range c.nodes {
+		if n2 == n {
+			c.nodes = append(c.nodes[:i], c.nodes[i+1:]...)
+			break
+		}
+	}
+	delete(c.nodeMap, n.id)
+	if c.eventNode == n {
+		c.eventNode = nil
+	}
+	n.graph = nil
+
+	// Remove edges of the node
+	edges := c.edges[:0]
+	for _, e := range c.edges {
+		if e.from != n && e.to != n {
+			edges = append(edges, e)
+		}
+	}
+	c.edges = edges
+
+	return true
+}
+
+func (c *graphImpl) Nodes() []GraphNode {
+	nodes := make([]GraphNode, len(c.nodes))
+	for i, n := range c.nodes {
+		nodes[i] = n
+	}
+	return nodes
+}
+
+func (c *graphImpl) NodeById(id ID) GraphNode {
+	if n := c.nodeMap[id]; n != nil {
+		return n
+	}
+	return nil
+}
+
+func (c *graphImpl) AddEdge(from, to GraphNode, text string) {
+	f, t := c.nodeMap[from.Id()], c.nodeMap[to.Id()]
+	if f == nil || t == nil {
+		return
+	}
+	c.edges = append(c.edges, graphEdge{from: f, to: t, text: text})
+}
+
+func (c *graphImpl) RemoveEdge(from, to GraphNode) bool {
+	removed := false
+	edges := c.edges[:0]
+	for _, e := range c.edges {
+		if e.from.id == from.Id() && e.to.id == to.Id() {
+			removed = true
+			continue
+		}
+		edges = append(edges, e)
+	}
+	c.edges = edges
+	return removed
+}
+
+func (c *graphImpl) Edges() []GraphEdge {
+	edges := make([]GraphEdge, len(c.edges))
+	for i, e := range c.edges {
+		edges[i] = GraphEdge{From: e.from, To: e.to, Text: e.text}
+	}
+	return edges
+}
+
+func (c *graphImpl) Clear() {
+	for _, n := range c.nodes {
+		n.graph = nil
+	}
+	c.nodes = nil
+	c.nodeMap = make(map[ID]*graphNodeImpl)
+	c.edges = nil
+	c.eventNode = nil
+}
+
+func (c *graphImpl) Layout() GraphLayout {
+	return c.layout
+}
+
+func (c *graphImpl) SetLayout(layout GraphLayout) {
+	c.layout = layout
+}
+
+func (c *graphImpl) NodeSize() (width, height int) {
+	return c.nodeW, c.nodeH
+}
+
+func (c *graphImpl) SetNodeSize(width, height int) {
+	c.nodeW, c.nodeH = width, height
+}
+
+func (c *graphImpl) EventNode() GraphNode {
+	if c.eventNode == nil {
+		return nil
+	}
+	return c.eventNode
+}
+
+func (c *graphImpl) preprocessEvent(event Event, r *http.Request) {
+	c.eventNode = nil
+
+	id, err := AtoID(r.FormValue(_PARAM_COMP_VALUE))
+	if err != nil {
+		return
+	}
+	c.eventNode = c.nodeMap[id]
+}
+
+// graphPos is the position of a node.
+type graphPos struct {
+	x, y int
+}
+
+// positions calculates the positions (top-left corners) of the nodes.
+// Nodes are laid out as trees (spanning trees of the graph):
+// leaves take consecutive slots in their level, and parents are
+// centered above their children.
+func (c *graphImpl) positions() map[*graphNodeImpl]graphPos {
+	children := make(map[*graphNodeImpl][]*graphNodeImpl)
+	hasParent := make(map[*graphNodeImpl]bool)
+	for _, e := range c.edges {
+		children[e.from] = append(children[e.from], e.to)
+		if e.from != e.to {
+			hasParent[e.to] = true
+		}
+	}
+
+	// Slot and level of the nodes
+	slots := make(map[*graphNodeImpl]float64, len(c.nodes))
+	levels := make(map[*graphNodeImpl]int, len(c.nodes))
+	nextSlot := 0.0
+
+	var place func(n *graphNodeImpl, level int) float64
+	place = func(n *graphNodeImpl, level int) float64 {
+		levels[n] = level
+		slots[n] = -1 // Mark visited
+
+		first, last, count := 0.0, 0.0, 0
+		for _, ch := range children[n] {
+			if _, visited := slots[ch]; visited {
+				continue
+			}
+			slot := place(ch, level+1)
+			if count == 0 {
+				first = slot
+			}
+			last = slot
+			count++
+		}
+
+		if count == 0 {
+			slots[n] = nextSlot
+			nextSlot++
+		} else {
+			slots[n] = (first + last) / 2
+		}
+		return slots[n]
+	}
+
+	// Roots first, then the rest (nodes in cycles)
+	for _, n := range c.nodes {
+		if _, visited := slots[n]; !visited && !hasParent[n] {
+			place(n, 0)
+		}
+	}
+	for _, n := range c.nodes {
+		if _, visited := slots[n]; !visited {
+			place(n, 0)
+		}
+	}
+
+	pos := make(map[*graphNodeImpl]graphPos, len(c.nodes))
+	for _, n := range c.nodes {
+		if n.fixed {
+			pos[n] = graphPos{n.x, n.y}
+			continue
+		}
+		if c.layout == GRAPH_LAYOUT_LEFT_RIGHT {
+			pos[n] = graphPos{levels[n] * (c.nodeW + _GRAPH_LEVEL_GAP), int(slots[n] * float64(c.nodeH+_GRAPH_GAP))}
+		} else {
+			pos[n] = graphPos{int(slots[n] * float64(c.nodeW+_GRAPH_GAP)), levels[n] * (c.nodeH + _GRAPH_LEVEL_GAP)}
+		}
+	}
+	return pos
+}
+
+var (
+	_STR_GR_SVG_OP     = []byte(`<svg class="gwu-Graph-Svg"><g>`)       // `<svg class="gwu-Graph-Svg"><g>`
+	_STR_GR_SVG_CL     = []byte(`</g></svg>`)                           // `</g></svg>`
+	_STR_GR_EDGE_OP    = []byte(`<path class="gwu-Graph-Edge" d="M`)    // `<path class="gwu-Graph-Edge" d="M`
+	_STR_GR_EDGE_CL    = []byte(`"/>`)                                  // `"/>`
+	_STR_GR_ETEXT_OP   = []byte(`<text class="gwu-Graph-EdgeText" x="`) // `<text class="gwu-Graph-EdgeText" x="`
+	_STR_GR_NODE_OP    = []byte(`<g class="gwu-Graph-Node`)             // `<g class="gwu-Graph-Node`
+	_STR_GR_NODE_TR    = []byte(`" transform="translate(`)              // `" transform="translate(`
+	_STR_GR_NODE_CLICK = []byte(`)" onclick="grClick(event,`)           // `)" onclick="grClick(event,`
+	_STR_GR_RECT_OP    = []byte(`)"><rect rx="4" width="`)              // `)"><rect rx="4" width="`
+	_STR_GR_NTEXT_OP   = []byte(`"/><text x="`)                         // `"/><text x="`
+	_STR_GR_Y          = []byte(`" y="`)                                // `" y="`
+	_STR_GR_HEIGHT     = []byte(`" height="`)                           // `" height="`
+	_STR_GR_TEXT_CL    = []byte(`</text>`)                              // `</text>`
+	_STR_GR_NODE_CL    = []byte(`</text></g>`)                          // `</text></g>`
+	_STR_GR_INIT       = []byte(`<script>grInit(`)                      // `<script>grInit(`
+	_STR_GR_SCRIPT_CL  = []byte(`);</script>`)                          // `);</script>`
+)
+
+func (c *graphImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	pos := c.positions()
+	w.Write(_STR_GR_SVG_OP)
+
+	// Edges first so nodes are drawn over them
+	for _, e := range c.edges {
+		c.renderEdge(w, e, pos[e.from], pos[e.to])
+	}
+
+	for _, n := range c.nodes {
+		p := pos[n]
+		// To render: <g class="gwu-Graph-Node class" transform="translate(x,y)" onclick="grClick(event,compId,etype,nodeId)">
+		w.Write(_STR_GR_NODE_OP)
+		if len(n.class) > 0 {
+			w.Write(_STR_SPACE)
+			w.Writees(n.class)
+		}
+		w.Write(_STR_GR_NODE_TR)
+		w.Writevs(p.x, _STR_COMMA, p.y)
+		w.Write(_STR_GR_NODE_CLICK)
+		w.Writevs(int(c.id), _STR_COMMA, int(ETYPE_CLICK), _STR_COMMA, int(n.id))
+		// To render: )"><rect rx="4" width="w" height="h"/><text x="w/2" y="h/2">text</text></g>
+		w.Write(_STR_GR_RECT_OP)
+		w.Writev(c.nodeW)
+		w.Write(_STR_GR_HEIGHT)
+		w.Writev(c.nodeH)
+		w.Write(_STR_GR_NTEXT_OP)
+		w.Writev(c.nodeW / 2)
+		w.Write(_STR_GR_Y)
+		w.Writev(c.nodeH / 2)
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		w.Writees(n.text)
+		w.Write(_STR_GR_NODE_CL)
+	}
+
+	w.Write(_STR_GR_SVG_CL)
+
+	// To render: <script>grInit(compId);</script>
+	w.writeScript(_STR_GR_INIT)
+	w.Writev(int(c.id))
+	w.Write(_STR_GR_SCRIPT_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderEdge renders an edge between nodes at the specified positions.
+func (c *graphImpl) renderEdge(w writer, e graphEdge, from, to graphPos) {
+	// Edges are drawn as elbow connectors from the bottom (right) side
+	// of the source node to the top (left) side of the target node.
+	var x1, y1, x2, y2, tx, ty int
+	w.Write(_STR_GR_EDGE_OP)
+	if c.layout == GRAPH_LAYOUT_LEFT_RIGHT {
+		x1, y1 = from.x+c.nodeW, from.y+c.nodeH/2
+		x2, y2 = to.x, to.y+c.nodeH/2
+		xm := (x1 + x2) / 2
+		// To render: x1 y1 H xm V y2 H x2
+		w.Writevs(x1, " ", y1, " H ", xm, " V ", y2, " H ", x2)
+		tx, ty = xm, (y1+y2)/2
+	} else {
+		x1, y1 = from.x+c.nodeW/2, from.y+c.nodeH
+		x2, y2 = to.x+c.nodeW/2, to.y
+		ym := (y1 + y2) / 2
+		// To render: x1 y1 V ym H x2 V y2
+		w.Writevs(x1, " ", y1, " V ", ym, " H ", x2, " V ", y2)
+		tx, ty = x2, ym
+	}
+	w.Write(_STR_GR_EDGE_CL)
+
+	if len(e.text) > 0 {
+		// To render: <text class="gwu-Graph-EdgeText" x="tx" y="ty">text</text>
+		w.Write(_STR_GR_ETEXT_OP)
+		w.Writev(tx)
+		w.Write(_STR_GR_Y)
+		w.Writev(ty)
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		w.Writees(e.text)
+		w.Write(_STR_GR_TEXT_CL)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// GridPanel component interface and implementation.
+
+package gwu
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GridPanel interface defines a Panel which lays out its children
+// in a CSS grid, as an alternative of the limited row and column
+// spanning of HTML tables.
+//
+// Children are placed into the grid cells sequentially (row by row),
+// unless their position is specified explicitly. Positions and spans
+// of the children can be specified by their grid formatters (see GridFmt()).
+//
+// Layout of a GridPanel is LAYOUT_GRID.
+//
+// Default style classes: "gwu-Panel", "gwu-Panel-Grid"
+type GridPanel interface {
+	// GridPanel is a Panel.
+	Panel
+
+	// Columns returns the column template.
+	Columns() string
+
+	// SetColumns sets the column template (sizes of the columns),
+	// e.g. "200px 1fr 2fr" or "repeat(auto-fill, minmax(150px, 1fr))".
+	SetColumns(template string)
+
+	// SetColumnCount sets the column template to the specified number
+	// of equal width columns.
+	SetColumnCount(count int)
+
+	// Rows returns the row template.
+	Rows() string
+
+	// SetRows sets the row template (sizes of the rows), e.g. "auto 1fr auto".
+	// Pass an empty string to size rows by their content (this is the default).
+	SetRows(template string)
+
+	// Gap returns the gap between cells, e.g. "10px".
+	Gap() string
+
+	// SetGap sets the gap between cells, e.g. "10px", or "10px 20px"
+	// to specify different row and column gaps.
+	SetGap(gap string)
+
+	// SetGapPx sets the gap between cells, in pixels.
+	SetGapPx(gap int)
+
+	// GridFmt returns the grid formatter of the specified child component.
+	// If the specified component is not a child, nil is returned.
+	GridFmt(c Comp) GridFmt
+}
+
+// GridFmt interface defines a grid formatter which can be used to
+// specify the placement and spans of the wrapper cells of
+// child components of a GridPanel.
+//
+// Grid settings are stored in the style of the cell formatter,
+// so they are also available through CellFmt.
+type GridFmt interface {
+	// GridFmt is a CellFmt.
+	CellFmt
+
+	// Col returns the column (1-based) the child is placed in,
+	// 0 if it is placed automatically.
+	Col() int
+
+	// SetCol sets the column (1-based) the child is placed in.
+	// Pass 0 to place it automatically (this is the default).
+	SetCol(col int)
+
+	// ColSpan returns the number of columns the child spans.
+	ColSpan() int
+
+	// SetColSpan sets the number of columns the child spans.
+	// Default is 1.
+	SetColSpan(span int)
+
+	// Row returns the row (1-based) the child is placed in,
+	// 0 if it is placed automatically.
+	Row() int
+
+	// SetRow sets the row (1-based) the child is placed in.
+	// Pass 0 to place it automatically (this is the default).
+	SetRow(row int)
+
+	// RowSpan returns the number of rows the child spans.
+	RowSpan() int
+
+	// SetRowSpan sets the number of rows the child spans.
+	// Default is 1.
+	SetRowSpan(span int)
+}
+
+// GridPanel implementation.
+type gridPanelImpl struct {
+	panelImpl // Panel implementation
+}
+
+// NewGridPanel creates a new GridPanel with the specified column template
+// (e.g. "200px 1fr 2fr"). See GridPanel.SetColumns().
+func NewGridPanel(columns string) GridPanel {
+	c := &gridPanelImpl{newPanelImpl()}
+	c.Style().AddClass("gwu-Panel")
+	c.SetLayout(LAYOUT_GRID)
+	c.SetColumns(columns)
+	return c
+}
+
+func (c *gridPanelImpl) Columns() string {
+	return c.Style().Get(ST_GRID_TEMPLATE_COLUMNS)
+}
+
+func (c *gridPanelImpl) SetColumns(template string) {
+	c.Style().Set(ST_GRID_TEMPLATE_COLUMNS, template)
+}
+
+func (c *gridPanelImpl) SetColumnCount(count int) {
+	c.SetColumns("repeat(" + strconv.Itoa(count) + ", 1fr)")
+}
+
+func (c *gridPanelImpl) Rows() string {
+	return c.Style().Get(ST_GRID_TEMPLATE_ROWS)
+}
+
+func (c *gridPanelImpl) SetRows(template string) {
+	c.Style().Set(ST_GRID_TEMPLATE_ROWS, template)
+}
+
+func (c *gridPanelImpl) Gap() string {
+	return c.Style().Get(ST_GAP)
+}
+
+func (c *gridPanelImpl) SetGap(gap string) {
+	c.Style().Set(ST_GAP, gap)
+}
+
+func (c *gridPanelImpl) SetGapPx(gap int) {
+	c.SetGap(strconv.Itoa(gap) + "px")
+}
+
+func (c *gridPanelImpl) GridFmt(c2 Comp) GridFmt {
+	cf := c.CellFmt(c2)
+	if cf == nil {
+		return nil
+	}
+	return gridFmtImpl{cf.(*cellFmtImpl)}
+}
+
+// GridFmt implementation.
+type gridFmtImpl struct {
+	*cellFmtImpl // Cell formatter implementation, grid settings are stored in its style
+}
+
+// gridLine returns the start and span of the specified grid line style attribute.
+// The value is in the form of "start / span n", "start" or "span n".
+func (c gridFmtImpl) gridLine(name string) (start, span int) {
+	start, span = 0, 1
+	for _, part := range strings.Split(c.Style().Get(name), "/") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "span ") {
+			if n, err := strconv.Atoi(part[5:]); err == nil {
+				span = n
+			}
+		} else if n, err := strconv.Atoi(part); err == nil {
+			start = n
+		}
+	}
+	return
+}
+
+// setGridLine sets the specified grid line style attribute from the specified start and span.
+func (c gridFmtImpl) setGridLine(name string, start, span int) {
+	var value string
+	if start > 0 {
+		value = strconv.Itoa(start)
+	}
+	if span > 1 {
+		if len(value) > 0 {
+			value += " / "
+		}
+		value += "span " + strconv.Itoa(span)
+	}
+	c.Style().Set(name, value)
+}
+
+func (c gridFmtImpl) Col() int {
+	col, _ := c.gridLine(ST_GRID_COLUMN)
+	return col
+}
+
+func (c gridFmtImpl) SetCol(col int) {
+	c.setGridLine(ST_GRID_COLUMN, col, c.ColSpan())
+}
+
+func (c gridFmtImpl) ColSpan() int {
+	_, span := c.gridLine(ST_GRID_COLUMN)
+	return span
+}
+
+func (c gridFmtImpl) SetColSpan(span int) {
+	c.setGridLine(ST_GRID_COLUMN, c.Col(), span)
+}
+
+func (c gridFmtImpl) Row() int {
+	row, _ := c.gridLine(ST_GRID_ROW)
+	return row
+}
+
+func (c gridFmtImpl) SetRow(row int) {
+	c.setGridLine(ST_GRID_ROW, row, c.RowSpan())
+}
+
+func (c gridFmtImpl) RowSpan() int {
+	_, span := c.gridLine(ST_GRID_ROW)
+	return span
+}
+
+func (c gridFmtImpl) SetRowSpan(span int) {
+	c.setGridLine(ST_GRID_ROW, c.Row(), span)
+}
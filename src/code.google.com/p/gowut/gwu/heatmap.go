@@ -0,0 +1,292 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// HeatmapCalendar component interface and implementation.
+
+package gwu
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Default colors of HeatmapCalendar, from no activity to the highest.
+var DefaultHeatmapColors = []string{"#ebedf0", "#9be9a8", "#40c463", "#30a14e", "#216e39"}
+
+// HeatmapCalendar interface defines a component which displays values of days
+// as a contribution-style calendar: a grid of days (weeks in columns,
+// weekdays in rows) colored by the values, e.g. for activity and metrics overviews.
+//
+// You can register ETYPE_CLICK event handlers which will be called when the user
+// clicks on a day. The event source will be the calendar, the day can be
+// acquired by EventDate().
+//
+// Default style classes: "gwu-HeatmapCalendar", "gwu-HeatmapCalendar-Month",
+// "gwu-HeatmapCalendar-Weekday", "gwu-HeatmapCalendar-Day", "gwu-HeatmapCalendar-Legend"
+type HeatmapCalendar interface {
+	// HeatmapCalendar is a component.
+	Comp
+
+	// Data returns the values mapped from days.
+	Data() map[time.Time]float64
+
+	// SetData sets the values mapped from days.
+	// Only the date part (year, month, day) of the times is used,
+	// values of the same day are summed.
+	SetData(data map[time.Time]float64)
+
+	// Value returns the value of a day.
+	Value(day time.Time) float64
+
+	// SetValue sets the value of a day.
+	SetValue(day time.Time, value float64)
+
+	// Range returns the range of days displayed.
+	Range() (start, end time.Time)
+
+	// SetRange sets the range of days displayed.
+	// If end is zero, the last day of the data (or today if there is no data) is used.
+	// If start is zero, a year (52 weeks) before end is used.
+	SetRange(start, end time.Time)
+
+	// Colors returns the color scale.
+	Colors() []string
+
+	// SetColors sets the color scale (CSS colors): the first color is used
+	// for days without activity (zero or no value), the rest for
+	// evenly divided value ranges up to the max value.
+	// Default is DefaultHeatmapColors.
+	SetColors(colors ...string)
+
+	// MaxValue returns the max value of the color scale.
+	MaxValue() float64
+
+	// SetMaxValue sets the max value of the color scale.
+	// Pass 0 to use the max value of the displayed days.
+	// Default is 0.
+	SetMaxValue(max float64)
+
+	// EventDate returns the day of the last click event of the calendar.
+	EventDate() time.Time
+}
+
+// HeatmapCalendar implementation.
+type heatmapCalendarImpl struct {
+	compImpl // Component implementation
+
+	data       map[time.Time]float64 // Values mapped from days
+	start, end time.Time             // Range of days displayed
+	colors     []string              // Color scale
+	max        float64               // Max value of the color scale
+	eventDate  time.Time             // Day of the last event
+}
+
+// NewHeatmapCalendar creates a new HeatmapCalendar.
+func NewHeatmapCalendar(data map[time.Time]float64) HeatmapCalendar {
+	c := &heatmapCalendarImpl{compImpl: newCompImpl(nil), colors: DefaultHeatmapColors}
+	c.SetData(data)
+	c.Style().AddClass("gwu-HeatmapCalendar")
+	return c
+}
+
+func (c *heatmapCalendarImpl) Data() map[time.Time]float64 {
+	data := make(map[time.Time]float64, len(c.data))
+	for day, value := range c.data {
+		data[day] = value
+	}
+	return data
+}
+
+func (c *heatmapCalendarImpl) SetData(data map[time.Time]float64) {
+	c.data = make(map[time.Time]float64, len(data))
+	for day, value := range data {
+		c.data[truncDate(day)] += value
+	}
+}
+
+func (c *heatmapCalendarImpl) Value(day time.Time) float64 {
+	return c.data[truncDate(day)]
+}
+
+func (c *heatmapCalendarImpl) SetValue(day time.Time, value float64) {
+	c.data[truncDate(day)] = value
+}
+
+func (c *heatmapCalendarImpl) Range() (start, end time.Time) {
+	return c.start, c.end
+}
+
+func (c *heatmapCalendarImpl) SetRange(start, end time.Time) {
+	c.start, c.end = truncDate(start), truncDate(end)
+}
+
+func (c *heatmapCalendarImpl) Colors() []string {
+	return c.colors
+}
+
+func (c *heatmapCalendarImpl) SetColors(colors ...string) {
+	c.colors = colors
+}
+
+func (c *heatmapCalendarImpl) MaxValue() float64 {
+	return c.max
+}
+
+func (c *heatmapCalendarImpl) SetMaxValue(max float64) {
+	c.max = max
+}
+
+func (c *heatmapCalendarImpl) EventDate() time.Time {
+	return c.eventDate
+}
+
+func (c *heatmapCalendarImpl) preprocessEvent(event Event, r *http.Request) {
+	c.eventDate = time.Time{}
+	if date, err := time.Parse(_DATE_LAYOUT_ISO, r.FormValue(_PARAM_COMP_VALUE)); err == nil {
+		c.eventDate = date
+	}
+}
+
+// displayedRange returns the range of days displayed.
+func (c *heatmapCalendarImpl) displayedRange() (start, end time.Time) {
+	start, end = c.start, c.end
+	if end.IsZero() {
+		for day := range c.data {
+			if day.After(end) {
+				end = day
+			}
+		}
+		if end.IsZero() {
+			end = truncDate(time.Now())
+		}
+	}
+	if start.IsZero() || start.After(end) {
+		start = end.AddDate(0, 0, -52*7+1)
+	}
+	return
+}
+
+// level returns the index of the color of the specified value.
+func (c *heatmapCalendarImpl) level(value, max float64) int {
+	if value <= 0 || max <= 0 || len(c.colors) < 2 {
+		return 0
+	}
+	level := int(math.Ceil(value / max * float64(len(c.colors)-1)))
+	if level >= len(c.colors) {
+		level = len(c.colors) - 1
+	}
+	return level
+}
+
+// Weekday labels of HeatmapCalendar (every other one is displayed).
+var heatmapWeekdays = [7]string{"", "Mon", "", "Wed", "", "Fri", ""}
+
+var (
+	_STR_HM_TABLE_OP   = []byte(`<table cellspacing="2" cellpadding="0"><tr><td></td>`)     // `<table cellspacing="2" cellpadding="0"><tr><td></td>`
+	_STR_HM_MONTH_OP   = []byte(`<td class="gwu-HeatmapCalendar-Month">`)                   // `<td class="gwu-HeatmapCalendar-Month">`
+	_STR_HM_WEEKDAY_OP = []byte(`<tr><td class="gwu-HeatmapCalendar-Weekday">`)             // `<tr><td class="gwu-HeatmapCalendar-Weekday">`
+	_STR_HM_DAY_OP     = []byte(`<td class="gwu-HeatmapCalendar-Day" style="background:`)   // `<td class="gwu-HeatmapCalendar-Day" style="background:`
+	_STR_HM_TITLE      = []byte(`" title="`)                                                // `" title="`
+	_STR_HM_ONCLICK    = []byte(`" onclick="se(event,`)                                     // `" onclick="se(event,`
+	_STR_HM_DATE_OP    = []byte(`,'`)                                                       // `,'`
+	_STR_HM_DAY_CL     = []byte(`')"></td>`)                                                // `')"></td>`
+	_STR_HM_LEGEND_OP  = []byte(`<div class="gwu-HeatmapCalendar-Legend">Less `)            // `<div class="gwu-HeatmapCalendar-Legend">Less `
+	_STR_HM_LEGEND_DAY = []byte(`<span class="gwu-HeatmapCalendar-Day" style="background:`) // `<span class="gwu-HeatmapCalendar-Day" style="background:`
+	_STR_HM_LEGEND_CL  = []byte(` More</div>`)                                              // ` More</div>`
+	_STR_HM_TD_CL      = []byte("</td>")                                                    // "</td>"
+	_STR_HM_TR_CL      = []byte("</tr>")                                                    // "</tr>"
+)
+
+func (c *heatmapCalendarImpl) Render(w writer) {
+	start, end := c.displayedRange()
+	// First column starts on the Sunday of the week of start
+	first := start.AddDate(0, 0, -int(start.Weekday()))
+	weeks := int(end.Sub(first).Hours()/24)/7 + 1
+
+	max := c.max
+	if max <= 0 {
+		for day, value := range c.data {
+			if !day.Before(start) && !day.After(end) && value > max {
+				max = value
+			}
+		}
+	}
+
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	// Click events are sent by the days
+	for etype := range c.handlers {
+		if etype != ETYPE_CLICK {
+			c.renderEHandler(w, etype)
+		}
+	}
+	w.Write(_STR_GT)
+
+	// Month labels above the weeks in which the months start
+	w.Write(_STR_HM_TABLE_OP)
+	for week := 0; week < weeks; week++ {
+		w.Write(_STR_HM_MONTH_OP)
+		if day := first.AddDate(0, 0, week*7+6); day.Day() <= 7 || week == 0 && day.Day() <= 21 {
+			w.Writes(day.Month().String()[:3])
+		}
+		w.Write(_STR_HM_TD_CL)
+	}
+	w.Write(_STR_HM_TR_CL)
+
+	for weekday := 0; weekday < 7; weekday++ {
+		w.Write(_STR_HM_WEEKDAY_OP)
+		w.Writes(heatmapWeekdays[weekday])
+		w.Write(_STR_HM_TD_CL)
+		for week := 0; week < weeks; week++ {
+			day := first.AddDate(0, 0, week*7+weekday)
+			if day.Before(start) || day.After(end) {
+				w.Write(_STR_TD)
+				w.Write(_STR_HM_TD_CL)
+				continue
+			}
+			value := c.data[day]
+			// To render: <td class="gwu-HeatmapCalendar-Day" style="background:color" title="date: value"
+			// onclick="se(event,etype,compId,'date')"></td>
+			w.Write(_STR_HM_DAY_OP)
+			if len(c.colors) > 0 {
+				w.Writees(c.colors[c.level(value, max)])
+			}
+			w.Write(_STR_HM_TITLE)
+			w.Writess(day.Format(_DATE_LAYOUT_ISO), ": ", strconv.FormatFloat(value, 'g', -1, 64))
+			w.Write(_STR_HM_ONCLICK)
+			w.Writevs(int(ETYPE_CLICK), _STR_COMMA, int(c.id))
+			w.Write(_STR_HM_DATE_OP)
+			w.Writes(day.Format(_DATE_LAYOUT_ISO))
+			w.Write(_STR_HM_DAY_CL)
+		}
+		w.Write(_STR_HM_TR_CL)
+	}
+	w.Write(_STR_TABLE_CL)
+
+	// Legend of the color scale
+	w.Write(_STR_HM_LEGEND_OP)
+	for _, color := range c.colors {
+		w.Write(_STR_HM_LEGEND_DAY)
+		w.Writees(color)
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		w.Write(_STR_SPAN_CL)
+	}
+	w.Write(_STR_HM_LEGEND_CL)
+
+	w.Write(_STR_DIV_CL)
+}
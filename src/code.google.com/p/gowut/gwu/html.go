@@ -18,6 +18,8 @@
 package gwu
 
 // Html interface defines a component which wraps an HTML text into a component.
+// The HTML text is rendered as-is (not escaped), only use it with trusted markup,
+// never with user data! For texts use Label (see also SafeHTMLer).
 // 
 // Default style class: "gwu-Html"
 type Html interface {
@@ -0,0 +1,86 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Idle warning: warning the users before their sessions expire.
+
+package gwu
+
+import (
+	"net/http"
+	"time"
+)
+
+// Default texts of the idle warning.
+const (
+	DEFAULT_IDLE_WARNING_TEXT = "Your session is about to expire due to inactivity." // Default text of the idle warning
+	DEFAULT_KEEP_ALIVE_TEXT   = "Keep me signed in"                                  // Default text of the keep alive button
+)
+
+func (s *serverImpl) IdleWarning() time.Duration {
+	return s.idleWarning
+}
+
+func (s *serverImpl) SetIdleWarning(before time.Duration) {
+	s.idleWarning = before
+}
+
+func (s *serverImpl) IdleWarningTexts() (text, keepAliveText string) {
+	return s.idleWarningText, s.keepAliveText
+}
+
+func (s *serverImpl) SetIdleWarningTexts(text, keepAliveText string) {
+	s.idleWarningText, s.keepAliveText = text, keepAliveText
+}
+
+func (s *serverImpl) ExpiredWin() string {
+	return s.expiredWin
+}
+
+func (s *serverImpl) SetExpiredWin(name string) {
+	s.expiredWin = name
+}
+
+// expiredEvent responds to an event sent from a window which is not found
+// (typically because its session expired): the client loads the expired window
+// (see SetExpiredWin()), or reloads the window.
+func (s *serverImpl) expiredEvent(w http.ResponseWriter) {
+	if s.logger != nil {
+		s.logger.Println("\tEvent from window not found, session expired?")
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8") // We send it as text
+	NewWriter(w).Writevs(_ERA_RELOAD_WIN, _STR_COMMA, s.expiredWin)
+}
+
+// renderIdleJs renders the JavaScript variables of the idle warning
+// and of the session expiration of the specified window.
+func renderIdleJs(w writer, s Server, sess Session, win Window) {
+	before, expiredWin := s.IdleWarning(), s.ExpiredWin()
+	on := sess.Private() && (before > 0 || len(expiredWin) > 0) // The public session does not expire
+	if !on {
+		before = 0
+	}
+	timeout := sess.Timeout()
+	left := timeout - time.Since(sess.Accessed()) // Remaining lifetime of the session
+	text, keepAliveText := s.IdleWarningTexts()
+
+	w.Writevs("var _idleOn=", on, ",_idleWarn=", int(before/time.Millisecond), ",_idleTimeout=", int(timeout/time.Millisecond),
+		",_idleLeft=", int(left/time.Millisecond), ",_idleWinId=", int(win.Id()), ";")
+	if on {
+		w.Writess("var _idleExpiredWin='", jsEscape(expiredWin), "';")
+	}
+	if before > 0 {
+		w.Writess("var _idleText='", jsEscape(text), "',_idleKeepAlive='", jsEscape(keepAliveText), "';")
+	}
+}
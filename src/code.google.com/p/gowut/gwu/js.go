@@ -19,6 +19,7 @@ package gwu
 
 import (
 	"strconv"
+	"strings"
 )
 
 // Static JavaScript resource name
@@ -34,6 +35,8 @@ func init() {
 		"var _pEventType='" + _PARAM_EVENT_TYPE +
 		"',_pCompId='" + _PARAM_COMP_ID +
 		"',_pCompValue='" + _PARAM_COMP_VALUE +
+		"',_pCompVersion='" + _PARAM_COMP_VERSION +
+		"',_pCompValuePrefix='" + _PARAM_COMP_VALUE_PREFIX +
 		"',_pFocCompId='" + _PARAM_FOCUSED_COMP_ID +
 		"',_pMouseWX='" + _PARAM_MOUSE_WX +
 		"',_pMouseWY='" + _PARAM_MOUSE_WY +
@@ -42,6 +45,12 @@ func init() {
 		"',_pMouseBtn='" + _PARAM_MOUSE_BTN +
 		"',_pModKeys='" + _PARAM_MOD_KEYS +
 		"',_pKeyCode='" + _PARAM_KEY_CODE +
+		"',_pPushSeq='" + _PARAM_PUSH_SEQ +
+		"',_pTailSeq='" + _PARAM_TAIL_SEQ +
+		"',_pPushNoWait='" + _PARAM_PUSH_NOWAIT +
+		"',_pToken='" + _PARAM_TOKEN +
+		"',_pFileName='" + _PARAM_FILE_NAME +
+		"',_pSig='" + _PARAM_SIG +
 		"';\n" +
 		// Modifier key masks
 		"var _modKeyAlt=" + strconv.Itoa(int(MOD_KEY_ALT)) +
@@ -49,11 +58,25 @@ func init() {
 		",_modKeyMeta=" + strconv.Itoa(int(MOD_KEY_META)) +
 		",_modKeyShift=" + strconv.Itoa(int(MOD_KEY_SHIFT)) +
 		";\n" +
+		// Media capture modes
+		"var _captureAudio=" + strconv.Itoa(int(CAPTURE_AUDIO)) +
+		";\n" +
+		// Busy indicators
+		"var _busyNone=" + strconv.Itoa(int(BUSY_NONE)) +
+		",_busySpinner=" + strconv.Itoa(int(BUSY_SPINNER)) +
+		",_classNoBusy='" + _CLASS_NO_BUSY +
+		"';\n" +
 		// Event response action consts
 		"var _eraNoAction=" + strconv.Itoa(_ERA_NO_ACTION) +
 		",_eraReloadWin=" + strconv.Itoa(_ERA_RELOAD_WIN) +
 		",_eraDirtyComps=" + strconv.Itoa(_ERA_DIRTY_COMPS) +
 		",_eraFocusComp=" + strconv.Itoa(_ERA_FOCUS_COMP) +
+		",_eraPushSeq=" + strconv.Itoa(_ERA_PUSH_SEQ) +
+		",_eraSessCookie=" + strconv.Itoa(_ERA_SESS_COOKIE) +
+		",_eraSig=" + strconv.Itoa(_ERA_SIG) +
+		",_eraDownload=" + strconv.Itoa(_ERA_DOWNLOAD) +
+		",_eraTheme=" + strconv.Itoa(_ERA_THEME) +
+		",_eraShutdown=" + strconv.Itoa(_ERA_SHUTDOWN) +
 		";" +
 		`
 
@@ -64,18 +87,32 @@ function createXmlHttp() {
 		return xmlhttp=new ActiveXObject("Microsoft.XMLHTTP");
 }
 
-// Send event
-function se(event, etype, compId, compValue) {
-	var xmlhttp = createXmlHttp();
-	
-	xmlhttp.onreadystatechange = function() {
-		if (xmlhttp.readyState == 4 && xmlhttp.status == 200)
-			procEresp(xmlhttp);
+// Encodes binary data for transfer: base64 with URL-safe alphabet without padding.
+// data may be an ArrayBuffer, a typed array or a data URL (e.g. of a canvas snapshot).
+function b64(data) {
+	var s;
+	if (typeof data == "string") {
+		// Data URL, it's already base64 encoded
+		s = data.substring(data.indexOf(",") + 1);
+	} else {
+		var bytes = data instanceof ArrayBuffer ? new Uint8Array(data) : new Uint8Array(data.buffer, data.byteOffset, data.byteLength);
+		var bin = "";
+		for (var i = 0; i < bytes.length; i += 8192) // Chunks to stay within the argument count limit
+			bin += String.fromCharCode.apply(null, bytes.subarray(i, i + 8192));
+		s = btoa(bin);
 	}
-	
-	xmlhttp.open("POST", _pathEvent, true); // asynch call
-	xmlhttp.setRequestHeader("Content-type", "application/x-www-form-urlencoded");
-	
+	return s.replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+// Returns the path of an internal endpoint signed if signing is enabled
+function sp(path) {
+	if (_sig == null)
+		return path;
+	return path + (path.indexOf("?") < 0 ? "?" : "&") + _pSig + "=" + _sig;
+}
+
+// Send event
+function se(event, etype, compId, compValue, compVersion, compValues) {
 	var data="";
 	
 	if (etype != null)
@@ -84,8 +121,14 @@ function se(event, etype, compId, compValue) {
 		data += "&" + _pCompId + "=" + compId;
 	if (compValue != null)
 		data += "&" + _pCompValue + "=" + compValue;
+	if (compVersion != null)
+		data += "&" + _pCompVersion + "=" + compVersion;
+	if (compValues != null)
+		for (var name in compValues)
+			data += "&" + _pCompValuePrefix + name + "=" + encodeURIComponent(compValues[name]);
 	if (document.activeElement.id != null)
 		data += "&" + _pFocCompId + "=" + document.activeElement.id;
+	data += "&" + _pPushSeq + "=" + _pushSeq;
 	
 	if (event != null) {
 		if (event.clientX != null) {
@@ -112,9 +155,80 @@ function se(event, etype, compId, compValue) {
 		data += "&" + _pKeyCode + "=" + (event.which ? event.which : event.keyCode);
 	}
 	
+	var busy = busyStart(compId);
+	pushActivity();
+	idleReset();
+	
+	if (ws != null) {
+		_busyQueue.push(busy);
+		ws.send(data);
+		return;
+	}
+	
+	var xmlhttp = createXmlHttp();
+	
+	xmlhttp.onreadystatechange = function() {
+		if (xmlhttp.readyState != 4)
+			return;
+		if (xmlhttp.status == 200)
+			procEresp(xmlhttp);
+		busyEnd(busy);
+	}
+	
+	xmlhttp.open("POST", sp(_pathEvent), true); // asynch call
+	xmlhttp.setRequestHeader("Content-type", "application/x-www-form-urlencoded");
+	
 	xmlhttp.send(data);
 }
 
+var _busyBarCount = 0, _busyQueue = [];
+
+// Starts the busy indicator for an event of the specified component.
+// The indicator is displayed after a delay, so it does not flash for fast event handlers.
+// Returns the busy state to be passed to busyEnd(), null if no indicator is to be displayed.
+function busyStart(compId) {
+	if (_busyMode == _busyNone)
+		return null;
+	var e = compId == null ? null : document.getElementById(compId);
+	if (e != null && (" " + e.className + " ").indexOf(" " + _classNoBusy + " ") >= 0)
+		return null;
+	
+	var busy = {e: _busyMode == _busySpinner ? e : null, shown: false};
+	busy.timer = setTimeout(function() {
+		busy.shown = true;
+		if (busy.e != null)
+			busy.e.className += " gwu-Busy";
+		else if (_busyBarCount++ == 0)
+			busyBar().style.display = "block";
+	}, _busyDelay);
+	return busy;
+}
+
+// Ends the busy indicator started by busyStart().
+function busyEnd(busy) {
+	if (busy == null)
+		return;
+	clearTimeout(busy.timer);
+	if (!busy.shown)
+		return;
+	if (busy.e != null)
+		busy.e.className = busy.e.className.replace(" gwu-Busy", ""); // Element might have been re-rendered already
+	else if (--_busyBarCount == 0)
+		busyBar().style.display = "none";
+}
+
+// Returns the global busy bar, creates it first if needed.
+function busyBar() {
+	var bar = document.getElementById("gwu-BusyBar");
+	if (bar == null) {
+		bar = document.createElement("div");
+		bar.id = "gwu-BusyBar";
+		bar.className = "gwu-BusyBar";
+		document.body.appendChild(bar);
+	}
+	return bar;
+}
+
 function procEresp(xmlhttp) {
 	var actions = xmlhttp.responseText.split(";");
 	
@@ -128,7 +242,10 @@ function procEresp(xmlhttp) {
 		switch (parseInt(n[0])) {
 		case _eraDirtyComps:
 			for (var j = 1; j < n.length; j++)
-				rerenderComp(n[j]);
+				if (_pollPauseHidden && document.hidden)
+					pushDeferred[n[j]] = true; // Re-rendered when the page becomes visible
+				else
+					rerenderComp(n[j]);
 			break;
 		case _eraFocusComp:
 			if (n.length > 1)
@@ -136,6 +253,37 @@ function procEresp(xmlhttp) {
 			break;
 		case _eraNoAction:
 			break;
+		case _eraPushSeq:
+			if (n.length > 1)
+				_pushSeq = parseInt(n[1]);
+			break;
+		case _eraDownload:
+			if (n.length > 1)
+				downloadFile(n[1]);
+			break;
+		case _eraSig:
+			if (n.length > 1)
+				_sig = n[1];
+			break;
+		case _eraTheme:
+			if (n.length > 1)
+				document.getElementById("gwu-theme").href = n[1];
+			break;
+		case _eraShutdown:
+			serverShutdown(n.length > 1 ? decodeURIComponent(n[1]) : "");
+			break;
+		case _eraSessCookie:
+			// Session created over WebSocket: claim its cookie, then process the rest of the actions
+			var rest = actions.slice(i + 1).join(";");
+			var xmlhttp2 = createXmlHttp();
+			xmlhttp2.onreadystatechange = function() {
+				if (xmlhttp2.readyState == 4 && rest.length > 0)
+					procEresp({responseText: rest});
+			}
+			xmlhttp2.open("POST", _pathSessCookie, true); // asynch call
+			xmlhttp2.setRequestHeader("Content-type", "application/x-www-form-urlencoded");
+			xmlhttp2.send(_pToken + "=" + n[1]);
+			return;
 		case _eraReloadWin:
 			if (n.length > 1 && n[1].length > 0)
 				window.location.href = _pathApp + n[1];
@@ -149,6 +297,1482 @@ function procEresp(xmlhttp) {
 	}
 }
 
+var pushRetry = 1000, pushInterval = 0, pushTimer = null, pushPending = false, pushPolling = false, pushDeferred = {};
+
+// Poll for pushes (long polling, or polling in intervals if _pollInterval > 0)
+function pushPoll() {
+	pushTimer = null;
+	if (_shutdown)
+		return;
+	if (!pushPolling) {
+		pushPolling = true;
+		pushInterval = _pollInterval;
+	}
+	if (_pollPauseHidden && document.hidden)
+		return; // Resumed when the page becomes visible
+	var xmlhttp = createXmlHttp();
+	
+	xmlhttp.onreadystatechange = function() {
+		if (xmlhttp.readyState != 4)
+			return;
+		pushPending = false;
+		if (xmlhttp.status == 200) {
+			pushRetry = 1000;
+			var seq = _pushSeq;
+			procEresp(xmlhttp);
+			if (_pushSeq != seq)
+				pushInterval = _pollInterval;
+			else if (_pollMaxInterval > pushInterval)
+				// Idle, back off
+				pushInterval = Math.min(pushInterval * 2, _pollMaxInterval);
+			pushSchedule(pushInterval);
+		} else {
+			// Server unreachable or restarted, retry later with backoff
+			pushSchedule(pushRetry);
+			pushRetry = Math.min(pushRetry * 2, 60000);
+		}
+	}
+	
+	xmlhttp.open("POST", sp(_pathPush), true); // asynch call
+	xmlhttp.setRequestHeader("Content-type", "application/x-www-form-urlencoded");
+	
+	pushPending = true;
+	xmlhttp.send(_pPushSeq + "=" + _pushSeq + (_pollInterval > 0 ? "&" + _pPushNoWait + "=1" : ""));
+}
+
+// Schedules the next poll for pushes
+function pushSchedule(delay) {
+	if (pushTimer != null)
+		clearTimeout(pushTimer);
+	pushTimer = setTimeout(pushPoll, delay);
+}
+
+// Resets the poll interval backed off while idle, called on user activity
+function pushActivity() {
+	if (!pushPolling || pushInterval <= _pollInterval)
+		return;
+	pushInterval = _pollInterval;
+	if (pushTimer != null)
+		pushSchedule(pushInterval);
+}
+
+addonvisibilitychange(function() {
+	if (document.hidden)
+		return;
+	// Render the changes deferred while the page was hidden
+	for (var compId in pushDeferred)
+		rerenderComp(compId);
+	pushDeferred = {};
+	// Resume polling paused while the page was hidden (with a poll right away)
+	if (pushPolling && !pushPending && ws == null) {
+		pushInterval = _pollInterval;
+		pushSchedule(0);
+	}
+});
+
+var _shutdown = false;
+
+// Handles the shutdown of the server: displays the shutdown text (if any),
+// and reloads the window when the server is available again
+function serverShutdown(text) {
+	if (_shutdown)
+		return;
+	_shutdown = true;
+	
+	if (text.length > 0) {
+		var overlay = document.createElement("div");
+		overlay.className = "gwu-ShutdownOverlay";
+		var box = document.createElement("div");
+		box.className = "gwu-ShutdownOverlay-Text";
+		setText(box, text);
+		overlay.appendChild(box);
+		document.body.appendChild(overlay);
+	}
+	
+	var probe = function() {
+		var xmlhttp = createXmlHttp();
+		xmlhttp.onreadystatechange = function() {
+			if (xmlhttp.readyState != 4)
+				return;
+			if (xmlhttp.status == 200)
+				window.location.reload(true);
+			else
+				setTimeout(probe, 3000);
+		}
+		xmlhttp.open("GET", window.location.href, true); // asynch call
+		xmlhttp.send();
+	};
+	setTimeout(probe, 3000);
+}
+
+var idleTimer = null, idleDeadline = 0, idleOverlay = null;
+
+// Restarts the idle countdown of the session (the server is accessed),
+// left is the remaining lifetime of the session (optional, default is the session timeout)
+function idleReset(left) {
+	if (!_idleOn)
+		return;
+	if (left === undefined)
+		left = _idleTimeout;
+	idleDeadline = new Date().getTime() + left;
+	if (idleOverlay != null) {
+		document.body.removeChild(idleOverlay);
+		idleOverlay = null;
+	}
+	if (idleTimer != null)
+		clearTimeout(idleTimer);
+	idleTimer = setTimeout(idleTick, Math.max(left - _idleWarn, 0));
+}
+
+// Displays and updates the idle warning, leaves the window when the session expired
+function idleTick() {
+	idleTimer = null;
+	var left = idleDeadline - new Date().getTime();
+	if (left <= 0) {
+		if (_idleExpiredWin.length > 0)
+			window.location.href = _pathApp + _idleExpiredWin;
+		else
+			window.location.reload(true);
+		return;
+	}
+	if (left > _idleWarn) {
+		idleTimer = setTimeout(idleTick, left - _idleWarn);
+		return;
+	}
+	if (idleOverlay == null) {
+		idleOverlay = document.createElement("div");
+		idleOverlay.className = "gwu-IdleWarning";
+		var box = document.createElement("div");
+		box.className = "gwu-IdleWarning-Box";
+		var text = document.createElement("div");
+		setText(text, _idleText);
+		box.appendChild(text);
+		var countdown = document.createElement("div");
+		countdown.className = "gwu-IdleWarning-Countdown";
+		box.appendChild(countdown);
+		var button = document.createElement("button");
+		button.className = "gwu-IdleWarning-Button";
+		setText(button, _idleKeepAlive);
+		button.onclick = function() {
+			// Any event keeps the session alive
+			se(null, ` + strconv.Itoa(int(ETYPE_STATE_CHANGE)) + `, _idleWinId);
+		};
+		box.appendChild(button);
+		idleOverlay.appendChild(box);
+		document.body.appendChild(idleOverlay);
+		button.focus();
+	}
+	var secs = Math.ceil(left / 1000);
+	setText(idleOverlay.querySelector(".gwu-IdleWarning-Countdown"), Math.floor(secs / 60) + ":" + (secs % 60 < 10 ? "0" : "") + secs % 60);
+	idleTimer = setTimeout(idleTick, left - (secs - 1) * 1000);
+}
+
+// Timers of hidden pages may be delayed, check the countdown when the page becomes visible
+if (document.addEventListener)
+	document.addEventListener("visibilitychange", function() {
+		if (!document.hidden && idleTimer != null) {
+			clearTimeout(idleTimer);
+			idleTick();
+		}
+	}, false);
+
+// Download a file sent by an event handler
+function downloadFile(token) {
+	// Use a hidden iframe so the window is not unloaded
+	var f = document.createElement("iframe");
+	f.style.display = "none";
+	f.src = sp(_pathDownload + "?" + _pToken + "=" + token);
+	document.body.appendChild(f);
+	setTimeout(function() {
+		document.body.removeChild(f);
+	}, 60000);
+}
+
+// Upload the file selected in a FileUpload
+function fuUpload(input, compId) {
+	if (!input.files || input.files.length == 0)
+		return;
+	var file = input.files[0];
+	
+	var xmlhttp = createXmlHttp();
+	
+	xmlhttp.onreadystatechange = function() {
+		if (xmlhttp.readyState == 4 && xmlhttp.status == 200)
+			procEresp(xmlhttp);
+	}
+	
+	xmlhttp.open("POST", sp(_pathUpload + "?" + _pCompId + "=" + compId + "&" + _pFileName + "=" + encodeURIComponent(file.name)
+		+ "&" + _pPushSeq + "=" + _pushSeq), true); // asynch call
+	xmlhttp.setRequestHeader("Content-type", file.type ? file.type : "application/octet-stream");
+	
+	xmlhttp.send(file);
+}
+
+// Makes the tabs of a TabPanel draggable along the tab bar to reorder them
+function tpReorder(compId, etype, tabIds) {
+	var dragged = -1;
+	tabIds.forEach(function(tabId, i) {
+		var tab = document.getElementById(tabId);
+		if (!tab)
+			return;
+		var cell = tab.parentNode;
+		cell.draggable = true;
+		cell.ondragstart = function(e) {
+			dragged = i;
+			e.dataTransfer.effectAllowed = "move";
+			e.dataTransfer.setData("text/plain", ""); // Required by some browsers to start dragging
+		};
+		cell.ondragover = function(e) {
+			if (dragged >= 0)
+				e.preventDefault(); // Allow dropping
+		};
+		cell.ondrop = function(e) {
+			e.preventDefault();
+			if (dragged >= 0 && dragged != i)
+				se(null, etype, compId, dragged + "," + i);
+			dragged = -1;
+		};
+		cell.ondragend = function() {
+			dragged = -1;
+		};
+	});
+}
+
+// Makes the cards of a Board draggable within and between columns
+function bdInit(compId, etype) {
+	var board = document.getElementById(compId);
+	var lists = [], dragged = null, from = null;
+	for (var i = 0; i < board.children.length; i++) {
+		var col = board.children[i];
+		if (col.className == "gwu-Board-Column")
+			lists.push(col.getElementsByClassName("gwu-Board-Cards")[0]);
+	}
+	
+	lists.forEach(function(list, col) {
+		for (var pos = 0; pos < list.children.length; pos++) {
+			(function(card, pos) {
+				card.draggable = true;
+				card.ondragstart = function(e) {
+					dragged = card;
+					from = [col, pos];
+					e.dataTransfer.effectAllowed = "move";
+					e.dataTransfer.setData("text/plain", ""); // Required by some browsers to start dragging
+				};
+				card.ondragend = function() {
+					dragged = null;
+				};
+			})(list.children[pos], pos);
+		}
+		
+		// Drop targets are the card lists (columns)
+		list.ondragover = function(e) {
+			if (dragged != null)
+				e.preventDefault(); // Allow dropping
+		};
+		list.ondrop = function(e) {
+			e.preventDefault();
+			if (dragged == null)
+				return;
+			// Target position: number of other cards above the drop point
+			var pos = 0;
+			for (var i = 0; i < list.children.length; i++) {
+				var card = list.children[i];
+				if (card == dragged)
+					continue;
+				var r = card.getBoundingClientRect();
+				if (r.top + r.height / 2 < e.clientY)
+					pos++;
+			}
+			dragged = null;
+			if (from[0] != col || from[1] != pos)
+				se(null, etype, compId, from[0] + "," + from[1] + "," + col + "," + pos);
+		};
+	});
+}
+
+// Initializes a MessageList: scrolls to the bottom, and tracks if the user scrolled up
+function mlInit(compId) {
+	var list = document.getElementById(compId);
+	var msgs = list.children[0];
+	msgs.mlAtBottom = true;
+	msgs.scrollTop = msgs.scrollHeight;
+	msgs.onscroll = function() {
+		msgs.mlAtBottom = msgs.scrollTop + msgs.clientHeight >= msgs.scrollHeight - 5;
+		if (msgs.mlAtBottom)
+			list.children[1].style.display = "none"; // Hide new messages indicator
+	};
+}
+
+// Called when messages are appended to a MessageList
+function mlAppended(compId) {
+	var list = document.getElementById(compId);
+	if (list.children[0].mlAtBottom)
+		mlScroll(compId);
+	else
+		list.children[1].style.display = ""; // Show new messages indicator
+}
+
+// Scrolls a MessageList to the bottom
+function mlScroll(compId) {
+	var list = document.getElementById(compId);
+	var msgs = list.children[0];
+	msgs.scrollTop = msgs.scrollHeight;
+	list.children[1].style.display = "none";
+}
+
+// Initializes a LogView
+function lvInit(compId) {
+	var lines = document.getElementById(compId).children[1];
+	lines.lvAtBottom = true;
+	lines.scrollTop = lines.scrollHeight;
+	lines.onscroll = function() {
+		lines.lvAtBottom = lines.scrollTop + lines.clientHeight >= lines.scrollHeight - 5;
+	};
+}
+
+// Called when lines are appended to a LogView
+function lvAppended(compId, maxLines) {
+	var lines = document.getElementById(compId).children[1];
+	// Last child is the tail
+	if (maxLines > 0)
+		while (lines.children.length - 1 > maxLines)
+			lines.removeChild(lines.firstChild);
+	lvFilter(compId);
+	if (lines.lvAtBottom)
+		lines.scrollTop = lines.scrollHeight;
+}
+
+// Shows only the lines of a LogView containing the text of its filter box
+function lvFilter(compId) {
+	var view = document.getElementById(compId);
+	var filter = view.children[0].value.toLowerCase();
+	var lines = view.children[1].children;
+	for (var i = 0; i < lines.length - 1; i++) {
+		var line = lines[i];
+		line.style.display = filter.length == 0 || line.textContent.toLowerCase().indexOf(filter) >= 0 ? "" : "none";
+	}
+}
+
+// Initializes a Terminal
+function tmInit(compId, etype, keySeq, maxOutput, cr) {
+	var t = document.getElementById(compId);
+	var out = t.children[0];
+	if (out.firstChild.nodeType != 3) // Output text node
+		out.insertBefore(document.createTextNode(""), out.firstChild);
+	t.tmEtype = etype;
+	t.tmKeySeq = keySeq;
+	t.tmKeys = "";
+	t.tmMax = maxOutput;
+	t.tmCr = cr;
+	out.tmAtBottom = true;
+	out.scrollTop = out.scrollHeight;
+	out.onscroll = function() {
+		out.tmAtBottom = out.scrollTop + out.clientHeight >= out.scrollHeight - 5;
+	};
+}
+
+// Writes text to the output of a Terminal.
+// The server side equivalent is the termWrite() Go function.
+function tmWrite(compId, text) {
+	var t = document.getElementById(compId);
+	var out = t.children[0];
+	var s = out.firstChild.data;
+	for (var i = 0; i < text.length; i++) {
+		var ch = text.charAt(i);
+		if (t.tmCr && ch != "\n")
+			s = s.substring(0, s.lastIndexOf("\n") + 1); // Carriage return without new line: the current line is overwritten
+		t.tmCr = false;
+		if (ch == "\r")
+			t.tmCr = true;
+		else if (ch == "\b") {
+			if (s.length > 0 && s.charAt(s.length - 1) != "\n")
+				s = s.substring(0, s.length - 1);
+		} else
+			s += ch;
+	}
+	if (t.tmMax > 0 && s.length > t.tmMax)
+		s = s.substring(s.length - t.tmMax);
+	out.firstChild.data = s;
+	if (out.tmAtBottom)
+		out.scrollTop = out.scrollHeight;
+}
+
+// Replaces the output of a Terminal (when the browser missed some of the output).
+function tmReset(compId, text, cr) {
+	var t = document.getElementById(compId);
+	var out = t.children[0];
+	out.firstChild.data = text;
+	t.tmCr = cr;
+	if (out.tmAtBottom)
+		out.scrollTop = out.scrollHeight;
+}
+
+// Key sequences sent by a Terminal for special keys
+var tmKeySeqs = {Enter:"\r", Backspace:"\x7f", Tab:"\t", Escape:"\x1b", ArrowUp:"\x1b[A", ArrowDown:"\x1b[B",
+	ArrowRight:"\x1b[C", ArrowLeft:"\x1b[D", Home:"\x1b[H", End:"\x1b[F", Insert:"\x1b[2~", Delete:"\x1b[3~",
+	PageUp:"\x1b[5~", PageDown:"\x1b[6~"};
+
+// Handles a key down event of a Terminal
+function tmKey(compId, event) {
+	if (event.altKey || event.metaKey)
+		return; // Leave browser shortcuts alone
+	var k = event.key, keys = null;
+	if (event.ctrlKey) {
+		if (k.length == 1) {
+			var code = k.toUpperCase().charCodeAt(0);
+			if (code >= 64 && code <= 95)
+				keys = String.fromCharCode(code - 64); // Control character, e.g. Ctrl+C
+		}
+	} else if (k.length == 1)
+		keys = k;
+	else
+		keys = tmKeySeqs[k];
+	if (keys == null)
+		return;
+	event.preventDefault();
+	tmSend(compId, keys);
+}
+
+// Handles a paste event of a Terminal
+function tmPaste(compId, event) {
+	event.preventDefault();
+	tmSend(compId, event.clipboardData.getData("text"));
+}
+
+// Sends keystrokes of a Terminal.
+// Keystrokes typed in a short time are sent together.
+function tmSend(compId, keys) {
+	var t = document.getElementById(compId);
+	t.tmKeys += keys;
+	if (t.tmTimer != null)
+		return;
+	t.tmTimer = setTimeout(function() {
+		t.tmTimer = null;
+		se(null, t.tmEtype, compId, t.tmKeySeq++ + "," + encodeURIComponent(t.tmKeys));
+		t.tmKeys = "";
+	}, 10);
+}
+
+// Expands or collapses a node of a JsonView
+function jvToggle(toggle) {
+	jvExpand(toggle, toggle.className.indexOf("gwuimg-collapsed") >= 0);
+}
+
+// Expands or collapses a node of a JsonView specified by its toggle
+function jvExpand(toggle, expand) {
+	toggle.className = "gwu-JsonView-Toggle " + (expand ? "gwuimg-expanded" : "gwuimg-collapsed");
+	toggle.parentNode.lastChild.style.display = expand ? "" : "none"; // Children
+}
+
+// Copies the path of a node of a JsonView to the clipboard
+function jvCopy(key) {
+	if (navigator.clipboard)
+		navigator.clipboard.writeText(key.title);
+}
+
+// Highlights the keys and values of a JsonView containing the text of its search box,
+// and expands their ancestors
+function jvSearch(compId) {
+	var view = document.getElementById(compId);
+	var filter = view.children[0].value.toLowerCase();
+	var spans = view.children[1].getElementsByTagName("span");
+	for (var i = 0; i < spans.length; i++) {
+		var span = spans[i];
+		var cls = span.className.replace(" gwu-JsonView-Match", "");
+		if (cls.indexOf("gwu-JsonView-Toggle") >= 0 || cls.indexOf("gwu-JsonView-Summary") >= 0)
+			continue;
+		if (filter.length > 0 && span.textContent.toLowerCase().indexOf(filter) >= 0) {
+			span.className = cls + " gwu-JsonView-Match";
+			// Expand ancestors:
+			for (var node = span.parentNode.parentNode; node.className == "gwu-JsonView-Children"; node = node.parentNode.parentNode)
+				jvExpand(node.parentNode.firstChild, true);
+		} else
+			span.className = cls;
+	}
+}
+
+// Validates the value of an input element, and updates its error style class
+function vdCheck(e, valid) {
+	var cls = e.className.replace(/ ?gwu-Invalid/, "");
+	e.className = valid(e.value) ? cls : cls + " gwu-Invalid";
+}
+
+// Initializes a PdfView: loads the document into its frame
+function pvInit(compId, version, page, zoom) {
+	var doc = document.getElementById(compId).getElementsByTagName("iframe")[0];
+	// Page and zoom are passed as PDF open parameters
+	doc.src = sp(_pathResource + "?" + _pCompId + "=" + compId + "&v=" + version) + "#page=" + page + "&zoom=" + zoom;
+}
+
+// Navigates a PdfView to a page and zoom
+function pvNav(compId, etype, page, zoom) {
+	if (isNaN(page))
+		return;
+	se(null, etype, compId, page + "," + zoom);
+}
+
+// Pan and zoom states of Graph components, mapped from component id
+var graphViews = {};
+
+// Initializes a Graph: sets up panning and zooming
+function grInit(compId) {
+	var svg = document.getElementById(compId).getElementsByTagName("svg")[0];
+	var g = svg.getElementsByTagName("g")[0];
+	
+	function apply(v) {
+		g.setAttribute("transform", "translate(" + v.x + "," + v.y + ") scale(" + v.s + ")");
+	}
+	// Fits the graph into the view (zooming out only)
+	function fit() {
+		var b = g.getBBox(), r = svg.getBoundingClientRect();
+		var s = b.width > 0 && r.width > 0 ? Math.min(1, (r.width - 20) / b.width, (r.height - 20) / b.height) : 1;
+		var v = {x: (r.width - b.width * s) / 2 - b.x * s, y: 10 - b.y * s, s: s};
+		graphViews[compId] = v;
+		apply(v);
+	}
+	
+	// Keep the view of the graph when it is re-rendered
+	if (graphViews[compId])
+		apply(graphViews[compId]);
+	else
+		fit();
+	
+	var drag = null;
+	svg.onmousedown = function(event) {
+		var v = graphViews[compId];
+		drag = {x: event.clientX - v.x, y: event.clientY - v.y};
+		v.moved = false;
+	};
+	svg.onmousemove = function(event) {
+		if (drag == null)
+			return;
+		var v = graphViews[compId], x = event.clientX - drag.x, y = event.clientY - drag.y;
+		if (Math.abs(x - v.x) + Math.abs(y - v.y) > 2)
+			v.moved = true;
+		v.x = x;
+		v.y = y;
+		apply(v);
+	};
+	svg.onmouseup = svg.onmouseleave = function() {
+		drag = null;
+	};
+	svg.onwheel = function(event) {
+		event.preventDefault();
+		var v = graphViews[compId], r = svg.getBoundingClientRect();
+		var s = Math.min(10, Math.max(0.1, v.s * (event.deltaY < 0 ? 1.2 : 1 / 1.2)));
+		// Zoom around the mouse position
+		var mx = event.clientX - r.left, my = event.clientY - r.top;
+		v.x = mx - (mx - v.x) * s / v.s;
+		v.y = my - (my - v.y) * s / v.s;
+		v.s = s;
+		apply(v);
+	};
+	svg.ondblclick = fit;
+}
+
+// Handles a click on a node of a Graph (if it was not the end of a pan)
+function grClick(event, compId, etype, nodeId) {
+	event.stopPropagation();
+	var v = graphViews[compId];
+	if (v && v.moved)
+		return;
+	se(event, etype, compId, nodeId);
+}
+
+// Timers of pending ComboBox suggestion requests, mapped from component id
+var cbTimers = {};
+
+// Returns the suggestion list of a ComboBox
+function cbList(compId) {
+	return document.getElementById(compId).getElementsByTagName("div")[0];
+}
+
+// Handles key down in a ComboBox: navigates and chooses the suggestions
+function cbKeyDown(event, compId, etype) {
+	var list = cbList(compId), items = list.getElementsByTagName("div"), key = event.keyCode;
+	if (list.style.display == "none" || items.length == 0 || key != 13 && key != 27 && key != 38 && key != 40)
+		return;
+	
+	var active = -1;
+	for (var i = 0; i < items.length; i++)
+		if (items[i].className.indexOf("gwu-ComboBox-Item-Active") >= 0) {
+			active = i;
+			items[i].className = "gwu-ComboBox-Item";
+		}
+	
+	switch (key) {
+	case 13: // Enter
+		if (active >= 0) {
+			event.preventDefault();
+			cbSelect(compId, etype, items[active]);
+		}
+		break;
+	case 27: // Escape
+		list.style.display = "none";
+		break;
+	default: // Up, down
+		event.preventDefault();
+		active = key == 40 ? (active + 1) % items.length : (active <= 0 ? items.length : active) - 1;
+		items[active].className = "gwu-ComboBox-Item gwu-ComboBox-Item-Active";
+		items[active].scrollIntoView(false);
+	}
+}
+
+// Handles key up in a ComboBox: requests suggestions if the text changed
+// and the user stops typing for the delay
+function cbKeyUp(event, compId, etype, delay) {
+	var input = event.target;
+	if (input.cbText === undefined)
+		input.cbText = input.defaultValue;
+	if (input.value == input.cbText)
+		return;
+	input.cbText = input.value;
+	
+	clearTimeout(cbTimers[compId]);
+	cbTimers[compId] = setTimeout(function() {
+		delete cbTimers[compId];
+		se(null, etype, compId, encodeURIComponent(input.value));
+	}, delay);
+}
+
+// Chooses a suggestion of a ComboBox
+function cbSelect(compId, etype, item) {
+	var input = document.getElementById(compId).getElementsByTagName("input")[0];
+	input.value = input.cbText = item.textContent;
+	cbList(compId).style.display = "none";
+	cbChange(input, compId, etype);
+}
+
+// Sends the change event of a ComboBox if its text changed since last sent
+function cbChange(input, compId, etype) {
+	clearTimeout(cbTimers[compId]);
+	delete cbTimers[compId];
+	if (input.cbSent === undefined)
+		input.cbSent = input.defaultValue;
+	if (input.value == input.cbSent)
+		return;
+	input.cbSent = input.value;
+	se(null, etype, compId, encodeURIComponent(input.value));
+}
+
+// Handles the dropdown button of a ComboBox: hides the list or requests all suggestions
+function cbDrop(compId, etype) {
+	var list = cbList(compId);
+	document.getElementById(compId).getElementsByTagName("input")[0].focus();
+	if (list.style.display != "none")
+		list.style.display = "none";
+	else
+		se(null, etype, compId);
+}
+
+// Ids of the shown Tour components, mapped to true
+var tours = {}, toListening = false;
+
+// Shows a step of a Tour highlighting the target component
+function toShow(compId, targetId) {
+	var target = document.getElementById(targetId);
+	document.getElementById(compId).tourTarget = targetId;
+	if (target != null)
+		target.scrollIntoView(false);
+	
+	tours[compId] = true;
+	if (!toListening) {
+		toListening = true;
+		window.addEventListener("scroll", toPlaceAll, true);
+		window.addEventListener("resize", toPlaceAll);
+	}
+	toPlace(compId);
+}
+
+// Places the shown Tours
+function toPlaceAll() {
+	for (var compId in tours)
+		if (!toPlace(compId))
+			delete tours[compId];
+}
+
+// Places the highlight and the popup of a Tour at its target component.
+// Returns false if the Tour is not shown.
+function toPlace(compId) {
+	var e = document.getElementById(compId);
+	if (e == null || e.tourTarget === undefined || e.firstChild == null)
+		return false;
+	
+	var hl = e.firstChild, popup = hl.nextSibling, target = document.getElementById(e.tourTarget);
+	if (target == null) {
+		// No target: dim the window and center the popup
+		hl.style.left = hl.style.top = "50%";
+		hl.style.width = hl.style.height = "0px";
+		popup.style.left = Math.max(0, (window.innerWidth - popup.offsetWidth) / 2) + "px";
+		popup.style.top = Math.max(0, (window.innerHeight - popup.offsetHeight) / 2) + "px";
+		return true;
+	}
+	
+	var r = target.getBoundingClientRect();
+	hl.style.left = (r.left - 4) + "px";
+	hl.style.top = (r.top - 4) + "px";
+	hl.style.width = (r.width + 8) + "px";
+	hl.style.height = (r.height + 8) + "px";
+	// Popup below the target if it fits, else above
+	var top = r.bottom + 10;
+	if (top + popup.offsetHeight > window.innerHeight && r.top - 10 - popup.offsetHeight >= 0)
+		top = r.top - 10 - popup.offsetHeight;
+	popup.style.top = top + "px";
+	popup.style.left = Math.max(0, Math.min(r.left, window.innerWidth - popup.offsetWidth)) + "px";
+	return true;
+}
+
+// Steps the value of a NumberBox in the specified direction (1 or -1)
+function nbStep(compId, min, max, step, dir) {
+	var input = document.getElementById(compId).firstChild;
+	var v = parseFloat(input.value);
+	if (isNaN(v))
+		v = 0;
+	v = Math.min(max, Math.max(min, v + dir * step));
+	var s = String(step), i = s.indexOf(".");
+	input.value = v.toFixed(i < 0 ? 0 : s.length - i - 1); // Avoid floating point artifacts
+	input.dispatchEvent(new Event("change", {bubbles: true}));
+}
+
+// Handles a key down event of a NumberBox: arrow keys step the value
+function nbKey(event, compId, min, max, step) {
+	if (event.key == "ArrowUp" || event.key == "ArrowDown") {
+		event.preventDefault();
+		nbStep(compId, min, max, step, event.key == "ArrowUp" ? 1 : -1);
+	}
+}
+
+// Executes a toolbar command on the editor of a RichTextBox
+function rtbCmd(compId, cmd) {
+	var editor = document.getElementById(compId).lastChild;
+	if (editor.contentEditable != "true")
+		return;
+	var v = null, sel = window.getSelection();
+	var range = sel.rangeCount > 0 ? sel.getRangeAt(0) : null;
+	if (cmd == "createLink" && !(v = prompt("URL:", "https://")))
+		return;
+	editor.focus();
+	if (range != null && editor.contains(range.commonAncestorContainer)) {
+		// Restore the selection lost by the prompt
+		sel.removeAllRanges();
+		sel.addRange(range);
+	}
+	document.execCommand(cmd, false, v);
+}
+
+// Handles the blur of the editor of a RichTextBox: fires a change event if its content changed
+function rtbBlur(editor) {
+	if (editor.gwuHtml != editor.innerHTML)
+		editor.dispatchEvent(new Event("change", {bubbles: true}));
+}
+
+// Returns the visible entries (menus and menu items) of a menu bar or menu popup
+function menuEntries(parent) {
+	var entries = [];
+	for (var e = parent.firstChild; e != null; e = e.nextSibling)
+		if (!e.hidden && (e.classList.contains("gwu-Menu") || e.classList.contains("gwu-MenuItem")))
+			entries.push(e);
+	return entries;
+}
+
+// Opens or closes a menu; closing a menu closes its open submenus too
+function menuSetOpen(menu, open) {
+	if (open && menu.firstChild.getAttribute("aria-disabled") == "true")
+		return false;
+	var menus = open ? [menu] : [menu].concat(Array.prototype.slice.call(menu.querySelectorAll(".gwu-Menu-Open")));
+	for (var i = 0; i < menus.length; i++) {
+		menus[i].classList.toggle("gwu-Menu-Open", open);
+		menus[i].firstChild.setAttribute("aria-expanded", open);
+	}
+	return true;
+}
+
+// Closes all open menus and popup menus
+function menuCloseAll() {
+	var menus = document.querySelectorAll(".gwu-MenuBar > .gwu-Menu-Open, .gwu-PopupMenu > .gwu-Menu-Open");
+	for (var i = 0; i < menus.length; i++)
+		menuSetOpen(menus[i], false);
+	menus = document.querySelectorAll(".gwu-PopupMenu-Open");
+	for (var i = 0; i < menus.length; i++)
+		menus[i].classList.remove("gwu-PopupMenu-Open");
+}
+
+// Opens a popup menu at the mouse position as the context menu of a component
+function ctxMenu(event, menuId, compId, send) {
+	var menu = document.getElementById(menuId);
+	if (menu == null)
+		return;
+	event.preventDefault();
+	event.stopPropagation(); // Innermost context menu wins
+	menuCloseAll();
+	
+	se(event, ` + strconv.Itoa(int(ETYPE_CONTEXT_MENU)) + `, menuId, compId); // Tells the target to the popup menu
+	if (send)
+		se(event, ` + strconv.Itoa(int(ETYPE_CONTEXT_MENU)) + `, compId);
+	
+	menu.classList.add("gwu-PopupMenu-Open");
+	// Keep it inside the viewport
+	var x = Math.min(event.clientX, window.innerWidth - menu.offsetWidth);
+	var y = Math.min(event.clientY, window.innerHeight - menu.offsetHeight);
+	menu.style.left = Math.max(0, x) + "px";
+	menu.style.top = Math.max(0, y) + "px";
+	var entries = menuEntries(menu);
+	if (entries.length > 0)
+		(entries[0].classList.contains("gwu-Menu") ? entries[0].firstChild : entries[0]).focus();
+}
+
+// Closes the open sibling menus of a menu entry, returns true if there was one
+function menuCloseSiblings(entry) {
+	var entries = menuEntries(entry.parentNode), wasOpen = false;
+	for (var i = 0; i < entries.length; i++)
+		if (entries[i] != entry && entries[i].classList.contains("gwu-Menu-Open")) {
+			menuSetOpen(entries[i], false);
+			wasOpen = true;
+		}
+	return wasOpen;
+}
+
+// Handles the mouse entering a menu entry: submenus open on hover,
+// top level menus only if another top level menu was open
+function menuHover(entry) {
+	var wasOpen = menuCloseSiblings(entry);
+	if (entry.classList.contains("gwu-Menu") && (wasOpen || !entry.parentNode.classList.contains("gwu-MenuBar")))
+		menuSetOpen(entry, true);
+}
+
+// Handles a click on the label of a menu: toggles the menu
+function menuClick(menu) {
+	menuCloseSiblings(menu);
+	menuSetOpen(menu, !menu.classList.contains("gwu-Menu-Open"));
+}
+
+// Returns the first entry of the popup of a menu, opening the menu
+function menuOpenFirst(menu) {
+	if (!menuSetOpen(menu, true))
+		return null;
+	var entries = menuEntries(menu.lastChild);
+	return entries.length > 0 ? entries[0] : null;
+}
+
+// Handles a key down event of a menu bar: keyboard navigation of the menus
+function menuKey(event) {
+	var entry = event.target.classList.contains("gwu-Menu-Label") ? event.target.parentNode : event.target;
+	var parent = entry.parentNode, top = parent.classList.contains("gwu-MenuBar");
+	var popupTop = parent.classList.contains("gwu-PopupMenu"); // Entry of a popup menu (not of its submenus)
+	var isMenu = entry.classList.contains("gwu-Menu"), focus = null;
+	var entries = menuEntries(parent), i = entries.indexOf(entry), n = entries.length;
+	if (i < 0)
+		return;
+	switch (event.key) {
+	case "ArrowDown":
+	case "ArrowUp":
+		var down = event.key == "ArrowDown";
+		if (!top)
+			focus = entries[(i + (down ? 1 : n - 1)) % n];
+		else if (isMenu && down)
+			focus = menuOpenFirst(entry);
+		break;
+	case "ArrowRight":
+	case "ArrowLeft":
+		var right = event.key == "ArrowRight";
+		if (!top && right && isMenu) {
+			focus = menuOpenFirst(entry);
+		} else if (popupTop) {
+			// Nothing to move to
+		} else if (!top && !right && !parent.parentNode.parentNode.classList.contains("gwu-MenuBar")) {
+			// Close the submenu
+			focus = parent.parentNode;
+			menuSetOpen(focus, false);
+		} else if (entry.closest(".gwu-MenuBar") != null) {
+			// Move to the previous / next top level menu
+			var t = entry;
+			while (!t.parentNode.classList.contains("gwu-MenuBar"))
+				t = t.parentNode.parentNode;
+			var tops = menuEntries(t.parentNode), j = tops.indexOf(t);
+			focus = tops[(j + (right ? 1 : tops.length - 1)) % tops.length];
+			if (t.classList.contains("gwu-Menu-Open")) {
+				menuSetOpen(t, false);
+				if (focus.classList.contains("gwu-Menu"))
+					menuSetOpen(focus, true);
+			}
+		}
+		break;
+	case "Enter":
+	case " ":
+		if (isMenu)
+			focus = menuOpenFirst(entry);
+		else
+			entry.click();
+		break;
+	case "Escape":
+		if (top) {
+			menuSetOpen(entry, false);
+		} else if (popupTop) {
+			menuCloseAll();
+		} else {
+			focus = parent.parentNode;
+			menuSetOpen(focus, false);
+		}
+		break;
+	default:
+		return;
+	}
+	event.preventDefault();
+	if (focus != null)
+		(focus.classList.contains("gwu-Menu") ? focus.firstChild : focus).focus();
+}
+
+if (document.addEventListener) {
+	document.addEventListener("click", function(event) {
+		// Clicks outside of menu labels (e.g. on menu items) close the open menus
+		if (!(event.target.closest && event.target.closest(".gwu-Menu-Label")))
+			menuCloseAll();
+	});
+	document.addEventListener("contextmenu", menuCloseAll); // Context menus opened by ctxMenu() do not get here
+}
+
+// Drags the splitter of a SplitPanel, and sends the new split position when released
+function spltDrag(event, compId, etype, horizontal) {
+	var splitter = event.currentTarget;
+	var panel = document.getElementById(compId);
+	var first = splitter.previousSibling;
+	// Positions are reported in the unit of the current position
+	var percent = first.style.flexBasis.indexOf("%") >= 0;
+	var coord = function(e) { return horizontal ? e.clientX : e.clientY; };
+	var start = coord(event);
+	var startSize = horizontal ? first.offsetWidth : first.offsetHeight;
+	
+	var pos = function(e) {
+		var total = horizontal ? panel.clientWidth : panel.clientHeight;
+		var splitterSize = horizontal ? splitter.offsetWidth : splitter.offsetHeight;
+		var size = Math.max(0, Math.min(total - splitterSize, startSize + coord(e) - start));
+		return percent ? Math.round(size * 10000 / total) / 100 + "%" : Math.round(size) + "px";
+	};
+	
+	splitter.setPointerCapture(event.pointerId);
+	event.preventDefault();
+	splitter.onpointermove = function(e) {
+		first.style.flexBasis = pos(e);
+	};
+	splitter.onpointerup = splitter.onpointercancel = function(e) {
+		splitter.onpointermove = splitter.onpointerup = splitter.onpointercancel = null;
+		first.style.flexBasis = pos(e);
+		se(null, etype, compId, encodeURIComponent(first.style.flexBasis));
+	};
+}
+
+// Id and event type of the active command palette
+var cpalId = null, cpalEtype;
+
+// Initializes a command palette, and registers the Ctrl+K (Cmd+K) shortcut opening it
+function cpalInit(compId, etype) {
+	if (cpalId == null) {
+		document.addEventListener("keydown", function(event) {
+			var p = document.getElementById(cpalId);
+			if (p == null || !(event.ctrlKey || event.metaKey) || event.altKey || event.key.toLowerCase() != "k")
+				return;
+			event.preventDefault();
+			cpalOpen(p, !p.classList.contains("gwu-CommandPalette-Open"));
+		});
+	}
+	cpalId = compId;
+	cpalEtype = etype;
+	
+	var p = document.getElementById(compId);
+	var input = p.querySelector(".gwu-CommandPalette-Input");
+	input.oninput = function() {
+		cpalFilter(p, input.value);
+	};
+	input.onkeydown = function(event) {
+		switch (event.key) {
+		case "ArrowDown":
+			cpalMove(p, 1);
+			break;
+		case "ArrowUp":
+			cpalMove(p, -1);
+			break;
+		case "Enter":
+			var sel = p.querySelector(".gwu-CommandPalette-Selected");
+			if (sel != null)
+				cpalInvoke(p, sel);
+			break;
+		case "Escape":
+			cpalOpen(p, false);
+			break;
+		default:
+			return;
+		}
+		event.preventDefault();
+	};
+	p.onmousedown = function(event) {
+		if (event.target == p) // Click outside of the box
+			cpalOpen(p, false);
+	};
+	p.querySelectorAll(".gwu-CommandPalette-Item").forEach(function(item) {
+		item.onmousemove = function() {
+			cpalSelect(p, item);
+		};
+		item.onclick = function() {
+			cpalInvoke(p, item);
+		};
+	});
+}
+
+// Opens or closes a command palette
+function cpalOpen(p, open) {
+	var input = p.querySelector(".gwu-CommandPalette-Input");
+	if (open) {
+		p.cpalFocused = document.activeElement;
+		p.classList.add("gwu-CommandPalette-Open");
+		input.value = "";
+		cpalFilter(p, "");
+		input.focus();
+	} else {
+		p.classList.remove("gwu-CommandPalette-Open");
+		if (p.cpalFocused && p.cpalFocused.focus)
+			p.cpalFocused.focus();
+	}
+}
+
+// Lists the entries of a command palette containing all words of the filter text
+function cpalFilter(p, text) {
+	var words = text.toLowerCase().split(/\s+/).filter(function(word) {
+		return word.length > 0;
+	});
+	var first = null;
+	p.querySelectorAll(".gwu-CommandPalette-Item").forEach(function(item) {
+		var itemText = item.textContent.toLowerCase();
+		var match = words.every(function(word) {
+			return itemText.indexOf(word) >= 0;
+		});
+		item.style.display = match ? "" : "none";
+		if (match && first == null)
+			first = item;
+	});
+	cpalSelect(p, first);
+}
+
+// Selects an entry of a command palette
+function cpalSelect(p, item) {
+	var sel = p.querySelector(".gwu-CommandPalette-Selected");
+	if (sel != null)
+		sel.classList.remove("gwu-CommandPalette-Selected");
+	if (item != null) {
+		item.classList.add("gwu-CommandPalette-Selected");
+		if (item.scrollIntoView)
+			item.scrollIntoView({block: "nearest"});
+	}
+}
+
+// Moves the selection of a command palette by delta listed entries
+function cpalMove(p, delta) {
+	var items = Array.prototype.filter.call(p.querySelectorAll(".gwu-CommandPalette-Item"), function(item) {
+		return item.style.display != "none";
+	});
+	if (items.length == 0)
+		return;
+	var i = items.indexOf(p.querySelector(".gwu-CommandPalette-Selected"));
+	cpalSelect(p, items[(i + delta + items.length) % items.length]);
+}
+
+// Invokes an entry of a command palette
+function cpalInvoke(p, item) {
+	cpalOpen(p, false);
+	se(null, cpalEtype, p.id, encodeURIComponent(item.getAttribute("data-name")));
+}
+
+// Handles a click on an item of a side navigation: sections are expanded / collapsed, other items are activated
+function snClick(a, compId, idx) {
+	var li = a.parentNode;
+	if (li.classList.contains("gwu-SideNav-Section")) {
+		var expanded = li.classList.toggle("gwu-SideNav-Expanded");
+		se(null, ` + strconv.Itoa(int(ETYPE_STATE_CHANGE)) + `, compId, idx + "," + (expanded ? 1 : 0));
+		return;
+	}
+	snActivate(document.getElementById(compId), li);
+	se(null, ` + strconv.Itoa(int(ETYPE_CHANGE)) + `, compId, idx);
+}
+
+// Collapses or expands a side navigation (icon-only mode)
+function snToggle(compId) {
+	var collapsed = document.getElementById(compId).classList.toggle("gwu-SideNav-Collapsed");
+	se(null, ` + strconv.Itoa(int(ETYPE_STATE_CHANGE)) + `, compId, "," + (collapsed ? 1 : 0));
+}
+
+// Marks an item of a side navigation active (item may be null)
+function snActivate(nav, item) {
+	var actives = nav.querySelectorAll(".gwu-SideNav-Active");
+	for (var i = 0; i < actives.length; i++)
+		actives[i].classList.remove("gwu-SideNav-Active");
+	if (item != null)
+		item.classList.add("gwu-SideNav-Active");
+}
+
+var sideNavs = {};
+
+// Synchronizes the active item of a side navigation with the route in the URL hash
+function snSync(compId) {
+	if (sideNavs[compId])
+		return;
+	sideNavs[compId] = true;
+	window.addEventListener("hashchange", function() {
+		var nav = document.getElementById(compId);
+		if (nav == null)
+			return;
+		var route = decodeURIComponent(window.location.hash.substring(1)), match = null, matchLen = 0;
+		var items = nav.querySelectorAll("li[data-route]");
+		for (var i = 0; i < items.length; i++) {
+			var r = items[i].getAttribute("data-route");
+			if (r == route) {
+				match = items[i];
+				break;
+			}
+			if (r.length > matchLen && route.indexOf(r) == 0 && (r.charAt(r.length - 1) == "/" || route.charAt(r.length) == "/")) {
+				match = items[i];
+				matchLen = r.length;
+			}
+		}
+		snActivate(nav, match);
+	}, false);
+}
+
+// Evaluates a condition of a show / enable binding
+function condEval(c) {
+	var i;
+	switch (c[0]) {
+	case "not":
+		return !condEval(c[1]);
+	case "and":
+		for (i = 1; i < c.length; i++)
+			if (!condEval(c[i]))
+				return false;
+		return true;
+	case "or":
+		for (i = 1; i < c.length; i++)
+			if (condEval(c[i]))
+				return true;
+		return false;
+	}
+	
+	var e = document.getElementById(c[1]);
+	if (e != null && e.tagName != "INPUT" && e.tagName != "SELECT" && e.tagName != "TEXTAREA")
+		e = e.querySelector("input,select,textarea"); // Input wrapped in a tag (e.g. CheckBox)
+	if (e == null)
+		return false;
+	switch (c[0]) {
+	case "checked":
+		return e.checked;
+	case "empty":
+		return e.value == "";
+	case "in":
+		var v = e.type == "checkbox" || e.type == "radio" ? String(e.checked) : e.value;
+		for (i = 2; i < c.length; i++)
+			if (v == c[i])
+				return true;
+	}
+	return false;
+}
+
+// Updates the visibility and enabled state of components having show / enable bindings
+function bindUpdate() {
+	var es = document.querySelectorAll("[data-gwu-show],[data-gwu-enable]");
+	for (var i = 0; i < es.length; i++) {
+		var e = es[i], c = e.getAttribute("data-gwu-show");
+		if (c != null)
+			e.hidden = !condEval(JSON.parse(c));
+		c = e.getAttribute("data-gwu-enable");
+		if (c == null)
+			continue;
+		if (condEval(JSON.parse(c))) {
+			e.removeAttribute("disabled");
+			e.removeAttribute("aria-disabled");
+		} else {
+			e.setAttribute("disabled", "disabled");
+			e.setAttribute("aria-disabled", "true");
+		}
+	}
+}
+
+if (document.addEventListener) {
+	document.addEventListener("input", bindUpdate, true);
+	document.addEventListener("change", bindUpdate, true);
+}
+
+// Id of the component handling the undo and redo shortcuts, and its event type
+var undoCompId = null, undoEtype;
+
+// Sets up the undo (Ctrl+Z) and redo (Ctrl+Y, Ctrl+Shift+Z) keyboard shortcuts
+function undoKeys(compId, etype) {
+	if (undoCompId == null) {
+		document.addEventListener("keydown", function(event) {
+			if (!(event.ctrlKey || event.metaKey) || event.altKey || document.getElementById(undoCompId) == null)
+				return;
+			var a = document.activeElement;
+			if (a != null && (a.tagName == "INPUT" || a.tagName == "TEXTAREA" || a.isContentEditable))
+				return; // Leave the native undo of text inputs alone
+			var k = event.key.toLowerCase(), action;
+			if (k == "z")
+				action = event.shiftKey ? "redo" : "undo";
+			else if (k == "y")
+				action = "redo";
+			else
+				return;
+			event.preventDefault();
+			se(null, undoEtype, undoCompId, action);
+		});
+	}
+	undoCompId = compId;
+	undoEtype = etype;
+}
+
+// Sets the text content of an element
+function setText(e, text) {
+	e.innerHTML = "";
+	e.appendChild(document.createTextNode(text));
+}
+
+// Media captures in progress, mapped from component id
+var captures = {};
+
+// Starts capturing, or captures and sends the data of a MediaCapture component
+function mcToggle(button, compId, etype, mode, maxDur, startText, captureText) {
+	var cap = captures[compId];
+	
+	function send(contentType, data) {
+		se(null, etype, compId, encodeURIComponent(contentType) + "," + b64(data));
+	}
+	function stop() {
+		delete captures[compId];
+		cap.stream.getTracks().forEach(function(t) { t.stop(); });
+		if (cap.video != null)
+			button.parentNode.removeChild(cap.video);
+		setText(button, startText);
+	}
+	
+	if (cap == null) {
+		if (!navigator.mediaDevices || !navigator.mediaDevices.getUserMedia)
+			return;
+		cap = captures[compId] = {};
+		navigator.mediaDevices.getUserMedia(mode == _captureAudio ? {audio: true} : {video: true}).then(function(stream) {
+			cap.stream = stream;
+			setText(button, captureText);
+			if (mode == _captureAudio) {
+				var chunks = [];
+				cap.rec = new MediaRecorder(stream);
+				cap.rec.ondataavailable = function(e) { chunks.push(e.data); };
+				cap.rec.onstop = function() {
+					var blob = new Blob(chunks, {type: cap.rec.mimeType});
+					stop();
+					blob.arrayBuffer().then(function(data) { send(blob.type, data); });
+				};
+				cap.rec.start();
+				if (maxDur > 0)
+					cap.timer = setTimeout(function() { if (cap.rec.state != "inactive") cap.rec.stop(); }, maxDur);
+			} else {
+				cap.video = document.createElement("video");
+				cap.video.className = "gwu-MediaCapture-Preview";
+				cap.video.autoplay = true;
+				cap.video.srcObject = stream;
+				button.parentNode.insertBefore(cap.video, button);
+			}
+		}, function() {
+			// Permission denied or no device
+			delete captures[compId];
+		});
+		return;
+	}
+	
+	if (cap.stream == null)
+		return; // Still waiting for permission
+	
+	if (mode == _captureAudio) {
+		clearTimeout(cap.timer);
+		if (cap.rec.state != "inactive")
+			cap.rec.stop();
+	} else {
+		var canvas = document.createElement("canvas");
+		canvas.width = cap.video.videoWidth;
+		canvas.height = cap.video.videoHeight;
+		canvas.getContext("2d").drawImage(cap.video, 0, 0);
+		stop();
+		send("image/png", canvas.toDataURL("image/png"));
+	}
+}
+
+// Scanners in progress, mapped from component id
+var scanners = {};
+
+// Starts or stops scanning codes with a Scanner component
+function scToggle(button, compId, etype, continuous, formats, startText, stopText) {
+	var sc = scanners[compId];
+	
+	function stop() {
+		delete scanners[compId];
+		clearInterval(sc.timer);
+		sc.stream.getTracks().forEach(function(t) { t.stop(); });
+		button.parentNode.removeChild(sc.video);
+		setText(button, startText);
+	}
+	
+	if (sc != null) {
+		if (sc.stream != null) // Else still waiting for permission
+			stop();
+		return;
+	}
+	
+	if (!navigator.mediaDevices || !navigator.mediaDevices.getUserMedia || !window.BarcodeDetector)
+		return;
+	sc = scanners[compId] = {};
+	var detector = new BarcodeDetector(formats.length > 0 ? {formats: formats} : {});
+	navigator.mediaDevices.getUserMedia({video: {facingMode: "environment"}}).then(function(stream) {
+		sc.stream = stream;
+		setText(button, stopText);
+		sc.video = document.createElement("video");
+		sc.video.className = "gwu-Scanner-Preview";
+		sc.video.autoplay = true;
+		sc.video.setAttribute("playsinline", "");
+		sc.video.srcObject = stream;
+		button.parentNode.insertBefore(sc.video, button);
+		
+		var last = null;
+		sc.timer = setInterval(function() {
+			if (sc.busy || sc.video.readyState < 2)
+				return;
+			sc.busy = true;
+			detector.detect(sc.video).then(function(codes) {
+				sc.busy = false;
+				if (codes.length == 0 || scanners[compId] != sc)
+					return;
+				var code = codes[0];
+				if (continuous && code.rawValue == last)
+					return;
+				last = code.rawValue;
+				if (!continuous)
+					stop();
+				se(null, etype, compId, encodeURIComponent(code.format) + "," + encodeURIComponent(code.rawValue));
+			}, function() {
+				sc.busy = false;
+			});
+		}, 200);
+	}, function() {
+		// Permission denied or no camera
+		delete scanners[compId];
+	});
+}
+
+// Returns the canvas of a SignaturePad component
+function spCanvas(compId) {
+	return document.getElementById(compId).getElementsByTagName("canvas")[0];
+}
+
+// Draws a stroke (array of [x,y] points) of a SignaturePad
+function spDraw(ctx, stroke) {
+	ctx.beginPath();
+	ctx.moveTo(stroke[0][0], stroke[0][1]);
+	for (var i = 1; i < stroke.length; i++)
+		ctx.lineTo(stroke[i][0], stroke[i][1]);
+	if (stroke.length == 1)
+		ctx.lineTo(stroke[0][0] + 0.5, stroke[0][1]); // Make a dot visible
+	ctx.stroke();
+}
+
+// Initializes a SignaturePad component: draws the strokes, and enables drawing if drawable
+function spInit(compId, etype, drawable, strokes) {
+	var canvas = spCanvas(compId);
+	var ctx = canvas.getContext("2d");
+	ctx.lineWidth = 2;
+	ctx.lineCap = ctx.lineJoin = "round";
+	
+	var data = canvas.spData = [];
+	if (strokes.length > 0) {
+		strokes.split("~").forEach(function(s) {
+			data.push(s.split("_").map(function(p) {
+				var xy = p.split(".");
+				return [parseInt(xy[0]), parseInt(xy[1])];
+			}));
+		});
+	}
+	data.forEach(function(stroke) { spDraw(ctx, stroke); });
+	
+	if (!drawable)
+		return;
+	
+	var cur = null;
+	function pos(e) {
+		var r = canvas.getBoundingClientRect();
+		return [Math.max(0, Math.round((e.clientX - r.left) * canvas.width / r.width)),
+			Math.max(0, Math.round((e.clientY - r.top) * canvas.height / r.height))];
+	}
+	canvas.onpointerdown = function(e) {
+		canvas.setPointerCapture(e.pointerId);
+		cur = [pos(e)];
+		data.push(cur);
+		spDraw(ctx, cur);
+		e.preventDefault();
+	};
+	canvas.onpointermove = function(e) {
+		if (cur == null)
+			return;
+		cur.push(pos(e));
+		spDraw(ctx, cur.slice(-2));
+	};
+	canvas.onpointerup = canvas.onpointercancel = function(e) {
+		if (cur == null)
+			return;
+		cur = null;
+		spSend(compId, etype);
+	};
+}
+
+// Sends the signature of a SignaturePad component
+function spSend(compId, etype) {
+	var canvas = spCanvas(compId);
+	var data = canvas.spData;
+	if (data.length == 0) {
+		se(null, etype, compId, "");
+		return;
+	}
+	var strokes = data.map(function(stroke) {
+		return stroke.map(function(p) { return p[0] + "." + p[1]; }).join("_");
+	}).join("~");
+	se(null, etype, compId, strokes + ":" + b64(canvas.toDataURL("image/png")));
+}
+
+// Clears the signature of a SignaturePad component
+function spClear(compId, etype) {
+	var canvas = spCanvas(compId);
+	canvas.getContext("2d").clearRect(0, 0, canvas.width, canvas.height);
+	canvas.spData.length = 0;
+	spSend(compId, etype);
+}
+
+var ws = null;
+
+// Open the WebSocket channel
+function wsOpen() {
+	var loc = window.location;
+	// Pushes are continued from the last one received by the client
+	var sock = new WebSocket((loc.protocol == "https:" ? "wss://" : "ws://") + loc.host + sp(_pathWs + "?" + _pPushSeq + "=" + _pushSeq));
+	
+	sock.onopen = function() {
+		ws = sock;
+	}
+	sock.onmessage = function(m) {
+		procEresp({responseText: m.data});
+		// Responses arrive in the order of the events
+		// (pushes may end an indicator early, which is acceptable)
+		busyEnd(_busyQueue.shift());
+	}
+	sock.onclose = function() {
+		// Could not connect or connection lost, fall back to HTTP requests
+		ws = null;
+		while (_busyQueue.length > 0)
+			busyEnd(_busyQueue.shift());
+		if (_pushEnabled && !_shutdown)
+			pushPoll();
+	}
+}
+
 function rerenderComp(compId) {
 	var e = document.getElementById(compId);
 	if (!e) // Component removed or not visible (e.g. on inactive tab of TabPanel)
@@ -163,19 +1787,38 @@ function rerenderComp(compId) {
 			e.outerHTML = xmlhttp.responseText;
 			focusComp(focusedCompId);
 			
-			// Inserted JS code is not executed automatically, do it manually:
+			// Inserted JS code is not executed automatically, do it manually
+			// (by new script elements which are allowed by the Content-Security-Policy, unlike eval):
 			// Have to "re-get" element by compId!
 			var scripts = document.getElementById(compId).getElementsByTagName("script");
-			for (var i = 0; i < scripts.length; i++) {
-				eval(scripts[i].innerText);
-			}
+			for (var i = 0; i < scripts.length; i++)
+				runScript(scripts[i].text);
+			
+			bindUpdate(); // Value of a bound source might have changed
 		}
 	}
 	
-	xmlhttp.open("POST", _pathRenderComp, false); // synch call (if async, browser specific DOM rendering errors may arise)
+	xmlhttp.open("POST", sp(_pathRenderComp), false); // synch call (if async, browser specific DOM rendering errors may arise)
 	xmlhttp.setRequestHeader("Content-type", "application/x-www-form-urlencoded");
 	
-	xmlhttp.send(_pCompId + "=" + compId);
+	var data = _pCompId + "=" + compId;
+	var tailSeq = e.getAttribute("data-gwu-seq");
+	if (tailSeq != null) // Tail of appended items: only the items the browser does not have yet are rendered
+		data += "&" + _pTailSeq + "=" + tailSeq;
+	xmlhttp.send(data);
+}
+
+// Nonce of the Content-Security-Policy (nonce of the script tag of this script)
+var _cspNonce = document.currentScript ? document.currentScript.nonce : "";
+
+// Runs the specified JavaScript code
+function runScript(code) {
+	var script = document.createElement("script");
+	if (_cspNonce)
+		script.nonce = _cspNonce;
+	script.text = code;
+	document.head.appendChild(script);
+	document.head.removeChild(script);
 }
 
 // Get selected indices (of an HTML select)
@@ -230,6 +1873,11 @@ function addonload(func) {
 	}
 }
 
+function addonvisibilitychange(func) {
+	if (document.addEventListener)
+		document.addEventListener("visibilitychange", func, false);
+}
+
 function addonbeforeunload(func) {
 	var oldonbeforeunload = window.onbeforeunload;
 	if (typeof window.onbeforeunload != 'function') {
@@ -245,10 +1893,11 @@ function addonbeforeunload(func) {
 
 var timers = new Object();
 
-function setupTimer(compId, etype, timeout, repeat, active, reset) {
+function setupTimer(compId, etype, timeout, repeat, active, reset, pauseHidden) {
 	var timer = timers[compId];
 	
 	if (timer != null) {
+		timer.pauseHidden = pauseHidden;
 		var changed = timer.timeout != timeout || timer.repeat != repeat || timer.reset != reset;
 		if (!active || changed) {
 			if (timer.repeat)
@@ -268,19 +1917,87 @@ function setupTimer(compId, etype, timeout, repeat, active, reset) {
 	timer.timeout = timeout;
 	timer.repeat = repeat;
 	timer.reset = reset;
+	timer.pauseHidden = pauseHidden;
+	timer.fire = function() {
+		if (timer.pauseHidden && document.hidden) {
+			timer.missed = true; // Fired when the page becomes visible
+			return;
+		}
+		se(null, etype, compId);
+	};
 	
 	// Start the timer
-	var js = "se(null," + etype + "," + compId + ");";
 	if (timer.repeat)
-		timer.id = setInterval(js, timeout);
+		timer.id = setInterval(timer.fire, timeout);
 	else
-		timer.id = setTimeout(js, timeout);
+		timer.id = setTimeout(timer.fire, timeout);
 }
 
+// Fire the timers missed while the page was hidden (once)
+addonvisibilitychange(function() {
+	if (document.hidden)
+		return;
+	for (var compId in timers) {
+		var timer = timers[compId];
+		if (timer != null && timer.missed) {
+			timer.missed = false;
+			if (document.getElementById(compId))
+				timer.fire();
+		}
+	}
+});
+
+var routers = new Object();
+
+// Synchronize router route with the browser history (URL fragment)
+function rtSync(compId, etype, route) {
+	var first = routers[compId] == null;
+	routers[compId] = route;
+	
+	var hash = decodeURIComponent(window.location.hash.substring(1));
+	if (first && hash.length > 0 && hash != route) {
+		// Deep link (e.g. bookmarked route): navigate to it
+		se(null, etype, compId, encodeURIComponent(hash));
+		return;
+	}
+	if (hash != route) {
+		if (first && window.history.replaceState)
+			window.history.replaceState(null, "", "#" + encodeURIComponent(route));
+		else
+			window.location.hash = encodeURIComponent(route);
+	}
+}
+
+function rtHashChange() {
+	var hash = decodeURIComponent(window.location.hash.substring(1));
+	for (var compId in routers)
+		if (routers[compId] != null && routers[compId] != hash && document.getElementById(compId))
+			se(null, ` + strconv.Itoa(int(ETYPE_STATE_CHANGE)) + `, compId, encodeURIComponent(hash));
+}
+
+if (window.addEventListener)
+	window.addEventListener("hashchange", rtHashChange, false);
+else if (window.attachEvent)
+	window.attachEvent("onhashchange", rtHashChange);
+
 // INITIALIZATION
 
 addonload(function() {
 	focusComp(_focCompId);
+	idleReset(_idleLeft);
+	if (_wsEnabled && window.WebSocket)
+		wsOpen();
+	else if (_pushEnabled)
+		pushPoll();
 });
 `)
 }
+
+// jsEscape escapes a string so it can be safely embedded
+// in a single-quoted JavaScript string literal inside HTML.
+func jsEscape(s string) string {
+	return jsReplacer.Replace(s)
+}
+
+// Replacer used by jsEscape.
+var jsReplacer = strings.NewReplacer(`\`, `\\`, "'", `\'`, "\n", `\n`, "\r", `\r`, "<", `\x3c`, ">", `\x3e`)
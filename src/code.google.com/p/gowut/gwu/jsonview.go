@@ -0,0 +1,330 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// JsonView component interface and implementation.
+
+package gwu
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JsonView interface defines a component which displays an arbitrary
+// Go value or raw JSON as a collapsible tree, e.g. for API consoles
+// and debugging screens.
+//
+// Nodes can be expanded and collapsed, and searched by typing into the
+// search box, all in the browser, without server round trips.
+// Clicking on the key of a node copies its path (e.g. $.items[2].name)
+// to the clipboard.
+//
+// Default style classes: "gwu-JsonView", "gwu-JsonView-Search", "gwu-JsonView-Tree",
+// "gwu-JsonView-Node", "gwu-JsonView-Toggle", "gwuimg-collapsed", "gwuimg-expanded",
+// "gwu-JsonView-Key", "gwu-JsonView-Summary", "gwu-JsonView-Children",
+// "gwu-JsonView-String", "gwu-JsonView-Number", "gwu-JsonView-Bool",
+// "gwu-JsonView-Null", "gwu-JsonView-Match"
+type JsonView interface {
+	// JsonView is a component.
+	Comp
+
+	// Json returns the displayed JSON.
+	Json() []byte
+
+	// SetJson sets the JSON to display.
+	// The order of the object keys is preserved.
+	// An error is returned if data is not valid JSON,
+	// in which case the displayed JSON is not changed.
+	SetJson(data []byte) error
+
+	// SetValue sets the Go value to display, marshaled to JSON
+	// with the encoding/json package.
+	// An error is returned if the value cannot be marshaled,
+	// in which case the displayed JSON is not changed.
+	SetValue(v interface{}) error
+
+	// ExpandDepth returns the number of levels expanded initially.
+	ExpandDepth() int
+
+	// SetExpandDepth sets the number of levels expanded initially.
+	// Pass a negative value to expand all levels.
+	// Default is 1 (only the root is expanded).
+	SetExpandDepth(depth int)
+
+	// SearchText returns the text displayed in the empty search box.
+	SearchText() string
+
+	// SetSearchText sets the text displayed in the empty search box.
+	SetSearchText(text string)
+}
+
+// Kind of a JSON node.
+type jsonKind int
+
+// JSON node kinds.
+const (
+	jsonObject jsonKind = iota // Object
+	jsonArray                  // Array
+	jsonString                 // String
+	jsonNumber                 // Number
+	jsonBool                   // Bool
+	jsonNull                   // Null
+)
+
+// jsonNode is a node of a parsed JSON document.
+type jsonNode struct {
+	kind     jsonKind    // Kind of the node
+	key      string      // Key (or index) of the node in its parent
+	path     string      // Path of the node, e.g. $.items[2].name
+	value    string      // JSON text of the value of leaf nodes
+	children []*jsonNode // Child nodes of objects and arrays
+}
+
+// JsonView implementation.
+type jsonViewImpl struct {
+	compImpl // Component implementation
+
+	json        []byte    // The displayed JSON
+	root        *jsonNode // The root node of the parsed JSON
+	expandDepth int       // Number of levels expanded initially
+	searchText  string    // Text displayed in the empty search box
+}
+
+// NewJsonView creates a new JsonView.
+// v is the Go value to display, see SetValue().
+// If v cannot be marshaled, the view will be empty.
+func NewJsonView(v interface{}) JsonView {
+	c := &jsonViewImpl{compImpl: newCompImpl(nil), expandDepth: 1, searchText: "Search"}
+	c.SetValue(v)
+	c.Style().AddClass("gwu-JsonView")
+	return c
+}
+
+func (c *jsonViewImpl) Json() []byte {
+	return c.json
+}
+
+func (c *jsonViewImpl) SetJson(data []byte) error {
+	root, err := parseJson(data)
+	if err != nil {
+		return err
+	}
+	c.json, c.root = data, root
+	return nil
+}
+
+func (c *jsonViewImpl) SetValue(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.SetJson(data)
+}
+
+func (c *jsonViewImpl) ExpandDepth() int {
+	return c.expandDepth
+}
+
+func (c *jsonViewImpl) SetExpandDepth(depth int) {
+	c.expandDepth = depth
+}
+
+func (c *jsonViewImpl) SearchText() string {
+	return c.searchText
+}
+
+func (c *jsonViewImpl) SetSearchText(text string) {
+	c.searchText = text
+}
+
+// parseJson parses a JSON document into a tree of nodes,
+// preserving the order of object keys.
+func parseJson(data []byte) (*jsonNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root := &jsonNode{path: "$"}
+	if err := parseJsonNode(dec, root); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("Invalid JSON: data after the top-level value!")
+	}
+	return root, nil
+}
+
+// Regexp of object keys which can be used in paths in dot notation.
+var jsonIdentRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// parseJsonNode parses the next value from dec into n.
+func parseJsonNode(dec *json.Decoder, n *jsonNode) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := t.(type) {
+	case json.Delim:
+		n.kind = jsonArray
+		if v == '{' {
+			n.kind = jsonObject
+		}
+		for i := 0; dec.More(); i++ {
+			child := &jsonNode{}
+			if n.kind == jsonObject {
+				kt, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child.key = kt.(string)
+				if jsonIdentRegexp.MatchString(child.key) {
+					child.path = n.path + "." + child.key
+				} else {
+					child.path = n.path + "[" + strconv.Quote(child.key) + "]"
+				}
+			} else {
+				child.key = strconv.Itoa(i)
+				child.path = n.path + "[" + child.key + "]"
+			}
+			if err := parseJsonNode(dec, child); err != nil {
+				return err
+			}
+			n.children = append(n.children, child)
+		}
+		// Closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	case string:
+		n.kind = jsonString
+		// Don't escape HTML characters, the value is HTML-escaped when rendered
+		var quoted bytes.Buffer
+		enc := json.NewEncoder(&quoted)
+		enc.SetEscapeHTML(false)
+		enc.Encode(v)
+		n.value = strings.TrimSuffix(quoted.String(), "\n")
+	case json.Number:
+		n.kind, n.value = jsonNumber, v.String()
+	case bool:
+		n.kind, n.value = jsonBool, strconv.FormatBool(v)
+	case nil:
+		n.kind, n.value = jsonNull, "null"
+	}
+	return nil
+}
+
+// Style classes of the leaf node kinds.
+var jsonValueClasses = map[jsonKind]string{jsonString: "gwu-JsonView-String", jsonNumber: "gwu-JsonView-Number",
+	jsonBool: "gwu-JsonView-Bool", jsonNull: "gwu-JsonView-Null"}
+
+var (
+	_STR_JV_SEARCH_OP   = []byte(`<input type="text" class="gwu-JsonView-Search" placeholder="`) // `<input type="text" class="gwu-JsonView-Search" placeholder="`
+	_STR_JV_SEARCH_ON   = []byte(`" oninput="jvSearch(`)                                         // `" oninput="jvSearch(`
+	_STR_JV_SEARCH_CL   = []byte(`)">`)                                                          // `)">`
+	_STR_JV_TREE_OP     = []byte(`<div class="gwu-JsonView-Tree">`)                              // `<div class="gwu-JsonView-Tree">`
+	_STR_JV_NODE_OP     = []byte(`<div class="gwu-JsonView-Node">`)                              // `<div class="gwu-JsonView-Node">`
+	_STR_JV_TOGGLE_OP   = []byte(`<span class="gwu-JsonView-Toggle `)                            // `<span class="gwu-JsonView-Toggle `
+	_STR_JV_TOGGLE_CL   = []byte(`" onclick="jvToggle(this)"></span>`)                           // `" onclick="jvToggle(this)"></span>`
+	_STR_JV_KEY_OP      = []byte(`<span class="gwu-JsonView-Key" title="`)                       // `<span class="gwu-JsonView-Key" title="`
+	_STR_JV_KEY_ON      = []byte(`" onclick="jvCopy(this)">`)                                    // `" onclick="jvCopy(this)">`
+	_STR_JV_KEY_CL      = []byte("</span>: ")                                                    // "</span>: "
+	_STR_JV_VALUE_OP    = []byte(`<span class="`)                                                // `<span class="`
+	_STR_JV_SUMMARY_OP  = []byte(`<span class="gwu-JsonView-Summary">`)                          // `<span class="gwu-JsonView-Summary">`
+	_STR_JV_CHILDREN_OP = []byte(`<div class="gwu-JsonView-Children"`)                           // `<div class="gwu-JsonView-Children"`
+	_STR_JV_HIDDEN      = []byte(` style="display:none"`)                                        // ` style="display:none"`
+)
+
+func (c *jsonViewImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_JV_SEARCH_OP)
+	w.Writees(c.searchText)
+	w.Write(_STR_JV_SEARCH_ON)
+	w.Writev(int(c.id))
+	w.Write(_STR_JV_SEARCH_CL)
+
+	w.Write(_STR_JV_TREE_OP)
+	if c.root != nil {
+		c.renderNode(w, c.root, 0)
+	}
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderNode renders a node and its children.
+func (c *jsonViewImpl) renderNode(w writer, n *jsonNode, depth int) {
+	w.Write(_STR_JV_NODE_OP)
+
+	container := n.kind == jsonObject || n.kind == jsonArray
+	expanded := c.expandDepth < 0 || depth < c.expandDepth
+	if container {
+		w.Write(_STR_JV_TOGGLE_OP)
+		if expanded {
+			w.Writes("gwuimg-expanded")
+		} else {
+			w.Writes("gwuimg-collapsed")
+		}
+		w.Write(_STR_JV_TOGGLE_CL)
+	}
+
+	if depth > 0 {
+		// To render: <span class="gwu-JsonView-Key" title="path" onclick="jvCopy(this)">key</span>:
+		w.Write(_STR_JV_KEY_OP)
+		w.Writees(n.path)
+		w.Write(_STR_JV_KEY_ON)
+		w.Writees(n.key)
+		w.Write(_STR_JV_KEY_CL)
+	}
+
+	if !container {
+		w.Write(_STR_JV_VALUE_OP)
+		w.Writes(jsonValueClasses[n.kind])
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		w.Writees(n.value)
+		w.Write(_STR_SPAN_CL)
+		w.Write(_STR_DIV_CL)
+		return
+	}
+
+	// Summary, e.g. {3} or [5]
+	w.Write(_STR_JV_SUMMARY_OP)
+	if n.kind == jsonObject {
+		w.Writevs("{", len(n.children), "}")
+	} else {
+		w.Writevs("[", len(n.children), "]")
+	}
+	w.Write(_STR_SPAN_CL)
+
+	w.Write(_STR_JV_CHILDREN_OP)
+	if !expanded {
+		w.Write(_STR_JV_HIDDEN)
+	}
+	w.Write(_STR_GT)
+	for _, child := range n.children {
+		c.renderNode(w, child, depth+1)
+	}
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_DIV_CL)
+}
@@ -18,6 +18,8 @@
 package gwu
 
 // Label interface defines a component which wraps a text into a component.
+// The text is HTML-escaped when rendered. Trusted HTML markup can be set
+// by SetSafeHTML() of SafeHTMLer (which is implemented by Label).
 // 
 // Default style class: "gwu-Label"
 type Label interface {
@@ -30,13 +32,13 @@ type Label interface {
 
 // Label implementation
 type labelImpl struct {
-	compImpl    // Component implementation
-	hasTextImpl // Has text implementation
+	compImpl        // Component implementation
+	hasSafeTextImpl // Has safe text implementation
 }
 
 // NewLabel creates a new Label.
 func NewLabel(text string) Label {
-	c := &labelImpl{newCompImpl(nil), newHasTextImpl(text)}
+	c := &labelImpl{compImpl: newCompImpl(nil), hasSafeTextImpl: newHasSafeTextImpl(text)}
 	c.Style().AddClass("gwu-Label")
 	return c
 }
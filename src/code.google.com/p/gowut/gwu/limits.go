@@ -0,0 +1,158 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Request size limits and upload quotas.
+
+package gwu
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Default max size of request bodies (except file uploads) in bytes.
+const DEFAULT_MAX_REQUEST_SIZE = 4 << 20
+
+// Limit type.
+type Limit int
+
+// Limits.
+const (
+	LIMIT_VALUE_SIZE   Limit = iota // Max size of synchronized values (see Comp.SetMaxValueSize() and Server.SetMaxValueSize())
+	LIMIT_UPLOAD_SIZE               // Max size of uploaded files (see FileUpload.SetMaxSize())
+	LIMIT_UPLOAD_QUOTA              // Upload quota of sessions (see Server.SetUploadQuota())
+	LIMIT_SESSION_COMPS             // Max number of components of sessions (see Server.SetMaxSessionComps())
+	LIMIT_SESSION_MEMORY            // Max memory footprint of sessions (see Server.SetMaxSessionMemory())
+)
+
+// String returns the name of the limit.
+func (l Limit) String() string {
+	switch l {
+	case LIMIT_VALUE_SIZE:
+		return "value size"
+	case LIMIT_UPLOAD_SIZE:
+		return "upload size"
+	case LIMIT_UPLOAD_QUOTA:
+		return "upload quota"
+	case LIMIT_SESSION_COMPS:
+		return "session components"
+	case LIMIT_SESSION_MEMORY:
+		return "session memory"
+	}
+	return fmt.Sprint("Limit(", int(l), ")")
+}
+
+// LimitError describes an exceeded limit.
+// It is available in ETYPE_LIMIT_EXCEEDED event handlers by Event.LimitError(),
+// and it is passed to the SessionLimitHandler.
+// Sizes are in bytes, except for LIMIT_SESSION_COMPS where they are component counts.
+type LimitError struct {
+	Limit Limit // The exceeded limit
+	Size  int64 // Size which exceeded the limit
+	Max   int64 // The max allowed size
+}
+
+// Error returns the description of the exceeded limit.
+func (e *LimitError) Error() string {
+	return fmt.Sprint("Limit exceeded: ", e.Limit, " (size: ", e.Size, ", max: ", e.Max, ")")
+}
+
+func (s *serverImpl) MaxRequestSize() int64 {
+	return s.maxRequestSize
+}
+
+func (s *serverImpl) SetMaxRequestSize(size int64) {
+	s.maxRequestSize = size
+}
+
+func (s *serverImpl) MaxValueSize() int {
+	return s.maxValueSize
+}
+
+func (s *serverImpl) SetMaxValueSize(size int) {
+	s.maxValueSize = size
+}
+
+func (s *serverImpl) UploadQuota() int64 {
+	return s.uploadQuota
+}
+
+func (s *serverImpl) SetUploadQuota(quota int64) {
+	s.uploadQuota = quota
+}
+
+func (c *compImpl) MaxValueSize() int {
+	return c.maxValueSize
+}
+
+func (c *compImpl) SetMaxValueSize(size int) {
+	c.maxValueSize = size
+}
+
+// checkValueSize checks the size of the values sent by the client with an event
+// (the component value and the additional named values) against the max value size
+// of the component (or of the server if the component has none).
+// nil is returned if the size is within the limit.
+func (s *serverImpl) checkValueSize(comp Comp, r *http.Request) *LimitError {
+	max := comp.MaxValueSize()
+	if max == 0 {
+		max = s.maxValueSize
+	}
+	if max <= 0 {
+		return nil
+	}
+
+	var size int
+	for name, values := range r.Form {
+		if name == _PARAM_COMP_VALUE || strings.HasPrefix(name, _PARAM_COMP_VALUE_PREFIX) {
+			for _, v := range values {
+				size += len(v)
+			}
+		}
+	}
+	if size > max {
+		return &LimitError{Limit: LIMIT_VALUE_SIZE, Size: int64(size), Max: int64(max)}
+	}
+	return nil
+}
+
+// dispatchLimitExceeded dispatches an ETYPE_LIMIT_EXCEEDED event to the component
+// forked from the specified event.
+func dispatchLimitExceeded(e *eventImpl, comp Comp, err *LimitError) {
+	le := e.forkEvent(ETYPE_LIMIT_EXCEEDED, comp).(*eventImpl)
+	le.limitErr = err
+	comp.dispatchEvent(le)
+}
+
+func (s *sessionImpl) Uploaded() int64 {
+	return atomic.LoadInt64(&s.uploaded)
+}
+
+// reserveUpload reserves the specified number of bytes from the upload quota.
+// Returns false if the quota would be exceeded, in which case nothing is reserved.
+// Pass a negative size to release a reservation.
+func (s *sessionImpl) reserveUpload(size, quota int64) bool {
+	for {
+		uploaded := atomic.LoadInt64(&s.uploaded)
+		if size > 0 && quota > 0 && uploaded+size > quota {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&s.uploaded, uploaded, uploaded+size) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxRequestSize(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	s.SetMaxRequestSize(64)
+	s.AddWin(NewWindow("main", "Main"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/main/"+s.paths.Event, strings.NewReader(strings.Repeat("x", 100)))
+	s.serveHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected: %d, got: %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/main/"+s.paths.Event, strings.NewReader("et=0"))
+	s.serveHTTP(w, r)
+	if w.Code == http.StatusRequestEntityTooLarge {
+		t.Errorf("Small request rejected")
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	win := NewWindow("main", "Main")
+	fu := NewFileUpload()
+	fu.SetMaxSize(10)
+	win.Add(fu)
+	s.AddWin(win)
+	cid := fu.Id().String()
+
+	// The body is the file content, parameters must not be taken from it
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/main/"+s.paths.Upload, strings.NewReader(_PARAM_COMP_ID+"="+cid))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	s.serveHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Body parsed as form, expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Max size applies if the content length is unknown
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/main/"+s.paths.Upload+"?"+_PARAM_COMP_ID+"="+cid, strings.NewReader(strings.Repeat("x", 100)))
+	r.ContentLength = -1
+	s.serveHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Too big upload accepted, expected: %d, got: %d", http.StatusBadRequest, w.Code)
+	}
+	if fu.Reader() != nil {
+		t.Errorf("Too big upload accepted")
+	}
+}
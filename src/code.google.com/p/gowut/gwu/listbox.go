@@ -23,12 +23,33 @@ import (
 	"strings"
 )
 
+// ListItem is an item of a ListBox.
+type ListItem struct {
+	Value    string // Value (key) of the item
+	Text     string // Displayed text of the item, Value is displayed if empty
+	Icon     string // URL of the icon of the item, optional
+	Disabled bool   // Tells if the item is disabled (cannot be selected by the user)
+}
+
+// DisplayText returns the displayed text of the item:
+// Text if it is not empty, else Value.
+func (i ListItem) DisplayText() string {
+	if len(i.Text) > 0 {
+		return i.Text
+	}
+	return i.Value
+}
+
 // ListBox interface defines a component which allows selecting one or multiple values
 // from a predefined list.
 // 
+// Items can be simple values (strings), or ListItems with a display text,
+// an icon and an enabled state. Note that icons are only displayed by browsers
+// supporting background images for list options.
+// 
 // Suggested event type to handle changes: ETYPE_CHANGE
 // 
-// Default style class: "gwu-ListBox"
+// Default style classes: "gwu-ListBox", "gwu-ListBox-Icon"
 type ListBox interface {
 	// ListBox is a component
 	Comp
@@ -36,6 +57,28 @@ type ListBox interface {
 	// ListBox can be enabled/disabled.
 	HasEnabled
 
+	// ListBox has a versioned value (selection).
+	HasValueVersion
+
+	// ListBox has validators of the (first) selected value.
+	// The value is an empty string if nothing is selected.
+	HasValidators
+
+	// Values returns the values to choose from.
+	Values() []string
+
+	// AddItem adds an item to the end of the items to choose from.
+	AddItem(item ListItem)
+
+	// Items returns the items to choose from.
+	Items() []ListItem
+
+	// Item returns the item at index i.
+	Item(i int) ListItem
+
+	// ClearItems removes all items.
+	ClearItems()
+
 	// Multi tells if multiple selections are allowed.
 	Multi() bool
 
@@ -58,6 +101,9 @@ type ListBox interface {
 	// SelectedValues retruns all the selected values.
 	SelectedValues() []string
 
+	// SelectedItems returns all the selected items.
+	SelectedItems() []ListItem
+
 	// Selected tells if the value at index i is selected.
 	Selected(i int) bool
 
@@ -81,13 +127,14 @@ type ListBox interface {
 
 // ListBox implementation.
 type listBoxImpl struct {
-	compImpl       // Component implementation 
-	hasEnabledImpl // Has enabled implementation
-
-	values   []string // Values to choose from
-	multi    bool     // Allow multiple selection
-	selected []bool   // Array of selection state of the values
-	rows     int      // Number of displayed rows
+	compImpl          // Component implementation 
+	hasEnabledImpl    // Has enabled implementation
+	hasValidatorsImpl // Has validators implementation
+
+	items    []ListItem // Items to choose from
+	multi    bool       // Allow multiple selection
+	selected []bool     // Array of selection state of the items
+	rows     int        // Number of displayed rows
 }
 
 var (
@@ -96,12 +143,54 @@ var (
 
 // NewListBox creates a new ListBox.
 func NewListBox(values []string) ListBox {
-	c := &listBoxImpl{newCompImpl(_STR_SELIDXS), newHasEnabledImpl(), values, false, make([]bool, len(values)), 1}
+	items := make([]ListItem, len(values))
+	for i, value := range values {
+		items[i].Value = value
+	}
+	c := &listBoxImpl{compImpl: newInputCompImpl(_STR_SELIDXS), hasEnabledImpl: newHasEnabledImpl(), items: items, selected: make([]bool, len(values)), rows: 1}
 	c.AddSyncOnETypes(ETYPE_CHANGE)
 	c.Style().AddClass("gwu-ListBox")
 	return c
 }
 
+// NewListBoxItems creates a new ListBox with the specified items.
+func NewListBoxItems(items ...ListItem) ListBox {
+	c := NewListBox(nil)
+	for _, item := range items {
+		c.AddItem(item)
+	}
+	return c
+}
+
+func (c *listBoxImpl) Values() []string {
+	values := make([]string, len(c.items))
+	for i, item := range c.items {
+		values[i] = item.Value
+	}
+	return values
+}
+
+func (c *listBoxImpl) AddItem(item ListItem) {
+	c.items = append(c.items, item)
+	c.selected = append(c.selected, false)
+}
+
+func (c *listBoxImpl) Items() []ListItem {
+	items := make([]ListItem, len(c.items))
+	copy(items, c.items)
+	return items
+}
+
+func (c *listBoxImpl) Item(i int) ListItem {
+	return c.items[i]
+}
+
+func (c *listBoxImpl) ClearItems() {
+	c.items = nil
+	c.selected = nil
+	c.valueChanged()
+}
+
 func (c *listBoxImpl) Multi() bool {
 	return c.multi
 }
@@ -120,7 +209,7 @@ func (c *listBoxImpl) SetRows(rows int) {
 
 func (c *listBoxImpl) SelectedValue() string {
 	if i := c.SelectedIdx(); i >= 0 {
-		return c.values[i]
+		return c.items[i].Value
 	}
 
 	return ""
@@ -129,7 +218,16 @@ func (c *listBoxImpl) SelectedValue() string {
 func (c *listBoxImpl) SelectedValues() (sv []string) {
 	for i, s := range c.selected {
 		if s {
-			sv = append(sv, c.values[i])
+			sv = append(sv, c.items[i].Value)
+		}
+	}
+	return
+}
+
+func (c *listBoxImpl) SelectedItems() (si []ListItem) {
+	for i, s := range c.selected {
+		if s {
+			si = append(si, c.items[i])
 		}
 	}
 	return
@@ -159,6 +257,7 @@ func (c *listBoxImpl) SelectedIndices() (si []int) {
 
 func (c *listBoxImpl) SetSelected(i int, selected bool) {
 	c.selected[i] = selected
+	c.valueChanged()
 }
 
 func (c *listBoxImpl) SetSelectedIndices(indices []int) {
@@ -171,9 +270,18 @@ func (c *listBoxImpl) SetSelectedIndices(indices []int) {
 	for _, idx := range indices {
 		c.selected[idx] = true
 	}
+
+	c.valueChanged()
 }
 
 func (c *listBoxImpl) ClearSelected() {
+	c.clearSelected()
+	c.valueChanged()
+}
+
+// clearSelected deselects all values without
+// registering a server side value change.
+func (c *listBoxImpl) clearSelected() {
 	for i, _ := range c.selected {
 		c.selected[i] = false
 	}
@@ -186,21 +294,38 @@ func (c *listBoxImpl) preprocessEvent(event Event, r *http.Request) {
 	}
 
 	// Set selected indices
-	c.ClearSelected()
+	c.clearSelected()
 	for _, sidx := range strings.Split(value, ",") {
-		if idx, err := strconv.Atoi(sidx); err == nil {
+		// Disabled items cannot be selected by the user
+		if idx, err := strconv.Atoi(sidx); err == nil && idx >= 0 && idx < len(c.items) && !c.items[idx].Disabled {
 			c.selected[idx] = true
 		}
 	}
+
+	// Validators not validating in the browser might give a different result
+	if _, changed := c.validate(c.SelectedValue(), c.Style()); changed {
+		event.MarkDirty(c)
+	}
+}
+
+func (c *listBoxImpl) Validate() error {
+	err, _ := c.validate(c.SelectedValue(), c.Style())
+	return err
+}
+
+func (c *listBoxImpl) Valid() bool {
+	return c.Validate() == nil
 }
 
 var (
-	_STR_SELECT_OP     = []byte("<select")                      // "<select"
-	_STR_MULTIPLE      = []byte(` multiple="multiple"`)         // ` multiple="multiple"`
-	_STR_OPTION_OP_SEL = []byte(`<option selected="selected">`) // `<option selected="selected">`
-	_STR_OPTION_OP     = []byte("<option>")                     // "<option>"
-	_STR_OPTION_CL     = []byte("</option>")                    // "</option>"
-	_STR_SELECT_CL     = []byte("</select>")                    // "</select>"
+	_STR_SELECT_OP      = []byte("<select")                                                      // "<select"
+	_STR_MULTIPLE       = []byte(` multiple="multiple"`)                                         // ` multiple="multiple"`
+	_STR_OPTION_OP      = []byte("<option")                                                      // "<option"
+	_STR_SELECTED       = []byte(` selected="selected"`)                                         // ` selected="selected"`
+	_STR_OPTION_ICON    = []byte(` class="gwu-ListBox-Icon" style="background-image:url(&quot;`) // ` class="gwu-ListBox-Icon" style="background-image:url(&quot;`
+	_STR_OPTION_ICON_CL = []byte(`&quot;)"`)                                                     // `&quot;)"`
+	_STR_OPTION_CL      = []byte("</option>")                                                    // "</option>"
+	_STR_SELECT_CL      = []byte("</select>")                                                    // "</select>"
 )
 
 func (c *listBoxImpl) Render(w writer) {
@@ -212,15 +337,24 @@ func (c *listBoxImpl) Render(w writer) {
 	c.renderAttrsAndStyle(w)
 	c.renderEnabled(w)
 	c.renderEHandlers(w)
+	c.renderValidators(w)
 	w.Write(_STR_GT)
 
-	for i, value := range c.values {
+	for i, item := range c.items {
+		w.Write(_STR_OPTION_OP)
 		if c.selected[i] {
-			w.Write(_STR_OPTION_OP_SEL)
-		} else {
-			w.Write(_STR_OPTION_OP)
+			w.Write(_STR_SELECTED)
+		}
+		if item.Disabled {
+			w.Write(_STR_DISABLED)
+		}
+		if len(item.Icon) > 0 {
+			w.Write(_STR_OPTION_ICON)
+			w.Writees(item.Icon)
+			w.Write(_STR_OPTION_ICON_CL)
 		}
-		w.Writees(value)
+		w.Write(_STR_GT)
+		w.Writees(item.DisplayText())
 		w.Write(_STR_OPTION_CL)
 	}
 
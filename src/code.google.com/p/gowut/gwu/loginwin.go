@@ -0,0 +1,300 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Defines the LoginWindow component.
+
+package gwu
+
+import (
+	"time"
+)
+
+// CredentialChecker is a function which checks the credentials entered
+// in a LoginWindow: returns the principal of the user if the credentials
+// are valid, nil otherwise.
+//
+// Example:
+//
+//	func checkCredentials(user, password string) gwu.Principal {
+//		if user == "admin" && gwu.ConstTimeEquals(password, "secret") {
+//			return gwu.NewPrincipal(user, "admin")
+//		}
+//		return nil
+//	}
+type CredentialChecker func(user, password string) Principal
+
+// LoginHandler is a function which is called after a successful login
+// in a LoginWindow, e.g. to build the private windows of the user.
+type LoginHandler func(e Event, p Principal)
+
+// Default texts of LoginWindow.
+const (
+	DEFAULT_LOGIN_USER_TEXT     = "User name:"  // Default text of the user name label
+	DEFAULT_LOGIN_PASSWORD_TEXT = "Password:"   // Default text of the password label
+	DEFAULT_LOGIN_REMEMBER_TEXT = "Remember me" // Default text of the remember me check box
+	DEFAULT_LOGIN_BUTTON_TEXT   = "Login"       // Default text of the login button
+)
+
+// LoginWindow interface defines a standard login window: a form
+// with user name and password fields, an optional remember me check box,
+// a login button and an error display.
+//
+// Credentials are checked by a CredentialChecker, through the login throttle
+// of the server (see Server.LoginThrottle()) for brute-force protection.
+// On successful login a new private session is created (the current private session,
+// if any, is removed to prevent session fixation), and the principal is set to it
+// (see Session.SetPrincipal()).
+// Then the login handler is called (see SetLoginHandler()), and the target window
+// is loaded (see SetTargetWin()).
+//
+// If remember me is checked, the timeout of the session is set to the
+// remember timeout (see SetRememberTimeout()).
+//
+// Login windows are typically public windows or are created by session
+// handlers for session creator names (see Server.AddSessCreatorName()),
+// and are set as the login window of the server (see Server.SetLoginWin()).
+//
+// Default style classes: "gwu-Window", "gwu-LoginWindow", "gwu-LoginWindow-Form",
+// "gwu-LoginWindow-Title", "gwu-LoginWindow-Error", "gwu-LoginWindow-Remember",
+// "gwu-LoginWindow-Button"
+type LoginWindow interface {
+	// LoginWindow is a Window.
+	Window
+
+	// Checker returns the credential checker.
+	Checker() CredentialChecker
+
+	// SetChecker sets the credential checker.
+	SetChecker(checker CredentialChecker)
+
+	// LoginHandler returns the login handler.
+	LoginHandler() LoginHandler
+
+	// SetLoginHandler sets the function to be called after a successful login.
+	SetLoginHandler(handler LoginHandler)
+
+	// TargetWin returns the name of the window to load after a successful login.
+	TargetWin() string
+
+	// SetTargetWin sets the name of the window to load after a successful login.
+	// If empty (this is the default), no window is loaded by the login window,
+	// the login handler should load one (see Event.ReloadWin()).
+	SetTargetWin(name string)
+
+	// RememberTimeout returns the session timeout set if remember me is checked.
+	RememberTimeout() time.Duration
+
+	// SetRememberTimeout sets the session timeout set if remember me is checked.
+	// The remember me check box is only displayed if this is positive.
+	// Default is 0 (the remember me check box is not displayed).
+	SetRememberTimeout(timeout time.Duration)
+
+	// SetTexts sets the texts of the user name and password labels,
+	// of the remember me check box and of the login button.
+	// Defaults are DEFAULT_LOGIN_USER_TEXT, DEFAULT_LOGIN_PASSWORD_TEXT,
+	// DEFAULT_LOGIN_REMEMBER_TEXT and DEFAULT_LOGIN_BUTTON_TEXT.
+	SetTexts(user, password, remember, button string)
+
+	// Form returns the panel of the login form (which contains the title,
+	// the error display, the fields and the login button), e.g. to add
+	// additional components to it.
+	Form() Panel
+
+	// UserBox returns the user name text box.
+	UserBox() TextBox
+
+	// SetError displays the specified error message in the form.
+	// Pass an empty string to clear the error message.
+	SetError(msg string)
+}
+
+// LoginWindow implementation.
+type loginWindowImpl struct {
+	*windowImpl // Window implementation
+
+	checker  CredentialChecker // Credential checker
+	handler  LoginHandler      // Login handler
+	target   string            // Name of the window to load after login
+	remember time.Duration     // Session timeout if remember me is checked
+
+	form      Panel    // Panel of the login form
+	errL      Label    // Label displaying the error message
+	userL     Label    // Label of the user name box
+	passwordL Label    // Label of the password box
+	userTb    TextBox  // User name box
+	passwordB TextBox  // Password box
+	rememberC CheckBox // Remember me check box
+	loginB    Button   // Login button
+}
+
+// NewLoginWindow creates a new LoginWindow with the specified name, text
+// (title) and credential checker.
+func NewLoginWindow(name, text string, checker CredentialChecker) LoginWindow {
+	c := &loginWindowImpl{windowImpl: NewWindow(name, text).(*windowImpl), checker: checker}
+	c.Style().AddClass("gwu-LoginWindow").SetFullSize()
+	c.SetAlign(HA_CENTER, VA_MIDDLE)
+
+	c.form = NewPanel()
+	c.form.Style().AddClass("gwu-LoginWindow-Form")
+	c.form.SetHAlign(HA_CENTER)
+	c.form.SetCellPadding(2)
+
+	title := NewLabel(text)
+	title.Style().AddClass("gwu-LoginWindow-Title")
+	c.form.Add(title)
+
+	c.errL = NewLabel("")
+	c.errL.Style().AddClass("gwu-LoginWindow-Error")
+	c.form.Add(c.errL)
+
+	table := NewTable()
+	table.SetCellPadding(2)
+	c.userL, c.passwordL = NewLabel(DEFAULT_LOGIN_USER_TEXT), NewLabel(DEFAULT_LOGIN_PASSWORD_TEXT)
+	c.userTb, c.passwordB = NewTextBox(""), NewPasswBox("")
+	table.Add(c.userL, 0, 0)
+	table.Add(c.userTb, 0, 1)
+	table.Add(c.passwordL, 1, 0)
+	table.Add(c.passwordB, 1, 1)
+	c.form.Add(table)
+
+	c.rememberC = NewCheckBox(DEFAULT_LOGIN_REMEMBER_TEXT)
+	c.rememberC.Style().AddClass("gwu-LoginWindow-Remember").SetDisplay(DISPLAY_NONE)
+	c.form.Add(c.rememberC)
+
+	c.loginB = NewButton(DEFAULT_LOGIN_BUTTON_TEXT)
+	c.loginB.Style().AddClass("gwu-LoginWindow-Button")
+	c.form.Add(c.loginB)
+
+	c.Add(c.form)
+	c.SetFocusedCompId(c.userTb.Id())
+
+	c.loginB.AddEHandlerFunc(c.login, ETYPE_CLICK)
+	// Enter in the password box also logs in
+	c.passwordB.AddSyncOnETypes(ETYPE_KEY_UP)
+	c.passwordB.AddEHandlerFunc(func(e Event) {
+		if e.KeyCode() == KEY_ENTER {
+			c.login(e)
+		}
+	}, ETYPE_KEY_UP)
+
+	return c
+}
+
+func (c *loginWindowImpl) Checker() CredentialChecker {
+	return c.checker
+}
+
+func (c *loginWindowImpl) SetChecker(checker CredentialChecker) {
+	c.checker = checker
+}
+
+func (c *loginWindowImpl) LoginHandler() LoginHandler {
+	return c.handler
+}
+
+func (c *loginWindowImpl) SetLoginHandler(handler LoginHandler) {
+	c.handler = handler
+}
+
+func (c *loginWindowImpl) TargetWin() string {
+	return c.target
+}
+
+func (c *loginWindowImpl) SetTargetWin(name string) {
+	c.target = name
+}
+
+func (c *loginWindowImpl) RememberTimeout() time.Duration {
+	return c.remember
+}
+
+func (c *loginWindowImpl) SetRememberTimeout(timeout time.Duration) {
+	c.remember = timeout
+	if timeout > 0 {
+		c.rememberC.Style().SetDisplay("")
+	} else {
+		c.rememberC.Style().SetDisplay(DISPLAY_NONE)
+	}
+}
+
+func (c *loginWindowImpl) SetTexts(user, password, remember, button string) {
+	c.userL.SetText(user)
+	c.passwordL.SetText(password)
+	c.rememberC.SetText(remember)
+	c.loginB.SetText(button)
+}
+
+func (c *loginWindowImpl) Form() Panel {
+	return c.form
+}
+
+func (c *loginWindowImpl) UserBox() TextBox {
+	return c.userTb
+}
+
+func (c *loginWindowImpl) SetError(msg string) {
+	c.errL.SetText(msg)
+}
+
+// login checks the entered credentials, and logs in the user if they are valid.
+func (c *loginWindowImpl) login(e Event) {
+	user, password := c.userTb.Text(), c.passwordB.Text()
+	// Password is not kept (not even in the browser)
+	c.passwordB.SetText("")
+	e.MarkDirty(c.passwordB)
+
+	var p Principal
+	check := func(user, password string) bool {
+		if c.checker != nil {
+			p = c.checker(user, password)
+		}
+		return p != nil
+	}
+
+	var throttle LoginThrottle
+	if ei, ok := e.(*eventImpl); ok && ei.shared.server != nil {
+		throttle = ei.shared.server.loginThrottle
+	}
+
+	var err error
+	if throttle != nil {
+		err = throttle.Login(user, password, e.Request().RemoteAddr(), check)
+	} else if !check(user, password) {
+		err = ErrInvalidCredentials
+	}
+	if err != nil {
+		c.SetError(err.Error())
+		e.MarkDirty(c.errL)
+		e.SetFocusedComp(c.passwordB)
+		return
+	}
+
+	c.SetError("")
+	e.MarkDirty(c.errL)
+
+	// Always issue a new session id on login (session fixation)
+	sess := e.NewSession()
+	sess.SetPrincipal(p)
+	if c.remember > 0 && c.rememberC.State() {
+		sess.SetTimeout(c.remember)
+	}
+
+	if c.handler != nil {
+		c.handler(e, p)
+	}
+	if len(c.target) > 0 {
+		e.ReloadWin(c.target)
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// LogView component interface and implementation.
+
+package gwu
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Severity of a log line.
+type Severity int
+
+// Log line severities.
+const (
+	SEV_NONE  Severity = iota // No severity (line is not colored)
+	SEV_DEBUG                 // Debug line
+	SEV_INFO                  // Info line
+	SEV_WARN                  // Warning line
+	SEV_ERROR                 // Error line
+)
+
+// Style classes of the severities.
+var sevClasses = []string{"", " gwu-LogView-Debug", " gwu-LogView-Info", " gwu-LogView-Warn", " gwu-LogView-Error"}
+
+// SeverityFunc is a function which tells the severity of a log line.
+type SeverityFunc func(line string) Severity
+
+// Default max number of lines retained by a LogView.
+const DEFAULT_MAX_LOG_LINES = 1000
+
+// Lines read by LogView.Follow() arriving within this time are pushed together.
+const _LOG_VIEW_PUSH_DELAY = 100 * time.Millisecond
+
+// LogView interface defines a component which displays log lines,
+// e.g. for ops dashboards. It is optimized for appending lines:
+// AppendLines() only sends the new lines to the browser, the view
+// is not re-rendered.
+//
+// Lines can be streamed from an io.Reader or a channel to the browser
+// with Follow() and FollowChan(). Lines are colored based on their severity,
+// and can be filtered in the browser by typing into the filter box.
+//
+// The view is scrolled to the bottom automatically when lines are appended,
+// unless the user scrolled up.
+//
+// Default style classes: "gwu-LogView", "gwu-LogView-Filter", "gwu-LogView-Lines",
+// "gwu-LogView-Line", "gwu-LogView-Debug", "gwu-LogView-Info",
+// "gwu-LogView-Warn", "gwu-LogView-Error"
+type LogView interface {
+	// LogView is a component.
+	Comp
+
+	// Lines returns the lines of the view.
+	Lines() []string
+
+	// AppendLines appends lines to the end of the view.
+	// e may be nil if not called during event handling (e.g. when building
+	// the window), else only the new lines are sent to the browser.
+	// Use Session.Push() to append lines from other goroutines.
+	AppendLines(e Event, lines ...string)
+
+	// ClearLines removes all lines.
+	// The view has to be marked dirty for the change to be visible.
+	ClearLines()
+
+	// MaxLines returns the max number of lines retained.
+	MaxLines() int
+
+	// SetMaxLines sets the max number of lines retained.
+	// Oldest lines are discarded (also in the browser) when this is exceeded.
+	// Pass 0 to retain all lines. Default is DEFAULT_MAX_LOG_LINES.
+	SetMaxLines(max int)
+
+	// SeverityFunc returns the function telling the severity of lines.
+	SeverityFunc() SeverityFunc
+
+	// SetSeverityFunc sets the function telling the severity of lines.
+	// The default looks for the "ERROR", "WARN", "INFO" and "DEBUG" words
+	// (and some synonyms) in the line.
+	SetSeverityFunc(f SeverityFunc)
+
+	// FilterText returns the text displayed in the empty filter box.
+	FilterText() string
+
+	// SetFilterText sets the text displayed in the empty filter box.
+	SetFilterText(text string)
+
+	// Follow starts a new goroutine which reads lines from r and appends
+	// them to the view using sess.Push(), until r reaches EOF or returns an error.
+	// Lines arriving close to each other are pushed together.
+	// sess is the session of the window the view is added to
+	// (the Server for public windows).
+	Follow(sess Session, r io.Reader)
+
+	// FollowChan starts a new goroutine which receives lines from ch and
+	// appends them to the view using sess.Push(), until ch is closed.
+	// Lines arriving close to each other are pushed together.
+	// sess is the session of the window the view is added to
+	// (the Server for public windows).
+	FollowChan(sess Session, ch <-chan string)
+}
+
+// LogView implementation.
+type logViewImpl struct {
+	compImpl // Component implementation
+
+	lines        []string     // Lines of the view
+	maxLines     int          // Max number of lines retained
+	severityFunc SeverityFunc // Severity function
+	filterText   string       // Text displayed in the empty filter box
+
+	tail *appendTail // Tail of the view, rendering appended lines
+}
+
+// NewLogView creates a new LogView.
+func NewLogView() LogView {
+	c := &logViewImpl{compImpl: newCompImpl(nil), maxLines: DEFAULT_MAX_LOG_LINES, severityFunc: DefaultSeverity, filterText: "Filter"}
+	c.tail = newAppendTail(c)
+	c.tail.setParent(c)
+	c.Style().AddClass("gwu-LogView")
+	return c
+}
+
+// DefaultSeverity is the default severity function of LogView.
+// It looks for the "ERROR", "WARN", "INFO" and "DEBUG" words
+// (and some synonyms) in the line.
+func DefaultSeverity(line string) Severity {
+	switch {
+	case strings.Contains(line, "ERROR") || strings.Contains(line, "FATAL") || strings.Contains(line, "PANIC"):
+		return SEV_ERROR
+	case strings.Contains(line, "WARN"):
+		return SEV_WARN
+	case strings.Contains(line, "INFO"):
+		return SEV_INFO
+	case strings.Contains(line, "DEBUG") || strings.Contains(line, "TRACE"):
+		return SEV_DEBUG
+	}
+	return SEV_NONE
+}
+
+// Remove is needed to be the parent of the tail (Container).
+func (c *logViewImpl) Remove(c2 Comp) bool {
+	return false
+}
+
+func (c *logViewImpl) ById(id ID) Comp {
+	switch id {
+	case c.id:
+		return c
+	case c.tail.id:
+		return c.tail
+	}
+	return nil
+}
+
+func (c *logViewImpl) Clear() {
+	c.ClearLines()
+}
+
+func (c *logViewImpl) Lines() []string {
+	lines := make([]string, len(c.lines))
+	copy(lines, c.lines)
+	return lines
+}
+
+func (c *logViewImpl) AppendLines(e Event, lines ...string) {
+	c.lines = append(c.lines, lines...)
+	c.tail.appended(len(lines))
+
+	if c.maxLines > 0 && len(c.lines) > c.maxLines {
+		n := len(c.lines) - c.maxLines
+		c.lines = append(c.lines[:0], c.lines[n:]...)
+	}
+
+	if e != nil {
+		e.MarkDirty(c.tail)
+	}
+}
+
+func (c *logViewImpl) ClearLines() {
+	c.lines = nil
+}
+
+func (c *logViewImpl) MaxLines() int {
+	return c.maxLines
+}
+
+func (c *logViewImpl) SetMaxLines(max int) {
+	c.maxLines = max
+}
+
+func (c *logViewImpl) SeverityFunc() SeverityFunc {
+	return c.severityFunc
+}
+
+func (c *logViewImpl) SetSeverityFunc(f SeverityFunc) {
+	c.severityFunc = f
+}
+
+func (c *logViewImpl) FilterText() string {
+	return c.filterText
+}
+
+func (c *logViewImpl) SetFilterText(text string) {
+	c.filterText = text
+}
+
+func (c *logViewImpl) Follow(sess Session, r io.Reader) {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if line = strings.TrimRight(line, "\r\n"); len(line) > 0 || err == nil {
+				ch <- line
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	c.FollowChan(sess, ch)
+}
+
+func (c *logViewImpl) FollowChan(sess Session, ch <-chan string) {
+	go pushCollected(sess, ch, _LOG_VIEW_PUSH_DELAY, func(e Event, lines []string) {
+		c.AppendLines(e, lines...)
+	})
+}
+
+var (
+	_STR_LV_FILTER_OP   = []byte(`<input type="text" class="gwu-LogView-Filter" placeholder="`) // `<input type="text" class="gwu-LogView-Filter" placeholder="`
+	_STR_LV_FILTER_ON   = []byte(`" oninput="lvFilter(`)                                        // `" oninput="lvFilter(`
+	_STR_LV_FILTER_CL   = []byte(`)">`)                                                         // `)">`
+	_STR_LV_LINES_OP    = []byte(`<div class="gwu-LogView-Lines">`)                             // `<div class="gwu-LogView-Lines">`
+	_STR_LV_INIT_OP     = []byte("<script>lvInit(")                                             // "<script>lvInit("
+	_STR_LV_APPENDED_OP = []byte("<script>lvAppended(")                                         // "<script>lvAppended("
+	_STR_LV_SCRIPT_CL   = []byte(");</script>")                                                 // ");</script>"
+	_STR_LV_LINE_OP     = []byte(`<div class="gwu-LogView-Line`)                                // `<div class="gwu-LogView-Line`
+)
+
+func (c *logViewImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_LV_FILTER_OP)
+	w.Writees(c.filterText)
+	w.Write(_STR_LV_FILTER_ON)
+	w.Writev(int(c.id))
+	w.Write(_STR_LV_FILTER_CL)
+
+	w.Write(_STR_LV_LINES_OP)
+	for _, line := range c.lines {
+		c.renderLine(w, line)
+	}
+	c.tail.Render(w)
+	w.Write(_STR_DIV_CL)
+
+	// To render: <script>lvInit(compId);</script>
+	w.writeScript(_STR_LV_INIT_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_LV_SCRIPT_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderLine renders a line.
+func (c *logViewImpl) renderLine(w writer, line string) {
+	w.Write(_STR_LV_LINE_OP)
+	if sev := c.severityFunc(line); sev > SEV_NONE && int(sev) < len(sevClasses) {
+		w.Writes(sevClasses[sev])
+	}
+	w.Write(_STR_QUOTE)
+	w.Write(_STR_GT)
+	w.Writees(line)
+	w.Write(_STR_DIV_CL)
+}
+
+func (c *logViewImpl) renderAppended(w writer, since int) {
+	// Lines discarded in the meantime are simply missing
+	start, _ := c.tail.start(len(c.lines), since)
+	for _, line := range c.lines[start:] {
+		c.renderLine(w, line)
+	}
+}
+
+func (c *logViewImpl) renderAppendedJs(w writer, since int) {
+	// To render: <script>lvAppended(compId,maxLines);</script>
+	w.writeScript(_STR_LV_APPENDED_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(c.maxLines)
+	w.Write(_STR_LV_SCRIPT_CL)
+}
+
+func (c *logViewImpl) usage() ([]Comp, int64) {
+	var memory int64
+	for _, line := range c.lines {
+		memory += _ITEM_FOOTPRINT + int64(len(line))
+	}
+	return nil, memory
+}
@@ -0,0 +1,221 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Defines the MediaCapture component.
+
+package gwu
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Media capture mode type.
+type CaptureMode int
+
+// Media capture modes.
+const (
+	CAPTURE_PHOTO CaptureMode = iota // Captures a webcam snapshot (image)
+	CAPTURE_AUDIO                    // Records audio from the microphone
+)
+
+// MediaCapture interface defines a component which captures a webcam
+// snapshot or records audio in the browser, and delivers the captured
+// data to the server.
+// 
+// The component renders a button. When clicked, the browser asks the user
+// for permission to use the camera or microphone. In CAPTURE_PHOTO mode
+// a live preview is displayed and clicking the button again takes a snapshot.
+// In CAPTURE_AUDIO mode the recording starts right away and it lasts until the button
+// is clicked again, or until the max duration is reached.
+// 
+// When the captured data arrives to the server, ETYPE_CHANGE is dispatched.
+// In ETYPE_CHANGE handlers the captured data can be acquired by BinaryValue(),
+// and its content type by ContentType(). Captured data exceeding the max
+// binary size is rejected and no event is dispatched.
+// 
+// Suggested event type to handle actions: ETYPE_CHANGE
+// 
+// Default style classes: "gwu-MediaCapture", "gwu-MediaCapture-Button",
+// "gwu-MediaCapture-Preview"
+type MediaCapture interface {
+	// MediaCapture is a component.
+	Comp
+
+	// MediaCapture can be enabled/disabled.
+	HasEnabled
+
+	// MediaCapture has a binary value, the captured data.
+	HasBinaryValue
+
+	// Mode returns the capture mode.
+	Mode() CaptureMode
+
+	// SetMode sets the capture mode.
+	SetMode(mode CaptureMode)
+
+	// MaxDuration returns the max duration of audio recordings.
+	MaxDuration() time.Duration
+
+	// SetMaxDuration sets the max duration of audio recordings.
+	// The recording is stopped automatically when the max duration is reached.
+	// Pass 0 to not limit the duration. The default is 1 minute.
+	SetMaxDuration(maxDuration time.Duration)
+
+	// Texts returns the texts of the button.
+	Texts() (start, capture string)
+
+	// SetTexts sets the texts of the button: start is displayed
+	// before the camera/microphone is started, capture is displayed
+	// while the preview is displayed / while recording.
+	// Pass empty strings to use the default texts of the mode.
+	SetTexts(start, capture string)
+
+	// ContentType returns the content (MIME) type of the captured data
+	// as reported by the browser, e.g. "image/png" or "audio/webm".
+	ContentType() string
+}
+
+// Default max duration of audio recordings.
+const DEFAULT_MAX_CAPTURE_DURATION = time.Minute
+
+// Default texts of the button, mapped from capture mode.
+var captureTexts = map[CaptureMode][2]string{
+	CAPTURE_PHOTO: {"Start camera", "Take photo"},
+	CAPTURE_AUDIO: {"Record", "Stop"},
+}
+
+// MediaCapture implementation.
+type mediaCaptureImpl struct {
+	compImpl           // Component implementation
+	hasEnabledImpl     // Has enabled implementation
+	hasBinaryValueImpl // Has binary value implementation
+
+	mode        CaptureMode   // Capture mode
+	maxDuration time.Duration // Max duration of audio recordings
+	startText   string        // Text of the button to start capturing
+	captureText string        // Text of the button to capture
+	contentType string        // Content type of the captured data
+
+	rejected bool // Tells if the received captured data was rejected
+}
+
+// NewMediaCapture creates a new MediaCapture.
+func NewMediaCapture(mode CaptureMode) MediaCapture {
+	c := &mediaCaptureImpl{compImpl: newInputCompImpl(nil), hasEnabledImpl: newHasEnabledImpl(), hasBinaryValueImpl: newHasBinaryValueImpl(),
+		mode: mode, maxDuration: DEFAULT_MAX_CAPTURE_DURATION}
+	c.Style().AddClass("gwu-MediaCapture")
+	return c
+}
+
+func (c *mediaCaptureImpl) Mode() CaptureMode {
+	return c.mode
+}
+
+func (c *mediaCaptureImpl) SetMode(mode CaptureMode) {
+	c.mode = mode
+}
+
+func (c *mediaCaptureImpl) MaxDuration() time.Duration {
+	return c.maxDuration
+}
+
+func (c *mediaCaptureImpl) SetMaxDuration(maxDuration time.Duration) {
+	c.maxDuration = maxDuration
+}
+
+func (c *mediaCaptureImpl) Texts() (start, capture string) {
+	return c.startText, c.captureText
+}
+
+func (c *mediaCaptureImpl) SetTexts(start, capture string) {
+	c.startText, c.captureText = start, capture
+}
+
+func (c *mediaCaptureImpl) ContentType() string {
+	return c.contentType
+}
+
+func (c *mediaCaptureImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_CHANGE {
+		return
+	}
+
+	// Value format: "contentType,base64data" (already decoded by FormValue())
+	value := r.FormValue(_PARAM_COMP_VALUE)
+	i := strings.IndexByte(value, ',')
+	if i < 0 {
+		c.rejected = true
+		return
+	}
+	if c.decodeBinaryValue(value[i+1:]) != nil {
+		c.rejected = true
+		return
+	}
+	c.contentType = value[:i]
+}
+
+func (c *mediaCaptureImpl) dispatchEvent(e Event) {
+	if c.rejected {
+		c.rejected = false
+		return
+	}
+	c.compImpl.dispatchEvent(e)
+}
+
+var (
+	_STR_MC_BUTTON_OP = []byte(`<button type="button" class="gwu-MediaCapture-Button"`) // `<button type="button" class="gwu-MediaCapture-Button"`
+	_STR_MC_ONCLICK   = []byte(` onclick="mcToggle(this,`)                              // ` onclick="mcToggle(this,`
+	_STR_MC_TEXTS     = []byte(`,'`)                                                    // `,'`
+	_STR_MC_TEXTS_SEP = []byte(`','`)                                                   // `','`
+	_STR_MC_BUTTON_CL = []byte(`')">`)                                                  // `')">`
+)
+
+func (c *mediaCaptureImpl) Render(w writer) {
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	start, capture := c.startText, c.captureText
+	if len(start) == 0 {
+		start = captureTexts[c.mode][0]
+	}
+	if len(capture) == 0 {
+		capture = captureTexts[c.mode][1]
+	}
+
+	// To render: <button ... onclick="mcToggle(this,compId,etype,mode,maxDurationMs,'start','capture')">start</button>
+	w.Write(_STR_MC_BUTTON_OP)
+	c.renderEnabled(w)
+	w.Write(_STR_MC_ONCLICK)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_CHANGE))
+	w.Write(_STR_COMMA)
+	w.Writev(int(c.mode))
+	w.Write(_STR_COMMA)
+	w.Writev(int(c.maxDuration / time.Millisecond))
+	w.Write(_STR_MC_TEXTS)
+	w.Writees(jsEscape(start))
+	w.Write(_STR_MC_TEXTS_SEP)
+	w.Writees(jsEscape(capture))
+	w.Write(_STR_MC_BUTTON_CL)
+	w.Writees(start)
+	w.Write(_STR_BUTTON_CL)
+
+	w.Write(_STR_SPAN_CL)
+}
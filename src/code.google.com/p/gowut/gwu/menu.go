@@ -0,0 +1,516 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// MenuBar, Menu, MenuItem and PopupMenu component interfaces and implementations.
+
+package gwu
+
+import (
+	"net/http"
+)
+
+// MenuItem interface defines a clickable item of a Menu.
+// Menu items are created by Menu.AddItem().
+//
+// Suggested event type to handle actions: ETYPE_CLICK
+//
+// Default style classes: "gwu-MenuItem", "gwu-MenuItem-Disabled"
+type MenuItem interface {
+	// MenuItem is a component.
+	Comp
+
+	// MenuItem has text.
+	HasText
+
+	// MenuItem can be enabled/disabled.
+	// Disabled menu items do not generate events.
+	HasEnabled
+
+	// Icon returns the URL of the icon of the menu item.
+	Icon() string
+
+	// SetIcon sets the URL of the icon of the menu item.
+	// Pass an empty string to remove the icon.
+	SetIcon(url string)
+}
+
+// Menu interface defines a menu which has a text (displayed as its label)
+// and which contains menu items, separators and submenus.
+// The menu is opened by clicking on its label, submenus are opened
+// by hovering over them.
+// Menus are created by MenuBar.AddMenu() and Menu.AddMenu().
+//
+// Default style classes: "gwu-Menu", "gwu-Menu-Disabled", "gwu-Menu-Label",
+// "gwu-Menu-Icon", "gwu-Menu-Popup", "gwu-Menu-Separator", "gwu-Menu-Open"
+type Menu interface {
+	// Menu is a container.
+	Container
+
+	// Menu has text.
+	HasText
+
+	// Menu can be enabled/disabled.
+	// Disabled menus cannot be opened.
+	HasEnabled
+
+	// Icon returns the URL of the icon of the menu.
+	Icon() string
+
+	// SetIcon sets the URL of the icon of the menu.
+	// Pass an empty string to remove the icon.
+	SetIcon(url string)
+
+	// AddItem creates a new menu item with the specified text,
+	// and adds it to the end of the menu.
+	AddItem(text string) MenuItem
+
+	// AddMenu creates a new submenu with the specified text,
+	// and adds it to the end of the menu.
+	AddMenu(text string) Menu
+
+	// AddSeparator adds a separator to the end of the menu.
+	AddSeparator()
+
+	// Entries returns the menu items and submenus of the menu.
+	Entries() []Comp
+}
+
+// MenuBar interface defines a desktop-style menu bar containing menus,
+// usually placed at the top of a window.
+//
+// The menus can be navigated with the keyboard: the arrow keys move between
+// the menus and menu items (and open submenus), Enter and Space activate
+// the focused menu item, Escape closes the open menu.
+//
+// Default style class: "gwu-MenuBar"
+type MenuBar interface {
+	// MenuBar is a container.
+	Container
+
+	// AddMenu creates a new menu with the specified text,
+	// and adds it to the end of the menu bar.
+	AddMenu(text string) Menu
+
+	// Menus returns the menus of the menu bar.
+	Menus() []Menu
+}
+
+// PopupMenu interface defines a context menu which is opened at the mouse position
+// when the user right clicks on a component the popup menu is set to
+// by Comp.SetContextMenu(). The same popup menu can be set to multiple components.
+// The popup menu must be added to the window (or to any container of it),
+// it is hidden until opened.
+//
+// Selecting a menu item closes the popup menu, and the ETYPE_CLICK event handlers
+// of the menu item are called; the component the menu was opened on is
+// available by Target().
+//
+// Default style classes: "gwu-PopupMenu", "gwu-PopupMenu-Open"
+type PopupMenu interface {
+	// PopupMenu is a container.
+	Container
+
+	// AddItem creates a new menu item with the specified text,
+	// and adds it to the end of the popup menu.
+	AddItem(text string) MenuItem
+
+	// AddMenu creates a new submenu with the specified text,
+	// and adds it to the end of the popup menu.
+	AddMenu(text string) Menu
+
+	// AddSeparator adds a separator to the end of the popup menu.
+	AddSeparator()
+
+	// Entries returns the menu items and submenus of the popup menu.
+	Entries() []Comp
+
+	// Target returns the component the popup menu was last opened on.
+	Target() Comp
+}
+
+// MenuItem implementation.
+type menuItemImpl struct {
+	compImpl       // Component implementation
+	hasTextImpl    // Has text implementation
+	hasEnabledImpl // Has enabled implementation
+
+	icon string // URL of the icon
+}
+
+// Menu implementation.
+type menuImpl struct {
+	compImpl       // Component implementation
+	hasTextImpl    // Has text implementation
+	hasEnabledImpl // Has enabled implementation
+
+	icon    string // URL of the icon
+	entries []Comp // Menu items and submenus, nil for separators
+}
+
+// MenuBar implementation.
+type menuBarImpl struct {
+	compImpl // Component implementation
+
+	menus []Menu // Menus of the menu bar
+}
+
+// PopupMenu implementation.
+type popupMenuImpl struct {
+	menuImpl // Menu implementation (its entries)
+
+	target Comp // Component the popup menu was last opened on
+}
+
+// newMenuItemImpl creates a new MenuItem.
+func newMenuItemImpl(text string) *menuItemImpl {
+	c := &menuItemImpl{compImpl: newCompImpl(nil), hasTextImpl: newHasTextImpl(text), hasEnabledImpl: newHasEnabledImpl()}
+	c.Style().AddClass("gwu-MenuItem")
+	return c
+}
+
+// newMenuImpl creates a new Menu.
+func newMenuImpl(text string) *menuImpl {
+	c := &menuImpl{compImpl: newCompImpl(nil), hasTextImpl: newHasTextImpl(text), hasEnabledImpl: newHasEnabledImpl()}
+	c.Style().AddClass("gwu-Menu")
+	return c
+}
+
+// NewMenuBar creates a new MenuBar.
+func NewMenuBar() MenuBar {
+	c := &menuBarImpl{compImpl: newCompImpl(nil)}
+	c.Style().AddClass("gwu-MenuBar")
+	return c
+}
+
+// NewPopupMenu creates a new PopupMenu.
+func NewPopupMenu() PopupMenu {
+	c := &popupMenuImpl{menuImpl: *newMenuImpl("")}
+	c.Style().SetClass("gwu-PopupMenu")
+	return c
+}
+
+func (c *menuItemImpl) Icon() string {
+	return c.icon
+}
+
+func (c *menuItemImpl) SetIcon(url string) {
+	c.icon = url
+}
+
+// SetEnabled sets the enabled property.
+// We have to manage the disabled class style too.
+func (c *menuItemImpl) SetEnabled(enabled bool) {
+	if enabled {
+		c.Style().RemoveClass("gwu-MenuItem-Disabled")
+	} else {
+		c.Style().AddClass("gwu-MenuItem-Disabled")
+	}
+
+	c.hasEnabledImpl.SetEnabled(enabled)
+}
+
+func (c *menuImpl) Remove(c2 Comp) bool {
+	for i, entry := range c.entries {
+		if entry != nil && entry.Equals(c2) {
+			c2.setParent(nil)
+			c.entries = append(c.entries[:i], c.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *menuImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	for _, entry := range c.entries {
+		if entry == nil {
+			continue
+		}
+		if entry.Id() == id {
+			return entry
+		}
+		if c2, isContainer := entry.(Container); isContainer {
+			if c3 := c2.ById(id); c3 != nil {
+				return c3
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *menuImpl) Clear() {
+	for _, entry := range c.entries {
+		if entry != nil {
+			entry.setParent(nil)
+		}
+	}
+	c.entries = nil
+}
+
+func (c *menuImpl) Icon() string {
+	return c.icon
+}
+
+func (c *menuImpl) SetIcon(url string) {
+	c.icon = url
+}
+
+// SetEnabled sets the enabled property.
+// We have to manage the disabled class style too.
+func (c *menuImpl) SetEnabled(enabled bool) {
+	if enabled {
+		c.Style().RemoveClass("gwu-Menu-Disabled")
+	} else {
+		c.Style().AddClass("gwu-Menu-Disabled")
+	}
+
+	c.hasEnabledImpl.SetEnabled(enabled)
+}
+
+func (c *menuImpl) AddItem(text string) MenuItem {
+	item := newMenuItemImpl(text)
+	item.setParent(c)
+	c.entries = append(c.entries, item)
+	return item
+}
+
+func (c *menuImpl) AddMenu(text string) Menu {
+	menu := newMenuImpl(text)
+	menu.setParent(c)
+	c.entries = append(c.entries, menu)
+	return menu
+}
+
+func (c *menuImpl) AddSeparator() {
+	c.entries = append(c.entries, nil)
+}
+
+func (c *menuImpl) Entries() []Comp {
+	entries := make([]Comp, 0, len(c.entries))
+	for _, entry := range c.entries {
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (c *menuBarImpl) Remove(c2 Comp) bool {
+	for i, menu := range c.menus {
+		if menu.Equals(c2) {
+			c2.setParent(nil)
+			c.menus = append(c.menus[:i], c.menus[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *menuBarImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	for _, menu := range c.menus {
+		if c2 := menu.ById(id); c2 != nil {
+			return c2
+		}
+	}
+
+	return nil
+}
+
+func (c *menuBarImpl) Clear() {
+	for _, menu := range c.menus {
+		menu.setParent(nil)
+	}
+	c.menus = nil
+}
+
+func (c *menuBarImpl) AddMenu(text string) Menu {
+	menu := newMenuImpl(text)
+	menu.setParent(c)
+	c.menus = append(c.menus, menu)
+	return menu
+}
+
+func (c *menuBarImpl) Menus() []Menu {
+	return c.menus
+}
+
+func (c *popupMenuImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+	return c.menuImpl.ById(id)
+}
+
+func (c *popupMenuImpl) Target() Comp {
+	return c.target
+}
+
+func (c *popupMenuImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_CONTEXT_MENU {
+		return
+	}
+
+	// Value is the id of the component the popup menu is opened on
+	id, err := AtoID(r.FormValue(_PARAM_COMP_VALUE))
+	if err != nil {
+		return
+	}
+	if e, isImpl := event.(*eventImpl); isImpl && e.shared.win != nil {
+		c.target = e.shared.win.ById(id)
+	}
+}
+
+func (c *compImpl) ContextMenu() PopupMenu {
+	return c.contextMenu
+}
+
+func (c *compImpl) SetContextMenu(menu PopupMenu) {
+	c.contextMenu = menu
+}
+
+var (
+	_STR_MENU_ITEM_ATTRS  = []byte(` role="menuitem" tabindex="-1" onmouseenter="menuHover(this)"`)                            // ` role="menuitem" tabindex="-1" onmouseenter="menuHover(this)"`
+	_STR_MENU_ARIA_DIS    = []byte(` aria-disabled="true"`)                                                                    // ` aria-disabled="true"`
+	_STR_MENU_OP          = []byte(` role="none" onmouseenter="menuHover(this)"><span class="gwu-Menu-Label" role="menuitem"`) // ` role="none" onmouseenter="menuHover(this)"><span class="gwu-Menu-Label" role="menuitem"`
+	_STR_MENU_LABEL_TOP   = []byte(` tabindex="0"`)                                                                            // ` tabindex="0"`
+	_STR_MENU_LABEL_SUB   = []byte(` tabindex="-1"`)                                                                           // ` tabindex="-1"`
+	_STR_MENU_LABEL_CL    = []byte(` aria-haspopup="true" aria-expanded="false" onclick="menuClick(this.parentNode)">`)        // ` aria-haspopup="true" aria-expanded="false" onclick="menuClick(this.parentNode)">`
+	_STR_MENU_POPUP_OP    = []byte(`</span><div class="gwu-Menu-Popup" role="menu">`)                                          // `</span><div class="gwu-Menu-Popup" role="menu">`
+	_STR_MENU_SEPARATOR   = []byte(`<div class="gwu-Menu-Separator" role="separator"></div>`)                                  // `<div class="gwu-Menu-Separator" role="separator"></div>`
+	_STR_MENU_ICON_OP     = []byte(`<img class="gwu-Menu-Icon" alt="" src="`)                                                  // `<img class="gwu-Menu-Icon" alt="" src="`
+	_STR_MENU_POPUP_ROOT  = []byte(` role="menu" onkeydown="menuKey(event)">`)                                                 // ` role="menu" onkeydown="menuKey(event)">`
+	_STR_CONTEXT_MENU_OP  = []byte(` oncontextmenu="ctxMenu(event,`)                                                           // ` oncontextmenu="ctxMenu(event,`
+	_STR_MENU_BAR_KEYDOWN = []byte(` role="menubar" onkeydown="menuKey(event)">`)                                              // ` role="menubar" onkeydown="menuKey(event)">`
+)
+
+func (c *menuItemImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	w.Write(_STR_MENU_ITEM_ATTRS)
+	if c.enabled {
+		c.renderEHandlers(w)
+	} else {
+		w.Write(_STR_MENU_ARIA_DIS)
+	}
+	w.Write(_STR_GT)
+
+	renderMenuIcon(w, c.icon)
+	c.renderText(w)
+
+	w.Write(_STR_DIV_CL)
+}
+
+func (c *menuImpl) Render(w writer) {
+	// To render: <div id="compId" class="gwu-Menu"><span class="gwu-Menu-Label">text</span><div class="gwu-Menu-Popup">entries</div></div>
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	w.Write(_STR_MENU_OP)
+	if _, top := c.parent.(MenuBar); top {
+		w.Write(_STR_MENU_LABEL_TOP)
+	} else {
+		w.Write(_STR_MENU_LABEL_SUB)
+	}
+	if !c.enabled {
+		w.Write(_STR_MENU_ARIA_DIS)
+	}
+	w.Write(_STR_MENU_LABEL_CL)
+
+	renderMenuIcon(w, c.icon)
+	c.renderText(w)
+
+	w.Write(_STR_MENU_POPUP_OP)
+	for _, entry := range c.entries {
+		if entry == nil {
+			w.Write(_STR_MENU_SEPARATOR)
+		} else {
+			entry.Render(w)
+		}
+	}
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderMenuIcon renders the icon of a menu or menu item, if it has one.
+func renderMenuIcon(w writer, icon string) {
+	if icon == "" {
+		return
+	}
+	w.Write(_STR_MENU_ICON_OP)
+	w.Writees(icon)
+	w.Write(_STR_IMG_CL)
+}
+
+func (c *popupMenuImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	w.Write(_STR_MENU_POPUP_ROOT)
+
+	for _, entry := range c.entries {
+		if entry == nil {
+			w.Write(_STR_MENU_SEPARATOR)
+		} else {
+			entry.Render(w)
+		}
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderContextMenu renders the context menu opener attribute of the component
+// if it has a context menu.
+// If the component has ETYPE_CONTEXT_MENU handlers, the event is also sent.
+func (c *compImpl) renderContextMenu(w writer) {
+	if c.contextMenu == nil {
+		return
+	}
+
+	// To render: ` oncontextmenu="ctxMenu(event,menuId,compId,send)"`
+	w.Write(_STR_CONTEXT_MENU_OP)
+	w.Writevs(int(c.contextMenu.Id()), _STR_COMMA, int(c.id), _STR_COMMA, c.handlers[ETYPE_CONTEXT_MENU] != nil)
+	w.Write(_STR_SE_SUFFIX)
+}
+
+func (c *menuBarImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	w.Write(_STR_MENU_BAR_KEYDOWN)
+
+	for _, menu := range c.menus {
+		menu.Render(w)
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
+func (c *menuImpl) usage() ([]Comp, int64) {
+	return usageComps(c.entries...), 0
+}
+
+func (c *menuBarImpl) usage() ([]Comp, int64) {
+	comps := make([]Comp, len(c.menus))
+	for i, menu := range c.menus {
+		comps[i] = menu
+	}
+	return comps, 0
+}
@@ -0,0 +1,295 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// MessageList component interface and implementation.
+
+package gwu
+
+import (
+	"time"
+)
+
+// Message is a message of a MessageList.
+type Message struct {
+	Author string    // Author of the message
+	Avatar string    // URL of the avatar image of the author, optional
+	Text   string    // Text of the message
+	Time   time.Time // Time of the message
+	Own    bool      // Tells if the message is sent by the user viewing the list (displayed differently)
+}
+
+// TimestampFunc is a function which formats the time of a message.
+type TimestampFunc func(t time.Time) string
+
+// AvatarFunc is a function which returns the URL of the avatar image of a message.
+// Return an empty string to display no avatar.
+type AvatarFunc func(m *Message) string
+
+// MessageList interface defines a component which displays a list of
+// messages, e.g. a chat. It is optimized for appending messages:
+// AppendMessage() only sends the new message to the browser, the list
+// is not re-rendered.
+// 
+// The list is scrolled to the bottom automatically when a message is appended,
+// unless the user scrolled up, in which case a "new messages" indicator is
+// displayed which scrolls to the bottom when clicked.
+// 
+// Default style classes: "gwu-MessageList", "gwu-MessageList-Messages",
+// "gwu-MessageList-Message", "gwu-MessageList-Own", "gwu-MessageList-Avatar",
+// "gwu-MessageList-Body", "gwu-MessageList-Author", "gwu-MessageList-Time",
+// "gwu-MessageList-Text", "gwu-MessageList-NewMessages"
+type MessageList interface {
+	// MessageList is a component.
+	Comp
+
+	// Messages returns the messages of the list.
+	Messages() []Message
+
+	// AppendMessage appends a message to the end of the list.
+	// e may be nil if not called during event handling (e.g. when building
+	// the window), else only the new message is sent to the browser.
+	// Use Session.Push() to append messages from other goroutines.
+	AppendMessage(m Message, e Event)
+
+	// ClearMessages removes all messages.
+	// The list has to be marked dirty for the change to be visible.
+	ClearMessages()
+
+	// MaxMessages returns the max number of messages retained.
+	MaxMessages() int
+
+	// SetMaxMessages sets the max number of messages retained.
+	// Oldest messages are discarded when this is exceeded.
+	// Messages already displayed in the browser remain visible until the
+	// list is re-rendered.
+	// Pass 0 to retain all messages. This is the default.
+	SetMaxMessages(max int)
+
+	// TimestampFunc returns the function formatting the time of messages.
+	TimestampFunc() TimestampFunc
+
+	// SetTimestampFunc sets the function formatting the time of messages.
+	// The default formats the time as "15:04".
+	SetTimestampFunc(f TimestampFunc)
+
+	// AvatarFunc returns the function providing the avatar images of messages.
+	AvatarFunc() AvatarFunc
+
+	// SetAvatarFunc sets the function providing the avatar images of messages.
+	// The default returns the Avatar field of the message.
+	SetAvatarFunc(f AvatarFunc)
+
+	// NewMessagesText returns the text of the new messages indicator.
+	NewMessagesText() string
+
+	// SetNewMessagesText sets the text of the new messages indicator.
+	SetNewMessagesText(text string)
+}
+
+// MessageList implementation.
+type messageListImpl struct {
+	compImpl // Component implementation
+
+	messages        []Message     // Messages of the list
+	maxMessages     int           // Max number of messages retained
+	timestampFunc   TimestampFunc // Timestamp formatting function
+	avatarFunc      AvatarFunc    // Avatar function
+	newMessagesText string        // Text of the new messages indicator
+
+	tail *appendTail // Tail of the list, rendering appended messages
+}
+
+// NewMessageList creates a new MessageList.
+func NewMessageList() MessageList {
+	c := &messageListImpl{compImpl: newCompImpl(nil), newMessagesText: "New messages"}
+	c.tail = newAppendTail(c)
+	c.tail.setParent(c)
+	c.timestampFunc = func(t time.Time) string {
+		return t.Format("15:04")
+	}
+	c.avatarFunc = func(m *Message) string {
+		return m.Avatar
+	}
+	c.Style().AddClass("gwu-MessageList")
+	return c
+}
+
+// Remove is needed to be the parent of the tail (Container).
+func (c *messageListImpl) Remove(c2 Comp) bool {
+	return false
+}
+
+func (c *messageListImpl) ById(id ID) Comp {
+	switch id {
+	case c.id:
+		return c
+	case c.tail.id:
+		return c.tail
+	}
+	return nil
+}
+
+func (c *messageListImpl) Clear() {
+	c.ClearMessages()
+}
+
+func (c *messageListImpl) Messages() []Message {
+	messages := make([]Message, len(c.messages))
+	copy(messages, c.messages)
+	return messages
+}
+
+func (c *messageListImpl) AppendMessage(m Message, e Event) {
+	c.messages = append(c.messages, m)
+	c.tail.appended(1)
+
+	if c.maxMessages > 0 && len(c.messages) > c.maxMessages {
+		n := len(c.messages) - c.maxMessages
+		c.messages = append(c.messages[:0], c.messages[n:]...)
+	}
+
+	if e != nil {
+		e.MarkDirty(c.tail)
+	}
+}
+
+func (c *messageListImpl) ClearMessages() {
+	c.messages = nil
+}
+
+func (c *messageListImpl) MaxMessages() int {
+	return c.maxMessages
+}
+
+func (c *messageListImpl) SetMaxMessages(max int) {
+	c.maxMessages = max
+}
+
+func (c *messageListImpl) TimestampFunc() TimestampFunc {
+	return c.timestampFunc
+}
+
+func (c *messageListImpl) SetTimestampFunc(f TimestampFunc) {
+	c.timestampFunc = f
+}
+
+func (c *messageListImpl) AvatarFunc() AvatarFunc {
+	return c.avatarFunc
+}
+
+func (c *messageListImpl) SetAvatarFunc(f AvatarFunc) {
+	c.avatarFunc = f
+}
+
+func (c *messageListImpl) NewMessagesText() string {
+	return c.newMessagesText
+}
+
+func (c *messageListImpl) SetNewMessagesText(text string) {
+	c.newMessagesText = text
+}
+
+var (
+	_STR_ML_MESSAGES_OP = []byte(`<div class="gwu-MessageList-Messages">`)                                           // `<div class="gwu-MessageList-Messages">`
+	_STR_ML_NEW_OP      = []byte(`<div class="gwu-MessageList-NewMessages" style="display:none" onclick="mlScroll(`) // `<div class="gwu-MessageList-NewMessages" style="display:none" onclick="mlScroll(`
+	_STR_ML_NEW_CL      = []byte(`)">`)                                                                              // `)">`
+	_STR_ML_INIT_OP     = []byte("<script>mlInit(")                                                                  // "<script>mlInit("
+	_STR_ML_APPENDED_OP = []byte("<script>mlAppended(")                                                              // "<script>mlAppended("
+	_STR_ML_SCRIPT_CL   = []byte(");</script>")                                                                      // ");</script>"
+	_STR_ML_MESSAGE_OP  = []byte(`<div class="gwu-MessageList-Message`)                                              // `<div class="gwu-MessageList-Message`
+	_STR_ML_OWN         = []byte(` gwu-MessageList-Own`)                                                             // ` gwu-MessageList-Own`
+	_STR_ML_AVATAR_OP   = []byte(`"><img class="gwu-MessageList-Avatar" src="`)                                      // `"><img class="gwu-MessageList-Avatar" src="`
+	_STR_ML_BODY_OP     = []byte(`<div class="gwu-MessageList-Body"><span class="gwu-MessageList-Author">`)          // `<div class="gwu-MessageList-Body"><span class="gwu-MessageList-Author">`
+	_STR_ML_TIME_OP     = []byte(`</span><span class="gwu-MessageList-Time">`)                                       // `</span><span class="gwu-MessageList-Time">`
+	_STR_ML_TEXT_OP     = []byte(`</span><div class="gwu-MessageList-Text">`)                                        // `</span><div class="gwu-MessageList-Text">`
+	_STR_ML_MESSAGE_CL  = []byte(`</div></div></div>`)                                                               // `</div></div></div>`
+)
+
+func (c *messageListImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_ML_MESSAGES_OP)
+	for i := range c.messages {
+		c.renderMessage(w, &c.messages[i])
+	}
+	c.tail.Render(w)
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_ML_NEW_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_ML_NEW_CL)
+	w.Writees(c.newMessagesText)
+	w.Write(_STR_DIV_CL)
+
+	// To render: <script>mlInit(compId);</script>
+	w.writeScript(_STR_ML_INIT_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_ML_SCRIPT_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderMessage renders a message.
+func (c *messageListImpl) renderMessage(w writer, m *Message) {
+	w.Write(_STR_ML_MESSAGE_OP)
+	if m.Own {
+		w.Write(_STR_ML_OWN)
+	}
+	if avatar := c.avatarFunc(m); len(avatar) > 0 {
+		w.Write(_STR_ML_AVATAR_OP)
+		w.Writees(avatar)
+		w.Write(_STR_QUOTE)
+	} else {
+		w.Write(_STR_QUOTE)
+	}
+	w.Write(_STR_GT)
+
+	w.Write(_STR_ML_BODY_OP)
+	w.Writees(m.Author)
+	w.Write(_STR_ML_TIME_OP)
+	if !m.Time.IsZero() {
+		w.Writees(c.timestampFunc(m.Time))
+	}
+	w.Write(_STR_ML_TEXT_OP)
+	w.Writees(m.Text)
+	w.Write(_STR_ML_MESSAGE_CL)
+}
+
+func (c *messageListImpl) renderAppended(w writer, since int) {
+	// Messages discarded in the meantime are simply missing
+	start, _ := c.tail.start(len(c.messages), since)
+	for i := start; i < len(c.messages); i++ {
+		c.renderMessage(w, &c.messages[i])
+	}
+}
+
+func (c *messageListImpl) renderAppendedJs(w writer, since int) {
+	// To render: <script>mlAppended(compId);</script>
+	w.writeScript(_STR_ML_APPENDED_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_ML_SCRIPT_CL)
+}
+
+func (c *messageListImpl) usage() ([]Comp, int64) {
+	var memory int64
+	for i := range c.messages {
+		m := &c.messages[i]
+		memory += _ITEM_FOOTPRINT + int64(len(m.Author)+len(m.Avatar)+len(m.Text))
+	}
+	return nil, memory
+}
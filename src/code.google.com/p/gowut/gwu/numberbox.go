@@ -0,0 +1,249 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// NumberBox component interface and implementation.
+
+package gwu
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// NumberBox interface defines a component for numeric input purpose,
+// with up/down stepper buttons (the arrow keys also step the value).
+// It can be in integer mode (the default) or float mode.
+//
+// The value is synchronized with the server on ETYPE_CHANGE event,
+// the parsed number is available by Value() (or IntValue()) in the
+// event handlers. Invalid numbers sent by the client are rejected
+// (the previous value is restored), numbers outside of the min and max
+// bounds are clamped.
+//
+// Suggested event type to handle changes: ETYPE_CHANGE
+//
+// Default style classes: "gwu-NumberBox", "gwu-NumberBox-Input",
+// "gwu-NumberBox-Down", "gwu-NumberBox-Up"
+type NumberBox interface {
+	// NumberBox is a component.
+	Comp
+
+	// NumberBox can be enabled/disabled.
+	HasEnabled
+
+	// NumberBox has a versioned value (number).
+	HasValueVersion
+
+	// Value returns the value.
+	Value() float64
+
+	// IntValue returns the value rounded to an int.
+	IntValue() int
+
+	// SetValue sets the value.
+	// The value is clamped to the range Min()..Max(),
+	// and rounded in integer mode.
+	SetValue(value float64)
+
+	// Min returns the min value (lower bound).
+	Min() float64
+
+	// SetMin sets the min value (lower bound).
+	// Pass math.Inf(-1) to not limit it (default).
+	SetMin(min float64)
+
+	// Max returns the max value (upper bound).
+	Max() float64
+
+	// SetMax sets the max value (upper bound).
+	// Pass math.Inf(1) to not limit it (default).
+	SetMax(max float64)
+
+	// Step returns the step of the stepper buttons.
+	Step() float64
+
+	// SetStep sets the step of the stepper buttons.
+	// Default is 1.
+	SetStep(step float64)
+
+	// Integer tells if the number box is in integer mode.
+	Integer() bool
+
+	// SetInteger sets if the number box is in integer mode.
+	// In integer mode the value is rounded to an integer.
+	SetInteger(integer bool)
+}
+
+// NumberBox implementation.
+type numberBoxImpl struct {
+	compImpl       // Component implementation
+	hasEnabledImpl // Has enabled implementation
+
+	value, min, max, step float64 // The value, the bounds and the step
+	integer               bool    // Tells if in integer mode
+}
+
+var _STR_ENC_URI_THIS_FIRST_V = []byte("encodeURIComponent(this.firstChild.value)") // "encodeURIComponent(this.firstChild.value)"
+
+// NewNumberBox creates a new NumberBox in integer mode.
+func NewNumberBox(value float64) NumberBox {
+	c := &numberBoxImpl{compImpl: newInputCompImpl(_STR_ENC_URI_THIS_FIRST_V), hasEnabledImpl: newHasEnabledImpl(),
+		min: math.Inf(-1), max: math.Inf(1), step: 1, integer: true}
+	c.SetValue(value)
+	c.AddSyncOnETypes(ETYPE_CHANGE)
+	c.Style().AddClass("gwu-NumberBox")
+	return c
+}
+
+func (c *numberBoxImpl) Value() float64 {
+	return c.value
+}
+
+func (c *numberBoxImpl) IntValue() int {
+	return int(math.Round(c.value))
+}
+
+func (c *numberBoxImpl) SetValue(value float64) {
+	c.value = c.normalize(value)
+	c.valueChanged()
+}
+
+// normalize clamps the specified value to the bounds,
+// and rounds it in integer mode.
+func (c *numberBoxImpl) normalize(value float64) float64 {
+	if c.integer {
+		value = math.Round(value)
+	}
+	if value < c.min {
+		value = c.min
+	} else if value > c.max {
+		value = c.max
+	}
+	return value
+}
+
+func (c *numberBoxImpl) Min() float64 {
+	return c.min
+}
+
+func (c *numberBoxImpl) SetMin(min float64) {
+	c.min = min
+}
+
+func (c *numberBoxImpl) Max() float64 {
+	return c.max
+}
+
+func (c *numberBoxImpl) SetMax(max float64) {
+	c.max = max
+}
+
+func (c *numberBoxImpl) Step() float64 {
+	return c.step
+}
+
+func (c *numberBoxImpl) SetStep(step float64) {
+	c.step = step
+}
+
+func (c *numberBoxImpl) Integer() bool {
+	return c.integer
+}
+
+func (c *numberBoxImpl) SetInteger(integer bool) {
+	c.integer = integer
+	if integer {
+		c.value = c.normalize(c.value)
+	}
+}
+
+func (c *numberBoxImpl) preprocessEvent(event Event, r *http.Request) {
+	value, text, ok := c.clientFloat(r)
+	if !ok {
+		// Reject it, restore the previous value in the browser
+		event.MarkDirty(c)
+		return
+	}
+
+	c.value = c.normalize(value)
+	if c.format(c.value) != text {
+		// Clamped or rounded, display the normalized value
+		event.MarkDirty(c)
+	}
+}
+
+// format formats the specified value according to the mode.
+func (c *numberBoxImpl) format(value float64) string {
+	if c.integer {
+		return strconv.FormatFloat(value, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// jsNum formats a number as a JavaScript number literal.
+func jsNum(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+var (
+	_STR_NB_INPUT_OP  = []byte(`<input type="text" inputmode="decimal" class="gwu-NumberBox-Input" value="`) // `<input type="text" inputmode="decimal" class="gwu-NumberBox-Input" value="`
+	_STR_NB_INPUT_KEY = []byte(`" onkeydown="nbKey(event,`)                                                  // `" onkeydown="nbKey(event,`
+	_STR_NB_DOWN_OP   = []byte(`<button type="button" class="gwu-NumberBox-Down" onclick="nbStep(`)          // `<button type="button" class="gwu-NumberBox-Down" onclick="nbStep(`
+	_STR_NB_DOWN_CL   = []byte(">&minus;</button>")                                                          // ">&minus;</button>"
+	_STR_NB_UP_OP     = []byte(`<button type="button" class="gwu-NumberBox-Up" onclick="nbStep(`)            // `<button type="button" class="gwu-NumberBox-Up" onclick="nbStep(`
+	_STR_NB_UP_CL     = []byte(">+</button>")                                                                // ">+</button>"
+	_STR_NB_CL        = []byte(`)"`)                                                                         // `)"`
+)
+
+func (c *numberBoxImpl) Render(w writer) {
+	// To render: <span id="compId" class="gwu-NumberBox" onchange="..."><input ...><button ...>-</button><button ...>+</button></span>
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	// Stepper args: compId,min,max,step
+	args := strconv.Itoa(int(c.id)) + "," + jsNum(c.min) + "," + jsNum(c.max) + "," + jsNum(c.step)
+
+	// Input must be the first child (see the value provider)
+	w.Write(_STR_NB_INPUT_OP)
+	w.Writees(c.format(c.value))
+	w.Write(_STR_NB_INPUT_KEY)
+	w.Writes(args)
+	w.Write(_STR_NB_CL)
+	c.renderEnabled(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_NB_DOWN_OP)
+	w.Writess(args, ",-1")
+	w.Write(_STR_NB_CL)
+	c.renderEnabled(w)
+	w.Write(_STR_NB_DOWN_CL)
+
+	w.Write(_STR_NB_UP_OP)
+	w.Writess(args, ",1")
+	w.Write(_STR_NB_CL)
+	c.renderEnabled(w)
+	w.Write(_STR_NB_UP_CL)
+
+	w.Write(_STR_SPAN_CL)
+}
@@ -0,0 +1,314 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// PagedTable component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// RowProvider is a function which provides the rows of a PagedTable:
+// it returns the components of (at most) count rows starting at
+// row index from.
+type RowProvider func(from, count int) [][]Comp
+
+// Default page size of PagedTable.
+const DEFAULT_PAGE_SIZE = 50
+
+// PagedTable interface defines a table for large datasets: rows are fetched
+// by a RowProvider, and only the rows of the current page are rendered,
+// with pager controls to navigate between pages.
+//
+// Rows of the current page are fetched when the page or the row provider is
+// changed, or when Refresh() is called (e.g. after the data changed).
+// The rows are displayed in a Table (see Table()) below the optional header row.
+//
+// Components of frequently refreshed pages can be recycled by setting a
+// component pool (see SetCompPool()): the row provider obtains the components
+// from the pool, and the components of the previous page are released to
+// the pool when the rows are fetched again.
+//
+// You can register ETYPE_STATE_CHANGE event handlers which will be called
+// when the user navigates to another page.
+//
+// Default style classes: "gwu-PagedTable", "gwu-PagedTable-Pager", "gwu-PagedTable-Info"
+type PagedTable interface {
+	// PagedTable is a Container.
+	Container
+
+	// Table returns the table displaying the header and the rows of
+	// the current page, e.g. to format its cells.
+	Table() Table
+
+	// SetHeader sets the components of the header row.
+	SetHeader(comps ...Comp)
+
+	// RowProvider returns the row provider.
+	RowProvider() RowProvider
+
+	// SetRowProvider sets the row provider and the number of rows,
+	// and fetches the rows of the first page.
+	SetRowProvider(provider RowProvider, rowCount int)
+
+	// RowCount returns the number of rows.
+	RowCount() int
+
+	// SetRowCount sets the number of rows, and fetches the rows
+	// of the current page.
+	SetRowCount(rowCount int)
+
+	// PageSize returns the page size (number of rows per page).
+	PageSize() int
+
+	// SetPageSize sets the page size (number of rows per page),
+	// and fetches the rows of the first page.
+	// Default is DEFAULT_PAGE_SIZE.
+	SetPageSize(pageSize int)
+
+	// Page returns the current page (0-based).
+	Page() int
+
+	// SetPage sets the current page (0-based), and fetches its rows.
+	SetPage(page int)
+
+	// PageCount returns the number of pages.
+	PageCount() int
+
+	// Refresh fetches the rows of the current page again.
+	Refresh()
+
+	// CompPool returns the component pool the row components are released to.
+	CompPool() CompPool
+
+	// SetCompPool sets the component pool the row components are released to
+	// when the rows are fetched again (the header is not released).
+	// The row provider should obtain the row components from the same pool.
+	// Pass nil to not recycle row components. This is the default.
+	SetCompPool(pool CompPool)
+}
+
+// PagedTable implementation.
+type pagedTableImpl struct {
+	compImpl // Component implementation
+
+	table    Table       // Table displaying the rows of the current page
+	header   []Comp      // Components of the header row
+	provider RowProvider // Row provider
+	rowCount int         // Number of rows
+	pageSize int         // Number of rows per page
+	page     int         // Current page
+	rows     [][]Comp    // Components of the rows of the current page
+	pool     CompPool    // Pool to release row components to
+}
+
+// NewPagedTable creates a new PagedTable.
+func NewPagedTable(provider RowProvider, rowCount int) PagedTable {
+	c := &pagedTableImpl{compImpl: newCompImpl(nil), table: NewTable(), pageSize: DEFAULT_PAGE_SIZE}
+	c.table.setParent(c)
+	c.Style().AddClass("gwu-PagedTable")
+	c.SetRowProvider(provider, rowCount)
+	return c
+}
+
+// Remove is needed to be the parent of the table (Container).
+func (c *pagedTableImpl) Remove(c2 Comp) bool {
+	return false
+}
+
+func (c *pagedTableImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+	return c.table.ById(id)
+}
+
+// Clear clears the rows of the current page (not the header).
+func (c *pagedTableImpl) Clear() {
+	c.table.Clear()
+	for col, c2 := range c.header {
+		c.table.Add(c2, 0, col)
+	}
+}
+
+func (c *pagedTableImpl) Table() Table {
+	return c.table
+}
+
+func (c *pagedTableImpl) SetHeader(comps ...Comp) {
+	c.header = comps
+	c.Refresh()
+}
+
+func (c *pagedTableImpl) RowProvider() RowProvider {
+	return c.provider
+}
+
+func (c *pagedTableImpl) SetRowProvider(provider RowProvider, rowCount int) {
+	c.provider = provider
+	c.rowCount = rowCount
+	c.SetPage(0)
+}
+
+func (c *pagedTableImpl) RowCount() int {
+	return c.rowCount
+}
+
+func (c *pagedTableImpl) SetRowCount(rowCount int) {
+	c.rowCount = rowCount
+	c.SetPage(c.page)
+}
+
+func (c *pagedTableImpl) PageSize() int {
+	return c.pageSize
+}
+
+func (c *pagedTableImpl) SetPageSize(pageSize int) {
+	if pageSize < 1 {
+		pageSize = 1
+	}
+	c.pageSize = pageSize
+	c.SetPage(0)
+}
+
+func (c *pagedTableImpl) Page() int {
+	return c.page
+}
+
+func (c *pagedTableImpl) SetPage(page int) {
+	if page >= c.PageCount() {
+		page = c.PageCount() - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	c.page = page
+	c.Refresh()
+}
+
+func (c *pagedTableImpl) PageCount() int {
+	return (c.rowCount + c.pageSize - 1) / c.pageSize
+}
+
+func (c *pagedTableImpl) Refresh() {
+	c.Clear()
+	if c.pool != nil {
+		for _, comps := range c.rows {
+			c.pool.Put(comps...)
+		}
+	}
+	c.rows = nil
+	if c.provider == nil || c.rowCount == 0 {
+		return
+	}
+
+	from := c.page * c.pageSize
+	count := c.pageSize
+	if from+count > c.rowCount {
+		count = c.rowCount - from
+	}
+
+	row := 0
+	if len(c.header) > 0 {
+		row = 1
+	}
+	c.rows = c.provider(from, count)
+	for _, comps := range c.rows {
+		for col, c2 := range comps {
+			if c2 != nil {
+				c.table.Add(c2, row, col)
+			}
+		}
+		row++
+	}
+}
+
+func (c *pagedTableImpl) CompPool() CompPool {
+	return c.pool
+}
+
+func (c *pagedTableImpl) SetCompPool(pool CompPool) {
+	c.pool = pool
+}
+
+func (c *pagedTableImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_STATE_CHANGE {
+		return
+	}
+	page, err := strconv.Atoi(r.FormValue(_PARAM_COMP_VALUE))
+	if err != nil {
+		return
+	}
+	c.SetPage(page)
+	event.MarkDirty(c)
+}
+
+var (
+	_STR_PT_PAGER_OP  = []byte(`<div class="gwu-PagedTable-Pager">`)      // `<div class="gwu-PagedTable-Pager">`
+	_STR_PT_BUTTON_OP = []byte(`<button type="button" onclick="se(null,`) // `<button type="button" onclick="se(null,`
+	_STR_PT_BUTTON_CL = []byte(`)"`)                                      // `)"`
+	_STR_PT_INFO_OP   = []byte(`<span class="gwu-PagedTable-Info">`)      // `<span class="gwu-PagedTable-Info">`
+)
+
+func (c *pagedTableImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	c.table.Render(w)
+
+	if pages := c.PageCount(); pages > 1 {
+		w.Write(_STR_PT_PAGER_OP)
+		c.renderButton(w, "&laquo;", 0, c.page > 0)
+		c.renderButton(w, "&lsaquo;", c.page-1, c.page > 0)
+
+		// To render: <span class="gwu-PagedTable-Info">Page x of y (rows a-b of n)</span>
+		from := c.page*c.pageSize + 1
+		to := from + c.pageSize - 1
+		if to > c.rowCount {
+			to = c.rowCount
+		}
+		w.Write(_STR_PT_INFO_OP)
+		w.Writevs("Page ", c.page+1, " of ", pages, " (rows ", from, "-", to, " of ", c.rowCount, ")")
+		w.Write(_STR_SPAN_CL)
+
+		c.renderButton(w, "&rsaquo;", c.page+1, c.page < pages-1)
+		c.renderButton(w, "&raquo;", pages-1, c.page < pages-1)
+		w.Write(_STR_DIV_CL)
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderButton renders a pager button which navigates to the specified page.
+func (c *pagedTableImpl) renderButton(w writer, text string, page int, enabled bool) {
+	// To render: <button type="button" onclick="se(null,etype,compId,page)">text</button>
+	w.Write(_STR_PT_BUTTON_OP)
+	w.Writevs(int(ETYPE_STATE_CHANGE), _STR_COMMA, int(c.id), _STR_COMMA, page)
+	w.Write(_STR_PT_BUTTON_CL)
+	if !enabled {
+		w.Write(_STR_DISABLED)
+	}
+	w.Write(_STR_GT)
+	w.Writes(text)
+	w.Write(_STR_BUTTON_CL)
+}
+
+func (c *pagedTableImpl) usage() ([]Comp, int64) {
+	return usageComps(c.table), 0
+}
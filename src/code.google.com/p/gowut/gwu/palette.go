@@ -0,0 +1,178 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// CommandPalette component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+)
+
+// CommandPalette interface defines a keyboard-driven command palette,
+// a navigation aid for large applications: a popup opened by the Ctrl+K
+// (Cmd+K on Mac) shortcut, listing the named actions registered by the
+// application. The list is filtered as the user types (entries containing
+// all the typed words are listed); the selected entry (navigated by the
+// up and down keys) is invoked by Enter or by clicking on it, which calls
+// the handler of the action.
+//
+// Command palettes don't have a visual part until they are opened. A command
+// palette has to be added to the window (or to a container of the window)
+// like other components. Only one command palette is active in a window
+// (the last rendered one).
+//
+// Default style classes: "gwu-CommandPalette", "gwu-CommandPalette-Open", "gwu-CommandPalette-Box",
+// "gwu-CommandPalette-Input", "gwu-CommandPalette-List", "gwu-CommandPalette-Item",
+// "gwu-CommandPalette-Hint", "gwu-CommandPalette-Selected"
+type CommandPalette interface {
+	// CommandPalette is a component.
+	Comp
+
+	// AddAction adds a named action to the palette, or replaces the
+	// action having the same name. hint is displayed next to the name
+	// (e.g. the location of the action or its keyboard shortcut),
+	// and is also matched when filtering; it may be empty.
+	// The handler is called when the user invokes the action.
+	AddAction(name, hint string, handler func(e Event))
+
+	// RemoveAction removes the action having the specified name.
+	// Returns false if there is no such action.
+	RemoveAction(name string) bool
+
+	// Actions returns the names of the actions in the order they were added.
+	Actions() []string
+
+	// Placeholder returns the placeholder text of the filter input.
+	Placeholder() string
+
+	// SetPlaceholder sets the placeholder text of the filter input.
+	// The default is "Type a command...".
+	SetPlaceholder(placeholder string)
+}
+
+// An action of a CommandPalette.
+type paletteAction struct {
+	name, hint string        // Name and hint of the action
+	handler    func(e Event) // Handler of the action
+}
+
+// CommandPalette implementation.
+type commandPaletteImpl struct {
+	compImpl // Component implementation
+
+	actions     []*paletteAction // Actions of the palette
+	placeholder string           // Placeholder text of the filter input
+	invoked     string           // Name of the action invoked by the user
+}
+
+// NewCommandPalette creates a new CommandPalette.
+func NewCommandPalette() CommandPalette {
+	c := &commandPaletteImpl{compImpl: newCompImpl(nil), placeholder: "Type a command..."}
+	c.Style().AddClass("gwu-CommandPalette")
+	c.AddEHandlerFunc(func(e Event) {
+		if a := c.action(c.invoked); a != nil && a.handler != nil {
+			a.handler(e)
+		}
+	}, ETYPE_STATE_CHANGE)
+	return c
+}
+
+// action returns the action having the specified name, nil if there is no such action.
+func (c *commandPaletteImpl) action(name string) *paletteAction {
+	for _, a := range c.actions {
+		if a.name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+func (c *commandPaletteImpl) AddAction(name, hint string, handler func(e Event)) {
+	if a := c.action(name); a != nil {
+		a.hint, a.handler = hint, handler
+		return
+	}
+	c.actions = append(c.actions, &paletteAction{name: name, hint: hint, handler: handler})
+}
+
+func (c *commandPaletteImpl) RemoveAction(name string) bool {
+	for i, a := range c.actions {
+		if a.name == name {
+			c.actions = append(c.actions[:i], c.actions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *commandPaletteImpl) Actions() []string {
+	names := make([]string, len(c.actions))
+	for i, a := range c.actions {
+		names[i] = a.name
+	}
+	return names
+}
+
+func (c *commandPaletteImpl) Placeholder() string {
+	return c.placeholder
+}
+
+func (c *commandPaletteImpl) SetPlaceholder(placeholder string) {
+	c.placeholder = placeholder
+}
+
+func (c *commandPaletteImpl) preprocessEvent(event Event, r *http.Request) {
+	c.invoked = r.FormValue(_PARAM_COMP_VALUE)
+}
+
+var (
+	_STR_CPAL_OP        = []byte(`<div class="gwu-CommandPalette-Box"><input type="text" class="gwu-CommandPalette-Input" placeholder="`) // `<div class="gwu-CommandPalette-Box"><input type="text" class="gwu-CommandPalette-Input" placeholder="`
+	_STR_CPAL_LIST      = []byte(`"><div class="gwu-CommandPalette-List">`)                                                               // `"><div class="gwu-CommandPalette-List">`
+	_STR_CPAL_ITEM_OP   = []byte(`<div class="gwu-CommandPalette-Item" data-name="`)                                                      // `<div class="gwu-CommandPalette-Item" data-name="`
+	_STR_CPAL_ITEM_CL   = []byte(`">`)                                                                                                    // `">`
+	_STR_CPAL_HINT_OP   = []byte(`<span class="gwu-CommandPalette-Hint">`)                                                                // `<span class="gwu-CommandPalette-Hint">`
+	_STR_CPAL_SCRIPT_OP = []byte(`</div></div><script>cpalInit(`)                                                                         // `</div></div><script>cpalInit(`
+)
+
+func (c *commandPaletteImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_CPAL_OP)
+	w.Writees(c.placeholder)
+	w.Write(_STR_CPAL_LIST)
+	for _, a := range c.actions {
+		w.Write(_STR_CPAL_ITEM_OP)
+		w.Writees(a.name)
+		w.Write(_STR_CPAL_ITEM_CL)
+		w.Writees(a.name)
+		if len(a.hint) > 0 {
+			w.Write(_STR_CPAL_HINT_OP)
+			w.Writees(a.hint)
+			w.Write(_STR_SPAN_CL)
+		}
+		w.Write(_STR_DIV_CL)
+	}
+	w.writeScript(_STR_CPAL_SCRIPT_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_STATE_CHANGE))
+	w.Write(_STR_SCRIPT_CL)
+
+	w.Write(_STR_DIV_CL)
+}
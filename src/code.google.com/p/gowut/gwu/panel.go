@@ -19,6 +19,7 @@ package gwu
 
 import (
 	"bytes"
+	"strconv"
 )
 
 // Layout strategy type.
@@ -26,16 +27,41 @@ type Layout int
 
 // Layout strategies.
 const (
-	LAYOUT_NATURAL    Layout = iota // Natural layout: elements are displayed in their natural order.
-	LAYOUT_VERTICAL                 // Vertical layout: elements are layed out vertically.
-	LAYOUT_HORIZONTAL               // Horizontal layout: elements are layed out horizontally.
+	LAYOUT_NATURAL     Layout = iota // Natural layout: elements are displayed in their natural order.
+	LAYOUT_VERTICAL                  // Vertical layout: elements are layed out vertically.
+	LAYOUT_HORIZONTAL                // Horizontal layout: elements are layed out horizontally.
+	LAYOUT_FLEX_ROW                  // Flex row layout: elements are layed out horizontally using CSS flexbox.
+	LAYOUT_FLEX_COLUMN               // Flex column layout: elements are layed out vertically using CSS flexbox.
+	LAYOUT_GRID                      // Grid layout: elements are layed out in a CSS grid.
 )
 
+// Names of the layout strategies.
+var layoutNames = []string{"natural", "vertical", "horizontal", "flex-row", "flex-column", "grid"}
+
+// String returns the name of the layout strategy, e.g. "vertical".
+func (l Layout) String() string {
+	if l.Valid() {
+		return layoutNames[l]
+	}
+	return strconv.Itoa(int(l))
+}
+
+// Valid tells if the layout strategy is one of the LAYOUT_ constants.
+func (l Layout) Valid() bool {
+	return l >= LAYOUT_NATURAL && l <= LAYOUT_GRID
+}
+
 // PanelView interface defines a container which stores child components
 // sequentially (one dimensional, associated with an index), and lays out
 // its children in a row or column using TableView based on a layout strategy,
 // but does not define the way how child components can be added.
 // 
+// With the flex layouts (LAYOUT_FLEX_ROW and LAYOUT_FLEX_COLUMN) the panel
+// is rendered using CSS flexbox instead of a table, the child components
+// are wrapped in div cells. See FlexPanel for flex settings.
+// Similarly with LAYOUT_GRID the panel is rendered using CSS grid,
+// see GridPanel for grid settings.
+// 
 // Default style class: "gwu-Panel"
 type PanelView interface {
 	// PanelView is a TableView.
@@ -82,18 +108,18 @@ type Panel interface {
 	Insert(c Comp, idx int) bool
 
 	// AddHSpace adds and returns a fixed-width horizontal space consumer.
-	// Useful when layout is LAYOUT_HORIZONTAL.
+	// Useful when layout is LAYOUT_HORIZONTAL or LAYOUT_FLEX_ROW.
 	AddHSpace(width int) Comp
 
 	// AddVSpace adds and returns a fixed-height vertical space consumer.
-	// Useful when layout is LAYOUT_VERTICAL.
+	// Useful when layout is LAYOUT_VERTICAL or LAYOUT_FLEX_COLUMN.
 	AddVSpace(height int) Comp
 
 	// AddSpace adds and returns a fixed-size space consumer.
 	AddSpace(width, height int) Comp
 
 	// AddHConsumer adds and returns a horizontal (free) space consumer.
-	// Useful when layout is LAYOUT_HORIZONTAL.
+	// Useful when layout is LAYOUT_HORIZONTAL or LAYOUT_FLEX_ROW.
 	// 
 	// Tip: When adding a horizontal space consumer, you may set the
 	// white space style attribute of other components in the the panel
@@ -101,7 +127,7 @@ type Panel interface {
 	AddHConsumer() Comp
 
 	// AddVConsumer adds and returns a vertical (free) space consumer.
-	// Useful when layout is LAYOUT_VERTICAL.
+	// Useful when layout is LAYOUT_VERTICAL or LAYOUT_FLEX_COLUMN.
 	AddVConsumer() Comp
 }
 
@@ -112,6 +138,8 @@ type panelImpl struct {
 	layout   Layout              // Layout strategy
 	comps    []Comp              // Components added to this panel
 	cellFmts map[ID]*cellFmtImpl // Lazily initialized cell formatters of the child components
+
+	flushChildren bool // Tells if output is to be flushed after rendering each child (for Window)
 }
 
 // NewPanel creates a new Panel.
@@ -210,12 +238,43 @@ func (c *panelImpl) Clear() {
 	c.comps = nil
 }
 
+// swapContent replaces the child components and their cell formatters,
+// and returns the old ones.
+func (c *panelImpl) swapContent(comps []Comp, cellFmts map[ID]*cellFmtImpl) ([]Comp, map[ID]*cellFmtImpl) {
+	oldComps, oldCellFmts := c.comps, c.cellFmts
+	for _, c2 := range oldComps {
+		c2.setParent(nil)
+	}
+
+	c.comps, c.cellFmts = comps, cellFmts
+	for _, c2 := range comps {
+		c2.setParent(c)
+	}
+	return oldComps, oldCellFmts
+}
+
 func (c *panelImpl) Layout() Layout {
 	return c.layout
 }
 
 func (c *panelImpl) SetLayout(layout Layout) {
 	c.layout = layout
+
+	// Flex and grid layouts are set up by style classes
+	c.Style().RemoveClass("gwu-Panel-FlexRow").RemoveClass("gwu-Panel-FlexColumn").RemoveClass("gwu-Panel-Grid")
+	switch layout {
+	case LAYOUT_FLEX_ROW:
+		c.Style().AddClass("gwu-Panel-FlexRow")
+	case LAYOUT_FLEX_COLUMN:
+		c.Style().AddClass("gwu-Panel-FlexColumn")
+	case LAYOUT_GRID:
+		c.Style().AddClass("gwu-Panel-Grid")
+	}
+}
+
+// flex tells if the layout of the panel is a flex layout.
+func (c *panelImpl) flex() bool {
+	return c.layout == LAYOUT_FLEX_ROW || c.layout == LAYOUT_FLEX_COLUMN
 }
 
 func (c *panelImpl) CompsCount() int {
@@ -302,14 +361,22 @@ func (c *panelImpl) AddSpace(width, height int) Comp {
 func (c *panelImpl) AddHConsumer() Comp {
 	l := NewLabel("")
 	c.Add(l)
-	c.CellFmt(l).Style().SetFullWidth()
+	if c.flex() {
+		c.CellFmt(l).Style().Set(ST_FLEX_GROW, strconv.Itoa(1))
+	} else {
+		c.CellFmt(l).Style().SetFullWidth()
+	}
 	return l
 }
 
 func (c *panelImpl) AddVConsumer() Comp {
 	l := NewLabel("")
 	c.Add(l)
-	c.CellFmt(l).Style().SetFullHeight()
+	if c.flex() {
+		c.CellFmt(l).Style().Set(ST_FLEX_GROW, strconv.Itoa(1))
+	} else {
+		c.CellFmt(l).Style().SetFullHeight()
+	}
 	return l
 }
 
@@ -321,6 +388,8 @@ func (c *panelImpl) Render(w writer) {
 		c.layoutHorizontal(w)
 	case LAYOUT_VERTICAL:
 		c.layoutVertical(w)
+	case LAYOUT_FLEX_ROW, LAYOUT_FLEX_COLUMN, LAYOUT_GRID:
+		c.layoutDivs(w)
 	}
 }
 
@@ -335,6 +404,9 @@ func (c *panelImpl) layoutNatural(w writer) {
 
 	for _, c2 := range c.comps {
 		c2.Render(w)
+		if c.flushChildren {
+			w.Flush()
+		}
 	}
 
 	w.Write(_STR_SPAN_CL)
@@ -353,6 +425,9 @@ func (c *panelImpl) layoutHorizontal(w writer) {
 	for _, c2 := range c.comps {
 		c.renderTd(c2, w)
 		c2.Render(w)
+		if c.flushChildren {
+			w.Flush()
+		}
 	}
 
 	w.Write(_STR_TABLE_CL)
@@ -375,11 +450,40 @@ func (c *panelImpl) layoutVertical(w writer) {
 		w.Write(tr)
 		c.renderTd(c2, w)
 		c2.Render(w)
+		if c.flushChildren {
+			w.Flush()
+		}
 	}
 
 	w.Write(_STR_TABLE_CL)
 }
 
+var _STR_DIV = []byte("<div>") // "<div>"
+
+// layoutDivs renders the panel and the child components wrapped in div cells
+// (using a flex or grid layout strategy).
+func (c *panelImpl) layoutDivs(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	for _, c2 := range c.comps {
+		if cf := c.cellFmts[c2.Id()]; cf == nil {
+			w.Write(_STR_DIV)
+		} else {
+			cf.render(_STR_DIV_OP, w)
+		}
+		c2.Render(w)
+		w.Write(_STR_DIV_CL)
+		if c.flushChildren {
+			w.Flush()
+		}
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
 // renderTd renders the formatted HTML TD tag for the specified child component.
 func (c *panelImpl) renderTd(c2 Comp, w writer) {
 	if cf := c.cellFmts[c2.Id()]; cf == nil {
@@ -0,0 +1,129 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Configurable internal endpoint paths and path signing.
+
+package gwu
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Paths defines the window-relative URL paths of the internal
+// endpoints the clients (browsers) send requests to.
+type Paths struct {
+	Event      string // Path for sending events
+	RenderComp string // Path for rendering a component
+	Push       string // Path for receiving pushes (long polling)
+	Ws         string // Path of the WebSocket channel
+	SessCookie string // Path for claiming the cookie of a session created over WebSocket
+	Upload     string // Path for uploading files
+	Download   string // Path for downloading files sent by event handlers
+	Resource   string // Path for fetching resources of components (e.g. the document of a PdfView)
+}
+
+// DefaultPaths returns the default internal endpoint paths.
+func DefaultPaths() Paths {
+	return Paths{Event: _PATH_EVENT, RenderComp: _PATH_RENDER_COMP, Push: _PATH_PUSH,
+		Ws: _PATH_WS, SessCookie: _PATH_SESS_COOKIE, Upload: _PATH_UPLOAD, Download: _PATH_DOWNLOAD,
+		Resource: _PATH_RESOURCE}
+}
+
+// RandomPaths returns random (unpredictable) internal endpoint paths.
+// Since the paths are generated at each call, clients have to reload
+// windows if paths generated by a previous server run were used.
+func RandomPaths() Paths {
+	return Paths{Event: genId(), RenderComp: genId(), Push: genId(),
+		Ws: genId(), SessCookie: genId(), Upload: genId(), Download: genId(), Resource: genId()}
+}
+
+// list returns the paths as a slice.
+func (p *Paths) list() []string {
+	return []string{p.Event, p.RenderComp, p.Push, p.Ws, p.SessCookie, p.Upload, p.Download, p.Resource}
+}
+
+// contains tells if the specified path is one of the paths.
+func (p *Paths) contains(path string) bool {
+	for _, path2 := range p.list() {
+		if path == path2 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *serverImpl) Paths() Paths {
+	return s.paths
+}
+
+func (s *serverImpl) SetPaths(paths Paths) error {
+	list := paths.list()
+	for i, path := range list {
+		if len(path) == 0 {
+			return errors.New("Paths cannot be empty!")
+		}
+		if strings.ContainsAny(path, "/?#") {
+			return errors.New("Invalid path: " + path)
+		}
+		for _, path2 := range list[:i] {
+			if path == path2 {
+				return errors.New("Paths must be unique: " + path)
+			}
+		}
+	}
+
+	s.paths = paths
+	return nil
+}
+
+func (s *serverImpl) PathSigning() bool {
+	return s.pathSigning
+}
+
+func (s *serverImpl) SetPathSigning(signing bool) {
+	s.pathSigning = signing
+}
+
+// newSigKey generates a new random key to sign paths with.
+func newSigKey() []byte {
+	key := make([]byte, 32)
+	io.ReadFull(rand.Reader, key)
+	return key
+}
+
+// pathSig returns the signature of internal endpoint requests
+// of the specified window in the specified session.
+func (s *serverImpl) pathSig(sess Session, winName string) string {
+	mac := hmac.New(sha256.New, s.sigKey)
+	io.WriteString(mac, sess.Id())
+	mac.Write([]byte{0})
+	io.WriteString(mac, winName)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// checkPathSig checks the signature of an internal endpoint request.
+// The signature is taken from the URL query (not from the body),
+// so it can be checked without parsing the body.
+func (s *serverImpl) checkPathSig(sess Session, winName string, r *http.Request) bool {
+	sig := r.URL.Query().Get(_PARAM_SIG)
+	return hmac.Equal([]byte(sig), []byte(s.pathSig(sess, winName)))
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathSigning(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	s.SetPathSigning(true)
+	s.AddWin(NewWindow("main", "Main"))
+
+	cases := []struct {
+		name string
+		sig  string
+		ok   bool
+	}{
+		{"missing signature", "", false},
+		{"invalid signature", "AAAA", false},
+		{"signature of another window", s.pathSig(&s.sessionImpl, "other"), false},
+		{"valid signature", s.pathSig(&s.sessionImpl, "main"), true},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		s.serveHTTP(w, httptest.NewRequest("POST", "/main/"+s.paths.Event+"?"+_PARAM_SIG+"="+c.sig, nil))
+		if forbidden := w.Code == http.StatusForbidden; forbidden == c.ok {
+			t.Errorf("%s: unexpected status: %d", c.name, w.Code)
+		}
+	}
+
+	// Windows themselves are not signed
+	w := httptest.NewRecorder()
+	s.serveHTTP(w, httptest.NewRequest("GET", "/main", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestPathSigKey(t *testing.T) {
+	s1, s2 := newServerImpl("", "", "", ""), newServerImpl("", "", "", "")
+	if s1.pathSig(&s1.sessionImpl, "main") == s2.pathSig(&s2.sessionImpl, "main") {
+		t.Error("Expected signatures to depend on the key of the server")
+	}
+}
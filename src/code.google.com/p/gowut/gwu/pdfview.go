@@ -0,0 +1,273 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// PdfView component interface and implementation.
+
+package gwu
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Zoom limits of PdfView (in percent).
+const (
+	_PDF_ZOOM_MIN  = 25  // Min zoom
+	_PDF_ZOOM_MAX  = 400 // Max zoom
+	_PDF_ZOOM_STEP = 25  // Zoom step of the zoom controls
+)
+
+// PdfView interface defines a component which displays a PDF document
+// with page navigation and zoom controls, e.g. for report previews.
+//
+// The document is displayed by the PDF viewer of the browser,
+// and is fetched by the browser through the resource path of the window
+// (see Paths.Resource).
+//
+// You can register ETYPE_STATE_CHANGE event handlers which will be called
+// when the user navigates to another page or changes the zoom.
+//
+// Default style classes: "gwu-PdfView", "gwu-PdfView-Controls", "gwu-PdfView-Page",
+// "gwu-PdfView-Zoom", "gwu-PdfView-Doc"
+type PdfView interface {
+	// PdfView is a component.
+	Comp
+
+	// SetPdf sets the PDF document to display, read from r
+	// (r is read until EOF). The page is reset to 1.
+	// The PdfView has to be marked dirty for the change to be visible.
+	SetPdf(r io.Reader) error
+
+	// FileName returns the file name of the document.
+	FileName() string
+
+	// SetFileName sets the file name of the document,
+	// used e.g. when the user saves it from the PDF viewer.
+	SetFileName(name string)
+
+	// PageCount returns the number of pages of the document.
+	// 0 is returned if the page count cannot be determined
+	// (in which case the page navigation is not limited).
+	PageCount() int
+
+	// Page returns the current page (1-based).
+	Page() int
+
+	// SetPage sets the current page (1-based).
+	SetPage(page int)
+
+	// Zoom returns the zoom in percent.
+	Zoom() int
+
+	// SetZoom sets the zoom in percent.
+	// Default is 100.
+	SetZoom(zoom int)
+}
+
+// PdfView implementation.
+type pdfViewImpl struct {
+	compImpl // Component implementation
+
+	pdf       []byte // The PDF document
+	version   int    // Version of the document, used to avoid serving cached documents
+	fileName  string // File name of the document
+	pageCount int    // Number of pages, 0 if unknown
+	page      int    // Current page
+	zoom      int    // Zoom in percent
+}
+
+// NewPdfView creates a new PdfView.
+// Use SetPdf() to set the document to display.
+func NewPdfView() PdfView {
+	c := &pdfViewImpl{compImpl: newCompImpl(nil), fileName: "document.pdf", page: 1, zoom: 100}
+	c.Style().AddClass("gwu-PdfView")
+	return c
+}
+
+func (c *pdfViewImpl) SetPdf(r io.Reader) error {
+	pdf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	c.pdf = pdf
+	c.version++
+	c.pageCount = pdfPageCount(pdf)
+	c.page = 1
+	return nil
+}
+
+// Regexps used to determine the page count of PDF documents.
+var (
+	pdfCountRegexp = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+	pdfPageRegexp  = regexp.MustCompile(`/Type\s*/Page\b`)
+)
+
+// pdfPageCount determines the page count of a PDF document.
+// The /Count of the root page tree node is used (the largest one),
+// or the number of page objects if there is none.
+// Returns 0 if neither is found (e.g. they are in compressed object streams).
+func pdfPageCount(pdf []byte) int {
+	count := 0
+	for _, m := range pdfCountRegexp.FindAllSubmatch(pdf, -1) {
+		n, _ := strconv.Atoi(string(m[1]) + string(m[2]))
+		if n > count {
+			count = n
+		}
+	}
+	if count == 0 {
+		count = len(pdfPageRegexp.FindAllIndex(pdf, -1))
+	}
+	return count
+}
+
+func (c *pdfViewImpl) FileName() string {
+	return c.fileName
+}
+
+func (c *pdfViewImpl) SetFileName(name string) {
+	c.fileName = name
+}
+
+func (c *pdfViewImpl) PageCount() int {
+	return c.pageCount
+}
+
+func (c *pdfViewImpl) Page() int {
+	return c.page
+}
+
+func (c *pdfViewImpl) SetPage(page int) {
+	if page < 1 {
+		page = 1
+	}
+	if c.pageCount > 0 && page > c.pageCount {
+		page = c.pageCount
+	}
+	c.page = page
+}
+
+func (c *pdfViewImpl) Zoom() int {
+	return c.zoom
+}
+
+func (c *pdfViewImpl) SetZoom(zoom int) {
+	if zoom < _PDF_ZOOM_MIN {
+		zoom = _PDF_ZOOM_MIN
+	}
+	if zoom > _PDF_ZOOM_MAX {
+		zoom = _PDF_ZOOM_MAX
+	}
+	c.zoom = zoom
+}
+
+func (c *pdfViewImpl) preprocessEvent(event Event, r *http.Request) {
+	// Page and zoom are sent in the form of: "page,zoom"
+	parts := strings.Split(r.FormValue(_PARAM_COMP_VALUE), ",")
+	if len(parts) != 2 {
+		return
+	}
+	page, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return
+	}
+	zoom, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return
+	}
+	c.SetPage(page)
+	c.SetZoom(zoom)
+	event.MarkDirty(c)
+}
+
+func (c *pdfViewImpl) serveResource(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": c.fileName}))
+	// Document URLs contain the version, so they can be cached
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	http.ServeContent(w, r, c.fileName, time.Time{}, bytes.NewReader(c.pdf))
+}
+
+var (
+	_STR_PV_CONTROLS_OP = []byte(`<div class="gwu-PdfView-Controls">`)                           // `<div class="gwu-PdfView-Controls">`
+	_STR_PV_BUTTON_OP   = []byte(`<button type="button" onclick="pvNav(`)                        // `<button type="button" onclick="pvNav(`
+	_STR_PV_BUTTON_CL   = []byte(`)"`)                                                           // `)"`
+	_STR_PV_PAGE_OP     = []byte(`<input type="text" class="gwu-PdfView-Page" size="3" value="`) // `<input type="text" class="gwu-PdfView-Page" size="3" value="`
+	_STR_PV_PAGE_ON     = []byte(`" onchange="pvNav(`)                                           // `" onchange="pvNav(`
+	_STR_PV_PAGE_VAL    = []byte(`,this.value-0,`)                                               // `,this.value-0,`
+	_STR_PV_PAGE_CL     = []byte(`)">`)                                                          // `)">`
+	_STR_PV_ZOOM_OP     = []byte(`<span class="gwu-PdfView-Zoom">`)                              // `<span class="gwu-PdfView-Zoom">`
+	_STR_PV_DOC_OP      = []byte(`<iframe class="gwu-PdfView-Doc"></iframe>`)                    // `<iframe class="gwu-PdfView-Doc"></iframe>`
+	_STR_PV_INIT_OP     = []byte("<script>pvInit(")                                              // "<script>pvInit("
+	_STR_PV_SCRIPT_CL   = []byte(");</script>")                                                  // ");</script>"
+)
+
+func (c *pdfViewImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_PV_CONTROLS_OP)
+	c.renderButton(w, "&#9664;", c.page-1, c.zoom, c.page > 1)
+	w.Write(_STR_PV_PAGE_OP)
+	w.Writev(c.page)
+	// To render: " onchange="pvNav(compId,etype,this.value-0,zoom)">
+	w.Write(_STR_PV_PAGE_ON)
+	w.Writevs(int(c.id), _STR_COMMA, int(ETYPE_STATE_CHANGE), _STR_PV_PAGE_VAL, c.zoom)
+	w.Write(_STR_PV_PAGE_CL)
+	if c.pageCount > 0 {
+		w.Writevs(" / ", c.pageCount)
+	}
+	c.renderButton(w, "&#9654;", c.page+1, c.zoom, c.pageCount == 0 || c.page < c.pageCount)
+	c.renderButton(w, "&minus;", c.page, c.zoom-_PDF_ZOOM_STEP, c.zoom > _PDF_ZOOM_MIN)
+	w.Write(_STR_PV_ZOOM_OP)
+	w.Writevs(c.zoom, "%")
+	w.Write(_STR_SPAN_CL)
+	c.renderButton(w, "+", c.page, c.zoom+_PDF_ZOOM_STEP, c.zoom < _PDF_ZOOM_MAX)
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_PV_DOC_OP)
+
+	if len(c.pdf) > 0 {
+		// To render: <script>pvInit(compId,version,page,zoom);</script>
+		w.writeScript(_STR_PV_INIT_OP)
+		w.Writevs(int(c.id), _STR_COMMA, c.version, _STR_COMMA, c.page, _STR_COMMA, c.zoom)
+		w.Write(_STR_PV_SCRIPT_CL)
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderButton renders a navigation or zoom button which navigates to the
+// specified page and zoom.
+func (c *pdfViewImpl) renderButton(w writer, text string, page, zoom int, enabled bool) {
+	// To render: <button type="button" onclick="pvNav(compId,etype,page,zoom)">text</button>
+	w.Write(_STR_PV_BUTTON_OP)
+	w.Writevs(int(c.id), _STR_COMMA, int(ETYPE_STATE_CHANGE), _STR_COMMA, page, _STR_COMMA, zoom)
+	w.Write(_STR_PV_BUTTON_CL)
+	if !enabled {
+		w.Write(_STR_DISABLED)
+	}
+	w.Write(_STR_GT)
+	w.Writes(text)
+	w.Write(_STR_BUTTON_CL)
+}
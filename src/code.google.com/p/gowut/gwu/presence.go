@@ -0,0 +1,287 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Presence: which authenticated users currently have live windows.
+
+package gwu
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time after which a window is considered gone if no request is received from it.
+// Windows with push enabled or with an open WebSocket connection send
+// requests (heartbeats) more often than this.
+const PRESENCE_TIMEOUT = 2 * _PUSH_POLL_TIMEOUT
+
+// PresenceInfo describes the presence of a user.
+type PresenceInfo struct {
+	User     string    // Name of the user
+	Sessions int       // Number of live sessions of the user
+	Windows  []string  // Names of the live windows of the user (sorted)
+	Since    time.Time // Time since the user is present
+}
+
+// Presence interface defines the presence subsystem which reports
+// which authenticated users (see Session.User()) currently have live windows.
+//
+// A window is live while it has an open push (long poll) request or WebSocket
+// connection, and for PRESENCE_TIMEOUT after its last request.
+// Enable push (Window.SetPushEnabled()) or the WebSocket channel for accurate presence.
+type Presence interface {
+	// Users returns the present users, sorted by name.
+	Users() []PresenceInfo
+
+	// Online tells if the specified user is present.
+	Online(user string) bool
+
+	// AddChangeHandler adds a handler which is called when the present users
+	// (or their windows) change. Handlers are called from various goroutines,
+	// they must not block.
+	AddChangeHandler(handler func(users []PresenceInfo))
+}
+
+// presenceKey identifies a window of a session.
+type presenceKey struct {
+	sess Session // The session
+	win  string  // Name of the window
+}
+
+// presenceEntry is the presence of a window of a session.
+type presenceEntry struct {
+	user     string    // Name of the user of the session
+	conns    int       // Number of open push requests and WebSocket connections
+	since    time.Time // Time of the first request
+	lastSeen time.Time // Time of the last request
+}
+
+// Presence implementation.
+type presenceImpl struct {
+	mutex    sync.Mutex                     // Mutex to synchronize access
+	entries  map[presenceKey]*presenceEntry // Live windows
+	state    string                         // State of the last reported presence
+	handlers []func(users []PresenceInfo)   // Change handlers
+	lists    map[*presenceListImpl]Session  // Presence lists to refresh, mapped to their sessions
+}
+
+// newPresenceImpl creates a new presenceImpl.
+func newPresenceImpl() *presenceImpl {
+	return &presenceImpl{entries: make(map[presenceKey]*presenceEntry), lists: make(map[*presenceListImpl]Session)}
+}
+
+func (p *presenceImpl) Users() []PresenceInfo {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.users()
+}
+
+// users returns the present users. Must be called with the mutex locked.
+func (p *presenceImpl) users() []PresenceInfo {
+	infos := make(map[string]*PresenceInfo)
+	sessions := make(map[string]map[Session]bool)
+	for key, entry := range p.entries {
+		info := infos[entry.user]
+		if info == nil {
+			info = &PresenceInfo{User: entry.user, Since: entry.since}
+			infos[entry.user] = info
+			sessions[entry.user] = make(map[Session]bool)
+		}
+		info.Windows = append(info.Windows, key.win)
+		if entry.since.Before(info.Since) {
+			info.Since = entry.since
+		}
+		sessions[entry.user][key.sess] = true
+	}
+
+	users := make([]PresenceInfo, 0, len(infos))
+	for user, info := range infos {
+		info.Sessions = len(sessions[user])
+		sort.Strings(info.Windows)
+		users = append(users, *info)
+	}
+	sort.Sort(presenceInfos(users))
+	return users
+}
+
+// presenceInfos implements sort.Interface to sort by user name.
+type presenceInfos []PresenceInfo
+
+func (p presenceInfos) Len() int           { return len(p) }
+func (p presenceInfos) Less(i, j int) bool { return p[i].User < p[j].User }
+func (p presenceInfos) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func (p *presenceImpl) Online(user string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, entry := range p.entries {
+		if entry.user == user {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *presenceImpl) AddChangeHandler(handler func(users []PresenceInfo)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.handlers = append(p.handlers, handler)
+}
+
+// touch records a request from a window of a session.
+// conns is the change of the number of open connections of the window
+// (+1 when a push request or WebSocket connection opens, -1 when it closes).
+// Only authenticated sessions are tracked.
+func (p *presenceImpl) touch(sess Session, win string, conns int) {
+	if !sess.Private() || sess.User() == "" {
+		return
+	}
+
+	p.mutex.Lock()
+	now := time.Now()
+	key := presenceKey{sess, win}
+	entry := p.entries[key]
+	if entry == nil {
+		entry = &presenceEntry{user: sess.User(), since: now}
+		p.entries[key] = entry
+	}
+	entry.user = sess.User()
+	entry.conns += conns
+	entry.lastSeen = now
+	p.changed()
+}
+
+// removeSess removes the windows and presence lists of a session.
+func (p *presenceImpl) removeSess(sess Session) {
+	p.mutex.Lock()
+	for key := range p.entries {
+		if key.sess == sess {
+			delete(p.entries, key)
+		}
+	}
+	for list, listSess := range p.lists {
+		if listSess == sess {
+			delete(p.lists, list)
+		}
+	}
+	p.changed()
+}
+
+// expire removes the windows not seen for PRESENCE_TIMEOUT.
+func (p *presenceImpl) expire(now time.Time) {
+	p.mutex.Lock()
+	for key, entry := range p.entries {
+		if entry.conns <= 0 && now.Sub(entry.lastSeen) > PRESENCE_TIMEOUT {
+			delete(p.entries, key)
+		}
+	}
+	p.changed()
+}
+
+// changed reports the presence if it has changed since last reported.
+// Must be called with the mutex locked, unlocks the mutex.
+func (p *presenceImpl) changed() {
+	users := p.users()
+	parts := make([]string, len(users))
+	for i, info := range users {
+		parts[i] = info.User + ":" + strconv.Itoa(info.Sessions) + ":" + strings.Join(info.Windows, ",")
+	}
+	state := strings.Join(parts, ";")
+	if state == p.state {
+		p.mutex.Unlock()
+		return
+	}
+	p.state = state
+
+	handlers := make([]func(users []PresenceInfo), len(p.handlers))
+	copy(handlers, p.handlers)
+	lists := make(map[*presenceListImpl]Session, len(p.lists))
+	for list, sess := range p.lists {
+		lists[list] = sess
+	}
+	p.mutex.Unlock()
+
+	for _, handler := range handlers {
+		handler(users)
+	}
+	for list, sess := range lists {
+		list := list
+		// The session might be locked by the caller, do not wait for it
+		go sess.Push(func(e Event) {
+			e.MarkDirty(list)
+		})
+	}
+}
+
+// PresenceList interface defines a component which displays
+// the present users (see Presence), and refreshes itself when they change.
+//
+// Default style classes: "gwu-PresenceList", "gwu-PresenceList-User"
+type PresenceList interface {
+	// PresenceList is a component.
+	Comp
+}
+
+// PresenceList implementation.
+type presenceListImpl struct {
+	compImpl // Component implementation
+
+	presence Presence // Presence to display
+}
+
+// NewPresenceList creates a new PresenceList which displays the present users
+// of the specified presence (see Server.Presence()), to be added to a window
+// of the specified session.
+func NewPresenceList(presence Presence, sess Session) PresenceList {
+	c := &presenceListImpl{compImpl: newCompImpl(nil), presence: presence}
+	c.Style().AddClass("gwu-PresenceList")
+	if p, isImpl := presence.(*presenceImpl); isImpl {
+		p.mutex.Lock()
+		p.lists[c] = sess
+		p.mutex.Unlock()
+	}
+	return c
+}
+
+var (
+	_STR_UL_OP            = []byte("<ul")                                       // "<ul"
+	_STR_UL_CL            = []byte("</ul>")                                     // "</ul>"
+	_STR_PRESENCE_USER_OP = []byte(`<li class="gwu-PresenceList-User" title="`) // `<li class="gwu-PresenceList-User" title="`
+	_STR_LI_CL            = []byte("</li>")                                     // "</li>"
+)
+
+func (c *presenceListImpl) Render(w writer) {
+	w.Write(_STR_UL_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	for _, info := range c.presence.Users() {
+		w.Write(_STR_PRESENCE_USER_OP)
+		w.Writees(strings.Join(info.Windows, ", "))
+		w.Write(_STR_QUOTE)
+		w.Write(_STR_GT)
+		w.Writees(info.User)
+		w.Write(_STR_LI_CL)
+	}
+
+	w.Write(_STR_UL_CL)
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// ProgressBar component interface and implementation.
+
+package gwu
+
+import (
+	"strconv"
+)
+
+// ProgressBar interface defines a component which displays the progress
+// of an operation as a horizontal bar, either in percent or
+// in indeterminate mode (when the progress is not known).
+//
+// Long-running operations started from event handlers can update the
+// progress from a goroutine using Session.Push(), for example:
+//
+//	pb := gwu.NewProgressBar()
+//	go func() {
+//		for i := 1; i <= 100; i++ {
+//			doSomeWork()
+//			e.Session().Push(func(e gwu.Event) {
+//				pb.SetValue(i)
+//				e.MarkDirty(pb)
+//			})
+//		}
+//	}()
+//
+// Push should be enabled on the window for the progress to be displayed
+// right away (see Window.SetPushEnabled()). Alternatively a Timer can be
+// used to refresh the progress periodically.
+//
+// Default style classes: "gwu-ProgressBar", "gwu-ProgressBar-Bar", "gwu-ProgressBar-Text",
+// "gwu-ProgressBar-Indeterminate"
+type ProgressBar interface {
+	// ProgressBar is a component.
+	Comp
+
+	// Value returns the progress in percent.
+	Value() int
+
+	// SetValue sets the progress in percent.
+	// Values outside of the range 0..100 are clamped.
+	SetValue(percent int)
+
+	// Indeterminate tells if the progress bar is in indeterminate mode.
+	Indeterminate() bool
+
+	// SetIndeterminate sets if the progress bar is in indeterminate mode,
+	// in which an animation is displayed instead of the progress value.
+	SetIndeterminate(indeterminate bool)
+}
+
+// ProgressBar implementation.
+type progressBarImpl struct {
+	compImpl // Component implementation
+
+	value         int  // Progress in percent
+	indeterminate bool // Tells if in indeterminate mode
+}
+
+// NewProgressBar creates a new ProgressBar.
+func NewProgressBar() ProgressBar {
+	c := &progressBarImpl{compImpl: newCompImpl(nil)}
+	c.Style().AddClass("gwu-ProgressBar")
+	return c
+}
+
+func (c *progressBarImpl) Value() int {
+	return c.value
+}
+
+func (c *progressBarImpl) SetValue(percent int) {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	c.value = percent
+}
+
+func (c *progressBarImpl) Indeterminate() bool {
+	return c.indeterminate
+}
+
+func (c *progressBarImpl) SetIndeterminate(indeterminate bool) {
+	c.indeterminate = indeterminate
+}
+
+var (
+	_STR_PB_BAR_OP  = []byte(`<div class="gwu-ProgressBar-Bar" style="width:`)    // `<div class="gwu-ProgressBar-Bar" style="width:`
+	_STR_PB_BAR_CL  = []byte(`%"></div>`)                                         // `%"></div>`
+	_STR_PB_INDET   = []byte(`<div class="gwu-ProgressBar-Indeterminate"></div>`) // `<div class="gwu-ProgressBar-Indeterminate"></div>`
+	_STR_PB_TEXT    = []byte(`<div class="gwu-ProgressBar-Text">`)                // `<div class="gwu-ProgressBar-Text">`
+	_STR_PB_PERCENT = []byte("%")                                                 // "%"
+)
+
+func (c *progressBarImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	if c.indeterminate {
+		w.Write(_STR_PB_INDET)
+	} else {
+		value := strconv.Itoa(c.value)
+		w.Write(_STR_PB_BAR_OP)
+		w.Writes(value)
+		w.Write(_STR_PB_BAR_CL)
+		w.Write(_STR_PB_TEXT)
+		w.Writes(value)
+		w.Write(_STR_PB_PERCENT)
+		w.Write(_STR_DIV_CL)
+	}
+
+	w.Write(_STR_DIV_CL)
+}
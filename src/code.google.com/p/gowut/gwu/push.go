@@ -0,0 +1,297 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Server push: delivering component changes made outside of event
+// handling (e.g. from background goroutines) to the clients.
+
+package gwu
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Max number of recent pushes retained by a push queue.
+// If a client misses more pushes than this, the window is reloaded.
+const _PUSH_QUEUE_SIZE = 64
+
+// Max time a push (long poll) request waits for a push.
+const _PUSH_POLL_TIMEOUT = 30 * time.Second
+
+// PollStrategy defines how the browser polls the server for pushes if the push
+// channel of a window is enabled but WebSockets are not used
+// (see Window.SetPollStrategy()).
+// The zero value is long polling without pausing.
+type PollStrategy struct {
+	// Interval is the time between polls. If 0, long polling is used:
+	// the server holds the poll request until there is a push
+	// (or it times out), and the next poll is sent right after.
+	Interval time.Duration
+
+	// MaxInterval is the max interval polling is backed off to while idle:
+	// the interval is doubled after each poll bringing no pushes (up to MaxInterval),
+	// and it is reset to Interval when a push arrives or on user activity
+	// (events of the window). A value not greater than Interval disables backoff.
+	// Only used if Interval > 0.
+	MaxInterval time.Duration
+
+	// PauseHidden tells if pushes are paused while the page is hidden
+	// (e.g. on a background tab), detected by the Page Visibility API:
+	// polling is paused, and components changed by pushes arriving otherwise
+	// (e.g. over WebSocket) are re-rendered when the page becomes visible again.
+	// When the page becomes visible again, it is polled right away.
+	PauseHidden bool
+}
+
+// pushEntry is an entry of a push queue.
+type pushEntry struct {
+	seq     int  // Sequence number of the push
+	compIds []ID // Ids of the components to be re-rendered
+	reload  bool // Tells if the window has to be reloaded
+}
+
+// pushQueue holds the recent pushes targeting a window.
+// Clients keep track of the sequence number of the last push they received,
+// and ask for pushes newer than that.
+type pushQueue struct {
+	mutex   sync.Mutex    // Mutex to synchronize queue access
+	seq     int           // Sequence number of the last push
+	entries []pushEntry   // Recent pushes
+	changed chan struct{} // Channel which is closed (and replaced) when a new push is added
+}
+
+// newPushQueue creates a new pushQueue.
+func newPushQueue() *pushQueue {
+	return &pushQueue{changed: make(chan struct{})}
+}
+
+// add adds a new push to the queue, and wakes up the waiting clients.
+func (q *pushQueue) add(compIds []ID, reload bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.seq++
+	if len(q.entries) == _PUSH_QUEUE_SIZE {
+		copy(q.entries, q.entries[1:])
+		q.entries = q.entries[:len(q.entries)-1]
+	}
+	q.entries = append(q.entries, pushEntry{seq: q.seq, compIds: compIds, reload: reload})
+
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// curSeq returns the sequence number of the last push.
+func (q *pushQueue) curSeq() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.seq
+}
+
+// since returns the pushes newer than the specified sequence number
+// merged into the set of dirty component ids, and the sequence number of
+// the last push. lost is true if some pushes are no longer retained, in which
+// case the window has to be reloaded.
+// If there are no newer pushes, changed is the channel to wait on.
+func (q *pushQueue) since(seq int) (compIds []ID, reload, lost bool, curSeq int, changed chan struct{}) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if seq == q.seq {
+		return nil, false, false, q.seq, q.changed
+	}
+
+	// seq > q.seq means the client has pushes from a previous server run
+	if seq > q.seq || len(q.entries) == 0 || q.entries[0].seq > seq+1 {
+		return nil, false, true, q.seq, nil
+	}
+
+	for _, entry := range q.entries {
+		if entry.seq <= seq {
+			continue
+		}
+		if entry.reload {
+			reload = true
+		}
+		compIds = append(compIds, entry.compIds...)
+	}
+	return compIds, reload, false, q.seq, nil
+}
+
+// compWin returns the window of the session the specified component is added to.
+// nil is returned if the component is not added to a window of the session.
+func (s *sessionImpl) compWin(c Comp) Window {
+	var root Comp = c
+	for parent := c.Parent(); parent != nil; parent = parent.Parent() {
+		root = parent
+	}
+
+	// Shared components are displayed in the window by the view of the session
+	if sh, isShared := root.(*sharedImpl); isShared {
+		view := sh.viewOf(s)
+		if view == nil {
+			return nil
+		}
+		root = view
+		for parent := view.Parent(); parent != nil; parent = parent.Parent() {
+			root = parent
+		}
+	}
+
+	// Components of dialogs are in the dialog layer of the window
+	if layer, isLayer := root.(*dialogLayer); isLayer {
+		root = layer.win
+	}
+
+	// Parent of a component added to a window is the embedded Panel,
+	// so compare ids.
+	for _, win := range s.windows {
+		if win.Id() == root.Id() {
+			return win
+		}
+	}
+	return nil
+}
+
+func (s *sessionImpl) Push(f func(e Event)) {
+	s.rwMutex_.Lock()
+	defer s.rwMutex_.Unlock()
+
+	e := newEventImpl(ETYPE_STATE_CHANGE, nil, s.server, s)
+	shared := e.shared
+	e.x, e.y, shared.wx, shared.wy, shared.mbtn = -1, -1, -1, -1, MOUSE_BTN_UNKNOWN
+
+	f(e)
+
+	s.pushChanges(shared)
+}
+
+// pushCollected receives values from ch until it is closed, and passes them
+// to f using sess.Push(). Values arriving within delay after the first one
+// are collected and passed together.
+func pushCollected(sess Session, ch <-chan string, delay time.Duration, f func(e Event, values []string)) {
+	for value := range ch {
+		values := []string{value}
+
+		// Collect values arriving shortly after to push them together:
+		timeout := time.After(delay)
+	collect:
+		for {
+			select {
+			case value, ok := <-ch:
+				if !ok {
+					break collect
+				}
+				values = append(values, value)
+			case <-timeout:
+				break collect
+			}
+		}
+
+		sess.Push(func(e Event) {
+			f(e, values)
+		})
+	}
+}
+
+// pushChanges pushes the changes (dirty components, window reloads)
+// recorded in the specified shared event data to the clients.
+func (s *sessionImpl) pushChanges(shared *sharedEvtData) {
+	// Group dirty components by windows:
+	shared.coalesceDirty()
+	winComps := make(map[Window][]ID)
+	for id, c := range shared.dirtyComps {
+		if win := s.compWin(c); win != nil {
+			winComps[win] = append(winComps[win], id)
+		}
+	}
+
+	if shared.reload {
+		if len(shared.reloadWin) > 0 {
+			if win := s.WinByName(shared.reloadWin); win != nil {
+				win.pushQueue().add(nil, true)
+				delete(winComps, win)
+			}
+		} else {
+			for _, win := range s.windows {
+				win.pushQueue().add(nil, true)
+				delete(winComps, win)
+			}
+		}
+	}
+
+	for win, ids := range winComps {
+		win.pushQueue().add(ids, false)
+	}
+}
+
+// writePushes writes the pushes newer than the specified sequence number
+// as event response actions, and returns if any action was written,
+// and the sequence number sent to the client.
+// hasAction tells if there are actions written already.
+// The window must be locked for reading.
+func writePushes(w writer, win Window, seq int, hasAction bool) (bool, int) {
+	compIds, reload, lost, curSeq, _ := win.pushQueue().since(seq)
+
+	if hasAction {
+		w.Write(_STR_SEMICOL)
+	}
+	w.Writevs(_ERA_PUSH_SEQ, _STR_COMMA, curSeq)
+
+	switch {
+	case reload, lost:
+		w.Write(_STR_SEMICOL)
+		w.Writev(_ERA_RELOAD_WIN)
+		w.Write(_STR_COMMA)
+	case len(compIds) > 0:
+		w.Write(_STR_SEMICOL)
+		w.Writev(_ERA_DIRTY_COMPS)
+		for _, id := range compIds {
+			w.Write(_STR_COMMA)
+			w.Writev(int(id))
+		}
+	}
+
+	return true, curSeq
+}
+
+// handlePush handles a push (long poll) request: waits until there are pushes
+// newer than the sequence number sent by the client, and sends them.
+func (s *serverImpl) handlePush(win Window, wr http.ResponseWriter, r *http.Request) {
+	seq, err := strconv.Atoi(r.FormValue(_PARAM_PUSH_SEQ))
+	if err != nil {
+		http.Error(wr, "Invalid push sequence!", http.StatusBadRequest)
+		return
+	}
+
+	if _, _, _, _, changed := win.pushQueue().since(seq); changed != nil && len(r.FormValue(_PARAM_PUSH_NOWAIT)) == 0 {
+		// Nothing to send yet, wait for a push (long polling):
+		select {
+		case <-changed:
+		case <-time.After(_PUSH_POLL_TIMEOUT):
+		case <-s.stopping:
+		}
+	}
+
+	wr.Header().Set("Content-Type", "text/plain; charset=utf-8") // We send it as text
+	if s.isStopping() {
+		s.writeShutdown(NewWriter(wr))
+		return
+	}
+	writePushes(NewWriter(wr), win, seq, false)
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Defines the RequestInfo type, a read-only view of HTTP requests.
+
+package gwu
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// RequestInfo interface defines a read-only view of the
+// HTTP request an event originates from.
+type RequestInfo interface {
+	// Header returns the first value of the specified request header.
+	// Empty string is returned if the header is not present.
+	Header(name string) string
+
+	// Headers returns (a copy of) the request headers.
+	Headers() http.Header
+
+	// RemoteAddr returns the (real) IP address of the client,
+	// taking trusted proxies into account (see Server.SetTrustedProxies()).
+	RemoteAddr() string
+
+	// Host returns the host the request was sent to.
+	Host() string
+
+	// UserAgent returns the User-Agent of the client.
+	UserAgent() string
+
+	// Lang returns the preferred language of the client
+	// (taken from the Accept-Language header).
+	// Empty string is returned if not known.
+	Lang() string
+
+	// TLS returns the TLS connection state.
+	// nil is returned if the request was not sent over HTTPS.
+	// The returned value must not be modified.
+	TLS() *tls.ConnectionState
+}
+
+// RequestInfo implementation.
+type requestInfoImpl struct {
+	r          *http.Request // The wrapped request
+	remoteAddr string        // Real IP address of the client
+}
+
+// newRequestInfoImpl creates a new requestInfoImpl.
+func newRequestInfoImpl(r *http.Request, remoteAddr string) *requestInfoImpl {
+	return &requestInfoImpl{r: r, remoteAddr: remoteAddr}
+}
+
+func (ri *requestInfoImpl) Header(name string) string {
+	return ri.r.Header.Get(name)
+}
+
+func (ri *requestInfoImpl) Headers() http.Header {
+	h := make(http.Header, len(ri.r.Header))
+	for name, values := range ri.r.Header {
+		h[name] = append([]string(nil), values...)
+	}
+	return h
+}
+
+func (ri *requestInfoImpl) RemoteAddr() string {
+	return ri.remoteAddr
+}
+
+func (ri *requestInfoImpl) Host() string {
+	return ri.r.Host
+}
+
+func (ri *requestInfoImpl) UserAgent() string {
+	return ri.r.UserAgent()
+}
+
+func (ri *requestInfoImpl) Lang() string {
+	return clientLang(ri.r)
+}
+
+func (ri *requestInfoImpl) TLS() *tls.ConnectionState {
+	return ri.r.TLS
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Component resources: content of components fetched by the browser
+// in separate requests (e.g. the document displayed by a PdfView).
+
+package gwu
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// resourceComp interface defines a component which has a resource
+// the browser fetches in a separate request.
+type resourceComp interface {
+	// serveResource serves the resource of the component.
+	// The session of the component is locked for reading.
+	serveResource(w http.ResponseWriter, r *http.Request)
+}
+
+// handleResource handles a resource request: serves the resource
+// of the component specified by the request.
+func (s *serverImpl) handleResource(win Window, w http.ResponseWriter, r *http.Request) {
+	id, err := AtoID(r.FormValue(_PARAM_COMP_ID))
+	if err != nil {
+		http.Error(w, "Invalid component id!", http.StatusBadRequest)
+		return
+	}
+
+	rc, isResourceComp := win.ById(id).(resourceComp)
+	if !isResourceComp {
+		http.Error(w, fmt.Sprint("Component resource not found: ", id), http.StatusNotFound)
+		return
+	}
+
+	rc.serveResource(w, r)
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Responsive layout support: breakpoints.
+
+package gwu
+
+import (
+	"strconv"
+)
+
+// Breakpoint type: a range of viewport (browser window) widths.
+type Breakpoint int
+
+// Breakpoints.
+const (
+	BP_PHONE   Breakpoint = iota // Phones: viewport narrower than BP_TABLET_WIDTH
+	BP_TABLET                    // Tablets: viewport at least BP_TABLET_WIDTH but narrower than BP_DESKTOP_WIDTH
+	BP_DESKTOP                   // Desktops: viewport at least BP_DESKTOP_WIDTH wide
+)
+
+// Viewport widths (in pixels) the breakpoints start at.
+const (
+	BP_TABLET_WIDTH  = 600  // Min width of BP_TABLET
+	BP_DESKTOP_WIDTH = 1024 // Min width of BP_DESKTOP
+)
+
+// bpNames are the names of the breakpoints used in style class names.
+var bpNames = [...]string{BP_PHONE: "Phone", BP_TABLET: "Tablet", BP_DESKTOP: "Desktop"}
+
+// mediaQuery returns the CSS media query of the breakpoint.
+func (bp Breakpoint) mediaQuery() string {
+	switch bp {
+	case BP_PHONE:
+		return "@media (max-width:" + strconv.Itoa(BP_TABLET_WIDTH-1) + "px)"
+	case BP_TABLET:
+		return "@media (min-width:" + strconv.Itoa(BP_TABLET_WIDTH) + "px) and (max-width:" + strconv.Itoa(BP_DESKTOP_WIDTH-1) + "px)"
+	}
+	return "@media (min-width:" + strconv.Itoa(BP_DESKTOP_WIDTH) + "px)"
+}
+
+// hiddenClass returns the style class which hides components at the breakpoint.
+func (bp Breakpoint) hiddenClass() string {
+	return "gwu-Hidden-" + bpNames[bp]
+}
+
+// layoutClass returns the style class which sets the specified flex layout at the breakpoint.
+func (bp Breakpoint) layoutClass(layout Layout) string {
+	if layout == LAYOUT_FLEX_COLUMN {
+		return "gwu-" + bpNames[bp] + "-FlexColumn"
+	}
+	return "gwu-" + bpNames[bp] + "-FlexRow"
+}
+
+// responsiveCss returns the media-query CSS rules of the breakpoint style classes.
+func responsiveCss() string {
+	css := ""
+	for bp := range bpNames {
+		bp := Breakpoint(bp)
+		css += bp.mediaQuery() + " {." + bp.hiddenClass() + " {display:none !important} ." +
+			bp.layoutClass(LAYOUT_FLEX_ROW) + " {flex-direction:row !important} ." +
+			bp.layoutClass(LAYOUT_FLEX_COLUMN) + " {flex-direction:column !important}}\n"
+	}
+	return css
+}
+
+func (c *compImpl) VisibleAt(bp Breakpoint) bool {
+	return !c.Style().HasClass(bp.hiddenClass())
+}
+
+func (c *compImpl) SetVisibleAt(bp Breakpoint, visible bool) {
+	if visible {
+		c.Style().RemoveClass(bp.hiddenClass())
+	} else {
+		c.Style().AddClass(bp.hiddenClass())
+	}
+}
+
+func (c *flexPanelImpl) LayoutAt(bp Breakpoint) Layout {
+	for _, layout := range []Layout{LAYOUT_FLEX_ROW, LAYOUT_FLEX_COLUMN} {
+		if c.Style().HasClass(bp.layoutClass(layout)) {
+			return layout
+		}
+	}
+	return c.layout
+}
+
+func (c *flexPanelImpl) SetLayoutAt(bp Breakpoint, layout Layout) {
+	c.Style().RemoveClass(bp.layoutClass(LAYOUT_FLEX_ROW)).RemoveClass(bp.layoutClass(LAYOUT_FLEX_COLUMN))
+	if layout == LAYOUT_FLEX_ROW || layout == LAYOUT_FLEX_COLUMN {
+		c.Style().AddClass(bp.layoutClass(layout))
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// RichTextBox component interface and implementation.
+
+package gwu
+
+import (
+	"bytes"
+	"html"
+	"net/http"
+	"strings"
+)
+
+// RichTextBox interface defines a WYSIWYG editor component for formatted text,
+// with a toolbar to make the text bold or italic, to create lists and links.
+//
+// The content is HTML which is synchronized with the server on ETYPE_CHANGE event,
+// fired when the editor loses focus after its content has been modified.
+// The content is sanitized on the server side (see SanitizeHTML()): content sent
+// by the client or set by SetHTML() only contains the formatting the editor supports,
+// so it is safe to display.
+//
+// Suggested event type to handle changes: ETYPE_CHANGE
+//
+// Default style classes: "gwu-RichTextBox", "gwu-RichTextBox-Toolbar",
+// "gwu-RichTextBox-Editor"
+type RichTextBox interface {
+	// RichTextBox is a component.
+	Comp
+
+	// RichTextBox can be enabled/disabled.
+	HasEnabled
+
+	// HTML returns the (sanitized) HTML content.
+	HTML() string
+
+	// SetHTML sets the HTML content.
+	// The content is sanitized, see SanitizeHTML().
+	SetHTML(html string)
+}
+
+// RichTextBox implementation.
+type richTextBoxImpl struct {
+	compImpl       // Component implementation
+	hasEnabledImpl // Has enabled implementation
+
+	html string // The sanitized HTML content
+}
+
+var _STR_ENC_URI_THIS_LAST_HTML = []byte("encodeURIComponent(this.lastChild.innerHTML)") // "encodeURIComponent(this.lastChild.innerHTML)"
+
+// NewRichTextBox creates a new RichTextBox.
+func NewRichTextBox(html string) RichTextBox {
+	c := &richTextBoxImpl{compImpl: newInputCompImpl(_STR_ENC_URI_THIS_LAST_HTML), hasEnabledImpl: newHasEnabledImpl()}
+	c.SetHTML(html)
+	c.AddSyncOnETypes(ETYPE_CHANGE)
+	c.Style().AddClass("gwu-RichTextBox")
+	return c
+}
+
+func (c *richTextBoxImpl) HTML() string {
+	return c.html
+}
+
+func (c *richTextBoxImpl) SetHTML(html string) {
+	c.html = SanitizeHTML(html)
+	c.valueChanged()
+}
+
+func (c *richTextBoxImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_CHANGE {
+		return
+	}
+
+	if !c.enabled {
+		// Reject it, restore the content in the browser
+		event.MarkDirty(c)
+		return
+	}
+
+	value, _, err := c.clientText(event, r, SANITIZE_ESCAPE_HTML) // HTML is sanitized by SanitizeHTML()
+	if err != nil {
+		// Rejected by a codec, restore the content in the browser
+		event.MarkDirty(c)
+		return
+	}
+	c.html = SanitizeHTML(value)
+	if c.html != value {
+		// Something was removed, display the sanitized content
+		event.MarkDirty(c)
+	}
+}
+
+// Toolbar commands: the command, the title and the content of the button.
+var rtbCommands = [...]struct{ cmd, title, content string }{
+	{"bold", "Bold", "<b>B</b>"},
+	{"italic", "Italic", "<i>I</i>"},
+	{"insertUnorderedList", "Bulleted list", "&bull;&#8801;"},
+	{"insertOrderedList", "Numbered list", "1.&#8801;"},
+	{"createLink", "Link", "&#128279;"},
+	{"unlink", "Remove link", "&#10060;"},
+}
+
+var (
+	_STR_RTB_TOOLBAR_OP = []byte(`<div class="gwu-RichTextBox-Toolbar">`)                                                                              // `<div class="gwu-RichTextBox-Toolbar">`
+	_STR_RTB_BUTTON_OP  = []byte(` title="`)                                                                                                           // ` title="`
+	_STR_RTB_BUTTON_CMD = []byte(`" onmousedown="return false" onclick="rtbCmd(`)                                                                      // `" onmousedown="return false" onclick="rtbCmd(`
+	_STR_RTB_BUTTON_CL  = []byte(`')"`)                                                                                                                // `')"`
+	_STR_RTB_EDITOR_OP  = []byte(`<div class="gwu-RichTextBox-Editor" onfocus="this.gwuHtml=this.innerHTML" onblur="rtbBlur(this)" contenteditable="`) // `<div class="gwu-RichTextBox-Editor" onfocus="this.gwuHtml=this.innerHTML" onblur="rtbBlur(this)" contenteditable="`
+	_STR_RTB_TRUE       = []byte(`true">`)                                                                                                             // `true">`
+	_STR_RTB_FALSE      = []byte(`false">`)                                                                                                            // `false">`
+)
+
+func (c *richTextBoxImpl) Render(w writer) {
+	// To render: <div id="compId" class="gwu-RichTextBox" onchange="..."><div class="gwu-RichTextBox-Toolbar">...</div><div class="gwu-RichTextBox-Editor" contenteditable="true">html</div></div>
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_RTB_TOOLBAR_OP)
+	for _, command := range rtbCommands {
+		w.Write(_STR_BUTTON_OP)
+		w.Write(_STR_RTB_BUTTON_OP)
+		w.Writes(command.title)
+		w.Write(_STR_RTB_BUTTON_CMD)
+		w.Writevs(int(c.id), ",'", command.cmd)
+		w.Write(_STR_RTB_BUTTON_CL)
+		c.renderEnabled(w)
+		w.Write(_STR_GT)
+		w.Writes(command.content)
+		w.Write(_STR_BUTTON_CL)
+	}
+	w.Write(_STR_DIV_CL)
+
+	// Editor must be the last child (see the value provider)
+	w.Write(_STR_RTB_EDITOR_OP)
+	if c.enabled && !c.inReadOnly() {
+		w.Write(_STR_RTB_TRUE)
+	} else {
+		w.Write(_STR_RTB_FALSE)
+	}
+	w.Writes(c.html)
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// Tags kept by SanitizeHTML().
+var sanitizeTags = map[string]bool{
+	"a": true, "b": true, "strong": true, "i": true, "em": true, "u": true, "s": true, "strike": true,
+	"p": true, "div": true, "br": true, "ul": true, "ol": true, "li": true, "blockquote": true,
+}
+
+// Tags which are removed by SanitizeHTML() along with their content.
+var sanitizeDropTags = map[string]bool{
+	"script": true, "style": true, "title": true, "textarea": true, "noscript": true,
+}
+
+// SanitizeHTML sanitizes the specified HTML content so it only contains
+// the formatting supported by RichTextBox, and it is safe to display.
+//
+// Only the following tags are kept (without attributes):
+//
+//	a, b, strong, i, em, u, s, strike, p, div, br, ul, ol, li, blockquote
+//
+// Links only keep their href attribute if it is a http, https or mailto URL
+// or a relative URL. Tags are balanced, comments are removed, and the content
+// of script and style tags is removed; the text content of other tags is kept.
+func SanitizeHTML(s string) string {
+	b := bytes.Buffer{}
+	var open []string // Open tags
+	drop := ""        // Tag whose content is being dropped
+
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			i = len(s)
+		}
+		if drop == "" {
+			b.WriteString(s[:i])
+		}
+		if s = s[i:]; len(s) == 0 {
+			break
+		}
+
+		if strings.HasPrefix(s, "<!--") {
+			if i = strings.Index(s, "-->"); i < 0 {
+				break
+			}
+			s = s[i+3:]
+			continue
+		}
+
+		name, href, closing, n := parseTag(s)
+		if n == 0 {
+			// Not a tag
+			if drop == "" {
+				b.WriteString("&lt;")
+			}
+			s = s[1:]
+			continue
+		}
+		s = s[n:]
+
+		switch {
+		case drop != "":
+			if closing && name == drop {
+				drop = ""
+			}
+		case sanitizeDropTags[name]:
+			if !closing {
+				drop = name
+			}
+		case !sanitizeTags[name]:
+		case closing:
+			// Close the tags opened after the matching open tag
+			for j := len(open) - 1; j >= 0; j-- {
+				if open[j] == name {
+					for k := len(open) - 1; k >= j; k-- {
+						b.WriteString("</" + open[k] + ">")
+					}
+					open = open[:j]
+					break
+				}
+			}
+		default:
+			b.WriteString("<" + name)
+			if name == "a" && href != "" && safeURL(href) {
+				b.WriteString(` href="` + html.EscapeString(href) + `"`)
+			}
+			b.WriteByte('>')
+			if name != "br" {
+				open = append(open, name)
+			}
+		}
+	}
+
+	for j := len(open) - 1; j >= 0; j-- {
+		b.WriteString("</" + open[j] + ">")
+	}
+
+	return b.String()
+}
+
+// parseTag parses the HTML tag at the beginning of s.
+// Returns the lower-cased tag name, the value of its href attribute,
+// whether it is a closing tag and the length of the tag;
+// n is 0 if s does not start with a (complete) tag.
+func parseTag(s string) (name, href string, closing bool, n int) {
+	i := 1
+	if i < len(s) && s[i] == '/' {
+		closing = true
+		i++
+	}
+	start := i
+	for i < len(s) && (isLetter(s[i]) || i > start && s[i] >= '0' && s[i] <= '9') {
+		i++
+	}
+	if i == start {
+		return "", "", false, 0
+	}
+	name = strings.ToLower(s[start:i])
+
+	// Attributes
+	for i < len(s) {
+		switch s[i] {
+		case '>':
+			return name, href, closing, i + 1
+		case ' ', '\t', '\n', '\r', '\f', '/':
+			i++
+			continue
+		}
+
+		start = i
+		for i < len(s) && !strings.ContainsRune(" \t\n\r\f/>=", rune(s[i])) {
+			i++
+		}
+		attr := strings.ToLower(s[start:i])
+		for i < len(s) && strings.ContainsRune(" \t\n\r\f", rune(s[i])) {
+			i++
+		}
+		if i >= len(s) || s[i] != '=' {
+			continue // Attribute without value
+		}
+		for i++; i < len(s) && strings.ContainsRune(" \t\n\r\f", rune(s[i])); i++ {
+		}
+		if i >= len(s) {
+			break
+		}
+
+		var value string
+		if q := s[i]; q == '"' || q == '\'' {
+			end := strings.IndexByte(s[i+1:], q)
+			if end < 0 {
+				break
+			}
+			value = s[i+1 : i+1+end]
+			i += end + 2
+		} else {
+			start = i
+			for i < len(s) && !strings.ContainsRune(" \t\n\r\f>", rune(s[i])) {
+				i++
+			}
+			value = s[start:i]
+		}
+		if attr == "href" {
+			href = html.UnescapeString(value)
+		}
+	}
+
+	return "", "", false, 0
+}
+
+// isLetter tells if the specified byte is an ASCII letter.
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// safeURL tells if the specified URL is safe to be used as a link:
+// if it is a http, https or mailto URL, or a relative URL.
+func safeURL(url string) bool {
+	// Browsers ignore whitespace and control characters in the scheme
+	u := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(url))
+
+	i := strings.IndexByte(u, ':')
+	if i < 0 || strings.IndexAny(u[:i], "/?#") >= 0 {
+		return true // Relative URL
+	}
+	switch u[:i] {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	cases := []struct{ in, exp string }{
+		{"plain text", "plain text"},
+		{"<b>bold</b> <i>italic</i>", "<b>bold</b> <i>italic</i>"},
+		{"<script>alert(1)</script>text", "text"},
+		{"<SCRIPT>alert(1)</SCRIPT>text", "text"},
+		{"<style>body{}</style>text", "text"},
+		{`<b onclick="alert(1)">x</b>`, "<b>x</b>"},
+		{`<img src=x onerror=alert(1)>`, ""},
+		{`<iframe src="http://evil"></iframe>x`, "x"},
+		{`<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href="JaVaScRiPt:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href="java&#x09;script:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href=" javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{`<a href="data:text/html,x">x</a>`, "<a>x</a>"},
+		{`<a href="http://example.com/?a=1&b=2">x</a>`, `<a href="http://example.com/?a=1&amp;b=2">x</a>`},
+		{`<a href='x" onclick="alert(1)'>x</a>`, `<a href="x&#34; onclick=&#34;alert(1)">x</a>`},
+		{"<b><i>unbalanced</b>", "<b><i>unbalanced</i></b>"},
+		{"<b>unclosed", "<b>unclosed</b>"},
+		{"a <!-- comment --> b", "a  b"},
+		{"1 < 2", "1 &lt; 2"},
+		{"<<script>script>alert(1)</script>", "&lt;"},
+		{"<scr<script>ipt>alert(1)</script>", "ipt>alert(1)"}, // No tag can be formed from the remains
+	}
+
+	for _, c := range cases {
+		if got := SanitizeHTML(c.in); got != c.exp {
+			t.Errorf("SanitizeHTML(%q): expected: %q, got: %q", c.in, c.exp, got)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	cases := []struct {
+		url string
+		exp bool
+	}{
+		{"http://example.com", true},
+		{"HTTPS://example.com", true},
+		{"mailto:bob@example.com", true},
+		{"relative/path", true},
+		{"/absolute/path?a=b:c", true},
+		{"#fragment:x", true},
+		{"javascript:alert(1)", false},
+		{"JAVASCRIPT:alert(1)", false},
+		{" javascript:alert(1)", false},
+		{"java\tscript:alert(1)", false},
+		{"java\x00script:alert(1)", false},
+		{"vbscript:msgbox(1)", false},
+		{"data:text/html;base64,PHNjcmlwdD4=", false},
+	}
+
+	for _, c := range cases {
+		if got := safeURL(c.url); got != c.exp {
+			t.Errorf("safeURL(%q): expected: %v, got: %v", c.url, c.exp, got)
+		}
+	}
+}
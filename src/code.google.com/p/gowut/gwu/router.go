@@ -0,0 +1,323 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Router component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ViewFactory is a function which creates the view (component)
+// of a route. The path is the route path being navigated to.
+// The event may be nil if the navigation does not happen
+// during event handling.
+type ViewFactory func(e Event, path string) Comp
+
+// Router interface defines a container which maps path-like keys (routes)
+// to view factories, and displays one view at a time: the view of the
+// current route. This allows building multi-"page" applications inside
+// a single Window without a full page reload on navigation.
+// 
+// A route path registered with a trailing slash (e.g. "users/") is a
+// prefix route: it matches all paths starting with it (e.g. "users/12")
+// if there is no exact match. The longest matching prefix route wins.
+// 
+// Views are created lazily when their path is first navigated to, and
+// they are cached per route, so navigating back to a path displays the same
+// view instance with its state. A prefix route caches only the view of the
+// path it was last navigated to, so the number of cached views
+// is bounded by the number of routes.
+// 
+// The router is integrated with the browser history: the current route
+// is reflected in the URL fragment (e.g. "/appname/main#users/12"),
+// so the browser Back and Forward buttons navigate between routes,
+// and routes can be bookmarked. Only one router per window should be
+// used for this reason.
+// 
+// You can register ETYPE_STATE_CHANGE event handlers which will be called
+// when the route is changed from the browser (e.g. the user pressed the
+// Back button or opened a bookmarked route). The event source will be the router.
+// 
+// Default style class: "gwu-Router"
+type Router interface {
+	// Router is a Container.
+	Container
+
+	// AddRoute registers a view factory for the specified route path.
+	// A path with a trailing slash registers a prefix route.
+	AddRoute(path string, factory ViewFactory)
+
+	// RemoveRoute removes the route registered for the specified path.
+	// Cached views created by the route are also discarded.
+	RemoveRoute(path string)
+
+	// DefaultPath returns the default route path.
+	DefaultPath() string
+
+	// SetDefaultPath sets the default route path, which is used
+	// when a path is navigated to that does not match any routes.
+	SetDefaultPath(path string)
+
+	// Route returns the current route path.
+	// Empty string is returned if no route has been navigated to yet.
+	Route() string
+
+	// View returns the view of the current route.
+	// Returns nil if no route has been navigated to yet.
+	View() Comp
+
+	// Navigate navigates to the specified route path, creating its view
+	// if it does not exist yet.
+	// e may be nil if not called during event handling (e.g. when building
+	// the window), else the router will be marked dirty.
+	// Returns false if neither the path nor the default path matches
+	// any routes, in which case the current route remains unchanged.
+	Navigate(path string, e Event) bool
+
+	// DiscardViews discards the cached views, except the view of the
+	// current route. Discarded views will be recreated by their factories
+	// when their path is navigated to again.
+	DiscardViews()
+}
+
+// Router implementation.
+type routerImpl struct {
+	compImpl // Component implementation
+
+	routes      map[string]ViewFactory // Registered view factories mapped from route path
+	views       map[string]Comp        // Cached views mapped from route path
+	viewPaths   map[string]string      // Paths the cached views were created for, mapped from route path
+	defaultPath string                 // Default route path
+	route       string                 // Current route path
+	view        Comp                   // View of the current route
+
+	newRoute string // Route path received from the client, to be navigated to
+}
+
+// NewRouter creates a new Router.
+func NewRouter() Router {
+	c := &routerImpl{compImpl: newCompImpl(nil), routes: make(map[string]ViewFactory), views: make(map[string]Comp),
+		viewPaths: make(map[string]string)}
+	c.Style().AddClass("gwu-Router")
+
+	// Navigate to routes changed in the browser:
+	c.AddEHandlerFunc(func(e Event) {
+		if c.newRoute == c.route {
+			return
+		}
+		c.Navigate(c.newRoute, e)
+	}, ETYPE_STATE_CHANGE)
+
+	return c
+}
+
+func (c *routerImpl) Remove(c2 Comp) bool {
+	for rpath, view := range c.views {
+		if view.Equals(c2) {
+			delete(c.views, rpath)
+			delete(c.viewPaths, rpath)
+			c2.setParent(nil)
+			if c.view != nil && c.view.Equals(c2) {
+				c.view = nil
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *routerImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	// Only the displayed view is searched, others are not part of the window.
+	if c.view != nil {
+		if c.view.Id() == id {
+			return c.view
+		}
+		if c2, isContainer := c.view.(Container); isContainer {
+			if c3 := c2.ById(id); c3 != nil {
+				return c3
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *routerImpl) Clear() {
+	for _, view := range c.views {
+		view.setParent(nil)
+	}
+	c.views = make(map[string]Comp)
+	c.viewPaths = make(map[string]string)
+	c.view = nil
+}
+
+func (c *routerImpl) AddRoute(path string, factory ViewFactory) {
+	c.routes[path] = factory
+}
+
+func (c *routerImpl) RemoveRoute(path string) {
+	delete(c.routes, path)
+
+	if view := c.views[path]; view != nil {
+		view.setParent(nil)
+		delete(c.views, path)
+		delete(c.viewPaths, path)
+		if c.view != nil && c.view.Equals(view) {
+			c.route = ""
+			c.view = nil
+		}
+	}
+}
+
+func (c *routerImpl) DefaultPath() string {
+	return c.defaultPath
+}
+
+func (c *routerImpl) SetDefaultPath(path string) {
+	c.defaultPath = path
+}
+
+func (c *routerImpl) Route() string {
+	return c.route
+}
+
+func (c *routerImpl) View() Comp {
+	return c.view
+}
+
+// match returns the registered route path matching the specified path.
+// Empty string is returned if no route matches the path.
+func (c *routerImpl) match(path string) string {
+	if _, found := c.routes[path]; found {
+		return path
+	}
+
+	match := ""
+	for rpath := range c.routes {
+		if strings.HasSuffix(rpath, "/") && strings.HasPrefix(path, rpath) && len(rpath) > len(match) {
+			match = rpath
+		}
+	}
+	return match
+}
+
+func (c *routerImpl) Navigate(path string, e Event) bool {
+	rpath := c.match(path)
+	if len(rpath) == 0 {
+		if path == c.defaultPath {
+			return false
+		}
+		path = c.defaultPath
+		if rpath = c.match(path); len(rpath) == 0 {
+			return false
+		}
+	}
+
+	view := c.views[rpath]
+	if view == nil || c.viewPaths[rpath] != path {
+		// A prefix route keeps only the view of its last path.
+		newView := c.routes[rpath](e, path)
+		if newView == nil {
+			return false
+		}
+		if view != nil {
+			view.setParent(nil)
+		}
+		view = newView
+		view.makeOrphan()
+		view.setParent(c)
+		c.views[rpath] = view
+		c.viewPaths[rpath] = path
+	}
+
+	c.route = path
+	c.view = view
+
+	if e != nil {
+		e.MarkDirty(c)
+	}
+
+	return true
+}
+
+func (c *routerImpl) DiscardViews() {
+	for rpath, view := range c.views {
+		if c.view == nil || !view.Equals(c.view) {
+			delete(c.views, rpath)
+			delete(c.viewPaths, rpath)
+			view.setParent(nil)
+		}
+	}
+}
+
+func (c *routerImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_STATE_CHANGE {
+		return
+	}
+
+	// Empty string is a valid route, so we have to check whether it is supplied.
+	// Form is surely parsed (FormValue() was called before).
+	r.FormValue(_PARAM_COMP_VALUE)
+	if values, present := r.Form[_PARAM_COMP_VALUE]; present && len(values) > 0 {
+		c.newRoute = values[0]
+	} else {
+		c.newRoute = c.route
+	}
+}
+
+var (
+	_STR_ROUTER_SYNC_OP = []byte("<script>rtSync(") // "<script>rtSync("
+	_STR_ROUTER_SYNC_CL = []byte(");</script>")     // ");</script>"
+)
+
+func (c *routerImpl) Render(w writer) {
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	if c.view != nil {
+		c.view.Render(w)
+	}
+
+	// Synchronize the current route with the browser history:
+	w.writeScript(_STR_ROUTER_SYNC_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_STATE_CHANGE))
+	w.Writess(",'", jsEscape(c.route), "'")
+	w.Write(_STR_ROUTER_SYNC_CL)
+
+	w.Write(_STR_SPAN_CL)
+}
+
+func (c *routerImpl) usage() ([]Comp, int64) {
+	// The view of the current route is a walked child
+	var comps []Comp
+	for _, view := range c.views {
+		if c.view == nil || !view.Equals(c.view) {
+			comps = append(comps, view)
+		}
+	}
+	return comps, 0
+}
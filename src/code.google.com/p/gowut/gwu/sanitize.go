@@ -0,0 +1,108 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Sanitation policy of text sent by the client.
+
+package gwu
+
+import (
+	"html"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sanitation is a sanitation policy of text sent by the client,
+// a combination (bitwise OR) of SANITIZE_XXX flags.
+// The sanitation policy of the server (see Server.SetSanitation()) is applied
+// to the values of text-bearing components (TextBox, PasswBox, ComboBox
+// and RichTextBox) before value codecs (see Comp.AddValueCodec()),
+// and to the values of edited Table cells.
+// Only SANITIZE_UTF8 is applied to the keystrokes of Terminal (keystrokes
+// are control sequences by nature).
+//
+// Warning: components (e.g. Label, TextBox) already HTML-escape their texts
+// when rendering, so if SANITIZE_ESCAPE_HTML is enabled, the texts are
+// double-escaped when displayed (e.g. "a<b" is displayed as "a&lt;b").
+// Only enable it if the values are used in raw HTML output elsewhere.
+type Sanitation int
+
+// Sanitation flags.
+const (
+	SANITIZE_UTF8          Sanitation = 1 << iota // Replace invalid UTF-8 sequences with the Unicode replacement character
+	SANITIZE_NEWLINES                             // Normalize newlines ("\r\n" and "\r") to "\n"
+	SANITIZE_STRIP_CONTROL                        // Remove control and invisible format characters except tabs and newlines
+	SANITIZE_ESCAPE_HTML                          // HTML-escape the text (not applied to PasswBox, RichTextBox and Terminal)
+
+	SANITIZE_NONE    Sanitation = 0                                                          // No sanitation
+	SANITIZE_DEFAULT            = SANITIZE_UTF8 | SANITIZE_NEWLINES | SANITIZE_STRIP_CONTROL // Default sanitation policy
+)
+
+// Apply applies the sanitation policy to the specified text.
+func (s Sanitation) Apply(text string) string {
+	if s&SANITIZE_UTF8 != 0 && !utf8.ValidString(text) {
+		text = strings.ToValidUTF8(text, string(utf8.RuneError))
+	}
+	if s&SANITIZE_NEWLINES != 0 && strings.IndexByte(text, '\r') >= 0 {
+		text = strings.Replace(text, "\r\n", "\n", -1)
+		text = strings.Replace(text, "\r", "\n", -1)
+	}
+	if s&SANITIZE_STRIP_CONTROL != 0 {
+		text, _ = CodecStripControl(text)
+	}
+	if s&SANITIZE_ESCAPE_HTML != 0 {
+		text = html.EscapeString(text)
+	}
+	return text
+}
+
+// SanitizeCodec returns a value codec which applies the specified
+// sanitation policy, e.g. to sanitize values of components other than
+// the text-bearing components.
+func SanitizeCodec(s Sanitation) ValueCodec {
+	return func(value string) (string, error) {
+		return s.Apply(value), nil
+	}
+}
+
+func (s *serverImpl) Sanitation() Sanitation {
+	return s.sanitation
+}
+
+func (s *serverImpl) SetSanitation(sanitation Sanitation) {
+	s.sanitation = sanitation
+}
+
+// eventSanitation returns the sanitation policy of the server the event
+// was received by.
+func eventSanitation(e Event) Sanitation {
+	if ei, ok := e.(*eventImpl); ok && ei.shared.server != nil {
+		return ei.shared.server.sanitation
+	}
+	return SANITIZE_NONE
+}
+
+// clientText returns the text value sent by the client with the event,
+// sanitized by the sanitation policy of the server (except the flags of mask)
+// and converted by the value codecs of the component.
+func (c *compImpl) clientText(e Event, r *http.Request, mask Sanitation) (value string, present bool, err error) {
+	value = r.FormValue(_PARAM_COMP_VALUE)
+	if _, present = r.Form[_PARAM_COMP_VALUE]; !present { // Form is surely parsed (we called FormValue())
+		return
+	}
+
+	value, err = c.convertValue((eventSanitation(e) &^ mask).Apply(value))
+	return
+}
@@ -0,0 +1,40 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"testing"
+)
+
+func TestSanitationApply(t *testing.T) {
+	cases := []struct {
+		s       Sanitation
+		in, exp string
+	}{
+		{SANITIZE_NONE, "a\r\nb\x00", "a\r\nb\x00"},
+		{SANITIZE_UTF8, "a\xffb", "a�b"},
+		{SANITIZE_NEWLINES, "a\r\nb\rc", "a\nb\nc"},
+		{SANITIZE_STRIP_CONTROL, "a\x00b\x1b\tc​", "ab\tc"},
+		{SANITIZE_ESCAPE_HTML, `<b>"x"</b>`, "&lt;b&gt;&#34;x&#34;&lt;/b&gt;"},
+		{SANITIZE_DEFAULT, "a\r\n\x07<b>", "a\n<b>"},
+	}
+
+	for _, c := range cases {
+		if got := c.s.Apply(c.in); got != c.exp {
+			t.Errorf("Sanitation(%d).Apply(%q): expected: %q, got: %q", c.s, c.in, c.exp, got)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Defines the Scanner component.
+
+package gwu
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Scanner interface defines a component which scans barcodes and QR codes
+// with the device camera. Decoding is done client-side, using the
+// Barcode Detection API of the browser.
+// 
+// The component renders a button. When clicked, the browser asks the user
+// for permission to use the camera, and a live preview is displayed while
+// scanning. Scanning stops when a code is detected (unless the scanner
+// is continuous), or when the button is clicked again.
+// 
+// When a code is detected, ETYPE_CHANGE is dispatched. In ETYPE_CHANGE handlers
+// the decoded string can be acquired by Code(), and its format by Format().
+// 
+// Suggested event type to handle actions: ETYPE_CHANGE
+// 
+// Default style classes: "gwu-Scanner", "gwu-Scanner-Button",
+// "gwu-Scanner-Preview"
+type Scanner interface {
+	// Scanner is a component.
+	Comp
+
+	// Scanner can be enabled/disabled.
+	HasEnabled
+
+	// Formats returns the barcode formats to scan.
+	Formats() []string
+
+	// SetFormats sets the barcode formats to scan, e.g. "qr_code", "ean_13", "code_128"
+	// (format names of the Barcode Detection API).
+	// Pass no formats to scan all formats supported by the browser. This is the default.
+	SetFormats(formats ...string)
+
+	// Continuous tells if the scanner is continuous.
+	Continuous() bool
+
+	// SetContinuous sets if the scanner is continuous.
+	// A continuous scanner keeps scanning after a code is detected
+	// until the button is clicked again; the same code is only
+	// reported once in a row.
+	// Scanners are not continuous by default.
+	SetContinuous(continuous bool)
+
+	// Texts returns the texts of the button.
+	Texts() (start, stop string)
+
+	// SetTexts sets the texts of the button: start is displayed
+	// when not scanning, stop is displayed while scanning.
+	SetTexts(start, stop string)
+
+	// Code returns the last detected code.
+	Code() string
+
+	// Format returns the format of the last detected code
+	// as reported by the browser, e.g. "qr_code".
+	Format() string
+}
+
+// Scanner implementation.
+type scannerImpl struct {
+	compImpl       // Component implementation
+	hasEnabledImpl // Has enabled implementation
+
+	formats    []string // Barcode formats to scan
+	continuous bool     // Tells if the scanner is continuous
+	startText  string   // Text of the button to start scanning
+	stopText   string   // Text of the button to stop scanning
+	code       string   // Last detected code
+	format     string   // Format of the last detected code
+
+	rejected bool // Tells if the received code was rejected
+}
+
+// NewScanner creates a new Scanner.
+func NewScanner() Scanner {
+	c := &scannerImpl{compImpl: newInputCompImpl(nil), hasEnabledImpl: newHasEnabledImpl(), startText: "Scan", stopText: "Stop"}
+	c.Style().AddClass("gwu-Scanner")
+	return c
+}
+
+func (c *scannerImpl) Formats() []string {
+	return c.formats
+}
+
+func (c *scannerImpl) SetFormats(formats ...string) {
+	c.formats = formats
+}
+
+func (c *scannerImpl) Continuous() bool {
+	return c.continuous
+}
+
+func (c *scannerImpl) SetContinuous(continuous bool) {
+	c.continuous = continuous
+}
+
+func (c *scannerImpl) Texts() (start, stop string) {
+	return c.startText, c.stopText
+}
+
+func (c *scannerImpl) SetTexts(start, stop string) {
+	c.startText, c.stopText = start, stop
+}
+
+func (c *scannerImpl) Code() string {
+	return c.code
+}
+
+func (c *scannerImpl) Format() string {
+	return c.format
+}
+
+func (c *scannerImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_CHANGE {
+		return
+	}
+
+	// Value format: "format,code" (already decoded by FormValue()).
+	// Formats never contain a comma, the code might.
+	value := r.FormValue(_PARAM_COMP_VALUE)
+	i := strings.IndexByte(value, ',')
+	if i < 0 {
+		c.rejected = true
+		return
+	}
+	c.format, c.code = value[:i], value[i+1:]
+}
+
+func (c *scannerImpl) dispatchEvent(e Event) {
+	if c.rejected {
+		c.rejected = false
+		return
+	}
+	c.compImpl.dispatchEvent(e)
+}
+
+var (
+	_STR_SC_BUTTON_OP = []byte(`<button type="button" class="gwu-Scanner-Button"`) // `<button type="button" class="gwu-Scanner-Button"`
+	_STR_SC_ONCLICK   = []byte(` onclick="scToggle(this,`)                         // ` onclick="scToggle(this,`
+	_STR_SC_FORMATS   = []byte(`,[`)                                               // `,[`
+	_STR_SC_TEXTS     = []byte(`],'`)                                              // `],'`
+)
+
+func (c *scannerImpl) Render(w writer) {
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	// To render: <button ... onclick="scToggle(this,compId,etype,continuous,['format',...],'start','stop')">start</button>
+	w.Write(_STR_SC_BUTTON_OP)
+	c.renderEnabled(w)
+	w.Write(_STR_SC_ONCLICK)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_CHANGE))
+	w.Write(_STR_COMMA)
+	w.Writev(c.continuous)
+	w.Write(_STR_SC_FORMATS)
+	for i, format := range c.formats {
+		if i > 0 {
+			w.Write(_STR_COMMA)
+		}
+		w.Writees("'" + jsEscape(format) + "'")
+	}
+	w.Write(_STR_SC_TEXTS)
+	w.Writees(jsEscape(c.startText))
+	w.Write(_STR_MC_TEXTS_SEP)
+	w.Writees(jsEscape(c.stopText))
+	w.Write(_STR_MC_BUTTON_CL)
+	w.Writees(c.startText)
+	w.Write(_STR_BUTTON_CL)
+
+	w.Write(_STR_SPAN_CL)
+}
@@ -0,0 +1,174 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Security headers (Content-Security-Policy and others) of responses.
+
+package gwu
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Placeholder of the nonce in Content-Security-Policy values (see SecurityHeaders.CSP).
+const CSP_NONCE = "{nonce}"
+
+// Default Content-Security-Policy: inline scripts are only allowed
+// with the nonce generated for each response.
+// Inline event handler attributes (rendered by gowut components)
+// and inline styles are allowed.
+const DEFAULT_CSP = "default-src 'self'; script-src 'nonce-" + CSP_NONCE + "' 'strict-dynamic'; script-src-attr 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; img-src 'self' data: blob:; media-src 'self' blob:; connect-src 'self'; " +
+	"object-src 'none'; base-uri 'self'; frame-ancestors 'self'"
+
+// SecurityHeaders is the configuration of the security headers
+// of the responses of a server (see Server.SetSecurityHeaders()).
+// Empty (zero) fields are not sent.
+type SecurityHeaders struct {
+	// Content-Security-Policy. The CSP_NONCE placeholder is replaced
+	// with a nonce generated for each response, which is added to
+	// the inline scripts rendered by gowut, so a strict CSP can be used,
+	// e.g. "script-src 'nonce-{nonce}' 'strict-dynamic'".
+	CSP string
+
+	// Tells if the CSP is sent as Content-Security-Policy-Report-Only
+	// (violations are only reported, not enforced).
+	CSPReportOnly bool
+
+	// X-Frame-Options, e.g. "DENY" or "SAMEORIGIN".
+	FrameOptions string
+
+	// Tells if "X-Content-Type-Options: nosniff" is sent.
+	NoSniff bool
+
+	// Referrer-Policy, e.g. "same-origin".
+	ReferrerPolicy string
+
+	// Max age of Strict-Transport-Security, only sent over HTTPS.
+	HSTS time.Duration
+
+	// Additional headers, mapped from their names.
+	Extra map[string]string
+}
+
+// DefaultSecurityHeaders returns the recommended security headers:
+// DEFAULT_CSP, SAMEORIGIN frame options, nosniff and same-origin
+// referrer policy.
+func DefaultSecurityHeaders() SecurityHeaders {
+	return SecurityHeaders{CSP: DEFAULT_CSP, FrameOptions: "SAMEORIGIN", NoSniff: true, ReferrerPolicy: "same-origin"}
+}
+
+func (s *serverImpl) SecurityHeaders() SecurityHeaders {
+	return s.securityHeaders
+}
+
+func (s *serverImpl) SetSecurityHeaders(cfg SecurityHeaders) {
+	s.securityHeaders = cfg
+}
+
+// setSecurityHeaders sets the security headers of the response,
+// and returns the nonce of the inline scripts of the response
+// (empty if the CSP does not contain the CSP_NONCE placeholder).
+// An error is returned if the nonce could not be generated.
+func (s *serverImpl) setSecurityHeaders(w http.ResponseWriter, r *http.Request) (nonce string, err error) {
+	cfg := &s.securityHeaders
+	h := w.Header()
+
+	if len(cfg.CSP) > 0 {
+		csp := cfg.CSP
+		if strings.Contains(csp, CSP_NONCE) {
+			if nonce, err = newNonce(); err != nil {
+				return
+			}
+			csp = strings.Replace(csp, CSP_NONCE, nonce, -1)
+		}
+		if cfg.CSPReportOnly {
+			h.Set("Content-Security-Policy-Report-Only", csp)
+		} else {
+			h.Set("Content-Security-Policy", csp)
+		}
+	}
+	if len(cfg.FrameOptions) > 0 {
+		h.Set("X-Frame-Options", cfg.FrameOptions)
+	}
+	if cfg.NoSniff {
+		h.Set("X-Content-Type-Options", "nosniff")
+	}
+	if len(cfg.ReferrerPolicy) > 0 {
+		h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+	}
+	if cfg.HSTS > 0 && (s.secure || r.TLS != nil) {
+		h.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(cfg.HSTS/time.Second)))
+	}
+	for name, value := range cfg.Extra {
+		h.Set(name, value)
+	}
+
+	return
+}
+
+// newNonce generates a new random nonce.
+// A nonce is never returned if the random source fails,
+// as it would be predictable.
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+var (
+	_STR_SCRIPT_TAG = []byte("<script>")        // "<script>"
+	_STR_SCRIPT_N   = []byte(`<script nonce="`) // `<script nonce="`
+)
+
+// writeScript writes the specified HTML text containing the opening tag
+// of an inline script ("<script>"), adding the nonce of the response
+// to the script tag if there is one.
+func (w writer) writeScript(text []byte) (n int, err error) {
+	i := bytes.Index(text, _STR_SCRIPT_TAG)
+	if len(w.nonce) == 0 || i < 0 {
+		return w.Write(text)
+	}
+
+	// To render: <script nonce="nonce">
+	n, err = w.Write(text[:i])
+	if err == nil {
+		var m int
+		m, err = w.Writevs(_STR_SCRIPT_N, w.nonce, _STR_QUOTE, _STR_GT)
+		n += m
+		if err == nil {
+			m, err = w.Write(text[i+len(_STR_SCRIPT_TAG):])
+			n += m
+		}
+	}
+	return
+}
+
+// addNonce adds the nonce of the response (if there is one)
+// to the script tags of the specified HTML text.
+func (w writer) addNonce(text string) string {
+	if len(w.nonce) == 0 {
+		return text
+	}
+	return strings.Replace(text, "<script", `<script nonce="`+w.nonce+`"`, -1)
+}
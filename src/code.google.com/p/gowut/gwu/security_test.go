@@ -0,0 +1,120 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteScript(t *testing.T) {
+	cases := []struct{ nonce, in, exp string }{
+		{"", "<script>f();</script>", "<script>f();</script>"},
+		{"abc", "<script>f();</script>", `<script nonce="abc">f();</script>`},
+		{"abc", "<span><script>f(", `<span><script nonce="abc">f(`},
+		{"abc", "no script", "no script"},
+	}
+
+	for _, c := range cases {
+		b := &bytes.Buffer{}
+		w := NewWriter(b)
+		w.nonce = c.nonce
+		n, err := w.writeScript([]byte(c.in))
+		if got := b.String(); got != c.exp || n != len(c.exp) || err != nil {
+			t.Errorf("writeScript(%q) with nonce %q: expected: %q, got: %q (n: %d, err: %v)", c.in, c.nonce, c.exp, got, n, err)
+		}
+	}
+}
+
+func TestAddNonce(t *testing.T) {
+	w := NewWriter(nil)
+	if got := w.addNonce("<script>a</script>"); got != "<script>a</script>" {
+		t.Errorf("Expected unchanged text without nonce, got: %q", got)
+	}
+	w.nonce = "abc"
+	exp := `<b>x</b><script nonce="abc">a</script><script nonce="abc" src="y"></script>`
+	if got := w.addNonce(`<b>x</b><script>a</script><script src="y"></script>`); got != exp {
+		t.Errorf("Expected: %q, got: %q", exp, got)
+	}
+}
+
+func TestNewNonce(t *testing.T) {
+	n1, err := newNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n2, _ := newNonce()
+	if len(n1) == 0 || n1 == n2 {
+		t.Errorf("Expected unique nonces, got: %q, %q", n1, n2)
+	}
+}
+
+func TestSecurityHeadersNonce(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	s.SetSecurityHeaders(DefaultSecurityHeaders())
+
+	nonce, err := s.setSecurityHeaders(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err != nil || len(nonce) == 0 {
+		t.Fatalf("Expected a nonce, got: %q, %v", nonce, err)
+	}
+
+	w := httptest.NewRecorder()
+	nonce, _ = s.setSecurityHeaders(w, httptest.NewRequest("GET", "/", nil))
+	csp := w.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "'nonce-"+nonce+"'") || strings.Contains(csp, CSP_NONCE) {
+		t.Errorf("Nonce not substituted in CSP: %q", csp)
+	}
+}
+
+func TestErrorBoundaryKeepsNonce(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	s.SetSecurityHeaders(DefaultSecurityHeaders())
+	win := NewWindow("main", "Main")
+	win.Add(NewErrorBoundary(NewRouter()))
+	s.AddWin(win)
+
+	w := httptest.NewRecorder()
+	s.serveHTTP(w, httptest.NewRequest("GET", "/main", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected: %d, got: %d", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "<script>") {
+		t.Errorf("Inline script without nonce rendered: %s", body)
+	}
+	if !strings.Contains(body, "rtSync(") {
+		t.Errorf("Script of the router inside the error boundary not rendered: %s", body)
+	}
+}
+
+func TestWriterSub(t *testing.T) {
+	sess := newSessionImpl(true)
+	w := newSessWriter(&bytes.Buffer{}, &sess)
+	w.nonce = "n0nce"
+
+	b := &bytes.Buffer{}
+	sub := w.sub(b)
+	if sub.sess != w.sess || sub.nonce != w.nonce {
+		t.Error("Session and nonce are not kept")
+	}
+	sub.Writes("x")
+	if b.String() != "x" {
+		t.Errorf("Expected output in the new writer, got: %q", b.String())
+	}
+}
@@ -19,37 +19,57 @@
 package gwu
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"html/template"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 // Internal path constants.
+// Window-relative paths are the defaults, see Server.SetPaths().
 const (
 	_PATH_STATIC      = "_gwu_static/" // App path-relative path for GWU static contents.
 	_PATH_EVENT       = "e"            // Window-relative path for sending events 
 	_PATH_RENDER_COMP = "rc"           // Window-relative path for rendering a component 
+	_PATH_PUSH        = "p"            // Window-relative path for receiving pushes (long polling)
+	_PATH_WS          = "ws"           // Window-relative path for the WebSocket channel
+	_PATH_SESS_COOKIE = "sc"           // Window-relative path for claiming the cookie of a session created over WebSocket
+	_PATH_UPLOAD      = "u"            // Window-relative path for uploading files
+	_PATH_DOWNLOAD    = "d"            // Window-relative path for downloading files sent by event handlers
+	_PATH_RESOURCE    = "r"            // Window-relative path for fetching resources of components
 )
 
 // Parameters passed between the browser and the server.
 const (
-	_PARAM_EVENT_TYPE      = "et"   // Event type parameter name
-	_PARAM_COMP_ID         = "cid"  // Component id parameter name
-	_PARAM_COMP_VALUE      = "cval" // Component value parameter name
-	_PARAM_FOCUSED_COMP_ID = "fcid" // Focused component id parameter name
-	_PARAM_MOUSE_WX        = "mwx"  // Mouse x pixel coordinate (inside window)
-	_PARAM_MOUSE_WY        = "mwy"  // Mouse y pixel coordinate (inside window)
-	_PARAM_MOUSE_X         = "mx"   // Mouse x pixel coordinate (relative to source component)
-	_PARAM_MOUSE_Y         = "my"   // Mouse y pixel coordinate (relative to source component)
-	_PARAM_MOUSE_BTN       = "mb"   // Mouse button
-	_PARAM_MOD_KEYS        = "mk"   // Modifier key states
-	_PARAM_KEY_CODE        = "kc"   // Key code
+	_PARAM_EVENT_TYPE        = "et"   // Event type parameter name
+	_PARAM_COMP_ID           = "cid"  // Component id parameter name
+	_PARAM_COMP_VALUE        = "cval" // Component value parameter name
+	_PARAM_COMP_VERSION      = "cver" // Component value version parameter name
+	_PARAM_COMP_VALUE_PREFIX = "cv."  // Prefix of the names of additional named component value parameters
+	_PARAM_FOCUSED_COMP_ID   = "fcid" // Focused component id parameter name
+	_PARAM_MOUSE_WX          = "mwx"  // Mouse x pixel coordinate (inside window)
+	_PARAM_MOUSE_WY          = "mwy"  // Mouse y pixel coordinate (inside window)
+	_PARAM_MOUSE_X           = "mx"   // Mouse x pixel coordinate (relative to source component)
+	_PARAM_MOUSE_Y           = "my"   // Mouse y pixel coordinate (relative to source component)
+	_PARAM_MOUSE_BTN         = "mb"   // Mouse button
+	_PARAM_MOD_KEYS          = "mk"   // Modifier key states
+	_PARAM_KEY_CODE          = "kc"   // Key code
+	_PARAM_PUSH_SEQ          = "pseq" // Sequence number of the last push received by the client
+	_PARAM_PUSH_NOWAIT       = "pnw"  // Tells not to wait for pushes (polling in intervals)
+	_PARAM_TAIL_SEQ          = "tseq" // Sequence number of the last appended item received by the client
+	_PARAM_TOKEN             = "tok"  // Session cookie token
+	_PARAM_FILE_NAME         = "fn"   // Name of the uploaded file
+	_PARAM_SIG               = "sig"  // Signature of internal endpoint requests
 )
 
 // Event response actions (client actions to take after processing an event).
@@ -58,6 +78,12 @@ const (
 	_ERA_RELOAD_WIN         // Window name to be reloaded
 	_ERA_DIRTY_COMPS        // There are dirty components which needs to be refreshed
 	_ERA_FOCUS_COMP         // Focus a compnent 
+	_ERA_PUSH_SEQ           // Sequence number of the last push
+	_ERA_SESS_COOKIE        // Session cookie token to claim the cookie of a new session with
+	_ERA_SIG                // Signature of internal endpoint requests for a new session
+	_ERA_DOWNLOAD           // Token of a file to be downloaded
+	_ERA_THEME              // URL of the theme stylesheet to switch to
+	_ERA_SHUTDOWN           // Server is stopping, text to display
 )
 
 // GWU session id cookie name
@@ -76,6 +102,50 @@ type SessionHandler interface {
 	Removed(sess Session)
 }
 
+// Session remove reason type.
+type SessRemoveReason int
+
+// Session remove reasons.
+const (
+	SESS_REMOVE_TIMEOUT     SessRemoveReason = iota // The session timed out (see Session.SetTimeout())
+	SESS_REMOVE_LOGOUT                              // Removed by the application (Event.RemoveSess() or replaced by Event.NewSession())
+	SESS_REMOVE_STOP                                // The server is stopped (see Server.Stop() and Server.Shutdown())
+	SESS_REMOVE_INVALIDATED                         // Invalidated by the server (e.g. pin mismatch, the authenticated user changed)
+	SESS_REMOVE_LIMIT                               // The session exceeded a limit (see Server.SetSessionLimitHandler())
+)
+
+// String returns the name of the session remove reason.
+func (r SessRemoveReason) String() string {
+	switch r {
+	case SESS_REMOVE_TIMEOUT:
+		return "timeout"
+	case SESS_REMOVE_LOGOUT:
+		return "logout"
+	case SESS_REMOVE_STOP:
+		return "stop"
+	case SESS_REMOVE_INVALIDATED:
+		return "invalidated"
+	case SESS_REMOVE_LIMIT:
+		return "limit"
+	}
+	return "unknown"
+}
+
+// SessionListener interface defines callbacks to get notified about the
+// life-cycle of sessions, e.g. to audit logins, to release resources
+// or to persist user state at the end of sessions.
+// Unlike SessionHandler, it is told why a session is removed.
+type SessionListener interface {
+	// Created is called when a new session is created
+	// (or a session is resumed, see Server.SetSessionStore()).
+	// At this time the client does not yet know about the session.
+	Created(sess Session)
+
+	// Removed is called when a session is being removed from the server,
+	// after the session handlers. reason tells why the session is removed.
+	Removed(sess Session, reason SessRemoveReason)
+}
+
 // Server interface defines the GUI server which handles sessions,
 // renders the windows, components and handles event dispatching.
 type Server interface {
@@ -129,6 +199,11 @@ type Server interface {
 	// AddSHandler adds a new session handler.
 	AddSHandler(handler SessionHandler)
 
+	// AddSessionListener adds a new session listener.
+	// Listeners are called synchronously (holding internal locks of the server),
+	// they should return quickly, and must not call Session.Push().
+	AddSessionListener(l SessionListener)
+
 	// AddStaticDir registers a directory whose content (files) recursively
 	// will be served by the server when requested.
 	// path is an app-path relative path to address a file, dir is the root directory
@@ -140,17 +215,277 @@ type Server interface {
 	// Note that the app name must be included in the request path!
 	AddStaticDir(path, dir string) error
 
+	// WinListEnabled tells if the window list page is enabled.
+	WinListEnabled() bool
+
+	// SetWinListEnabled sets if the window list page is enabled.
+	// If disabled, requesting the window list (the app path)
+	// results in a "404 Not Found" response.
+	// The window list is enabled by default.
+	SetWinListEnabled(enabled bool)
+
+	// WinListTemplate returns the custom window list template.
+	// nil is returned if no custom template is set.
+	WinListTemplate() *template.Template
+
+	// SetWinListTemplate sets a custom template to render the window
+	// list page with. The template is executed with a WinListData value.
+	// Windows are listed in the order defined by their Order() value,
+	// window titles are localized according to the client's preferred
+	// language (Accept-Language header) if localized titles are set.
+	// Pass nil to use the built-in default template.
+	SetWinListTemplate(t *template.Template)
+
 	// Theme returns the default CSS theme of the server.
 	Theme() string
 
-	// SetTheme sets the default CSS theme of the server.
+	// SetTheme sets the default CSS theme of the server,
+	// the name of a registered theme (see AddTheme()).
+	// Built-in themes are THEME_DEFAULT, THEME_DEBUG and THEME_DARK.
+	// Windows get the new theme when they are next rendered (reloaded).
 	SetTheme(theme string)
 
+	// AddTheme registers a CSS theme which can then be used by its name
+	// (see SetTheme() and Window.SetTheme()).
+	// If a theme with the same name is already registered, it is replaced,
+	// also at runtime: windows get the new CSS when they are next rendered
+	// (or when their theme is switched).
+	// Themes extending a replaced theme are not updated.
+	AddTheme(theme Theme)
+
+	// Themes returns the names of the registered themes, sorted.
+	Themes() []string
+
+	// AuditStore returns the audit store.
+	AuditStore() AuditStore
+
+	// SetAuditStore sets the audit store, the storage backend of the
+	// event audit trail. If an audit store is set, an AuditRecord
+	// will be stored for each event received from the clients,
+	// recording the session and user the event originates from,
+	// the source component, the event handlers the event is dispatched to,
+	// and the value of the source component before and after the event.
+	// Pass nil to disable auditing. This is the default.
+	SetAuditStore(store AuditStore)
+
+	// TrustedProxies returns the trusted proxies.
+	TrustedProxies() []string
+
+	// SetTrustedProxies sets the trusted proxies. Proxies can be specified
+	// by their IP addresses (e.g. "10.0.0.1") or by networks in CIDR
+	// notation (e.g. "10.0.0.0/8").
+	// If a request comes from a trusted proxy, the real client address is
+	// taken from the X-Forwarded-For (or if missing, from the X-Real-IP)
+	// header. Requests from other addresses are not allowed to spoof their
+	// address with these headers.
+	// Call it without arguments to clear trusted proxies. This is the default.
+	// An error is returned if a proxy is invalid, in which case
+	// trusted proxies remain unchanged.
+	SetTrustedProxies(proxies ...string) error
+
+	// ClientAddr returns the (real) IP address of the client of the request,
+	// taking trusted proxies into account.
+	ClientAddr(r *http.Request) string
+
+	// SessPinning returns the session pinning attributes.
+	SessPinning() SessPin
+
+	// SetSessPinning sets the client attributes private sessions are pinned
+	// (bound) to, e.g. SESS_PIN_IP|SESS_PIN_TLS_CERT.
+	// The attributes are recorded when the session is created and
+	// sent to the client. If a later request of the session comes with
+	// different attributes, the session is invalidated (removed).
+	// This is an extra defense against stolen session cookies.
+	// The default is SESS_PIN_NONE.
+	SetSessPinning(pinning SessPin)
+
+	// SessPinHeaders returns the names of the HTTP headers
+	// private sessions are pinned to.
+	SessPinHeaders() []string
+
+	// SetSessPinHeaders sets the names of HTTP headers (in addition to
+	// the attributes set by SetSessPinning()) private sessions are pinned to.
+	SetSessPinHeaders(headers ...string)
+
+	// Authenticator returns the authenticator of the server.
+	Authenticator() Authenticator
+
+	// SetAuthenticator sets the authenticator of the server which
+	// populates the identity (user) of sessions from incoming requests,
+	// e.g. NewBasicAuthenticator() or NewHeaderAuthenticator().
+	// Pass nil to disable authentication. This is the default.
+	SetAuthenticator(a Authenticator)
+
+	// AuthRequired tells if authentication is required.
+	AuthRequired() bool
+
+	// SetAuthRequired sets if authentication is required.
+	// If required, requests which are not authenticated by the
+	// authenticator are not served but challenged (e.g. a Basic Auth
+	// login prompt is displayed in the browser).
+	// If not required, unauthenticated requests are served with the
+	// public session (or with an existing private session).
+	// Authentication is not required by default.
+	SetAuthRequired(required bool)
+
+	// AccessController returns the access controller of the server.
+	AccessController() AccessController
+
+	// SetAccessController sets the access controller of the server which
+	// decides if sessions are authenticated and which windows they may access.
+	// Default is NewRoleAccessController() which grants access based on
+	// the required roles of windows (see Window.SetRequiredRoles()).
+	// Pass nil to disable access control.
+	SetAccessController(ac AccessController)
+
+	// LoginWin returns the name of the login window.
+	LoginWin() string
+
+	// SetLoginWin sets the name of the login window.
+	// If set, requests of unauthenticated sessions for windows they
+	// may not access (or which are not found) are redirected to the login window.
+	// The login window is typically a public window or a session creator name
+	// (see AddSessCreatorName()). No login window is set by default.
+	SetLoginWin(name string)
+
+	// LoginThrottle returns the login throttle (brute-force protection) of the server.
+	LoginThrottle() LoginThrottle
+
+	// SetLoginThrottle sets the login throttle (brute-force protection) of the server,
+	// used by the built-in authenticators checking credentials, and which can be used
+	// by login windows of applications (see LoginThrottle.Login()).
+	// Pass nil to disable login throttling.
+	// The default is a throttle created by NewDefaultLoginThrottle().
+	SetLoginThrottle(t LoginThrottle)
+
+	// WsEnabled tells if the WebSocket channel is enabled.
+	WsEnabled() bool
+
+	// SetWsEnabled sets if the WebSocket channel is enabled.
+	// If enabled, windows open a WebSocket connection to the server
+	// (if supported by the browser), and events and their responses
+	// flow over this persistent connection instead of separate
+	// HTTP requests, which reduces latency (e.g. of TextBoxes
+	// synchronized on ETYPE_KEY_UP).
+	// Pushes (see Session.Push()) are also delivered over the WebSocket
+	// connection, regardless of Window.PushEnabled().
+	// If the connection cannot be established or is lost, windows
+	// fall back to HTTP requests.
+	// The WebSocket channel is disabled by default.
+	SetWsEnabled(enabled bool)
+
+	// Paths returns the window-relative paths of the internal endpoints.
+	Paths() Paths
+
+	// SetPaths sets the window-relative paths of the internal endpoints
+	// the clients send events and other requests to, e.g. to co-exist
+	// with application routes. RandomPaths() can be used to generate
+	// unpredictable paths.
+	// Paths must be non-empty, unique and must not contain '/', '?' or '#'.
+	// An error is returned if paths are invalid, in which case paths
+	// remain unchanged.
+	// The default is DefaultPaths().
+	SetPaths(paths Paths) error
+
+	// StreamRender tells if windows are rendered streamed.
+	StreamRender() bool
+
+	// SetStreamRender sets if windows are rendered streamed.
+	// If enabled, the rendered window HTML is sent (flushed) to the
+	// client in chunks: after the head section and after each top-level
+	// child component of the window, so users on slow links see the page
+	// shell quickly instead of waiting for the entire component tree
+	// to be rendered and buffered.
+	// Stream rendering is disabled by default.
+	SetStreamRender(stream bool)
+
+	// PathSigning tells if internal endpoint requests are signed.
+	PathSigning() bool
+
+	// SetPathSigning sets if internal endpoint requests are signed.
+	// If enabled, requests sent to the internal endpoints of a window
+	// must carry a signature bound to the session and the window
+	// (issued when the window is rendered), else they are rejected.
+	// This also protects against cross-site request forgery.
+	// Path signing is disabled by default.
+	SetPathSigning(signing bool)
+
+	// Sanitation returns the sanitation policy of text sent by the client.
+	Sanitation() Sanitation
+
+	// SetSanitation sets the sanitation policy of text sent by the client,
+	// which is applied centrally to the values of text-bearing components
+	// (TextBox, PasswBox, ComboBox and RichTextBox) before they are stored.
+	// The default is SANITIZE_DEFAULT.
+	SetSanitation(sanitation Sanitation)
+
+	// MaxRequestSize returns the max size of request bodies in bytes.
+	MaxRequestSize() int64
+
+	// SetMaxRequestSize sets the max size of request bodies in bytes
+	// (file uploads excluded, see FileUpload.SetMaxSize()).
+	// Requests exceeding this size are rejected before they are parsed.
+	// Pass a value <= 0 to not limit the size.
+	// The default is DEFAULT_MAX_REQUEST_SIZE.
+	SetMaxRequestSize(size int64)
+
+	// MaxValueSize returns the max size of the values sent by the client
+	// with an event in bytes.
+	MaxValueSize() int
+
+	// SetMaxValueSize sets the max size of the values sent by the client
+	// with an event in bytes, for components not having their own limit
+	// (see Comp.SetMaxValueSize()). If exceeded, the event is not processed,
+	// instead an ETYPE_LIMIT_EXCEEDED event is dispatched to the component.
+	// Pass a value <= 0 to not limit the size. This is the default.
+	SetMaxValueSize(size int)
+
+	// UploadQuota returns the upload quota of sessions in bytes.
+	UploadQuota() int64
+
+	// SetUploadQuota sets the upload quota of sessions in bytes:
+	// the max total size of files uploaded in a session
+	// (see Session.Uploaded()). If an upload would exceed the quota,
+	// it is rejected, and an ETYPE_LIMIT_EXCEEDED event is dispatched
+	// to the FileUpload.
+	// Pass a value <= 0 to not limit uploads. This is the default.
+	SetUploadQuota(quota int64)
+
+	// MaxSessionComps returns the max number of components of sessions.
+	MaxSessionComps() int
+
+	// SetMaxSessionComps sets the max number of components of private
+	// sessions (see Session.Usage()). Sessions are checked after each event
+	// and periodically; sessions exceeding the limit are evicted (removed and
+	// their windows reloaded), unless the session limit handler tells otherwise
+	// (see SetSessionLimitHandler()).
+	// Pass a value <= 0 to not limit components. This is the default.
+	SetMaxSessionComps(max int)
+
+	// MaxSessionMemory returns the max approximate memory footprint of sessions in bytes.
+	MaxSessionMemory() int64
+
+	// SetMaxSessionMemory sets the max approximate memory footprint of private
+	// sessions in bytes (see Session.Usage()). Sessions exceeding the limit
+	// are treated the same way as by SetMaxSessionComps().
+	// Pass a value <= 0 to not limit the memory footprint. This is the default.
+	SetMaxSessionMemory(max int64)
+
+	// SetSessionLimitHandler sets the handler which is called when a session
+	// exceeds a limit (see SetMaxSessionComps() and SetMaxSessionMemory()).
+	// The session is evicted if the handler returns true.
+	// Pass nil to always evict sessions exceeding a limit. This is the default.
+	SetSessionLimitHandler(handler SessionLimitHandler)
+
 	// SetLogger sets the logger to be used
 	// to log incoming requests.
 	// Pass nil to disable logging. This is the default.
 	SetLogger(logger *log.Logger)
 
+	// Presence returns the presence subsystem of the server which reports
+	// which authenticated users currently have live windows.
+	Presence() Presence
+
 	// Start starts the GUI server and waits for incoming connections.
 	// 
 	// Sessionless window names may be specified as optional parameters
@@ -159,6 +494,140 @@ type Server interface {
 	// Tip: Not passing any window names will start the server silently
 	// without opening any windows.
 	Start(openWins ...string) error
+
+	// StartTLS starts the GUI server in secure (HTTPS) mode with the
+	// specified certificate and key files, and waits for incoming connections.
+	// Optional window names are treated the same way as in Start().
+	StartTLS(certFile, keyFile string, openWins ...string) error
+
+	// HttpServer returns the pre-configured HTTP server set by SetHttpServer().
+	HttpServer() *http.Server
+
+	// SetHttpServer sets a pre-configured HTTP server to be used by Start()
+	// and StartTLS(), e.g. to set timeouts, TLS or HTTP/2 settings.
+	// If its Addr is empty, the address of the GUI server is used.
+	// If its Handler is nil, the http.DefaultServeMux is used (which the GUI server
+	// is registered to); else the handler must serve the GUI server (see Handler()).
+	// Pass nil to use a default HTTP server. This is the default.
+	SetHttpServer(hs *http.Server)
+
+	// Stop gracefully shuts down the GUI server started by Start() or StartTLS():
+	// stops accepting new connections, waits for the active requests to complete
+	// (or ctx to be done), then removes all sessions (session handlers and listeners
+	// are notified, see SessionListener.Removed()), and stops the background tasks of the server.
+	// Start() and StartTLS() return nil after Stop().
+	// The GUI server cannot be restarted after Stop().
+	// Connected clients are notified the same way as by Shutdown().
+	Stop(ctx context.Context) error
+
+	// Shutdown is like Stop(), but it also saves the sessions into the
+	// session store (if set, see SetSessionStore()) before removing them,
+	// so a restarted server can resume them.
+	// Events received during the shutdown are not processed; the clients
+	// are notified (by the push channel, WebSocket connection or as the
+	// response of their next event) and display the shutdown text
+	// (see SetShutdownText()) in an overlay, and reload the window
+	// when the server is available again.
+	Shutdown(ctx context.Context) error
+
+	// SessionStore returns the session store of the server.
+	SessionStore() SessionStore
+
+	// SetSessionStore sets the session store of the server,
+	// which sessions are saved into by Shutdown(), and resumed from
+	// when the server is started (see Start() and Handler()).
+	// Pass nil to not persist sessions. This is the default.
+	SetSessionStore(store SessionStore)
+
+	// ShutdownText returns the text displayed to the clients
+	// when the server is stopping.
+	ShutdownText() string
+
+	// SetShutdownText sets the text displayed to the clients in an
+	// overlay when the server is stopping, e.g. "Server restarting...".
+	// If empty, no overlay is displayed (but windows are still reloaded
+	// when the server is available again). The default is empty.
+	SetShutdownText(text string)
+
+	// IdleWarning returns how long before the expiration of sessions
+	// the idle warning is displayed.
+	IdleWarning() time.Duration
+
+	// SetIdleWarning sets how long before the expiration of private sessions
+	// (see Session.SetTimeout()) an idle warning is displayed in the browser:
+	// an overlay counting down the time left, with a "keep alive" button
+	// which keeps the session alive. Expired windows are reloaded
+	// (or the expired window is loaded, see SetExpiredWin()).
+	// The countdown is restarted by each event sent from the window.
+	// Pass 0 to disable the idle warning. This is the default.
+	// Windows have to be reloaded for the change to take effect.
+	SetIdleWarning(before time.Duration)
+
+	// IdleWarningTexts returns the text of the idle warning
+	// and the text of its keep alive button.
+	IdleWarningTexts() (text, keepAliveText string)
+
+	// SetIdleWarningTexts sets the text of the idle warning (displayed
+	// above the countdown) and the text of its keep alive button.
+	// Defaults are DEFAULT_IDLE_WARNING_TEXT and DEFAULT_KEEP_ALIVE_TEXT.
+	SetIdleWarningTexts(text, keepAliveText string)
+
+	// ExpiredWin returns the name of the window to load when a session expired.
+	ExpiredWin() string
+
+	// SetExpiredWin sets the name of the window to load when a session expired
+	// (e.g. a public "You have been logged out" window or the login window):
+	// windows of private sessions load it when the session expires while the
+	// window is open (the browser counts down the remaining session lifetime),
+	// and events sent from windows of expired sessions are answered by loading it
+	// (instead of an error).
+	// If empty, expired windows are reloaded. This is the default.
+	// Windows have to be reloaded for the change to take effect.
+	SetExpiredWin(name string)
+
+	// SecurityHeaders returns the configuration of the security headers.
+	SecurityHeaders() SecurityHeaders
+
+	// SetSecurityHeaders sets the configuration of the security headers
+	// (Content-Security-Policy, X-Frame-Options, X-Content-Type-Options etc.)
+	// sent with the window, event and other responses of the server.
+	// If the Content-Security-Policy contains the CSP_NONCE placeholder,
+	// a nonce is generated for each response and is added to the inline
+	// scripts rendered by gowut, so a strict policy can be used
+	// (see DefaultSecurityHeaders() and DEFAULT_CSP).
+	// By default no security headers are sent.
+	SetSecurityHeaders(cfg SecurityHeaders)
+
+	// Handler returns an http.Handler serving the GUI server: its windows,
+	// internal endpoints, static contents and static directories
+	// (see AddStaticDir()). This allows to mount the GUI server into an
+	// existing http.ServeMux or middleware stack instead of starting it
+	// by Start() (which serves it by the http.DefaultServeMux).
+	// The handler must be mounted at the app path (see AppPath()), e.g.
+	// 
+	// 	mux.Handle(server.AppPath(), server.Handler())
+	// 
+	// Tip: The app name may consist of multiple path segments
+	// (e.g. "admin/gui") to mount the GUI server under any path.
+	// 
+	// Background tasks of the server (e.g. removing timed out sessions)
+	// are started when the handler is first requested.
+	Handler() http.Handler
+
+	// trusted tells if the specified IP address (string) is a trusted proxy.
+	trusted(addr string) bool
+
+	// sessUser returns the user of the private session the request
+	// belongs to. Empty string is returned if there is no such session.
+	sessUser(r *http.Request) string
+
+	// themeRes returns the resource name of the specified theme,
+	// of the server's theme if theme is empty.
+	themeRes(theme string) string
+
+	// pathSig returns the signature of internal endpoint requests
+	// of the specified window in the specified session.
+	pathSig(sess Session, winName string) string
 }
 
 // Server implementation.
@@ -166,21 +635,70 @@ type serverImpl struct {
 	sessionImpl // Single public session implementation
 	hasTextImpl // Has text implementation
 
-	appName           string             // Application name (part of the application path)
-	addr              string             // Server address
-	secure            bool               // Tells if the server is configured to run in secure (HTTPS) mode
-	appPath           string             // Application path
-	appUrl            string             // Application URL
-	sessions          map[string]Session // Sessions
-	certFile, keyFile string             // Certificate and key files for secure (HTTPS) mode
-	sessCreatorNames  map[string]string  // Session creator names
-	sessionHandlers   []SessionHandler   // Registered session handlers
-	theme             string             // Default CSS theme of the server
-	logger            *log.Logger        // Logger.
+	appName           string               // Application name (part of the application path)
+	addr              string               // Server address
+	secure            bool                 // Tells if the server is configured to run in secure (HTTPS) mode
+	appPath           string               // Application path
+	appUrl            string               // Application URL
+	sessions          map[string]Session   // Sessions
+	sessionsMutex     sync.RWMutex         // RW mutex to synchronize sessions access
+	certFile, keyFile string               // Certificate and key files for secure (HTTPS) mode
+	sessCreatorNames  map[string]string    // Session creator names
+	sessionHandlers   []SessionHandler     // Registered session handlers
+	sessionListeners  []SessionListener    // Registered session listeners
+	theme             string               // Default CSS theme of the server
+	logger            *log.Logger          // Logger.
+	auditStore        AuditStore           // Audit store
+	winListEnabled    bool                 // Tells if the window list page is enabled
+	winListTemplate   *template.Template   // Custom window list template
+	trustedProxies    []string             // Trusted proxies
+	trustedNets       []*net.IPNet         // Parsed trusted proxies
+	sessPinning       SessPin              // Session pinning attributes
+	sessPinHeaders    []string             // Names of HTTP headers sessions are pinned to
+	authenticator     Authenticator        // Authenticator of requests
+	authRequired      bool                 // Tells if authentication is required
+	accessController  AccessController     // Access controller of windows
+	loginWin          string               // Name of the login window
+	wsEnabled         bool                 // Tells if the WebSocket channel is enabled
+	sessTokens        map[string]sessToken // Session cookie tokens of sessions created over WebSocket
+	sessTokensMutex   sync.Mutex           // Mutex to synchronize session cookie token access
+	paths             Paths                // Window-relative paths of the internal endpoints
+	pathSigning       bool                 // Tells if internal endpoint requests are signed
+	sigKey            []byte               // Key to sign internal endpoint requests with
+	downloads         map[string]*download // Files to be downloaded, mapped from their tokens
+	downloadsMutex    sync.Mutex           // Mutex to synchronize downloads access
+	streamRender      bool                 // Tells if windows are rendered streamed
+	presence          *presenceImpl        // Presence of authenticated users
+	themes            map[string]*themeRes // Registered themes, mapped from their names
+	themesMutex       sync.Mutex           // Mutex to synchronize themes access
+	themeSeq          int                  // Sequence number of registered themes (used in resource names)
+	sanitation        Sanitation           // Sanitation policy of text sent by the client
+	maxRequestSize    int64                // Max size of request bodies
+	maxValueSize      int                  // Max size of values sent with an event
+	uploadQuota       int64                // Upload quota of sessions
+	maxSessionComps   int                  // Max number of components of sessions
+	maxSessionMemory  int64                // Max approximate memory footprint of sessions
+	sessLimitHandler  SessionLimitHandler  // Handler of sessions exceeding a limit
+	loginThrottle     LoginThrottle        // Login throttle (brute-force protection)
+	mux               *http.ServeMux       // Mux serving the app path, static contents and static dirs
+	startOnce         sync.Once            // To start background tasks only once
+	httpServer        *http.Server         // Pre-configured HTTP server
+	running           *http.Server         // The running HTTP server
+	runningMutex      sync.Mutex           // Mutex to synchronize access to the running HTTP server
+	stopping          chan struct{}        // Channel which is closed when the server is stopped
+	stopOnce          sync.Once            // To close stopping only once
+	stopFlag          int32                // Tells if the server is stopping (1) or not (0), accessed atomically
+	sessionStore      SessionStore         // Store of sessions to persist them
+	shutdownText      string               // Text displayed to the clients when the server is stopping
+	idleWarning       time.Duration        // How long before the expiration of sessions the idle warning is displayed
+	idleWarningText   string               // Text of the idle warning
+	keepAliveText     string               // Text of the keep alive button of the idle warning
+	expiredWin        string               // Name of the window to load when a session expired
+	securityHeaders   SecurityHeaders      // Security headers of the responses
 }
 
 // NewServer creates a new GUI server in HTTP mode.
-// The specified app name will be part of the application path (the first part).
+// The specified app name will be part of the application path (the first part(s)).
 // If addr is empty string, "localhost:3434" will be used.
 // 
 // Tip: Pass an empty string as appName to place the GUI server to the root path ("/").
@@ -189,7 +707,7 @@ func NewServer(appName, addr string) Server {
 }
 
 // NewServerTLS creates a new GUI server in secure (HTTPS) mode.
-// The specified app name will be part of the application path (the first part).
+// The specified app name will be part of the application path (the first part(s)).
 // If addr is empty string, "localhost:3434" will be used.
 // 
 // Tip: Pass an empty string as appName to place the GUI server to the root path ("/").
@@ -206,7 +724,17 @@ func newServerImpl(appName, addr, certFile, keyFile string) *serverImpl {
 	}
 
 	s := &serverImpl{sessionImpl: newSessionImpl(false), appName: appName, addr: addr, sessions: make(map[string]Session),
-		sessCreatorNames: make(map[string]string), theme: THEME_DEFAULT}
+		sessCreatorNames: make(map[string]string), theme: THEME_DEFAULT, winListEnabled: true,
+		sessTokens: make(map[string]sessToken), paths: DefaultPaths(), sigKey: newSigKey(),
+		downloads: make(map[string]*download), presence: newPresenceImpl(), themes: make(map[string]*themeRes),
+		sanitation: SANITIZE_DEFAULT, maxRequestSize: DEFAULT_MAX_REQUEST_SIZE,
+		loginThrottle: NewDefaultLoginThrottle(), stopping: make(chan struct{}),
+		idleWarningText: DEFAULT_IDLE_WARNING_TEXT, keepAliveText: DEFAULT_KEEP_ALIVE_TEXT,
+		accessController: NewRoleAccessController()}
+
+	s.addBuiltinThemes()
+
+	s.sessionImpl.server = s
 
 	if len(s.appName) == 0 {
 		s.appPath = "/"
@@ -214,6 +742,10 @@ func newServerImpl(appName, addr, certFile, keyFile string) *serverImpl {
 		s.appPath = "/" + s.appName + "/"
 	}
 
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc(s.appPath, s.serveHTTP)
+	s.mux.HandleFunc(s.appPath+_PATH_STATIC, s.serveStatic)
+
 	if len(certFile) == 0 || len(keyFile) == 0 {
 		s.secure = false
 		s.appUrl = "http://" + addr + s.appPath
@@ -249,6 +781,10 @@ func (s *serverImpl) AddSHandler(handler SessionHandler) {
 	s.sessionHandlers = append(s.sessionHandlers, handler)
 }
 
+func (s *serverImpl) AddSessionListener(l SessionListener) {
+	s.sessionListeners = append(s.sessionListeners, l)
+}
+
 // newSession creates a new (private) Session.
 // The event is optional. If specified and the current session
 // (as returned by Event.Session()) is private, it will be removed first.
@@ -256,36 +792,47 @@ func (s *serverImpl) AddSHandler(handler SessionHandler) {
 func (s *serverImpl) newSession(e *eventImpl) Session {
 	if e != nil {
 		// First remove old session
-		s.removeSess(e)
+		s.removeSess(e, SESS_REMOVE_LOGOUT)
 	}
 
 	sessImpl := newSessionImpl(true)
+	sessImpl.server = s
 	sess := &sessImpl
 	if e != nil {
 		e.shared.session = sess
 	}
 	// Store new session
+	s.sessionsMutex.Lock()
 	s.sessions[sess.Id()] = sess
+	s.sessionsMutex.Unlock()
 
 	if s.logger != nil {
 		s.logger.Println("SESSION created:", sess.Id())
 	}
 
-	// Notify session handlers
+	s.notifyCreated(sess)
+
+	return sess
+}
+
+// notifyCreated notifies the session handlers and listeners
+// that the specified session has been created.
+func (s *serverImpl) notifyCreated(sess Session) {
 	for _, handler := range s.sessionHandlers {
 		handler.Created(sess)
 	}
-
-	return sess
+	for _, l := range s.sessionListeners {
+		l.Created(sess)
+	}
 }
 
 // removeSess removes (invalidates) the current session of the specified event.
 // Only private sessions can be removed, calling this
 // when the current session (as returned by Event.Session()) is public is a no-op.
 // After this method Event.Session() will return the shared public session.
-func (s *serverImpl) removeSess(e *eventImpl) {
+func (s *serverImpl) removeSess(e *eventImpl, reason SessRemoveReason) {
 	if e.shared.session.Private() {
-		s.removeSess2(e.shared.session)
+		s.removeSess2(e.shared.session, reason)
 		e.shared.session = &s.sessionImpl
 	}
 }
@@ -293,24 +840,62 @@ func (s *serverImpl) removeSess(e *eventImpl) {
 // removeSess2 removes (invalidates) the specified session.
 // Only private sessions can be removed, calling this
 // the public session is a no-op.
-func (s *serverImpl) removeSess2(sess Session) {
+func (s *serverImpl) removeSess2(sess Session, reason SessRemoveReason) {
 	if sess.Private() {
+		// Only the goroutine which actually removes the session notifies,
+		// the session may be removed concurrently (e.g. by the session cleaner).
+		s.sessionsMutex.Lock()
+		current := s.sessions[sess.Id()] == sess
+		if current {
+			delete(s.sessions, sess.Id())
+		}
+		s.sessionsMutex.Unlock()
+		if !current {
+			return
+		}
+
 		if s.logger != nil {
-			s.logger.Println("SESSION removed:", sess.Id())
+			s.logger.Println("SESSION removed:", sess.Id(), "reason:", reason)
 		}
 
-		// Notify session handlers
+		// Notify session handlers and listeners
 		for _, handler := range s.sessionHandlers {
 			handler.Removed(sess)
 		}
-		delete(s.sessions, sess.Id())
+		for _, l := range s.sessionListeners {
+			l.Removed(sess, reason)
+		}
+		s.presence.removeSess(sess)
+		// Shared contents must not retain (and broadcast to) views of removed sessions
+		sess.detachSharedViews()
 	}
 }
 
+// sessionById returns the private session with the specified id.
+// nil is returned if there is no such session.
+func (s *serverImpl) sessionById(id string) Session {
+	s.sessionsMutex.RLock()
+	defer s.sessionsMutex.RUnlock()
+	return s.sessions[id]
+}
+
+// sessionList returns a snapshot of the private sessions,
+// which can be iterated over while sessions are added or removed.
+func (s *serverImpl) sessionList() []Session {
+	s.sessionsMutex.RLock()
+	defer s.sessionsMutex.RUnlock()
+	sessions := make([]Session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
 // addSessCookie lets the client know about the specified (new) session
 // by setting the GWU session id cookie.
-// Also clears the new flag of the session.
-func (s *serverImpl) addSessCookie(sess Session, w http.ResponseWriter) {
+// Also clears the new flag of the session, and pins the session
+// to the client attributes of the request.
+func (s *serverImpl) addSessCookie(sess Session, w http.ResponseWriter, r *http.Request) {
 	// HttpOnly: do not allow non-HTTP access to it (like javascript) to prevent stealing it...
 	// Secure: only send it over HTTPS
 	// MaxAge: to specify the max age of the cookie in seconds, else it's a session cookie and gets deleted after the browser is closed.
@@ -319,6 +904,7 @@ func (s *serverImpl) addSessCookie(sess Session, w http.ResponseWriter) {
 	http.SetCookie(w, &c)
 
 	sess.clearNew()
+	sess.setPin(s.sessPin(r))
 }
 
 // sessCleaner periodically checks whether private sessions has timed out
@@ -329,14 +915,22 @@ func (s *serverImpl) sessCleaner() {
 	for {
 		now := time.Now()
 
-		// TODO synchronization?
-		for _, sess := range s.sessions {
+		for _, sess := range s.sessionList() {
 			if now.Sub(sess.Accessed()) > sess.Timeout() {
-				s.removeSess2(sess)
+				s.removeSess2(sess, SESS_REMOVE_TIMEOUT)
 			}
 		}
+		s.presence.expire(now)
+		s.checkSessionsLimits()
+		if t := s.loginThrottle; t != nil {
+			t.expire(now)
+		}
 
-		time.Sleep(sleep)
+		select {
+		case <-time.After(sleep):
+		case <-s.stopping:
+			return
+		}
 	}
 }
 
@@ -359,11 +953,27 @@ func (s *serverImpl) AddStaticDir(path, dir string) error {
 		return errors.New("path cannot be '" + _PATH_STATIC + "' (reserved)!")
 	}
 
-	http.Handle(path, http.StripPrefix(path, http.FileServer(http.Dir(dir))))
+	s.mux.Handle(path, http.StripPrefix(path, http.FileServer(http.Dir(dir))))
 
 	return nil
 }
 
+func (s *serverImpl) WinListEnabled() bool {
+	return s.winListEnabled
+}
+
+func (s *serverImpl) SetWinListEnabled(enabled bool) {
+	s.winListEnabled = enabled
+}
+
+func (s *serverImpl) WinListTemplate() *template.Template {
+	return s.winListTemplate
+}
+
+func (s *serverImpl) SetWinListTemplate(t *template.Template) {
+	s.winListTemplate = t
+}
+
 func (s *serverImpl) Theme() string {
 	return s.theme
 }
@@ -372,10 +982,30 @@ func (s *serverImpl) SetTheme(theme string) {
 	s.theme = theme
 }
 
+func (s *serverImpl) StreamRender() bool {
+	return s.streamRender
+}
+
+func (s *serverImpl) SetStreamRender(stream bool) {
+	s.streamRender = stream
+}
+
+func (s *serverImpl) AuditStore() AuditStore {
+	return s.auditStore
+}
+
+func (s *serverImpl) SetAuditStore(store AuditStore) {
+	s.auditStore = store
+}
+
 func (s *serverImpl) SetLogger(logger *log.Logger) {
 	s.logger = logger
 }
 
+func (s *serverImpl) Presence() Presence {
+	return s.presence
+}
+
 // open opens the specified URL in the default browser of the user.
 func open(url string) error {
 	var cmd string
@@ -394,14 +1024,16 @@ func open(url string) error {
 	return exec.Command(cmd, args...).Start()
 }
 
-func (s *serverImpl) Start(openWins ...string) error {
-	http.HandleFunc(s.appPath, func(w http.ResponseWriter, r *http.Request) {
-		s.serveHTTP(w, r)
+func (s *serverImpl) Handler() http.Handler {
+	s.startOnce.Do(func() {
+		s.resumeSessions()
+		go s.sessCleaner()
 	})
+	return s.mux
+}
 
-	http.HandleFunc(s.appPath+_PATH_STATIC, func(w http.ResponseWriter, r *http.Request) {
-		s.serveStatic(w, r)
-	})
+func (s *serverImpl) Start(openWins ...string) error {
+	http.Handle(s.appPath, s.Handler())
 
 	fmt.Println("Starting GUI server on:", s.appUrl)
 	if s.logger != nil {
@@ -412,47 +1044,55 @@ func (s *serverImpl) Start(openWins ...string) error {
 		open(s.appUrl + winName)
 	}
 
-	go s.sessCleaner()
+	hs := s.httpServer
+	if hs == nil {
+		hs = &http.Server{}
+	}
+	if len(hs.Addr) == 0 {
+		hs.Addr = s.addr
+	}
+	s.runningMutex.Lock()
+	s.running = hs
+	s.runningMutex.Unlock()
 
 	var err error
 	if s.secure {
-		err = http.ListenAndServeTLS(s.addr, s.certFile, s.keyFile, nil)
+		err = hs.ListenAndServeTLS(s.certFile, s.keyFile)
 	} else {
-		err = http.ListenAndServe(s.addr, nil)
+		err = hs.ListenAndServe()
 	}
 
-	if err != nil {
+	if err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
 }
 
+func (s *serverImpl) StartTLS(certFile, keyFile string, openWins ...string) error {
+	s.secure = true
+	s.certFile, s.keyFile = certFile, keyFile
+	s.appUrl = "https://" + s.addr + s.appPath
+	return s.Start(openWins...)
+}
+
+func (s *serverImpl) HttpServer() *http.Server {
+	return s.httpServer
+}
+
+func (s *serverImpl) SetHttpServer(hs *http.Server) {
+	s.httpServer = hs
+}
+
 // serveStatic handles the static contents of GWU.
 func (s *serverImpl) serveStatic(w http.ResponseWriter, r *http.Request) {
-	// Parts example: "/appname/_gwu_static/gwu-0.8.0.js" => {"", "appname", "_gwu_static", "gwu-0.8.0.js"}
-	parts := strings.Split(r.URL.Path, "/")
-
-	if len(s.appName) == 0 {
-		// No app name, gui server resides in root
-		if len(parts) < 2 {
-			// This should never happen. Path is always at least a slash ("/").
-			http.NotFound(w, r)
-			return
-		}
-		// Omit the first empty string and _PATH_STATIC
-		parts = parts[2:]
-	} else {
-		// We have app name
-		if len(parts) < 3 {
-			// Missing app name from path
-			http.NotFound(w, r)
-			return
-		}
-		// Omit the first empty string, app name and _PATH_STATIC
-		parts = parts[3:]
+	// Example: "/appname/_gwu_static/gwu-0.8.0.js" => "gwu-0.8.0.js"
+	prefix := s.appPath + _PATH_STATIC
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
 	}
 
-	res := parts[0]
+	res := r.URL.Path[len(prefix):]
 	if res == _RES_NAME_STATIC_JS {
 		w.Header().Set("Expires", time.Now().Add(72*time.Hour).Format(http.TimeFormat)) // Set 72 hours caching
 		w.Header().Set("Content-Type", "application/x-javascript; charset=utf-8")
@@ -460,7 +1100,7 @@ func (s *serverImpl) serveStatic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if strings.HasSuffix(res, ".css") {
-		cssCode := staticCss[res]
+		cssCode := s.themeCss(res)
 		if cssCode != nil {
 			w.Header().Set("Expires", time.Now().Add(72*time.Hour).Format(http.TimeFormat)) // Set 72 hours caching
 			w.Header().Set("Content-Type", "text/css; charset=utf-8")
@@ -476,42 +1116,58 @@ func (s *serverImpl) serveStatic(w http.ResponseWriter, r *http.Request) {
 // Renders of the URL-selected window,
 // and also handles event dispatching.
 func (s *serverImpl) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	clientAddr := s.ClientAddr(r)
 	if s.logger != nil {
-		s.logger.Println("Incoming: ", r.URL.Path)
+		s.logger.Println("Incoming: ", r.URL.Path, "from", clientAddr)
+	}
+
+	nonce, err := s.setSecurityHeaders(w, r)
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Println("Failed to generate CSP nonce:", err)
+		}
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
 	}
 
 	// Check session
 	var sess Session
 	c, err := r.Cookie(_GWU_SESSID_COOKIE)
 	if err == nil {
-		sess = s.sessions[c.Value]
+		sess = s.sessionById(c.Value)
+		if sess != nil && !s.checkSessPin(sess, r) {
+			sess = nil
+		}
+	}
+	if s.authenticator != nil {
+		var ok bool
+		if sess, ok = s.authenticate(sess, w, r); !ok {
+			return
+		}
 	}
 	if sess == nil {
 		sess = &s.sessionImpl
 	}
-	sess.access()
 
-	// Parts example: "/appname/winname/e?et=0&cid=1" => {"", "appname", "winname", "e"}
-	parts := strings.Split(r.URL.Path, "/")
+	// Parts example: "/appname/winname/e?et=0&cid=1" => {"winname", "e"}
+	if !strings.HasPrefix(r.URL.Path, s.appPath) {
+		// Missing app path (the handler is mounted at a wrong path)
+		http.NotFound(w, r)
+		return
+	}
+	parts := strings.Split(r.URL.Path[len(s.appPath):], "/")
 
-	if len(s.appName) == 0 {
-		// No app name, gui server resides in root
-		if len(parts) < 1 {
-			// This should never happen. Path is always at least a slash ("/").
-			http.NotFound(w, r)
-			return
-		}
-		// Omit the first empty string
-		parts = parts[1:]
-	} else {
-		// We have app name
-		if len(parts) < 2 {
-			// Missing app name from path
-			http.NotFound(w, r)
-			return
-		}
-		// Omit the first empty string and the app name
-		parts = parts[2:]
+	// Push requests (long polls) must not keep the session alive
+	pushReq := len(parts) >= 2 && parts[1] == s.paths.Push
+	if !pushReq {
+		sess.access(clientAddr)
+	}
+
+	if len(parts) >= 2 && parts[1] == s.paths.SessCookie {
+		// The session of the token is not yet known by the client, serve it before window lookup.
+		// No need to check path signature, the token itself is a secret.
+		s.handleSessCookie(w, r)
+		return
 	}
 
 	if len(parts) < 1 || len(parts[0]) == 0 {
@@ -526,8 +1182,8 @@ func (s *serverImpl) serveHTTP(w http.ResponseWriter, r *http.Request) {
 	// If not found and we're on an authenticated session, try the public window list
 	if win == nil && sess.Private() {
 		win = s.WinByName(winName) // Server is a Session, the public session
-		if win != nil {
-			s.access()
+		if win != nil && !pushReq {
+			s.access(clientAddr)
 		}
 	}
 	// If still not found and no private session, try the session creator names
@@ -535,97 +1191,111 @@ func (s *serverImpl) serveHTTP(w http.ResponseWriter, r *http.Request) {
 		_, found := s.sessCreatorNames[winName]
 		if found {
 			sess = s.newSession(nil)
-			s.addSessCookie(sess, w)
+			s.addSessCookie(sess, w, r)
 			// Search again in the new session as SessionHandlers may have added windows.
 			win = sess.WinByName(winName)
 		}
 	}
 
+	var path string
+	if len(parts) >= 2 {
+		path = parts[1]
+	}
+
+	if win == nil && s.toLogin(sess, winName) {
+		// Windows of logged-in users are not available, go to the login window
+		s.accessDenied(sess, winName, path, w, r)
+		return
+	}
+
+	if win == nil && path == s.paths.Event {
+		// Typically the session of the window expired, let the client leave the window
+		s.expiredEvent(w)
+		return
+	}
+
 	if win == nil {
 		// Invalid window name, render an error message with a link to the window list
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusNotFound)
-		NewWriter(w).Writess("<html><body>Window for name <b>'", winName, `'</b> not found. See the <a href="`, s.appPath, `">Window list</a>.</body></html>`)
+		NewWriter(w).Writess("<html><body>Window for name <b>'", template.HTMLEscapeString(winName), `'</b> not found. See the <a href="`, s.appPath, `">Window list</a>.</body></html>`)
 		return
 	}
 
-	var path string
-	if len(parts) >= 2 {
-		path = parts[1]
+	if !s.canAccess(sess, win) {
+		s.accessDenied(sess, winName, path, w, r)
+		return
+	}
+
+	if s.maxRequestSize > 0 && path != s.paths.Upload && r.Body != nil {
+		if r.ContentLength > s.maxRequestSize {
+			http.Error(w, "Request too big!", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxRequestSize)
+	}
+
+	if s.pathSigning && s.paths.contains(path) && !s.checkPathSig(sess, winName, r) {
+		http.Error(w, "Invalid signature!", http.StatusForbidden)
+		return
+	}
+
+	// Record presence: push requests and WebSocket connections keep the window live while open
+	if path == s.paths.Push || path == s.paths.Ws {
+		s.presence.touch(sess, winName, 1)
+		defer s.presence.touch(sess, winName, -1)
+	} else {
+		s.presence.touch(sess, winName, 0)
 	}
 
 	rwMutex := sess.rwMutex()
 
 	switch path {
-	case _PATH_EVENT:
+	case s.paths.Push:
+		// Push queues are synchronized on their own, no need to lock the session
+		s.handlePush(win, w, r)
+	case s.paths.Ws:
+		// The session is locked for each event received over the connection
+		s.handleWs(sess, win, w, r)
+	case s.paths.Upload:
+		// The session is only locked while upload events are dispatched
+		s.handleUpload(sess, win, w, r)
+	case s.paths.Download:
+		// Downloads are synchronized on their own, no need to lock the session
+		s.handleDownload(sess, w, r)
+	case s.paths.Resource:
+		rwMutex.RLock()
+		defer rwMutex.RUnlock()
+
+		s.handleResource(win, w, r)
+	case s.paths.Event:
 		rwMutex.Lock()
 		defer rwMutex.Unlock()
 
 		s.handleEvent(sess, win, w, r)
-	case _PATH_RENDER_COMP:
+	case s.paths.RenderComp:
 		rwMutex.RLock()
 		defer rwMutex.RUnlock()
 
 		// Render just a component
-		s.renderComp(win, w, r)
+		s.renderComp(sess, win, w, r)
 	default:
 		rwMutex.RLock()
 		defer rwMutex.RUnlock()
 
 		// Render the whole window
-		win.RenderWin(NewWriter(w), s)
-	}
-}
-
-// renderWinList renders the window list of a session as HTML document with clickable links. 
-func (s *serverImpl) renderWinList(sess Session, wr http.ResponseWriter, r *http.Request) {
-	if s.logger != nil {
-		s.logger.Println("\tRending windows list.")
-	}
-	wr.Header().Set("Content-Type", "text/html; charset=utf-8")
-
-	w := NewWriter(wr)
-
-	w.Writes(`<html><head><meta http-equiv="content-type" content="text/html; charset=UTF-8"><title>`)
-	w.Writees(s.text)
-	w.Writess(" - Window list</title></head><body><h2>")
-	w.Writees(s.text)
-	w.Writes(" - Window list</h2>")
-
-	// Render both private and public session windows
-	sessions := make([]Session, 1, 2)
-	sessions[0] = sess
-	if sess.Private() {
-		sessions = append(sessions, &s.sessionImpl)
-	} else {
-		// No private session yet, render session creators:
-		if len(s.sessCreatorNames) > 0 {
-			w.Writes("Session creators:<ul>") // TODO needs a better name
-			for name, text := range s.sessCreatorNames {
-				w.Writess(`<li><a href="`, s.appPath, name, `">`, text, "</a>")
-			}
-			w.Writes("</ul>")
-		}
-	}
-
-	for _, session := range sessions {
-		if session.Private() {
-			w.Writes("Authenticated windows:")
-		} else {
-			w.Writes("Public windows:")
-		}
-		w.Writes("<ul>")
-		for _, win := range session.SortedWins() {
-			w.Writess(`<li><a href="`, s.appPath, win.Name(), `">`, win.Text(), "</a>")
+		var out io.Writer = w
+		if !s.streamRender {
+			out = struct{ io.Writer }{w} // Hide http.Flusher so flushing is a no-op
 		}
-		w.Writes("</ul>")
+		wr := NewWriter(out)
+		wr.nonce = nonce
+		win.renderWinLang(wr, s, sess, clientLang(r))
 	}
-
-	w.Writes("</body></html>")
 }
 
 // renderComp renders just a component. 
-func (s *serverImpl) renderComp(win Window, w http.ResponseWriter, r *http.Request) {
+func (s *serverImpl) renderComp(sess Session, win Window, w http.ResponseWriter, r *http.Request) {
 	id, err := AtoID(r.FormValue(_PARAM_COMP_ID))
 	if err != nil {
 		http.Error(w, "Invalid component id!", http.StatusBadRequest)
@@ -642,12 +1312,33 @@ func (s *serverImpl) renderComp(win Window, w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if sh := sharedOf(comp); sh != nil {
+		sh.mutex.Lock()
+		defer sh.mutex.Unlock()
+	}
+
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8") // We send it as text!
-	comp.Render(NewWriter(w))
+	if tail, isTail := comp.(*appendTail); isTail {
+		// Only the items the client does not have yet are rendered
+		since, err := strconv.Atoi(r.FormValue(_PARAM_TAIL_SEQ))
+		if err != nil {
+			since = tail.seq
+		}
+		tail.renderSince(newSessWriter(w, sess), since)
+		return
+	}
+	comp.Render(newSessWriter(w, sess))
 }
 
 // handleEvent handles the event dispatching.
 func (s *serverImpl) handleEvent(sess Session, win Window, wr http.ResponseWriter, r *http.Request) {
+	if s.isStopping() {
+		// Events are not processed anymore, just notify the client
+		wr.Header().Set("Content-Type", "text/plain; charset=utf-8") // We send it as text
+		s.writeShutdown(NewWriter(wr))
+		return
+	}
+
 	focCompId, err := AtoID(r.FormValue(_PARAM_FOCUSED_COMP_ID))
 	if err == nil {
 		win.SetFocusedCompId(focCompId)
@@ -669,10 +1360,18 @@ func (s *serverImpl) handleEvent(sess Session, win Window, wr http.ResponseWrite
 	}
 
 	etype := parseIntParam(r, _PARAM_EVENT_TYPE)
-	if etype < 0 {
+	if !EventType(etype).Valid() {
 		http.Error(wr, "Invalid event type!", http.StatusBadRequest)
 		return
 	}
+
+	if effectiveAccess(comp, sess) != ACCESS_FULL || comp.inReadOnly() {
+		if s.logger != nil {
+			s.logger.Println("\tAccess denied to comp:", id)
+		}
+		http.Error(wr, "Access denied!", http.StatusForbidden)
+		return
+	}
 	if s.logger != nil {
 		s.logger.Println("\tEvent from comp:", id, " event:", etype)
 	}
@@ -692,18 +1391,67 @@ func (s *serverImpl) handleEvent(sess Session, win Window, wr http.ResponseWrite
 
 	shared.modKeys = parseIntParam(r, _PARAM_MOD_KEYS)
 	shared.keyCode = Key(parseIntParam(r, _PARAM_KEY_CODE))
+	shared.request = newRequestInfoImpl(r, s.ClientAddr(r))
+	shared.win = win
+	for name, values := range r.Form {
+		if strings.HasPrefix(name, _PARAM_COMP_VALUE_PREFIX) && len(values) > 0 {
+			if shared.syncValues == nil {
+				shared.syncValues = make(map[string]string)
+			}
+			shared.syncValues[name[len(_PARAM_COMP_VALUE_PREFIX):]] = values[0]
+		}
+	}
 
-	comp.preprocessEvent(event, r)
+	var auditRec *AuditRecord
+	if s.auditStore != nil {
+		auditRec = newAuditRecord(event, win, shared.request.RemoteAddr())
+	}
 
-	// Dispatch event...
-	comp.dispatchEvent(event)
+	// Preprocess and dispatch event (guarded by the error boundary of the comp, if any)...
+	process := func() {
+		guardEvent(event, comp, func() {
+			if comp.Id() == win.Id() {
+				win.preprocessWinEvent(event, r)
+			}
+			if err := s.checkValueSize(comp, r); err != nil {
+				// Reject it, restore the previous value in the browser
+				event.MarkDirty(comp)
+				dispatchLimitExceeded(event, comp, err)
+				return
+			}
+			if comp.checkValueVersion(event, r) {
+				comp.preprocessEvent(event, r)
+			}
+			comp.dispatchEvent(event)
+		})
+	}
+	if sh := sharedOf(comp); sh != nil {
+		// Shared comps are locked, changes are broadcast to other sessions
+		sh.process(event, process)
+	} else {
+		process()
+	}
 
-	// Check if a new session was created during event dispatching
-	if shared.session.New() {
-		s.addSessCookie(shared.session, wr)
+	if auditRec != nil {
+		auditRec.After = auditValue(comp)
+		s.auditStore.Store(auditRec)
 	}
 
+	s.checkSessionLimits(event, win)
+
 	// ...and send back the result
+	s.writeEresp(win, shared, wr, r)
+}
+
+// writeEresp writes the event response: the actions the client has to take
+// after processing an event (e.g. dirty components to re-render).
+func (s *serverImpl) writeEresp(win Window, shared *sharedEvtData, wr http.ResponseWriter, r *http.Request) {
+	// Check if a new session was created during event dispatching
+	newSess := shared.session.New()
+	if newSess {
+		s.addSessCookie(shared.session, wr, r)
+	}
+
 	wr.Header().Set("Content-Type", "text/plain; charset=utf-8") // We send it as text
 	w := NewWriter(wr)
 	hasAction := false
@@ -711,7 +1459,11 @@ func (s *serverImpl) handleEvent(sess Session, win Window, wr http.ResponseWrite
 	if shared.reload {
 		hasAction = true
 		w.Writevs(_ERA_RELOAD_WIN, _STR_COMMA, shared.reloadWin)
+		if shared.download != nil {
+			shared.download.close()
+		}
 	} else {
+		shared.coalesceDirty()
 		if len(shared.dirtyComps) > 0 {
 			hasAction = true
 			w.Writev(_ERA_DIRTY_COMPS)
@@ -730,6 +1482,39 @@ func (s *serverImpl) handleEvent(sess Session, win Window, wr http.ResponseWrite
 			// Also register focusable comp at window
 			win.SetFocusedCompId(shared.focusedComp.Id())
 		}
+		// New session: requests have to be signed for the new session
+		if newSess && s.pathSigning {
+			if hasAction {
+				w.Write(_STR_SEMICOL)
+			} else {
+				hasAction = true
+			}
+			w.Writevs(_ERA_SIG, _STR_COMMA, s.pathSig(shared.session, win.Name()))
+		}
+		if shared.download != nil {
+			if hasAction {
+				w.Write(_STR_SEMICOL)
+			} else {
+				hasAction = true
+			}
+			shared.download.sessId = shared.session.Id()
+			w.Writevs(_ERA_DOWNLOAD, _STR_COMMA, s.addDownload(shared.download))
+		}
+		// Theme switched: the client replaces the theme stylesheet
+		if win.themeChanged() {
+			if hasAction {
+				w.Write(_STR_SEMICOL)
+			} else {
+				hasAction = true
+			}
+			w.Writevs(_ERA_THEME, _STR_COMMA, s.appPath, _PATH_STATIC, s.themeRes(win.Theme()))
+		}
+	}
+	// Also deliver pushes the client has not yet received
+	if !shared.reload && len(r.FormValue(_PARAM_PUSH_SEQ)) > 0 {
+		if seq, err := strconv.Atoi(r.FormValue(_PARAM_PUSH_SEQ)); err == nil {
+			hasAction, _ = writePushes(w, win, seq, hasAction)
+		}
 	}
 	if !hasAction {
 		w.Writev(_ERA_NO_ACTION)
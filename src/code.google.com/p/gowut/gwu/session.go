@@ -58,47 +58,192 @@ type Session interface {
 	WinByName(name string) Window
 
 	// Attr returns the value of an attribute stored in the session.
+	// 
+	// Attribute accessors are safe for concurrent use: attributes are guarded
+	// by their own lock (independent from the session lock held while events are
+	// processed), so they can be used from event handlers, timers and background
+	// goroutines alike. Use UpdateAttr() to modify an attribute based on its
+	// current value (e.g. incrementing a counter), getting and setting it separately
+	// is not atomic. Note that the values themselves are not guarded, mutable
+	// values (e.g. maps) must be synchronized by their users or replaced on change.
 	// TODO use an interface type something like "serializable".
 	Attr(name string) interface{}
 
+	// AttrOk returns the value of an attribute stored in the session,
+	// and tells if the attribute is set.
+	AttrOk(name string) (value interface{}, ok bool)
+
+	// AttrString returns the value of a string attribute stored in the session.
+	// An empty string is returned if the attribute is not set or is not a string.
+	AttrString(name string) string
+
+	// AttrInt returns the value of an int attribute stored in the session.
+	// 0 is returned if the attribute is not set or is not an int.
+	AttrInt(name string) int
+
 	// SetAttr sets the value of an attribute stored in the session.
 	// Pass the nil value to delete the attribute.
 	SetAttr(name string, value interface{})
 
+	// UpdateAttr atomically updates an attribute stored in the session:
+	// f is called with the current value of the attribute (nil if not set),
+	// and the value returned by f is stored (nil deletes the attribute).
+	// The new value is returned.
+	// f must not access the attributes of the session, else it will deadlock.
+	UpdateAttr(name string, f func(value interface{}) interface{}) interface{}
+
+	// AttrNames returns the names of the attributes stored in the session.
+	AttrNames() []string
+
 	// Created returns the time when the session was created.
 	Created() time.Time
 
 	// Accessed returns the time when the session was last accessed.
 	Accessed() time.Time
 
+	// User returns the name of the user the session belongs to.
+	// Empty string is returned if the user is not known
+	// (e.g. for the public session).
+	User() string
+
+	// SetUser sets the name of the user the session belongs to.
+	// It is set automatically if the server has an authenticator
+	// (see Server.SetAuthenticator()), else you can set it
+	// when the user logs in.
+	SetUser(user string)
+
+	// Roles returns the roles of the user the session belongs to.
+	Roles() []string
+
+	// SetRoles sets the roles of the user the session belongs to,
+	// typically when the user logs in or is authenticated.
+	// Roles are used to determine the access to components
+	// (see Comp.SetVisibleForRoles()).
+	SetRoles(roles ...string)
+
+	// HasRole tells if the user the session belongs to has the specified role.
+	HasRole(role string) bool
+
+	// Principal returns the identity of the logged-in user of the session.
+	// nil is returned if no principal is set.
+	Principal() Principal
+
+	// SetPrincipal sets the identity of the logged-in user of the session,
+	// typically when the user logs in. The user and roles of the session
+	// are also set from the principal (see User() and Roles()).
+	// Pass nil to clear the identity (e.g. when the user logs out).
+	SetPrincipal(p Principal)
+
+	// RemoteAddr returns the IP address of the client which
+	// accessed the session last.
+	// Requests coming through trusted proxies are taken into account
+	// (see Server.SetTrustedProxies()).
+	RemoteAddr() string
+
 	// Timeout returns the session timeout.
 	Timeout() time.Duration
 
 	// SetTimeout sets the session timeout.
 	SetTimeout(timeout time.Duration)
 
-	// access registers an access to the session.
-	access()
+	// Usage returns the resource usage of the session: the number of its
+	// windows and components, and its approximate memory footprint.
+	// Components are counted by walking the component trees of the windows,
+	// so it should be called holding the session lock (from event handlers
+	// or from the function passed to Push()).
+	Usage() SessionUsage
+
+	// Uploaded returns the number of bytes uploaded (or being uploaded)
+	// in the session, counted against the upload quota
+	// (see Server.SetUploadQuota()).
+	Uploaded() int64
+
+	// Push executes f with exclusive access to the session (and to
+	// its windows and components), and delivers the changes made in f
+	// to the clients (server push).
+	// This is how components can be modified from outside of event
+	// handlers, e.g. from background goroutines.
+	// 
+	// The event passed to f can be used to mark components dirty,
+	// and to request windows to be reloaded (pass an empty string to
+	// ReloadWin() to reload all windows of the session).
+	// The source of the event is nil, and it has no mouse and key info.
+	// Creating or removing sessions through the event is not supported.
+	// 
+	// Dirty components are re-rendered in the browser immediately
+	// if the push channel of their window is enabled (see Window.SetPushEnabled()),
+	// else they are re-rendered after the next event sent from the window.
+	// 
+	// Since the Server is a Session (the public session), calling Push()
+	// on the Server delivers changes of public windows.
+	// 
+	// Push must not be called from event handlers (or from f),
+	// else it will deadlock.
+	Push(f func(e Event))
+
+	// access registers an access to the session
+	// from the specified client address.
+	access(remoteAddr string)
 
 	// ClearNew clears the new flag.
 	// After this New() will return false.
 	clearNew()
 
+	// pin returns the pin of the session, the fingerprint of the client
+	// attributes the session is bound to.
+	pin() string
+
+	// setPin sets the pin of the session.
+	setPin(pin string)
+
+	// pushChanges pushes the changes (dirty components, window reloads)
+	// recorded in the specified shared event data to the clients.
+	pushChanges(shared *sharedEvtData)
+
+	// compWin returns the window of the session the specified component is added to.
+	// nil is returned if the component is not added to a window of the session.
+	compWin(c Comp) Window
+
 	// rwMutex returns the RW mutex of the session.
 	rwMutex() *sync.RWMutex
+
+	// reserveUpload reserves the specified number of bytes from the upload quota.
+	reserveUpload(size, quota int64) bool
+
+	// addSharedView registers a view of a shared content displayed in the session.
+	addSharedView(v *sharedViewImpl)
+
+	// removeSharedView unregisters a view of a shared content.
+	removeSharedView(v *sharedViewImpl)
+
+	// detachSharedViews removes the registered views from their shared contents,
+	// so they are not retained by the shared contents after the session is removed.
+	detachSharedViews()
 }
 
 // Session implementation.
 type sessionImpl struct {
-	id       string                 // Id of the session
-	isNew    bool                   // Tells if the session is new
-	created  time.Time              // Creation time
-	accessed time.Time              // Last accessed time
-	windows  map[string]Window      // Windows of the session
-	attrs    map[string]interface{} // Attributes stored in the session
-	timeout  time.Duration          // Session timeout
-
-	rwMutex_ *sync.RWMutex // RW mutex to synchronize session (and related Window and component) access
+	uploaded   int64                  // Bytes uploaded in the session (first for 64-bit atomic alignment)
+	id         string                 // Id of the session
+	isNew      bool                   // Tells if the session is new
+	created    time.Time              // Creation time
+	accessed   time.Time              // Last accessed time
+	remoteAddr string                 // Address of the client which accessed the session last
+	user       string                 // Name of the user the session belongs to
+	roles      []string               // Roles of the user the session belongs to
+	principal  Principal              // Identity of the logged-in user
+	windows    map[string]Window      // Windows of the session
+	attrs      map[string]interface{} // Attributes stored in the session
+	timeout    time.Duration          // Session timeout
+	pin_       string                 // Fingerprint of the client attributes the session is pinned to
+
+	sharedViews      []*sharedViewImpl // Views of shared contents displayed in the session
+	sharedViewsMutex *sync.Mutex       // Mutex to synchronize shared views access
+
+	rwMutex_       *sync.RWMutex // RW mutex to synchronize session (and related Window and component) access
+	attrMutex      *sync.RWMutex // RW mutex to synchronize attribute access
+	principalMutex *sync.RWMutex // RW mutex to synchronize user, roles and principal access (access checks are made unlocked)
+	server         *serverImpl   // The server the session belongs to
 }
 
 // newSessionImpl creates a new sessionImpl.
@@ -114,7 +259,8 @@ func newSessionImpl(private bool) sessionImpl {
 
 	// Initialzie private sessions as new, but not the public session
 	return sessionImpl{id: id, isNew: private, created: now, accessed: now, windows: make(map[string]Window),
-		attrs: make(map[string]interface{}), timeout: 30 * time.Minute, rwMutex_: &sync.RWMutex{}}
+		attrs: make(map[string]interface{}), timeout: 30 * time.Minute, rwMutex_: &sync.RWMutex{}, attrMutex: &sync.RWMutex{},
+		principalMutex: &sync.RWMutex{}, sharedViewsMutex: &sync.Mutex{}}
 }
 
 // Number of valid id runes.
@@ -207,10 +353,37 @@ func (s *sessionImpl) WinByName(name string) Window {
 }
 
 func (s *sessionImpl) Attr(name string) interface{} {
+	s.attrMutex.RLock()
+	defer s.attrMutex.RUnlock()
 	return s.attrs[name]
 }
 
+func (s *sessionImpl) AttrOk(name string) (value interface{}, ok bool) {
+	s.attrMutex.RLock()
+	defer s.attrMutex.RUnlock()
+	value, ok = s.attrs[name]
+	return
+}
+
+func (s *sessionImpl) AttrString(name string) string {
+	value, _ := s.Attr(name).(string)
+	return value
+}
+
+func (s *sessionImpl) AttrInt(name string) int {
+	value, _ := s.Attr(name).(int)
+	return value
+}
+
 func (s *sessionImpl) SetAttr(name string, value interface{}) {
+	s.attrMutex.Lock()
+	defer s.attrMutex.Unlock()
+	s.setAttr(name, value)
+}
+
+// setAttr sets the value of an attribute.
+// The attribute mutex must be locked.
+func (s *sessionImpl) setAttr(name string, value interface{}) {
 	if value == nil {
 		delete(s.attrs, name)
 	} else {
@@ -218,6 +391,25 @@ func (s *sessionImpl) SetAttr(name string, value interface{}) {
 	}
 }
 
+func (s *sessionImpl) UpdateAttr(name string, f func(value interface{}) interface{}) interface{} {
+	s.attrMutex.Lock()
+	defer s.attrMutex.Unlock()
+	value := f(s.attrs[name])
+	s.setAttr(name, value)
+	return value
+}
+
+func (s *sessionImpl) AttrNames() []string {
+	s.attrMutex.RLock()
+	defer s.attrMutex.RUnlock()
+	names := make([]string, 0, len(s.attrs))
+	for name := range s.attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (s *sessionImpl) Created() time.Time {
 	return s.created
 }
@@ -234,14 +426,62 @@ func (s *sessionImpl) SetTimeout(timeout time.Duration) {
 	s.timeout = timeout
 }
 
-func (s *sessionImpl) access() {
+func (s *sessionImpl) User() string {
+	s.principalMutex.RLock()
+	defer s.principalMutex.RUnlock()
+	return s.user
+}
+
+func (s *sessionImpl) SetUser(user string) {
+	s.principalMutex.Lock()
+	defer s.principalMutex.Unlock()
+	s.user = user
+}
+
+func (s *sessionImpl) Roles() []string {
+	s.principalMutex.RLock()
+	defer s.principalMutex.RUnlock()
+	return s.roles
+}
+
+func (s *sessionImpl) SetRoles(roles ...string) {
+	s.principalMutex.Lock()
+	defer s.principalMutex.Unlock()
+	s.roles = roles
+}
+
+func (s *sessionImpl) HasRole(role string) bool {
+	s.principalMutex.RLock()
+	defer s.principalMutex.RUnlock()
+	for _, r := range s.roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sessionImpl) RemoteAddr() string {
+	return s.remoteAddr
+}
+
+func (s *sessionImpl) access(remoteAddr string) {
 	s.accessed = time.Now()
+	s.remoteAddr = remoteAddr
 }
 
 func (s *sessionImpl) clearNew() {
 	s.isNew = false
 }
 
+func (s *sessionImpl) pin() string {
+	return s.pin_
+}
+
+func (s *sessionImpl) setPin(pin string) {
+	s.pin_ = pin
+}
+
 func (s *sessionImpl) rwMutex() *sync.RWMutex {
 	return s.rwMutex_
 }
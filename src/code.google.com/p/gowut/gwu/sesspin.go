@@ -0,0 +1,101 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Session pinning: binding sessions to client attributes.
+
+package gwu
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Session pinning attribute type.
+type SessPin int
+
+// Session pinning attributes (masks).
+const (
+	SESS_PIN_IP         SessPin = 1 << iota // Pin sessions to the client IP address
+	SESS_PIN_TLS_CERT                       // Pin sessions to the TLS client certificate
+	SESS_PIN_USER_AGENT                     // Pin sessions to the User-Agent header
+
+	SESS_PIN_NONE SessPin = 0 // No session pinning
+)
+
+func (s *serverImpl) SessPinning() SessPin {
+	return s.sessPinning
+}
+
+func (s *serverImpl) SetSessPinning(pinning SessPin) {
+	s.sessPinning = pinning
+}
+
+func (s *serverImpl) SessPinHeaders() []string {
+	return s.sessPinHeaders
+}
+
+func (s *serverImpl) SetSessPinHeaders(headers ...string) {
+	s.sessPinHeaders = headers
+}
+
+// sessPin computes the pin of a session from the client attributes
+// of the request.
+// Empty string is returned if session pinning is disabled.
+func (s *serverImpl) sessPin(r *http.Request) string {
+	if s.sessPinning == SESS_PIN_NONE && len(s.sessPinHeaders) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	if s.sessPinning&SESS_PIN_IP != 0 {
+		h.Write([]byte(s.ClientAddr(r)))
+	}
+	h.Write([]byte{0})
+	if s.sessPinning&SESS_PIN_TLS_CERT != 0 && r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		h.Write(r.TLS.PeerCertificates[0].Raw)
+	}
+	h.Write([]byte{0})
+	if s.sessPinning&SESS_PIN_USER_AGENT != 0 {
+		h.Write([]byte(r.UserAgent()))
+	}
+	for _, header := range s.sessPinHeaders {
+		h.Write([]byte{0})
+		h.Write([]byte(r.Header.Get(header)))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// checkSessPin checks if the client attributes of the request match the pin
+// of the specified private session. If they do not match, the session is
+// removed (invalidated), and false is returned.
+func (s *serverImpl) checkSessPin(sess Session, r *http.Request) bool {
+	pin := sess.pin()
+	if len(pin) == 0 {
+		// Session was created while pinning was disabled
+		return true
+	}
+
+	if s.sessPin(r) == pin {
+		return true
+	}
+
+	if s.logger != nil {
+		s.logger.Println("SESSION pin mismatch, invalidating session:", sess.Id(), "from", s.ClientAddr(r))
+	}
+	s.removeSess2(sess, SESS_REMOVE_INVALIDATED)
+	return false
+}
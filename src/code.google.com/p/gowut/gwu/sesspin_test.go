@@ -0,0 +1,68 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessPin(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	s.SetSessPinning(SESS_PIN_IP | SESS_PIN_USER_AGENT)
+	s.AddWin(NewWindow("main", "Main"))
+
+	newReq := func(addr, ua string, sess Session) *http.Request {
+		r := httptest.NewRequest("GET", "/main", nil)
+		r.RemoteAddr = addr
+		r.Header.Set("User-Agent", ua)
+		r.AddCookie(&http.Cookie{Name: _GWU_SESSID_COOKIE, Value: sess.Id()})
+		return r
+	}
+
+	sess := s.newSession(nil)
+	sess.setPin(s.sessPin(newReq("1.2.3.4:1000", "browser", sess)))
+
+	// Same client (port may change)
+	if !s.checkSessPin(sess, newReq("1.2.3.4:2000", "browser", sess)) || s.sessionById(sess.Id()) != sess {
+		t.Fatal("Expected the session to be kept for the same client")
+	}
+
+	// Stolen cookie used by another client
+	s.serveHTTP(httptest.NewRecorder(), newReq("1.2.3.4:1000", "other-browser", sess))
+	if s.sessionById(sess.Id()) != nil {
+		t.Error("Expected the session to be invalidated on User-Agent mismatch")
+	}
+
+	sess = s.newSession(nil)
+	sess.setPin(s.sessPin(newReq("1.2.3.4:1000", "browser", sess)))
+	if s.checkSessPin(sess, newReq("5.6.7.8:1000", "browser", sess)) || s.sessionById(sess.Id()) != nil {
+		t.Error("Expected the session to be invalidated on IP mismatch")
+	}
+}
+
+func TestSessPinUnpinned(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	s.SetSessPinning(SESS_PIN_IP)
+
+	// Sessions created while pinning was disabled are not checked
+	sess := s.newSession(nil)
+	r := httptest.NewRequest("GET", "/", nil)
+	if !s.checkSessPin(sess, r) {
+		t.Error("Expected unpinned session to pass")
+	}
+}
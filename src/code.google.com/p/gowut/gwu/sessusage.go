@@ -0,0 +1,163 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Session resource usage accounting and limits.
+
+package gwu
+
+// Approximate memory footprint of a component in bytes, without its text.
+const _COMP_FOOTPRINT = 1024
+
+// Approximate memory footprint of an item of a component
+// (e.g. a tree node or a log line) in bytes, without its text.
+const _ITEM_FOOTPRINT = 64
+
+// SessionUsage is the resource usage of a session (see Session.Usage()).
+type SessionUsage struct {
+	Windows int   // Number of windows
+	Comps   int   // Number of components in the windows (including the shown dialogs and retained components, e.g. cached views of routers)
+	Memory  int64 // Approximate memory footprint in bytes (components, retained content e.g. lines of log views, and string attributes)
+}
+
+// SessionLimitHandler is called when a session exceeds a limit
+// (see Server.SetMaxSessionComps() and Server.SetMaxSessionMemory()),
+// e.g. to log a warning or to release cached content.
+// It returns if the session has to be evicted (removed).
+type SessionLimitHandler func(sess Session, err *LimitError) (evict bool)
+
+func (s *sessionImpl) Usage() SessionUsage {
+	u := SessionUsage{Windows: len(s.windows)}
+
+	var count func(path string, c Comp)
+	count = func(path string, c Comp) {
+		u.Comps++
+		u.Memory += _COMP_FOOTPRINT
+		if t, ok := c.(HasText); ok {
+			u.Memory += int64(len(t.Text()))
+		}
+		comps, memory := c.usage()
+		u.Memory += memory
+		for _, c2 := range comps {
+			walkComps(c2, "", count)
+		}
+	}
+	for _, win := range s.windows {
+		walkComps(win, "", count)
+		for _, d := range win.Dialogs() {
+			walkComps(d, "", count)
+		}
+	}
+
+	s.attrMutex.RLock()
+	for name, value := range s.attrs {
+		u.Memory += int64(len(name))
+		if str, ok := value.(string); ok {
+			u.Memory += int64(len(str))
+		}
+	}
+	s.attrMutex.RUnlock()
+
+	return u
+}
+
+func (s *serverImpl) MaxSessionComps() int {
+	return s.maxSessionComps
+}
+
+func (s *serverImpl) SetMaxSessionComps(max int) {
+	s.maxSessionComps = max
+}
+
+func (s *serverImpl) MaxSessionMemory() int64 {
+	return s.maxSessionMemory
+}
+
+func (s *serverImpl) SetMaxSessionMemory(max int64) {
+	s.maxSessionMemory = max
+}
+
+func (s *serverImpl) SetSessionLimitHandler(handler SessionLimitHandler) {
+	s.sessLimitHandler = handler
+}
+
+// sessionLimitError returns the error describing the exceeded session limit
+// if the specified session exceeds one, else nil.
+// The session must be locked.
+func (s *serverImpl) sessionLimitError(sess Session) *LimitError {
+	if !sess.Private() || s.maxSessionComps <= 0 && s.maxSessionMemory <= 0 {
+		return nil
+	}
+
+	u := sess.Usage()
+	if s.maxSessionComps > 0 && u.Comps > s.maxSessionComps {
+		return &LimitError{Limit: LIMIT_SESSION_COMPS, Size: int64(u.Comps), Max: int64(s.maxSessionComps)}
+	}
+	if s.maxSessionMemory > 0 && u.Memory > s.maxSessionMemory {
+		return &LimitError{Limit: LIMIT_SESSION_MEMORY, Size: u.Memory, Max: s.maxSessionMemory}
+	}
+	return nil
+}
+
+// overLimit tells if the specified session exceeding a limit has to be evicted.
+func (s *serverImpl) overLimit(sess Session, err *LimitError) bool {
+	if s.logger != nil {
+		s.logger.Println("SESSION over limit:", sess.Id(), err)
+	}
+	return s.sessLimitHandler == nil || s.sessLimitHandler(sess, err)
+}
+
+// checkSessionLimits checks the limits of the session of the specified event
+// (processed in the specified window), and evicts the session if it has to be.
+func (s *serverImpl) checkSessionLimits(e *eventImpl, win Window) {
+	sess := e.shared.session
+	if err := s.sessionLimitError(sess); err != nil && s.overLimit(sess, err) {
+		s.removeSess(e, SESS_REMOVE_LIMIT)
+		e.ReloadWin(win.Name())
+	}
+}
+
+// checkSessionsLimits checks the limits of all sessions, and evicts the sessions
+// which have to be. It catches sessions grown by pushes.
+func (s *serverImpl) checkSessionsLimits() {
+	if s.maxSessionComps <= 0 && s.maxSessionMemory <= 0 {
+		return
+	}
+
+	for _, sess := range s.sessionList() {
+		rwMutex := sess.rwMutex()
+		rwMutex.RLock()
+		err := s.sessionLimitError(sess)
+		rwMutex.RUnlock()
+
+		if err != nil && s.overLimit(sess, err) {
+			s.removeSess2(sess, SESS_REMOVE_LIMIT)
+		}
+	}
+}
+
+func (c *compImpl) usage() ([]Comp, int64) {
+	return nil, 0
+}
+
+// usageComps returns the non-nil ones of the specified components.
+func usageComps(comps ...Comp) []Comp {
+	var nonNil []Comp
+	for _, c := range comps {
+		if c != nil {
+			nonNil = append(nonNil, c)
+		}
+	}
+	return nonNil
+}
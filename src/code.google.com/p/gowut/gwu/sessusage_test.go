@@ -0,0 +1,61 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"testing"
+)
+
+func TestUsageRetained(t *testing.T) {
+	sess := newSessionImpl(true)
+	win := NewWindow("main", "Main")
+	sess.AddWin(win)
+
+	usage := sess.Usage
+	base := usage()
+
+	// Cached views of a router are counted, not just the current one
+	r := NewRouter()
+	for _, path := range []string{"a", "b", "c"} {
+		r.AddRoute(path, func(e Event, path string) Comp { return NewLabel(path) })
+	}
+	win.Add(r)
+	r.Navigate("a", nil)
+	r.Navigate("b", nil)
+	r.Navigate("c", nil)
+	if u := usage(); u.Comps != base.Comps+4 {
+		t.Errorf("Expected comps: %d, got: %d", base.Comps+4, u.Comps)
+	}
+
+	// Cards of a board are counted
+	b := NewBoard()
+	win.Add(b)
+	before := usage()
+	b.AddColumn("todo")
+	b.AddCard(0, NewLabel("card"))
+	if u := usage(); u.Comps != before.Comps+1 {
+		t.Errorf("Expected comps: %d, got: %d", before.Comps+1, u.Comps)
+	}
+
+	// Buffered lines are counted
+	lv := NewLogView()
+	win.Add(lv)
+	before = usage()
+	lv.AppendLines(nil, string(make([]byte, 1000)))
+	if u := usage(); u.Memory < before.Memory+1000 {
+		t.Errorf("Lines not counted: %d, before: %d", u.Memory, before.Memory)
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Collaborative mode: component trees shared across multiple sessions.
+
+package gwu
+
+import (
+	"sync"
+)
+
+// Shared interface defines a component tree which is shared across
+// multiple sessions, e.g. a shared whiteboard or status board.
+//
+// The shared content is displayed in the windows of the sessions by views
+// created by NewView(). When a session changes the shared content (a value of a
+// shared input is synchronized or shared components are marked dirty during
+// event handling), the changes are broadcast to the views of the other sessions
+// over the push channel (see Window.SetPushEnabled()).
+//
+// Events of shared components are processed one at a time (the shared content is
+// locked during processing). Concurrent edits of the same value are resolved
+// by last-writer-wins: the value synchronized last is kept and broadcast.
+type Shared interface {
+	// Content returns the shared content.
+	Content() Comp
+
+	// NewView creates a view of the shared content for the specified session.
+	// The view is a component which has to be added to a window of the session.
+	// A session should have at most one view of a shared content.
+	NewView(sess Session) Comp
+
+	// RemoveView removes a view, the shared changes are no longer broadcast to it.
+	// Views removed from their window and views of removed sessions
+	// are also removed automatically.
+	RemoveView(view Comp)
+
+	// Update modifies the shared content outside of event handling
+	// (e.g. from a background goroutine): it calls f with the content locked,
+	// then broadcasts the specified dirty components to all views.
+	// Must not be called from event handlers of shared components.
+	Update(f func(), dirty ...Comp)
+}
+
+// Shared implementation.
+// It is the parent of the shared content.
+type sharedImpl struct {
+	compImpl // Component implementation
+
+	content Comp       // The shared content
+	mutex   sync.Mutex // Mutex to lock the shared content
+
+	views      []*sharedViewImpl // Views of the shared content
+	viewsMutex sync.Mutex        // Mutex to synchronize views access
+}
+
+// Shared view implementation.
+type sharedViewImpl struct {
+	compImpl // Component implementation
+
+	shared *sharedImpl // The shared content
+	sess   Session     // Session of the view
+	win    Window      // Window the view was last rendered in, guarded by the views mutex of shared
+}
+
+// NewShared creates a new Shared for the specified content.
+func NewShared(content Comp) Shared {
+	c := &sharedImpl{compImpl: newCompImpl(nil), content: content}
+	content.makeOrphan()
+	content.setParent(c)
+	return c
+}
+
+func (c *sharedImpl) Remove(c2 Comp) bool {
+	return false // Shared content cannot be removed
+}
+
+func (c *sharedImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	if c.content.Id() == id {
+		return c.content
+	}
+	if c2, isContainer := c.content.(Container); isContainer {
+		return c2.ById(id)
+	}
+	return nil
+}
+
+func (c *sharedImpl) Clear() {
+}
+
+func (c *sharedImpl) Render(w writer) {
+	c.content.Render(w)
+}
+
+func (c *sharedImpl) Content() Comp {
+	return c.content
+}
+
+func (c *sharedImpl) NewView(sess Session) Comp {
+	v := &sharedViewImpl{compImpl: newCompImpl(nil), shared: c, sess: sess}
+	v.Style().AddClass("gwu-Shared")
+
+	c.addView(v)
+	return v
+}
+
+// addView adds a view to the views changes are broadcast to,
+// if it is not added already.
+func (c *sharedImpl) addView(view *sharedViewImpl) {
+	c.viewsMutex.Lock()
+	defer c.viewsMutex.Unlock()
+
+	for _, v := range c.views {
+		if v == view {
+			return
+		}
+	}
+	c.views = append(c.views, view)
+	if view.sess != nil {
+		view.sess.addSharedView(view)
+	}
+}
+
+func (c *sharedImpl) RemoveView(view Comp) {
+	c.viewsMutex.Lock()
+	defer c.viewsMutex.Unlock()
+
+	for i, v := range c.views {
+		if v.Equals(view) {
+			c.views = append(c.views[:i], c.views[i+1:]...)
+			v.win = nil
+			if v.sess != nil {
+				v.sess.removeSharedView(v)
+			}
+			return
+		}
+	}
+}
+
+func (c *sharedImpl) Update(f func(), dirty ...Comp) {
+	c.mutex.Lock()
+	f()
+	c.mutex.Unlock()
+
+	c.broadcast(nil, dirty)
+}
+
+// process processes an event of a shared component with the shared content locked,
+// and broadcasts the changes to the views of the other sessions.
+// The source of the event is also broadcast as its value might have changed.
+func (c *sharedImpl) process(e *eventImpl, f func()) {
+	c.mutex.Lock()
+	func() {
+		defer c.mutex.Unlock()
+		f()
+	}()
+
+	changed := []Comp{e.src}
+	e.shared.coalesceDirty()
+	for _, comp := range e.shared.dirtyComps {
+		if sharedOf(comp) == c && !comp.Equals(e.src) {
+			changed = append(changed, comp)
+		}
+	}
+	c.broadcast(e.shared.session, changed)
+}
+
+// broadcast marks the specified components dirty in the views of all sessions
+// except the specified one (which may be nil).
+func (c *sharedImpl) broadcast(except Session, comps []Comp) {
+	if len(comps) == 0 {
+		return
+	}
+
+	ids := make([]ID, len(comps))
+	for i, comp := range comps {
+		ids[i] = comp.Id()
+	}
+
+	// Push queues are synchronized on their own, so the sessions
+	// of the views don't have to be locked (nor waited for).
+	c.viewsMutex.Lock()
+	defer c.viewsMutex.Unlock()
+
+	for _, v := range c.views {
+		// Views not yet rendered display the current state when rendered
+		if v.sess != except && v.win != nil {
+			v.win.pushQueue().add(ids, false)
+		}
+	}
+}
+
+// viewOf returns the view of the shared content of the specified session.
+// Returns nil if the session has no view.
+func (c *sharedImpl) viewOf(sess Session) Comp {
+	c.viewsMutex.Lock()
+	defer c.viewsMutex.Unlock()
+
+	for _, v := range c.views {
+		if v.sess == sess {
+			return v
+		}
+	}
+	return nil
+}
+
+// sharedOf returns the shared content the specified component belongs to.
+// Returns nil if the component is not part of a shared content.
+func sharedOf(c Comp) *sharedImpl {
+	for p := c.Parent(); p != nil; p = p.Parent() {
+		if sh, isShared := p.(*sharedImpl); isShared {
+			return sh
+		}
+	}
+	return nil
+}
+
+func (c *sharedViewImpl) setParent(parent Container) {
+	c.compImpl.setParent(parent)
+
+	// Detach the view when it is removed, and attach it again if it is re-added
+	if parent == nil {
+		c.shared.RemoveView(c)
+	} else {
+		c.shared.addView(c)
+	}
+}
+
+func (c *sharedViewImpl) Remove(c2 Comp) bool {
+	return false // Shared content cannot be removed
+}
+
+func (c *sharedViewImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+	return c.shared.ById(id)
+}
+
+func (c *sharedViewImpl) Clear() {
+}
+
+func (c *sharedViewImpl) Render(w writer) {
+	// The session of the view is locked, record the window pushes go to
+	if w.sess != nil {
+		win := w.sess.compWin(c)
+		c.shared.viewsMutex.Lock()
+		c.win = win
+		c.shared.viewsMutex.Unlock()
+	}
+
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	c.shared.mutex.Lock()
+	c.shared.content.Render(w)
+	c.shared.mutex.Unlock()
+
+	w.Write(_STR_DIV_CL)
+}
+
+func (s *sessionImpl) addSharedView(v *sharedViewImpl) {
+	s.sharedViewsMutex.Lock()
+	defer s.sharedViewsMutex.Unlock()
+
+	s.sharedViews = append(s.sharedViews, v)
+}
+
+func (s *sessionImpl) removeSharedView(v *sharedViewImpl) {
+	s.sharedViewsMutex.Lock()
+	defer s.sharedViewsMutex.Unlock()
+
+	for i, v2 := range s.sharedViews {
+		if v2 == v {
+			s.sharedViews = append(s.sharedViews[:i], s.sharedViews[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *sessionImpl) detachSharedViews() {
+	// RemoveView() unregisters the view, so the session must not be locked
+	s.sharedViewsMutex.Lock()
+	views := make([]*sharedViewImpl, len(s.sharedViews))
+	copy(views, s.sharedViews)
+	s.sharedViewsMutex.Unlock()
+
+	for _, v := range views {
+		v.shared.RemoveView(v)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"testing"
+)
+
+func TestSharedViewsOfRemovedSession(t *testing.T) {
+	s := newServerImpl("", "", "", "")
+	sh := NewShared(NewLabel("shared")).(*sharedImpl)
+
+	sess := s.newSession(nil)
+	win := NewWindow("main", "Main")
+	sess.AddWin(win)
+	win.Add(sh.NewView(sess))
+	if sh.viewOf(sess) == nil {
+		t.Fatalf("View not registered")
+	}
+
+	s.removeSess2(sess, SESS_REMOVE_LOGOUT)
+	if sh.viewOf(sess) != nil {
+		t.Errorf("View of removed session retained")
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Graceful shutdown and session persistence.
+
+package gwu
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// SessionData is the persisted state of a session, saved by Server.Shutdown()
+// into the session store of the server (see Server.SetSessionStore()).
+//
+// Windows and components of sessions are not persisted (they may hold
+// arbitrary values and functions); when a session is resumed, session
+// handlers are notified (see SessionHandler.Created()) so they can rebuild
+// the windows, and then the state of windows having the same names is restored
+// from their snapshots (see Window.Snapshot()).
+type SessionData struct {
+	Id      string                 // Id of the session
+	Pin     string                 // Fingerprint of the client attributes the session is pinned to
+	User    string                 // Name of the user the session belongs to
+	Roles   []string               // Roles of the user the session belongs to
+	Timeout time.Duration          // Session timeout
+	Created time.Time              // Creation time
+	Attrs   map[string]interface{} // Attributes of the session (only the ones encodable by encoding/gob)
+	Windows map[string]Snapshot    // Snapshots of the windows, mapped from their names
+}
+
+// SessionStore interface defines a persistent store of sessions.
+type SessionStore interface {
+	// Save saves the specified sessions, replacing the previously saved ones.
+	Save(sessions []*SessionData) error
+
+	// Load loads the saved sessions.
+	// The store should be emptied, so sessions are resumed only once.
+	Load() ([]*SessionData, error)
+}
+
+// File SessionStore implementation.
+type fileSessionStore struct {
+	path string // Path of the file
+}
+
+// NewFileSessionStore creates a new SessionStore which saves sessions
+// into the specified file, encoded by encoding/gob.
+// Custom types stored in session attributes have to be registered
+// with gob.Register() to be persisted.
+func NewFileSessionStore(path string) SessionStore {
+	return &fileSessionStore{path: path}
+}
+
+func (st *fileSessionStore) Save(sessions []*SessionData) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sessions); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(st.path, buf.Bytes(), 0600)
+}
+
+func (st *fileSessionStore) Load() ([]*SessionData, error) {
+	data, err := ioutil.ReadFile(st.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	os.Remove(st.path)
+
+	var sessions []*SessionData
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (s *serverImpl) SessionStore() SessionStore {
+	return s.sessionStore
+}
+
+func (s *serverImpl) SetSessionStore(store SessionStore) {
+	s.sessionStore = store
+}
+
+func (s *serverImpl) ShutdownText() string {
+	return s.shutdownText
+}
+
+func (s *serverImpl) SetShutdownText(text string) {
+	s.shutdownText = text
+}
+
+func (s *serverImpl) Stop(ctx context.Context) error {
+	return s.stop(ctx, false)
+}
+
+func (s *serverImpl) Shutdown(ctx context.Context) error {
+	return s.stop(ctx, true)
+}
+
+// isStopping tells if the server is stopping (or stopped).
+func (s *serverImpl) isStopping() bool {
+	return atomic.LoadInt32(&s.stopFlag) != 0
+}
+
+// stop stops the server: stops accepting events, notifies the connected clients,
+// shuts down the HTTP server, optionally saves the sessions into the session store,
+// then removes the sessions.
+func (s *serverImpl) stop(ctx context.Context, persist bool) error {
+	atomic.StoreInt32(&s.stopFlag, 1)
+
+	// Wake up pending push requests so they notify the clients and don't delay the shutdown
+	s.stopOnce.Do(func() {
+		close(s.stopping)
+	})
+
+	s.runningMutex.Lock()
+	hs := s.running
+	s.runningMutex.Unlock()
+
+	var err error
+	if hs != nil {
+		err = hs.Shutdown(ctx)
+	}
+
+	if persist && s.sessionStore != nil {
+		if err2 := s.sessionStore.Save(s.sessionsData()); err2 != nil {
+			if s.logger != nil {
+				s.logger.Println("Failed to save sessions:", err2)
+			}
+			if err == nil {
+				err = err2
+			}
+		}
+	}
+
+	// Close sessions cleanly. Without an HTTP server to shut down (see Handler())
+	// requests may still add sessions, so iterate over a snapshot.
+	for _, sess := range s.sessionList() {
+		s.removeSess2(sess, SESS_REMOVE_STOP)
+	}
+
+	if s.logger != nil {
+		s.logger.Println("GUI server stopped:", s.appUrl)
+	}
+	return err
+}
+
+// sessionsData returns the persistable state of the private sessions.
+func (s *serverImpl) sessionsData() []*SessionData {
+	list := s.sessionList()
+	sessions := make([]*SessionData, 0, len(list))
+	for _, sess := range list {
+		si, ok := sess.(*sessionImpl)
+		if !ok {
+			continue
+		}
+
+		rwMutex := si.rwMutex()
+		rwMutex.RLock()
+		sd := &SessionData{Id: si.id, Pin: si.pin_, User: si.User(), Roles: si.Roles(), Timeout: si.timeout,
+			Created: si.created, Attrs: make(map[string]interface{}), Windows: make(map[string]Snapshot)}
+		si.attrMutex.RLock()
+		for name, value := range si.attrs {
+			// Only keep attributes encodable by gob
+			if gob.NewEncoder(ioutil.Discard).Encode(&value) == nil {
+				sd.Attrs[name] = value
+			}
+		}
+		si.attrMutex.RUnlock()
+		for name, win := range si.windows {
+			sd.Windows[name] = win.Snapshot()
+		}
+		rwMutex.RUnlock()
+
+		sessions = append(sessions, sd)
+	}
+	return sessions
+}
+
+// resumeSessions resumes the sessions saved into the session store.
+func (s *serverImpl) resumeSessions() {
+	if s.sessionStore == nil {
+		return
+	}
+
+	sessions, err := s.sessionStore.Load()
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Println("Failed to load sessions:", err)
+		}
+		return
+	}
+
+	for _, sd := range sessions {
+		if len(sd.Id) == 0 || s.sessionById(sd.Id) != nil {
+			continue
+		}
+
+		sessImpl := newSessionImpl(true)
+		sessImpl.server = s
+		sessImpl.id, sessImpl.pin_, sessImpl.user, sessImpl.roles = sd.Id, sd.Pin, sd.User, sd.Roles
+		sessImpl.isNew = false // The client knows about it
+		sessImpl.created = sd.Created
+		if sd.Timeout > 0 {
+			sessImpl.timeout = sd.Timeout
+		}
+		for name, value := range sd.Attrs {
+			sessImpl.attrs[name] = value
+		}
+		sess := &sessImpl
+		s.sessionsMutex.Lock()
+		s.sessions[sess.Id()] = sess
+		s.sessionsMutex.Unlock()
+
+		if s.logger != nil {
+			s.logger.Println("SESSION resumed:", sess.Id())
+		}
+
+		// Let session handlers rebuild the windows...
+		s.notifyCreated(sess)
+		// ...and restore their state
+		for name, snapshot := range sd.Windows {
+			if win := sess.WinByName(name); win != nil {
+				win.Restore(snapshot)
+			}
+		}
+	}
+}
+
+// writeShutdown writes the shutdown action (notifying the client that
+// the server is stopping) as an event response action.
+func (s *serverImpl) writeShutdown(w writer) {
+	w.Writevs(_ERA_SHUTDOWN, _STR_COMMA, url.PathEscape(s.shutdownText))
+}
@@ -0,0 +1,442 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// SideNav component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NavItem interface defines an item of a SideNav: a navigation entry
+// or a section (an item having child items) which can be expanded and collapsed.
+type NavItem interface {
+	// Text returns the text of the item.
+	Text() string
+
+	// SetText sets the text of the item.
+	SetText(text string)
+
+	// Icon returns the icon of the item.
+	Icon() string
+
+	// SetIcon sets the icon of the item: a short text, e.g. a symbol
+	// or an emoji, or a ligature of an icon font. The icon is displayed
+	// in collapsed (icon-only) mode too.
+	SetIcon(icon string)
+
+	// Route returns the route path of the item.
+	Route() string
+
+	// AddItem adds a child item, making this item a section.
+	// Sections are expanded / collapsed when clicked (they are not invoked).
+	// route is the route path navigated to when the item is invoked
+	// (see SideNav.SetRouter()), may be empty.
+	AddItem(text, icon, route string) NavItem
+
+	// Items returns the child items.
+	Items() []NavItem
+
+	// Expanded tells if the child items of the section are displayed.
+	Expanded() bool
+
+	// SetExpanded sets if the child items of the section are displayed.
+	SetExpanded(expanded bool)
+
+	// SetHandler sets a function to be called when the item is invoked.
+	// Pass nil to remove the handler.
+	SetHandler(handler func(e Event))
+}
+
+// SideNav interface defines a side navigation: the standard left-rail navigation
+// of applications, a list of items with icons, organized in nested sections.
+// The side navigation is sticky: it remains visible while the content is scrolled.
+// It can be collapsed into an icon-only mode by the user (by the toggle button
+// at its top) or by SetCollapsed().
+//
+// The active item is tracked: when an item is invoked, it becomes the
+// active item (and its handler is called, see NavItem.SetHandler()).
+// If a router is set (see SetRouter()), invoking an item having a route
+// navigates the router to the route, and the active item follows the route
+// of the router, including route changes from the browser history
+// (e.g. when the user presses the Back button).
+//
+// You can register ETYPE_CHANGE event handlers which will be called
+// when an item is invoked by the user (after the handler of the item),
+// and ETYPE_STATE_CHANGE event handlers which will be called when a section
+// is expanded or collapsed, or the side navigation is collapsed or expanded by the user.
+// The event source will be the side navigation; EventItem() tells the item.
+//
+// Default style classes: "gwu-SideNav", "gwu-SideNav-Collapsed", "gwu-SideNav-Toggle",
+// "gwu-SideNav-List", "gwu-SideNav-Item", "gwu-SideNav-Section", "gwu-SideNav-Expanded",
+// "gwu-SideNav-Active", "gwu-SideNav-Icon", "gwu-SideNav-Text"
+type SideNav interface {
+	// SideNav is a component.
+	Comp
+
+	// AddItem adds a top-level item.
+	// route is the route path navigated to when the item is invoked
+	// (see SetRouter()), may be empty.
+	AddItem(text, icon, route string) NavItem
+
+	// Items returns the top-level items.
+	Items() []NavItem
+
+	// Router returns the router the side navigation is synchronized with.
+	Router() Router
+
+	// SetRouter sets the router the side navigation is synchronized with.
+	// Pass nil to not use a router. This is the default.
+	SetRouter(router Router)
+
+	// Active returns the active item, nil if there is no active item.
+	// If a router is set, this is the item whose route matches the route
+	// of the router (an exact match, else the item with the longest route
+	// being a path prefix of the route of the router).
+	Active() NavItem
+
+	// SetActive sets the active item.
+	// If a router is set, this has no effect, the active item follows
+	// the route of the router.
+	SetActive(item NavItem)
+
+	// Collapsed tells if the side navigation is collapsed (icon-only mode).
+	Collapsed() bool
+
+	// SetCollapsed sets if the side navigation is collapsed (icon-only mode).
+	SetCollapsed(collapsed bool)
+
+	// EventItem returns the item of the last event, nil if the last event
+	// was not about an item (e.g. the side navigation was collapsed).
+	// Should only be used from event handlers.
+	EventItem() NavItem
+}
+
+// NavItem implementation.
+type navItemImpl struct {
+	nav        *sideNavImpl   // Side navigation the item belongs to
+	idx        int            // Index of the item (unique in the side navigation)
+	text, icon string         // Text and icon of the item
+	route      string         // Route path of the item
+	items      []*navItemImpl // Child items
+	expanded   bool           // Tells if the child items are displayed
+	handler    func(e Event)  // Handler of the item
+}
+
+func (it *navItemImpl) Text() string {
+	return it.text
+}
+
+func (it *navItemImpl) SetText(text string) {
+	it.text = text
+}
+
+func (it *navItemImpl) Icon() string {
+	return it.icon
+}
+
+func (it *navItemImpl) SetIcon(icon string) {
+	it.icon = icon
+}
+
+func (it *navItemImpl) Route() string {
+	return it.route
+}
+
+func (it *navItemImpl) AddItem(text, icon, route string) NavItem {
+	item := it.nav.newItem(text, icon, route)
+	it.items = append(it.items, item)
+	return item
+}
+
+func (it *navItemImpl) Items() []NavItem {
+	return navItems(it.items)
+}
+
+func (it *navItemImpl) Expanded() bool {
+	return it.expanded
+}
+
+func (it *navItemImpl) SetExpanded(expanded bool) {
+	it.expanded = expanded
+}
+
+func (it *navItemImpl) SetHandler(handler func(e Event)) {
+	it.handler = handler
+}
+
+// navItems converts the specified items to a NavItem slice.
+func navItems(items []*navItemImpl) []NavItem {
+	navItems := make([]NavItem, len(items))
+	for i, item := range items {
+		navItems[i] = item
+	}
+	return navItems
+}
+
+// SideNav implementation.
+type sideNavImpl struct {
+	compImpl // Component implementation
+
+	items     []*navItemImpl // Top-level items
+	itemSeq   int            // Sequence of item indices
+	router    Router         // Router to synchronize with
+	active    *navItemImpl   // Active item (if there is no router)
+	collapsed bool           // Tells if collapsed (icon-only mode)
+	eventItem *navItemImpl   // Item of the last event
+}
+
+// NewSideNav creates a new SideNav.
+func NewSideNav() SideNav {
+	c := &sideNavImpl{compImpl: newCompImpl(nil)}
+	c.Style().AddClass("gwu-SideNav")
+	return c
+}
+
+// newItem creates a new item.
+func (c *sideNavImpl) newItem(text, icon, route string) *navItemImpl {
+	c.itemSeq++
+	return &navItemImpl{nav: c, idx: c.itemSeq, text: text, icon: icon, route: route}
+}
+
+func (c *sideNavImpl) AddItem(text, icon, route string) NavItem {
+	item := c.newItem(text, icon, route)
+	c.items = append(c.items, item)
+	return item
+}
+
+func (c *sideNavImpl) Items() []NavItem {
+	return navItems(c.items)
+}
+
+func (c *sideNavImpl) Router() Router {
+	return c.router
+}
+
+func (c *sideNavImpl) SetRouter(router Router) {
+	c.router = router
+}
+
+// walkNavItems calls f for the specified items and their descendants (depth-first).
+// Walking stops if f returns false; the return value tells if walking was stopped.
+func walkNavItems(items []*navItemImpl, f func(item *navItemImpl) bool) bool {
+	for _, item := range items {
+		if !f(item) || !walkNavItems(item.items, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *sideNavImpl) Active() NavItem {
+	item := c.activeItem()
+	if item == nil {
+		return nil
+	}
+	return item
+}
+
+// activeItem returns the active item, nil if there is no active item.
+func (c *sideNavImpl) activeItem() *navItemImpl {
+	if c.router == nil {
+		return c.active
+	}
+
+	route := c.router.Route()
+	var match *navItemImpl
+	walkNavItems(c.items, func(item *navItemImpl) bool {
+		if len(item.route) == 0 {
+			return true
+		}
+		if item.route == route {
+			match = item
+			return false
+		}
+		if (match == nil || len(item.route) > len(match.route)) && strings.HasPrefix(route, item.route) &&
+			(strings.HasSuffix(item.route, "/") || route[len(item.route)] == '/') {
+			match = item
+		}
+		return true
+	})
+	return match
+}
+
+func (c *sideNavImpl) SetActive(item NavItem) {
+	if item == nil {
+		c.active = nil
+		return
+	}
+	if it, ok := item.(*navItemImpl); ok && it.nav == c {
+		c.active = it
+	}
+}
+
+func (c *sideNavImpl) Collapsed() bool {
+	return c.collapsed
+}
+
+func (c *sideNavImpl) SetCollapsed(collapsed bool) {
+	if c.collapsed == collapsed {
+		return
+	}
+	c.collapsed = collapsed
+	if collapsed {
+		c.Style().AddClass("gwu-SideNav-Collapsed")
+	} else {
+		c.Style().RemoveClass("gwu-SideNav-Collapsed")
+	}
+}
+
+func (c *sideNavImpl) EventItem() NavItem {
+	if c.eventItem == nil {
+		return nil
+	}
+	return c.eventItem
+}
+
+func (c *sideNavImpl) preprocessEvent(event Event, r *http.Request) {
+	c.eventItem = nil
+
+	// Values sent by the client:
+	//   ETYPE_CHANGE      : "<idx>" (the item is invoked)
+	//   ETYPE_STATE_CHANGE: "<idx>,<expanded>" (section state) or ",<collapsed>" (side navigation state)
+	// where states are "1" (true) or "0" (false).
+	value := r.FormValue(_PARAM_COMP_VALUE)
+	switch event.Type() {
+	case ETYPE_CHANGE:
+		// Activate the item invoked by the user
+		item := c.itemByIdx(value)
+		if item == nil {
+			return
+		}
+		c.eventItem = item
+		c.active = item
+		if c.router != nil && len(item.route) > 0 {
+			c.router.Navigate(item.route, event)
+		}
+		if item.handler != nil {
+			item.handler(event)
+		}
+	case ETYPE_STATE_CHANGE:
+		parts := strings.Split(value, ",")
+		if len(parts) != 2 {
+			return
+		}
+		if len(parts[0]) == 0 {
+			c.SetCollapsed(parts[1] == "1")
+		} else if c.eventItem = c.itemByIdx(parts[0]); c.eventItem != nil {
+			c.eventItem.expanded = parts[1] == "1"
+		}
+	}
+}
+
+// itemByIdx returns the item having the specified index, nil if there is no such item.
+func (c *sideNavImpl) itemByIdx(idxStr string) *navItemImpl {
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil {
+		return nil
+	}
+
+	var found *navItemImpl
+	walkNavItems(c.items, func(item *navItemImpl) bool {
+		if item.idx == idx {
+			found = item
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+var (
+	_STR_SIDENAV_OP        = []byte("<nav")                                                  // "<nav"
+	_STR_SIDENAV_CL        = []byte("</nav>")                                                // "</nav>"
+	_STR_SIDENAV_TOGGLE_OP = []byte(`<div class="gwu-SideNav-Toggle" onclick="snToggle(`)    // `<div class="gwu-SideNav-Toggle" onclick="snToggle(`
+	_STR_SIDENAV_TOGGLE_CL = []byte(`)">&#9776;</div>`)                                      // `)">&#9776;</div>`
+	_STR_SIDENAV_LIST_OP   = []byte(`<ul class="gwu-SideNav-List">`)                         // `<ul class="gwu-SideNav-List">`
+	_STR_SIDENAV_LIST_CL   = []byte("</ul>")                                                 // "</ul>"
+	_STR_SIDENAV_ITEM_OP   = []byte(`<li class="gwu-SideNav-Item`)                           // `<li class="gwu-SideNav-Item`
+	_STR_SIDENAV_SECTION   = []byte(" gwu-SideNav-Section")                                  // " gwu-SideNav-Section"
+	_STR_SIDENAV_EXPANDED  = []byte(" gwu-SideNav-Expanded")                                 // " gwu-SideNav-Expanded"
+	_STR_SIDENAV_ACTIVE    = []byte(" gwu-SideNav-Active")                                   // " gwu-SideNav-Active"
+	_STR_SIDENAV_ROUTE     = []byte(`" data-route="`)                                        // `" data-route="`
+	_STR_SIDENAV_TITLE     = []byte(`" title="`)                                             // `" title="`
+	_STR_SIDENAV_A_OP      = []byte(`"><a href="javascript:void(0)" onclick="snClick(this,`) // `"><a href="javascript:void(0)" onclick="snClick(this,`
+	_STR_SIDENAV_ICON_OP   = []byte(`);return false"><span class="gwu-SideNav-Icon">`)       // `);return false"><span class="gwu-SideNav-Icon">`
+	_STR_SIDENAV_TEXT_OP   = []byte(`</span><span class="gwu-SideNav-Text">`)                // `</span><span class="gwu-SideNav-Text">`
+	_STR_SIDENAV_A_CL      = []byte("</span></a>")                                           // "</span></a>"
+	_STR_SIDENAV_LI_CL     = []byte("</li>")                                                 // "</li>"
+	_STR_SIDENAV_SYNC_OP   = []byte("<script>snSync(")                                       // "<script>snSync("
+)
+
+func (c *sideNavImpl) Render(w writer) {
+	w.Write(_STR_SIDENAV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_SIDENAV_TOGGLE_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_SIDENAV_TOGGLE_CL)
+
+	c.renderItems(w, c.items, c.activeItem())
+
+	if c.router != nil {
+		// Follow route changes from the browser history
+		w.writeScript(_STR_SIDENAV_SYNC_OP)
+		w.Writev(int(c.id))
+		w.Write(_STR_SCRIPT_CL)
+	}
+
+	w.Write(_STR_SIDENAV_CL)
+}
+
+// renderItems renders the specified items.
+func (c *sideNavImpl) renderItems(w writer, items []*navItemImpl, active *navItemImpl) {
+	w.Write(_STR_SIDENAV_LIST_OP)
+	for _, item := range items {
+		w.Write(_STR_SIDENAV_ITEM_OP)
+		if len(item.items) > 0 {
+			w.Write(_STR_SIDENAV_SECTION)
+			if item.expanded {
+				w.Write(_STR_SIDENAV_EXPANDED)
+			}
+		}
+		if item == active {
+			w.Write(_STR_SIDENAV_ACTIVE)
+		}
+		if len(item.route) > 0 {
+			w.Write(_STR_SIDENAV_ROUTE)
+			w.Writees(item.route)
+		}
+		w.Write(_STR_SIDENAV_TITLE)
+		w.Writees(item.text)
+		w.Write(_STR_SIDENAV_A_OP)
+		w.Writevs(int(c.id), _STR_COMMA, item.idx)
+		w.Write(_STR_SIDENAV_ICON_OP)
+		w.Writees(item.icon)
+		w.Write(_STR_SIDENAV_TEXT_OP)
+		w.Writees(item.text)
+		w.Write(_STR_SIDENAV_A_CL)
+		if len(item.items) > 0 {
+			c.renderItems(w, item.items, active)
+		}
+		w.Write(_STR_SIDENAV_LI_CL)
+	}
+	w.Write(_STR_SIDENAV_LIST_CL)
+}
@@ -0,0 +1,291 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Defines the SignaturePad component.
+
+package gwu
+
+import (
+	"image"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Stroke is a stroke of a signature: the points of a continuous line
+// drawn without lifting the pen (mouse button, finger).
+type Stroke []image.Point
+
+// SignaturePad interface defines a component where users can draw
+// their signature with the mouse or by touch.
+// 
+// When the user finishes a stroke or clears the pad, the signature is sent to
+// the server both as stroke data (Strokes()) and as a PNG image (BinaryValue()),
+// and ETYPE_CHANGE is dispatched.
+// 
+// In read-only mode the signature is only displayed, it cannot be changed;
+// this can be used to display a stored signature set by SetStrokes().
+// 
+// Suggested event type to handle actions: ETYPE_CHANGE
+// 
+// Default style classes: "gwu-SignaturePad", "gwu-SignaturePad-Canvas",
+// "gwu-SignaturePad-Clear"
+type SignaturePad interface {
+	// SignaturePad is a component.
+	Comp
+
+	// SignaturePad can be enabled/disabled.
+	HasEnabled
+
+	// SignaturePad has a binary value, the PNG image of the signature.
+	// The image is created in the browser, it is empty if the signature is
+	// empty or if the strokes were set by SetStrokes().
+	HasBinaryValue
+
+	// Strokes returns the strokes of the signature.
+	Strokes() []Stroke
+
+	// SetStrokes sets the strokes of the signature.
+	// Coordinates are relative to the top left corner of the canvas.
+	SetStrokes(strokes []Stroke)
+
+	// Empty tells if the signature is empty (has no strokes).
+	Empty() bool
+
+	// Clear clears the signature.
+	Clear()
+
+	// ReadOnly tells if the signature pad is read-only.
+	ReadOnly() bool
+
+	// SetReadOnly sets if the signature pad is read-only.
+	// The signature of a read-only signature pad is only displayed,
+	// it cannot be drawn or cleared.
+	SetReadOnly(readOnly bool)
+
+	// CanvasSize returns the size of the drawing canvas in pixels.
+	CanvasSize() (width, height int)
+
+	// SetCanvasSize sets the size of the drawing canvas in pixels.
+	// The default size is 400x150.
+	SetCanvasSize(width, height int)
+
+	// ClearText returns the text of the clear button.
+	ClearText() string
+
+	// SetClearText sets the text of the clear button.
+	SetClearText(text string)
+}
+
+// SignaturePad implementation.
+type signaturePadImpl struct {
+	compImpl           // Component implementation
+	hasEnabledImpl     // Has enabled implementation
+	hasBinaryValueImpl // Has binary value implementation
+
+	strokes       []Stroke // Strokes of the signature
+	readOnly      bool     // Tells if the signature pad is read-only
+	width, height int      // Size of the canvas
+	clearText     string   // Text of the clear button
+
+	rejected bool // Tells if the received signature was rejected
+}
+
+// NewSignaturePad creates a new SignaturePad.
+func NewSignaturePad() SignaturePad {
+	c := &signaturePadImpl{compImpl: newInputCompImpl(nil), hasEnabledImpl: newHasEnabledImpl(), hasBinaryValueImpl: newHasBinaryValueImpl(),
+		width: 400, height: 150, clearText: "Clear"}
+	c.Style().AddClass("gwu-SignaturePad")
+	return c
+}
+
+func (c *signaturePadImpl) Strokes() []Stroke {
+	return c.strokes
+}
+
+func (c *signaturePadImpl) SetStrokes(strokes []Stroke) {
+	c.strokes = strokes
+	c.binValue = nil
+}
+
+func (c *signaturePadImpl) Empty() bool {
+	return len(c.strokes) == 0
+}
+
+func (c *signaturePadImpl) Clear() {
+	c.strokes = nil
+	c.binValue = nil
+}
+
+func (c *signaturePadImpl) ReadOnly() bool {
+	return c.readOnly
+}
+
+func (c *signaturePadImpl) SetReadOnly(readOnly bool) {
+	c.readOnly = readOnly
+}
+
+func (c *signaturePadImpl) CanvasSize() (width, height int) {
+	return c.width, c.height
+}
+
+func (c *signaturePadImpl) SetCanvasSize(width, height int) {
+	c.width, c.height = width, height
+}
+
+func (c *signaturePadImpl) ClearText() string {
+	return c.clearText
+}
+
+func (c *signaturePadImpl) SetClearText(text string) {
+	c.clearText = text
+}
+
+// parseStrokes parses strokes in the format sent by the client:
+// coordinates of points are separated by dots, points of a stroke are
+// separated by underscores, strokes are separated by tildes,
+// e.g. "10.20_11.22~40.20_41.25".
+func parseStrokes(s string) ([]Stroke, error) {
+	var strokes []Stroke
+	for _, ss := range strings.Split(s, "~") {
+		var stroke Stroke
+		for _, sp := range strings.Split(ss, "_") {
+			i := strings.IndexByte(sp, '.')
+			if i < 0 {
+				return nil, strconv.ErrSyntax
+			}
+			x, err := strconv.Atoi(sp[:i])
+			if err != nil {
+				return nil, err
+			}
+			y, err := strconv.Atoi(sp[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			stroke = append(stroke, image.Point{x, y})
+		}
+		strokes = append(strokes, stroke)
+	}
+	return strokes, nil
+}
+
+// formatStrokes formats strokes in the format parsed by parseStrokes().
+func formatStrokes(strokes []Stroke) string {
+	buf := make([]byte, 0, 256)
+	for i, stroke := range strokes {
+		if i > 0 {
+			buf = append(buf, '~')
+		}
+		for j, p := range stroke {
+			if j > 0 {
+				buf = append(buf, '_')
+			}
+			buf = strconv.AppendInt(buf, int64(p.X), 10)
+			buf = append(buf, '.')
+			buf = strconv.AppendInt(buf, int64(p.Y), 10)
+		}
+	}
+	return string(buf)
+}
+
+func (c *signaturePadImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_CHANGE {
+		return
+	}
+
+	if c.readOnly || !c.enabled {
+		// Reject it, restore the signature in the browser
+		c.rejected = true
+		event.MarkDirty(c)
+		return
+	}
+
+	// Value format: "strokes:png" (png is base64 encoded), empty string if cleared
+	value := r.FormValue(_PARAM_COMP_VALUE)
+	if len(value) == 0 {
+		c.Clear()
+		return
+	}
+
+	i := strings.IndexByte(value, ':')
+	if i < 0 || c.maxBinSize > 0 && i > c.maxBinSize {
+		c.rejected = true
+		event.MarkDirty(c)
+		return
+	}
+	strokes, err := parseStrokes(value[:i])
+	if err != nil || c.decodeBinaryValue(value[i+1:]) != nil {
+		c.rejected = true
+		event.MarkDirty(c)
+		return
+	}
+	c.strokes = strokes
+}
+
+func (c *signaturePadImpl) dispatchEvent(e Event) {
+	if c.rejected {
+		c.rejected = false
+		return
+	}
+	c.compImpl.dispatchEvent(e)
+}
+
+var (
+	_STR_SP_CANVAS_OP = []byte(`<canvas class="gwu-SignaturePad-Canvas" width="`)                        // `<canvas class="gwu-SignaturePad-Canvas" width="`
+	_STR_SP_HEIGHT    = []byte(`" height="`)                                                             // `" height="`
+	_STR_SP_CANVAS_CL = []byte(`"></canvas>`)                                                            // `"></canvas>`
+	_STR_SP_CLEAR_OP  = []byte(`<button type="button" class="gwu-SignaturePad-Clear" onclick="spClear(`) // `<button type="button" class="gwu-SignaturePad-Clear" onclick="spClear(`
+	_STR_SP_CLEAR_CL  = []byte(`)"`)                                                                     // `)"`
+	_STR_SP_INIT_OP   = []byte("<script>spInit(")                                                        // "<script>spInit("
+	_STR_SP_INIT_CL   = []byte("');</script>")                                                           // "');</script>"
+)
+
+func (c *signaturePadImpl) Render(w writer) {
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_SP_CANVAS_OP)
+	w.Writev(c.width)
+	w.Write(_STR_SP_HEIGHT)
+	w.Writev(c.height)
+	w.Write(_STR_SP_CANVAS_CL)
+
+	if !c.readOnly {
+		w.Write(_STR_SP_CLEAR_OP)
+		w.Writev(int(c.id))
+		w.Write(_STR_COMMA)
+		w.Writev(int(ETYPE_CHANGE))
+		w.Write(_STR_SP_CLEAR_CL)
+		c.renderEnabled(w)
+		w.Write(_STR_GT)
+		w.Writees(c.clearText)
+		w.Write(_STR_BUTTON_CL)
+	}
+
+	// To render: <script>spInit(compId,etype,drawable,'strokes');</script>
+	w.writeScript(_STR_SP_INIT_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_CHANGE))
+	w.Write(_STR_COMMA)
+	w.Writev(!c.readOnly && c.enabled)
+	w.Write(_STR_MC_TEXTS)
+	w.Writes(formatStrokes(c.strokes))
+	w.Write(_STR_SP_INIT_CL)
+
+	w.Write(_STR_SPAN_CL)
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Skeleton component interface and implementation,
+// and loading content with skeletons displayed.
+
+package gwu
+
+// Skeleton shape type.
+type SkeletonShape int
+
+// Skeleton shapes.
+const (
+	SKELETON_TEXT   SkeletonShape = iota // Lines of text
+	SKELETON_BLOCK                       // Rectangular block, e.g. in place of an image or a chart
+	SKELETON_AVATAR                      // Circle, e.g. in place of an avatar
+)
+
+// Style classes of the skeleton shapes.
+var skeletonClasses = []string{
+	SKELETON_TEXT:   "gwu-Skeleton-Text",
+	SKELETON_BLOCK:  "gwu-Skeleton-Block",
+	SKELETON_AVATAR: "gwu-Skeleton-Avatar",
+}
+
+// Skeleton interface defines a placeholder component displayed in place of
+// content which is being loaded, giving a preview of the layout
+// with animated shapes (text lines, blocks or avatars).
+//
+// The size of blocks and avatars can be set with Style().SetSize().
+//
+// See LoadWithSkeletons() to display skeletons while loading the content of a panel.
+//
+// Default style classes: "gwu-Skeleton", "gwu-Skeleton-Text", "gwu-Skeleton-Line",
+// "gwu-Skeleton-Block", "gwu-Skeleton-Avatar"
+type Skeleton interface {
+	// Skeleton is a component.
+	Comp
+
+	// Shape returns the shape of the skeleton.
+	Shape() SkeletonShape
+
+	// Lines returns the number of text lines.
+	Lines() int
+
+	// SetLines sets the number of text lines (of SKELETON_TEXT),
+	// the last line is displayed shorter.
+	// Default is 3.
+	SetLines(lines int)
+}
+
+// Skeleton implementation.
+type skeletonImpl struct {
+	compImpl // Component implementation
+
+	shape SkeletonShape // Shape of the skeleton
+	lines int           // Number of text lines
+}
+
+// NewSkeleton creates a new Skeleton.
+func NewSkeleton(shape SkeletonShape) Skeleton {
+	c := &skeletonImpl{compImpl: newCompImpl(nil), shape: shape, lines: 3}
+	c.Style().AddClass("gwu-Skeleton")
+	if int(shape) >= 0 && int(shape) < len(skeletonClasses) {
+		c.Style().AddClass(skeletonClasses[shape])
+	}
+	return c
+}
+
+func (c *skeletonImpl) Shape() SkeletonShape {
+	return c.shape
+}
+
+func (c *skeletonImpl) Lines() int {
+	return c.lines
+}
+
+func (c *skeletonImpl) SetLines(lines int) {
+	c.lines = lines
+}
+
+var (
+	_STR_SKELETON_LINE = []byte(`<div class="gwu-Skeleton-Line"></div>`) // `<div class="gwu-Skeleton-Line"></div>`
+)
+
+func (c *skeletonImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	if c.shape == SKELETON_TEXT {
+		for i := 0; i < c.lines; i++ {
+			w.Write(_STR_SKELETON_LINE)
+		}
+	}
+
+	w.Write(_STR_DIV_CL)
+}
+
+// LoadWithSkeletons displays the specified skeletons in place of the content of
+// a panel while loading data: load is called in a new goroutine, and when it
+// returns, the original content of the panel is restored and done is called
+// (if not nil) using Session.Push(), so done can update the content with
+// the loaded data.
+//
+// load is called without holding the session lock, it should not access
+// components. Push should be enabled on the window for the restored content
+// to be displayed right away (see Window.SetPushEnabled()).
+func LoadWithSkeletons(e Event, p Panel, load func(), done func(e Event), skeletons ...Comp) {
+	impl, ok := p.(interface {
+		swapContent(comps []Comp, cellFmts map[ID]*cellFmtImpl) ([]Comp, map[ID]*cellFmtImpl)
+	})
+	if !ok {
+		return
+	}
+
+	comps, cellFmts := impl.swapContent(skeletons, nil)
+	e.MarkDirty(p)
+
+	sess := e.Session()
+	go func() {
+		defer sess.Push(func(e Event) {
+			impl.swapContent(comps, cellFmts)
+			if done != nil {
+				done(e)
+			}
+			e.MarkDirty(p)
+		})
+		load()
+	}()
+}
@@ -0,0 +1,162 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Slider component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Slider interface defines a component which allows selecting a numeric
+// value from a range by dragging a handle (an HTML range input),
+// e.g. for volume, zoom or numeric filter controls.
+//
+// The value is synchronized when the user releases the handle (ETYPE_CHANGE),
+// and also continuously while dragging (ETYPE_INPUT) if there are
+// ETYPE_INPUT event handlers added to the slider.
+//
+// Suggested event type to handle changes: ETYPE_CHANGE
+//
+// Default style class: "gwu-Slider"
+type Slider interface {
+	// Slider is a component.
+	Comp
+
+	// Slider can be enabled/disabled.
+	HasEnabled
+
+	// Min returns the min value.
+	Min() float64
+
+	// SetMin sets the min value.
+	SetMin(min float64)
+
+	// Max returns the max value.
+	Max() float64
+
+	// SetMax sets the max value.
+	SetMax(max float64)
+
+	// Step returns the step (granularity) of the value.
+	Step() float64
+
+	// SetStep sets the step (granularity) of the value.
+	// Default is 1.
+	SetStep(step float64)
+
+	// Value returns the value.
+	Value() float64
+
+	// SetValue sets the value.
+	// The value is clamped to the range Min()..Max().
+	SetValue(value float64)
+}
+
+// Slider implementation.
+type sliderImpl struct {
+	compImpl       // Component implementation
+	hasEnabledImpl // Has enabled implementation
+
+	min, max, step float64 // Range and step
+	value          float64 // Value
+}
+
+var _STR_THIS_V = []byte("this.value") // "this.value"
+
+// NewSlider creates a new Slider with the specified range and value.
+func NewSlider(min, max, value float64) Slider {
+	c := &sliderImpl{compImpl: newInputCompImpl(_STR_THIS_V), hasEnabledImpl: newHasEnabledImpl(), min: min, max: max, step: 1}
+	c.SetValue(value)
+	c.AddSyncOnETypes(ETYPE_CHANGE)
+	// Value is also sent with input events, but those are only generated if there are handlers
+	c.syncOnETypes[ETYPE_INPUT] = true
+	c.Style().AddClass("gwu-Slider")
+	return c
+}
+
+func (c *sliderImpl) Min() float64 {
+	return c.min
+}
+
+func (c *sliderImpl) SetMin(min float64) {
+	c.min = min
+}
+
+func (c *sliderImpl) Max() float64 {
+	return c.max
+}
+
+func (c *sliderImpl) SetMax(max float64) {
+	c.max = max
+}
+
+func (c *sliderImpl) Step() float64 {
+	return c.step
+}
+
+func (c *sliderImpl) SetStep(step float64) {
+	c.step = step
+}
+
+func (c *sliderImpl) Value() float64 {
+	return c.value
+}
+
+func (c *sliderImpl) SetValue(value float64) {
+	if value < c.min {
+		value = c.min
+	} else if value > c.max {
+		value = c.max
+	}
+	c.value = value
+	c.valueChanged()
+}
+
+func (c *sliderImpl) preprocessEvent(event Event, r *http.Request) {
+	value, _, ok := c.clientFloat(r)
+	if !ok || value < c.min || value > c.max {
+		return
+	}
+	c.value = value
+}
+
+var (
+	_STR_SLIDER_OP   = []byte(`<input type="range"`) // `<input type="range"`
+	_STR_SLIDER_STEP = []byte(` step="`)             // ` step="`
+	_STR_SLIDER_CL   = []byte(`">`)                  // `">`
+)
+
+func (c *sliderImpl) Render(w writer) {
+	w.Write(_STR_SLIDER_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEnabled(w)
+	c.renderEHandlers(w)
+
+	w.Write(_STR_MIN)
+	w.Writes(strconv.FormatFloat(c.min, 'f', -1, 64))
+	w.Write(_STR_QUOTE)
+	w.Write(_STR_MAX)
+	w.Writes(strconv.FormatFloat(c.max, 'f', -1, 64))
+	w.Write(_STR_QUOTE)
+	w.Write(_STR_SLIDER_STEP)
+	w.Writes(strconv.FormatFloat(c.step, 'f', -1, 64))
+	w.Write(_STR_QUOTE)
+	w.Write(_STR_VALUE)
+	w.Writes(strconv.FormatFloat(c.value, 'f', -1, 64))
+	w.Write(_STR_SLIDER_CL)
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Window state snapshot and restore.
+
+package gwu
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Snapshot holds the state of the components of a window:
+// values of input components, selections, expanded state of expanders,
+// selected tabs, routes of routers.
+// 
+// Components are identified by their structural path inside the window
+// (e.g. "0/2/1" is the second child of the third child of the first child
+// of the window), so a snapshot can be restored into a window which is
+// built the same way, even in another session or after a server restart.
+// 
+// Snapshot is a simple map which can be serialized e.g. with the Marshal()
+// method or with encoding/gob.
+type Snapshot map[string]string
+
+// Marshal returns the JSON encoding of the snapshot.
+func (s Snapshot) Marshal() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// UnmarshalSnapshot parses a snapshot from its JSON encoding.
+func UnmarshalSnapshot(data []byte) (Snapshot, error) {
+	s := Snapshot{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// walkComps walks the component tree rooted at c, calling f for each component
+// with its structural path. f is called before descending into the children
+// of a container, so f may change the children (e.g. the route of a Router).
+func walkComps(c Comp, path string, f func(path string, c Comp)) {
+	f(path, c)
+
+	child := func(c2 Comp, idx string) {
+		if c2 != nil {
+			if len(path) > 0 {
+				walkComps(c2, path+"/"+idx, f)
+			} else {
+				walkComps(c2, idx, f)
+			}
+		}
+	}
+
+	switch c2 := c.(type) {
+	case TabPanel:
+		for i := 0; i < c2.CompsCount(); i++ {
+			child(c2.CompAt(i), strconv.Itoa(i))
+			child(c2.TabBar().CompAt(i), "t"+strconv.Itoa(i))
+		}
+	case PanelView:
+		for i := 0; i < c2.CompsCount(); i++ {
+			child(c2.CompAt(i), strconv.Itoa(i))
+		}
+	case *tableImpl:
+		for row, rowComps := range c2.comps {
+			for col, c3 := range rowComps {
+				child(c3, strconv.Itoa(row)+"."+strconv.Itoa(col))
+			}
+		}
+	case Expander:
+		child(c2.Header(), "h")
+		child(c2.Content(), "c")
+	case Link:
+		child(c2.Comp(), "0")
+	case Router:
+		child(c2.View(), "v")
+	}
+}
+
+// Snapshot returns a snapshot of the component states of the window.
+func (win *windowImpl) Snapshot() Snapshot {
+	s := Snapshot{}
+
+	walkComps(win, "", func(path string, c Comp) {
+		switch c2 := c.(type) {
+		case TextBox:
+			s[path] = c2.Text()
+		case StateButton:
+			s[path] = strconv.FormatBool(c2.State())
+		case SwitchButton:
+			s[path] = strconv.FormatBool(c2.State())
+		case ListBox:
+			idxs := c2.SelectedIndices()
+			sidxs := make([]string, len(idxs))
+			for i, idx := range idxs {
+				sidxs[i] = strconv.Itoa(idx)
+			}
+			s[path] = strings.Join(sidxs, ",")
+		case Expander:
+			s[path] = strconv.FormatBool(c2.Expanded())
+		case TabPanel:
+			s[path] = strconv.Itoa(c2.Selected())
+		case Router:
+			s[path] = c2.Route()
+		}
+	})
+
+	return s
+}
+
+// Restore restores the component states of the window from the snapshot.
+func (win *windowImpl) Restore(s Snapshot) {
+	walkComps(win, "", func(path string, c Comp) {
+		value, found := s[path]
+		if !found {
+			return
+		}
+
+		switch c2 := c.(type) {
+		case TextBox:
+			c2.SetText(value)
+		case StateButton:
+			if state, err := strconv.ParseBool(value); err == nil {
+				c2.SetState(state)
+			}
+		case SwitchButton:
+			if state, err := strconv.ParseBool(value); err == nil {
+				c2.SetState(state)
+			}
+		case ListBox:
+			var idxs []int
+			for _, sidx := range strings.Split(value, ",") {
+				if idx, err := strconv.Atoi(sidx); err == nil && idx >= 0 && idx < len(c2.Values()) {
+					idxs = append(idxs, idx)
+				}
+			}
+			c2.SetSelectedIndices(idxs)
+		case Expander:
+			if expanded, err := strconv.ParseBool(value); err == nil {
+				c2.SetExpanded(expanded)
+			}
+		case TabPanel:
+			if idx, err := strconv.Atoi(value); err == nil && idx < c2.CompsCount() {
+				c2.SetSelected(idx)
+			}
+		case Router:
+			if value != c2.Route() {
+				c2.Navigate(value, nil)
+			}
+		}
+	})
+}
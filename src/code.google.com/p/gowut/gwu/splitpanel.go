@@ -0,0 +1,274 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// SplitPanel component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SplitPanel interface defines a container which displays 2 components
+// (areas) next to each other, separated by a splitter which can be
+// dragged by the user to resize the areas.
+//
+// In a horizontal split panel the areas are laid out horizontally
+// (the first is on the left), in a vertical split panel vertically
+// (the first is on the top). The split position is the size of the first area.
+//
+// The split position is synchronized to the server when the user
+// releases the splitter. You can register ETYPE_STATE_CHANGE event handlers
+// which will be called when this happens.
+//
+// Default style classes: "gwu-SplitPanel", "gwu-SplitPanel-Horizontal",
+// "gwu-SplitPanel-Vertical", "gwu-SplitPanel-First", "gwu-SplitPanel-Splitter",
+// "gwu-SplitPanel-Second"
+type SplitPanel interface {
+	// SplitPanel is a Container.
+	Container
+
+	// Layout returns the layout of the split panel,
+	// either LAYOUT_HORIZONTAL or LAYOUT_VERTICAL.
+	Layout() Layout
+
+	// First returns the component of the first area.
+	First() Comp
+
+	// SetFirst sets the component of the first area.
+	SetFirst(c Comp)
+
+	// Second returns the component of the second area.
+	Second() Comp
+
+	// SetSecond sets the component of the second area.
+	SetSecond(c Comp)
+
+	// SplitPos returns the split position (the size of the first area),
+	// either in percent (e.g. "30%") or in pixels (e.g. "200px").
+	SplitPos() string
+
+	// SetSplitPos sets the split position (the size of the first area),
+	// either in percent (e.g. "30%") or in pixels (e.g. "200px").
+	// Invalid values are ignored.
+	// Positions set by the user are reported in the same unit.
+	// Default value is "50%".
+	SetSplitPos(pos string)
+
+	// SetSplitPosPx sets the split position in pixels.
+	SetSplitPosPx(pos int)
+
+	// SetSplitPosPercent sets the split position in percent.
+	SetSplitPosPercent(pos int)
+}
+
+// SplitPanel implementation.
+type splitPanelImpl struct {
+	compImpl // Component implementation
+
+	layout   Layout // Layout of the split panel
+	first    Comp   // Component of the first area
+	second   Comp   // Component of the second area
+	splitPos string // Split position
+}
+
+// NewHorizontalSplitPanel creates a new SplitPanel
+// whose areas are laid out horizontally.
+func NewHorizontalSplitPanel() SplitPanel {
+	return newSplitPanelImpl(LAYOUT_HORIZONTAL)
+}
+
+// NewVerticalSplitPanel creates a new SplitPanel
+// whose areas are laid out vertically.
+func NewVerticalSplitPanel() SplitPanel {
+	return newSplitPanelImpl(LAYOUT_VERTICAL)
+}
+
+// newSplitPanelImpl creates a new splitPanelImpl.
+func newSplitPanelImpl(layout Layout) *splitPanelImpl {
+	c := &splitPanelImpl{compImpl: newCompImpl(nil), layout: layout, splitPos: "50%"}
+	c.Style().AddClass("gwu-SplitPanel")
+	if layout == LAYOUT_HORIZONTAL {
+		c.Style().AddClass("gwu-SplitPanel-Horizontal")
+	} else {
+		c.Style().AddClass("gwu-SplitPanel-Vertical")
+	}
+	return c
+}
+
+func (c *splitPanelImpl) Remove(c2 Comp) bool {
+	if c.first != nil && c.first.Equals(c2) {
+		c2.setParent(nil)
+		c.first = nil
+		return true
+	}
+
+	if c.second != nil && c.second.Equals(c2) {
+		c2.setParent(nil)
+		c.second = nil
+		return true
+	}
+
+	return false
+}
+
+func (c *splitPanelImpl) ById(id ID) Comp {
+	if c.id == id {
+		return c
+	}
+
+	for _, c2 := range []Comp{c.first, c.second} {
+		if c2 == nil {
+			continue
+		}
+		if c2.Id() == id {
+			return c2
+		}
+		if c3, isContainer := c2.(Container); isContainer {
+			if c4 := c3.ById(id); c4 != nil {
+				return c4
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *splitPanelImpl) Clear() {
+	if c.first != nil {
+		c.first.setParent(nil)
+		c.first = nil
+	}
+	if c.second != nil {
+		c.second.setParent(nil)
+		c.second = nil
+	}
+}
+
+func (c *splitPanelImpl) Layout() Layout {
+	return c.layout
+}
+
+func (c *splitPanelImpl) First() Comp {
+	return c.first
+}
+
+func (c *splitPanelImpl) SetFirst(c2 Comp) {
+	if c.first != nil {
+		c.first.setParent(nil)
+	}
+	c2.makeOrphan()
+	c.first = c2
+	c2.setParent(c)
+}
+
+func (c *splitPanelImpl) Second() Comp {
+	return c.second
+}
+
+func (c *splitPanelImpl) SetSecond(c2 Comp) {
+	if c.second != nil {
+		c.second.setParent(nil)
+	}
+	c2.makeOrphan()
+	c.second = c2
+	c2.setParent(c)
+}
+
+func (c *splitPanelImpl) SplitPos() string {
+	return c.splitPos
+}
+
+func (c *splitPanelImpl) SetSplitPos(pos string) {
+	if validSplitPos(pos) {
+		c.splitPos = pos
+	}
+}
+
+func (c *splitPanelImpl) SetSplitPosPx(pos int) {
+	c.SetSplitPos(strconv.Itoa(pos) + "px")
+}
+
+func (c *splitPanelImpl) SetSplitPosPercent(pos int) {
+	c.SetSplitPos(strconv.Itoa(pos) + "%")
+}
+
+// validSplitPos tells if the specified split position is valid:
+// a non-negative number in percent (at most 100) or in pixels.
+func validSplitPos(pos string) bool {
+	var num string
+	max := -1.0
+	if strings.HasSuffix(pos, "%") {
+		num, max = pos[:len(pos)-1], 100
+	} else if strings.HasSuffix(pos, "px") {
+		num = pos[:len(pos)-2]
+	} else {
+		return false
+	}
+
+	// Only allow plain decimal numbers (no sign, exponent, NaN or Inf)
+	if strings.Trim(num, "0123456789.") != "" {
+		return false
+	}
+	value, err := strconv.ParseFloat(num, 64)
+	return err == nil && (max < 0 || value <= max)
+}
+
+func (c *splitPanelImpl) preprocessEvent(event Event, r *http.Request) {
+	// Split position is sent when the user releases the splitter
+	c.SetSplitPos(r.FormValue(_PARAM_COMP_VALUE))
+}
+
+var (
+	_STR_SPLIT_FIRST_OP  = []byte(`<div class="gwu-SplitPanel-First" style="flex-basis:`)                // `<div class="gwu-SplitPanel-First" style="flex-basis:`
+	_STR_SPLIT_SPLITTER  = []byte(`<div class="gwu-SplitPanel-Splitter" onpointerdown="spltDrag(event,`) // `<div class="gwu-SplitPanel-Splitter" onpointerdown="spltDrag(event,`
+	_STR_SPLIT_SECOND_OP = []byte(`<div class="gwu-SplitPanel-Second">`)                                 // `<div class="gwu-SplitPanel-Second">`
+)
+
+func (c *splitPanelImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_SPLIT_FIRST_OP)
+	w.Writees(c.splitPos)
+	w.Write(_STR_IMG_CL)
+	if c.first != nil {
+		c.first.Render(w)
+	}
+	w.Write(_STR_DIV_CL)
+
+	// To render: <div class="gwu-SplitPanel-Splitter" onpointerdown="spltDrag(event,compId,etype,horizontal)"></div>
+	w.Write(_STR_SPLIT_SPLITTER)
+	w.Writevs(int(c.id), _STR_COMMA, int(ETYPE_STATE_CHANGE), _STR_COMMA, c.layout == LAYOUT_HORIZONTAL)
+	w.Write(_STR_SE_SUFFIX)
+	w.Write(_STR_GT)
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_SPLIT_SECOND_OP)
+	if c.second != nil {
+		c.second.Render(w)
+	}
+	w.Write(_STR_DIV_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+func (c *splitPanelImpl) usage() ([]Comp, int64) {
+	return usageComps(c.first, c.second), 0
+}
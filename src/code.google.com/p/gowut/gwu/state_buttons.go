@@ -36,12 +36,25 @@ type StateButton interface {
 	// In case of RadioButton, the button's RadioGroup is managed
 	// so that only one can be selected.
 	SetState(state bool)
+
+	// Indeterminate tells if the button is in indeterminate state.
+	Indeterminate() bool
+
+	// SetIndeterminate sets if the button is in indeterminate state.
+	// The indeterminate state is displayed regardless of the state
+	// (e.g. a "select all" check box over a partially selected list).
+	// It is cleared when the user changes the state of the button.
+	SetIndeterminate(indeterminate bool)
+
+	// StateButton has a versioned value (state).
+	HasValueVersion
 }
 
 // CheckBox interface defines a check box, a button which has
 // 2 states: selected/deselected.
 // 
-// Suggested event type to handle changes: ETYPE_CLICK
+// Suggested event type to handle changes: ETYPE_STATE_CHANGE
+// which is only fired if the state is changed by the user.
 // 
 // Default style classes: "gwu-CheckBox", "gwu-CheckBox-Disabled"
 type CheckBox interface {
@@ -52,7 +65,9 @@ type CheckBox interface {
 // SwitchButton interface defines a button which can be switched
 // ON and OFF.
 // 
-// Suggested event type to handle changes: ETYPE_CLICK
+// Suggested event type to handle changes: ETYPE_STATE_CHANGE
+// which is only fired if the state is changed by the user
+// (and not when clicking on the already active side).
 // 
 // Default style classes: "gwu-SwitchButton", "gwu-SwitchButton-On-Active"
 // "gwu-SwitchButton-On-Inactive", "gwu-SwitchButton-Off-Active",
@@ -64,6 +79,9 @@ type SwitchButton interface {
 	// SwitchButton can be enabled/disabled.
 	HasEnabled
 
+	// SwitchButton has a versioned value (state).
+	HasValueVersion
+
 	// State returns the state of the switch button.
 	State() bool
 
@@ -80,6 +98,12 @@ type SwitchButton interface {
 	SetOnOff(on, off string)
 }
 
+// RadioGroupHandler is the type of the handler of a radio group
+// which is called when the selection of the group changes.
+// The new and previous selection is available through
+// RadioGroup.Selected() and RadioGroup.PrevSelected().
+type RadioGroupHandler func(e Event, group RadioGroup)
+
 // RadioGroup interface defines the group for grouping radio buttons.
 type RadioGroup interface {
 	// Name returns the name of the radio group.
@@ -92,6 +116,29 @@ type RadioGroup interface {
 	// before the current selected radio button.
 	PrevSelected() RadioButton
 
+	// Members returns the radio buttons of the group,
+	// in the order they were created.
+	Members() []RadioButton
+
+	// ClearSelection deselects the selected radio button of the group,
+	// if there is one.
+	// The deselected radio button has to be marked dirty for the change
+	// to be visible.
+	ClearSelection()
+
+	// ChangeHandler returns the handler called when the selection
+	// of the group is changed by the user.
+	ChangeHandler() RadioGroupHandler
+
+	// SetChangeHandler sets the handler called when the selection
+	// of the group is changed by the user.
+	// The handler is called before the event handlers of the
+	// selected radio button.
+	SetChangeHandler(handler RadioGroupHandler)
+
+	// addMember adds a radio button to the members of the group.
+	addMember(b RadioButton)
+
 	// setSelected sets the selected radio button of the group,
 	// and before that sets the current selected as the prev selected
 	setSelected(selected RadioButton)
@@ -104,7 +151,9 @@ type RadioGroup interface {
 // Selecting an unselected radio button deselects the selected
 // radio button of the group, if there was one.
 // 
-// Suggested event type to handle changes: ETYPE_CLICK
+// Suggested event type to handle changes: ETYPE_STATE_CHANGE
+// which is only fired if the state is changed by the user
+// (and not when clicking on the already selected radio button).
 // 
 // Default style classes: "gwu-RadioButton", "gwu-RadioButton-Disabled"
 type RadioButton interface {
@@ -124,6 +173,9 @@ type radioGroupImpl struct {
 	name         string      // Name of the radio group
 	selected     RadioButton // Selected radio button of the group
 	prevSelected RadioButton // Previous selected radio button of the group
+
+	members       []RadioButton     // Radio buttons of the group
+	changeHandler RadioGroupHandler // Handler called when the selection changes
 }
 
 // StateButton implementation.
@@ -131,10 +183,12 @@ type stateButtonImpl struct {
 	buttonImpl // Button implementation 
 
 	state         bool       // State of the button
+	indeterminate bool       // Tells if the button is in indeterminate state
 	inputType     []byte     // Type of the underlying input tag
 	group         RadioGroup // Group of the button
 	inputId       ID         // distinct id for the rendered input tag
 	disabledClass string     // Disabled style class
+	stateChanged  bool       // Tells if the state was changed by the event being processed
 }
 
 // SwitchButton implementation.
@@ -143,6 +197,7 @@ type switchButtonImpl struct {
 
 	onButton, offButton *buttonImpl // ON and OFF button implementations
 	state               bool        // State of the switch
+	stateChanged        bool        // Tells if the state was changed by the event being processed
 }
 
 // NewRadioGroup creates a new RadioGroup.
@@ -176,12 +231,12 @@ func NewSwitchButton() SwitchButton {
 	// if ON is pressed when switch is ON, do not switch to OFF):
 	valueProviderJs := []byte("sbtnVal(event,'" + onButton.Id().String() + "','" + offButton.Id().String() + "')")
 
-	c := &switchButtonImpl{newCompImpl(valueProviderJs), &onButton, &offButton, true} // Note the "true" state, so the following SetState(false) will be executed (different states)!
+	c := &switchButtonImpl{compImpl: newInputCompImpl(valueProviderJs), onButton: &onButton, offButton: &offButton, state: true} // Note the "true" state, so the following SetState(false) will be executed (different states)!
 	c.AddSyncOnETypes(ETYPE_CLICK)
 	c.SetAttr("cellspacing", "0")
 	c.SetAttr("cellpadding", "0")
 	c.Style().AddClass("gwu-SwitchButton")
-	c.SetState(false)
+	c.setState(false)
 	return c
 }
 
@@ -190,12 +245,16 @@ func NewSwitchButton() SwitchButton {
 func NewRadioButton(text string, group RadioGroup) RadioButton {
 	c := newStateButtonImpl(text, _STR_RADIO, group, "gwu-RadioButton-Disabled")
 	c.Style().AddClass("gwu-RadioButton")
+	if group != nil {
+		group.addMember(c)
+	}
 	return c
 }
 
 // newStateButtonImpl creates a new stateButtonImpl.
 func newStateButtonImpl(text string, inputType []byte, group RadioGroup, disabledClass string) *stateButtonImpl {
-	c := &stateButtonImpl{newButtonImpl(_STR_THIS_CHECKED, text), false, inputType, group, nextCompId(), disabledClass}
+	c := &stateButtonImpl{buttonImpl: newButtonImpl(_STR_THIS_CHECKED, text), inputType: inputType, group: group, inputId: nextCompId(), disabledClass: disabledClass}
+	c.input = true
 	// Use ETYPE_CLICK because IE fires onchange only when focus is lost...
 	c.AddSyncOnETypes(ETYPE_CLICK)
 	return c
@@ -213,6 +272,30 @@ func (r *radioGroupImpl) PrevSelected() RadioButton {
 	return r.prevSelected
 }
 
+func (r *radioGroupImpl) Members() []RadioButton {
+	members := make([]RadioButton, len(r.members))
+	copy(members, r.members)
+	return members
+}
+
+func (r *radioGroupImpl) ClearSelection() {
+	if r.selected != nil {
+		r.selected.SetState(false)
+	}
+}
+
+func (r *radioGroupImpl) ChangeHandler() RadioGroupHandler {
+	return r.changeHandler
+}
+
+func (r *radioGroupImpl) SetChangeHandler(handler RadioGroupHandler) {
+	r.changeHandler = handler
+}
+
+func (r *radioGroupImpl) addMember(b RadioButton) {
+	r.members = append(r.members, b)
+}
+
 func (r *radioGroupImpl) setSelected(selected RadioButton) {
 	r.prevSelected = r.selected
 	r.selected = selected
@@ -236,6 +319,15 @@ func (c *stateButtonImpl) State() bool {
 }
 
 func (c *stateButtonImpl) SetState(state bool) {
+	if c.state != state {
+		c.valueChanged()
+	}
+	c.setState(state)
+}
+
+// setState sets the state of the button without
+// registering a server side value change.
+func (c *stateButtonImpl) setState(state bool) {
 	// Only continue if state changes:
 	if c.state == state {
 		return
@@ -268,6 +360,14 @@ func (c *stateButtonImpl) SetState(state bool) {
 	c.state = state
 }
 
+func (c *stateButtonImpl) Indeterminate() bool {
+	return c.indeterminate
+}
+
+func (c *stateButtonImpl) SetIndeterminate(indeterminate bool) {
+	c.indeterminate = indeterminate
+}
+
 func (c *stateButtonImpl) Group() RadioGroup {
 	return c.group
 }
@@ -283,9 +383,39 @@ func (c *stateButtonImpl) preprocessEvent(event Event, r *http.Request) {
 	}
 
 	if v, err := strconv.ParseBool(value); err == nil {
-		// Call SetState instead of assigning to the state property
-		// because SetState properly manages radio groups.
-		c.SetState(v)
+		var sel RadioButton
+		if c.group != nil {
+			sel = c.group.Selected()
+		}
+		c.stateChanged = c.state != v
+		// Call setState instead of assigning to the state property
+		// because setState properly manages radio groups.
+		c.setState(v)
+		// The browser clears the indeterminate state when clicked
+		c.indeterminate = false
+
+		if c.group != nil && c.group.Selected() != sel {
+			if handler := c.group.ChangeHandler(); handler != nil {
+				handler(event, c.group)
+			}
+		}
+	}
+}
+
+func (c *stateButtonImpl) dispatchEvent(e Event) {
+	c.compImpl.dispatchEvent(e)
+	if c.stateChanged && e.Type() == ETYPE_CLICK {
+		c.stateChanged = false
+		c.compImpl.dispatchEvent(e.forkEvent(ETYPE_STATE_CHANGE, c))
+	}
+}
+
+// renderStateChangeSync renders the state synchronizing ETYPE_CLICK event sender
+// of a state button if only ETYPE_STATE_CHANGE handlers are registered.
+// ETYPE_STATE_CHANGE events are fired based on the synchronized state.
+func renderStateChangeSync(c *compImpl, w writer) {
+	if c.handlers[ETYPE_STATE_CHANGE] != nil && c.handlers[ETYPE_CLICK] == nil {
+		c.renderEHandler(w, ETYPE_CLICK)
 	}
 }
 
@@ -296,6 +426,9 @@ var (
 	_STR_CHECKED   = []byte(` checked="checked"`) // ` checked="checked"`
 	_STR_LABEL_FOR = []byte(`><label for="`)      // `><label for="`
 	_STR_LABEL_CL  = []byte("</label>")           // "</label>"
+
+	_STR_INDETERMINATE_OP = []byte("<script>document.getElementById('") // "<script>document.getElementById('"
+	_STR_INDETERMINATE_CL = []byte("').indeterminate=true;</script>")   // "').indeterminate=true;</script>"
 )
 
 func (c *stateButtonImpl) Render(w writer) {
@@ -311,7 +444,7 @@ func (c *stateButtonImpl) Render(w writer) {
 	w.Write(_STR_QUOTE)
 	if c.group != nil {
 		w.Write(_STR_NAME)
-		w.Writes(c.group.Name())
+		w.Writees(c.group.Name())
 		w.Write(_STR_QUOTE)
 	}
 	if c.state {
@@ -319,6 +452,7 @@ func (c *stateButtonImpl) Render(w writer) {
 	}
 	c.renderEnabled(w)
 	c.renderEHandlers(w)
+	renderStateChangeSync(&c.compImpl, w)
 
 	w.Write(_STR_LABEL_FOR)
 	w.Writev(int(c.inputId))
@@ -329,6 +463,12 @@ func (c *stateButtonImpl) Render(w writer) {
 	w.Write(_STR_GT)
 	c.renderText(w)
 	w.Write(_STR_LABEL_CL)
+	if c.indeterminate {
+		// Indeterminate state can only be set from JavaScript
+		w.writeScript(_STR_INDETERMINATE_OP)
+		w.Writev(int(c.inputId))
+		w.Write(_STR_INDETERMINATE_CL)
+	}
 	w.Write(_STR_SPAN_CL)
 }
 
@@ -346,6 +486,15 @@ func (c *switchButtonImpl) State() bool {
 }
 
 func (c *switchButtonImpl) SetState(state bool) {
+	if c.state != state {
+		c.valueChanged()
+	}
+	c.setState(state)
+}
+
+// setState sets the state of the switch button without
+// registering a server side value change.
+func (c *switchButtonImpl) setState(state bool) {
 	// Only continue if state changes:
 	if c.state == state {
 		return
@@ -381,14 +530,23 @@ func (c *switchButtonImpl) preprocessEvent(event Event, r *http.Request) {
 	}
 
 	if v, err := strconv.ParseBool(value); err == nil {
-		// Call SetState instead of assigning to the state property
-		// because SetState properly changes style classes.
-		c.SetState(v)
+		c.stateChanged = c.state != v
+		// Call setState instead of assigning to the state property
+		// because setState properly changes style classes.
+		c.setState(v)
 		// SwitchButtons' client code properly updates internal buttons' style,
 		// so we're good not to mark the switch button dirty if state changes.
 	}
 }
 
+func (c *switchButtonImpl) dispatchEvent(e Event) {
+	c.compImpl.dispatchEvent(e)
+	if c.stateChanged && e.Type() == ETYPE_CLICK {
+		c.stateChanged = false
+		c.compImpl.dispatchEvent(e.forkEvent(ETYPE_STATE_CHANGE, c))
+	}
+}
+
 var (
 	_STR_CL_TR = []byte("><tr>")            // "><tr>"
 	_STR_TD_50 = []byte(`<td width="50%">`) // `<td width="50%">`
@@ -398,6 +556,7 @@ func (c *switchButtonImpl) Render(w writer) {
 	w.Write(_STR_TABLE_OP)
 	c.renderAttrsAndStyle(w)
 	c.renderEHandlers(w)
+	renderStateChangeSync(&c.compImpl, w)
 	// For Internet Explorer only:
 	// Since state synchronization is done on ETYPE_CLICK, which will add a click handler
 	// to the wrapper tag and not to the on/off buttons, the wrapper tag itself must be
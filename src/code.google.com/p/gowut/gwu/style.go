@@ -23,31 +23,44 @@ import (
 
 // Style attribute constants.
 const (
-	ST_BACKGROUND     = "background"     // Background (color)
-	ST_BORDER         = "border"         // Border
-	ST_BORDER_LEFT    = "border-left"    // Left border
-	ST_BORDER_RIGHT   = "border-right"   // Right border
-	ST_BORDER_TOP     = "border-top"     // Top border
-	ST_BORDER_BOTTOM  = "border-bottom"  // Bottom border
-	ST_COLOR          = "color"          // (Foreground) color
-	ST_CURSOR         = "cursor"         // Cursor
-	ST_DISPLAY        = "display"        // Display
-	ST_FONT_SIZE      = "font-size"      // Font size
-	ST_FONT_STYLE     = "font-style"     // Font style
-	ST_FONT_WEIGHT    = "font-weight"    // Font weight
-	ST_HEIGHT         = "height"         // Height
-	ST_MARGIN         = "margin"         // Margin
-	ST_MARGIN_LEFT    = "margin-left"    // Left margin
-	ST_MARGIN_RIGHT   = "margin-right"   // Right margin
-	ST_MARGIN_TOP     = "margin-top"     // Top margin
-	ST_MARGIN_BOTTOM  = "margin-bottom"  // Bottom margin
-	ST_PADDING        = "padding"        // Padding
-	ST_PADDING_LEFT   = "padding-left"   // Left padding
-	ST_PADDING_RIGHT  = "padding-right"  // Right padding
-	ST_PADDING_TOP    = "padding-top"    // Top padding
-	ST_PADDING_BOTTOM = "padding-bottom" // Bottom padding
-	ST_WHITE_SPACE    = "white-space"    // White-space
-	ST_WIDTH          = "width"          // Width
+	ST_ALIGN_ITEMS           = "align-items"           // Align items (alignment of flex items along the cross axis)
+	ST_ALIGN_SELF            = "align-self"            // Align self (alignment of a flex item along the cross axis)
+	ST_BACKGROUND            = "background"            // Background (color)
+	ST_BORDER                = "border"                // Border
+	ST_BORDER_LEFT           = "border-left"           // Left border
+	ST_BORDER_RIGHT          = "border-right"          // Right border
+	ST_BORDER_TOP            = "border-top"            // Top border
+	ST_BORDER_BOTTOM         = "border-bottom"         // Bottom border
+	ST_COLOR                 = "color"                 // (Foreground) color
+	ST_CURSOR                = "cursor"                // Cursor
+	ST_DISPLAY               = "display"               // Display
+	ST_FLEX_BASIS            = "flex-basis"            // Flex basis (initial size of flex items)
+	ST_FLEX_GROW             = "flex-grow"             // Flex grow factor
+	ST_FLEX_SHRINK           = "flex-shrink"           // Flex shrink factor
+	ST_FLEX_WRAP             = "flex-wrap"             // Flex wrap
+	ST_FONT_SIZE             = "font-size"             // Font size
+	ST_FONT_STYLE            = "font-style"            // Font style
+	ST_FONT_WEIGHT           = "font-weight"           // Font weight
+	ST_GAP                   = "gap"                   // Gap between flex (and grid) items
+	ST_GRID_COLUMN           = "grid-column"           // Grid column (placement and span of a grid item)
+	ST_GRID_ROW              = "grid-row"              // Grid row (placement and span of a grid item)
+	ST_GRID_TEMPLATE_COLUMNS = "grid-template-columns" // Grid template columns
+	ST_GRID_TEMPLATE_ROWS    = "grid-template-rows"    // Grid template rows
+	ST_HEIGHT                = "height"                // Height
+	ST_JUSTIFY_CONTENT       = "justify-content"       // Justify content (alignment of flex items along the main axis)
+	ST_MARGIN                = "margin"                // Margin
+	ST_MARGIN_LEFT           = "margin-left"           // Left margin
+	ST_MARGIN_RIGHT          = "margin-right"          // Right margin
+	ST_MARGIN_TOP            = "margin-top"            // Top margin
+	ST_MARGIN_BOTTOM         = "margin-bottom"         // Bottom margin
+	ST_ORDER                 = "order"                 // Order of flex items
+	ST_PADDING               = "padding"               // Padding
+	ST_PADDING_LEFT          = "padding-left"          // Left padding
+	ST_PADDING_RIGHT         = "padding-right"         // Right padding
+	ST_PADDING_TOP           = "padding-top"           // Top padding
+	ST_PADDING_BOTTOM        = "padding-bottom"        // Bottom padding
+	ST_WHITE_SPACE           = "white-space"           // White-space
+	ST_WIDTH                 = "width"                 // Width
 )
 
 // The 17 standard color constants.
@@ -147,6 +160,16 @@ type Style interface {
 	// If the specified class is not found, this is a no-op.
 	RemoveClass(class string) Style
 
+	// HasClass tells if the class name list contains the specified style class name.
+	HasClass(class string) bool
+
+	// AddStyleClass adds the specified style classes to the class name list.
+	AddStyleClass(classes ...StyleClass) Style
+
+	// RemoveStyleClass removes the specified style classes.
+	// Classes not found are skipped.
+	RemoveStyleClass(classes ...StyleClass) Style
+
 	// Get returns the explicitly set value of the specified style attribute.
 	// Explicitly set style attributes will be concatenated and rendered
 	// as the "style" HTML attribute of the component.
@@ -156,6 +179,14 @@ type Style interface {
 	// Pass an empty string value to delete the specified style attribute.
 	Set(name, value string) Style
 
+	// ApplyCSS parses a CSS declaration string (e.g. "margin: 4px; color: #333")
+	// and sets the declared style attributes, making it easy to port existing
+	// CSS snippets. Comments are allowed, property names are lowercased.
+	// Invalid declarations are skipped and are reported in the returned
+	// error of type CSSErrors; valid declarations are set even if there are
+	// invalid ones.
+	ApplyCSS(css string) error
+
 	// Size returns the size.
 	Size() (width, height string)
 
@@ -465,6 +496,15 @@ func (s *styleImpl) RemoveClass(class string) Style {
 	return s
 }
 
+func (s *styleImpl) HasClass(class string) bool {
+	for _, class_ := range s.classes {
+		if class_ == class {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *styleImpl) Get(name string) string {
 	return s.attrs[name]
 }
@@ -800,7 +840,7 @@ func (s *styleImpl) renderClasses(w writer) {
 			if i > 0 {
 				w.Write(_STR_SPACE)
 			}
-			w.Writes(class)
+			w.Writees(class)
 		}
 		w.Write(_STR_QUOTE)
 	}
@@ -808,9 +848,9 @@ func (s *styleImpl) renderClasses(w writer) {
 
 func (s *styleImpl) renderAttrs(w writer) {
 	for name, value := range s.attrs {
-		w.Writes(name)
+		w.Writees(name)
 		w.Write(_STR_COLON)
-		w.Writes(value)
+		w.Writees(value)
 		w.Write(_STR_SEMICOL)
 	}
 }
@@ -0,0 +1,111 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Style class registry.
+
+package gwu
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// StyleClass is the type of style class names.
+//
+// Style classes of the built-in themes and of the themes added to servers
+// (see Server.AddTheme()) are registered automatically, custom classes
+// can be registered by RegisterStyleClass().
+type StyleClass string
+
+// String returns the name of the style class.
+func (c StyleClass) String() string {
+	return string(c)
+}
+
+// Valid tells if the style class is registered.
+func (c StyleClass) Valid() bool {
+	styleClassesMutex.RLock()
+	defer styleClassesMutex.RUnlock()
+	return styleClasses[c]
+}
+
+var (
+	styleClasses      = make(map[StyleClass]bool) // Registered style classes
+	styleClassesMutex sync.RWMutex                // Mutex to synchronize registered style classes access
+)
+
+// Regexp of valid style class names (CSS identifiers).
+var styleClassRegexp = regexp.MustCompile(`^-?[_a-zA-Z][_a-zA-Z0-9-]*$`)
+
+// Regexps to find class names in the selectors of CSS code.
+var (
+	cssBlockRegexp = regexp.MustCompile(`\{[^}]*\}`)
+	cssClassRegexp = regexp.MustCompile(`\.(-?[_a-zA-Z][_a-zA-Z0-9-]*)`)
+)
+
+// RegisterStyleClass registers a style class with the specified name,
+// and returns it. Registering an already registered class is allowed.
+// An error is returned if name is not a valid CSS class name.
+func RegisterStyleClass(name string) (StyleClass, error) {
+	if !styleClassRegexp.MatchString(name) {
+		return "", errors.New("Invalid style class name: " + name)
+	}
+
+	styleClassesMutex.Lock()
+	defer styleClassesMutex.Unlock()
+	styleClasses[StyleClass(name)] = true
+	return StyleClass(name), nil
+}
+
+// RegisteredStyleClasses returns the registered style classes, sorted by name.
+func RegisteredStyleClasses() []StyleClass {
+	styleClassesMutex.RLock()
+	defer styleClassesMutex.RUnlock()
+
+	classes := make([]StyleClass, 0, len(styleClasses))
+	for c := range styleClasses {
+		classes = append(classes, c)
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i] < classes[j] })
+	return classes
+}
+
+// registerCssClasses registers the style classes used in the selectors
+// of the specified CSS code.
+func registerCssClasses(css []byte) {
+	selectors := cssBlockRegexp.ReplaceAll(css, nil)
+
+	styleClassesMutex.Lock()
+	defer styleClassesMutex.Unlock()
+	for _, m := range cssClassRegexp.FindAllSubmatch(selectors, -1) {
+		styleClasses[StyleClass(m[1])] = true
+	}
+}
+
+func (s *styleImpl) AddStyleClass(classes ...StyleClass) Style {
+	for _, c := range classes {
+		s.classes = append(s.classes, string(c))
+	}
+	return s
+}
+
+func (s *styleImpl) RemoveStyleClass(classes ...StyleClass) Style {
+	for _, c := range classes {
+		s.RemoveClass(string(c))
+	}
+	return s
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Parsing CSS declaration strings into styles.
+
+package gwu
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CSSError describes an invalid declaration of a CSS declaration string
+// (see Style.ApplyCSS()).
+type CSSError struct {
+	Decl   string // The invalid declaration
+	Offset int    // Byte offset of the declaration in the CSS declaration string
+	Msg    string // Description of the problem
+}
+
+// Error returns the description of the invalid declaration.
+func (e *CSSError) Error() string {
+	return fmt.Sprintf("Invalid CSS declaration at offset %d: %q: %s", e.Offset, e.Decl, e.Msg)
+}
+
+// CSSErrors is the error returned by Style.ApplyCSS(),
+// listing all invalid declarations.
+type CSSErrors []*CSSError
+
+// Error returns the descriptions of the invalid declarations, one per line.
+func (es CSSErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Regexp of valid CSS property names (custom properties included).
+var cssPropRegexp = regexp.MustCompile(`^(--[_a-zA-Z0-9-]+|-?[a-z][a-z0-9-]*)$`)
+
+func (s *styleImpl) ApplyCSS(css string) error {
+	var errs CSSErrors
+
+	for _, d := range splitCssDecls(css) {
+		decl := strings.TrimSpace(d.text)
+		if len(decl) == 0 {
+			continue
+		}
+		name, value, msg := parseCssDecl(decl)
+		if len(msg) > 0 {
+			errs = append(errs, &CSSError{Decl: decl, Offset: d.offset + strings.Index(d.text, decl), Msg: msg})
+			continue
+		}
+		s.Set(name, value)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// cssDecl is a declaration of a CSS declaration string.
+type cssDecl struct {
+	text   string // Text of the declaration (comments replaced with spaces)
+	offset int    // Byte offset of the declaration
+}
+
+// splitCssDecls splits a CSS declaration string into declarations
+// separated by semicolons (outside of strings and parentheses).
+// Comments are replaced with spaces.
+func splitCssDecls(css string) []cssDecl {
+	b := []byte(css)
+	var decls []cssDecl
+	start, depth := 0, 0
+	var quote byte // Quote char of the current string, 0 if not in a string
+
+	for i := 0; i < len(b); i++ {
+		ch := b[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				end = len(b)
+			} else {
+				end += i + 4
+			}
+			for ; i < end; i++ {
+				b[i] = ' '
+			}
+			i--
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == ';' && depth <= 0:
+			decls = append(decls, cssDecl{text: string(b[start:i]), offset: start})
+			start, depth = i+1, 0
+		}
+	}
+	return append(decls, cssDecl{text: string(b[start:]), offset: start})
+}
+
+// parseCssDecl parses and validates a declaration, and returns
+// the property name and value. Double quoted strings of the value
+// are converted to single quoted ones (values are rendered in a
+// double quoted HTML attribute).
+// If the declaration is invalid, the description of the problem is returned in msg.
+func parseCssDecl(decl string) (name, value, msg string) {
+	i := strings.IndexByte(decl, ':')
+	if i < 0 {
+		return "", "", "missing colon"
+	}
+
+	name = strings.TrimSpace(decl[:i])
+	if !strings.HasPrefix(name, "--") {
+		name = strings.ToLower(name)
+	}
+	if !cssPropRegexp.MatchString(name) {
+		return "", "", "invalid property name"
+	}
+
+	value = strings.TrimSpace(decl[i+1:])
+	if len(value) == 0 {
+		return "", "", "missing value"
+	}
+	lower := strings.ToLower(value)
+	if strings.Contains(lower, "expression(") || strings.Contains(lower, "javascript:") {
+		return "", "", "unsafe value"
+	}
+
+	v := []byte(value)
+	depth := 0
+	var quote byte // Quote char of the current string, 0 if not in a string
+	for j := 0; j < len(v); j++ {
+		ch := v[j]
+		switch {
+		case ch < ' ' || ch == '<' || ch == '>':
+			return "", "", "invalid character in value"
+		case quote != 0:
+			if ch == '\\' && j+1 < len(v) {
+				if j++; v[j] == '"' {
+					return "", "", "unsupported quote in string"
+				}
+			} else if ch == quote {
+				quote = 0
+				v[j] = '\''
+			} else if ch == '\'' || ch == '"' {
+				return "", "", "unsupported quote in string"
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+			v[j] = '\''
+		case ch == '(':
+			depth++
+		case ch == ')':
+			if depth--; depth < 0 {
+				return "", "", "unbalanced parentheses"
+			}
+		}
+	}
+	if quote != 0 {
+		return "", "", "unterminated string"
+	}
+	if depth != 0 {
+		return "", "", "unbalanced parentheses"
+	}
+
+	return name, string(v), ""
+}
@@ -17,6 +17,34 @@
 
 package gwu
 
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Cell editor type.
+type CellEditor int
+
+// Cell editors.
+const (
+	CELL_EDITOR_NONE  CellEditor = iota // No editor, the column is not editable
+	CELL_EDITOR_TEXT                    // Text box editor
+	CELL_EDITOR_CHECK                   // Check box editor, values are "true" and "false"
+	CELL_EDITOR_LIST                    // List box (drop-down) editor of the specified values
+)
+
+// Row selection mode type.
+type SelectionMode int
+
+// Row selection modes.
+const (
+	SELECTION_NONE   SelectionMode = iota // Rows are not selectable
+	SELECTION_SINGLE                      // Only one row can be selected
+	SELECTION_MULTI                       // Multiple rows can be selected, clicking on a row toggles its selection
+)
+
 // Table interface defines a container which lays out its children
 // using a configurable, flexible table.
 // The size of the table grows dynamically, on demand. However,
@@ -24,7 +52,26 @@ package gwu
 // it is recommended to call EnsureSize to minimize reallocations
 // in the background.
 // 
-// Default style class: "gwu-Table"
+// Columns can be made sortable with SetSortable(), in which case the first row
+// is the header row: clicking on the header of a sortable column sorts the
+// rest of the rows by that column (clicking again reverses the order).
+// Sorting only changes the order in which the rows are displayed, the rows
+// keep their indices (e.g. in CompAt()). ETYPE_SORT event handlers are called
+// when the user sorts the table.
+// 
+// Columns can be made editable with SetEditable(), in which case the cells
+// of the column (except the first, header row) are rendered as in-place
+// editors, initialized with the text of the cell components (if they implement
+// HasText). When the user edits a cell, the text of the cell component is updated
+// (a Label is created for empty cells), and ETYPE_CELL_EDITED event handlers
+// are called. The edited cell and its new value are available with EditedCell().
+// 
+// Rows can be made selectable with SetSelectionMode(), in which case clicking
+// on a row (except the first, header row) selects it, and ETYPE_ROW_SELECT
+// event handlers are called. The clicked row is available with EventRow().
+// 
+// Default style classes: "gwu-Table", "gwu-Table-Sortable", "gwu-Table-SortInd",
+// "gwu-Table-Editor", "gwu-Table-SelRow", "gwu-Table-Selected"
 type Table interface {
 	// Table is a TableView.
 	TableView
@@ -81,6 +128,62 @@ type Table interface {
 	// If the table does not have a cell specified by row and col,
 	// this is a no-op.
 	SetColSpan(row, col, colSpan int)
+
+	// SetSortable makes a column sortable by the specified less function,
+	// which tells if the row with index r1 should be displayed before
+	// the row with index r2 in ascending order.
+	// Pass nil to make the column not sortable.
+	SetSortable(col int, less func(r1, r2 int) bool)
+
+	// SortCol returns the column the table is sorted by.
+	// -1 is returned if the table is not sorted.
+	SortCol() int
+
+	// SortAsc tells if the table is sorted in ascending order.
+	SortAsc() bool
+
+	// Sort sorts the table by the specified (sortable) column.
+	// Pass -1 to display the rows in their original order.
+	Sort(col int, asc bool)
+
+	// SetEditable makes a column editable with the specified editor.
+	// values are the selectable values in case of CELL_EDITOR_LIST.
+	// Pass CELL_EDITOR_NONE to make the column not editable.
+	SetEditable(col int, editor CellEditor, values ...string)
+
+	// Editable returns the editor of the specified column.
+	Editable(col int) CellEditor
+
+	// EditedCell returns the row and column of the last edited cell,
+	// and its new value.
+	// Meaningful only in ETYPE_CELL_EDITED event handlers.
+	EditedCell() (row, col int, value string)
+
+	// SelectionMode returns the row selection mode.
+	SelectionMode() SelectionMode
+
+	// SetSelectionMode sets the row selection mode.
+	// Changing the selection mode clears the selection.
+	// Default is SELECTION_NONE.
+	SetSelectionMode(mode SelectionMode)
+
+	// Selected tells if the specified row is selected.
+	Selected(row int) bool
+
+	// SetSelected sets the selection of the specified row.
+	// In SELECTION_SINGLE mode selecting a row deselects the others.
+	// This is a no-op if rows are not selectable.
+	SetSelected(row int, selected bool)
+
+	// SelectedRows returns the indices of the selected rows in ascending order.
+	SelectedRows() []int
+
+	// ClearSelection deselects all rows.
+	ClearSelection()
+
+	// EventRow returns the row the user clicked on.
+	// Meaningful only in ETYPE_ROW_SELECT event handlers.
+	EventRow() int
 }
 
 // cellIdx type specifies a cell by its row and col indices.
@@ -95,13 +198,32 @@ type tableImpl struct {
 	comps    [][]Comp                 // Components added to the table. Structure: comps[rowIdx][colIdx]
 	rowFmts  map[int]*cellFmtImpl     // Lazily initialized row formatters of the rows
 	cellFmts map[cellIdx]*cellFmtImpl // Lazily initialized cell formatters of the cells
+
+	sortLess map[int]func(r1, r2 int) bool // Less functions of the sortable columns
+	sortCol  int                           // Column the table is sorted by, -1 if not sorted
+	sortAsc  bool                          // Tells if the table is sorted in ascending order
+
+	editors   map[int]*cellEditor // Editors of the editable columns
+	editedRow int                 // Row of the last edited cell
+	editedCol int                 // Column of the last edited cell
+	editedVal string              // New value of the last edited cell
+
+	selMode  SelectionMode // Row selection mode
+	selected map[int]bool  // Selected rows. Lazily initialized.
+	eventRow int           // The row the user clicked on
+}
+
+// cellEditor describes the editor of an editable column.
+type cellEditor struct {
+	editor CellEditor // Type of the editor
+	values []string   // Selectable values in case of CELL_EDITOR_LIST
 }
 
 // NewTable creates a new Table.
 // Default horizontal alignment is HA_DEFAULT,
 // default vertical alignment is VA_DEFAULT.
 func NewTable() Table {
-	c := &tableImpl{tableViewImpl: newTableViewImpl()}
+	c := &tableImpl{tableViewImpl: newTableViewImpl(), sortCol: -1, editedRow: -1, editedCol: -1, eventRow: -1}
 	c.Style().AddClass("gwu-Table")
 	c.SetCellSpacing(0)
 	c.SetCellPadding(0)
@@ -156,10 +278,13 @@ func (c *tableImpl) Clear() {
 
 	for _, rowComps := range c.comps {
 		for _, c2 := range rowComps {
-			c2.setParent(nil)
+			if c2 != nil {
+				c2.setParent(nil)
+			}
 		}
 	}
 	c.comps = nil
+	c.selected = nil
 }
 
 func (c *tableImpl) EnsureSize(rows, cols int) {
@@ -332,6 +457,195 @@ func (c *tableImpl) SetColSpan(row, col, colSpan int) {
 	}
 }
 
+func (c *tableImpl) SetSortable(col int, less func(r1, r2 int) bool) {
+	if less == nil {
+		delete(c.sortLess, col)
+		if c.sortCol == col {
+			c.sortCol = -1
+		}
+		return
+	}
+	if c.sortLess == nil {
+		c.sortLess = make(map[int]func(r1, r2 int) bool)
+	}
+	c.sortLess[col] = less
+}
+
+func (c *tableImpl) SortCol() int {
+	return c.sortCol
+}
+
+func (c *tableImpl) SortAsc() bool {
+	return c.sortAsc
+}
+
+func (c *tableImpl) Sort(col int, asc bool) {
+	if c.sortLess[col] == nil {
+		col = -1
+	}
+	c.sortCol, c.sortAsc = col, asc
+}
+
+func (c *tableImpl) SetEditable(col int, editor CellEditor, values ...string) {
+	if editor == CELL_EDITOR_NONE {
+		delete(c.editors, col)
+		return
+	}
+	if c.editors == nil {
+		c.editors = make(map[int]*cellEditor)
+	}
+	c.editors[col] = &cellEditor{editor: editor, values: values}
+}
+
+func (c *tableImpl) Editable(col int) CellEditor {
+	if ce := c.editors[col]; ce != nil {
+		return ce.editor
+	}
+	return CELL_EDITOR_NONE
+}
+
+func (c *tableImpl) EditedCell() (row, col int, value string) {
+	return c.editedRow, c.editedCol, c.editedVal
+}
+
+func (c *tableImpl) preprocessEvent(event Event, r *http.Request) {
+	switch event.Type() {
+	case ETYPE_SORT:
+		c.preprocessSort(event, r)
+	case ETYPE_CELL_EDITED:
+		c.preprocessCellEdited(event, r)
+	case ETYPE_ROW_SELECT:
+		c.preprocessRowSelect(event, r)
+	}
+}
+
+// preprocessSort handles a sort request of the user.
+func (c *tableImpl) preprocessSort(event Event, r *http.Request) {
+	col, err := strconv.Atoi(r.FormValue(_PARAM_COMP_VALUE))
+	if err != nil || c.sortLess[col] == nil {
+		return
+	}
+	if col == c.sortCol {
+		c.sortAsc = !c.sortAsc
+	} else {
+		c.sortCol, c.sortAsc = col, true
+	}
+	event.MarkDirty(c)
+}
+
+// preprocessCellEdited stores the new value of the cell edited by the user.
+// Value of the event is in the form of "row,col,value".
+func (c *tableImpl) preprocessCellEdited(event Event, r *http.Request) {
+	parts := strings.SplitN(r.FormValue(_PARAM_COMP_VALUE), ",", 3)
+	if len(parts) != 3 {
+		return
+	}
+	row, err := strconv.Atoi(parts[0])
+	if err != nil || row < 1 || row >= len(c.comps) {
+		return
+	}
+	col, err := strconv.Atoi(parts[1])
+	if err != nil || col < 0 || col >= len(c.comps[row]) || c.editors[col] == nil {
+		return
+	}
+	// Cells are not editable in read-only containers
+	if c.inReadOnlyContainer() {
+		return
+	}
+
+	value := eventSanitation(event).Apply(parts[2])
+	c.editedRow, c.editedCol, c.editedVal = row, col, value
+
+	if c2 := c.comps[row][col]; c2 == nil {
+		c.Add(NewLabel(value), row, col)
+	} else if t, ok := c2.(HasText); ok {
+		t.SetText(value)
+	}
+	// The client already displays the new value, no need to mark the table dirty
+	// unless it was changed by sanitation.
+	if value != parts[2] {
+		event.MarkDirty(c)
+	}
+}
+
+func (c *tableImpl) SelectionMode() SelectionMode {
+	return c.selMode
+}
+
+func (c *tableImpl) SetSelectionMode(mode SelectionMode) {
+	if mode != c.selMode {
+		c.selMode = mode
+		c.selected = nil
+	}
+}
+
+func (c *tableImpl) Selected(row int) bool {
+	return c.selected[row]
+}
+
+func (c *tableImpl) SetSelected(row int, selected bool) {
+	if c.selMode == SELECTION_NONE {
+		return
+	}
+	if !selected {
+		delete(c.selected, row)
+		return
+	}
+	if c.selected == nil || c.selMode == SELECTION_SINGLE {
+		c.selected = make(map[int]bool)
+	}
+	c.selected[row] = true
+}
+
+func (c *tableImpl) SelectedRows() []int {
+	rows := make([]int, 0, len(c.selected))
+	for row := range c.selected {
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+func (c *tableImpl) ClearSelection() {
+	c.selected = nil
+}
+
+func (c *tableImpl) EventRow() int {
+	return c.eventRow
+}
+
+// preprocessRowSelect handles a click of the user on a selectable row.
+func (c *tableImpl) preprocessRowSelect(event Event, r *http.Request) {
+	row, err := strconv.Atoi(r.FormValue(_PARAM_COMP_VALUE))
+	if err != nil || row < 1 || row >= len(c.comps) || c.selMode == SELECTION_NONE {
+		return
+	}
+
+	c.eventRow = row
+	c.SetSelected(row, c.selMode == SELECTION_SINGLE || !c.selected[row])
+	event.MarkDirty(c)
+}
+
+// rowOrder returns the indices of the rows in the order they are displayed.
+func (c *tableImpl) rowOrder() []int {
+	rows := make([]int, len(c.comps))
+	for i := range rows {
+		rows[i] = i
+	}
+
+	if less := c.sortLess[c.sortCol]; less != nil && len(rows) > 2 {
+		// First row is the header
+		data := rows[1:]
+		sort.SliceStable(data, func(i, j int) bool {
+			if c.sortAsc {
+				return less(data[i], data[j])
+			}
+			return less(data[j], data[i])
+		})
+	}
+	return rows
+}
+
 func (c *tableImpl) Render(w writer) {
 	w.Write(_STR_TABLE_OP)
 	c.renderAttrsAndStyle(w)
@@ -341,12 +655,17 @@ func (c *tableImpl) Render(w writer) {
 	// Create a reusable cell index
 	ci := cellIdx{}
 
-	for row, rowComps := range c.comps {
+	readOnly := c.inReadOnlyContainer()
+	for _, row := range c.rowOrder() {
 		c.renderRowTr(row, w)
-		for col, c2 := range rowComps {
+		for col, c2 := range c.comps[row] {
 			ci.row, ci.col = row, col
 			c.renderTd(ci, w)
-			if c2 != nil {
+			if row == 0 && c.sortLess[col] != nil {
+				c.renderSortHeader(col, c2, w)
+			} else if ce := c.editors[col]; row > 0 && ce != nil && !readOnly {
+				c.renderEditor(row, col, ce, c2, w)
+			} else if c2 != nil {
 				c2.Render(w)
 			}
 		}
@@ -355,12 +674,117 @@ func (c *tableImpl) Render(w writer) {
 	w.Write(_STR_TABLE_CL)
 }
 
+var (
+	_STR_SORT_HEADER_OP = []byte(`<div class="gwu-Table-Sortable" onclick="se(event,`) // `<div class="gwu-Table-Sortable" onclick="se(event,`
+	_STR_SORT_HEADER_CL = []byte(`)">`)                                                // `)">`
+	_STR_SORT_IND_OP    = []byte(`<span class="gwu-Table-SortInd">`)                   // `<span class="gwu-Table-SortInd">`
+	_STR_SORT_ASC       = []byte("&#9650;")                                            // "&#9650;"
+	_STR_SORT_DESC      = []byte("&#9660;")                                            // "&#9660;"
+)
+
+// renderSortHeader renders the header of a sortable column,
+// with the sort direction indicator if the table is sorted by the column.
+func (c *tableImpl) renderSortHeader(col int, c2 Comp, w writer) {
+	// To render: <div class="gwu-Table-Sortable" onclick="se(event,etype,compId,col)">comp<span class="gwu-Table-SortInd">indicator</span></div>
+	w.Write(_STR_SORT_HEADER_OP)
+	w.Writevs(int(ETYPE_SORT), _STR_COMMA, int(c.id), _STR_COMMA, col)
+	w.Write(_STR_SORT_HEADER_CL)
+	if c2 != nil {
+		c2.Render(w)
+	}
+	w.Write(_STR_SORT_IND_OP)
+	if col == c.sortCol {
+		if c.sortAsc {
+			w.Write(_STR_SORT_ASC)
+		} else {
+			w.Write(_STR_SORT_DESC)
+		}
+	}
+	w.Write(_STR_SPAN_CL)
+	w.Write(_STR_DIV_CL)
+}
+
+var (
+	_STR_EDITOR_TEXT_OP  = []byte(`<input type="text" class="gwu-Table-Editor" value="`) // `<input type="text" class="gwu-Table-Editor" value="`
+	_STR_EDITOR_CHECK_OP = []byte(`<input type="checkbox" class="gwu-Table-Editor"`)     // `<input type="checkbox" class="gwu-Table-Editor"`
+	_STR_EDITOR_LIST_OP  = []byte(`<select class="gwu-Table-Editor"`)                    // `<select class="gwu-Table-Editor"`
+	_STR_EDITOR_LIST_CL  = []byte("</select>")                                           // "</select>"
+	_STR_EDITOR_CHECKED  = []byte(" checked")                                            // " checked"
+	_STR_EDITOR_CLICK    = []byte(` onclick="event.stopPropagation()"`)                  // ` onclick="event.stopPropagation()"`
+	_STR_EDITOR_CHANGE   = []byte(` onchange="se(event,`)                                // ` onchange="se(event,`
+	_STR_EDITOR_VAL      = []byte(`,'`)                                                  // `,'`
+	_STR_EDITOR_VAL_TEXT = []byte(`,'+encodeURIComponent(this.value))"`)                 // `,'+encodeURIComponent(this.value))"`
+	_STR_EDITOR_VAL_CHK  = []byte(`,'+this.checked)"`)                                   // `,'+this.checked)"`
+	_STR_EDITOR_OPTION   = []byte("<option")                                             // "<option"
+	_STR_EDITOR_SELECTED = []byte(" selected")                                           // " selected"
+	_STR_EDITOR_OPT_CL   = []byte("</option>")                                           // "</option>"
+)
+
+// renderEditor renders the editor of an editable cell.
+func (c *tableImpl) renderEditor(row, col int, ce *cellEditor, c2 Comp, w writer) {
+	var value string
+	if t, ok := c2.(HasText); ok {
+		value = t.Text()
+	}
+
+	switch ce.editor {
+	case CELL_EDITOR_CHECK:
+		// To render: <input type="checkbox" class="gwu-Table-Editor" checked onchange="se(event,etype,compId,'row,col,'+this.checked)">
+		w.Write(_STR_EDITOR_CHECK_OP)
+		if b, _ := strconv.ParseBool(value); b {
+			w.Write(_STR_EDITOR_CHECKED)
+		}
+		c.renderEditorChange(row, col, w)
+		w.Write(_STR_EDITOR_VAL_CHK)
+		w.Write(_STR_GT)
+	case CELL_EDITOR_LIST:
+		// To render: <select class="gwu-Table-Editor" onchange="se(event,etype,compId,'row,col,'+encodeURIComponent(this.value))"><option selected>value</option>...</select>
+		w.Write(_STR_EDITOR_LIST_OP)
+		c.renderEditorChange(row, col, w)
+		w.Write(_STR_EDITOR_VAL_TEXT)
+		w.Write(_STR_GT)
+		for _, v := range ce.values {
+			w.Write(_STR_EDITOR_OPTION)
+			if v == value {
+				w.Write(_STR_EDITOR_SELECTED)
+			}
+			w.Write(_STR_GT)
+			w.Writees(v)
+			w.Write(_STR_EDITOR_OPT_CL)
+		}
+		w.Write(_STR_EDITOR_LIST_CL)
+	default:
+		// To render: <input type="text" class="gwu-Table-Editor" value="value" onchange="se(event,etype,compId,'row,col,'+encodeURIComponent(this.value))">
+		w.Write(_STR_EDITOR_TEXT_OP)
+		w.Writees(value)
+		w.Write(_STR_QUOTE)
+		c.renderEditorChange(row, col, w)
+		w.Write(_STR_EDITOR_VAL_TEXT)
+		w.Write(_STR_GT)
+	}
+}
+
+// renderEditorChange renders the event handler attributes of a cell editor
+// up to the cell index part of the event value: ` onclick="..." onchange="se(event,etype,compId,'row,col`
+// Clicks are not propagated so editing a cell does not select its row.
+func (c *tableImpl) renderEditorChange(row, col int, w writer) {
+	w.Write(_STR_EDITOR_CLICK)
+	w.Write(_STR_EDITOR_CHANGE)
+	w.Writevs(int(ETYPE_CELL_EDITED), _STR_COMMA, int(c.id), _STR_EDITOR_VAL, row, _STR_COMMA, col)
+}
+
 // renderRowTr renders the formatted HTML TR tag for the specified row.
 func (c *tableImpl) renderRowTr(row int, w writer) {
 	var defha HAlign = c.halign // default halign of the table
 	var defva VAlign = c.valign // default valign of the table
 
-	if rf := c.rowFmts[row]; rf == nil {
+	rf := c.rowFmts[row]
+	tag := _STR_TR_OP
+	if row > 0 && c.selMode != SELECTION_NONE {
+		rf, tag = c.selRowFmt(row, rf), c.selRowTag(row)
+	}
+
+	if rf == nil {
 		c.renderTr(w)
 	} else {
 		// If rf does not specify alignments, it means alignments must not be overriden,
@@ -373,8 +797,36 @@ func (c *tableImpl) renderRowTr(row int, w writer) {
 			va = defva
 		}
 
-		rf.renderWithAligns(_STR_TR_OP, ha, va, w)
+		rf.renderWithAligns(tag, ha, va, w)
+	}
+}
+
+// selRowFmt returns a copy of the specified row formatter (which may be nil)
+// extended with the style classes of a selectable row.
+func (c *tableImpl) selRowFmt(row int, rf *cellFmtImpl) *cellFmtImpl {
+	sf := newCellFmtImpl()
+	if rf != nil {
+		*sf = *rf
+	}
+
+	st := newStyleImpl()
+	if sf.styleImpl != nil {
+		*st = *sf.styleImpl
+		st.classes = append([]string(nil), st.classes...)
 	}
+	st.classes = append(st.classes, "gwu-Table-SelRow")
+	if c.selected[row] {
+		st.classes = append(st.classes, "gwu-Table-Selected")
+	}
+	sf.styleImpl = st
+
+	return sf
+}
+
+// selRowTag returns the opening TR tag of a selectable row:
+// `<tr onclick="se(event,etype,compId,row)"`
+func (c *tableImpl) selRowTag(row int) []byte {
+	return []byte(`<tr onclick="se(event,` + strconv.Itoa(int(ETYPE_ROW_SELECT)) + "," + strconv.Itoa(int(c.id)) + "," + strconv.Itoa(row) + `)"`)
 }
 
 // renderTd renders the formatted HTML TD tag for the specified cell.
@@ -17,6 +17,12 @@
 
 package gwu
 
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
 // TabBar interface defines the tab bar for selecting the visible
 // component of a TabPanel.
 // 
@@ -60,6 +66,10 @@ func (c *tabBarImpl) Remove(c2 Comp) bool {
 	return c.panelImpl.Remove(c2)
 }
 
+// TabSelectionHandler is a function which is called when the user selects
+// another tab of a TabPanel. oldIdx is -1 if no tab was selected.
+type TabSelectionHandler func(e Event, oldIdx, newIdx int)
+
 // Tab bar placement type.
 type TabBarPlacement int
 
@@ -71,6 +81,22 @@ const (
 	TB_PLACEMENT_RIGHT                         // Tab bar placement to Right
 )
 
+// Names of the tab bar placements.
+var tbPlacementNames = []string{"top", "bottom", "left", "right"}
+
+// String returns the name of the tab bar placement, e.g. "top".
+func (p TabBarPlacement) String() string {
+	if p.Valid() {
+		return tbPlacementNames[p]
+	}
+	return strconv.Itoa(int(p))
+}
+
+// Valid tells if the tab bar placement is one of the TB_PLACEMENT_ constants.
+func (p TabBarPlacement) Valid() bool {
+	return p >= TB_PLACEMENT_TOP && p <= TB_PLACEMENT_RIGHT
+}
+
 // TabPanel interface defines a PanelView which has multiple child components
 // but only one is visible at a time. The visible child can be visually selected
 // using an internal TabBar component.
@@ -89,9 +115,16 @@ const (
 // other cell formatting applied to the tab bar using TabBarFmt() method.
 // 
 // You can register ETYPE_STATE_CHANGE event handlers which will be called when the user
-// changes tab selection by clicking on a tab. The event source will be the tab panel.
+// changes tab selection by clicking on another tab. The event source will be the tab panel.
 // The event will have a parent event whose source will be the clicked tab and will
-// contain the mouse coordinates.
+// contain the mouse coordinates. The old and new selected tab indices can be acquired
+// by PrevSelected() and Selected(). Alternatively you can set a selection handler
+// with SetSelectionHandler() which receives the old and new indices as arguments.
+// 
+// If tabs are reorderable (see SetTabsReorderable()), the user can drag tabs along
+// the tab bar. You can register ETYPE_TAB_MOVED event handlers which will be called
+// when the user moves a tab. The event source will be the tab panel, the old and
+// new positions can be acquired by MovedTab().
 // 
 // Default style classes: "gwu-TabPanel", "gwu-TabPanel-Content"
 type TabPanel interface {
@@ -134,6 +167,33 @@ type TabPanel interface {
 	// If idx < 0, no tabs will be selected.
 	// If idx > CompsCount(), this is a no-op.
 	SetSelected(idx int)
+
+	// SelectionHandler returns the tab selection handler.
+	SelectionHandler() TabSelectionHandler
+
+	// SetSelectionHandler sets the tab selection handler which is called
+	// when the user selects another tab, before the ETYPE_STATE_CHANGE
+	// event handlers. The handler may build the content of the selected
+	// tab lazily, the tab panel is marked dirty anyway.
+	// The event passed to the handler is the ETYPE_STATE_CHANGE event.
+	SetSelectionHandler(h TabSelectionHandler)
+
+	// TabsReorderable tells if tabs can be reordered by dragging them
+	// along the tab bar.
+	TabsReorderable() bool
+
+	// SetTabsReorderable sets if tabs can be reordered by dragging them
+	// along the tab bar. Tabs are not reorderable by default.
+	SetTabsReorderable(reorderable bool)
+
+	// MoveTab moves the tab (and its content component) at the from index
+	// to the to index. The selected tab remains selected.
+	// Returns false if an index is invalid.
+	MoveTab(from, to int) bool
+
+	// MovedTab returns the old and new positions of the tab
+	// moved by the user last time.
+	MovedTab() (from, to int)
 }
 
 // TabPanel implementation.
@@ -144,8 +204,14 @@ type tabPanelImpl struct {
 	tabBarPlacement TabBarPlacement // Tab bar placement
 	tabBarFmt       *cellFmtImpl    // Tab bar cell formatter
 
-	selected     int // The selected tab idx
-	prevSelected int // Previous selected tab idx
+	selected     int                 // The selected tab idx
+	prevSelected int                 // Previous selected tab idx
+	selHandler   TabSelectionHandler // Tab selection handler
+
+	reorderable  bool // Tells if tabs are reorderable
+	movedFrom    int  // Old position of the tab moved by the user last time
+	movedTo      int  // New position of the tab moved by the user last time
+	moveRejected bool // Tells if the tab move received from the client was rejected
 }
 
 // NewTabPanel creates a new TabPanel.
@@ -291,11 +357,20 @@ func (c *tabPanelImpl) Add(tab, content Comp) {
 
 	// TODO would be nice to remove this internal handler func when the tab is removed!
 	tab.AddEHandlerFunc(func(e Event) {
-		c.SetSelected(c.CompIdx(content))
+		idx := c.CompIdx(content)
+		if idx == c.selected {
+			return
+		}
+		c.SetSelected(idx)
 		e.MarkDirty(c)
-		if c.handlers[ETYPE_STATE_CHANGE] != nil {
-			c.dispatchEvent(e.forkEvent(ETYPE_STATE_CHANGE, c))
+		if c.selHandler == nil && c.handlers[ETYPE_STATE_CHANGE] == nil {
+			return
+		}
+		e2 := e.forkEvent(ETYPE_STATE_CHANGE, c)
+		if c.selHandler != nil {
+			c.selHandler(e2, c.prevSelected, c.selected)
 		}
+		c.dispatchEvent(e2)
 	}, ETYPE_CLICK)
 }
 
@@ -336,6 +411,134 @@ func (c *tabPanelImpl) SetSelected(idx int) {
 	}
 }
 
+func (c *tabPanelImpl) SelectionHandler() TabSelectionHandler {
+	return c.selHandler
+}
+
+func (c *tabPanelImpl) SetSelectionHandler(h TabSelectionHandler) {
+	c.selHandler = h
+}
+
+func (c *tabPanelImpl) TabsReorderable() bool {
+	return c.reorderable
+}
+
+func (c *tabPanelImpl) SetTabsReorderable(reorderable bool) {
+	c.reorderable = reorderable
+}
+
+// moveIdx returns the new index of the element at idx after moving
+// the element at from to the index to.
+func moveIdx(idx, from, to int) int {
+	switch {
+	case idx == from:
+		return to
+	case from < to && idx > from && idx <= to:
+		return idx - 1
+	case to < from && idx >= to && idx < from:
+		return idx + 1
+	}
+	return idx
+}
+
+// moveComp moves the component at from to the index to in the slice.
+func moveComp(comps []Comp, from, to int) {
+	c := comps[from]
+	if from < to {
+		copy(comps[from:to], comps[from+1:to+1])
+	} else {
+		copy(comps[to+1:from+1], comps[to:from])
+	}
+	comps[to] = c
+}
+
+func (c *tabPanelImpl) MoveTab(from, to int) bool {
+	count := c.CompsCount()
+	if from < 0 || from >= count || to < 0 || to >= count {
+		return false
+	}
+
+	// Cell formatters are mapped from component id, they move with the components.
+	moveComp(c.comps, from, to)
+	moveComp(c.tabBarImpl.comps, from, to)
+
+	if c.selected >= 0 {
+		c.selected = moveIdx(c.selected, from, to)
+	}
+	if c.prevSelected >= 0 {
+		c.prevSelected = moveIdx(c.prevSelected, from, to)
+	}
+	return true
+}
+
+func (c *tabPanelImpl) MovedTab() (from, to int) {
+	return c.movedFrom, c.movedTo
+}
+
+func (c *tabPanelImpl) preprocessEvent(event Event, r *http.Request) {
+	if event.Type() != ETYPE_TAB_MOVED {
+		return
+	}
+
+	// Value format: "from,to"
+	c.moveRejected = true
+	event.MarkDirty(c) // Either moved or rejected, the client has to be updated
+	if !c.reorderable {
+		return
+	}
+	parts := strings.Split(r.FormValue(_PARAM_COMP_VALUE), ",")
+	if len(parts) != 2 {
+		return
+	}
+	from, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return
+	}
+	to, err := strconv.Atoi(parts[1])
+	if err != nil || !c.MoveTab(from, to) {
+		return
+	}
+	c.movedFrom, c.movedTo = from, to
+	c.moveRejected = false
+}
+
+func (c *tabPanelImpl) dispatchEvent(e Event) {
+	if e.Type() == ETYPE_TAB_MOVED && c.moveRejected {
+		c.moveRejected = false
+		return
+	}
+	c.panelImpl.dispatchEvent(e)
+}
+
+var (
+	_STR_TP_REORDER_OP = []byte("<script>tpReorder(") // "<script>tpReorder("
+	_STR_TP_REORDER_CL = []byte("]);</script>")       // "]);</script>"
+)
+
+// renderTabBar renders the tab bar, and if tabs are reorderable,
+// the script which makes tabs draggable.
+func (c *tabPanelImpl) renderTabBar(w writer) {
+	c.tabBarImpl.Render(w)
+
+	if !c.reorderable {
+		return
+	}
+
+	// To render: <script>tpReorder(compId,etype,[tabId,...]);</script>
+	w.writeScript(_STR_TP_REORDER_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_TAB_MOVED))
+	w.Writes(",[")
+	for i, tab := range c.tabBarImpl.comps {
+		if i > 0 {
+			w.Write(_STR_COMMA)
+		}
+		w.Writev(int(tab.Id()))
+	}
+	w.Write(_STR_TP_REORDER_CL)
+}
+
 func (c *tabPanelImpl) Render(w writer) {
 	w.Write(_STR_TABLE_OP)
 	c.renderAttrsAndStyle(w)
@@ -346,7 +549,7 @@ func (c *tabPanelImpl) Render(w writer) {
 	case TB_PLACEMENT_TOP:
 		w.Write(_STR_TR)
 		c.tabBarFmt.render(_STR_TD_OP, w)
-		c.tabBarImpl.Render(w)
+		c.renderTabBar(w)
 		c.renderTr(w)
 		c.renderContent(w)
 	case TB_PLACEMENT_BOTTOM:
@@ -354,17 +557,17 @@ func (c *tabPanelImpl) Render(w writer) {
 		c.renderContent(w)
 		w.Write(_STR_TR)
 		c.tabBarFmt.render(_STR_TD_OP, w)
-		c.tabBarImpl.Render(w)
+		c.renderTabBar(w)
 	case TB_PLACEMENT_LEFT:
 		c.renderTr(w)
 		c.tabBarFmt.render(_STR_TD_OP, w)
-		c.tabBarImpl.Render(w)
+		c.renderTabBar(w)
 		c.renderContent(w)
 	case TB_PLACEMENT_RIGHT:
 		c.renderTr(w)
 		c.renderContent(w)
 		c.tabBarFmt.render(_STR_TD_OP, w)
-		c.tabBarImpl.Render(w)
+		c.renderTabBar(w)
 	}
 
 	w.Write(_STR_TABLE_CL)
@@ -0,0 +1,96 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Tail of components optimized for appending items (e.g. messages or lines).
+
+package gwu
+
+// tailOwner is a component whose appended items are sent to the browser
+// by re-rendering its tail.
+type tailOwner interface {
+	// renderAppended renders the items appended after the specified
+	// sequence number, these are rendered before the tail.
+	renderAppended(w writer, since int)
+
+	// renderAppendedJs renders the script (inside the tail) which processes
+	// the items appended after the specified sequence number in the browser.
+	renderAppendedJs(w writer, since int)
+}
+
+// appendTail is the (invisible) end of a component optimized for appending
+// items. Appending items marks the tail dirty, and re-rendering the tail
+// renders the appended items before a new tail.
+// 
+// Appended items are numbered. The browser sends the sequence number of the
+// last item it has when the tail is re-rendered, so the tail does not have to
+// track what has been sent: rendering does not modify the component, and all
+// clients of the component (e.g. multiple browser tabs) receive all items.
+type appendTail struct {
+	compImpl // Component implementation
+
+	owner tailOwner // Owner of the tail
+	seq   int       // Sequence number of the last appended item
+}
+
+// newAppendTail creates a new appendTail.
+func newAppendTail(owner tailOwner) *appendTail {
+	return &appendTail{compImpl: newCompImpl(nil), owner: owner}
+}
+
+// appended must be called when items are appended to the owner.
+func (c *appendTail) appended(n int) {
+	c.seq += n
+}
+
+// start returns the index of the first of the retained items which was
+// appended after the specified sequence number, n being the number of
+// retained items (the last appended items).
+// ok is false if some items appended after since are not retained anymore.
+func (c *appendTail) start(n, since int) (start int, ok bool) {
+	start = n - (c.seq - since)
+	if start < 0 {
+		return 0, false
+	}
+	if start > n {
+		start = n
+	}
+	return start, true
+}
+
+var (
+	_STR_TAIL_SEQ = []byte(` data-gwu-seq="`) // ` data-gwu-seq="`
+)
+
+// Render renders a tail without appended items.
+func (c *appendTail) Render(w writer) {
+	c.renderSince(w, c.seq)
+}
+
+// renderSince renders the items appended after the specified sequence number
+// followed by a new tail.
+func (c *appendTail) renderSince(w writer, since int) {
+	c.owner.renderAppended(w, since)
+
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	w.Write(_STR_TAIL_SEQ)
+	w.Writev(c.seq)
+	w.Write(_STR_QUOTE)
+	w.Write(_STR_GT)
+	if since < c.seq {
+		c.owner.renderAppendedJs(w, since)
+	}
+	w.Write(_STR_SPAN_CL)
+}
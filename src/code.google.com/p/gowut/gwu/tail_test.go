@@ -0,0 +1,77 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func renderTailSince(tail *appendTail, since int) string {
+	b := &bytes.Buffer{}
+	tail.renderSince(NewWriter(b), since)
+	return b.String()
+}
+
+func TestAppendTailClients(t *testing.T) {
+	lv := NewLogView().(*logViewImpl)
+	lv.AppendLines(nil, "first")
+	since := lv.tail.seq
+	lv.AppendLines(nil, "second", "third")
+
+	// Every client receives the lines it does not have, rendering does not consume them
+	for i := 0; i < 2; i++ {
+		out := renderTailSince(lv.tail, since)
+		if strings.Contains(out, "first") || !strings.Contains(out, "second") || !strings.Contains(out, "third") {
+			t.Errorf("Client %d: unexpected lines: %q", i, out)
+		}
+	}
+	if out := renderTailSince(lv.tail, lv.tail.seq); strings.Contains(out, "third") {
+		t.Errorf("Up to date client received lines: %q", out)
+	}
+}
+
+func TestAppendTailDiscarded(t *testing.T) {
+	ml := NewMessageList().(*messageListImpl)
+	ml.SetMaxMessages(2)
+	for _, text := range []string{"m1", "m2", "m3"} {
+		ml.AppendMessage(Message{Text: text}, nil)
+	}
+
+	out := renderTailSince(ml.tail, 0)
+	if strings.Contains(out, "m1") || !strings.Contains(out, "m2") || !strings.Contains(out, "m3") {
+		t.Errorf("Unexpected messages: %q", out)
+	}
+}
+
+func TestAppendTailTerminal(t *testing.T) {
+	tm := NewTerminal().(*terminalImpl)
+	tm.SetMaxOutput(8)
+	tm.AppendOutput(nil, "abc")
+	since := tm.tail.seq
+	tm.AppendOutput(nil, "def")
+
+	if out := renderTailSince(tm.tail, since); !strings.Contains(out, "tmWrite(") || !strings.Contains(out, ",'def'") {
+		t.Errorf("Expected the appended output: %q", out)
+	}
+
+	// Output not retained anymore: the whole output is sent
+	tm.AppendOutput(nil, "ghijkl")
+	if out := renderTailSince(tm.tail, since); !strings.Contains(out, "tmReset(") || !strings.Contains(out, "'efghijkl'") {
+		t.Errorf("Expected the whole output: %q", out)
+	}
+}
@@ -0,0 +1,431 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Terminal component interface and implementation.
+
+package gwu
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Default max number of output characters retained by a Terminal.
+const DEFAULT_MAX_TERMINAL_OUTPUT = 64 * 1024
+
+// Output read by a Terminal arriving within this time is pushed together.
+const _TERMINAL_PUSH_DELAY = 20 * time.Millisecond
+
+// Terminal interface defines a component which connects the keystrokes
+// of the user and the output to a server-side io.ReadWriter (e.g. a PTY),
+// enabling embedded admin shells and interactive CLI tools.
+//
+// The output is delivered to the browser over the push channel,
+// so the push channel of the window should be enabled
+// (see Window.SetPushEnabled()).
+//
+// This is a simple terminal: it handles new lines, carriage returns
+// (the current line is overwritten) and backspaces, other control characters
+// and ANSI escape sequences (e.g. colors, cursor movement) are removed from the output.
+//
+// Keystrokes are sent in ETYPE_CHANGE events, and are written to the
+// connected io.ReadWriter in the order they were typed.
+//
+// Default style classes: "gwu-Terminal", "gwu-Terminal-Output"
+type Terminal interface {
+	// Terminal is a component.
+	Comp
+
+	// Terminal can be enabled/disabled.
+	// Keystrokes are not sent if disabled.
+	HasEnabled
+
+	// Connect connects the terminal to rw: keystrokes are written to rw,
+	// and a new goroutine is started which reads the output from rw and
+	// appends it to the terminal using sess.Push(), until rw reaches EOF
+	// or returns an error.
+	// sess is the session of the window the terminal is added to
+	// (the Server for public windows).
+	// A connected terminal must be disconnected before connecting it again.
+	Connect(sess Session, rw io.ReadWriter)
+
+	// Connected tells if the terminal is connected.
+	Connected() bool
+
+	// Disconnect disconnects the terminal: keystrokes are no longer written
+	// to the connected io.ReadWriter, and it is closed if it is an io.Closer.
+	Disconnect()
+
+	// Output returns the (retained) output of the terminal.
+	Output() string
+
+	// AppendOutput appends text to the output of the terminal,
+	// e.g. to display messages of the application.
+	// e may be nil if not called during event handling (e.g. when building
+	// the window), else only the new text is sent to the browser.
+	AppendOutput(e Event, text string)
+
+	// ClearOutput clears the output of the terminal.
+	// The terminal has to be marked dirty for the change to be visible.
+	ClearOutput()
+
+	// MaxOutput returns the max number of output characters retained.
+	MaxOutput() int
+
+	// SetMaxOutput sets the max number of output characters retained.
+	// Oldest output is discarded (also in the browser) when this is exceeded.
+	// Pass 0 to retain all output. Default is DEFAULT_MAX_TERMINAL_OUTPUT.
+	SetMaxOutput(max int)
+}
+
+// Terminal implementation.
+type terminalImpl struct {
+	compImpl       // Component implementation
+	hasEnabledImpl // Has enabled implementation
+
+	output      []rune   // Retained output
+	cr          bool     // Tells if the last output char is a carriage return
+	appended    []string // Recently appended output texts, to be sent to the browser by the tail
+	appendedLen int      // Total length of the recently appended output texts
+	maxOutput   int      // Max number of output chars retained
+
+	keys      chan string    // Channel of keystrokes to be written to the connected io.ReadWriter
+	closer    io.Closer      // Closer of the connected io.ReadWriter, if it is an io.Closer
+	keySeq    int            // Sequence number of the next keystrokes to be written
+	keysAhead map[int]string // Keystrokes arrived before preceding ones, mapped from sequence number
+
+	tail *appendTail // Tail of the output, sending appended output
+}
+
+// NewTerminal creates a new Terminal.
+func NewTerminal() Terminal {
+	c := &terminalImpl{compImpl: newInputCompImpl(nil), hasEnabledImpl: newHasEnabledImpl(), maxOutput: DEFAULT_MAX_TERMINAL_OUTPUT}
+	c.tail = newAppendTail(c)
+	c.tail.setParent(c)
+	c.Style().AddClass("gwu-Terminal")
+	return c
+}
+
+// Remove is needed to be the parent of the tail (Container).
+func (c *terminalImpl) Remove(c2 Comp) bool {
+	return false
+}
+
+func (c *terminalImpl) ById(id ID) Comp {
+	switch id {
+	case c.id:
+		return c
+	case c.tail.id:
+		return c.tail
+	}
+	return nil
+}
+
+func (c *terminalImpl) Clear() {
+	c.ClearOutput()
+}
+
+func (c *terminalImpl) Connect(sess Session, rw io.ReadWriter) {
+	keys := make(chan string, 64)
+	c.keys = keys
+	c.closer, _ = rw.(io.Closer)
+
+	// Keystrokes writer
+	go func() {
+		for k := range keys {
+			if _, err := io.WriteString(rw, k); err != nil {
+				break
+			}
+		}
+		// Drain keys so senders are never blocked
+		for _ = range keys {
+		}
+	}()
+
+	// Output reader
+	output := make(chan string)
+	go func() {
+		defer close(output)
+		buf := make([]byte, 4096)
+		var rest []byte // Incomplete UTF-8 sequence from the previous read
+		for {
+			n, err := rw.Read(buf)
+			if n > 0 {
+				data := append(rest, buf[:n]...)
+				i := utf8End(data)
+				output <- string(data[:i])
+				rest = append([]byte(nil), data[i:]...)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		pushCollected(sess, output, _TERMINAL_PUSH_DELAY, func(e Event, texts []string) {
+			c.AppendOutput(e, strings.Join(texts, ""))
+		})
+		sess.Push(func(e Event) {
+			if c.keys == keys {
+				c.disconnect()
+			}
+		})
+	}()
+}
+
+// utf8End returns the length of data without an incomplete
+// UTF-8 sequence at its end.
+func utf8End(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
+
+func (c *terminalImpl) Connected() bool {
+	return c.keys != nil
+}
+
+func (c *terminalImpl) Disconnect() {
+	if c.keys == nil {
+		return
+	}
+	if c.closer != nil {
+		c.closer.Close()
+	}
+	c.disconnect()
+}
+
+// disconnect stops writing keystrokes to the connected io.ReadWriter.
+func (c *terminalImpl) disconnect() {
+	close(c.keys)
+	c.keys, c.closer = nil, nil
+}
+
+func (c *terminalImpl) Output() string {
+	return string(c.output)
+}
+
+// Regexp of the ANSI escape sequences and the control characters
+// (except new line, carriage return, backspace and tab) to be removed from the output.
+var termCtrlRegexp = regexp.MustCompile("\x1b\\[[0-9;?]*[ -/]*[@-~]|\x1b\\][^\x07\x1b]*(\x07|\x1b\\\\)?|\x1b[ -/]*[0-~]?|[\x00-\x07\x0b\x0c\x0e-\x1f\x7f]")
+
+func (c *terminalImpl) AppendOutput(e Event, text string) {
+	text = termCtrlRegexp.ReplaceAllString(text, "")
+	if len(text) == 0 {
+		return
+	}
+
+	c.output, c.cr = termWrite(c.output, c.cr, text)
+
+	if c.maxOutput > 0 && len(c.output) > c.maxOutput {
+		n := len(c.output) - c.maxOutput
+		c.output = append(c.output[:0], c.output[n:]...)
+	}
+
+	// Clients missing more recent output than this are sent the whole output instead
+	limit := c.maxOutput
+	if limit <= 0 {
+		limit = DEFAULT_MAX_TERMINAL_OUTPUT
+	}
+	c.appended = append(c.appended, text)
+	c.appendedLen += len(text)
+	c.tail.appended(1)
+	for len(c.appended) > 0 && c.appendedLen > limit {
+		c.appendedLen -= len(c.appended[0])
+		c.appended = c.appended[1:]
+	}
+
+	if e != nil {
+		e.MarkDirty(c.tail)
+	}
+}
+
+// termWrite writes text to the output of a terminal, and returns the new output.
+// cr tells if the last output char is a carriage return, its new value is also returned.
+// The browser side equivalent is the tmWrite() JavaScript function.
+func termWrite(output []rune, cr bool, text string) ([]rune, bool) {
+	for _, r := range text {
+		if cr && r != '\n' {
+			// Carriage return without new line: the current line is overwritten
+			output = output[:termLineStart(output)]
+		}
+		cr = false
+
+		switch r {
+		case '\r':
+			cr = true
+		case '\b':
+			if n := len(output); n > 0 && output[n-1] != '\n' {
+				output = output[:n-1]
+			}
+		default:
+			output = append(output, r)
+		}
+	}
+	return output, cr
+}
+
+// termLineStart returns the index of the start of the last line of the output.
+func termLineStart(output []rune) int {
+	for i := len(output) - 1; i >= 0; i-- {
+		if output[i] == '\n' {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (c *terminalImpl) ClearOutput() {
+	c.output, c.cr = nil, false
+	c.appended, c.appendedLen = nil, 0
+}
+
+func (c *terminalImpl) MaxOutput() int {
+	return c.maxOutput
+}
+
+func (c *terminalImpl) SetMaxOutput(max int) {
+	c.maxOutput = max
+}
+
+func (c *terminalImpl) preprocessEvent(event Event, r *http.Request) {
+	// Keystrokes are sent in the form of: "seq,keys"
+	parts := strings.SplitN(r.FormValue(_PARAM_COMP_VALUE), ",", 2)
+	if len(parts) != 2 || !c.enabled || c.keys == nil {
+		return
+	}
+	seq, err := strconv.Atoi(parts[0])
+	// Keystrokes too far ahead can't be buffered, they would allow
+	// the client to grow keysAhead without limit.
+	if err != nil || seq < c.keySeq || seq >= c.keySeq+cap(c.keys) {
+		return
+	}
+
+	// Events may arrive out of order, keystrokes must be written in order:
+	if c.keysAhead == nil {
+		c.keysAhead = make(map[int]string)
+	}
+	// Keystrokes are control sequences by nature, only the UTF-8 sanitation applies
+	c.keysAhead[seq] = (eventSanitation(event) & SANITIZE_UTF8).Apply(parts[1])
+	for {
+		keys, found := c.keysAhead[c.keySeq]
+		if !found {
+			break
+		}
+		delete(c.keysAhead, c.keySeq)
+		c.keySeq++
+		// The session is locked, never block it: if the connected io.ReadWriter
+		// does not keep up reading, keystrokes are dropped.
+		select {
+		case c.keys <- keys:
+		default:
+			if ei, ok := event.(*eventImpl); ok && ei.shared.server != nil && ei.shared.server.logger != nil {
+				ei.shared.server.logger.Println("Terminal not read, keystrokes dropped:", c.id)
+			}
+		}
+	}
+}
+
+var (
+	_STR_TM_KEYS      = []byte(` tabindex="0" onkeydown="tmKey(`)   // ` tabindex="0" onkeydown="tmKey(`
+	_STR_TM_PASTE     = []byte(`,event)" onpaste="tmPaste(`)        // `,event)" onpaste="tmPaste(`
+	_STR_TM_KEYS_CL   = []byte(`,event)"`)                          // `,event)"`
+	_STR_TM_PRE_OP    = []byte(`<pre class="gwu-Terminal-Output">`) // `<pre class="gwu-Terminal-Output">`
+	_STR_TM_PRE_CL    = []byte("</pre>")                            // "</pre>"
+	_STR_TM_INIT_OP   = []byte("<script>tmInit(")                   // "<script>tmInit("
+	_STR_TM_WRITE_OP  = []byte("<script>tmWrite(")                  // "<script>tmWrite("
+	_STR_TM_RESET_OP  = []byte("<script>tmReset(")                  // "<script>tmReset("
+	_STR_TM_SCRIPT_CL = []byte(");</script>")                       // ");</script>"
+)
+
+func (c *terminalImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	if c.enabled {
+		// To render: ` tabindex="0" onkeydown="tmKey(compId,event)" onpaste="tmPaste(compId,event)"`
+		w.Write(_STR_TM_KEYS)
+		w.Writev(int(c.id))
+		w.Write(_STR_TM_PASTE)
+		w.Writev(int(c.id))
+		w.Write(_STR_TM_KEYS_CL)
+	}
+	w.Write(_STR_GT)
+
+	w.Write(_STR_TM_PRE_OP)
+	w.Writees(string(c.output))
+	c.tail.Render(w)
+	w.Write(_STR_TM_PRE_CL)
+
+	// To render: <script>tmInit(compId,etype,keySeq,maxOutput,cr);</script>
+	w.writeScript(_STR_TM_INIT_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_CHANGE))
+	w.Write(_STR_COMMA)
+	w.Writev(c.keySeq)
+	w.Write(_STR_COMMA)
+	w.Writev(c.maxOutput)
+	w.Write(_STR_COMMA)
+	w.Writev(c.cr)
+	w.Write(_STR_TM_SCRIPT_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderAppended renders nothing, output is sent by the script of the tail.
+func (c *terminalImpl) renderAppended(w writer, since int) {
+}
+
+func (c *terminalImpl) renderAppendedJs(w writer, since int) {
+	start, ok := c.tail.start(len(c.appended), since)
+	if !ok {
+		// Some of the output is not retained anymore, send the whole output.
+		// To render: <script>tmReset(compId,'output',cr);</script>
+		w.writeScript(_STR_TM_RESET_OP)
+		w.Writev(int(c.id))
+		w.Writess(",'", jsEscape(string(c.output)), "',")
+		w.Writev(c.cr)
+		w.Write(_STR_TM_SCRIPT_CL)
+		return
+	}
+
+	// To render: <script>tmWrite(compId,'text');</script>
+	w.writeScript(_STR_TM_WRITE_OP)
+	w.Writev(int(c.id))
+	w.Writes(",'")
+	for _, text := range c.appended[start:] {
+		w.Writes(jsEscape(text))
+	}
+	w.Writes("'")
+	w.Write(_STR_TM_SCRIPT_CL)
+}
+
+func (c *terminalImpl) usage() ([]Comp, int64) {
+	// Output is stored as runes (4 bytes each)
+	return nil, 4*int64(len(c.output)) + int64(len(c.appended))*_ITEM_FOOTPRINT + int64(c.appendedLen)
+}
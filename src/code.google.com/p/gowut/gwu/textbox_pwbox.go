@@ -22,6 +22,19 @@ import (
 	"strconv"
 )
 
+// Text box input type.
+type TextBoxType string
+
+// Text box input type constants.
+const (
+	TBT_TEXT   TextBoxType = "text"   // Plain text
+	TBT_EMAIL  TextBoxType = "email"  // E-mail address
+	TBT_NUMBER TextBoxType = "number" // Number
+	TBT_URL    TextBoxType = "url"    // URL
+	TBT_TEL    TextBoxType = "tel"    // Telephone number
+	TBT_SEARCH TextBoxType = "search" // Search text
+)
+
 // TextBox interface defines a component for text input purpose.
 // 
 // Suggested event type to handle actions: ETYPE_CHANGE
@@ -45,6 +58,12 @@ type TextBox interface {
 	// TextBox can be enabled/disabled.
 	HasEnabled
 
+	// TextBox has a versioned value (text).
+	HasValueVersion
+
+	// TextBox has validators of the text.
+	HasValidators
+
 	// ReadOnly returns if the text box is read-only.
 	ReadOnly() bool
 
@@ -74,6 +93,24 @@ type TextBox interface {
 	// allowed in the text box.
 	// Pass -1 to not limit the maximum length.
 	SetMaxLength(maxLength int)
+
+	// Placeholder returns the placeholder text.
+	Placeholder() string
+
+	// SetPlaceholder sets the placeholder text, a short hint
+	// displayed in the text box when it is empty.
+	// Pass an empty string to not display a placeholder.
+	SetPlaceholder(placeholder string)
+
+	// InputType returns the input type of the text box.
+	InputType() TextBoxType
+
+	// SetInputType sets the input type of the text box,
+	// which enables appropriate mobile keyboards and native browser
+	// validation (e.g. TBT_EMAIL, TBT_NUMBER).
+	// Has no effect on password boxes and text areas (rows>1).
+	// Default is TBT_TEXT.
+	SetInputType(inputType TextBoxType)
 }
 
 // PasswBox interface defines a text box for password input purpose.
@@ -96,12 +133,15 @@ type PasswBox interface {
 
 // TextBox implementation.
 type textBoxImpl struct {
-	compImpl       // Component implementation
-	hasTextImpl    // Has text implementation
-	hasEnabledImpl // Has enabled implementation
-
-	isPassw    bool // Tells if the text box is a password box
-	rows, cols int  // Number of displayed rows and columns.
+	compImpl          // Component implementation
+	hasTextImpl       // Has text implementation
+	hasEnabledImpl    // Has enabled implementation
+	hasValidatorsImpl // Has validators implementation
+
+	isPassw     bool        // Tells if the text box is a password box
+	rows, cols  int         // Number of displayed rows and columns.
+	placeholder string      // Placeholder text
+	inputType   TextBoxType // Input type
 }
 
 var (
@@ -124,11 +164,16 @@ func NewPasswBox(text string) TextBox {
 
 // newTextBoxImpl creates a new textBoxImpl.
 func newTextBoxImpl(valueProviderJs []byte, text string, isPassw bool) textBoxImpl {
-	c := textBoxImpl{newCompImpl(valueProviderJs), newHasTextImpl(text), newHasEnabledImpl(), isPassw, 1, 20}
+	c := textBoxImpl{compImpl: newInputCompImpl(valueProviderJs), hasTextImpl: newHasTextImpl(text), hasEnabledImpl: newHasEnabledImpl(), isPassw: isPassw, rows: 1, cols: 20, inputType: TBT_TEXT}
 	c.AddSyncOnETypes(ETYPE_CHANGE)
 	return c
 }
 
+func (c *textBoxImpl) SetText(text string) {
+	c.hasTextImpl.SetText(text)
+	c.valueChanged()
+}
+
 func (c *textBoxImpl) ReadOnly() bool {
 	ro := c.Attr("readonly")
 	return len(ro) > 0
@@ -175,18 +220,52 @@ func (c *textBoxImpl) SetMaxLength(maxLength int) {
 	}
 }
 
+func (c *textBoxImpl) Placeholder() string {
+	return c.placeholder
+}
+
+func (c *textBoxImpl) SetPlaceholder(placeholder string) {
+	c.placeholder = placeholder
+}
+
+func (c *textBoxImpl) InputType() TextBoxType {
+	return c.inputType
+}
+
+func (c *textBoxImpl) SetInputType(inputType TextBoxType) {
+	c.inputType = inputType
+}
+
+func (c *textBoxImpl) Validate() error {
+	err, _ := c.validate(c.text, c.Style())
+	return err
+}
+
+func (c *textBoxImpl) Valid() bool {
+	return c.Validate() == nil
+}
+
 func (c *textBoxImpl) preprocessEvent(event Event, r *http.Request) {
 	// Empty string for text box is a valid value.
 	// So we have to check whether it is supplied, not just whether its len() > 0 
-	value := r.FormValue(_PARAM_COMP_VALUE)
-	if len(value) > 0 {
-		c.text = value
-	} else {
-		// Empty string might be a valid value, if the component value param is present:
-		values, present := r.Form[_PARAM_COMP_VALUE] // Form is surely parsed (we called FormValue())
-		if present && len(values) > 0 {
-			c.text = values[0]
-		}
+	var mask Sanitation
+	if c.isPassw {
+		mask = SANITIZE_ESCAPE_HTML // Passwords must be used as-is
+	}
+	value, present, err := c.clientText(event, r, mask)
+	if !present {
+		return
+	}
+	if err != nil {
+		// Rejected by a codec, restore the previous value in the browser
+		event.MarkDirty(c)
+		return
+	}
+	c.text = value
+
+	// Validators not validating in the browser might give a different result
+	if _, changed := c.validate(c.text, c.Style()); changed || value != r.FormValue(_PARAM_COMP_VALUE) {
+		event.MarkDirty(c)
 	}
 }
 
@@ -201,10 +280,11 @@ func (c *textBoxImpl) Render(w writer) {
 var (
 	_STR_INPUT_OP = []byte(`<input type="`) // `<input type="`
 	_STR_PASSWORD = []byte("password")      // "password"
-	_STR_TEXT     = []byte("text")          // "text"
 	_STR_SIZE     = []byte(`" size="`)      // `" size="`
 	_STR_VALUE    = []byte(` value="`)      // ` value="`
 	_STR_INPUT_CL = []byte(`"/>`)           // `"/>`
+
+	_STR_PLACEHOLDER = []byte(` placeholder="`) // ` placeholder="`
 )
 
 // renderInput renders the component as an input HTML tag.
@@ -213,7 +293,7 @@ func (c *textBoxImpl) renderInput(w writer) {
 	if c.isPassw {
 		w.Write(_STR_PASSWORD)
 	} else {
-		w.Write(_STR_TEXT)
+		w.Writes(string(c.inputType))
 	}
 	w.Write(_STR_SIZE)
 	w.Writev(c.cols)
@@ -221,6 +301,8 @@ func (c *textBoxImpl) renderInput(w writer) {
 	c.renderAttrsAndStyle(w)
 	c.renderEnabled(w)
 	c.renderEHandlers(w)
+	c.renderPlaceholder(w)
+	c.renderValidators(w)
 
 	w.Write(_STR_VALUE)
 	c.renderText(w)
@@ -241,6 +323,8 @@ func (c *textBoxImpl) renderTextArea(w writer) {
 	c.renderAttrsAndStyle(w)
 	c.renderEnabled(w)
 	c.renderEHandlers(w)
+	c.renderPlaceholder(w)
+	c.renderValidators(w)
 
 	// New line char after the <textarea> tag is ignored.
 	// So we must render a newline after textarea, else if text value
@@ -254,3 +338,12 @@ func (c *textBoxImpl) renderTextArea(w writer) {
 	c.renderText(w)
 	w.Write(_STR_TEXTAREA_CL)
 }
+
+// renderPlaceholder renders the placeholder attribute if there is a placeholder.
+func (c *textBoxImpl) renderPlaceholder(w writer) {
+	if len(c.placeholder) > 0 {
+		w.Write(_STR_PLACEHOLDER)
+		w.Writees(c.placeholder)
+		w.Write(_STR_QUOTE)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// CSS theme registration.
+
+package gwu
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Theme is a CSS theme: a named CSS bundle which can be registered
+// on the server (see Server.AddTheme()), and used by windows
+// (see Server.SetTheme() and Window.SetTheme()).
+type Theme struct {
+	Name    string // Name of the theme
+	Extends string // Optional name of a registered theme whose CSS is included before Css
+	Css     string // CSS code of the theme
+}
+
+// themeRes is a registered theme resource.
+type themeRes struct {
+	res string // Resource name of the theme
+	css []byte // CSS code of the theme
+}
+
+// addBuiltinThemes registers the built-in themes.
+func (s *serverImpl) addBuiltinThemes() {
+	for _, name := range []string{THEME_DEFAULT, THEME_DEBUG, THEME_DARK} {
+		res := resNameStaticCss(name)
+		s.themes[name] = &themeRes{res: res, css: staticCss[res]}
+	}
+}
+
+func (s *serverImpl) AddTheme(theme Theme) {
+	s.themesMutex.Lock()
+	defer s.themesMutex.Unlock()
+
+	var css []byte
+	if base := s.themes[theme.Extends]; base != nil {
+		css = append(css, base.css...)
+		css = append(css, '\n')
+	}
+	css = append(css, theme.Css...)
+	registerCssClasses([]byte(theme.Css))
+
+	// Each registration gets a new resource name, so browsers do not use
+	// the cached CSS of a replaced theme.
+	s.themeSeq++
+	res := "gowut-theme-" + strconv.Itoa(s.themeSeq) + "-" + GOWUT_VERSION + ".css"
+	s.themes[theme.Name] = &themeRes{res: res, css: css}
+}
+
+func (s *serverImpl) Themes() []string {
+	s.themesMutex.Lock()
+	defer s.themesMutex.Unlock()
+
+	names := make([]string, 0, len(s.themes))
+	for name := range s.themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *serverImpl) themeRes(theme string) string {
+	s.themesMutex.Lock()
+	defer s.themesMutex.Unlock()
+
+	if len(theme) == 0 {
+		theme = s.theme
+	}
+	if t := s.themes[theme]; t != nil {
+		return t.res
+	}
+	// Unknown theme, fall back to the default theme
+	return resNameStaticCss(THEME_DEFAULT)
+}
+
+// themeCss returns the CSS code of the theme with the specified resource name,
+// nil if there is no such theme.
+func (s *serverImpl) themeCss(res string) []byte {
+	s.themesMutex.Lock()
+	defer s.themesMutex.Unlock()
+
+	for _, t := range s.themes {
+		if t.res == res {
+			return t.css
+		}
+	}
+	return nil
+}
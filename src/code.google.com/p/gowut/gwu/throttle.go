@@ -0,0 +1,335 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Login throttling: brute-force protection of authentication.
+
+package gwu
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Default login throttling parameters.
+const (
+	DEFAULT_MAX_USER_FAILURES = 5                // Default max failed login attempts per user
+	DEFAULT_MAX_ADDR_FAILURES = 20               // Default max failed login attempts per client address
+	DEFAULT_FAILURE_WINDOW    = 15 * time.Minute // Default time window failed login attempts are counted in
+	DEFAULT_LOCKOUT           = 15 * time.Minute // Default lockout duration
+)
+
+// Errors returned by LoginThrottle.Login().
+var (
+	ErrLoginLocked        = errors.New("Too many failed login attempts, try again later!")
+	ErrInvalidCredentials = errors.New("Invalid user name or password!")
+)
+
+// LoginAlert describes a lockout caused by too many failed login attempts,
+// passed to the alert handler of the LoginThrottle.
+type LoginAlert struct {
+	User     string    // Name of the user (empty if the client address is locked)
+	Addr     string    // Address of the client of the last failed attempt
+	Failures int       // Number of failed attempts which caused the lockout
+	Until    time.Time // End of the lockout
+}
+
+// LoginThrottle interface defines a brute-force protection of logins:
+// failed login attempts are counted per user and per client address,
+// and if there are too many of them within a time window, further attempts
+// of the user or from the address are rejected for a while (temporary lockout).
+//
+// The login throttle of the server (see Server.SetLoginThrottle()) is used
+// by the built-in authenticators checking credentials (see NewBasicAuthenticator()),
+// and can be used by login windows of applications (see Login()).
+type LoginThrottle interface {
+	// Allowed tells if a login attempt of the specified user from the
+	// specified client address is allowed now.
+	// If not, retryAfter tells how long the lockout lasts.
+	Allowed(user, addr string) (ok bool, retryAfter time.Duration)
+
+	// Failed registers a failed login attempt.
+	Failed(user, addr string)
+
+	// Succeeded registers a successful login attempt,
+	// which clears the failed attempts of the user.
+	Succeeded(user, addr string)
+
+	// Login checks the credentials with the specified function
+	// if the login attempt is allowed, and registers the result.
+	// Returns nil if the credentials are valid, ErrLoginLocked if
+	// the attempt is not allowed, else ErrInvalidCredentials.
+	// Attempts in progress count towards the limits, so concurrent
+	// attempts cannot get around them.
+	//
+	// Example usage in a login window:
+	//
+	// 	err := server.LoginThrottle().Login(tb.Text(), pb.Text(), e.Request().RemoteAddr(), checkCredentials)
+	Login(user, password, addr string, check CredentialsFunc) error
+
+	// SetAlertHandler sets a function to be called when a lockout starts,
+	// e.g. to alert administrators or an external monitoring system.
+	// The handler is called synchronously, it should return quickly.
+	SetAlertHandler(handler func(alert LoginAlert))
+
+	// expire removes the stale records.
+	expire(now time.Time)
+}
+
+// Failed login attempts of a user or a client address.
+type loginFailures struct {
+	count       int       // Number of failed attempts in the current window
+	pending     int       // Number of attempts in progress (see Login())
+	first       time.Time // Time of the first failed attempt in the current window
+	lockedUntil time.Time // End of the lockout
+}
+
+// LoginThrottle implementation.
+type loginThrottleImpl struct {
+	mutex sync.Mutex // Mutex to synchronize access
+
+	maxUser, maxAddr int           // Max failed attempts per user and per client address
+	window           time.Duration // Time window failed attempts are counted in
+	lockout          time.Duration // Lockout duration
+
+	users map[string]*loginFailures // Failed attempts of users
+	addrs map[string]*loginFailures // Failed attempts from client addresses
+
+	alertHandler func(alert LoginAlert) // Alert handler
+}
+
+// NewLoginThrottle creates a new LoginThrottle which locks out users after maxUser
+// and client addresses after maxAddr failed login attempts within the specified
+// time window for the specified lockout duration.
+// Pass a value <= 0 as maxUser or maxAddr to not limit attempts per user or
+// per client address.
+func NewLoginThrottle(maxUser, maxAddr int, window, lockout time.Duration) LoginThrottle {
+	return &loginThrottleImpl{maxUser: maxUser, maxAddr: maxAddr, window: window, lockout: lockout,
+		users: make(map[string]*loginFailures), addrs: make(map[string]*loginFailures)}
+}
+
+// NewDefaultLoginThrottle creates a new LoginThrottle with the default parameters
+// (DEFAULT_MAX_USER_FAILURES, DEFAULT_MAX_ADDR_FAILURES, DEFAULT_FAILURE_WINDOW, DEFAULT_LOCKOUT).
+func NewDefaultLoginThrottle() LoginThrottle {
+	return NewLoginThrottle(DEFAULT_MAX_USER_FAILURES, DEFAULT_MAX_ADDR_FAILURES, DEFAULT_FAILURE_WINDOW, DEFAULT_LOCKOUT)
+}
+
+func (t *loginThrottleImpl) Allowed(user, addr string) (ok bool, retryAfter time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.allowed(user, addr, time.Now())
+}
+
+// allowed tells if a login attempt is allowed.
+// Must be called with the mutex locked.
+func (t *loginThrottleImpl) allowed(user, addr string, now time.Time) (ok bool, retryAfter time.Duration) {
+	for _, f := range []*loginFailures{t.users[user], t.addrs[addr]} {
+		if f != nil && now.Before(f.lockedUntil) && f.lockedUntil.Sub(now) > retryAfter {
+			retryAfter = f.lockedUntil.Sub(now)
+		}
+	}
+	return retryAfter == 0, retryAfter
+}
+
+func (t *loginThrottleImpl) Failed(user, addr string) {
+	t.mutex.Lock()
+	alerts := t.failed(user, addr, time.Now())
+	handler := t.alertHandler
+	t.mutex.Unlock()
+
+	t.alert(handler, alerts)
+}
+
+// failed registers a failed login attempt, and returns the alerts
+// of the lockouts started.
+// Must be called with the mutex locked.
+func (t *loginThrottleImpl) failed(user, addr string, now time.Time) (alerts []LoginAlert) {
+	if t.fail(t.users, user, t.maxUser, now) {
+		alerts = append(alerts, LoginAlert{User: user, Addr: addr, Failures: t.maxUser, Until: now.Add(t.lockout)})
+	}
+	if t.fail(t.addrs, addr, t.maxAddr, now) {
+		alerts = append(alerts, LoginAlert{Addr: addr, Failures: t.maxAddr, Until: now.Add(t.lockout)})
+	}
+	return
+}
+
+// alert calls the alert handler (if not nil) with the specified alerts.
+// Must be called with the mutex unlocked.
+func (t *loginThrottleImpl) alert(handler func(alert LoginAlert), alerts []LoginAlert) {
+	if handler != nil {
+		for _, alert := range alerts {
+			handler(alert)
+		}
+	}
+}
+
+// fail registers a failed attempt in the specified records.
+// Returns true if a lockout started.
+// Must be called with the mutex locked.
+func (t *loginThrottleImpl) fail(records map[string]*loginFailures, key string, max int, now time.Time) bool {
+	if max <= 0 {
+		return false
+	}
+
+	f := records[key]
+	if f == nil {
+		f = &loginFailures{}
+		records[key] = f
+	}
+	if now.Sub(f.first) > t.window {
+		f.count, f.first = 0, now
+	}
+	f.count++
+	if f.count < max {
+		return false
+	}
+
+	f.count, f.first, f.lockedUntil = 0, time.Time{}, now.Add(t.lockout)
+	return true
+}
+
+func (t *loginThrottleImpl) Succeeded(user, addr string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.succeeded(user)
+}
+
+// succeeded clears the failed attempts of the user.
+// Must be called with the mutex locked.
+func (t *loginThrottleImpl) succeeded(user string) {
+	if f := t.users[user]; f != nil {
+		if f.pending > 0 {
+			// Keep the attempts in progress
+			f.count, f.first, f.lockedUntil = 0, time.Time{}, time.Time{}
+		} else {
+			delete(t.users, user)
+		}
+	}
+}
+
+func (t *loginThrottleImpl) Login(user, password, addr string, check CredentialsFunc) error {
+	if !t.reserve(user, addr) {
+		return ErrLoginLocked
+	}
+
+	ok := check(user, password)
+
+	t.mutex.Lock()
+	t.release(user, addr)
+	var alerts []LoginAlert
+	if ok {
+		t.succeeded(user)
+	} else {
+		alerts = t.failed(user, addr, time.Now())
+	}
+	handler := t.alertHandler
+	t.mutex.Unlock()
+
+	t.alert(handler, alerts)
+
+	if !ok {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// reserve reserves a login attempt if it is allowed: in the same critical
+// section the attempt is checked in, it is counted as in progress.
+// An attempt is not allowed if the attempts in progress could exhaust
+// the limits if they all fail.
+// Returns false if the attempt is not allowed.
+func (t *loginThrottleImpl) reserve(user, addr string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	if ok, _ := t.allowed(user, addr, now); !ok {
+		return false
+	}
+
+	var records []*loginFailures
+	for _, r := range []struct {
+		records map[string]*loginFailures
+		key     string
+		max     int
+	}{{t.users, user, t.maxUser}, {t.addrs, addr, t.maxAddr}} {
+		if r.max <= 0 {
+			continue
+		}
+		f := r.records[r.key]
+		if f == nil {
+			f = &loginFailures{}
+			r.records[r.key] = f
+		}
+		count := f.count
+		if now.Sub(f.first) > t.window {
+			count = 0 // Failures of the previous window
+		}
+		if count+f.pending >= r.max {
+			return false
+		}
+		records = append(records, f)
+	}
+
+	for _, f := range records {
+		f.pending++
+	}
+	return true
+}
+
+// release releases a login attempt reserved by reserve().
+// Must be called with the mutex locked.
+func (t *loginThrottleImpl) release(user, addr string) {
+	if t.maxUser > 0 {
+		if f := t.users[user]; f != nil {
+			f.pending--
+		}
+	}
+	if t.maxAddr > 0 {
+		if f := t.addrs[addr]; f != nil {
+			f.pending--
+		}
+	}
+}
+
+func (t *loginThrottleImpl) SetAlertHandler(handler func(alert LoginAlert)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.alertHandler = handler
+}
+
+func (t *loginThrottleImpl) expire(now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, records := range []map[string]*loginFailures{t.users, t.addrs} {
+		for key, f := range records {
+			if f.pending == 0 && now.After(f.lockedUntil) && now.Sub(f.first) > t.window {
+				delete(records, key)
+			}
+		}
+	}
+}
+
+func (s *serverImpl) LoginThrottle() LoginThrottle {
+	return s.loginThrottle
+}
+
+func (s *serverImpl) SetLoginThrottle(t LoginThrottle) {
+	s.loginThrottle = t
+}
@@ -0,0 +1,132 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottleLockout(t *testing.T) {
+	th := NewLoginThrottle(3, 0, time.Minute, time.Minute)
+	var alerts []LoginAlert
+	th.SetAlertHandler(func(alert LoginAlert) {
+		alerts = append(alerts, alert)
+	})
+	invalid := func(user, password string) bool { return false }
+	valid := func(user, password string) bool { return true }
+
+	for i := 0; i < 3; i++ {
+		if err := th.Login("bob", "x", "1.2.3.4", invalid); err != ErrInvalidCredentials {
+			t.Fatalf("Attempt %d: expected: %v, got: %v", i, ErrInvalidCredentials, err)
+		}
+	}
+	if len(alerts) != 1 || alerts[0].User != "bob" || alerts[0].Failures != 3 {
+		t.Errorf("Expected 1 alert of bob, got: %v", alerts)
+	}
+
+	// Locked out, even with valid credentials
+	if err := th.Login("bob", "secret", "1.2.3.4", valid); err != ErrLoginLocked {
+		t.Errorf("Expected: %v, got: %v", ErrLoginLocked, err)
+	}
+	if ok, retryAfter := th.Allowed("bob", "5.6.7.8"); ok || retryAfter <= 0 {
+		t.Errorf("Expected lockout, got: %v, %v", ok, retryAfter)
+	}
+
+	// Other users are not affected
+	if err := th.Login("alice", "secret", "1.2.3.4", valid); err != nil {
+		t.Errorf("Expected success, got: %v", err)
+	}
+}
+
+func TestLoginThrottleSuccessClears(t *testing.T) {
+	th := NewLoginThrottle(3, 0, time.Minute, time.Minute)
+	invalid := func(user, password string) bool { return false }
+	valid := func(user, password string) bool { return true }
+
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 2; i++ {
+			th.Login("bob", "x", "", invalid)
+		}
+		if err := th.Login("bob", "secret", "", valid); err != nil {
+			t.Fatalf("Round %d: expected success, got: %v", round, err)
+		}
+	}
+}
+
+func TestLoginThrottleAddrLockout(t *testing.T) {
+	th := NewLoginThrottle(0, 2, time.Minute, time.Minute)
+	invalid := func(user, password string) bool { return false }
+
+	th.Login("u1", "x", "1.2.3.4", invalid)
+	th.Login("u2", "x", "1.2.3.4", invalid)
+	if ok, _ := th.Allowed("u3", "1.2.3.4"); ok {
+		t.Error("Expected the address to be locked out")
+	}
+	if ok, _ := th.Allowed("u3", "5.6.7.8"); !ok {
+		t.Error("Expected other addresses to be allowed")
+	}
+}
+
+func TestLoginThrottleConcurrent(t *testing.T) {
+	const max, attempts = 3, 20
+	th := NewLoginThrottle(max, 0, time.Minute, time.Minute)
+
+	var checks int32
+	release := make(chan struct{})
+	check := func(user, password string) bool {
+		atomic.AddInt32(&checks, 1)
+		<-release
+		return false
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- th.Login("bob", "x", "1.2.3.4", check)
+		}()
+	}
+
+	// Wait until the allowed attempts are in progress and the rest is rejected
+	deadline := time.Now().Add(5 * time.Second)
+	for len(errs) < attempts-max && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	if n := atomic.LoadInt32(&checks); n != max {
+		t.Errorf("Expected %d credential checks, got: %d", max, n)
+	}
+	locked := 0
+	for err := range errs {
+		if err == ErrLoginLocked {
+			locked++
+		}
+	}
+	if locked != attempts-max {
+		t.Errorf("Expected %d locked attempts, got: %d", attempts-max, locked)
+	}
+	if ok, _ := th.Allowed("bob", "1.2.3.4"); ok {
+		t.Error("Expected lockout after the concurrent failures")
+	}
+}
@@ -35,6 +35,12 @@ import (
 // Also note that the Timer component operates at the client side meaning
 // if the client is closed (or navigates away), events will not be generated.
 // (This can also be used to detect if a Window is still open.)
+// 
+// Events of timers do not display the busy indicator of the window
+// (see SetBusySuppressed()).
+// 
+// By default timers are paused while the page is hidden (e.g. on a background
+// tab), see SetPauseHidden().
 type Timer interface {
 	// Timer is a component.
 	Comp
@@ -67,6 +73,17 @@ type Timer interface {
 	// If a timer is deactivated and activated again, its countdown is reset.
 	SetActive(active bool)
 
+	// PauseHidden tells if the timer is paused while the page is hidden.
+	PauseHidden() bool
+
+	// SetPauseHidden sets if the timer is paused while the page is hidden
+	// (e.g. on a background tab or the browser is minimized), detected by
+	// the Page Visibility API. Timeouts elapsing while the page is hidden
+	// do not generate events, but a single event is generated when the page
+	// becomes visible again if any timeouts elapsed while hidden.
+	// Default is true.
+	SetPauseHidden(pauseHidden bool)
+
 	// Reset will cause the timer to restart/reschedule.
 	// A Timer does not resets the countdown when it is re-rendered,
 	// only if the timer config is changed (e.g. timeout or repeat).
@@ -79,16 +96,19 @@ type Timer interface {
 type timerImpl struct {
 	compImpl // Component implementation
 
-	timeout time.Duration // Timeout of the timer
-	repeat  bool          // Tells if timer is on repeat
-	active  bool          // Tells if the timer is active
-	reset   int           // Reset counter
+	timeout     time.Duration // Timeout of the timer
+	repeat      bool          // Tells if timer is on repeat
+	active      bool          // Tells if the timer is active
+	pauseHidden bool          // Tells if the timer is paused while the page is hidden
+	reset       int           // Reset counter
 }
 
 // NewTimer creates a new Timer.
-// By default the timer is active and does not repeat.
+// By default the timer is active, does not repeat and is paused while the page is hidden.
 func NewTimer(timeout time.Duration) Timer {
-	return &timerImpl{compImpl: newCompImpl(nil), timeout: timeout, active: true}
+	c := &timerImpl{compImpl: newCompImpl(nil), timeout: timeout, active: true, pauseHidden: true}
+	SetBusySuppressed(c, true)
+	return c
 }
 
 func (c *timerImpl) Timeout() time.Duration {
@@ -119,6 +139,14 @@ func (c *timerImpl) SetActive(active bool) {
 	c.active = active
 }
 
+func (c *timerImpl) PauseHidden() bool {
+	return c.pauseHidden
+}
+
+func (c *timerImpl) SetPauseHidden(pauseHidden bool) {
+	c.pauseHidden = pauseHidden
+}
+
 func (c *timerImpl) Reset() {
 	c.reset++
 }
@@ -134,7 +162,7 @@ func (c *timerImpl) Render(w writer) {
 	c.renderEHandlers(w)
 	w.Write(_STR_GT)
 
-	w.Write(_STR_SCRIPT_OP)
+	w.writeScript(_STR_SCRIPT_OP)
 	w.Writev(int(c.id))
 	w.Write(_STR_COMMA)
 	w.Writev(int(ETYPE_STATE_CHANGE))
@@ -146,6 +174,8 @@ func (c *timerImpl) Render(w writer) {
 	w.Writev(c.active)
 	w.Write(_STR_COMMA)
 	w.Writev(c.reset)
+	w.Write(_STR_COMMA)
+	w.Writev(c.pauseHidden)
 	w.Write(_STR_SCRIPT_CL)
 
 	w.Write(_STR_SPAN_CL)
@@ -0,0 +1,244 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Tour component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// TourStep is a step of a Tour.
+type TourStep struct {
+	Comp Comp   // Component highlighted by the step, nil if the step is not attached to a component
+	Text string // Text of the step
+}
+
+// Tour interface defines a guided tour, e.g. for onboarding users:
+// an ordered list of steps, each highlighting a component with an overlay
+// and displaying a text with navigation controls next to it.
+//
+// Tours don't have a visual part when they are not running. A tour has to be
+// added to the window (or to a container of the window) like other components,
+// and started by Start() during event handling.
+//
+// You can register ETYPE_STATE_CHANGE event handlers which will be called when the user
+// navigates to another step, and ETYPE_CHANGE event handlers which will be called when
+// the tour ends (the user completes or skips it). Completed() tells if the tour
+// was completed.
+//
+// Default style classes: "gwu-Tour", "gwu-Tour-Highlight", "gwu-Tour-Popup", "gwu-Tour-Text",
+// "gwu-Tour-Buttons", "gwu-Tour-Progress"
+type Tour interface {
+	// Tour is a component.
+	Comp
+
+	// AddStep adds a step to the end of the steps, highlighting the
+	// specified component and displaying the specified text.
+	// c may be nil, in which case only the text is displayed.
+	AddStep(c Comp, text string)
+
+	// Steps returns the steps of the tour.
+	Steps() []TourStep
+
+	// Start starts the tour from the first step,
+	// and marks the tour dirty.
+	Start(e Event)
+
+	// Stop stops the tour (no ETYPE_CHANGE event is fired),
+	// and marks the tour dirty.
+	Stop(e Event)
+
+	// Running tells if the tour is running.
+	Running() bool
+
+	// Step returns the index of the current step.
+	// Returns -1 if the tour is not running.
+	Step() int
+
+	// Completed tells if the tour was completed the last time it ended
+	// (false if it was skipped).
+	Completed() bool
+
+	// ButtonTexts returns the texts of the navigation buttons.
+	ButtonTexts() (back, next, skip, done string)
+
+	// SetButtonTexts sets the texts of the navigation buttons.
+	// Default texts are "Back", "Next", "Skip" and "Done".
+	SetButtonTexts(back, next, skip, done string)
+}
+
+// Tour implementation.
+type tourImpl struct {
+	compImpl // Component implementation
+
+	steps     []TourStep // Steps of the tour
+	step      int        // Index of the current step, -1 if the tour is not running
+	completed bool       // Tells if the tour was completed the last time it ended
+
+	backText, nextText, skipText, doneText string // Texts of the navigation buttons
+}
+
+// NewTour creates a new Tour.
+func NewTour() Tour {
+	c := &tourImpl{compImpl: newCompImpl(nil), step: -1,
+		backText: "Back", nextText: "Next", skipText: "Skip", doneText: "Done"}
+	c.Style().AddClass("gwu-Tour")
+	return c
+}
+
+func (c *tourImpl) AddStep(comp Comp, text string) {
+	c.steps = append(c.steps, TourStep{Comp: comp, Text: text})
+}
+
+func (c *tourImpl) Steps() []TourStep {
+	steps := make([]TourStep, len(c.steps))
+	copy(steps, c.steps)
+	return steps
+}
+
+func (c *tourImpl) Start(e Event) {
+	if len(c.steps) == 0 {
+		return
+	}
+	c.step = 0
+	e.MarkDirty(c)
+}
+
+func (c *tourImpl) Stop(e Event) {
+	c.step = -1
+	e.MarkDirty(c)
+}
+
+func (c *tourImpl) Running() bool {
+	return c.step >= 0
+}
+
+func (c *tourImpl) Step() int {
+	return c.step
+}
+
+func (c *tourImpl) Completed() bool {
+	return c.completed
+}
+
+func (c *tourImpl) ButtonTexts() (back, next, skip, done string) {
+	return c.backText, c.nextText, c.skipText, c.doneText
+}
+
+func (c *tourImpl) SetButtonTexts(back, next, skip, done string) {
+	c.backText, c.nextText, c.skipText, c.doneText = back, next, skip, done
+}
+
+// Values sent by the buttons ending the tour.
+const (
+	_TOUR_DONE = "done" // Done button
+	_TOUR_SKIP = "skip" // Skip button
+)
+
+func (c *tourImpl) preprocessEvent(event Event, r *http.Request) {
+	if c.step < 0 {
+		return
+	}
+
+	value := r.FormValue(_PARAM_COMP_VALUE)
+	switch event.Type() {
+	case ETYPE_STATE_CHANGE:
+		step, err := strconv.Atoi(value)
+		if err != nil || step < 0 || step >= len(c.steps) {
+			return
+		}
+		c.step = step
+	case ETYPE_CHANGE:
+		c.completed = value == _TOUR_DONE
+		c.step = -1
+	default:
+		return
+	}
+	event.MarkDirty(c)
+}
+
+var (
+	_STR_TOUR_HIDDEN    = []byte(` style="display:none"></span>`)                                                                 // ` style="display:none"></span>`
+	_STR_TOUR_OP        = []byte(`<div class="gwu-Tour-Highlight"></div><div class="gwu-Tour-Popup"><div class="gwu-Tour-Text">`) // `<div class="gwu-Tour-Highlight"></div><div class="gwu-Tour-Popup"><div class="gwu-Tour-Text">`
+	_STR_TOUR_BUTTONS   = []byte(`</div><div class="gwu-Tour-Buttons"><span class="gwu-Tour-Progress">`)                          // `</div><div class="gwu-Tour-Buttons"><span class="gwu-Tour-Progress">`
+	_STR_TOUR_BUTTON_OP = []byte(`<button type="button" onclick="se(null,`)                                                       // `<button type="button" onclick="se(null,`
+	_STR_TOUR_VALUE_OP  = []byte(`,'`)                                                                                            // `,'`
+	_STR_TOUR_BUTTON_CL = []byte(`')">`)                                                                                          // `')">`
+	_STR_TOUR_SHOW_OP   = []byte(`</div></div><script>toShow(`)                                                                   // `</div></div><script>toShow(`
+	_STR_TOUR_SCRIPT_CL = []byte(`);</script>`)                                                                                   // `);</script>`
+)
+
+func (c *tourImpl) Render(w writer) {
+	if c.step < 0 || c.step >= len(c.steps) {
+		w.Write(_STR_SPAN_OP)
+		c.renderAttrsAndStyle(w)
+		w.Write(_STR_TOUR_HIDDEN)
+		return
+	}
+
+	step := c.steps[c.step]
+
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	w.Write(_STR_GT)
+
+	w.Write(_STR_TOUR_OP)
+	w.Writees(step.Text)
+	w.Write(_STR_TOUR_BUTTONS)
+	w.Writevs(c.step+1, " / ", len(c.steps))
+	w.Write(_STR_SPAN_CL)
+
+	last := c.step == len(c.steps)-1
+	if !last {
+		c.renderButton(w, c.skipText, ETYPE_CHANGE, _TOUR_SKIP)
+	}
+	if c.step > 0 {
+		c.renderButton(w, c.backText, ETYPE_STATE_CHANGE, strconv.Itoa(c.step-1))
+	}
+	if last {
+		c.renderButton(w, c.doneText, ETYPE_CHANGE, _TOUR_DONE)
+	} else {
+		c.renderButton(w, c.nextText, ETYPE_STATE_CHANGE, strconv.Itoa(c.step+1))
+	}
+
+	// To render: </div></div><script>toShow(compId,targetId);</script>
+	w.writeScript(_STR_TOUR_SHOW_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	if step.Comp != nil {
+		w.Writev(int(step.Comp.Id()))
+	} else {
+		w.Writev(-1)
+	}
+	w.Write(_STR_TOUR_SCRIPT_CL)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderButton renders a navigation button which sends an event
+// of the specified type with the specified value.
+func (c *tourImpl) renderButton(w writer, text string, etype EventType, value string) {
+	// To render: <button type="button" onclick="se(null,etype,compId,'value')">text</button>
+	w.Write(_STR_TOUR_BUTTON_OP)
+	w.Writevs(int(etype), _STR_COMMA, int(c.id))
+	w.Write(_STR_TOUR_VALUE_OP)
+	w.Writes(value)
+	w.Write(_STR_TOUR_BUTTON_CL)
+	w.Writees(text)
+	w.Write(_STR_BUTTON_CL)
+}
@@ -0,0 +1,426 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Tree component interface and implementation.
+
+package gwu
+
+import (
+	"net/http"
+)
+
+// TreeLoader is a function which loads the child nodes of a tree node
+// on demand. It is called when a node which is not loaded yet is expanded
+// by the user. The loader should add the child nodes to the node.
+type TreeLoader func(e Event, node TreeNode)
+
+// TreeNode interface defines a node of a Tree.
+// Tree nodes are not components, they are created by the
+// AddNode() method of their parent node.
+type TreeNode interface {
+	// Id returns the unique id of the node.
+	Id() ID
+
+	// Tree returns the tree the node belongs to.
+	Tree() Tree
+
+	// Parent returns the parent node.
+	// Returns nil for the root node.
+	Parent() TreeNode
+
+	// The node has a text which is displayed as its label.
+	HasText
+
+	// Data returns the user data attached to the node.
+	Data() interface{}
+
+	// SetData attaches a user data to the node,
+	// e.g. the path of a file in a file browser.
+	SetData(data interface{})
+
+	// AddNode creates a new child node with the specified text,
+	// and adds it to the end of the child nodes.
+	// Adding a child node marks the node loaded.
+	AddNode(text string) TreeNode
+
+	// RemoveNode removes a child node.
+	// Return value indicates if the node was a child node and was removed.
+	RemoveNode(node TreeNode) bool
+
+	// ClearNodes removes all child nodes.
+	ClearNodes()
+
+	// Nodes returns the child nodes.
+	Nodes() []TreeNode
+
+	// Leaf tells if the node is a leaf.
+	Leaf() bool
+
+	// SetLeaf sets if the node is a leaf. Leaf nodes cannot be expanded
+	// and the tree loader is never called for them.
+	// Nodes are not leaves by default.
+	SetLeaf(leaf bool)
+
+	// Expanded tells if the node is expanded.
+	Expanded() bool
+
+	// SetExpanded sets if the node is expanded.
+	// The tree loader is not called by this method.
+	SetExpanded(expanded bool)
+
+	// Loaded tells if the child nodes of the node are loaded.
+	Loaded() bool
+
+	// SetLoaded sets if the child nodes of the node are loaded.
+	// Setting false causes the child nodes to be cleared and the tree
+	// loader to be called again the next time the node is expanded.
+	SetLoaded(loaded bool)
+}
+
+// Tree interface defines a component which displays hierarchical data
+// as nodes which can be expanded and collapsed.
+// 
+// The tree has an invisible root node, the top level nodes are the child
+// nodes of the root. Child nodes can be added in advance, or they can be
+// loaded on demand by a TreeLoader when a node is expanded.
+// 
+// You can register ETYPE_STATE_CHANGE event handlers which will be called when the user
+// expands or collapses a node, and ETYPE_CHANGE event handlers which will be called when
+// the user selects a node. The event source will be the tree, the node can be
+// acquired by EventNode().
+// 
+// Default style classes: "gwu-Tree", "gwu-Tree-Node", "gwu-Tree-Toggle",
+// "gwuimg-collapsed", "gwuimg-expanded", "gwu-Tree-Label", "gwu-Tree-Label-Selected",
+// "gwu-Tree-Children"
+type Tree interface {
+	// Tree is a component.
+	Comp
+
+	// Root returns the (invisible) root node of the tree.
+	Root() TreeNode
+
+	// Loader returns the tree loader.
+	Loader() TreeLoader
+
+	// SetLoader sets the tree loader which is called to load
+	// the child nodes of a node on demand.
+	// Pass nil to disable lazy loading.
+	SetLoader(loader TreeLoader)
+
+	// Selected returns the selected node.
+	// Returns nil if no node is selected.
+	Selected() TreeNode
+
+	// SetSelected sets the selected node.
+	// Pass nil to clear the selection.
+	SetSelected(node TreeNode)
+
+	// NodeById returns the node of the tree specified by its id.
+	// Returns nil if no such node exists.
+	NodeById(id ID) TreeNode
+
+	// EventNode returns the node of the last expand, collapse
+	// or selection event of the tree.
+	EventNode() TreeNode
+}
+
+// Tree node implementation.
+type treeNodeImpl struct {
+	hasTextImpl // Has text implementation
+
+	id       ID              // The node id
+	tree     *treeImpl       // The tree the node belongs to
+	parent   *treeNodeImpl   // Parent node
+	nodes    []*treeNodeImpl // Child nodes
+	data     interface{}     // User data
+	leaf     bool            // Tells if the node is a leaf
+	expanded bool            // Tells if the node is expanded
+	loaded   bool            // Tells if the child nodes are loaded
+}
+
+// Tree implementation.
+type treeImpl struct {
+	compImpl // Component implementation
+
+	root      *treeNodeImpl        // Root node
+	nodes     map[ID]*treeNodeImpl // Nodes of the tree mapped from node id
+	loader    TreeLoader           // Tree loader
+	selected  *treeNodeImpl        // Selected node
+	eventNode *treeNodeImpl        // Node of the last event
+}
+
+// NewTree creates a new Tree.
+func NewTree() Tree {
+	c := &treeImpl{compImpl: newInputCompImpl(nil), nodes: make(map[ID]*treeNodeImpl)}
+	c.root = c.newNode(nil, "")
+	c.root.expanded = true
+	c.Style().AddClass("gwu-Tree")
+	return c
+}
+
+// newNode creates a new node and registers it in the tree.
+func (c *treeImpl) newNode(parent *treeNodeImpl, text string) *treeNodeImpl {
+	n := &treeNodeImpl{hasTextImpl: newHasTextImpl(text), id: nextCompId(), tree: c, parent: parent}
+	c.nodes[n.id] = n
+	return n
+}
+
+// unregister unregisters a node and its descendants from the tree.
+func (c *treeImpl) unregister(n *treeNodeImpl) {
+	delete(c.nodes, n.id)
+	if c.selected == n {
+		c.selected = nil
+	}
+	if c.eventNode == n {
+		c.eventNode = nil
+	}
+	for _, n2 := range n.nodes {
+		c.unregister(n2)
+	}
+}
+
+func (n *treeNodeImpl) Id() ID {
+	return n.id
+}
+
+func (n *treeNodeImpl) Tree() Tree {
+	return n.tree
+}
+
+func (n *treeNodeImpl) Parent() TreeNode {
+	if n.parent == nil {
+		return nil
+	}
+	return n.parent
+}
+
+func (n *treeNodeImpl) Data() interface{} {
+	return n.data
+}
+
+func (n *treeNodeImpl) SetData(data interface{}) {
+	n.data = data
+}
+
+func (n *treeNodeImpl) AddNode(text string) TreeNode {
+	n2 := n.tree.newNode(n, text)
+	n.nodes = append(n.nodes, n2)
+	n.loaded = true
+	return n2
+}
+
+func (n *treeNodeImpl) RemoveNode(node TreeNode) bool {
+	for i, n2 := range n.nodes {
+		if n2.id == node.Id() {
+			n.tree.unregister(n2)
+			n2.parent = nil
+			n.nodes = append(n.nodes[:i], n.nodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (n *treeNodeImpl) ClearNodes() {
+	for _, n2 := range n.nodes {
+		n.tree.unregister(n2)
+		n2.parent = nil
+	}
+	n.nodes = nil
+}
+
+func (n *treeNodeImpl) Nodes() []TreeNode {
+	nodes := make([]TreeNode, len(n.nodes))
+	for i, n2 := range n.nodes {
+		nodes[i] = n2
+	}
+	return nodes
+}
+
+func (n *treeNodeImpl) Leaf() bool {
+	return n.leaf
+}
+
+func (n *treeNodeImpl) SetLeaf(leaf bool) {
+	n.leaf = leaf
+}
+
+func (n *treeNodeImpl) Expanded() bool {
+	return n.expanded
+}
+
+func (n *treeNodeImpl) SetExpanded(expanded bool) {
+	n.expanded = expanded
+}
+
+func (n *treeNodeImpl) Loaded() bool {
+	return n.loaded
+}
+
+func (n *treeNodeImpl) SetLoaded(loaded bool) {
+	n.loaded = loaded
+}
+
+// expandable tells if the node can be expanded.
+func (n *treeNodeImpl) expandable() bool {
+	if n.leaf {
+		return false
+	}
+	return len(n.nodes) > 0 || !n.loaded && n.tree.loader != nil
+}
+
+func (c *treeImpl) Root() TreeNode {
+	return c.root
+}
+
+func (c *treeImpl) Loader() TreeLoader {
+	return c.loader
+}
+
+func (c *treeImpl) SetLoader(loader TreeLoader) {
+	c.loader = loader
+}
+
+func (c *treeImpl) Selected() TreeNode {
+	if c.selected == nil {
+		return nil
+	}
+	return c.selected
+}
+
+func (c *treeImpl) SetSelected(node TreeNode) {
+	if node == nil {
+		c.selected = nil
+		return
+	}
+	c.selected = c.nodes[node.Id()]
+}
+
+func (c *treeImpl) NodeById(id ID) TreeNode {
+	if n := c.nodes[id]; n != nil {
+		return n
+	}
+	return nil
+}
+
+func (c *treeImpl) EventNode() TreeNode {
+	if c.eventNode == nil {
+		return nil
+	}
+	return c.eventNode
+}
+
+func (c *treeImpl) preprocessEvent(event Event, r *http.Request) {
+	c.eventNode = nil
+
+	id, err := AtoID(r.FormValue(_PARAM_COMP_VALUE))
+	if err != nil {
+		return
+	}
+	n := c.nodes[id]
+	if n == nil || n == c.root {
+		return
+	}
+	c.eventNode = n
+
+	switch event.Type() {
+	case ETYPE_STATE_CHANGE:
+		if !n.expanded && !n.leaf && !n.loaded && c.loader != nil {
+			n.ClearNodes()
+			c.loader(event, n)
+			n.loaded = true
+		}
+		n.expanded = !n.expanded
+	case ETYPE_CHANGE:
+		c.selected = n
+	}
+	event.MarkDirty(c)
+}
+
+var (
+	_STR_TREE_NODE_OP   = []byte(`<div class="gwu-Tree-Node"><span class="gwu-Tree-Toggle`) // `<div class="gwu-Tree-Node"><span class="gwu-Tree-Toggle`
+	_STR_TREE_LABEL_OP  = []byte(`<span class="gwu-Tree-Label`)                             // `<span class="gwu-Tree-Label`
+	_STR_TREE_SELECTED  = []byte(` gwu-Tree-Label-Selected`)                                // ` gwu-Tree-Label-Selected`
+	_STR_TREE_CHILDREN  = []byte(`<div class="gwu-Tree-Children">`)                         // `<div class="gwu-Tree-Children">`
+	_STR_TREE_QUOTE_SE  = []byte(`" onclick="se(event,`)                                    // `" onclick="se(event,`
+	_STR_TREE_SE_SUFFIX = []byte(`)">`)                                                     // `)">`
+)
+
+func (c *treeImpl) Render(w writer) {
+	w.Write(_STR_DIV_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	c.renderNodes(w, c.root)
+
+	w.Write(_STR_DIV_CL)
+}
+
+// renderNodes renders the child nodes of the specified node.
+func (c *treeImpl) renderNodes(w writer, n *treeNodeImpl) {
+	for _, n2 := range n.nodes {
+		w.Write(_STR_TREE_NODE_OP)
+		if n2.expandable() {
+			if n2.expanded {
+				w.Writes(" gwuimg-expanded")
+			} else {
+				w.Writes(" gwuimg-collapsed")
+			}
+			c.renderNodeSe(w, ETYPE_STATE_CHANGE, n2)
+		} else {
+			w.Write(_STR_QUOTE)
+			w.Write(_STR_GT)
+		}
+		w.Write(_STR_SPAN_CL)
+
+		w.Write(_STR_TREE_LABEL_OP)
+		if n2 == c.selected {
+			w.Write(_STR_TREE_SELECTED)
+		}
+		c.renderNodeSe(w, ETYPE_CHANGE, n2)
+		w.Writees(n2.text)
+		w.Write(_STR_SPAN_CL)
+
+		if n2.expanded && len(n2.nodes) > 0 {
+			w.Write(_STR_TREE_CHILDREN)
+			c.renderNodes(w, n2)
+			w.Write(_STR_DIV_CL)
+		}
+
+		w.Write(_STR_DIV_CL)
+	}
+}
+
+// renderNodeSe closes the class attribute and renders an onclick attribute
+// which sends an event of the specified type for the specified node.
+func (c *treeImpl) renderNodeSe(w writer, etype EventType, n *treeNodeImpl) {
+	// To render: `" onclick="se(event,etype,compId,nodeId)">`
+	w.Write(_STR_TREE_QUOTE_SE)
+	w.Writev(int(etype))
+	w.Write(_STR_COMMA)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(n.id))
+	w.Write(_STR_TREE_SE_SUFFIX)
+}
+
+func (c *treeImpl) usage() ([]Comp, int64) {
+	var memory int64
+	for _, n := range c.nodes {
+		memory += _ITEM_FOOTPRINT + int64(len(n.text))
+	}
+	return nil, memory
+}
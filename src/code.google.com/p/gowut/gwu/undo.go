@@ -0,0 +1,248 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Undo/redo support: reversible commands and the per-session undo manager.
+
+package gwu
+
+import (
+	"net/http"
+)
+
+// Command interface defines a reversible action, executed by an UndoManager.
+type Command interface {
+	// Name returns the name of the command, which can be displayed
+	// e.g. in an "Undo <name>" menu item.
+	Name() string
+
+	// Do executes (or re-executes in case of redo) the command.
+	Do(e Event)
+
+	// Undo reverts the effects of the command.
+	Undo(e Event)
+}
+
+// Default max number of commands an UndoManager can undo.
+const DEFAULT_UNDO_LIMIT = 100
+
+// UndoManager interface defines the undo and redo stacks of commands of a session.
+// Event handlers execute commands through the undo manager (instead of
+// performing the changes directly), which can then be undone and redone.
+//
+// Event handlers are called while holding the lock of the session,
+// so no synchronization is needed when using the undo manager of the session
+// from event handlers (or from functions passed to Session.Push()).
+//
+// The undo manager of a session can be obtained with UndoManagerOf().
+type UndoManager interface {
+	// Execute executes the specified command, and pushes it to the undo stack.
+	// The redo stack is cleared.
+	Execute(e Event, cmd Command)
+
+	// Undo undoes the last executed command and moves it to the redo stack.
+	// Returns false if there is nothing to undo.
+	Undo(e Event) bool
+
+	// Redo re-executes the last undone command and moves it back to the undo stack.
+	// Returns false if there is nothing to redo.
+	Redo(e Event) bool
+
+	// CanUndo tells if there is a command to undo.
+	CanUndo() bool
+
+	// CanRedo tells if there is a command to redo.
+	CanRedo() bool
+
+	// UndoName returns the name of the command to undo.
+	// Returns an empty string if there is nothing to undo.
+	UndoName() string
+
+	// RedoName returns the name of the command to redo.
+	// Returns an empty string if there is nothing to redo.
+	RedoName() string
+
+	// Clear clears the undo and redo stacks.
+	Clear()
+
+	// Limit returns the max number of commands that can be undone.
+	Limit() int
+
+	// SetLimit sets the max number of commands that can be undone,
+	// the oldest commands are dropped when the limit is exceeded.
+	// Default is DEFAULT_UNDO_LIMIT.
+	SetLimit(limit int)
+
+	// SetChangeHandler sets a function to be called when the undo or
+	// redo stack changes, e.g. to enable/disable undo and redo buttons.
+	// Pass nil to remove the change handler.
+	SetChangeHandler(handler func(e Event))
+
+	// NewShortcuts creates a new invisible component which handles
+	// the undo (Ctrl+Z) and redo (Ctrl+Y and Ctrl+Shift+Z) keyboard shortcuts
+	// when added to a window. Shortcuts are ignored while a text input
+	// is focused, so the native undo of the browser is left intact there.
+	NewShortcuts() Comp
+}
+
+// Name of the session attribute storing the undo manager.
+const _ATTR_UNDO_MANAGER = "gwu-UndoManager"
+
+// UndoManagerOf returns the undo manager of the specified session,
+// it is created on first use.
+func UndoManagerOf(sess Session) UndoManager {
+	if um, ok := sess.Attr(_ATTR_UNDO_MANAGER).(UndoManager); ok {
+		return um
+	}
+	um := &undoManagerImpl{limit: DEFAULT_UNDO_LIMIT}
+	sess.SetAttr(_ATTR_UNDO_MANAGER, um)
+	return um
+}
+
+// UndoManager implementation.
+type undoManagerImpl struct {
+	undos, redos  []Command     // Undo and redo stacks
+	limit         int           // Max number of commands that can be undone
+	changeHandler func(e Event) // Function to call when the stacks change
+}
+
+func (um *undoManagerImpl) Execute(e Event, cmd Command) {
+	cmd.Do(e)
+	um.undos = append(um.undos, cmd)
+	if len(um.undos) > um.limit {
+		um.undos = append(um.undos[:0], um.undos[len(um.undos)-um.limit:]...)
+	}
+	um.redos = nil
+	um.changed(e)
+}
+
+func (um *undoManagerImpl) Undo(e Event) bool {
+	if len(um.undos) == 0 {
+		return false
+	}
+	cmd := um.undos[len(um.undos)-1]
+	um.undos = um.undos[:len(um.undos)-1]
+	cmd.Undo(e)
+	um.redos = append(um.redos, cmd)
+	um.changed(e)
+	return true
+}
+
+func (um *undoManagerImpl) Redo(e Event) bool {
+	if len(um.redos) == 0 {
+		return false
+	}
+	cmd := um.redos[len(um.redos)-1]
+	um.redos = um.redos[:len(um.redos)-1]
+	cmd.Do(e)
+	um.undos = append(um.undos, cmd)
+	um.changed(e)
+	return true
+}
+
+func (um *undoManagerImpl) CanUndo() bool {
+	return len(um.undos) > 0
+}
+
+func (um *undoManagerImpl) CanRedo() bool {
+	return len(um.redos) > 0
+}
+
+func (um *undoManagerImpl) UndoName() string {
+	if len(um.undos) == 0 {
+		return ""
+	}
+	return um.undos[len(um.undos)-1].Name()
+}
+
+func (um *undoManagerImpl) RedoName() string {
+	if len(um.redos) == 0 {
+		return ""
+	}
+	return um.redos[len(um.redos)-1].Name()
+}
+
+func (um *undoManagerImpl) Clear() {
+	um.undos, um.redos = nil, nil
+}
+
+func (um *undoManagerImpl) Limit() int {
+	return um.limit
+}
+
+func (um *undoManagerImpl) SetLimit(limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+	um.limit = limit
+	if len(um.undos) > limit {
+		um.undos = append(um.undos[:0], um.undos[len(um.undos)-limit:]...)
+	}
+}
+
+func (um *undoManagerImpl) SetChangeHandler(handler func(e Event)) {
+	um.changeHandler = handler
+}
+
+// changed calls the change handler (if set).
+func (um *undoManagerImpl) changed(e Event) {
+	if um.changeHandler != nil {
+		um.changeHandler(e)
+	}
+}
+
+func (um *undoManagerImpl) NewShortcuts() Comp {
+	c := &undoShortcutsImpl{compImpl: newCompImpl(nil), um: um}
+	c.AddEHandlerFunc(func(e Event) {
+		if c.redo {
+			um.Redo(e)
+		} else {
+			um.Undo(e)
+		}
+	}, ETYPE_STATE_CHANGE)
+	SetBusySuppressed(c, true)
+	return c
+}
+
+// undoShortcutsImpl is an invisible component which handles
+// the undo and redo keyboard shortcuts.
+type undoShortcutsImpl struct {
+	compImpl // Component implementation
+
+	um   *undoManagerImpl // Undo manager to call
+	redo bool             // Tells if the last shortcut was redo
+}
+
+func (c *undoShortcutsImpl) preprocessEvent(event Event, r *http.Request) {
+	c.redo = r.FormValue(_PARAM_COMP_VALUE) == "redo"
+}
+
+var (
+	_STR_UNDO_SCRIPT_OP = []byte("<script>undoKeys(") // "<script>undoKeys("
+)
+
+func (c *undoShortcutsImpl) Render(w writer) {
+	w.Write(_STR_SPAN_OP)
+	c.renderAttrsAndStyle(w)
+	c.renderEHandlers(w)
+	w.Write(_STR_GT)
+
+	w.writeScript(_STR_UNDO_SCRIPT_OP)
+	w.Writev(int(c.id))
+	w.Write(_STR_COMMA)
+	w.Writev(int(ETYPE_STATE_CHANGE))
+	w.Write(_STR_SCRIPT_CL)
+
+	w.Write(_STR_SPAN_CL)
+}
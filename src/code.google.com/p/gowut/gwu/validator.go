@@ -0,0 +1,264 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Validators of component values.
+
+package gwu
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Validator interface defines a validator of component values.
+type Validator interface {
+	// Validate validates a value.
+	// Returns nil if the value is valid, else the error describing why it is invalid.
+	Validate(value string) error
+}
+
+// ClientValidator interface defines a validator which can also
+// validate values in the browser (as the user types).
+type ClientValidator interface {
+	// ClientValidator is a Validator.
+	Validator
+
+	// ValidatorJs returns a JavaScript expression which evaluates
+	// to true if the value held by the variable v is valid.
+	ValidatorJs() string
+}
+
+// ValidatorFunc is a function which implements Validator,
+// for custom validation logic.
+// Values are validated by ValidatorFuncs only on the server side.
+type ValidatorFunc func(value string) error
+
+// Validate calls f(value).
+func (f ValidatorFunc) Validate(value string) error {
+	return f(value)
+}
+
+// Length validator.
+type lengthValidator struct {
+	min, max int   // Min and max length (in characters)
+	err      error // Error returned for invalid values
+}
+
+// NewLengthValidator creates a new Validator which checks the length
+// of values (in characters).
+// Pass a negative max to not limit the max length.
+// msg is the message of the error returned for invalid values,
+// a default message is used if it is empty.
+func NewLengthValidator(min, max int, msg string) ClientValidator {
+	if len(msg) == 0 {
+		if max < 0 {
+			msg = "Must be at least " + strconv.Itoa(min) + " characters long!"
+		} else {
+			msg = "Must be " + strconv.Itoa(min) + " to " + strconv.Itoa(max) + " characters long!"
+		}
+	}
+	return &lengthValidator{min: min, max: max, err: errors.New(msg)}
+}
+
+func (v *lengthValidator) Validate(value string) error {
+	if n := utf8.RuneCountInString(value); n < v.min || v.max >= 0 && n > v.max {
+		return v.err
+	}
+	return nil
+}
+
+func (v *lengthValidator) ValidatorJs() string {
+	js := "v.length>=" + strconv.Itoa(v.min)
+	if v.max >= 0 {
+		js += "&&v.length<=" + strconv.Itoa(v.max)
+	}
+	return js
+}
+
+// Regexp validator.
+type regexpValidator struct {
+	re  *regexp.Regexp // Regexp values must match
+	err error          // Error returned for invalid values
+}
+
+// NewRegexpValidator creates a new Validator which checks if values
+// match a regular expression. Use anchors (^ and $) to require
+// the whole value to match.
+// In the browser the expression is evaluated by JavaScript's RegExp,
+// so use a syntax which is valid in both Go and JavaScript.
+// msg is the message of the error returned for invalid values,
+// a default message is used if it is empty.
+func NewRegexpValidator(re *regexp.Regexp, msg string) ClientValidator {
+	if len(msg) == 0 {
+		msg = "Invalid format!"
+	}
+	return &regexpValidator{re: re, err: errors.New(msg)}
+}
+
+func (v *regexpValidator) Validate(value string) error {
+	if !v.re.MatchString(value) {
+		return v.err
+	}
+	return nil
+}
+
+func (v *regexpValidator) ValidatorJs() string {
+	return "new RegExp('" + jsEscape(v.re.String()) + "').test(v)"
+}
+
+// Range validator.
+type rangeValidator struct {
+	min, max float64 // Min and max values
+	err      error   // Error returned for invalid values
+}
+
+// NewRangeValidator creates a new Validator which checks if values
+// are numbers in the range [min..max].
+// msg is the message of the error returned for invalid values,
+// a default message is used if it is empty.
+func NewRangeValidator(min, max float64, msg string) ClientValidator {
+	if len(msg) == 0 {
+		msg = "Must be a number between " + strconv.FormatFloat(min, 'g', -1, 64) +
+			" and " + strconv.FormatFloat(max, 'g', -1, 64) + "!"
+	}
+	return &rangeValidator{min: min, max: max, err: errors.New(msg)}
+}
+
+func (v *rangeValidator) Validate(value string) error {
+	if f, err := strconv.ParseFloat(value, 64); err != nil || f < v.min || f > v.max {
+		return v.err
+	}
+	return nil
+}
+
+func (v *rangeValidator) ValidatorJs() string {
+	// Number() (unlike parseFloat()) rejects values with trailing garbage like strconv.ParseFloat()
+	return "v.trim().length>0&&Number(v)>=" + strconv.FormatFloat(v.min, 'g', -1, 64) +
+		"&&Number(v)<=" + strconv.FormatFloat(v.max, 'g', -1, 64)
+}
+
+// HasValidators interface defines validators of the value of a component.
+//
+// The value is validated when it is synchronized with the server,
+// and if it is invalid, the "gwu-Invalid" style class is added to the component.
+// Values are also validated in the browser as the user types
+// by the validators implementing ClientValidator.
+type HasValidators interface {
+	// AddValidator adds a validator.
+	AddValidator(v Validator)
+
+	// Validators returns the validators.
+	Validators() []Validator
+
+	// Validate validates the current value with the validators,
+	// and updates the "gwu-Invalid" style class.
+	// Returns the error of the first validator the value fails,
+	// nil if the value is valid.
+	Validate() error
+
+	// Valid tells if the current value is valid.
+	Valid() bool
+}
+
+// HasValidators implementation.
+type hasValidatorsImpl struct {
+	validators []Validator // The validators
+}
+
+func (c *hasValidatorsImpl) AddValidator(v Validator) {
+	c.validators = append(c.validators, v)
+}
+
+func (c *hasValidatorsImpl) Validators() []Validator {
+	validators := make([]Validator, len(c.validators))
+	copy(validators, c.validators)
+	return validators
+}
+
+// validate validates the value with the validators,
+// and updates the "gwu-Invalid" style class of style.
+// Returns the error of the first validator the value fails,
+// and if the validity changed.
+func (c *hasValidatorsImpl) validate(value string, style Style) (err error, changed bool) {
+	if len(c.validators) == 0 {
+		return nil, false
+	}
+
+	for _, v := range c.validators {
+		if err = v.Validate(value); err != nil {
+			break
+		}
+	}
+
+	wasInvalid := style.HasClass("gwu-Invalid")
+	if err != nil && !wasInvalid {
+		style.AddClass("gwu-Invalid")
+	} else if err == nil && wasInvalid {
+		style.RemoveClass("gwu-Invalid")
+	}
+	return err, wasInvalid != (err != nil)
+}
+
+var (
+	_STR_VD_CHECK_OP = []byte(` oninput="vdCheck(this,function(v){return `) // ` oninput="vdCheck(this,function(v){return `
+	_STR_VD_AND      = []byte("&&")                                         // "&&"
+	_STR_VD_CHECK_CL = []byte(`;})"`)                                       // `;})"`
+)
+
+// renderValidators renders the client side validation of the validators
+// implementing ClientValidator.
+func (c *hasValidatorsImpl) renderValidators(w writer) {
+	first := true
+	for _, v := range c.validators {
+		cv, ok := v.(ClientValidator)
+		if !ok {
+			continue
+		}
+		if first {
+			first = false
+			// To render: ` oninput="vdCheck(this,function(v){return (js1)&&(js2);})"`
+			w.Write(_STR_VD_CHECK_OP)
+		} else {
+			w.Write(_STR_VD_AND)
+		}
+		w.Writees("(" + cv.ValidatorJs() + ")")
+	}
+	if !first {
+		w.Write(_STR_VD_CHECK_CL)
+	}
+}
+
+// ValidateAll validates all components having validators in the component
+// tree rooted at root (e.g. a form Panel or a Window).
+// Returns the invalid components, an empty slice if all are valid.
+// If e is not nil, components whose validity changed are marked dirty.
+func ValidateAll(e Event, root Comp) (invalid []Comp) {
+	walkComps(root, "", func(path string, c Comp) {
+		hv, ok := c.(HasValidators)
+		if !ok {
+			return
+		}
+		wasInvalid := c.Style().HasClass("gwu-Invalid")
+		if hv.Validate() != nil {
+			invalid = append(invalid, c)
+		}
+		if e != nil && wasInvalid != c.Style().HasClass("gwu-Invalid") {
+			e.MarkDirty(c)
+		}
+	})
+	return
+}
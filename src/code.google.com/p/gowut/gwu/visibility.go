@@ -0,0 +1,69 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Component visibility: hiding components without removing them.
+
+package gwu
+
+// Visibility type.
+type Visibility int
+
+// Visibilities.
+const (
+	VISIBLE         Visibility = iota // Component is visible
+	HIDDEN_COLLAPSE                   // Component is hidden and does not take up space
+	HIDDEN_PRESERVE                   // Component is hidden but its space is preserved in the layout
+)
+
+func (c *compImpl) Visible() bool {
+	return c.visibility == VISIBLE
+}
+
+func (c *compImpl) SetVisible(visible bool) {
+	if visible {
+		c.visibility = VISIBLE
+	} else {
+		c.visibility = HIDDEN_COLLAPSE
+	}
+}
+
+func (c *compImpl) Visibility() Visibility {
+	return c.visibility
+}
+
+func (c *compImpl) SetVisibility(visibility Visibility) {
+	c.visibility = visibility
+}
+
+// ShowComps makes the specified components visible,
+// and marks them dirty so the change is displayed.
+func ShowComps(e Event, comps ...Comp) {
+	SetVisibility(e, VISIBLE, comps...)
+}
+
+// HideComps hides the specified components (collapsing their space),
+// and marks them dirty so the change is displayed.
+func HideComps(e Event, comps ...Comp) {
+	SetVisibility(e, HIDDEN_COLLAPSE, comps...)
+}
+
+// SetVisibility sets the visibility of the specified components,
+// and marks them dirty so the change is displayed.
+func SetVisibility(e Event, visibility Visibility, comps ...Comp) {
+	for _, c := range comps {
+		c.SetVisibility(visibility)
+	}
+	e.MarkDirty(comps...)
+}
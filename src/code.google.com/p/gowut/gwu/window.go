@@ -17,6 +17,13 @@
 
 package gwu
 
+import (
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
 // The Window interface is the top of the component hierarchy.
 // A Window defines the content seen in the browser window.
 // Multiple windows can be created, but only one is visible
@@ -39,10 +46,145 @@ type Window interface {
 	// SetName sets the name of the window.
 	SetName(name string)
 
+	// LocalizedText returns the text (title) of the window for the
+	// specified language (e.g. "en", "en-US").
+	// If no text is set for the language, the text set for the primary
+	// language subtag (e.g. "en" for "en-US") is returned,
+	// else the result of Text() is returned.
+	LocalizedText(lang string) string
+
+	// SetLocalizedText sets the text (title) of the window for the
+	// specified language (e.g. "en", "de").
+	// Localized texts are used in the window list and as the title of
+	// the browser window, based on the client's preferred language.
+	// Pass an empty text to remove the localized text of the language.
+	SetLocalizedText(lang, text string)
+
+	// Description returns the description of the window.
+	Description() string
+
+	// SetDescription sets the description of the window
+	// which is displayed in the window list.
+	SetDescription(description string)
+
+	// Icon returns the URL of the icon of the window.
+	Icon() string
+
+	// SetIcon sets the URL of the icon of the window
+	// which is displayed in the window list.
+	SetIcon(url string)
+
+	// Order returns the order of the window in the window list.
+	Order() int
+
+	// SetOrder sets the order of the window in the window list.
+	// Windows with lower order are listed first, windows with
+	// the same order are sorted by their text (title).
+	// The default order is 0.
+	SetOrder(order int)
+
 	// AddHeadHtml adds an HTML text which will be included
 	// in the HTML head section.
+	// Script tags of the HTML text get the nonce of the Content-Security-Policy
+	// (see Server.SetSecurityHeaders()).
 	AddHeadHtml(html string)
 
+	// AddHeadCss adds an external stylesheet (e.g. a font or the
+	// application's own CSS) which will be linked in the HTML head section.
+	// Stylesheets are included after the CSS of the theme,
+	// so they may override its rules.
+	AddHeadCss(url string)
+
+	// AddHeadJs adds an external JavaScript which will be included
+	// in the HTML head section.
+	AddHeadJs(url string)
+
+	// PushEnabled tells if the push channel of the window is enabled.
+	PushEnabled() bool
+
+	// SetPushEnabled sets if the push channel of the window is enabled.
+	// If enabled, the browser keeps a pending (long poll) request to the
+	// server, and changes pushed by Session.Push() are delivered and
+	// re-rendered immediately, not just after the next event.
+	// The push channel is disabled by default.
+	// The window has to be reloaded for the change to take effect.
+	SetPushEnabled(enabled bool)
+
+	// PollStrategy returns the strategy of polling the server for pushes.
+	PollStrategy() PollStrategy
+
+	// SetPollStrategy sets the strategy of polling the server for pushes,
+	// used if the push channel is enabled but WebSockets are not used
+	// (see Server.SetWsEnabled()) or not supported by the browser.
+	// Intervals and pausing hidden pages reduce the load caused by idle clients
+	// (e.g. dashboards left open) at the cost of delayed pushes.
+	// Default is long polling paused while the page is hidden.
+	// The window has to be reloaded for the change to take effect.
+	SetPollStrategy(ps PollStrategy)
+
+	// Refresh re-renders the specified components of the window
+	// (the whole window content if no components are specified),
+	// in the browser without page reload.
+	// Components not added to the window are ignored.
+	// 
+	// Each component is re-rendered as a whole: the browser replaces
+	// its HTML element (including all descendants) with the new rendering,
+	// and executes the scripts of the new rendering. Client side state kept
+	// in the replaced elements (e.g. unsynchronized input, scroll positions)
+	// is lost, elements outside of the components are left intact.
+	// 
+	// Unlike Event.MarkDirty(), this can be used with events not originating
+	// from the window, e.g. events of other windows of the session or the event
+	// passed to the function of Session.Push(): the components are delivered
+	// to the window over its push channel (see SetPushEnabled()), or with
+	// the response of the next event of the window if push is not enabled.
+	Refresh(e Event, comps ...Comp)
+
+	// BusyIndicator returns the busy indicator of the window.
+	BusyIndicator() BusyIndicator
+
+	// SetBusyIndicator sets the busy indicator of the window, which is
+	// displayed automatically while an event is being processed (while
+	// the event round-trip is in flight).
+	// BUSY_SPINNER falls back to BUSY_BAR for events not generated by a component.
+	// See SetBusySuppressed() to suppress it for the events of a component.
+	// Default is BUSY_BAR.
+	// The window has to be reloaded for the change to take effect.
+	SetBusyIndicator(busy BusyIndicator)
+
+	// BusyDelay returns the delay after which the busy indicator is displayed.
+	BusyDelay() time.Duration
+
+	// SetBusyDelay sets the delay after which the busy indicator is displayed,
+	// so it does not flash for fast event handlers.
+	// Default is DEFAULT_BUSY_DELAY.
+	// The window has to be reloaded for the change to take effect.
+	SetBusyDelay(delay time.Duration)
+
+	// SetSplash sets up a staged load of the window, so heavy windows
+	// are displayed instantly: splash is a lightweight component (e.g. a logo
+	// and an indeterminate ProgressBar) which is rendered in place of the content
+	// of the window, and build is called in a second phase, in an event sent
+	// automatically by the browser when the page is loaded, to build
+	// the real (expensive) content of the window which is then swapped in.
+	// 
+	// build is called only once, after that the window is rendered as usual.
+	// Window load (ETYPE_WIN_LOAD) event handlers of the window are called
+	// after build. The splash is only displayed, its events are not handled.
+	// Pass a nil build to cancel a pending staged load.
+	SetSplash(splash Comp, build func(e Event))
+
+	// Splash returns the splash component of the pending staged load,
+	// nil if no staged load is pending.
+	Splash() Comp
+
+	// Hidden tells if the page of the window is hidden in the browser
+	// (e.g. it is on a background tab or the browser is minimized),
+	// as last reported by the browser.
+	// Visibility changes are only reported if the window has
+	// ETYPE_VISIBILITY_CHANGE event handlers.
+	Hidden() bool
+
 	// SetFocusedCompId sets the id of the currently focused component. 
 	SetFocusedCompId(id ID)
 
@@ -50,16 +192,70 @@ type Window interface {
 	// If an empty string is returned, the server's theme will be used.
 	Theme() string
 
-	// SetTheme sets the default CSS theme of the window.
+	// SetTheme sets the default CSS theme of the window,
+	// the name of a registered theme (see Server.AddTheme()).
 	// If an empty string is set, the server's theme will be used.
+	// If called while processing an event of the window, the theme is
+	// switched in the browser without page reload, else the window
+	// has to be reloaded for the change to take effect.
 	SetTheme(theme string)
 
+	// RequiredRoles returns the roles required to access the window.
+	RequiredRoles() []string
+
+	// SetRequiredRoles sets the roles required to access the window:
+	// sessions having any of the roles may access it
+	// (ROLE_AUTHENTICATED means any authenticated session).
+	// Access is checked by the access controller of the server
+	// (see Server.SetAccessController()).
+	// No roles are required by default.
+	SetRequiredRoles(roles ...string)
+
+	// Snapshot returns a snapshot of the state of the components
+	// of the window (values, selections, expanded state etc.).
+	// The snapshot can be serialized and restored later, e.g.
+	// to implement "save my workspace layout" features.
+	Snapshot() Snapshot
+
+	// Restore restores the state of the components of the window
+	// from the specified snapshot. Components are matched by their
+	// structural path, so the window must be built the same way
+	// as the one the snapshot was taken from.
+	// The window has to be marked dirty (or reloaded) after restoring
+	// during event handling for the changes to be visible.
+	Restore(s Snapshot)
+
 	// RenderWin renders the window as a complete HTML document.
 	RenderWin(w writer, s Server)
+
+	// renderWinLang renders the window as a complete HTML document
+	// for the specified session, using the title localized for the
+	// specified language.
+	renderWinLang(w writer, s Server, sess Session, lang string)
+
+	// Dialogs returns the dialogs shown in the window,
+	// the last one is on top.
+	Dialogs() []Dialog
+
+	// pushQueue returns the push queue of the window.
+	pushQueue() *pushQueue
+
+	// themeChanged tells if the theme of the window has been changed
+	// since the window was last rendered or themeChanged was called.
+	themeChanged() bool
+
+	// dialogLayer returns the container of the dialogs shown in the window.
+	dialogLayer() *dialogLayer
+
+	// preprocessWinEvent preprocesses a window event.
+	// Events of the window are sent to its embedded panel (that is what
+	// ById() returns for the id of the window), so this is called instead
+	// of preprocessEvent().
+	preprocessWinEvent(e Event, r *http.Request)
 }
 
 // WinSlice is a slice of windows which implements sort.Interface so it
-// can be sorted by window text (title).
+// can be sorted by window order and text (title).
 type WinSlice []Window
 
 func (w WinSlice) Len() int {
@@ -67,6 +263,9 @@ func (w WinSlice) Len() int {
 }
 
 func (w WinSlice) Less(i, j int) bool {
+	if w[i].Order() != w[j].Order() {
+		return w[i].Order() < w[j].Order()
+	}
 	return w[i].Text() < w[j].Text()
 }
 
@@ -83,12 +282,36 @@ type windowImpl struct {
 	heads         []string // Additional head HTML texts
 	focusedCompId ID       // Id of the last reported focused component
 	theme         string   // CSS theme of the window
+	themeSwitched bool     // Tells if the theme has been changed since the window was rendered
+
+	texts       map[string]string // Localized texts (titles) mapped from language. Lazily initialized.
+	description string            // Description of the window
+	icon        string            // URL of the icon of the window
+	order       int               // Order of the window in the window list
+
+	pushEnabled bool         // Tells if the push channel is enabled
+	pushQueue_  *pushQueue   // Queue of the recent pushes
+	poll        PollStrategy // Strategy of polling for pushes
+
+	busy      BusyIndicator // Busy indicator of the window
+	busyDelay time.Duration // Delay after which the busy indicator is displayed
+
+	dialogs *dialogLayer // Container of the shown dialogs
+
+	splash Comp          // Splash component of the pending staged load
+	build  func(e Event) // Build function of the pending staged load
+	hidden bool          // Tells if the page is hidden in the browser
+
+	requiredRoles []string // Roles required to access the window
 }
 
 // NewWindow creates a new window.
 // The default layout strategy is LAYOUT_VERTICAL.
 func NewWindow(name, text string) Window {
-	c := &windowImpl{panelImpl: newPanelImpl(), hasTextImpl: newHasTextImpl(text), name: name}
+	c := &windowImpl{panelImpl: newPanelImpl(), hasTextImpl: newHasTextImpl(text), name: name, pushQueue_: newPushQueue(),
+		busy: BUSY_BAR, busyDelay: DEFAULT_BUSY_DELAY, poll: PollStrategy{PauseHidden: true}}
+	c.dialogs = newDialogLayer(c)
+	c.flushChildren = true
 	c.Style().AddClass("gwu-Window")
 	return c
 }
@@ -101,10 +324,167 @@ func (w *windowImpl) SetName(name string) {
 	w.name = name
 }
 
+func (w *windowImpl) LocalizedText(lang string) string {
+	if w.texts != nil && len(lang) > 0 {
+		if text, found := w.texts[lang]; found {
+			return text
+		}
+		// Try primary language subtag, e.g. "en" for "en-US"
+		if i := strings.IndexAny(lang, "-_"); i > 0 {
+			if text, found := w.texts[lang[:i]]; found {
+				return text
+			}
+		}
+	}
+	return w.text
+}
+
+func (w *windowImpl) SetLocalizedText(lang, text string) {
+	if len(text) == 0 {
+		delete(w.texts, lang)
+		return
+	}
+	if w.texts == nil {
+		w.texts = make(map[string]string)
+	}
+	w.texts[lang] = text
+}
+
+func (w *windowImpl) Description() string {
+	return w.description
+}
+
+func (w *windowImpl) SetDescription(description string) {
+	w.description = description
+}
+
+func (w *windowImpl) Icon() string {
+	return w.icon
+}
+
+func (w *windowImpl) SetIcon(url string) {
+	w.icon = url
+}
+
+func (w *windowImpl) Order() int {
+	return w.order
+}
+
+func (w *windowImpl) SetOrder(order int) {
+	w.order = order
+}
+
 func (w *windowImpl) AddHeadHtml(html string) {
 	w.heads = append(w.heads, html)
 }
 
+func (w *windowImpl) AddHeadCss(url string) {
+	w.heads = append(w.heads, `<link href="`+html.EscapeString(url)+`" rel="stylesheet" type="text/css">`)
+}
+
+func (w *windowImpl) AddHeadJs(url string) {
+	w.heads = append(w.heads, `<script src="`+html.EscapeString(url)+`"></script>`)
+}
+
+func (w *windowImpl) PushEnabled() bool {
+	return w.pushEnabled
+}
+
+func (w *windowImpl) SetPushEnabled(enabled bool) {
+	w.pushEnabled = enabled
+}
+
+func (w *windowImpl) PollStrategy() PollStrategy {
+	return w.poll
+}
+
+func (w *windowImpl) SetPollStrategy(ps PollStrategy) {
+	w.poll = ps
+}
+
+func (w *windowImpl) Refresh(e Event, comps ...Comp) {
+	if len(comps) == 0 {
+		e.(*eventImpl).reloadIn(w, w)
+		return
+	}
+
+	own := make([]Comp, 0, len(comps))
+	for _, c := range comps {
+		if win := e.Session().compWin(c); win != nil && win.Id() == w.Id() {
+			own = append(own, c)
+		}
+	}
+	e.(*eventImpl).reloadIn(w, own...)
+}
+
+func (w *windowImpl) BusyIndicator() BusyIndicator {
+	return w.busy
+}
+
+func (w *windowImpl) SetBusyIndicator(busy BusyIndicator) {
+	w.busy = busy
+}
+
+func (w *windowImpl) BusyDelay() time.Duration {
+	return w.busyDelay
+}
+
+func (w *windowImpl) SetBusyDelay(delay time.Duration) {
+	w.busyDelay = delay
+}
+
+func (w *windowImpl) SetSplash(splash Comp, build func(e Event)) {
+	if build == nil {
+		splash = nil
+	}
+	w.splash, w.build = splash, build
+}
+
+func (w *windowImpl) Splash() Comp {
+	return w.splash
+}
+
+func (w *windowImpl) Hidden() bool {
+	return w.hidden
+}
+
+func (w *windowImpl) preprocessWinEvent(e Event, r *http.Request) {
+	switch e.Type() {
+	case ETYPE_WIN_LOAD:
+		if w.build == nil {
+			return
+		}
+		// Second phase of the staged load: build and swap in the content
+		build := w.build
+		w.splash, w.build = nil, nil
+		build(e)
+		e.MarkDirty(w)
+	case ETYPE_VISIBILITY_CHANGE:
+		w.hidden = r.FormValue(_PARAM_COMP_VALUE) == "true"
+	}
+}
+
+func (w *windowImpl) pushQueue() *pushQueue {
+	return w.pushQueue_
+}
+
+func (w *windowImpl) Dialogs() []Dialog {
+	dialogs := make([]Dialog, len(w.dialogs.dialogs))
+	copy(dialogs, w.dialogs.dialogs)
+	return dialogs
+}
+
+func (w *windowImpl) dialogLayer() *dialogLayer {
+	return w.dialogs
+}
+
+func (w *windowImpl) ById(id ID) Comp {
+	if c := w.panelImpl.ById(id); c != nil {
+		return c
+	}
+	return w.dialogs.ById(id)
+}
+
 func (w *windowImpl) SetFocusedCompId(id ID) {
 	w.focusedCompId = id
 }
@@ -114,7 +494,16 @@ func (s *windowImpl) Theme() string {
 }
 
 func (s *windowImpl) SetTheme(theme string) {
-	s.theme = theme
+	if s.theme != theme {
+		s.theme = theme
+		s.themeSwitched = true
+	}
+}
+
+func (s *windowImpl) themeChanged() bool {
+	changed := s.themeSwitched
+	s.themeSwitched = false
+	return changed
 }
 
 func (c *windowImpl) Render(w writer) {
@@ -132,49 +521,92 @@ func (c *windowImpl) Render(w writer) {
 
 		if !found {
 			found = true
-			w.Writes("<script>")
+			w.writeScript(_STR_SCRIPT_TAG)
 		}
 		// To render       : add<etypeFunc>(function(){se(null,etype,id);});
 		// Example (onload): addonload(function(){se(null,13,4327);});
-		w.Writevs("add", etypeFuncs[etype], "(function(){se(null,", int(etype), ",", int(c.id), ");});")
+		w.Writevs("add", etypeFuncs[etype], "(function(){se(null,", int(etype), ",", int(c.id))
+		if etype == ETYPE_VISIBILITY_CHANGE {
+			w.Writes(",document.hidden")
+		}
+		w.Writes(");});")
+	}
+	if c.build != nil && c.HandlersCount(ETYPE_WIN_LOAD) == 0 {
+		// Staged load: the window load event triggers the second phase
+		if !found {
+			found = true
+			w.writeScript(_STR_SCRIPT_TAG)
+		}
+		w.Writevs("add", etypeFuncs[ETYPE_WIN_LOAD], "(function(){se(null,", int(ETYPE_WIN_LOAD), ",", int(c.id), ");});")
 	}
 	if found {
 		w.Writes("</script>")
 	}
 
+	if c.build != nil {
+		// Staged load: render the splash in place of the content
+		comps, cellFmts := c.swapContent([]Comp{c.splash}, nil)
+		defer c.swapContent(comps, cellFmts)
+	}
+
 	// And now call panelImpl's Render()
 	c.panelImpl.Render(w)
 }
 
 func (win *windowImpl) RenderWin(w writer, s Server) {
+	win.renderWinLang(w, s, s, "")
+}
+
+func (win *windowImpl) renderWinLang(w writer, s Server, sess Session, lang string) {
+	w.sess = sess // Components are rendered for the session (e.g. access control)
+
 	// We could optimize this (store byte slices of static strings)
 	// but windows are rendered "so rarely"...
-	w.Writes(`<html><head><meta http-equiv="content-type" content="text/html; charset=UTF-8"><title>`)
-	w.Writees(win.text)
-	w.Writess(`</title><link href="`, s.AppPath(), _PATH_STATIC)
-	if len(win.theme) == 0 {
-		w.Writes(resNameStaticCss(s.Theme()))
-	} else {
-		w.Writes(resNameStaticCss(win.theme))
-	}
+	w.Writes(`<html><head><meta http-equiv="content-type" content="text/html; charset=UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>`)
+	w.Writees(win.LocalizedText(lang))
+	w.Writess(`</title><link id="gwu-theme" href="`, s.AppPath(), _PATH_STATIC, s.themeRes(win.theme))
 	w.Writes(`" rel="stylesheet" type="text/css">`)
-	win.renderDynJs(w, s)
-	w.Writess(`<script src="`, s.AppPath(), _PATH_STATIC, _RES_NAME_STATIC_JS, `"></script>`)
-	w.Writess(win.heads...)
+	win.themeSwitched = false
+	win.renderDynJs(w, s, sess)
+	w.Writess(w.addNonce(`<script src="`), s.AppPath(), _PATH_STATIC, _RES_NAME_STATIC_JS, `"></script>`)
+	for _, head := range win.heads {
+		w.Writes(w.addNonce(head))
+	}
 	w.Writes("</head><body>")
+	// Send the page shell right away (if streaming is enabled)
+	w.Flush()
 
 	win.Render(w)
+	win.dialogs.Render(w)
 
 	w.Writes("</body></html>")
 }
 
 // renderDynJs renders the dynamic JavaScript codes of Gowut.
-func (win *windowImpl) renderDynJs(w writer, s Server) {
-	w.Writes("<script>")
+func (win *windowImpl) renderDynJs(w writer, s Server, sess Session) {
+	paths := s.Paths()
+
+	w.writeScript(_STR_SCRIPT_TAG)
 	w.Writess("var _pathApp='", s.AppPath(), "';")
 	w.Writess("var _pathWin='", s.AppPath(), win.name, "/';")
-	w.Writess("var _pathEvent=_pathWin+'", _PATH_EVENT, "';")
-	w.Writess("var _pathRenderComp=_pathWin+'", _PATH_RENDER_COMP, "';")
+	w.Writess("var _pathEvent=_pathWin+'", paths.Event, "';")
+	w.Writess("var _pathRenderComp=_pathWin+'", paths.RenderComp, "';")
+	w.Writess("var _pathPush=_pathWin+'", paths.Push, "';")
+	w.Writess("var _pathWs=_pathWin+'", paths.Ws, "';")
+	w.Writess("var _pathSessCookie=_pathWin+'", paths.SessCookie, "';")
+	w.Writess("var _pathUpload=_pathWin+'", paths.Upload, "';")
+	w.Writess("var _pathDownload=_pathWin+'", paths.Download, "';")
+	w.Writess("var _pathResource=_pathWin+'", paths.Resource, "';")
+	if s.PathSigning() {
+		w.Writess("var _sig='", s.pathSig(sess, win.name), "';")
+	} else {
+		w.Writes("var _sig=null;")
+	}
 	w.Writess("var _focCompId='", win.focusedCompId.String(), "';")
+	w.Writevs("var _pushSeq=", win.pushQueue_.curSeq(), ",_pushEnabled=", win.pushEnabled, ",_wsEnabled=", s.WsEnabled(), ";")
+	w.Writevs("var _busyMode=", int(win.busy), ",_busyDelay=", int(win.busyDelay/time.Millisecond), ";")
+	renderIdleJs(w, s, sess, win)
+	w.Writevs("var _pollInterval=", int(win.poll.Interval/time.Millisecond), ",_pollMaxInterval=", int(win.poll.MaxInterval/time.Millisecond),
+		",_pollPauseHidden=", win.poll.PauseHidden, ";")
 	w.Writes("</script>")
 }
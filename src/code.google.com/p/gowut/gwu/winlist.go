@@ -0,0 +1,134 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Customizable window list page of the GUI server.
+
+package gwu
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// WinListItem describes an entry of the window list page.
+type WinListItem struct {
+	Name        string // Name of the window
+	Url         string // URL of the window
+	Text        string // Text (title) of the window, localized if available
+	Description string // Description of the window
+	Icon        string // URL of the icon of the window
+}
+
+// WinListData is the data passed to the window list template.
+type WinListData struct {
+	Title        string        // Text (title) of the server
+	Lang         string        // Preferred language of the client (e.g. "en", "de"); empty string if unknown
+	SessCreators []WinListItem // Session creator windows; only listed if there is no private session yet
+	PrivateWins  []WinListItem // Windows of the private session
+	PublicWins   []WinListItem // Public windows
+}
+
+// Default window list template.
+var defaultWinListTemplate = template.Must(template.New("winlist").Parse(`<html><head>
+<meta http-equiv="content-type" content="text/html; charset=UTF-8">
+<title>{{.Title}} - Window list</title>
+<style>
+body {font-family:Arial}
+ul.gwu-WinList {list-style:none; padding-left:10px}
+ul.gwu-WinList li {margin:6px 0px}
+ul.gwu-WinList img {width:16px; height:16px; vertical-align:middle; margin-right:5px}
+.gwu-WinList-Desc {color:#666; font-size:90%; margin-left:5px}
+</style>
+</head><body>
+<h2>{{.Title}} - Window list</h2>
+{{define "items"}}<ul class="gwu-WinList">{{range .}}<li>{{if .Icon}}<img src="{{.Icon}}">{{end}}<a href="{{.Url}}">{{.Text}}</a>{{if .Description}}<span class="gwu-WinList-Desc">{{.Description}}</span>{{end}}</li>{{end}}</ul>{{end}}
+{{if .SessCreators}}Session creators:{{template "items" .SessCreators}}{{end}}
+{{if .PrivateWins}}Authenticated windows:{{template "items" .PrivateWins}}{{end}}
+Public windows:{{template "items" .PublicWins}}
+</body></html>`))
+
+// clientLang returns the preferred language of the client
+// based on the Accept-Language header, e.g. "en" or "en-US".
+// Empty string is returned if the header is missing.
+func clientLang(r *http.Request) string {
+	al := r.Header.Get("Accept-Language")
+	if len(al) == 0 {
+		return ""
+	}
+	// First language is the preferred one, e.g. "en-US,en;q=0.8,de;q=0.6"
+	lang := strings.SplitN(al, ",", 2)[0]
+	lang = strings.SplitN(lang, ";", 2)[0]
+	return strings.TrimSpace(lang)
+}
+
+// newWinListItem creates a new WinListItem for the specified window.
+func (s *serverImpl) newWinListItem(win Window, lang string) WinListItem {
+	return WinListItem{Name: win.Name(), Url: s.appPath + win.Name(), Text: win.LocalizedText(lang),
+		Description: win.Description(), Icon: win.Icon()}
+}
+
+// renderWinList renders the window list of a session as HTML document with clickable links.
+func (s *serverImpl) renderWinList(sess Session, wr http.ResponseWriter, r *http.Request) {
+	if !s.winListEnabled {
+		http.NotFound(wr, r)
+		return
+	}
+
+	if s.logger != nil {
+		s.logger.Println("\tRending windows list.")
+	}
+
+	data := WinListData{Title: s.text, Lang: clientLang(r)}
+
+	// Render both private and public session windows (which the session may access)
+	if sess.Private() {
+		for _, win := range sess.SortedWins() {
+			if !s.canAccess(sess, win) {
+				continue
+			}
+			data.PrivateWins = append(data.PrivateWins, s.newWinListItem(win, data.Lang))
+		}
+	} else {
+		// No private session yet, render session creators:
+		names := make([]string, 0, len(s.sessCreatorNames))
+		for name := range s.sessCreatorNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if text := s.sessCreatorNames[name]; len(text) > 0 {
+				data.SessCreators = append(data.SessCreators, WinListItem{Name: name, Url: s.appPath + name, Text: text})
+			}
+		}
+	}
+	for _, win := range s.SortedWins() {
+		if !s.canAccess(sess, win) {
+			continue
+		}
+		data.PublicWins = append(data.PublicWins, s.newWinListItem(win, data.Lang))
+	}
+
+	t := s.winListTemplate
+	if t == nil {
+		t = defaultWinListTemplate
+	}
+
+	wr.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := t.Execute(wr, data); err != nil && s.logger != nil {
+		s.logger.Println("\tFailed to render window list:", err)
+	}
+}
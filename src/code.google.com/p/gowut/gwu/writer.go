@@ -69,11 +69,41 @@ func init() {
 // to easier write data we need
 type writer struct {
 	io.Writer // Writer implementation
+
+	sess  Session // Session the output is rendered for, may be nil
+	nonce string  // Nonce of inline scripts (Content-Security-Policy), may be empty
 }
 
 // NewWriter returns an implementation of our writer.
 func NewWriter(w io.Writer) writer {
-	return writer{w}
+	return writer{Writer: w}
+}
+
+// newSessWriter returns an implementation of our writer
+// which renders output for the specified session.
+func newSessWriter(w io.Writer, sess Session) writer {
+	return writer{Writer: w, sess: sess}
+}
+
+// sub returns a writer which writes to the specified writer, and renders
+// output for the same session with the same nonce as w.
+func (w writer) sub(out io.Writer) writer {
+	w.Writer = out
+	return w
+}
+
+// flusher is implemented by writers which can flush buffered data
+// to their destination (like http.ResponseWriter).
+type flusher interface {
+	Flush()
+}
+
+// Flush flushes buffered data (sends it to the client)
+// if the underlying writer supports it, else it is a no-op.
+func (w writer) Flush() {
+	if f, isFlusher := w.Writer.(flusher); isFlusher {
+		f.Flush()
+	}
 }
 
 // Writev writes a value.
@@ -136,6 +166,7 @@ func (w writer) Writees(s string) (n int, err error) {
 
 // WriteAttr writes an attribute in the form of:
 // ` name="value"`
+// The value is html-escaped.
 func (w writer) WriteAttr(name, value string) (n int, err error) {
 	// Easiest implementation would be:
 	// return w.Writevs(_STR_SPACE, name, _STR_EQ_QUOTE, value, _STR_QUOTE)
@@ -161,7 +192,7 @@ func (w writer) WriteAttr(name, value string) (n int, err error) {
 		return
 	}
 
-	m, err = w.Write([]byte(value))
+	m, err = w.Write([]byte(html.EscapeString(value)))
 	n += m
 	if err != nil {
 		return
@@ -0,0 +1,45 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package gwu
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteAttr(t *testing.T) {
+	cases := []struct{ name, value, exp string }{
+		{"title", "plain", ` title="plain"`},
+		{"title", `x" onmouseover="alert(1)`, ` title="x&#34; onmouseover=&#34;alert(1)"`},
+		{"title", "<script>&'", ` title="&lt;script&gt;&amp;&#39;"`},
+	}
+
+	for _, c := range cases {
+		b := &bytes.Buffer{}
+		NewWriter(b).WriteAttr(c.name, c.value)
+		if got := b.String(); got != c.exp {
+			t.Errorf("WriteAttr(%q, %q): expected: %q, got: %q", c.name, c.value, c.exp, got)
+		}
+	}
+}
+
+func TestWritees(t *testing.T) {
+	b := &bytes.Buffer{}
+	NewWriter(b).Writees(`<a href="x">&</a>`)
+	if exp, got := "&lt;a href=&#34;x&#34;&gt;&amp;&lt;/a&gt;", b.String(); got != exp {
+		t.Errorf("Expected: %q, got: %q", exp, got)
+	}
+}
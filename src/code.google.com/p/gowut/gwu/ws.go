@@ -0,0 +1,467 @@
+// Copyright (C) 2013 Andras Belicza. All rights reserved.
+// 
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+// 
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// WebSocket transport: a persistent channel for sending events
+// and receiving event responses and pushes (RFC 6455).
+
+package gwu
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GUID used in the WebSocket opening handshake.
+const _WS_GUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes.
+const (
+	_WS_OP_CONT  = 0x0 // Continuation frame
+	_WS_OP_TEXT  = 0x1 // Text frame
+	_WS_OP_BIN   = 0x2 // Binary frame
+	_WS_OP_CLOSE = 0x8 // Connection close
+	_WS_OP_PING  = 0x9 // Ping
+	_WS_OP_PONG  = 0xA // Pong
+)
+
+// Max size of a message accepted from clients.
+const _WS_MAX_MSG_SIZE = 1 << 20
+
+// Max age of a session cookie token.
+const _WS_SESS_TOKEN_TIMEOUT = time.Minute
+
+var errWsMsgTooBig = errors.New("WebSocket message too big!")
+
+// wsConn is a server side WebSocket connection.
+type wsConn struct {
+	conn    net.Conn      // The underlying network connection
+	br      *bufio.Reader // Buffered reader of the connection
+	wMutex  sync.Mutex    // Mutex to synchronize writes
+	closed  chan struct{} // Channel which is closed when the connection is closed
+	closing sync.Once     // To close the closed channel only once
+}
+
+// wsUpgrade performs the WebSocket opening handshake.
+// An error response is sent and nil is returned if the request
+// is not a valid WebSocket handshake request.
+func wsUpgrade(w http.ResponseWriter, r *http.Request) *wsConn {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		http.Error(w, "Not a WebSocket handshake!", http.StatusBadRequest)
+		return nil
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if len(key) == 0 {
+		http.Error(w, "Missing WebSocket key!", http.StatusBadRequest)
+		return nil
+	}
+	// Browsers send cookies with cross-site WebSocket requests too: only accept same origin
+	if origin := r.Header.Get("Origin"); len(origin) > 0 {
+		if u, err := url.Parse(origin); err != nil || !strings.EqualFold(u.Host, r.Host) {
+			http.Error(w, "Cross origin WebSocket request!", http.StatusForbidden)
+			return nil
+		}
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported!", http.StatusInternalServerError)
+		return nil
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil
+	}
+
+	h := sha1.New()
+	io.WriteString(h, key+_WS_GUID)
+	accept := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	rw.WriteString(accept)
+	rw.WriteString("\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil
+	}
+
+	return &wsConn{conn: conn, br: rw.Reader, closed: make(chan struct{})}
+}
+
+// headerContains tells if the specified comma separated header
+// contains the specified token (case-insensitive).
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readFrame reads a frame from the client.
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0f
+	if head[1]&0x80 == 0 {
+		// Client frames must be masked
+		return fin, opcode, nil, errors.New("Unmasked WebSocket frame!")
+	}
+
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > _WS_MAX_MSG_SIZE {
+		return fin, opcode, nil, errWsMsgTooBig
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i&3]
+	}
+	return
+}
+
+// readMessage reads the next (text or binary) message from the client.
+// Control frames are handled.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var msg []byte
+	started := false
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case _WS_OP_PING:
+			if err := c.writeFrame(_WS_OP_PONG, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case _WS_OP_PONG:
+			continue
+		case _WS_OP_CLOSE:
+			c.writeFrame(_WS_OP_CLOSE, payload)
+			return nil, io.EOF
+		case _WS_OP_TEXT, _WS_OP_BIN:
+			if started {
+				return nil, errors.New("Unexpected WebSocket data frame!")
+			}
+			started = true
+		case _WS_OP_CONT:
+			if !started {
+				return nil, errors.New("Unexpected WebSocket continuation frame!")
+			}
+		default:
+			return nil, errors.New("Unknown WebSocket opcode!")
+		}
+
+		if len(msg)+len(payload) > _WS_MAX_MSG_SIZE {
+			return nil, errWsMsgTooBig
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// writeFrame writes a (final, unmasked) frame to the client.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.wMutex.Lock()
+	defer c.wMutex.Unlock()
+
+	head := make([]byte, 2, 10)
+	head[0] = 0x80 | opcode
+	switch n := len(payload); {
+	case n < 126:
+		head[1] = byte(n)
+	case n <= 0xffff:
+		head[1] = 126
+		head = append(head, 0, 0)
+		binary.BigEndian.PutUint16(head[2:], uint16(n))
+	default:
+		head[1] = 127
+		head = append(head, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(head[2:], uint64(n))
+	}
+
+	if _, err := c.conn.Write(head); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// close closes the connection.
+func (c *wsConn) close() {
+	c.closing.Do(func() {
+		close(c.closed)
+		c.conn.Close()
+	})
+}
+
+// wsResponseWriter is an http.ResponseWriter which collects the response
+// of an event received over WebSocket.
+type wsResponseWriter struct {
+	header http.Header  // Response headers
+	status int          // Response status code
+	buf    bytes.Buffer // Response body
+}
+
+func (w *wsResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *wsResponseWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *wsResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// newSessCookie returns the id of the session whose cookie was set
+// to the response, empty string if no session cookie was set.
+func (w *wsResponseWriter) newSessCookie() string {
+	for _, c := range (&http.Response{Header: w.header}).Cookies() {
+		if c.Name == _GWU_SESSID_COOKIE {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+func (s *serverImpl) WsEnabled() bool {
+	return s.wsEnabled
+}
+
+func (s *serverImpl) SetWsEnabled(enabled bool) {
+	s.wsEnabled = enabled
+}
+
+// sessToken is a one-time token to claim the cookie of a session
+// created over WebSocket (cookies can't be set over WebSocket).
+type sessToken struct {
+	sessId  string    // Id of the session
+	created time.Time // Creation time of the token
+}
+
+// newSessToken creates a new session cookie token for the specified session.
+func (s *serverImpl) newSessToken(sessId string) string {
+	s.sessTokensMutex.Lock()
+	defer s.sessTokensMutex.Unlock()
+
+	token := genId()
+	s.sessTokens[token] = sessToken{sessId: sessId, created: time.Now()}
+	return token
+}
+
+// handleSessCookie handles a session cookie claim request:
+// sets the cookie of the session the token was issued for.
+func (s *serverImpl) handleSessCookie(w http.ResponseWriter, r *http.Request) {
+	s.sessTokensMutex.Lock()
+	now := time.Now()
+	for token, st := range s.sessTokens {
+		if now.Sub(st.created) > _WS_SESS_TOKEN_TIMEOUT {
+			delete(s.sessTokens, token)
+		}
+	}
+	st, found := s.sessTokens[r.FormValue(_PARAM_TOKEN)]
+	delete(s.sessTokens, r.FormValue(_PARAM_TOKEN))
+	s.sessTokensMutex.Unlock()
+
+	var sess Session
+	if found {
+		sess = s.sessionById(st.sessId)
+	}
+	if sess == nil {
+		http.Error(w, "Invalid token!", http.StatusBadRequest)
+		return
+	}
+
+	s.addSessCookie(sess, w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleWs handles a WebSocket connection of a window.
+// Events are received as messages (in the same form as event requests),
+// event responses and pushes are sent as messages.
+func (s *serverImpl) handleWs(sess Session, win Window, w http.ResponseWriter, r *http.Request) {
+	c := wsUpgrade(w, r)
+	if c == nil {
+		return
+	}
+	defer c.close()
+
+	if s.logger != nil {
+		s.logger.Println("WebSocket opened:", r.URL.Path)
+	}
+
+	// Continue pushes from the last one the client received
+	// (queued before the connection was opened).
+	seq, err := strconv.Atoi(r.FormValue(_PARAM_PUSH_SEQ))
+	if err != nil {
+		seq = win.pushQueue().curSeq()
+	}
+	stopPusher := make(chan struct{})
+	go s.wsPusher(c, win, seq, stopPusher)
+	defer func() { close(stopPusher) }()
+
+	clientAddr := s.ClientAddr(r)
+	for {
+		msg, err := c.readMessage()
+		if err != nil {
+			if err != io.EOF && s.logger != nil {
+				s.logger.Println("WebSocket error:", err)
+			}
+			break
+		}
+
+		// The session might have been removed (e.g. timed out or logged out)
+		if sess.Private() && s.sessionById(sess.Id()) != sess {
+			break
+		}
+		// Access might have been revoked (e.g. the user logged out).
+		// Closing the connection makes the client fall back to HTTP requests
+		// which are redirected to the login window or denied.
+		if !s.canAccess(sess, win) {
+			break
+		}
+		sess.access(clientAddr)
+
+		form, err := url.ParseQuery(string(msg))
+		if err != nil {
+			break
+		}
+		// Pushes are delivered by the pusher only, else they would be sent twice
+		form.Del(_PARAM_PUSH_SEQ)
+		// Synthetic request carrying the event parameters
+		er := new(http.Request)
+		*er = *r
+		er.Method = "POST"
+		er.Form, er.PostForm = form, form
+
+		rw := &wsResponseWriter{header: make(http.Header), status: http.StatusOK}
+		rwMutex := sess.rwMutex()
+		rwMutex.Lock()
+		s.handleEvent(sess, win, rw, er)
+		rwMutex.Unlock()
+
+		if rw.status != http.StatusOK {
+			continue // Just like XHR responses, error responses are ignored by the client
+		}
+
+		resp := rw.buf.Bytes()
+		if sessId := rw.newSessCookie(); len(sessId) > 0 {
+			// Session created by the event: continue with the new session,
+			// and let the client claim the session cookie.
+			if newSess := s.sessionById(sessId); newSess != nil {
+				sess = newSess
+				if newWin := sess.WinByName(win.Name()); newWin != nil && newWin != win {
+					// Pushes of the new window are to be sent from now on
+					win = newWin
+					close(stopPusher)
+					stopPusher = make(chan struct{})
+					go s.wsPusher(c, win, win.pushQueue().curSeq(), stopPusher)
+				}
+			}
+			var b bytes.Buffer
+			NewWriter(&b).Writevs(_ERA_SESS_COOKIE, _STR_COMMA, s.newSessToken(sessId), _STR_SEMICOL)
+			b.Write(resp)
+			resp = b.Bytes()
+		}
+
+		if err := c.writeFrame(_WS_OP_TEXT, resp); err != nil {
+			break
+		}
+	}
+
+	if s.logger != nil {
+		s.logger.Println("WebSocket closed:", r.URL.Path)
+	}
+}
+
+// wsPusher sends the pushes of the specified window newer than
+// the specified sequence number to the WebSocket connection
+// until the connection is closed or stop is closed.
+// This method is to start as a new go routine.
+func (s *serverImpl) wsPusher(c *wsConn, win Window, seq int, stop <-chan struct{}) {
+	for {
+		_, _, _, _, changed := win.pushQueue().since(seq)
+		if changed != nil {
+			select {
+			case <-changed:
+				continue
+			case <-c.closed:
+				return
+			case <-stop:
+				return
+			case <-s.stopping:
+				var b bytes.Buffer
+				s.writeShutdown(NewWriter(&b))
+				c.writeFrame(_WS_OP_TEXT, b.Bytes())
+				return
+			}
+		}
+
+		var b bytes.Buffer
+		// Continue from the sequence actually sent, pushes might have been
+		// added since the check above.
+		_, seq = writePushes(NewWriter(&b), win, seq, false)
+		if err := c.writeFrame(_WS_OP_TEXT, b.Bytes()); err != nil {
+			c.close()
+			return
+		}
+	}
+}